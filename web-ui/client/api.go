@@ -0,0 +1,873 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"web-ui/internal/audit"
+	"web-ui/internal/events"
+	"web-ui/internal/handlers"
+	"web-ui/internal/models"
+	"web-ui/internal/services"
+	"web-ui/internal/webhook"
+)
+
+// The API types are shared with the server so the client cannot drift from it
+type (
+	Challenge        = models.Challenge
+	ChallengeItem    = handlers.ChallengeListItem
+	FacetCount       = services.FacetCount
+	LearningPath     = models.LearningPath
+	NextChallenge    = handlers.NextChallengeResponse
+	UserProfile      = services.UserProfile
+	Activity         = services.ActivitySummary
+	ScoreboardEntry  = models.ScoreboardEntry
+	Submission       = models.Submission
+	ExecutionResult  = services.ExecutionResult
+	SaveResponse     = services.SaveSubmissionResponse
+	LeaderboardUser  = handlers.LeaderboardUser
+	Team             = models.Team
+	TeamSummary      = handlers.TeamSummary
+	TeamDetail       = handlers.TeamDetail
+	Contest          = models.Contest
+	ContestSummary   = handlers.ContestSummary
+	ContestRequest   = handlers.ContestRequest
+	ContestBoard     = services.ContestScoreboard
+	Interview        = handlers.InterviewSummary
+	InterviewRequest = handlers.InterviewRequest
+	InterviewInvite  = handlers.InterviewInvite
+	InterviewReport  = services.InterviewReport
+	InterviewExport  = services.InterviewExport
+	PairRoom         = handlers.PairRoom
+	Draft            = services.DraftSummary
+	DraftVersion     = models.DraftVersion
+	DraftVersionInfo = handlers.DraftVersionSummary
+	DraftDiff        = services.DraftDiff
+	SolutionGallery  = services.SolutionGallery
+	Solution         = services.Solution
+	SolutionDiff     = services.SolutionComparison
+	BenchmarkRun     = models.BenchmarkRun
+	Review           = services.ReviewDetail
+	ReviewSummary    = services.ReviewSummary
+	ReviewThread     = services.ReviewThreadDetail
+	ReviewAnchor     = models.ReviewAnchor
+	Notifications    = services.NotificationFeed
+	Attempts         = handlers.AttemptsResponse
+	GitIdentity      = handlers.GitIdentityResponse
+	AuditEvent       = audit.Event
+	Similarity       = services.SimilarityReport
+	Webhook          = webhook.Info
+	WebhookDelivery  = webhook.Delivery
+	Event            = events.Event
+)
+
+// Session is the signed-in state reported by /auth/me
+type Session struct {
+	Authenticated bool     `json:"authenticated"`
+	Username      string   `json:"username,omitempty"`
+	Provider      string   `json:"provider,omitempty"`
+	Providers     []string `json:"providers"`
+	CSRFToken     string   `json:"csrfToken"`
+}
+
+// ChallengeSearch filters and orders challenges; zero values match everything
+type ChallengeSearch struct {
+	Text         string
+	Difficulties []string
+	Tags         []string
+	Topics       []string
+	Status       string   // "solved" or "unsolved" by the signed-in user
+	Sort         string   // id, title, difficulty or relevance; "-" prefix reverses
+	Fields       []string // JSON fields to return, all when empty
+}
+
+// AuditQuery filters audit events; zero values match everything
+type AuditQuery struct {
+	Actor       string
+	Action      string
+	Outcome     string
+	ChallengeID int
+	Since       time.Time
+	Until       time.Time
+	Before      int64 // only events with a lower Seq, for the next page
+	Limit       int   // at most audit.MaxLimit; the server defaults to audit.DefaultLimit
+}
+
+// WebhookDeliveryQuery filters webhook delivery attempts; zero values match
+// everything
+type WebhookDeliveryQuery struct {
+	Subscription string
+	EventType    string
+	Outcome      string
+	Limit        int // at most webhook.MaxLimit; the server defaults to webhook.DefaultLimit
+}
+
+// Login signs in with a local password account; later calls act as that user
+func (c *Client) Login(ctx context.Context, username, password string) error {
+	credentials := map[string]string{"username": username, "password": password}
+	return c.do(ctx, http.MethodPost, "/auth/login", nil, credentials, nil)
+}
+
+// Logout ends the session
+func (c *Client) Logout(ctx context.Context) error {
+	return c.do(ctx, http.MethodPost, "/auth/logout", nil, nil, nil)
+}
+
+// Me returns the current session
+func (c *Client) Me(ctx context.Context) (*Session, error) {
+	var session Session
+	if err := c.do(ctx, http.MethodGet, "/auth/me", nil, nil, &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// ListChallenges returns all challenges ordered by ID
+func (c *Client) ListChallenges(ctx context.Context) ([]Challenge, error) {
+	var challenges []Challenge
+	err := c.do(ctx, http.MethodGet, "/api/v1/challenges", nil, nil, &challenges)
+	return challenges, err
+}
+
+// SearchChallenges returns the challenges matching q, limited to q.Fields
+func (c *Client) SearchChallenges(ctx context.Context, q ChallengeSearch) ([]ChallengeItem, error) {
+	var items []ChallengeItem
+	err := c.do(ctx, http.MethodGet, "/api/v1/challenges", q.values(), nil, &items)
+	return items, err
+}
+
+// Tags returns every challenge tag with its challenge count
+func (c *Client) Tags(ctx context.Context) ([]FacetCount, error) {
+	var tags []FacetCount
+	err := c.do(ctx, http.MethodGet, "/api/v1/tags", nil, nil, &tags)
+	return tags, err
+}
+
+// Topics returns every challenge topic with its challenge count
+func (c *Client) Topics(ctx context.Context) ([]FacetCount, error) {
+	var topics []FacetCount
+	err := c.do(ctx, http.MethodGet, "/api/v1/topics", nil, nil, &topics)
+	return topics, err
+}
+
+// LearningPaths returns every learning path ordered by name
+func (c *Client) LearningPaths(ctx context.Context) ([]LearningPath, error) {
+	var paths []LearningPath
+	err := c.do(ctx, http.MethodGet, "/api/v1/paths", nil, nil, &paths)
+	return paths, err
+}
+
+// LearningPath returns one learning path; IsNotFound reports a missing one
+func (c *Client) LearningPath(ctx context.Context, slug string) (*LearningPath, error) {
+	var path LearningPath
+	if err := c.do(ctx, http.MethodGet, "/api/v1/paths/"+url.PathEscape(slug), nil, nil, &path); err != nil {
+		return nil, err
+	}
+	return &path, nil
+}
+
+// NextChallenge recommends the challenge a user should attempt next,
+// following the learning path with the given slug when it is not empty
+func (c *Client) NextChallenge(ctx context.Context, username, pathSlug string) (*NextChallenge, error) {
+	var query url.Values
+	if pathSlug != "" {
+		query = url.Values{"path": {pathSlug}}
+	}
+	var next NextChallenge
+	if err := c.do(ctx, http.MethodGet, "/api/v1/users/"+url.PathEscape(username)+"/next", query, nil, &next); err != nil {
+		return nil, err
+	}
+	return &next, nil
+}
+
+// UserProfile returns a user's solved challenges, rank, streaks and recent activity
+func (c *Client) UserProfile(ctx context.Context, username string) (*UserProfile, error) {
+	var profile UserProfile
+	if err := c.do(ctx, http.MethodGet, "/api/v1/users/"+url.PathEscape(username), nil, nil, &profile); err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// UserActivity returns a user's streaks and activity per day for the given
+// number of days ending today; days <= 0 uses the server's default
+func (c *Client) UserActivity(ctx context.Context, username string, days int) (*Activity, error) {
+	var activity Activity
+	if err := c.do(ctx, http.MethodGet, "/api/v1/users/"+url.PathEscape(username)+"/activity", activityQuery(days), nil, &activity); err != nil {
+		return nil, err
+	}
+	return &activity, nil
+}
+
+// TeamActivity returns the whole team's streaks and activity per day
+func (c *Client) TeamActivity(ctx context.Context, days int) (*Activity, error) {
+	var activity Activity
+	if err := c.do(ctx, http.MethodGet, "/api/v1/activity", activityQuery(days), nil, &activity); err != nil {
+		return nil, err
+	}
+	return &activity, nil
+}
+
+func activityQuery(days int) url.Values {
+	if days <= 0 {
+		return nil
+	}
+	return url.Values{"days": {strconv.Itoa(days)}}
+}
+
+// GetChallenge returns one challenge; IsNotFound reports a missing one
+func (c *Client) GetChallenge(ctx context.Context, id int) (*Challenge, error) {
+	var challenge Challenge
+	if err := c.do(ctx, http.MethodGet, "/api/v1/challenges/"+strconv.Itoa(id), nil, nil, &challenge); err != nil {
+		return nil, err
+	}
+	return &challenge, nil
+}
+
+// Scoreboard returns a challenge's scoreboard
+func (c *Client) Scoreboard(ctx context.Context, challengeID int) ([]ScoreboardEntry, error) {
+	var entries []ScoreboardEntry
+	err := c.do(ctx, http.MethodGet, "/api/v1/challenges/"+strconv.Itoa(challengeID)+"/scoreboard", nil, nil, &entries)
+	return entries, err
+}
+
+// Run runs code against a challenge's tests without recording a submission
+func (c *Client) Run(ctx context.Context, challengeID int, code string) (*ExecutionResult, error) {
+	var result ExecutionResult
+	request := handlers.RunRequest{ChallengeID: challengeID, Code: code}
+	if err := c.do(ctx, http.MethodPost, "/api/v1/runs", nil, request, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Submit submits a solution as the signed-in user; passing submissions are
+// added to the challenge scoreboard
+func (c *Client) Submit(ctx context.Context, challengeID int, code string) (*Submission, error) {
+	var submission Submission
+	request := handlers.SubmissionRequest{ChallengeID: challengeID, Code: code}
+	if err := c.do(ctx, http.MethodPost, "/api/v1/submissions", nil, request, &submission); err != nil {
+		return nil, err
+	}
+	return &submission, nil
+}
+
+// SubmitToContest submits a solution as the signed-in user and enters it in
+// a running contest; a contest that is not running is a 409
+func (c *Client) SubmitToContest(ctx context.Context, contest string, challengeID int, code string) (*Submission, error) {
+	var submission Submission
+	request := handlers.SubmissionRequest{ChallengeID: challengeID, Code: code, Contest: contest}
+	if err := c.do(ctx, http.MethodPost, "/api/v1/submissions", nil, request, &submission); err != nil {
+		return nil, err
+	}
+	return &submission, nil
+}
+
+// Submissions returns the signed-in user's submissions, newest first
+func (c *Client) Submissions(ctx context.Context) ([]Submission, error) {
+	var submissions []Submission
+	err := c.do(ctx, http.MethodGet, "/api/v1/submissions", nil, nil, &submissions)
+	return submissions, err
+}
+
+// SaveToFilesystem writes the signed-in user's solution into the server's
+// repository checkout
+func (c *Client) SaveToFilesystem(ctx context.Context, challengeID int, code string) (*SaveResponse, error) {
+	var response SaveResponse
+	request := handlers.SaveSolutionRequest{Code: code}
+	if err := c.do(ctx, http.MethodPut, "/api/v1/challenges/"+strconv.Itoa(challengeID)+"/solution", nil, request, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// RefreshAttempts rescans a user's attempted challenges
+func (c *Client) RefreshAttempts(ctx context.Context, username string) (*Attempts, error) {
+	var attempts Attempts
+	if err := c.do(ctx, http.MethodPost, "/api/v1/users/"+url.PathEscape(username)+"/attempts/refresh", nil, nil, &attempts); err != nil {
+		return nil, err
+	}
+	return &attempts, nil
+}
+
+// Leaderboard returns the main leaderboard, best first
+func (c *Client) Leaderboard(ctx context.Context) ([]LeaderboardUser, error) {
+	var leaderboard []LeaderboardUser
+	err := c.do(ctx, http.MethodGet, "/api/v1/leaderboard", nil, nil, &leaderboard)
+	return leaderboard, err
+}
+
+// Rank returns a user's position on the main leaderboard, 0 when unranked
+func (c *Client) Rank(ctx context.Context, username string) (int, error) {
+	var response handlers.RankResponse
+	if err := c.do(ctx, http.MethodGet, "/api/v1/leaderboard/"+url.PathEscape(username), nil, nil, &response); err != nil {
+		return 0, err
+	}
+	return response.Rank, nil
+}
+
+// Teams returns every team with its aggregates, best average score first
+func (c *Client) Teams(ctx context.Context) ([]TeamSummary, error) {
+	var teams []TeamSummary
+	err := c.do(ctx, http.MethodGet, "/api/v1/teams", nil, nil, &teams)
+	return teams, err
+}
+
+// Team returns a team's aggregates and its members' standings; IsNotFound
+// reports a missing one
+func (c *Client) Team(ctx context.Context, slug string) (*TeamDetail, error) {
+	var team TeamDetail
+	if err := c.do(ctx, http.MethodGet, "/api/v1/teams/"+url.PathEscape(slug), nil, nil, &team); err != nil {
+		return nil, err
+	}
+	return &team, nil
+}
+
+// SaveTeam creates or replaces a team named name. The signed-in user must
+// be an administrator; teams defined in the repository cannot be changed.
+func (c *Client) SaveTeam(ctx context.Context, name string, members []string) (*Team, error) {
+	var team Team
+	request := handlers.TeamRequest{Name: name, Members: members}
+	if err := c.do(ctx, http.MethodPut, "/api/v1/admin/teams/"+url.PathEscape(services.TeamSlug(name)), nil, request, &team); err != nil {
+		return nil, err
+	}
+	return &team, nil
+}
+
+// DeleteTeam deletes a team created through the API. The signed-in user
+// must be an administrator.
+func (c *Client) DeleteTeam(ctx context.Context, slug string) error {
+	return c.do(ctx, http.MethodDelete, "/api/v1/admin/teams/"+url.PathEscape(slug), nil, nil, nil)
+}
+
+// Contests returns every contest, most recent start first
+func (c *Client) Contests(ctx context.Context) ([]ContestSummary, error) {
+	var contests []ContestSummary
+	err := c.do(ctx, http.MethodGet, "/api/v1/contests", nil, nil, &contests)
+	return contests, err
+}
+
+// Contest returns a contest; IsNotFound reports a missing one
+func (c *Client) Contest(ctx context.Context, slug string) (*ContestSummary, error) {
+	var contest ContestSummary
+	if err := c.do(ctx, http.MethodGet, "/api/v1/contests/"+url.PathEscape(slug), nil, nil, &contest); err != nil {
+		return nil, err
+	}
+	return &contest, nil
+}
+
+// ContestScoreboard returns a contest's public scoreboard, which hides the
+// results of submissions made during the freeze
+func (c *Client) ContestScoreboard(ctx context.Context, slug string) (*ContestBoard, error) {
+	var board ContestBoard
+	if err := c.do(ctx, http.MethodGet, "/api/v1/contests/"+url.PathEscape(slug)+"/scoreboard", nil, nil, &board); err != nil {
+		return nil, err
+	}
+	return &board, nil
+}
+
+// LiveContestScoreboard returns a contest's scoreboard including the results
+// hidden by the freeze. The signed-in user must be an administrator.
+func (c *Client) LiveContestScoreboard(ctx context.Context, slug string) (*ContestBoard, error) {
+	var board ContestBoard
+	if err := c.do(ctx, http.MethodGet, "/api/v1/admin/contests/"+url.PathEscape(slug)+"/scoreboard", nil, nil, &board); err != nil {
+		return nil, err
+	}
+	return &board, nil
+}
+
+// SaveContest creates or replaces the contest named request.Name. The
+// signed-in user must be an administrator.
+func (c *Client) SaveContest(ctx context.Context, request ContestRequest) (*Contest, error) {
+	var contest Contest
+	if err := c.do(ctx, http.MethodPut, "/api/v1/admin/contests/"+url.PathEscape(services.ContestSlug(request.Name)), nil, request, &contest); err != nil {
+		return nil, err
+	}
+	return &contest, nil
+}
+
+// DeleteContest deletes a contest. The signed-in user must be an
+// administrator.
+func (c *Client) DeleteContest(ctx context.Context, slug string) error {
+	return c.do(ctx, http.MethodDelete, "/api/v1/admin/contests/"+url.PathEscape(slug), nil, nil, nil)
+}
+
+// CreateInterview schedules a mock interview for the signed-in user. The
+// invite token in the result is not shown again.
+func (c *Client) CreateInterview(ctx context.Context, request InterviewRequest) (*InterviewInvite, error) {
+	var invite InterviewInvite
+	if err := c.do(ctx, http.MethodPost, "/api/v1/interviews", nil, request, &invite); err != nil {
+		return nil, err
+	}
+	return &invite, nil
+}
+
+// Interviews returns the interviews the signed-in user created, newest first
+func (c *Client) Interviews(ctx context.Context) ([]Interview, error) {
+	var interviews []Interview
+	err := c.do(ctx, http.MethodGet, "/api/v1/interviews", nil, nil, &interviews)
+	return interviews, err
+}
+
+// Interview returns one of the signed-in user's interviews; IsNotFound
+// reports a missing one or someone else's
+func (c *Client) Interview(ctx context.Context, id string) (*Interview, error) {
+	var interview Interview
+	if err := c.do(ctx, http.MethodGet, "/api/v1/interviews/"+url.PathEscape(id), nil, nil, &interview); err != nil {
+		return nil, err
+	}
+	return &interview, nil
+}
+
+// InterviewReport returns an interview's per-challenge summary and timeline
+func (c *Client) InterviewReport(ctx context.Context, id string) (*InterviewReport, error) {
+	var report InterviewReport
+	if err := c.do(ctx, http.MethodGet, "/api/v1/interviews/"+url.PathEscape(id)+"/report", nil, nil, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// ExportInterview returns an interview with its full recording
+func (c *Client) ExportInterview(ctx context.Context, id string) (*InterviewExport, error) {
+	var export InterviewExport
+	if err := c.do(ctx, http.MethodGet, "/api/v1/interviews/"+url.PathEscape(id)+"/export", nil, nil, &export); err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+// EndInterview ends an interview early, or revokes its invite if the
+// candidate has not joined
+func (c *Client) EndInterview(ctx context.Context, id string) (*Interview, error) {
+	var interview Interview
+	if err := c.do(ctx, http.MethodPost, "/api/v1/interviews/"+url.PathEscape(id)+"/end", nil, nil, &interview); err != nil {
+		return nil, err
+	}
+	return &interview, nil
+}
+
+// JoinInterview redeems an invite token as the candidate, starting their
+// clock. The client's cookie jar keeps the admission for later calls.
+func (c *Client) JoinInterview(ctx context.Context, token string) (*Interview, error) {
+	var interview Interview
+	request := handlers.JoinInterviewRequest{Token: token}
+	if err := c.do(ctx, http.MethodPost, "/api/v1/interviews/join", nil, request, &interview); err != nil {
+		return nil, err
+	}
+	return &interview, nil
+}
+
+// SaveEditorSnapshot records the candidate's editor contents for a challenge
+func (c *Client) SaveEditorSnapshot(ctx context.Context, id string, challengeID int, code string) (*Interview, error) {
+	var interview Interview
+	request := handlers.EditorSnapshotRequest{ChallengeID: challengeID, Code: code}
+	if err := c.do(ctx, http.MethodPut, "/api/v1/interviews/"+url.PathEscape(id)+"/editor", nil, request, &interview); err != nil {
+		return nil, err
+	}
+	return &interview, nil
+}
+
+// RunInInterview runs the candidate's code and records the run in the
+// interview; an interview that is not in progress is a 409
+func (c *Client) RunInInterview(ctx context.Context, id string, challengeID int, code string) (*ExecutionResult, error) {
+	var result ExecutionResult
+	request := handlers.RunRequest{ChallengeID: challengeID, Code: code, Interview: id}
+	if err := c.do(ctx, http.MethodPost, "/api/v1/runs", nil, request, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// CreatePairRoom opens a pair-programming room on a challenge with code as
+// its document, or the challenge template when code is empty
+func (c *Client) CreatePairRoom(ctx context.Context, challengeID int, code string) (*PairRoom, error) {
+	var room PairRoom
+	request := handlers.PairRoomRequest{ChallengeID: challengeID, Code: code}
+	if err := c.do(ctx, http.MethodPost, "/api/v1/pair-rooms", nil, request, &room); err != nil {
+		return nil, err
+	}
+	return &room, nil
+}
+
+// PairRoom returns a pair-programming room's participants and revision;
+// IsNotFound reports an unknown or expired room
+func (c *Client) PairRoom(ctx context.Context, id string) (*PairRoom, error) {
+	var room PairRoom
+	if err := c.do(ctx, http.MethodGet, "/api/v1/pair-rooms/"+url.PathEscape(id), nil, nil, &room); err != nil {
+		return nil, err
+	}
+	return &room, nil
+}
+
+// PairSocketURL returns the WebSocket URL that syncs a pair-programming
+// room, for use with a WebSocket library
+func (c *Client) PairSocketURL(id string) string {
+	u := *c.baseURL
+	u.Scheme = strings.Replace(u.Scheme, "http", "ws", 1)
+	u.Path += "/ws/pair/" + id
+	u.RawPath = ""
+	return u.String()
+}
+
+// Drafts lists the challenges the signed-in user has drafts for
+func (c *Client) Drafts(ctx context.Context) ([]Draft, error) {
+	var drafts []Draft
+	if err := c.do(ctx, http.MethodGet, "/api/v1/drafts", nil, nil, &drafts); err != nil {
+		return nil, err
+	}
+	return drafts, nil
+}
+
+// DraftVersions lists the signed-in user's draft versions of a challenge,
+// newest first and without their code
+func (c *Client) DraftVersions(ctx context.Context, challengeID int) ([]DraftVersionInfo, error) {
+	var versions []DraftVersionInfo
+	if err := c.do(ctx, http.MethodGet, "/api/v1/drafts/"+strconv.Itoa(challengeID), nil, nil, &versions); err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// SaveDraft saves code as the latest draft version of a challenge. Autosaves
+// are folded into the latest version when that is a recent unlabeled
+// autosave.
+func (c *Client) SaveDraft(ctx context.Context, challengeID int, code, label string, autosave bool) (*DraftVersion, error) {
+	var version DraftVersion
+	request := handlers.DraftRequest{Code: code, Label: label, Autosave: autosave}
+	if err := c.do(ctx, http.MethodPut, "/api/v1/drafts/"+strconv.Itoa(challengeID), nil, request, &version); err != nil {
+		return nil, err
+	}
+	return &version, nil
+}
+
+// DraftVersion returns a draft version with its code
+func (c *Client) DraftVersion(ctx context.Context, challengeID, version int) (*DraftVersion, error) {
+	var v DraftVersion
+	if err := c.do(ctx, http.MethodGet, draftVersionPath(challengeID, version), nil, nil, &v); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// DiffDrafts compares two draft versions of a challenge
+func (c *Client) DiffDrafts(ctx context.Context, challengeID, from, to int) (*DraftDiff, error) {
+	var d DraftDiff
+	query := url.Values{"from": {strconv.Itoa(from)}, "to": {strconv.Itoa(to)}}
+	if err := c.do(ctx, http.MethodGet, "/api/v1/drafts/"+strconv.Itoa(challengeID)+"/diff", query, nil, &d); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// RestoreDraft copies a draft version into a new latest version
+func (c *Client) RestoreDraft(ctx context.Context, challengeID, version int) (*DraftVersion, error) {
+	var v DraftVersion
+	if err := c.do(ctx, http.MethodPost, draftVersionPath(challengeID, version)+"/restore", nil, nil, &v); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+func draftVersionPath(challengeID, version int) string {
+	return "/api/v1/drafts/" + strconv.Itoa(challengeID) + "/versions/" + strconv.Itoa(version)
+}
+
+// Solutions lists the solutions submitted for a challenge. The signed-in
+// user must have solved it.
+func (c *Client) Solutions(ctx context.Context, challengeID int) (*SolutionGallery, error) {
+	var gallery SolutionGallery
+	if err := c.do(ctx, http.MethodGet, "/api/v1/challenges/"+strconv.Itoa(challengeID)+"/solutions", nil, nil, &gallery); err != nil {
+		return nil, err
+	}
+	return &gallery, nil
+}
+
+// Solution returns a user's solution to a challenge
+func (c *Client) Solution(ctx context.Context, challengeID int, username string) (*Solution, error) {
+	var solution Solution
+	if err := c.do(ctx, http.MethodGet, solutionPath(challengeID, username), nil, nil, &solution); err != nil {
+		return nil, err
+	}
+	return &solution, nil
+}
+
+// CompareSolutions diffs two users' solutions to a challenge declaration by
+// declaration
+func (c *Client) CompareSolutions(ctx context.Context, challengeID int, left, right string) (*SolutionDiff, error) {
+	var comparison SolutionDiff
+	if err := c.do(ctx, http.MethodGet, solutionPath(challengeID, left)+"/compare/"+url.PathEscape(right), nil, nil, &comparison); err != nil {
+		return nil, err
+	}
+	return &comparison, nil
+}
+
+// BenchmarkSolution runs a challenge's benchmarks against a user's solution
+func (c *Client) BenchmarkSolution(ctx context.Context, challengeID int, username string) (*BenchmarkRun, error) {
+	var run BenchmarkRun
+	if err := c.do(ctx, http.MethodPost, solutionPath(challengeID, username)+"/benchmarks", nil, nil, &run); err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+func solutionPath(challengeID int, username string) string {
+	return "/api/v1/challenges/" + strconv.Itoa(challengeID) + "/solutions/" + url.PathEscape(username)
+}
+
+// CreateReview starts reviewing a teammate's saved solution to a challenge.
+// Version 0 reviews their latest draft version.
+func (c *Client) CreateReview(ctx context.Context, author string, challengeID, version int, summary string) (*Review, error) {
+	var review Review
+	request := handlers.ReviewRequest{Author: author, ChallengeID: challengeID, Version: version, Summary: summary}
+	if err := c.do(ctx, http.MethodPost, "/api/v1/reviews", nil, request, &review); err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+// Reviews lists the reviews the signed-in user wrote or received, most
+// recently updated first. A challengeID of 0 lists every challenge's.
+func (c *Client) Reviews(ctx context.Context, challengeID int) ([]ReviewSummary, error) {
+	var query url.Values
+	if challengeID != 0 {
+		query = url.Values{"challenge": {strconv.Itoa(challengeID)}}
+	}
+	var reviews []ReviewSummary
+	if err := c.do(ctx, http.MethodGet, "/api/v1/reviews", query, nil, &reviews); err != nil {
+		return nil, err
+	}
+	return reviews, nil
+}
+
+// Review returns a review with its threads placed on the author's latest
+// version
+func (c *Client) Review(ctx context.Context, id string) (*Review, error) {
+	var review Review
+	if err := c.do(ctx, http.MethodGet, reviewPath(id), nil, nil, &review); err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+// SetVerdict sets the verdict of a review the signed-in user wrote and,
+// unless empty, replaces its summary
+func (c *Client) SetVerdict(ctx context.Context, id, verdict, summary string) (*Review, error) {
+	var review Review
+	request := handlers.VerdictRequest{Verdict: verdict, Summary: summary}
+	if err := c.do(ctx, http.MethodPut, reviewPath(id)+"/verdict", nil, request, &review); err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+// AddReviewThread starts a comment thread on a line of the reviewed solution
+func (c *Client) AddReviewThread(ctx context.Context, id string, anchor ReviewAnchor, body string) (*ReviewThread, error) {
+	var thread ReviewThread
+	request := handlers.ThreadRequest{File: anchor.File, Line: anchor.Line, Version: anchor.Version, Body: body}
+	if err := c.do(ctx, http.MethodPost, reviewPath(id)+"/threads", nil, request, &thread); err != nil {
+		return nil, err
+	}
+	return &thread, nil
+}
+
+// ReplyToThread adds a comment to a review thread
+func (c *Client) ReplyToThread(ctx context.Context, id string, threadID int, body string) (*ReviewThread, error) {
+	var thread ReviewThread
+	request := handlers.CommentRequest{Body: body}
+	if err := c.do(ctx, http.MethodPost, reviewThreadPath(id, threadID)+"/comments", nil, request, &thread); err != nil {
+		return nil, err
+	}
+	return &thread, nil
+}
+
+// ResolveThread resolves a review thread, or reopens it when resolved is
+// false
+func (c *Client) ResolveThread(ctx context.Context, id string, threadID int, resolved bool) (*ReviewThread, error) {
+	action := "/unresolve"
+	if resolved {
+		action = "/resolve"
+	}
+	var thread ReviewThread
+	if err := c.do(ctx, http.MethodPost, reviewThreadPath(id, threadID)+action, nil, nil, &thread); err != nil {
+		return nil, err
+	}
+	return &thread, nil
+}
+
+// Notifications returns the signed-in user's review notifications, newest
+// first
+func (c *Client) Notifications(ctx context.Context, unreadOnly bool) (*Notifications, error) {
+	var query url.Values
+	if unreadOnly {
+		query = url.Values{"unread": {"true"}}
+	}
+	var feed Notifications
+	if err := c.do(ctx, http.MethodGet, "/api/v1/notifications", query, nil, &feed); err != nil {
+		return nil, err
+	}
+	return &feed, nil
+}
+
+// MarkNotificationsRead marks the signed-in user's notifications up to and
+// including upTo as read, all of them for 0, and returns how many stay unread
+func (c *Client) MarkNotificationsRead(ctx context.Context, upTo int) (int, error) {
+	var response handlers.MarkReadResponse
+	if err := c.do(ctx, http.MethodPost, "/api/v1/notifications/read", nil, handlers.MarkReadRequest{UpTo: upTo}, &response); err != nil {
+		return 0, err
+	}
+	return response.Unread, nil
+}
+
+func reviewPath(id string) string {
+	return "/api/v1/reviews/" + url.PathEscape(id)
+}
+
+func reviewThreadPath(id string, threadID int) string {
+	return reviewPath(id) + "/threads/" + strconv.Itoa(threadID)
+}
+
+// GitIdentity returns the git identity configured for the server's repository
+func (c *Client) GitIdentity(ctx context.Context) (*GitIdentity, error) {
+	var identity GitIdentity
+	if err := c.do(ctx, http.MethodGet, "/api/v1/git-username", nil, nil, &identity); err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+// AuditEvents returns one page of audit events, newest first. The signed-in
+// user must be an administrator.
+func (c *Client) AuditEvents(ctx context.Context, q AuditQuery) ([]AuditEvent, error) {
+	var response handlers.AuditLogResponse
+	if err := c.do(ctx, http.MethodGet, "/api/v1/admin/audit", q.values(), nil, &response); err != nil {
+		return nil, err
+	}
+	return response.Events, nil
+}
+
+// SimilarityReport scores how similar every pair of a challenge's solutions
+// is, most similar first. A zero threshold uses the server's configured one.
+// The signed-in user must be an administrator.
+func (c *Client) SimilarityReport(ctx context.Context, challengeID int, threshold float64) (*Similarity, error) {
+	var report Similarity
+	if err := c.do(ctx, http.MethodGet, "/api/v1/admin/similarity/"+strconv.Itoa(challengeID), thresholdValues(threshold), nil, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// FlaggedSimilarity returns the challenges with pairs of solutions scoring
+// at least threshold, listing only those pairs. A zero threshold uses the
+// server's configured one. The signed-in user must be an administrator.
+func (c *Client) FlaggedSimilarity(ctx context.Context, threshold float64) ([]Similarity, error) {
+	var response handlers.FlaggedSimilarityResponse
+	if err := c.do(ctx, http.MethodGet, "/api/v1/admin/similarity", thresholdValues(threshold), nil, &response); err != nil {
+		return nil, err
+	}
+	return response.Challenges, nil
+}
+
+// Webhooks lists the configured webhook subscriptions, without their
+// secrets. The signed-in user must be an administrator.
+func (c *Client) Webhooks(ctx context.Context) ([]Webhook, error) {
+	var webhooks []Webhook
+	if err := c.do(ctx, http.MethodGet, "/api/v1/admin/webhooks", nil, nil, &webhooks); err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+// WebhookDeliveries returns one page of webhook delivery attempts, newest
+// first. The signed-in user must be an administrator.
+func (c *Client) WebhookDeliveries(ctx context.Context, q WebhookDeliveryQuery) ([]WebhookDelivery, error) {
+	var response handlers.WebhookDeliveriesResponse
+	if err := c.do(ctx, http.MethodGet, "/api/v1/admin/webhooks/deliveries", q.values(), nil, &response); err != nil {
+		return nil, err
+	}
+	return response.Deliveries, nil
+}
+
+// PingWebhook queues a ping event for the named webhook and returns it; its
+// delivery shows up in WebhookDeliveries. The signed-in user must be an
+// administrator.
+func (c *Client) PingWebhook(ctx context.Context, name string) (*Event, error) {
+	var event Event
+	if err := c.do(ctx, http.MethodPost, "/api/v1/admin/webhooks/"+url.PathEscape(name)+"/ping", nil, nil, &event); err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+func thresholdValues(threshold float64) url.Values {
+	values := url.Values{}
+	if threshold != 0 {
+		values.Set("threshold", strconv.FormatFloat(threshold, 'f', -1, 64))
+	}
+	return values
+}
+
+func (q ChallengeSearch) values() url.Values {
+	values := url.Values{}
+	set := func(key, value string) {
+		if value != "" {
+			values.Set(key, value)
+		}
+	}
+	set("q", q.Text)
+	set("difficulty", strings.Join(q.Difficulties, ","))
+	set("tag", strings.Join(q.Tags, ","))
+	set("topic", strings.Join(q.Topics, ","))
+	set("status", q.Status)
+	set("sort", q.Sort)
+	set("fields", strings.Join(q.Fields, ","))
+	return values
+}
+
+func (q AuditQuery) values() url.Values {
+	values := url.Values{}
+	set := func(key, value string) {
+		if value != "" {
+			values.Set(key, value)
+		}
+	}
+	set("actor", q.Actor)
+	set("action", q.Action)
+	set("outcome", q.Outcome)
+	if q.ChallengeID != 0 {
+		set("challenge", strconv.Itoa(q.ChallengeID))
+	}
+	if !q.Since.IsZero() {
+		set("since", q.Since.Format(time.RFC3339Nano))
+	}
+	if !q.Until.IsZero() {
+		set("until", q.Until.Format(time.RFC3339Nano))
+	}
+	if q.Before != 0 {
+		set("before", strconv.FormatInt(q.Before, 10))
+	}
+	if q.Limit != 0 {
+		set("limit", strconv.Itoa(q.Limit))
+	}
+	return values
+}
+
+func (q WebhookDeliveryQuery) values() url.Values {
+	values := url.Values{}
+	set := func(key, value string) {
+		if value != "" {
+			values.Set(key, value)
+		}
+	}
+	set("subscription", q.Subscription)
+	set("event", q.EventType)
+	set("outcome", q.Outcome)
+	if q.Limit != 0 {
+		set("limit", strconv.Itoa(q.Limit))
+	}
+	return values
+}
@@ -0,0 +1,267 @@
+// Package client is a typed Go client for the web UI's /api/v1 JSON API.
+//
+// Every method takes a context that bounds the whole call, retries included.
+// Failed calls return an *Error decoded from the server's error envelope.
+//
+//	c, err := client.New("http://localhost:8080")
+//	if err != nil { ... }
+//	if err := c.Login(ctx, "gopher", "secret"); err != nil { ... }
+//	result, err := c.Run(ctx, 1, code)
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// csrfHeader carries the CSRF token on unsafe requests
+const csrfHeader = "X-CSRF-Token"
+
+// RetryPolicy controls how failed requests are retried. Idempotent requests
+// (GET, PUT) are retried after network errors and 429, 502, 503 and 504
+// responses; POST requests only after 429 and 503, which the server sends
+// before doing any work.
+type RetryPolicy struct {
+	MaxAttempts int           // total attempts, 1 disables retries
+	MinBackoff  time.Duration // delay before the first retry
+	MaxBackoff  time.Duration // upper bound on any delay, including Retry-After
+}
+
+// DefaultRetryPolicy is used unless WithRetry is given
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	MinBackoff:  200 * time.Millisecond,
+	MaxBackoff:  5 * time.Second,
+}
+
+// Client calls the web UI API. It is safe for concurrent use.
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	retry      RetryPolicy
+	userAgent  string
+
+	mu        sync.Mutex
+	csrfToken string
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithHTTPClient sends requests with hc. Signing in needs hc to have a cookie jar.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.httpClient = hc }
+}
+
+// WithRetry replaces DefaultRetryPolicy
+func WithRetry(policy RetryPolicy) Option {
+	return func(c *Client) { c.retry = policy }
+}
+
+// WithUserAgent sets the User-Agent header
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) { c.userAgent = userAgent }
+}
+
+// New creates a client for the server at baseURL, e.g. "http://localhost:8080"
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL: %v", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid base URL %q: scheme must be http or https", baseURL)
+	}
+
+	c := &Client{
+		baseURL:   u,
+		retry:     DefaultRetryPolicy,
+		userAgent: "web-ui-client",
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.httpClient == nil {
+		jar, err := cookiejar.New(nil)
+		if err != nil {
+			return nil, err
+		}
+		c.httpClient = &http.Client{Jar: jar, Timeout: 2 * time.Minute}
+	}
+	if c.retry.MaxAttempts < 1 {
+		c.retry.MaxAttempts = 1
+	}
+	return c, nil
+}
+
+// do sends a JSON request and decodes a successful response into out,
+// retrying according to the client's policy
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, in, out any) error {
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return fmt.Errorf("failed to encode request: %v", err)
+		}
+	}
+
+	unsafe := method != http.MethodGet && method != http.MethodHead
+	refreshedCSRF := false
+	for attempt := 1; ; attempt++ {
+		var token string
+		if unsafe {
+			var err error
+			if token, err = c.csrf(ctx); err != nil {
+				return err
+			}
+		}
+
+		resp, err := c.send(ctx, method, path, query, body, token)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if method == http.MethodPost || attempt >= c.retry.MaxAttempts {
+				return err
+			}
+			if err := c.sleep(ctx, c.backoff(attempt, 0)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if resp.StatusCode < 300 {
+			defer resp.Body.Close()
+			if out == nil {
+				return nil
+			}
+			if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+				return fmt.Errorf("failed to decode %s %s response: %v", method, path, err)
+			}
+			return nil
+		}
+
+		apiErr := decodeError(resp)
+		// The CSRF cookie may have expired or been rotated; fetch a new one once
+		if apiErr.Code == CodeCSRF && !refreshedCSRF {
+			refreshedCSRF = true
+			c.setCSRF("")
+			attempt--
+			continue
+		}
+		if attempt >= c.retry.MaxAttempts || !retryable(method, resp.StatusCode) {
+			return apiErr
+		}
+		if err := c.sleep(ctx, c.backoff(attempt, retryAfter(resp))); err != nil {
+			return err
+		}
+	}
+}
+
+// send performs a single HTTP request
+func (c *Client) send(ctx context.Context, method, path string, query url.Values, body []byte, csrfToken string) (*http.Response, error) {
+	u := *c.baseURL
+	u.Path += path
+	u.RawQuery = query.Encode()
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", c.userAgent)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if csrfToken != "" {
+		req.Header.Set(csrfHeader, csrfToken)
+	}
+	return c.httpClient.Do(req)
+}
+
+// csrf returns the CSRF token for unsafe requests, fetching it from
+// /auth/me (which also sets the matching cookie) the first time
+func (c *Client) csrf(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	token := c.csrfToken
+	c.mu.Unlock()
+	if token != "" {
+		return token, nil
+	}
+
+	var me Session
+	if err := c.do(ctx, http.MethodGet, "/auth/me", nil, nil, &me); err != nil {
+		return "", fmt.Errorf("failed to fetch CSRF token: %w", err)
+	}
+	c.setCSRF(me.CSRFToken)
+	return me.CSRFToken, nil
+}
+
+func (c *Client) setCSRF(token string) {
+	c.mu.Lock()
+	c.csrfToken = token
+	c.mu.Unlock()
+}
+
+// backoff returns the delay before retry number attempt: the server's
+// Retry-After when given, otherwise exponential backoff with jitter
+func (c *Client) backoff(attempt int, retryAfter time.Duration) time.Duration {
+	delay := retryAfter
+	if delay <= 0 {
+		delay = c.retry.MinBackoff << (attempt - 1)
+		if delay > 0 {
+			delay = delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+		}
+	}
+	if c.retry.MaxBackoff > 0 && delay > c.retry.MaxBackoff {
+		delay = c.retry.MaxBackoff
+	}
+	return delay
+}
+
+// sleep waits for d or until ctx is done
+func (c *Client) sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// retryable reports whether a response status is worth retrying for method
+func retryable(method string, status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return true
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		return method != http.MethodPost
+	}
+	return false
+}
+
+// retryAfter parses a Retry-After header given in seconds
+func retryAfter(resp *http.Response) time.Duration {
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
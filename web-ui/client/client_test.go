@@ -0,0 +1,909 @@
+package client
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"web-ui/internal/audit"
+	"web-ui/internal/auth"
+	"web-ui/internal/collab"
+	"web-ui/internal/config"
+	"web-ui/internal/events"
+	"web-ui/internal/paths"
+	"web-ui/internal/server"
+	"web-ui/internal/services"
+	"web-ui/internal/webhook"
+)
+
+const passingSolution = `package main
+
+func Sum(a, b int) int { return a + b }
+
+func main() {}
+`
+
+// testServer is the real server over a one-challenge repository with the
+// local accounts gopher and admin (password "password") and a webhook named
+// receiver that gets every event
+type testServer struct {
+	*httptest.Server
+	root     string
+	auditLog *audit.Log
+	receiver *receiver
+}
+
+// receiver is a local webhook endpoint that keeps the events it was sent
+// with a valid signature
+type receiver struct {
+	*httptest.Server
+	mu     sync.Mutex
+	events []events.Event
+}
+
+func newReceiver(t *testing.T, secret string) *receiver {
+	rc := &receiver{}
+	rc.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !webhook.Verify(secret, r.Header.Get(webhook.SignatureHeader), r.Header.Get(webhook.TimestampHeader), body) {
+			http.Error(w, "bad signature", http.StatusUnauthorized)
+			return
+		}
+		var e events.Event
+		if err := json.Unmarshal(body, &e); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		rc.mu.Lock()
+		rc.events = append(rc.events, e)
+		rc.mu.Unlock()
+	}))
+	t.Cleanup(rc.Close)
+	return rc
+}
+
+// waitFor polls until an event of eventType arrives and returns it
+func (rc *receiver) waitFor(t *testing.T, eventType string) events.Event {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		rc.mu.Lock()
+		for _, e := range rc.events {
+			if e.Type == eventType {
+				rc.mu.Unlock()
+				return e
+			}
+		}
+		rc.mu.Unlock()
+	}
+	t.Fatalf("no %s event was delivered", eventType)
+	return events.Event{}
+}
+
+// writeFiles writes files, mapping slash-separated paths to their content,
+// under root
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"challenge-1/README.md":                            "# Challenge 1: Sum\n\nAdd two numbers.\n",
+		"challenge-1/solution-template.go":                 "package main\n\nfunc Sum(a, b int) int { return 0 }\n\nfunc main() {}\n",
+		"challenge-1/solution-template_test.go":            "package main\n\nimport \"testing\"\n\nfunc TestSum(t *testing.T) {\n\tif Sum(1, 2) != 3 {\n\t\tt.Fatal(\"Sum(1, 2) != 3\")\n\t}\n}\n",
+		"challenge-1/SCOREBOARD.md":                        "# Scoreboard for challenge-1\n| Username   | Passed Tests | Total Tests |\n|------------|--------------|-------------|\n| gopher | 1 | 1 |\n",
+		"challenge-1/submissions/ada/solution-template.go": passingSolution,
+		"teams.json": `[{"name": "Gophers", "members": ["gopher"]}]`,
+	})
+
+	cfg := config.Default()
+	cfg.Storage.Backend = config.StorageMemory
+	cfg.Auth.Admins = []string{"admin"}
+
+	resolver, err := paths.NewResolver(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	challengeService := services.NewChallengeService(resolver)
+	if err := challengeService.LoadChallenges(); err != nil {
+		t.Fatal(err)
+	}
+	scoreboardService := services.NewScoreboardService(resolver)
+	scoreboardService.LoadScoreboards(challengeService.GetChallenges())
+	executionService := services.NewExecutionService(resolver, services.ExecutionLimits{
+		Timeout: time.Minute, MaxConcurrent: 2, MaxOutputBytes: 64 << 10,
+	})
+
+	sessions, err := auth.NewSessionManager(nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	local, err := auth.NewLocalProvider("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, username := range []string{"gopher", "admin"} {
+		if _, err := local.Register(context.Background(), username, username+"@example.com", "password"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	providers := auth.NewRegistry(local)
+
+	auditLog, err := audit.Open("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scorer, err := services.NewScorer(services.ScoringCompletion, services.ScoringRules{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaderboard := services.NewLeaderboardService(challengeService, scoreboardService, scorer, nil)
+	teamService, err := services.NewTeamService(resolver, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	contestService, err := services.NewContestService(challengeService, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	interviewService, err := services.NewInterviewService(challengeService, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	solutionService, err := services.NewSolutionService(resolver, scoreboardService, executionService, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	draftService := services.NewDraftService(challengeService, "")
+	reviewService, err := services.NewReviewService(draftService, teamService, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc := newReceiver(t, "s3cret")
+	deliveries, err := webhook.OpenDeliveryLog("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	webhooks, err := webhook.New([]webhook.Subscription{{Name: "receiver", URL: rc.URL, Secret: "s3cret"}}, deliveries, webhook.Options{Backoff: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { webhooks.Close(context.Background()) })
+	bus := events.NewBus()
+	bus.Subscribe(webhooks.Handle)
+
+	srv := server.NewServer(embed.FS{}, cfg, resolver, challengeService, scoreboardService,
+		services.NewHistoryService(resolver), leaderboard, teamService, contestService, interviewService, collab.NewHub(0), draftService, solutionService, reviewService,
+		services.NewSimilarityService(resolver, challengeService, cfg.Similarity.Threshold), services.NewUserService(resolver), executionService, sessions, providers, auditLog,
+		bus, webhooks, deliveries)
+	ts := httptest.NewServer(srv.SetupRoutes())
+	t.Cleanup(ts.Close)
+	return &testServer{Server: ts, root: root, auditLog: auditLog, receiver: rc}
+}
+
+func newTestClient(t *testing.T, baseURL string, opts ...Option) *Client {
+	t.Helper()
+	c, err := New(baseURL, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestChallengesAndLeaderboard(t *testing.T) {
+	ts := newTestServer(t)
+	c := newTestClient(t, ts.URL)
+	ctx := context.Background()
+
+	challenges, err := c.ListChallenges(ctx)
+	if err != nil || len(challenges) != 1 || challenges[0].Title != "Sum" {
+		t.Fatalf("ListChallenges = %+v, %v", challenges, err)
+	}
+
+	items, err := c.SearchChallenges(ctx, ChallengeSearch{Text: "sum", Fields: []string{"id", "title"}})
+	if err != nil || len(items) != 1 || items[0].Title != "Sum" || items[0].Template != "" {
+		t.Errorf("SearchChallenges = %+v, %v, want only the id and title of challenge 1", items, err)
+	}
+	if _, err := c.SearchChallenges(ctx, ChallengeSearch{Status: "solved"}); !IsUnauthorized(err) {
+		t.Errorf("SearchChallenges(solved) signed out = %v, want 401", err)
+	}
+
+	next, err := c.NextChallenge(ctx, "newcomer", "")
+	if err != nil || next.ChallengeID != 1 || next.Reason != "unlocked" {
+		t.Errorf("NextChallenge = %+v, %v, want challenge 1", next, err)
+	}
+	if _, err := c.NextChallenge(ctx, "newcomer", "nope"); !IsNotFound(err) {
+		t.Errorf("NextChallenge on an unknown path = %v, want 404", err)
+	}
+
+	profile, err := c.UserProfile(ctx, "gopher")
+	if err != nil || profile.SolvedCount != 1 || profile.Rank != 1 || profile.Challenges[0].Status != "solved" {
+		t.Errorf("UserProfile = %+v, %v, want challenge 1 solved at rank 1", profile, err)
+	}
+
+	activity, err := c.UserActivity(ctx, "gopher", 7)
+	if err != nil || len(activity.Days) != 7 {
+		t.Errorf("UserActivity = %+v, %v, want 7 days", activity, err)
+	}
+	if _, err := c.TeamActivity(ctx, 1000); !hasStatus(err, http.StatusBadRequest) {
+		t.Errorf("TeamActivity(1000) = %v, want 400", err)
+	}
+
+	challenge, err := c.GetChallenge(ctx, 1)
+	if err != nil || challenge.ID != 1 {
+		t.Fatalf("GetChallenge(1) = %+v, %v", challenge, err)
+	}
+
+	_, err = c.GetChallenge(ctx, 99)
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || !IsNotFound(err) || apiErr.Code != CodeNotFound || apiErr.RequestID == "" {
+		t.Errorf("GetChallenge(99) error = %#v, want a not_found *Error with a request ID", err)
+	}
+
+	scoreboard, err := c.Scoreboard(ctx, 1)
+	if err != nil || len(scoreboard) != 1 || scoreboard[0].Username != "gopher" {
+		t.Errorf("Scoreboard(1) = %+v, %v", scoreboard, err)
+	}
+
+	leaderboard, err := c.Leaderboard(ctx)
+	if err != nil || len(leaderboard) != 1 || leaderboard[0].Username != "gopher" || leaderboard[0].Rank != 1 {
+		t.Errorf("Leaderboard = %+v, %v", leaderboard, err)
+	}
+	if rank, err := c.Rank(ctx, "gopher"); err != nil || rank != 1 {
+		t.Errorf("Rank(gopher) = %d, %v, want 1", rank, err)
+	}
+	if rank, err := c.Rank(ctx, "nobody"); err != nil || rank != 0 {
+		t.Errorf("Rank(nobody) = %d, %v, want 0", rank, err)
+	}
+}
+
+func TestTeams(t *testing.T) {
+	ts := newTestServer(t)
+	c := newTestClient(t, ts.URL)
+	ctx := context.Background()
+
+	if _, err := c.SaveTeam(ctx, "Night Owls", []string{"newcomer"}); !IsUnauthorized(err) {
+		t.Errorf("anonymous SaveTeam = %v, want 401", err)
+	}
+	if err := c.Login(ctx, "admin", "password"); err != nil {
+		t.Fatal(err)
+	}
+	owls, err := c.SaveTeam(ctx, "Night Owls", []string{"newcomer"})
+	if err != nil || owls.Slug != "night-owls" || owls.Source != "admin" {
+		t.Fatalf("SaveTeam = %+v, %v", owls, err)
+	}
+	if _, err := c.SaveTeam(ctx, "Gophers", nil); !hasStatus(err, http.StatusConflict) {
+		t.Errorf("SaveTeam over a repository team = %v, want 409", err)
+	}
+
+	teams, err := c.Teams(ctx)
+	if err != nil || len(teams) != 2 || teams[0].Slug != "gophers" || teams[0].Coverage != 100 || teams[1].Rank != 2 {
+		t.Fatalf("Teams = %+v, %v, want gophers with full coverage ahead of night-owls", teams, err)
+	}
+	team, err := c.Team(ctx, "night-owls")
+	if err != nil || len(team.Standings) != 1 || team.Standings[0].Username != "newcomer" || team.Standings[0].Rank != 0 {
+		t.Errorf("Team(night-owls) = %+v, %v, want newcomer unranked", team, err)
+	}
+
+	if err := c.DeleteTeam(ctx, "night-owls"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Team(ctx, "night-owls"); !IsNotFound(err) {
+		t.Errorf("Team after DeleteTeam = %v, want 404", err)
+	}
+}
+
+func TestContests(t *testing.T) {
+	ts := newTestServer(t)
+	c := newTestClient(t, ts.URL)
+	ctx := context.Background()
+
+	start := time.Now().Add(time.Hour)
+	request := ContestRequest{Name: "March Cup", ChallengeIDs: []int{1}, StartsAt: start, EndsAt: start.Add(2 * time.Hour), FreezeMinutes: 30}
+	if _, err := c.SaveContest(ctx, request); !IsUnauthorized(err) {
+		t.Errorf("anonymous SaveContest = %v, want 401", err)
+	}
+	if err := c.Login(ctx, "admin", "password"); err != nil {
+		t.Fatal(err)
+	}
+	contest, err := c.SaveContest(ctx, request)
+	if err != nil || contest.Slug != "march-cup" {
+		t.Fatalf("SaveContest = %+v, %v", contest, err)
+	}
+	request.ChallengeIDs = []int{2}
+	if _, err := c.SaveContest(ctx, request); !hasStatus(err, http.StatusBadRequest) {
+		t.Errorf("SaveContest with an unknown challenge = %v, want 400", err)
+	}
+
+	contests, err := c.Contests(ctx)
+	if err != nil || len(contests) != 1 || contests[0].Status != "upcoming" {
+		t.Fatalf("Contests = %+v, %v, want one upcoming contest", contests, err)
+	}
+	if _, err := c.SubmitToContest(ctx, "march-cup", 1, "package main"); !hasStatus(err, http.StatusConflict) {
+		t.Errorf("SubmitToContest before the start = %v, want 409", err)
+	}
+	board, err := c.LiveContestScoreboard(ctx, "march-cup")
+	if err != nil || len(board.Problems) != 1 || board.Problems[0].Label != "A" || len(board.Rows) != 0 {
+		t.Errorf("LiveContestScoreboard = %+v, %v, want problem A and no rows", board, err)
+	}
+
+	if err := c.DeleteContest(ctx, "march-cup"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.ContestScoreboard(ctx, "march-cup"); !IsNotFound(err) {
+		t.Errorf("ContestScoreboard after DeleteContest = %v, want 404", err)
+	}
+}
+
+func TestInterviews(t *testing.T) {
+	ts := newTestServer(t)
+	interviewer := newTestClient(t, ts.URL)
+	candidate := newTestClient(t, ts.URL)
+	ctx := context.Background()
+
+	if err := interviewer.Login(ctx, "gopher", "password"); err != nil {
+		t.Fatal(err)
+	}
+	invite, err := interviewer.CreateInterview(ctx, InterviewRequest{Candidate: "Ada", ChallengeIDs: []int{1}, DurationMinutes: 30})
+	if err != nil || invite.Status != "invited" || invite.InvitePath != "/invite/"+invite.InviteToken {
+		t.Fatalf("CreateInterview = %+v, %v", invite, err)
+	}
+
+	if _, err := candidate.SaveEditorSnapshot(ctx, invite.ID, 1, "package main"); !hasStatus(err, http.StatusForbidden) {
+		t.Errorf("SaveEditorSnapshot before joining = %v, want 403", err)
+	}
+	joined, err := candidate.JoinInterview(ctx, invite.InviteToken)
+	if err != nil || joined.Status != "in_progress" || joined.RemainingSeconds <= 0 {
+		t.Fatalf("JoinInterview = %+v, %v", joined, err)
+	}
+	if _, err := newTestClient(t, ts.URL).JoinInterview(ctx, invite.InviteToken); !hasStatus(err, http.StatusConflict) {
+		t.Errorf("second JoinInterview = %v, want 409", err)
+	}
+	if _, err := candidate.SaveEditorSnapshot(ctx, invite.ID, 1, "package main // v1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := candidate.Interview(ctx, invite.ID); !IsUnauthorized(err) {
+		t.Errorf("candidate Interview = %v, want 401", err)
+	}
+	if !testing.Short() {
+		if result, err := candidate.RunInInterview(ctx, invite.ID, 1, "package main"); err != nil || result.Passed {
+			t.Errorf("RunInInterview = %+v, %v, want a failed run", result, err)
+		}
+	}
+
+	report, err := interviewer.InterviewReport(ctx, invite.ID)
+	if err != nil || len(report.Challenges) != 1 || report.Challenges[0].Snapshots != 1 {
+		t.Fatalf("InterviewReport = %+v, %v, want one editor snapshot", report, err)
+	}
+	if _, err := interviewer.EndInterview(ctx, invite.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := candidate.SaveEditorSnapshot(ctx, invite.ID, 1, "package main // v2"); !hasStatus(err, http.StatusConflict) {
+		t.Errorf("SaveEditorSnapshot after the end = %v, want 409", err)
+	}
+	export, err := interviewer.ExportInterview(ctx, invite.ID)
+	if err != nil || export.Interview.EndedAt == nil || export.Events[0].Kind != "joined" {
+		t.Errorf("ExportInterview = %+v, %v, want the ended interview from the join onwards", export, err)
+	}
+	interviews, err := interviewer.Interviews(ctx)
+	if err != nil || len(interviews) != 1 || interviews[0].Status != "ended" {
+		t.Errorf("Interviews = %+v, %v, want the ended interview", interviews, err)
+	}
+}
+
+func TestPairRooms(t *testing.T) {
+	ts := newTestServer(t)
+	c := newTestClient(t, ts.URL)
+	ctx := context.Background()
+
+	if _, err := c.CreatePairRoom(ctx, 1, ""); !IsUnauthorized(err) {
+		t.Errorf("CreatePairRoom signed out = %v, want 401", err)
+	}
+	if err := c.Login(ctx, "gopher", "password"); err != nil {
+		t.Fatal(err)
+	}
+	room, err := c.CreatePairRoom(ctx, 1, "package main\n")
+	if err != nil || room.Owner != "gopher" || room.Path != "/challenge/1?pair="+room.ID {
+		t.Fatalf("CreatePairRoom = %+v, %v", room, err)
+	}
+
+	// The socket goes through the server's middleware and session cookie
+	dialer := websocket.Dialer{Jar: c.httpClient.Jar}
+	ws, _, err := dialer.Dial(c.PairSocketURL(room.ID), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+	var welcome collab.Message
+	if err := ws.ReadJSON(&welcome); err != nil || welcome.Name != "gopher" || welcome.Document == nil || *welcome.Document != "package main\n" {
+		t.Fatalf("welcome = %+v, %v, want gopher joining with the document", welcome, err)
+	}
+	if err := ws.WriteJSON(map[string]any{"type": "op", "revision": 0, "seq": 1, "op": []any{13, "// shared\n"}}); err != nil {
+		t.Fatal(err)
+	}
+	var ack collab.Message
+	if err := ws.ReadJSON(&ack); err != nil || ack.Type != collab.MessageOp || ack.Revision != 1 {
+		t.Fatalf("ack = %+v, %v, want the operation at revision 1", ack, err)
+	}
+
+	got, err := newTestClient(t, ts.URL).PairRoom(ctx, room.ID)
+	if err != nil || got.Revision != 1 || len(got.Participants) != 1 || got.ExpiresAt != nil {
+		t.Errorf("PairRoom = %+v, %v, want gopher connected at revision 1", got, err)
+	}
+	if _, err := c.PairRoom(ctx, "nope"); !IsNotFound(err) {
+		t.Errorf("PairRoom of an unknown room = %v, want 404", err)
+	}
+}
+
+func TestDrafts(t *testing.T) {
+	ts := newTestServer(t)
+	c := newTestClient(t, ts.URL)
+	ctx := context.Background()
+
+	if _, err := c.Drafts(ctx); !IsUnauthorized(err) {
+		t.Errorf("Drafts signed out = %v, want 401", err)
+	}
+	if err := c.Login(ctx, "gopher", "password"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.SaveDraft(ctx, 1, "package main\n", "", true); err != nil {
+		t.Fatal(err)
+	}
+	v2, err := c.SaveDraft(ctx, 1, "package main\n\nfunc main() {}\n", "with main", false)
+	if err != nil || v2.Version != 2 || v2.Label != "with main" {
+		t.Fatalf("SaveDraft = %+v, %v, want labeled version 2", v2, err)
+	}
+
+	d, err := c.DiffDrafts(ctx, 1, 1, 2)
+	if err != nil || d.Added != 2 || d.Removed != 0 || !strings.Contains(d.Unified, "+func main() {}\n") {
+		t.Errorf("DiffDrafts = %+v, %v", d, err)
+	}
+	restored, err := c.RestoreDraft(ctx, 1, 1)
+	if err != nil || restored.Version != 3 || restored.RestoredFrom != 1 || restored.Code != "package main\n" {
+		t.Errorf("RestoreDraft = %+v, %v, want version 3 copying version 1", restored, err)
+	}
+	versions, err := c.DraftVersions(ctx, 1)
+	if err != nil || len(versions) != 3 || versions[0].Version != 3 || versions[1].Lines != 3 {
+		t.Errorf("DraftVersions = %+v, %v", versions, err)
+	}
+	if v, err := c.DraftVersion(ctx, 1, 2); err != nil || v.Code != v2.Code {
+		t.Errorf("DraftVersion = %+v, %v", v, err)
+	}
+	if _, err := c.DraftVersion(ctx, 1, 9); !IsNotFound(err) {
+		t.Errorf("DraftVersion of a missing version = %v, want 404", err)
+	}
+	drafts, err := c.Drafts(ctx)
+	if err != nil || len(drafts) != 1 || drafts[0].ChallengeID != 1 || drafts[0].LatestVersion != 3 {
+		t.Errorf("Drafts = %+v, %v", drafts, err)
+	}
+}
+
+func TestSolutions(t *testing.T) {
+	ts := newTestServer(t)
+	ctx := context.Background()
+
+	// Solutions unlock once the challenge is solved
+	admin := newTestClient(t, ts.URL)
+	if err := admin.Login(ctx, "admin", "password"); err != nil {
+		t.Fatal(err)
+	}
+	var apiErr *Error
+	if _, err := admin.Solutions(ctx, 1); !errors.As(err, &apiErr) || apiErr.Code != CodeForbidden {
+		t.Errorf("Solutions of an unsolved challenge = %v, want 403", err)
+	}
+
+	c := newTestClient(t, ts.URL)
+	if err := c.Login(ctx, "gopher", "password"); err != nil {
+		t.Fatal(err)
+	}
+	gallery, err := c.Solutions(ctx, 1)
+	if err != nil || len(gallery.Solutions) != 1 || gallery.Solutions[0].Username != "ada" || gallery.Solutions[0].CodeLines != 3 || gallery.HasBenchmarks {
+		t.Fatalf("Solutions = %+v, %v", gallery, err)
+	}
+	if solution, err := c.Solution(ctx, 1, "ada"); err != nil || solution.Code != passingSolution {
+		t.Errorf("Solution = %+v, %v", solution, err)
+	}
+	if _, err := c.Solution(ctx, 1, "gopher"); !IsNotFound(err) {
+		t.Errorf("Solution without a file = %v, want 404", err)
+	}
+	comparison, err := c.CompareSolutions(ctx, 1, "ada", "ada")
+	if err != nil || !comparison.Formatted || comparison.Added != 0 || len(comparison.Declarations) != 3 {
+		t.Errorf("CompareSolutions = %+v, %v", comparison, err)
+	}
+	if _, err := c.BenchmarkSolution(ctx, 1, "ada"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusConflict {
+		t.Errorf("BenchmarkSolution without benchmarks = %v, want 409", err)
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	ts := newTestServer(t)
+	ctx := context.Background()
+	copied := "package main\n\nfunc Sum(a, b int) int {\n\tfor b > 0 {\n\t\ta, b = a+1, b-1\n\t}\n\treturn a\n}\n\nfunc main() {}\n"
+	writeFiles(t, filepath.Join(ts.root, "challenge-1", "submissions"), map[string]string{
+		"grace/solution-template.go": copied,
+		"linus/solution-template.go": copied,
+	})
+
+	c := newTestClient(t, ts.URL)
+	if err := c.Login(ctx, "gopher", "password"); err != nil {
+		t.Fatal(err)
+	}
+	var apiErr *Error
+	if _, err := c.SimilarityReport(ctx, 1, 0); !errors.As(err, &apiErr) || apiErr.Code != CodeForbidden {
+		t.Errorf("SimilarityReport as a non-admin = %v, want 403", err)
+	}
+
+	admin := newTestClient(t, ts.URL)
+	if err := admin.Login(ctx, "admin", "password"); err != nil {
+		t.Fatal(err)
+	}
+	report, err := admin.SimilarityReport(ctx, 1, 0)
+	if err != nil || report.Threshold != 0.8 || report.Solutions != 3 || len(report.Pairs) != 3 || report.Flagged != 1 {
+		t.Fatalf("SimilarityReport = %+v, %v", report, err)
+	}
+	if top := report.Pairs[0]; top.Left != "grace" || top.Right != "linus" || top.Score != 1 {
+		t.Errorf("most similar pair = %+v, want grace and linus", top)
+	}
+	flagged, err := admin.FlaggedSimilarity(ctx, 0.5)
+	if err != nil || len(flagged) != 1 || flagged[0].Threshold != 0.5 || len(flagged[0].Pairs) != 1 {
+		t.Errorf("FlaggedSimilarity = %+v, %v", flagged, err)
+	}
+	if _, err := admin.SimilarityReport(ctx, 9, 0); !IsNotFound(err) {
+		t.Errorf("SimilarityReport of a missing challenge = %v, want 404", err)
+	}
+}
+
+func TestReviews(t *testing.T) {
+	ts := newTestServer(t)
+	ctx := context.Background()
+
+	author := newTestClient(t, ts.URL)
+	if err := author.Login(ctx, "gopher", "password"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := author.SaveToFilesystem(ctx, 1, "package main\n\nfunc Sum(a, b int) int {\n\treturn 0\n}\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only teammates can review
+	mentor := newTestClient(t, ts.URL)
+	if err := mentor.Login(ctx, "admin", "password"); err != nil {
+		t.Fatal(err)
+	}
+	var apiErr *Error
+	if _, err := mentor.CreateReview(ctx, "gopher", 1, 0, ""); !errors.As(err, &apiErr) || apiErr.Code != CodeForbidden {
+		t.Errorf("CreateReview by a non-teammate = %v, want 403", err)
+	}
+	if _, err := mentor.SaveTeam(ctx, "Mentors", []string{"admin", "gopher"}); err != nil {
+		t.Fatal(err)
+	}
+	review, err := mentor.CreateReview(ctx, "gopher", 1, 0, "Close")
+	if err != nil || review.Version != 1 || review.Verdict != "pending" {
+		t.Fatalf("CreateReview = %+v, %v", review, err)
+	}
+	thread, err := mentor.AddReviewThread(ctx, review.ID, ReviewAnchor{Line: 4}, "Should add a and b")
+	if err != nil || thread.ID != 1 || thread.Line != 4 {
+		t.Fatalf("AddReviewThread = %+v, %v", thread, err)
+	}
+	if _, err := mentor.SetVerdict(ctx, review.ID, "changes_requested", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	// The thread follows its line into the fixed version
+	if _, err := author.SaveToFilesystem(ctx, 1, "package main\n\n// Sum adds\nfunc Sum(a, b int) int {\n\treturn a + b\n}\n"); err != nil {
+		t.Fatal(err)
+	}
+	if thread, err = author.ReplyToThread(ctx, review.ID, 1, "Fixed"); err != nil || thread.Line != 5 || !thread.Outdated || len(thread.Comments) != 2 {
+		t.Errorf("ReplyToThread = %+v, %v, want outdated at line 5", thread, err)
+	}
+	if thread, err = author.ResolveThread(ctx, review.ID, 1, true); err != nil || !thread.Resolved || thread.ResolvedBy != "gopher" {
+		t.Errorf("ResolveThread = %+v, %v", thread, err)
+	}
+	if reviews, err := author.Reviews(ctx, 1); err != nil || len(reviews) != 1 || reviews[0].OpenThreads != 0 {
+		t.Errorf("Reviews = %+v, %v", reviews, err)
+	}
+	if got, err := author.Review(ctx, review.ID); err != nil || got.LatestVersion != 2 || got.Verdict != "changes_requested" {
+		t.Errorf("Review = %+v, %v", got, err)
+	}
+
+	feed, err := author.Notifications(ctx, true)
+	if err != nil || feed.Unread != 3 || len(feed.Notifications) != 3 || feed.Notifications[0].Kind != "verdict" {
+		t.Fatalf("Notifications = %+v, %v, want the review, comment and verdict", feed, err)
+	}
+	if unread, err := author.MarkNotificationsRead(ctx, 0); err != nil || unread != 0 {
+		t.Errorf("MarkNotificationsRead = %d, %v", unread, err)
+	}
+	if feed, err := mentor.Notifications(ctx, false); err != nil || feed.Unread != 2 {
+		t.Errorf("mentor's Notifications = %+v, %v, want the reply and resolve", feed, err)
+	}
+}
+
+func TestWebhooks(t *testing.T) {
+	ts := newTestServer(t)
+	ctx := context.Background()
+
+	c := newTestClient(t, ts.URL)
+	if err := c.Login(ctx, "gopher", "password"); err != nil {
+		t.Fatal(err)
+	}
+	var apiErr *Error
+	if _, err := c.Webhooks(ctx); !errors.As(err, &apiErr) || apiErr.Code != CodeForbidden {
+		t.Errorf("Webhooks as a non-admin = %v, want 403", err)
+	}
+
+	admin := newTestClient(t, ts.URL)
+	if err := admin.Login(ctx, "admin", "password"); err != nil {
+		t.Fatal(err)
+	}
+	webhooks, err := admin.Webhooks(ctx)
+	if err != nil || len(webhooks) != 1 || webhooks[0].Name != "receiver" || webhooks[0].URL != ts.receiver.URL {
+		t.Fatalf("Webhooks = %+v, %v", webhooks, err)
+	}
+	ping, err := admin.PingWebhook(ctx, "receiver")
+	if err != nil || ping.Type != events.Ping {
+		t.Fatalf("PingWebhook = %+v, %v", ping, err)
+	}
+	if got := ts.receiver.waitFor(t, events.Ping); got.ID != ping.ID {
+		t.Errorf("received ping %s, want %s", got.ID, ping.ID)
+	}
+	if _, err := admin.PingWebhook(ctx, "nope"); !IsNotFound(err) {
+		t.Errorf("PingWebhook of an unknown webhook = %v, want 404", err)
+	}
+
+	if testing.Short() {
+		return
+	}
+	if _, err := admin.Submit(ctx, 1, passingSolution); err != nil {
+		t.Fatal(err)
+	}
+	passed := ts.receiver.waitFor(t, events.SubmissionPassed)
+	if data, _ := passed.Data.(map[string]any); data["username"] != "admin" || data["challengeTitle"] != "Sum" {
+		t.Errorf("submission.passed data = %+v", passed.Data)
+	}
+
+	// Every attempt is in the delivery log, recorded once the receiver
+	// has answered
+	var deliveries []WebhookDelivery
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		deliveries, err = admin.WebhookDeliveries(ctx, WebhookDeliveryQuery{Subscription: "receiver", Outcome: webhook.OutcomeDelivered})
+		if err != nil || len(deliveries) >= 2 || time.Now().After(deadline) {
+			break
+		}
+	}
+	if err != nil || len(deliveries) != 2 || deliveries[0].EventID != passed.ID || deliveries[1].EventID != ping.ID {
+		t.Errorf("WebhookDeliveries = %+v, %v, want the submission and the ping", deliveries, err)
+	}
+}
+
+func TestRunSubmitAndSave(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs go test for submitted solutions")
+	}
+	ts := newTestServer(t)
+	ctx := context.Background()
+
+	anonymous := newTestClient(t, ts.URL)
+	result, err := anonymous.Run(ctx, 1, passingSolution)
+	if err != nil || !result.Passed {
+		t.Fatalf("Run = %+v, %v", result, err)
+	}
+	if _, err := anonymous.Submit(ctx, 1, passingSolution); !IsUnauthorized(err) {
+		t.Errorf("anonymous Submit error = %v, want 401", err)
+	}
+
+	c := newTestClient(t, ts.URL)
+	if err := c.Login(ctx, "gopher", "wrong"); !IsUnauthorized(err) {
+		t.Errorf("Login with a wrong password = %v, want 401", err)
+	}
+	if err := c.Login(ctx, "gopher", "password"); err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	if me, err := c.Me(ctx); err != nil || me.Username != "gopher" {
+		t.Errorf("Me = %+v, %v", me, err)
+	}
+
+	submission, err := c.Submit(ctx, 1, passingSolution)
+	if err != nil || !submission.Passed || submission.Username != "gopher" {
+		t.Fatalf("Submit = %+v, %v", submission, err)
+	}
+	submissions, err := c.Submissions(ctx)
+	if err != nil || len(submissions) != 1 {
+		t.Errorf("Submissions = %+v, %v", submissions, err)
+	}
+
+	saved, err := c.SaveToFilesystem(ctx, 1, passingSolution)
+	if err != nil || !saved.Success {
+		t.Fatalf("SaveToFilesystem = %+v, %v", saved, err)
+	}
+	if content, err := os.ReadFile(saved.FilePath); err != nil || string(content) != passingSolution {
+		t.Errorf("saved file = %q, %v", content, err)
+	}
+
+	if err := c.Logout(ctx); err != nil {
+		t.Fatalf("Logout: %v", err)
+	}
+	if _, err := c.Submissions(ctx); !IsUnauthorized(err) {
+		t.Errorf("Submissions after Logout = %v, want 401", err)
+	}
+}
+
+func TestStaleCSRFTokenIsRefreshed(t *testing.T) {
+	ts := newTestServer(t)
+	c := newTestClient(t, ts.URL)
+	ctx := context.Background()
+
+	if err := c.Login(ctx, "gopher", "password"); err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	c.setCSRF("stale")
+	if _, err := c.RefreshAttempts(ctx, "gopher"); err != nil {
+		t.Errorf("RefreshAttempts with a stale CSRF token = %v", err)
+	}
+}
+
+func TestStreamAuditEvents(t *testing.T) {
+	ts := newTestServer(t)
+	ctx := context.Background()
+	// Events at the same time straddle page boundaries
+	at := time.Now().UTC()
+	for i := 1; i <= 5; i++ {
+		event := audit.Event{Time: at, Actor: "gopher", Action: audit.ActionRun, ChallengeID: i, Outcome: "passed"}
+		if err := ts.auditLog.Record(ctx, event); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c := newTestClient(t, ts.URL)
+	if err := c.StreamAuditEvents(ctx, AuditQuery{}, func(AuditEvent) error { return nil }); !IsUnauthorized(err) {
+		t.Errorf("anonymous StreamAuditEvents = %v, want 401", err)
+	}
+	if err := c.Login(ctx, "admin", "password"); err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+
+	var ids []int
+	err := c.StreamAuditEvents(ctx, AuditQuery{Limit: 2}, func(e AuditEvent) error {
+		ids = append(ids, e.ChallengeID)
+		return nil
+	})
+	if err != nil || len(ids) != 5 || ids[0] != 5 || ids[4] != 1 {
+		t.Errorf("StreamAuditEvents = %v, %v, want challenges 5..1", ids, err)
+	}
+
+	ids = nil
+	err = c.StreamAuditEvents(ctx, AuditQuery{Limit: 2}, func(e AuditEvent) error {
+		ids = append(ids, e.ChallengeID)
+		if len(ids) == 3 {
+			return ErrStop
+		}
+		return nil
+	})
+	if err != nil || len(ids) != 3 {
+		t.Errorf("StreamAuditEvents stopped early = %v, %v, want 3 events and no error", ids, err)
+	}
+}
+
+func TestWatchLeaderboard(t *testing.T) {
+	ts := newTestServer(t)
+	c := newTestClient(t, ts.URL)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	snapshots := 0
+	err := c.WatchLeaderboard(ctx, 10*time.Millisecond, func(leaderboard []LeaderboardUser) error {
+		snapshots++
+		return nil
+	})
+	// The leaderboard never changes, so only the first snapshot is delivered
+	if !errors.Is(err, context.DeadlineExceeded) || snapshots != 1 {
+		t.Errorf("WatchLeaderboard = %v after %d snapshots, want DeadlineExceeded after 1", err, snapshots)
+	}
+}
+
+func TestRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		status   int
+		wantHits int32
+	}{
+		{"GET retries 503", "GET", http.StatusServiceUnavailable, 3},
+		{"GET retries 502", "GET", http.StatusBadGateway, 3},
+		{"POST retries 503", "POST", http.StatusServiceUnavailable, 3},
+		{"POST does not retry 502", "POST", http.StatusBadGateway, 1},
+		{"500 is not retried", "GET", http.StatusInternalServerError, 1},
+	}
+	for _, tt := range tests {
+		var hits atomic.Int32
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/auth/me" {
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`{"csrfToken":"token"}`))
+				return
+			}
+			hits.Add(1)
+			w.Header().Set("Retry-After", "0")
+			http.Error(w, "try later", tt.status)
+		}))
+
+		c := newTestClient(t, ts.URL, WithRetry(RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond}))
+		err := c.do(context.Background(), tt.method, "/api/v1/thing", nil, nil, nil)
+		var apiErr *Error
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != tt.status || apiErr.Message != "try later" {
+			t.Errorf("%s: error = %v", tt.name, err)
+		}
+		if got := hits.Load(); got != tt.wantHits {
+			t.Errorf("%s: %d requests, want %d", tt.name, got, tt.wantHits)
+		}
+		ts.Close()
+	}
+}
+
+func TestRetryRecoversAndHonoursContext(t *testing.T) {
+	var hits atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			http.Error(w, "draining", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[]`))
+	}))
+	defer ts.Close()
+
+	c := newTestClient(t, ts.URL, WithRetry(RetryPolicy{MaxAttempts: 2, MinBackoff: time.Millisecond}))
+	if _, err := c.ListChallenges(context.Background()); err != nil || hits.Load() != 2 {
+		t.Errorf("ListChallenges = %v after %d requests, want success after 2", err, hits.Load())
+	}
+
+	// A long Retry-After must not outlive the caller's context
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "30")
+		http.Error(w, "draining", http.StatusServiceUnavailable)
+	}))
+	defer slow.Close()
+
+	c = newTestClient(t, slow.URL, WithRetry(RetryPolicy{MaxAttempts: 5, MaxBackoff: time.Minute}))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := c.ListChallenges(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("ListChallenges = %v, want DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("ListChallenges took %s after its context expired", elapsed)
+	}
+}
+
+func TestNewRejectsBadBaseURL(t *testing.T) {
+	for _, baseURL := range []string{"localhost:8080", "ftp://example.com", "://"} {
+		if _, err := New(baseURL); err == nil {
+			t.Errorf("New(%q) succeeded", baseURL)
+		}
+	}
+}
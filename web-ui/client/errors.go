@@ -0,0 +1,92 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"web-ui/internal/handlers"
+)
+
+// Error codes the server puts in the error envelope
+const (
+	CodeBadRequest      = handlers.CodeBadRequest
+	CodeInvalidID       = handlers.CodeInvalidID
+	CodeUnauthorized    = handlers.CodeUnauthorized
+	CodeForbidden       = handlers.CodeForbidden
+	CodeCSRF            = handlers.CodeCSRF
+	CodeNotFound        = handlers.CodeNotFound
+	CodeFeatureDisabled = handlers.CodeFeatureDisabled
+	CodeUnavailable     = handlers.CodeUnavailable
+	CodeInternal        = handlers.CodeInternal
+)
+
+// maxErrorBody bounds how much of an error response is read
+const maxErrorBody = 64 << 10
+
+// Error is a non-2xx response from the server
+type Error struct {
+	StatusCode int
+	Code       string // envelope code, e.g. "not_found"; empty for plain-text errors
+	Message    string
+	Details    any
+	RequestID  string // X-Request-ID of the failed request, for matching server logs
+}
+
+func (e *Error) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("web-ui API: %d %s: %s", e.StatusCode, e.Code, e.Message)
+	}
+	return fmt.Sprintf("web-ui API: %d: %s", e.StatusCode, e.Message)
+}
+
+// IsNotFound reports whether err is a 404 from the server
+func IsNotFound(err error) bool {
+	return hasStatus(err, http.StatusNotFound)
+}
+
+// IsUnauthorized reports whether err means the client must sign in
+func IsUnauthorized(err error) bool {
+	return hasStatus(err, http.StatusUnauthorized)
+}
+
+func hasStatus(err error, status int) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == status
+}
+
+// decodeError reads an error response, which is either the JSON envelope or
+// plain text from the unversioned endpoints
+func decodeError(resp *http.Response) *Error {
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+
+	apiErr := &Error{
+		StatusCode: resp.StatusCode,
+		RequestID:  resp.Header.Get("X-Request-ID"),
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType == "application/json" {
+		var envelope handlers.ErrorEnvelope
+		if err := json.Unmarshal(body, &envelope); err == nil && envelope.Error.Code != "" {
+			apiErr.Code = envelope.Error.Code
+			apiErr.Message = envelope.Error.Message
+			apiErr.Details = envelope.Error.Details
+			return apiErr
+		}
+	}
+
+	apiErr.Message = strings.TrimSpace(string(body))
+	if apiErr.Message == "" {
+		apiErr.Message = http.StatusText(resp.StatusCode)
+	}
+	if resp.StatusCode == http.StatusForbidden && strings.Contains(apiErr.Message, "CSRF") {
+		apiErr.Code = CodeCSRF
+	}
+	return apiErr
+}
@@ -0,0 +1,99 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"time"
+
+	"web-ui/internal/audit"
+)
+
+// The server has no push endpoints, so the stream helpers page through
+// collections or poll, delivering items to a callback until it returns an
+// error or the context is done.
+
+// ErrStop can be returned by a stream callback to end the stream without error
+var ErrStop = errors.New("stop streaming")
+
+// StreamAuditEvents calls fn for every audit event matching q, newest first,
+// fetching q.Limit events per request until the log is exhausted
+func (c *Client) StreamAuditEvents(ctx context.Context, q AuditQuery, fn func(AuditEvent) error) error {
+	// Match the server's clamping so a full page is recognised as one
+	if q.Limit <= 0 {
+		q.Limit = audit.DefaultLimit
+	}
+	if q.Limit > audit.MaxLimit {
+		q.Limit = audit.MaxLimit
+	}
+
+	for {
+		events, err := c.AuditEvents(ctx, q)
+		if err != nil {
+			return err
+		}
+		for _, event := range events {
+			if err := fn(event); err != nil {
+				return stopped(err)
+			}
+		}
+
+		// A short page means there is nothing older left
+		if len(events) < q.Limit {
+			return nil
+		}
+		// Events can share a time, so page by position in the log
+		q.Before = events[len(events)-1].Seq
+	}
+}
+
+// WatchLeaderboard polls the leaderboard every interval and calls fn with the
+// first snapshot and again whenever it changes
+func (c *Client) WatchLeaderboard(ctx context.Context, interval time.Duration, fn func([]LeaderboardUser) error) error {
+	var last []LeaderboardUser
+	first := true
+	for {
+		leaderboard, err := c.Leaderboard(ctx)
+		if err != nil {
+			return err
+		}
+		if first || !reflect.DeepEqual(leaderboard, last) {
+			if err := fn(leaderboard); err != nil {
+				return stopped(err)
+			}
+			last, first = leaderboard, false
+		}
+		if err := c.sleep(ctx, interval); err != nil {
+			return err
+		}
+	}
+}
+
+// WatchRank polls a user's rank every interval and calls fn with the first
+// value and again whenever it changes
+func (c *Client) WatchRank(ctx context.Context, username string, interval time.Duration, fn func(rank int) error) error {
+	last := -1
+	for {
+		rank, err := c.Rank(ctx, username)
+		if err != nil {
+			return err
+		}
+		if rank != last {
+			if err := fn(rank); err != nil {
+				return stopped(err)
+			}
+			last = rank
+		}
+		if err := c.sleep(ctx, interval); err != nil {
+			return err
+		}
+	}
+}
+
+// stopped hides ErrStop from callers
+func stopped(err error) error {
+	if errors.Is(err, ErrStop) {
+		return nil
+	}
+	return err
+}
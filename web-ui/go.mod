@@ -1,3 +1,8 @@
 module web-ui
 
-go 1.21
+go 1.22
+
+require (
+	github.com/gorilla/websocket v1.5.3
+	golang.org/x/crypto v0.17.0
+)
@@ -0,0 +1,194 @@
+// Package audit keeps an append-only record of who ran, submitted and saved
+// code, for which challenge and with what outcome, and of administrators'
+// changes.
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"web-ui/internal/logging"
+	"web-ui/internal/store"
+)
+
+// Actions recorded in the audit log
+const (
+	ActionRun     = "run"
+	ActionSubmit  = "submit"
+	ActionSave    = "save"
+	ActionTeam    = "team"    // an administrator changed a team; Detail is its slug
+	ActionContest = "contest" // an administrator changed a contest; Detail is its slug
+)
+
+// Query limits
+const (
+	DefaultLimit = 100
+	MaxLimit     = 1000
+)
+
+// Event is a single audit record. Events are never modified once written.
+type Event struct {
+	Seq         int64     `json:"seq,omitempty"` // position in the log from 1, set when read
+	Time        time.Time `json:"time"`
+	RequestID   string    `json:"requestId,omitempty"`
+	Actor       string    `json:"actor"` // signed-in username, empty for anonymous runs
+	RemoteAddr  string    `json:"remoteAddr,omitempty"`
+	Action      string    `json:"action"`
+	ChallengeID int       `json:"challengeId"`
+	Outcome     string    `json:"outcome"`
+	CodeSHA256  string    `json:"codeSha256,omitempty"`
+	DurationMs  int64     `json:"durationMs,omitempty"`
+	Detail      string    `json:"detail,omitempty"`
+}
+
+// Query filters events; zero values match everything
+type Query struct {
+	Actor       string
+	Action      string
+	Outcome     string
+	ChallengeID int
+	Since       time.Time
+	Until       time.Time
+	Before      int64 // only events with a lower Seq, to page past events sharing a time
+	Limit       int
+}
+
+// matches reports whether e satisfies every set filter
+func (q Query) matches(e Event) bool {
+	switch {
+	case q.Actor != "" && e.Actor != q.Actor,
+		q.Action != "" && e.Action != q.Action,
+		q.Outcome != "" && e.Outcome != q.Outcome,
+		q.ChallengeID != 0 && e.ChallengeID != q.ChallengeID,
+		!q.Since.IsZero() && e.Time.Before(q.Since),
+		!q.Until.IsZero() && !e.Time.Before(q.Until),
+		q.Before > 0 && e.Seq >= q.Before:
+		return false
+	}
+	return true
+}
+
+// Log appends events as JSON lines to a file, or keeps them in memory when
+// no path is given
+type Log struct {
+	path   string
+	mu     sync.Mutex
+	file   *os.File
+	events []Event
+}
+
+// Open opens (or creates) the audit log at path for appending. An empty path
+// keeps events in memory only.
+func Open(path string) (*Log, error) {
+	l := &Log{path: path}
+	if path == "" {
+		return l, nil
+	}
+
+	file, err := store.OpenLog(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %v", err)
+	}
+	l.file = file
+	return l, nil
+}
+
+// Record appends e, filling in the time and the request ID from ctx
+func (l *Log) Record(ctx context.Context, e Event) error {
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+	if e.RequestID == "" {
+		e.RequestID = logging.RequestID(ctx)
+	}
+	e.Seq = 0
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.path == "" {
+		l.events = append(l.events, e)
+		return nil
+	}
+	if l.file == nil {
+		return errors.New("audit log is closed")
+	}
+	if err := store.Append(l.file, e); err != nil {
+		return fmt.Errorf("failed to write audit event: %v", err)
+	}
+	return nil
+}
+
+// Query returns matching events, newest first
+func (l *Log) Query(q Query) ([]Event, error) {
+	if q.Limit <= 0 {
+		q.Limit = DefaultLimit
+	}
+	if q.Limit > MaxLimit {
+		q.Limit = MaxLimit
+	}
+
+	var matched []Event
+	var seq int64
+	collect := func(e Event) {
+		seq++
+		e.Seq = seq
+		if q.matches(e) {
+			matched = append(matched, e)
+		}
+	}
+
+	if l.path == "" {
+		l.mu.Lock()
+		for _, e := range l.events {
+			collect(e)
+		}
+		l.mu.Unlock()
+	} else if err := l.scanFile(collect); err != nil {
+		return nil, err
+	}
+
+	// Keep the newest q.Limit events
+	if len(matched) > q.Limit {
+		matched = matched[len(matched)-q.Limit:]
+	}
+	for i, j := 0, len(matched)-1; i < j; i, j = i+1, j-1 {
+		matched[i], matched[j] = matched[j], matched[i]
+	}
+	return matched, nil
+}
+
+// scanFile reads every complete event in the log file in write order. A line
+// longer than store.DefaultMaxLine is an error rather than the end of the log,
+// so a query never silently drops the events after it.
+func (l *Log) scanFile(fn func(Event)) error {
+	if err := store.ScanFile(l.path, 0, fn); err != nil {
+		return fmt.Errorf("failed to read audit log: %w", err)
+	}
+	return nil
+}
+
+// Close closes the underlying file
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+// HashCode returns the hex SHA-256 of submitted code, so the log records
+// exactly what was run without storing the source
+func HashCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,157 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"web-ui/internal/logging"
+	"web-ui/internal/store"
+)
+
+func TestFileLogAppendsAndQueries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit", "audit.jsonl")
+	l, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	ctx := logging.WithRequestID(context.Background(), "req-1")
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	events := []Event{
+		{Time: base, Actor: "alice", Action: ActionRun, ChallengeID: 1, Outcome: "failed"},
+		{Time: base.Add(time.Minute), Actor: "alice", Action: ActionSubmit, ChallengeID: 1, Outcome: "passed"},
+		{Time: base.Add(2 * time.Minute), Actor: "bob", Action: ActionSave, ChallengeID: 2, Outcome: "saved"},
+	}
+	for _, e := range events {
+		if err := l.Record(ctx, e); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+	l.Close()
+
+	// Simulate a crash that left a torn line, then reopen and append
+	f, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+	f.WriteString(`{"time":"2024-05-01T12:`)
+	f.Close()
+	if l, err = Open(path); err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer l.Close()
+	l.Record(context.Background(), Event{Time: base.Add(3 * time.Minute), Actor: "alice", Action: ActionRun, ChallengeID: 2, Outcome: "timed_out"})
+
+	all, err := l.Query(Query{})
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	// The torn line is skipped; events on either side of it survive
+	if len(all) != 4 || all[0].Outcome != "timed_out" || all[3].Outcome != "failed" {
+		t.Fatalf("Query returned %+v", all)
+	}
+	if all[len(all)-1].RequestID != "req-1" {
+		t.Errorf("RequestID = %q, want it taken from the context", all[len(all)-1].RequestID)
+	}
+
+	tests := []struct {
+		name  string
+		query Query
+		want  int
+	}{
+		{"by actor", Query{Actor: "alice"}, 3},
+		{"by action", Query{Action: ActionSave}, 1},
+		{"by challenge and outcome", Query{ChallengeID: 1, Outcome: "passed"}, 1},
+		{"time window", Query{Since: base.Add(time.Minute), Until: base.Add(2 * time.Minute)}, 1},
+		{"limit", Query{Limit: 2}, 2},
+		{"before", Query{Before: 3}, 2},
+	}
+	for _, tt := range tests {
+		got, err := l.Query(tt.query)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if len(got) != tt.want {
+			t.Errorf("%s: got %d events, want %d", tt.name, len(got), tt.want)
+		}
+	}
+}
+
+func TestMemoryLogNewestFirst(t *testing.T) {
+	l, err := Open("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= 5; i++ {
+		l.Record(context.Background(), Event{Action: ActionRun, ChallengeID: i, Outcome: "passed"})
+	}
+
+	got, err := l.Query(Query{Limit: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 || got[0].ChallengeID != 5 || got[2].ChallengeID != 3 {
+		t.Errorf("Query(limit 3) = %+v, want challenges 5, 4, 3", got)
+	}
+}
+
+func TestQueryPagesPastSharedTimes(t *testing.T) {
+	l, err := Open("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for i := 1; i <= 5; i++ {
+		l.Record(context.Background(), Event{Time: at, Action: ActionRun, ChallengeID: i, Outcome: "passed"})
+	}
+
+	var ids []int
+	q := Query{Limit: 2}
+	for {
+		page, err := l.Query(q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, e := range page {
+			ids = append(ids, e.ChallengeID)
+		}
+		if len(page) < q.Limit {
+			break
+		}
+		q.Before = page[len(page)-1].Seq
+	}
+	if len(ids) != 5 || ids[0] != 5 || ids[4] != 1 {
+		t.Errorf("paged by Seq = %v, want challenges 5..1", ids)
+	}
+}
+
+func TestQueryReportsOversizedLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	l, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if err := l.Record(context.Background(), Event{Action: ActionRun, Detail: strings.Repeat("x", store.DefaultMaxLine)}); err != nil {
+		t.Fatal(err)
+	}
+	l.Record(context.Background(), Event{Action: ActionSave})
+
+	if _, err := l.Query(Query{}); !errors.Is(err, bufio.ErrTooLong) {
+		t.Errorf("Query past an oversized line = %v, want bufio.ErrTooLong", err)
+	}
+}
+
+func TestRecordAfterCloseFails(t *testing.T) {
+	l, err := Open(filepath.Join(t.TempDir(), "audit.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Close()
+	if err := l.Record(context.Background(), Event{Action: ActionRun}); err == nil {
+		t.Error("Record after Close succeeded")
+	}
+}
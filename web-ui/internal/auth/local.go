@@ -0,0 +1,176 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"web-ui/internal/paths"
+	"web-ui/internal/store"
+)
+
+const minPasswordLength = 8
+
+// account is a stored local account
+type account struct {
+	Username     string    `json:"username"`
+	Email        string    `json:"email,omitempty"`
+	PasswordHash string    `json:"passwordHash"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// LocalProvider stores password accounts with bcrypt hashes in a JSON file
+type LocalProvider struct {
+	path     string
+	mu       sync.RWMutex
+	accounts map[string]*account
+	reserved []func(username string) bool // names held elsewhere
+}
+
+// NewLocalProvider loads accounts from path, creating an empty store if the
+// file does not exist. An empty path keeps accounts in memory only.
+func NewLocalProvider(path string) (*LocalProvider, error) {
+	lp := &LocalProvider{
+		path:     path,
+		accounts: make(map[string]*account),
+	}
+	if path == "" {
+		return lp, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return lp, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read accounts file: %v", err)
+	}
+
+	var accounts []*account
+	if err := json.Unmarshal(content, &accounts); err != nil {
+		return nil, fmt.Errorf("failed to parse accounts file: %v", err)
+	}
+	for _, acc := range accounts {
+		lp.accounts[strings.ToLower(acc.Username)] = acc
+	}
+	return lp, nil
+}
+
+// Name returns the provider name
+func (lp *LocalProvider) Name() string {
+	return "local"
+}
+
+// Exists reports whether a local account has username, in any case
+func (lp *LocalProvider) Exists(username string) bool {
+	lp.mu.RLock()
+	defer lp.mu.RUnlock()
+	_, ok := lp.accounts[strings.ToLower(username)]
+	return ok
+}
+
+// Reserve keeps Register from creating accounts whose names reserved
+// reports, such as those linked to OIDC identities or owning submissions in
+// the repository. Each call adds to the earlier ones.
+func (lp *LocalProvider) Reserve(reserved func(username string) bool) {
+	lp.mu.Lock()
+	defer lp.mu.Unlock()
+	lp.reserved = append(lp.reserved, reserved)
+}
+
+// isReserved reports whether username is held elsewhere. Callers must hold
+// lp.mu.
+func (lp *LocalProvider) isReserved(username string) bool {
+	for _, reserved := range lp.reserved {
+		if reserved(username) {
+			return true
+		}
+	}
+	return false
+}
+
+// Authenticate checks a username and password against the stored bcrypt hash
+func (lp *LocalProvider) Authenticate(ctx context.Context, username, password string) (*Identity, error) {
+	lp.mu.RLock()
+	acc, ok := lp.accounts[strings.ToLower(username)]
+	lp.mu.RUnlock()
+
+	if !ok {
+		// Compare against a dummy hash so unknown users take as long as known ones
+		bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		return nil, ErrInvalidCredentials
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(acc.PasswordHash), []byte(password)); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	return &Identity{Username: acc.Username, Email: acc.Email, Provider: lp.Name()}, nil
+}
+
+// Register creates a new account and persists the account store
+func (lp *LocalProvider) Register(ctx context.Context, username, email, password string) (*Identity, error) {
+	if err := paths.ValidateUsername(username); err != nil {
+		return nil, err
+	}
+	if len(password) < minPasswordLength {
+		return nil, fmt.Errorf("password must be at least %d characters", minPasswordLength)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %v", err)
+	}
+
+	lp.mu.Lock()
+	defer lp.mu.Unlock()
+
+	key := strings.ToLower(username)
+	if _, exists := lp.accounts[key]; exists || lp.isReserved(username) {
+		return nil, ErrUserExists
+	}
+
+	lp.accounts[key] = &account{
+		Username:     username,
+		Email:        email,
+		PasswordHash: string(hash),
+		CreatedAt:    time.Now().UTC(),
+	}
+	if err := lp.save(); err != nil {
+		delete(lp.accounts, key)
+		return nil, err
+	}
+
+	return &Identity{Username: username, Email: email, Provider: lp.Name()}, nil
+}
+
+// save writes the account store atomically. Callers must hold lp.mu.
+func (lp *LocalProvider) save() error {
+	if lp.path == "" {
+		return nil
+	}
+
+	accounts := make([]*account, 0, len(lp.accounts))
+	for _, acc := range lp.accounts {
+		accounts = append(accounts, acc)
+	}
+
+	content, err := json.MarshalIndent(accounts, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := store.WriteFile(lp.path, content); err != nil {
+		return fmt.Errorf("failed to write accounts file: %v", err)
+	}
+	return nil
+}
+
+// dummyHash is compared against when a username is unknown
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("not-a-real-password"), bcrypt.DefaultCost)
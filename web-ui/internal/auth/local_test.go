@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"web-ui/internal/paths"
+)
+
+func TestLocalProvider(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "accounts.json")
+
+	lp, err := NewLocalProvider(path)
+	if err != nil {
+		t.Fatalf("NewLocalProvider: %v", err)
+	}
+
+	if _, err := lp.Register(ctx, "gopher", "gopher@example.com", "correct horse"); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if _, err := lp.Register(ctx, "Gopher", "", "another password"); !errors.Is(err, ErrUserExists) {
+		t.Errorf("duplicate Register error = %v, want ErrUserExists", err)
+	}
+	if _, err := lp.Register(ctx, "../etc", "", "long enough password"); err == nil {
+		t.Error("Register accepted an invalid username")
+	}
+	if _, err := lp.Register(ctx, "shortpw", "", "short"); err == nil {
+		t.Error("Register accepted a short password")
+	}
+
+	// Accounts persist across provider instances
+	reloaded, err := NewLocalProvider(path)
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	identity, err := reloaded.Authenticate(ctx, "gopher", "correct horse")
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if identity.Username != "gopher" || identity.Provider != "local" {
+		t.Errorf("Authenticate identity = %+v", identity)
+	}
+
+	if _, err := reloaded.Authenticate(ctx, "gopher", "wrong password"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("wrong password error = %v, want ErrInvalidCredentials", err)
+	}
+	if _, err := reloaded.Authenticate(ctx, "nobody", "correct horse"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("unknown user error = %v, want ErrInvalidCredentials", err)
+	}
+}
+
+func TestLocalProviderReservesContributors(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "challenge-1", "submissions", "RezaSi"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	resolver, err := paths.NewResolver(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lp, err := NewLocalProvider("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lp.Reserve(resolver.HasSubmissions)
+	lp.Reserve(func(username string) bool { return username == "linked" })
+
+	// An existing contributor's name cannot be claimed with a password
+	ctx := context.Background()
+	for _, username := range []string{"RezaSi", "rezasi", "linked"} {
+		if _, err := lp.Register(ctx, username, "", "correct horse"); !errors.Is(err, ErrUserExists) {
+			t.Errorf("Register(%q) error = %v, want ErrUserExists", username, err)
+		}
+	}
+	if _, err := lp.Register(ctx, "newcomer", "", "correct horse"); err != nil {
+		t.Errorf("Register(newcomer): %v", err)
+	}
+}
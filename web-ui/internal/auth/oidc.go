@@ -0,0 +1,453 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"web-ui/internal/paths"
+	"web-ui/internal/store"
+)
+
+// OIDCConfig configures a generic OpenID Connect provider
+type OIDCConfig struct {
+	Name          string // provider name used in URLs, defaults to "oidc"
+	IssuerURL     string // issuer used for discovery and "iss" validation
+	ClientID      string
+	ClientSecret  string
+	RedirectURL   string   // e.g. http://localhost:8080/auth/oidc/callback
+	Scopes        []string // defaults to openid, profile, email
+	UsernameClaim string   // defaults to preferred_username
+	LinksPath     string   // where identities are linked to usernames; empty keeps links in memory
+	// UsernameTaken reports names held by other accounts, such as local
+	// ones, which identities from the issuer cannot claim
+	UsernameTaken func(username string) bool
+}
+
+// oidcDiscovery is the subset of the discovery document we rely on
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// oidcLink ties an identity at the issuer to the local username it claimed
+// when it first signed in
+type oidcLink struct {
+	Issuer   string    `json:"issuer"`
+	Subject  string    `json:"subject"`
+	Username string    `json:"username"`
+	LinkedAt time.Time `json:"linkedAt"`
+}
+
+// oidcLinkKey identifies an identity: subjects are only unique per issuer
+type oidcLinkKey struct {
+	issuer, subject string
+}
+
+// OIDCProvider implements the authorization code flow against any OIDC
+// issuer. Identities are keyed on issuer and subject: the first sign-in
+// links one to the username it claims, and later sign-ins keep that name
+// whatever the claim says by then.
+type OIDCProvider struct {
+	config     OIDCConfig
+	discovery  oidcDiscovery
+	httpClient *http.Client
+
+	mu   sync.RWMutex
+	keys map[string]*rsa.PublicKey
+
+	linksMu sync.Mutex
+	links   map[oidcLinkKey]*oidcLink
+	names   map[string]bool // lowercase linked usernames
+}
+
+// NewOIDCProvider fetches the issuer's discovery document and returns a ready provider
+func NewOIDCProvider(ctx context.Context, config OIDCConfig, httpClient *http.Client) (*OIDCProvider, error) {
+	if config.Name == "" {
+		config.Name = "oidc"
+	}
+	if len(config.Scopes) == 0 {
+		config.Scopes = []string{"openid", "profile", "email"}
+	}
+	if config.UsernameClaim == "" {
+		config.UsernameClaim = "preferred_username"
+	}
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	op := &OIDCProvider{
+		config:     config,
+		httpClient: httpClient,
+		keys:       make(map[string]*rsa.PublicKey),
+		links:      make(map[oidcLinkKey]*oidcLink),
+		names:      make(map[string]bool),
+	}
+	if err := op.loadLinks(); err != nil {
+		return nil, err
+	}
+
+	discoveryURL := strings.TrimSuffix(config.IssuerURL, "/") + "/.well-known/openid-configuration"
+	if err := op.getJSON(ctx, discoveryURL, &op.discovery); err != nil {
+		return nil, fmt.Errorf("oidc discovery failed: %v", err)
+	}
+	if op.discovery.Issuer != config.IssuerURL {
+		return nil, fmt.Errorf("oidc discovery issuer mismatch: got %q, want %q", op.discovery.Issuer, config.IssuerURL)
+	}
+
+	return op, nil
+}
+
+// Name returns the provider name
+func (op *OIDCProvider) Name() string {
+	return op.config.Name
+}
+
+// AuthCodeURL returns the authorization endpoint URL for the given state and nonce
+func (op *OIDCProvider) AuthCodeURL(state, nonce string) string {
+	params := url.Values{
+		"response_type": {"code"},
+		"client_id":     {op.config.ClientID},
+		"redirect_uri":  {op.config.RedirectURL},
+		"scope":         {strings.Join(op.config.Scopes, " ")},
+		"state":         {state},
+		"nonce":         {nonce},
+	}
+
+	sep := "?"
+	if strings.Contains(op.discovery.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return op.discovery.AuthorizationEndpoint + sep + params.Encode()
+}
+
+// Exchange redeems an authorization code and verifies the returned ID token
+func (op *OIDCProvider) Exchange(ctx context.Context, code, nonce string) (*Identity, error) {
+	form := url.Values{
+		"grant_type":   {"authorization_code"},
+		"code":         {code},
+		"redirect_uri": {op.config.RedirectURL},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, op.discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(op.config.ClientID), url.QueryEscape(op.config.ClientSecret))
+
+	resp, err := op.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("token request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("token endpoint returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var token struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return nil, fmt.Errorf("failed to decode token response: %v", err)
+	}
+	if token.IDToken == "" {
+		return nil, errors.New("token response did not contain an id_token")
+	}
+
+	claims, err := op.verifyIDToken(ctx, token.IDToken)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Nonce != nonce {
+		return nil, errors.New("id_token nonce mismatch")
+	}
+
+	if claims.Subject == "" {
+		return nil, errors.New("id_token is missing the \"sub\" claim")
+	}
+	claimed, _ := claims.raw[op.config.UsernameClaim].(string)
+	if claimed == "" {
+		return nil, fmt.Errorf("id_token is missing the %q claim", op.config.UsernameClaim)
+	}
+	username, err := op.link(claims.Subject, claimed)
+	if err != nil {
+		return nil, err
+	}
+	email, _ := claims.raw["email"].(string)
+
+	return &Identity{Username: username, Email: email, Provider: op.Name()}, nil
+}
+
+// Linked reports whether an identity from the issuer signed in as username
+func (op *OIDCProvider) Linked(username string) bool {
+	op.linksMu.Lock()
+	defer op.linksMu.Unlock()
+	return op.names[strings.ToLower(username)]
+}
+
+// link returns the username of the issuer's subject, linking it to claimed
+// on its first sign-in. A claimed name must be a valid username that no
+// other identity or account holds.
+func (op *OIDCProvider) link(subject, claimed string) (string, error) {
+	op.linksMu.Lock()
+	defer op.linksMu.Unlock()
+
+	key := oidcLinkKey{op.config.IssuerURL, subject}
+	if l, ok := op.links[key]; ok {
+		return l.Username, nil
+	}
+	if err := paths.ValidateUsername(claimed); err != nil {
+		return "", fmt.Errorf("the %q claim is not a valid username: %w", op.config.UsernameClaim, err)
+	}
+	lower := strings.ToLower(claimed)
+	if op.names[lower] || (op.config.UsernameTaken != nil && op.config.UsernameTaken(claimed)) {
+		return "", fmt.Errorf("%w: %q", ErrUsernameTaken, claimed)
+	}
+
+	op.links[key] = &oidcLink{Issuer: key.issuer, Subject: subject, Username: claimed, LinkedAt: time.Now().UTC()}
+	op.names[lower] = true
+	if err := op.saveLinks(); err != nil {
+		delete(op.links, key)
+		delete(op.names, lower)
+		return "", err
+	}
+	return claimed, nil
+}
+
+// loadLinks reads the links stored at LinksPath, if any
+func (op *OIDCProvider) loadLinks() error {
+	if op.config.LinksPath == "" {
+		return nil
+	}
+	content, err := os.ReadFile(op.config.LinksPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read OIDC links file: %v", err)
+	}
+	var links []*oidcLink
+	if err := json.Unmarshal(content, &links); err != nil {
+		return fmt.Errorf("failed to parse OIDC links file: %v", err)
+	}
+	for _, l := range links {
+		op.links[oidcLinkKey{l.Issuer, l.Subject}] = l
+		op.names[strings.ToLower(l.Username)] = true
+	}
+	return nil
+}
+
+// saveLinks writes the links atomically. Callers must hold op.linksMu.
+func (op *OIDCProvider) saveLinks() error {
+	if op.config.LinksPath == "" {
+		return nil
+	}
+	links := make([]*oidcLink, 0, len(op.links))
+	for _, l := range op.links {
+		links = append(links, l)
+	}
+	content, err := json.MarshalIndent(links, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := store.WriteFile(op.config.LinksPath, content); err != nil {
+		return fmt.Errorf("failed to write OIDC links file: %v", err)
+	}
+	return nil
+}
+
+// idTokenClaims are the registered claims checked during verification
+type idTokenClaims struct {
+	Issuer    string   `json:"iss"`
+	Subject   string   `json:"sub"`
+	Audience  audience `json:"aud"`
+	ExpiresAt int64    `json:"exp"`
+	IssuedAt  int64    `json:"iat"`
+	Nonce     string   `json:"nonce"`
+	raw       map[string]interface{}
+}
+
+// audience accepts both the string and array forms of the "aud" claim
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return err
+	}
+	*a = multiple
+	return nil
+}
+
+// verifyIDToken checks the RS256 signature and registered claims of a JWT
+func (op *OIDCProvider) verifyIDToken(ctx context.Context, rawToken string) (*idTokenClaims, error) {
+	parts := strings.Split(rawToken, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed id_token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("malformed id_token header: %v", err)
+	}
+	if header.Alg != "RS256" {
+		return nil, fmt.Errorf("unsupported id_token algorithm %q", header.Alg)
+	}
+
+	key, err := op.publicKey(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.New("malformed id_token signature")
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+		return nil, errors.New("invalid id_token signature")
+	}
+
+	var claims idTokenClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("malformed id_token claims: %v", err)
+	}
+	if err := decodeSegment(parts[1], &claims.raw); err != nil {
+		return nil, fmt.Errorf("malformed id_token claims: %v", err)
+	}
+
+	if claims.Issuer != op.config.IssuerURL {
+		return nil, fmt.Errorf("unexpected id_token issuer %q", claims.Issuer)
+	}
+	if !claims.Audience.contains(op.config.ClientID) {
+		return nil, errors.New("id_token audience does not include this client")
+	}
+	if time.Now().Unix() >= claims.ExpiresAt {
+		return nil, errors.New("id_token has expired")
+	}
+
+	return &claims, nil
+}
+
+func (a audience) contains(clientID string) bool {
+	for _, aud := range a {
+		if aud == clientID {
+			return true
+		}
+	}
+	return false
+}
+
+// publicKey returns the signing key for kid, refreshing the JWKS on a miss
+func (op *OIDCProvider) publicKey(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	op.mu.RLock()
+	key, ok := op.keys[kid]
+	op.mu.RUnlock()
+	if ok {
+		return key, nil
+	}
+
+	if err := op.refreshKeys(ctx); err != nil {
+		return nil, err
+	}
+
+	op.mu.RLock()
+	defer op.mu.RUnlock()
+	if key, ok := op.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("no signing key found for kid %q", kid)
+}
+
+// refreshKeys downloads the issuer's JSON Web Key Set
+func (op *OIDCProvider) refreshKeys(ctx context.Context) error {
+	var jwks struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := op.getJSON(ctx, op.discovery.JWKSURI, &jwks); err != nil {
+		return fmt.Errorf("failed to fetch jwks: %v", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, k := range jwks.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			continue
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	op.mu.Lock()
+	op.keys = keys
+	op.mu.Unlock()
+	return nil
+}
+
+// getJSON fetches url and decodes the JSON response into v
+func (op *OIDCProvider) getJSON(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := op.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s returned %d", url, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// decodeSegment decodes a base64url JWT segment into v
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
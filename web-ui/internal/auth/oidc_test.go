@@ -0,0 +1,272 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mockIdP is a minimal OpenID Connect provider for tests
+type mockIdP struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu     sync.Mutex
+	codes  map[string]string // code -> nonce
+	claims map[string]interface{}
+}
+
+func newMockIdP(t *testing.T) *mockIdP {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+
+	idp := &mockIdP{key: key, codes: make(map[string]string)}
+	mux := http.NewServeMux()
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 idp.server.URL,
+			"authorization_endpoint": idp.server.URL + "/authorize",
+			"token_endpoint":         idp.server.URL + "/token",
+			"jwks_uri":               idp.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/authorize", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		idp.mu.Lock()
+		code := "code-" + q.Get("state")[:8]
+		idp.codes[code] = q.Get("nonce")
+		idp.mu.Unlock()
+		http.Redirect(w, r, q.Get("redirect_uri")+"?code="+code+"&state="+url.QueryEscape(q.Get("state")), http.StatusFound)
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		clientID, secret, _ := r.BasicAuth()
+		if clientID != "web-ui" || secret != "s3cret" {
+			http.Error(w, `{"error":"invalid_client"}`, http.StatusUnauthorized)
+			return
+		}
+		idp.mu.Lock()
+		nonce, ok := idp.codes[r.PostFormValue("code")]
+		delete(idp.codes, r.PostFormValue("code"))
+		idp.mu.Unlock()
+		if !ok {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+
+		claims := map[string]interface{}{
+			"iss":                idp.server.URL,
+			"sub":                "1234",
+			"aud":                "web-ui",
+			"exp":                time.Now().Add(time.Hour).Unix(),
+			"iat":                time.Now().Unix(),
+			"nonce":              nonce,
+			"preferred_username": "gopher",
+			"email":              "gopher@example.com",
+		}
+		for k, v := range idp.claims {
+			claims[k] = v
+		}
+		json.NewEncoder(w).Encode(map[string]string{"id_token": idp.sign(t, claims)})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "test-key",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+
+	return idp
+}
+
+// sign produces an RS256 JWT for claims
+func (idp *mockIdP) sign(t *testing.T, claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "test-key", "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	digest := sha256.Sum256([]byte(signingInput))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, idp.key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatalf("SignPKCS1v15: %v", err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+// authorize follows the provider's auth URL and returns the code and state it redirects with
+func (idp *mockIdP) authorize(t *testing.T, authURL string) (code, state string) {
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatalf("authorize: %v", err)
+	}
+	resp.Body.Close()
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatalf("parse redirect: %v", err)
+	}
+	return location.Query().Get("code"), location.Query().Get("state")
+}
+
+func newTestOIDCProvider(t *testing.T, idp *mockIdP) *OIDCProvider {
+	t.Helper()
+	return newLinkedOIDCProvider(t, idp, "", nil)
+}
+
+func newLinkedOIDCProvider(t *testing.T, idp *mockIdP, linksPath string, taken func(string) bool) *OIDCProvider {
+	t.Helper()
+	op, err := NewOIDCProvider(context.Background(), OIDCConfig{
+		IssuerURL:     idp.server.URL,
+		ClientID:      "web-ui",
+		ClientSecret:  "s3cret",
+		RedirectURL:   "http://localhost:8080/auth/oidc/callback",
+		LinksPath:     linksPath,
+		UsernameTaken: taken,
+	}, idp.server.Client())
+	if err != nil {
+		t.Fatalf("NewOIDCProvider: %v", err)
+	}
+	return op
+}
+
+func TestOIDCProviderExchange(t *testing.T) {
+	idp := newMockIdP(t)
+	op := newTestOIDCProvider(t, idp)
+
+	authURL := op.AuthCodeURL("state-0123456789", "nonce-abc")
+	if !strings.HasPrefix(authURL, idp.server.URL+"/authorize?") {
+		t.Fatalf("AuthCodeURL = %q", authURL)
+	}
+
+	code, state := idp.authorize(t, authURL)
+	if state != "state-0123456789" {
+		t.Errorf("state = %q", state)
+	}
+
+	identity, err := op.Exchange(context.Background(), code, "nonce-abc")
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if identity.Username != "gopher" || identity.Email != "gopher@example.com" || identity.Provider != "oidc" {
+		t.Errorf("identity = %+v", identity)
+	}
+
+	// Codes are single use
+	if _, err := op.Exchange(context.Background(), code, "nonce-abc"); err == nil {
+		t.Error("Exchange accepted a reused code")
+	}
+}
+
+func TestOIDCProviderRejectsInvalidTokens(t *testing.T) {
+	tests := []struct {
+		name   string
+		claims map[string]interface{}
+		nonce  string
+	}{
+		{"wrong nonce", nil, "other-nonce"},
+		{"expired", map[string]interface{}{"exp": time.Now().Add(-time.Minute).Unix()}, "nonce-abc"},
+		{"wrong audience", map[string]interface{}{"aud": "someone-else"}, "nonce-abc"},
+		{"wrong issuer", map[string]interface{}{"iss": "https://evil.example"}, "nonce-abc"},
+		{"missing username", map[string]interface{}{"preferred_username": ""}, "nonce-abc"},
+		{"missing subject", map[string]interface{}{"sub": ""}, "nonce-abc"},
+		{"invalid username", map[string]interface{}{"preferred_username": "../admin"}, "nonce-abc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idp := newMockIdP(t)
+			idp.claims = tt.claims
+			op := newTestOIDCProvider(t, idp)
+
+			code, _ := idp.authorize(t, op.AuthCodeURL("state-0123456789", "nonce-abc"))
+			if _, err := op.Exchange(context.Background(), code, tt.nonce); err == nil {
+				t.Error("Exchange succeeded, want error")
+			}
+		})
+	}
+}
+
+// signIn runs the whole flow as the subject sub claiming username
+func (idp *mockIdP) signIn(t *testing.T, op *OIDCProvider, sub, username string) (*Identity, error) {
+	t.Helper()
+	idp.mu.Lock()
+	idp.claims = map[string]interface{}{"sub": sub, "preferred_username": username}
+	idp.mu.Unlock()
+	code, _ := idp.authorize(t, op.AuthCodeURL("state-0123456789", "nonce-abc"))
+	return op.Exchange(context.Background(), code, "nonce-abc")
+}
+
+func TestOIDCProviderLinksIdentities(t *testing.T) {
+	idp := newMockIdP(t)
+	linksPath := t.TempDir() + "/oidc-links.json"
+	local, err := NewLocalProvider("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := local.Register(context.Background(), "admin", "", "password1"); err != nil {
+		t.Fatal(err)
+	}
+	op := newLinkedOIDCProvider(t, idp, linksPath, local.Exists)
+	local.Reserve(op.Linked)
+
+	// Local accounts cannot be taken over by claiming their name
+	if _, err := idp.signIn(t, op, "1", "Admin"); !errors.Is(err, ErrUsernameTaken) {
+		t.Errorf("claiming a local account's name = %v, want ErrUsernameTaken", err)
+	}
+
+	if identity, err := idp.signIn(t, op, "1", "gopher"); err != nil || identity.Username != "gopher" {
+		t.Fatalf("first sign-in = %+v, %v", identity, err)
+	}
+	// Nor can another identity claim a linked name
+	if _, err := idp.signIn(t, op, "2", "gopher"); !errors.Is(err, ErrUsernameTaken) {
+		t.Errorf("claiming a linked name = %v, want ErrUsernameTaken", err)
+	}
+	if _, err := local.Register(context.Background(), "GOPHER", "", "password1"); !errors.Is(err, ErrUserExists) {
+		t.Errorf("registering a linked name = %v, want ErrUserExists", err)
+	}
+
+	// The subject keeps its name, across restarts, whatever it claims later
+	op = newLinkedOIDCProvider(t, idp, linksPath, local.Exists)
+	if identity, err := idp.signIn(t, op, "1", "admin"); err != nil || identity.Username != "gopher" {
+		t.Errorf("later sign-in = %+v, %v, want gopher", identity, err)
+	}
+}
+
+func TestOIDCProviderRejectsForeignSignature(t *testing.T) {
+	idp := newMockIdP(t)
+	op := newTestOIDCProvider(t, idp)
+
+	// A token signed by a different key with the same kid must be rejected
+	impostor := newMockIdP(t)
+	token := impostor.sign(t, map[string]interface{}{
+		"iss": idp.server.URL, "aud": "web-ui", "exp": time.Now().Add(time.Hour).Unix(),
+	})
+	if _, err := op.verifyIDToken(context.Background(), token); err == nil {
+		t.Error("verifyIDToken accepted a token signed by another key")
+	}
+}
@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	// ErrInvalidCredentials is returned when a username/password pair does not match
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	// ErrUserExists is returned when registering a username that is already taken
+	ErrUserExists = errors.New("user already exists")
+	// ErrUsernameTaken is returned when an identity from an OIDC issuer
+	// claims a username that belongs to another account
+	ErrUsernameTaken = errors.New("username belongs to another account")
+)
+
+// Identity is the result of a successful authentication
+type Identity struct {
+	Username string
+	Email    string
+	Provider string
+}
+
+// Provider is an authentication backend that can be registered with the server
+type Provider interface {
+	// Name returns the provider's identifier, used in URLs and sessions
+	Name() string
+}
+
+// PasswordProvider authenticates users with a username and password
+type PasswordProvider interface {
+	Provider
+	Authenticate(ctx context.Context, username, password string) (*Identity, error)
+	Register(ctx context.Context, username, email, password string) (*Identity, error)
+}
+
+// RedirectProvider authenticates users through a browser redirect flow such as OIDC
+type RedirectProvider interface {
+	Provider
+	// AuthCodeURL returns the URL the browser is sent to, bound to state and nonce
+	AuthCodeURL(state, nonce string) string
+	// Exchange trades an authorization code for a verified identity
+	Exchange(ctx context.Context, code, nonce string) (*Identity, error)
+}
+
+// Registry holds the configured providers by name
+type Registry struct {
+	providers map[string]Provider
+	order     []string
+}
+
+// NewRegistry creates a registry from the given providers
+func NewRegistry(providers ...Provider) *Registry {
+	reg := &Registry{providers: make(map[string]Provider)}
+	for _, p := range providers {
+		reg.Register(p)
+	}
+	return reg
+}
+
+// Register adds a provider, replacing any provider with the same name
+func (reg *Registry) Register(p Provider) {
+	if _, exists := reg.providers[p.Name()]; !exists {
+		reg.order = append(reg.order, p.Name())
+	}
+	reg.providers[p.Name()] = p
+}
+
+// Get returns the provider with the given name
+func (reg *Registry) Get(name string) (Provider, bool) {
+	p, ok := reg.providers[name]
+	return p, ok
+}
+
+// Names returns provider names in registration order
+func (reg *Registry) Names() []string {
+	return append([]string(nil), reg.order...)
+}
+
+// Password returns the first registered password provider
+func (reg *Registry) Password() (PasswordProvider, bool) {
+	for _, name := range reg.order {
+		if p, ok := reg.providers[name].(PasswordProvider); ok {
+			return p, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,374 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// SessionCookieName is the name of the signed session cookie
+	SessionCookieName = "session"
+	// CSRFCookieName is the name of the double-submit CSRF cookie
+	CSRFCookieName = "csrf_token"
+	// CSRFHeaderName is the header clients echo the CSRF token in
+	CSRFHeaderName = "X-CSRF-Token"
+	// CSRFFormField is the form field accepted as an alternative to the header
+	CSRFFormField = "csrf_token"
+
+	defaultSessionTTL = 30 * 24 * time.Hour
+
+	// csrfPrefix separates CSRF token payloads from other signed values
+	csrfPrefix = "csrf:"
+)
+
+// ErrInvalidSession is returned when a session cookie is missing, tampered with or expired
+var ErrInvalidSession = errors.New("invalid session")
+
+// Session is the signed-in identity carried in the session cookie
+type Session struct {
+	Username  string    `json:"u"`
+	Provider  string    `json:"p"`
+	ExpiresAt time.Time `json:"e"`
+}
+
+// SessionManager issues and verifies HMAC-signed session and CSRF cookies
+type SessionManager struct {
+	secret []byte
+	ttl    time.Duration
+	secure bool
+}
+
+// NewSessionManager creates a session manager. An empty secret generates a
+// random one, which means sessions do not survive a server restart.
+func NewSessionManager(secret []byte, secure bool) (*SessionManager, error) {
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, err
+		}
+	}
+	return &SessionManager{
+		secret: secret,
+		ttl:    defaultSessionTTL,
+		secure: secure,
+	}, nil
+}
+
+// Create signs a new session for username and sets it as an HttpOnly cookie
+func (sm *SessionManager) Create(w http.ResponseWriter, username, provider string) error {
+	session := Session{
+		Username:  username,
+		Provider:  provider,
+		ExpiresAt: time.Now().Add(sm.ttl).UTC(),
+	}
+
+	payload, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookieName,
+		Value:    sm.sign(payload),
+		Path:     "/",
+		Expires:  session.ExpiresAt,
+		HttpOnly: true,
+		Secure:   sm.secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return nil
+}
+
+// Get returns the verified session attached to the request
+func (sm *SessionManager) Get(r *http.Request) (*Session, error) {
+	cookie, err := r.Cookie(SessionCookieName)
+	if err != nil {
+		return nil, ErrInvalidSession
+	}
+
+	payload, ok := sm.verify(cookie.Value)
+	if !ok {
+		return nil, ErrInvalidSession
+	}
+
+	var session Session
+	if err := json.Unmarshal(payload, &session); err != nil {
+		return nil, ErrInvalidSession
+	}
+	if session.Username == "" || time.Now().After(session.ExpiresAt) {
+		return nil, ErrInvalidSession
+	}
+	return &session, nil
+}
+
+// Username returns the signed-in username, or "" for anonymous requests
+func (sm *SessionManager) Username(r *http.Request) string {
+	session, err := sm.Get(r)
+	if err != nil {
+		return ""
+	}
+	return session.Username
+}
+
+// Destroy clears the session cookie
+func (sm *SessionManager) Destroy(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookieName,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   sm.secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// CSRFToken returns the request's CSRF token, issuing a new cookie if none is present.
+// The token is signed so a cookie planted by a sibling domain is rejected.
+func (sm *SessionManager) CSRFToken(w http.ResponseWriter, r *http.Request) string {
+	if cookie, err := r.Cookie(CSRFCookieName); err == nil && sm.isCSRFToken(cookie.Value) {
+		return cookie.Value
+	}
+
+	nonce, err := randomToken()
+	if err != nil {
+		return ""
+	}
+	token := sm.sign([]byte(csrfPrefix + nonce))
+
+	// Not HttpOnly: the front-end reads it and echoes it in the X-CSRF-Token header
+	http.SetCookie(w, &http.Cookie{
+		Name:     CSRFCookieName,
+		Value:    token,
+		Path:     "/",
+		Secure:   sm.secure,
+		SameSite: http.SameSiteStrictMode,
+	})
+	return token
+}
+
+// CSRFMiddleware issues CSRF cookies and rejects unsafe requests whose
+// X-CSRF-Token header (or csrf_token form field) does not match the cookie
+func (sm *SessionManager) CSRFMiddleware(next http.Handler) http.Handler {
+	return sm.CSRFProtect(next, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Invalid or missing CSRF token", http.StatusForbidden)
+	})
+}
+
+// CSRFProtect is CSRFMiddleware with a custom response for rejected requests
+func (sm *SessionManager) CSRFProtect(next http.Handler, reject http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+			sm.CSRFToken(w, r)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !sm.validCSRF(r) {
+			reject(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// validCSRF checks the submitted token against the signed CSRF cookie
+func (sm *SessionManager) validCSRF(r *http.Request) bool {
+	cookie, err := r.Cookie(CSRFCookieName)
+	if err != nil || !sm.isCSRFToken(cookie.Value) {
+		return false
+	}
+
+	submitted := r.Header.Get(CSRFHeaderName)
+	if submitted == "" && strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		submitted = r.PostFormValue(CSRFFormField)
+	}
+	return subtle.ConstantTimeCompare([]byte(submitted), []byte(cookie.Value)) == 1
+}
+
+// isCSRFToken reports whether value is a CSRF token signed by this manager
+func (sm *SessionManager) isCSRFToken(value string) bool {
+	payload, ok := sm.verify(value)
+	return ok && strings.HasPrefix(string(payload), csrfPrefix)
+}
+
+// sign returns base64(payload) + "." + base64(HMAC-SHA256(payload))
+func (sm *SessionManager) sign(payload []byte) string {
+	mac := hmac.New(sha256.New, sm.secret)
+	mac.Write(payload)
+	return base64.RawURLEncoding.EncodeToString(payload) + "." +
+		base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// verify checks a value produced by sign and returns its payload
+func (sm *SessionManager) verify(value string) ([]byte, bool) {
+	encodedPayload, encodedSig, found := strings.Cut(value, ".")
+	if !found {
+		return nil, false
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return nil, false
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(encodedSig)
+	if err != nil {
+		return nil, false
+	}
+
+	mac := hmac.New(sha256.New, sm.secret)
+	mac.Write(payload)
+	if !hmac.Equal(sig, mac.Sum(nil)) {
+		return nil, false
+	}
+	return payload, true
+}
+
+// InterviewCookieName is the name of the signed cookie that admits a
+// candidate to a mock interview
+const InterviewCookieName = "interview"
+
+// candidateAdmission is the payload of the interview cookie
+type candidateAdmission struct {
+	Interview string    `json:"i"`
+	Expires   time.Time `json:"e"`
+}
+
+// AdmitCandidate sets a cookie admitting the browser to an interview's
+// workspace until expires. Candidates need no account.
+func (sm *SessionManager) AdmitCandidate(w http.ResponseWriter, interviewID string, expires time.Time) error {
+	payload, err := json.Marshal(candidateAdmission{Interview: interviewID, Expires: expires.UTC()})
+	if err != nil {
+		return err
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     InterviewCookieName,
+		Value:    sm.sign(payload),
+		Path:     "/",
+		Expires:  expires,
+		HttpOnly: true,
+		Secure:   sm.secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return nil
+}
+
+// Candidate returns the interview the request's browser was admitted to, or
+// "" if it was admitted to none or the admission has expired
+func (sm *SessionManager) Candidate(r *http.Request) string {
+	cookie, err := r.Cookie(InterviewCookieName)
+	if err != nil {
+		return ""
+	}
+	payload, ok := sm.verify(cookie.Value)
+	if !ok {
+		return ""
+	}
+
+	var admission candidateAdmission
+	if err := json.Unmarshal(payload, &admission); err != nil {
+		return ""
+	}
+	if time.Now().After(admission.Expires) {
+		return ""
+	}
+	return admission.Interview
+}
+
+const flowCookieName = "auth_flow"
+
+// flowState binds a redirect login to the browser that started it
+type flowState struct {
+	Provider string    `json:"p"`
+	State    string    `json:"s"`
+	Nonce    string    `json:"n"`
+	Expires  time.Time `json:"e"`
+}
+
+// BeginFlow generates state and nonce values for a redirect login and stores
+// them in a short-lived signed cookie
+func (sm *SessionManager) BeginFlow(w http.ResponseWriter, provider string) (state, nonce string, err error) {
+	state, err = randomToken()
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err = randomToken()
+	if err != nil {
+		return "", "", err
+	}
+
+	payload, err := json.Marshal(flowState{
+		Provider: provider,
+		State:    state,
+		Nonce:    nonce,
+		Expires:  time.Now().Add(10 * time.Minute).UTC(),
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     flowCookieName,
+		Value:    sm.sign(payload),
+		Path:     "/auth/",
+		MaxAge:   600,
+		HttpOnly: true,
+		Secure:   sm.secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return state, nonce, nil
+}
+
+// FinishFlow validates the state returned by the provider and returns the nonce
+// the ID token must carry. The flow cookie is cleared either way.
+func (sm *SessionManager) FinishFlow(w http.ResponseWriter, r *http.Request, provider, state string) (string, error) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     flowCookieName,
+		Value:    "",
+		Path:     "/auth/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   sm.secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	cookie, err := r.Cookie(flowCookieName)
+	if err != nil {
+		return "", errors.New("login flow not started")
+	}
+	payload, ok := sm.verify(cookie.Value)
+	if !ok {
+		return "", errors.New("login flow cookie is invalid")
+	}
+
+	var flow flowState
+	if err := json.Unmarshal(payload, &flow); err != nil {
+		return "", errors.New("login flow cookie is invalid")
+	}
+	if flow.Provider != provider || time.Now().After(flow.Expires) {
+		return "", errors.New("login flow has expired")
+	}
+	if subtle.ConstantTimeCompare([]byte(flow.State), []byte(state)) != 1 {
+		return "", errors.New("login flow state mismatch")
+	}
+	return flow.Nonce, nil
+}
+
+// randomToken returns 32 random bytes encoded as base64url
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,135 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestSessionManager(t *testing.T) *SessionManager {
+	t.Helper()
+	sm, err := NewSessionManager([]byte("test-secret"), false)
+	if err != nil {
+		t.Fatalf("NewSessionManager: %v", err)
+	}
+	return sm
+}
+
+func TestSessionRoundTrip(t *testing.T) {
+	sm := newTestSessionManager(t)
+
+	rec := httptest.NewRecorder()
+	if err := sm.Create(rec, "gopher", "local"); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	cookie := rec.Result().Cookies()[0]
+	if !cookie.HttpOnly {
+		t.Error("session cookie must be HttpOnly")
+	}
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(cookie)
+	if got := sm.Username(req); got != "gopher" {
+		t.Errorf("Username() = %q, want %q", got, "gopher")
+	}
+}
+
+func TestSessionRejectsTamperedCookie(t *testing.T) {
+	sm := newTestSessionManager(t)
+
+	rec := httptest.NewRecorder()
+	sm.Create(rec, "gopher", "local")
+	cookie := rec.Result().Cookies()[0]
+
+	// Re-sign a different payload with another secret
+	other, _ := NewSessionManager([]byte("other-secret"), false)
+	_, sig, _ := strings.Cut(cookie.Value, ".")
+	forged := other.sign([]byte(`{"u":"admin","p":"local","e":"2999-01-01T00:00:00Z"}`))
+	payload, _, _ := strings.Cut(forged, ".")
+
+	tests := map[string]string{
+		"forged payload":  payload + "." + sig,
+		"other secret":    forged,
+		"plain username":  "admin",
+		"empty signature": payload + ".",
+	}
+	for name, value := range tests {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.AddCookie(&http.Cookie{Name: SessionCookieName, Value: value})
+		if got := sm.Username(req); got != "" {
+			t.Errorf("%s: Username() = %q, want empty", name, got)
+		}
+	}
+}
+
+func TestCandidateAdmission(t *testing.T) {
+	sm := newTestSessionManager(t)
+
+	rec := httptest.NewRecorder()
+	if err := sm.AdmitCandidate(rec, "abc123", time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("AdmitCandidate: %v", err)
+	}
+	req := httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(rec.Result().Cookies()[0])
+	if got := sm.Candidate(req); got != "abc123" {
+		t.Errorf("Candidate() = %q, want %q", got, "abc123")
+	}
+
+	rec = httptest.NewRecorder()
+	sm.AdmitCandidate(rec, "abc123", time.Now().Add(-time.Minute))
+	req = httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(&http.Cookie{Name: InterviewCookieName, Value: rec.Result().Cookies()[0].Value})
+	if got := sm.Candidate(req); got != "" {
+		t.Errorf("Candidate() with an expired admission = %q, want none", got)
+	}
+
+	// A session cookie is not an admission
+	rec = httptest.NewRecorder()
+	sm.Create(rec, "gopher", "local")
+	req = httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(&http.Cookie{Name: InterviewCookieName, Value: rec.Result().Cookies()[0].Value})
+	if got := sm.Candidate(req); got != "" {
+		t.Errorf("Candidate() with a session cookie = %q, want none", got)
+	}
+}
+
+func TestCSRFMiddleware(t *testing.T) {
+	sm := newTestSessionManager(t)
+	handler := sm.CSRFMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	// A safe request issues the token cookie
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	token := rec.Result().Cookies()[0]
+
+	tests := []struct {
+		name   string
+		cookie *http.Cookie
+		header string
+		want   int
+	}{
+		{"matching token", token, token.Value, http.StatusNoContent},
+		{"missing header", token, "", http.StatusForbidden},
+		{"mismatched header", token, "bogus", http.StatusForbidden},
+		{"missing cookie", nil, token.Value, http.StatusForbidden},
+		{"unsigned cookie", &http.Cookie{Name: CSRFCookieName, Value: "abc"}, "abc", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("POST", "/api/run", nil)
+		if tt.cookie != nil {
+			req.AddCookie(tt.cookie)
+		}
+		if tt.header != "" {
+			req.Header.Set(CSRFHeaderName, tt.header)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, rec.Code, tt.want)
+		}
+	}
+}
@@ -0,0 +1,241 @@
+// Package badge renders shields.io-style SVG badges: a grey label on the
+// left and a coloured message on the right, in the common shields styles.
+package badge
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// ContentType is the content type of rendered badges
+const ContentType = "image/svg+xml; charset=utf-8"
+
+// MaxTextLength bounds the label and message, in characters; longer text is
+// cut short with an ellipsis
+const MaxTextLength = 64
+
+// Styles
+const (
+	Flat        = "flat"
+	FlatSquare  = "flat-square"
+	Plastic     = "plastic"
+	ForTheBadge = "for-the-badge"
+)
+
+// Styles lists the supported styles, the default first
+var Styles = []string{Flat, FlatSquare, Plastic, ForTheBadge}
+
+// Colors are the shields.io named colors
+var Colors = map[string]string{
+	"brightgreen": "#4c1",
+	"green":       "#97ca00",
+	"yellowgreen": "#a4a61d",
+	"yellow":      "#dfb317",
+	"orange":      "#fe7d37",
+	"red":         "#e05d44",
+	"blue":        "#007ec6",
+	"lightgrey":   "#9f9f9f",
+	"grey":        "#555",
+}
+
+var (
+	// ErrUnknownStyle is returned for a style not in Styles
+	ErrUnknownStyle = errors.New("unknown badge style")
+	// ErrInvalidColor is returned for a color that is neither a named color
+	// nor 3 or 6 hex digits
+	ErrInvalidColor = errors.New("invalid badge color")
+)
+
+var hexColorRe = regexp.MustCompile(`^#?([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// Badge describes a badge. Label and Message are plain text and escaped when
+// rendered, so they may come from users.
+type Badge struct {
+	Label      string
+	Message    string
+	Color      string // message background: a name from Colors or hex digits
+	LabelColor string // label background, grey when empty
+	Style      string // one of Styles, flat when empty
+}
+
+// ParseColor resolves a named color or hex digits, with or without "#", to a
+// CSS color
+func ParseColor(color string) (string, error) {
+	if hex, ok := Colors[strings.ToLower(color)]; ok {
+		return hex, nil
+	}
+	if hexColorRe.MatchString(color) {
+		return "#" + strings.TrimPrefix(color, "#"), nil
+	}
+	return "", ErrInvalidColor
+}
+
+// ValidStyle reports whether style is empty or one of Styles
+func ValidStyle(style string) bool {
+	if style == "" {
+		return true
+	}
+	for _, s := range Styles {
+		if s == style {
+			return true
+		}
+	}
+	return false
+}
+
+// layout holds the measurements that differ between styles
+type layout struct {
+	height    int
+	radius    int
+	fontSize  int
+	padding   int // on each side of the text
+	textY     int // baseline, in tenths of a pixel like the font metrics
+	bold      bool
+	uppercase bool
+	spacing   float64 // letter spacing in pixels
+	gradient  string  // stops of the gloss overlay, empty for none
+}
+
+var layouts = map[string]layout{
+	Flat:        {height: 20, radius: 3, fontSize: 11, padding: 5, textY: 140, gradient: `<stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/>`},
+	FlatSquare:  {height: 20, fontSize: 11, padding: 5, textY: 140},
+	Plastic:     {height: 18, radius: 4, fontSize: 11, padding: 5, textY: 130, gradient: `<stop offset="0" stop-color="#fff" stop-opacity=".7"/><stop offset=".1" stop-color="#aaa" stop-opacity=".1"/><stop offset=".9" stop-opacity=".3"/><stop offset="1" stop-opacity=".5"/>`},
+	ForTheBadge: {height: 28, fontSize: 10, padding: 9, textY: 175, bold: true, uppercase: true, spacing: 1.25},
+}
+
+// Render draws the badge as an SVG document
+func Render(b Badge) ([]byte, error) {
+	if b.Style == "" {
+		b.Style = Flat
+	}
+	l, ok := layouts[b.Style]
+	if !ok {
+		return nil, ErrUnknownStyle
+	}
+	if b.Color == "" {
+		b.Color = "lightgrey"
+	}
+	if b.LabelColor == "" {
+		b.LabelColor = "grey"
+	}
+	color, err := ParseColor(b.Color)
+	if err != nil {
+		return nil, err
+	}
+	labelColor, err := ParseColor(b.LabelColor)
+	if err != nil {
+		return nil, err
+	}
+
+	label, message := truncate(b.Label), truncate(b.Message)
+	if l.uppercase {
+		label, message = strings.ToUpper(label), strings.ToUpper(message)
+	}
+	labelWidth := 0
+	if label != "" {
+		labelWidth = l.textWidth(label) + 2*l.padding
+	}
+	messageWidth := l.textWidth(message) + 2*l.padding
+	width := labelWidth + messageWidth
+
+	title := message
+	if label != "" {
+		title = label + ": " + message
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" role="img" aria-label="%s">`, width, l.height, escape(title))
+	fmt.Fprintf(&buf, `<title>%s</title>`, escape(title))
+	if l.gradient != "" {
+		fmt.Fprintf(&buf, `<linearGradient id="s" x2="0" y2="100%%">%s</linearGradient>`, l.gradient)
+	}
+	fmt.Fprintf(&buf, `<clipPath id="r"><rect width="%d" height="%d" rx="%d" fill="#fff"/></clipPath>`, width, l.height, l.radius)
+	buf.WriteString(`<g clip-path="url(#r)">`)
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" fill="%s"/>`, labelWidth, l.height, labelColor)
+	fmt.Fprintf(&buf, `<rect x="%d" width="%d" height="%d" fill="%s"/>`, labelWidth, messageWidth, l.height, color)
+	if l.gradient != "" {
+		fmt.Fprintf(&buf, `<rect width="%d" height="%d" fill="url(#s)"/>`, width, l.height)
+	}
+	buf.WriteString(`</g>`)
+
+	weight := ""
+	if l.bold {
+		weight = ` font-weight="bold"`
+	}
+	spacing := ""
+	if l.spacing > 0 {
+		spacing = fmt.Sprintf(` letter-spacing="%g"`, l.spacing*10)
+	}
+	// Text is drawn at ten times the size and scaled down, as shields does,
+	// for sharper rendering at fractional positions
+	fmt.Fprintf(&buf, `<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" text-rendering="geometricPrecision" font-size="%d"%s%s>`, l.fontSize*10, weight, spacing)
+	if label != "" {
+		l.writeText(&buf, label, labelWidth/2*10, l.textWidth(label)*10)
+	}
+	l.writeText(&buf, message, (labelWidth+messageWidth/2)*10, l.textWidth(message)*10)
+	buf.WriteString(`</g></svg>`)
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// writeText draws text centred at x, with a drop shadow unless the style is
+// flat-square or for-the-badge
+func (l layout) writeText(buf *bytes.Buffer, text string, x, length int) {
+	if l.gradient != "" {
+		fmt.Fprintf(buf, `<text x="%d" y="%d" transform="scale(.1)" fill="#010101" fill-opacity=".3" textLength="%d">%s</text>`, x, l.textY+10, length, escape(text))
+	}
+	fmt.Fprintf(buf, `<text x="%d" y="%d" transform="scale(.1)" textLength="%d">%s</text>`, x, l.textY, length, escape(text))
+}
+
+// textWidth estimates the rendered width of text in pixels
+func (l layout) textWidth(text string) int {
+	width := 0.0
+	for _, r := range text {
+		width += runeWidth(r)*float64(l.fontSize)/11 + l.spacing
+	}
+	if l.bold {
+		width *= 1.1
+	}
+	return int(width + 0.5)
+}
+
+// runeWidth approximates a character's advance in 11px Verdana
+func runeWidth(r rune) float64 {
+	switch {
+	case strings.ContainsRune("il.,:;|!'", r):
+		return 3.5
+	case strings.ContainsRune("fjrt()[]/\\ ", r):
+		return 4.5
+	case strings.ContainsRune("mwMW", r):
+		return 10.5
+	case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		return 7.5
+	case r < utf8.RuneSelf:
+		return 6.8
+	default:
+		// Symbols and wide scripts
+		return 11
+	}
+}
+
+// truncate cuts text to MaxTextLength characters
+func truncate(text string) string {
+	text = strings.TrimSpace(text)
+	if utf8.RuneCountInString(text) <= MaxTextLength {
+		return text
+	}
+	runes := []rune(text)
+	return string(runes[:MaxTextLength-1]) + "…"
+}
+
+// escape makes text safe inside XML character data and attributes
+func escape(text string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(text))
+	return buf.String()
+}
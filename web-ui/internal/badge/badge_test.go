@@ -0,0 +1,84 @@
+package badge
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	for _, style := range Styles {
+		svg, err := Render(Badge{Label: "solved", Message: `<script>alert("x")</script> & 'more'`, Color: "brightgreen", Style: style})
+		if err != nil {
+			t.Fatalf("%s: %v", style, err)
+		}
+		var parsed struct {
+			XMLName xml.Name
+			Title   string `xml:"title"`
+		}
+		if err := xml.Unmarshal(svg, &parsed); err != nil {
+			t.Fatalf("%s is not valid XML: %v\n%s", style, err, svg)
+		}
+		if parsed.XMLName.Local != "svg" {
+			t.Errorf("%s root = %q", style, parsed.XMLName.Local)
+		}
+		if strings.Contains(string(svg), "<script") {
+			t.Errorf("%s does not escape the message:\n%s", style, svg)
+		}
+		wantTitle := `solved: <script>alert("x")</script> & 'more'`
+		if style == ForTheBadge {
+			wantTitle = strings.ToUpper(wantTitle)
+		}
+		if parsed.Title != wantTitle {
+			t.Errorf("%s title = %q, want %q", style, parsed.Title, wantTitle)
+		}
+	}
+
+	if _, err := Render(Badge{Message: "x", Style: "round"}); err != ErrUnknownStyle {
+		t.Errorf("unknown style: err = %v", err)
+	}
+	if _, err := Render(Badge{Message: "x", Color: `red"/><script>`}); err != ErrInvalidColor {
+		t.Errorf("invalid color: err = %v", err)
+	}
+}
+
+func TestRenderWidths(t *testing.T) {
+	short, _ := Render(Badge{Label: "solved", Message: "1/30"})
+	long, _ := Render(Badge{Label: "solved", Message: "18/30 · Intermediate"})
+	if width(t, long) <= width(t, short) {
+		t.Errorf("longer message is not wider: %d <= %d", width(t, long), width(t, short))
+	}
+
+	truncated, _ := Render(Badge{Message: strings.Repeat("w", 500)})
+	if !strings.Contains(string(truncated), "…") || width(t, truncated) > 11*MaxTextLength+10 {
+		t.Errorf("long message is not truncated: width %d", width(t, truncated))
+	}
+}
+
+func TestParseColor(t *testing.T) {
+	for color, want := range map[string]string{
+		"blue":    "#007ec6",
+		"Blue":    "#007ec6",
+		"ff0000":  "#ff0000",
+		"#abc":    "#abc",
+		"fff0000": "",
+		"url(#s)": "",
+		"":        "",
+	} {
+		got, err := ParseColor(color)
+		if got != want || (want == "") != (err != nil) {
+			t.Errorf("ParseColor(%q) = %q, %v; want %q", color, got, err, want)
+		}
+	}
+}
+
+func width(t *testing.T, svg []byte) int {
+	t.Helper()
+	var parsed struct {
+		Width int `xml:"width,attr"`
+	}
+	if err := xml.Unmarshal(svg, &parsed); err != nil {
+		t.Fatal(err)
+	}
+	return parsed.Width
+}
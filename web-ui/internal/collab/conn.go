@@ -0,0 +1,382 @@
+package collab
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Message types exchanged over a room's WebSocket. The server sends welcome
+// once per connection, then op, cursor, run and presence as participants
+// act; error is sent just before the server closes the connection. Clients
+// send op, cursor and run.
+const (
+	MessageWelcome  = "welcome"
+	MessageOp       = "op"
+	MessageCursor   = "cursor"
+	MessageRun      = "run"
+	MessagePresence = "presence"
+	MessageError    = "error"
+)
+
+const (
+	writeWait       = 10 * time.Second
+	pongWait        = 60 * time.Second
+	pingPeriod      = pongWait * 9 / 10
+	sendBuffer      = 256
+	maxMessageBytes = 4*MaxDocumentUnits + 64<<10
+)
+
+// Message is one WebSocket frame in either direction; which fields are set
+// depends on Type.
+//
+// A client sends op with the revision it is based on, a seq that increases
+// with each of its operations, and the operation. The server transforms it
+// against the operations since that revision and relays it to everyone,
+// including the sender as its acknowledgement, with the new revision.
+// Clients must wait for that acknowledgement before sending their next
+// operation.
+type Message struct {
+	Type     string `json:"type"`
+	ClientID string `json:"clientId,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Revision int    `json:"revision"`
+	Seq      int    `json:"seq,omitempty"`
+
+	Op     *Operation `json:"op,omitempty"`
+	Cursor *Cursor    `json:"cursor,omitempty"`
+	Run    *RunResult `json:"run,omitempty"`
+
+	// Welcome only: the secret that resumes this client's session on
+	// reconnect, and either the operations the client missed since the
+	// revision it reconnected with or, when it is too far behind or new, the
+	// whole document
+	Token    string     `json:"token,omitempty"`
+	Ops      []Change   `json:"ops,omitempty"`
+	Document *string    `json:"document,omitempty"`
+	LastRun  *RunResult `json:"lastRun,omitempty"`
+
+	Participants []Participant `json:"participants,omitempty"`
+	Message      string        `json:"message,omitempty"`
+}
+
+// Change is an operation from the room's history
+type Change struct {
+	ClientID string    `json:"clientId"`
+	Seq      int       `json:"seq"`
+	Op       Operation `json:"op"`
+}
+
+var (
+	upgrader = websocket.Upgrader{ReadBufferSize: 4096, WriteBufferSize: 4096}
+	tokenRe  = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+)
+
+// conn is one WebSocket connection; writes go through send so slow
+// browsers never block the hub
+type conn struct {
+	ws   *websocket.Conn
+	send chan []byte
+	done chan struct{}
+	once sync.Once
+}
+
+func (c *conn) enqueue(data []byte) {
+	select {
+	case c.send <- data:
+	default:
+		// Too far behind to catch up; it reconnects and resyncs
+		c.close()
+	}
+}
+
+func (c *conn) close() {
+	c.once.Do(func() { close(c.done) })
+}
+
+// writePump writes queued messages and keepalive pings until the connection
+// is closed, then flushes what is left and says goodbye
+func (c *conn) writePump() {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		c.ws.Close()
+	}()
+	for {
+		select {
+		case data := <-c.send:
+			c.ws.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.ws.WriteMessage(websocket.TextMessage, data); err != nil {
+				return
+			}
+		case <-ticker.C:
+			c.ws.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.ws.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		case <-c.done:
+			for {
+				select {
+				case data := <-c.send:
+					c.ws.SetWriteDeadline(time.Now().Add(writeWait))
+					if err := c.ws.WriteMessage(websocket.TextMessage, data); err != nil {
+						return
+					}
+				default:
+					c.ws.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(writeWait))
+					return
+				}
+			}
+		}
+	}
+}
+
+// ServeRoom upgrades the request to a WebSocket that joins the room as name
+// and serves it until the browser goes away. The query may carry the token
+// and revision of a previous connection to resume its session.
+//
+// Unknown rooms and full rooms are reported before upgrading so the caller
+// can answer with a normal HTTP error; later failures close the socket.
+func (h *Hub) ServeRoom(w http.ResponseWriter, r *http.Request, roomID, name string) error {
+	token := r.URL.Query().Get("token")
+	if !tokenRe.MatchString(token) {
+		token = ""
+	}
+	revision, err := strconv.Atoi(r.URL.Query().Get("revision"))
+	if err != nil {
+		revision = -1
+	}
+
+	h.mu.Lock()
+	rm, err := h.room(roomID)
+	if err == nil && rm.connected() >= MaxParticipants {
+		err = ErrRoomFull
+	}
+	h.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	ws, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// The upgrader has already answered
+		return nil
+	}
+	ws.SetReadLimit(maxMessageBytes)
+	c := &conn{ws: ws, send: make(chan []byte, sendBuffer), done: make(chan struct{})}
+	go c.writePump()
+
+	token, err = h.join(roomID, token, name, revision, c)
+	if err != nil {
+		c.fail(err)
+		return nil
+	}
+	h.readPump(roomID, token, c)
+	return nil
+}
+
+// fail sends an error message and closes the connection
+func (c *conn) fail(err error) {
+	data, _ := json.Marshal(Message{Type: MessageError, Message: err.Error()})
+	c.enqueue(data)
+	c.close()
+}
+
+func (h *Hub) readPump(roomID, token string, c *conn) {
+	defer func() {
+		h.leave(roomID, token, c)
+		c.close()
+	}()
+	c.ws.SetReadDeadline(time.Now().Add(pongWait))
+	c.ws.SetPongHandler(func(string) error {
+		return c.ws.SetReadDeadline(time.Now().Add(pongWait))
+	})
+	for {
+		_, data, err := c.ws.ReadMessage()
+		if err != nil {
+			return
+		}
+		c.ws.SetReadDeadline(time.Now().Add(pongWait))
+		var msg Message
+		if err := json.Unmarshal(data, &msg); err != nil {
+			c.fail(fmt.Errorf("invalid message: %v", err))
+			return
+		}
+		if err := h.handle(roomID, token, c, msg); err != nil {
+			c.fail(err)
+			return
+		}
+	}
+}
+
+// join attaches a connection to a new or resumed client session, sends it
+// the welcome and tells the others; it returns the session's token
+func (h *Hub) join(roomID, token, name string, revision int, c *conn) (string, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	rm, err := h.room(roomID)
+	if err != nil {
+		return "", err
+	}
+	now := h.now()
+	rm.prune(now)
+
+	cl, resumed := rm.clients[token]
+	if resumed && cl.conn != nil {
+		// The browser reconnected before the server noticed it had gone
+		cl.conn.close()
+		cl.conn = nil
+	}
+	if rm.connected() >= MaxParticipants {
+		return "", ErrRoomFull
+	}
+	if !resumed {
+		if token, err = randomID(18); err != nil {
+			return "", fmt.Errorf("failed to create client token: %v", err)
+		}
+		id, err := randomID(6)
+		if err != nil {
+			return "", fmt.Errorf("failed to create client id: %v", err)
+		}
+		rm.sessions++
+		cl = &client{id: id, order: rm.sessions}
+		rm.clients[token] = cl
+	}
+	cl.name = name
+	cl.conn = c
+	cl.lastSeen = now
+
+	welcome := Message{
+		Type:         MessageWelcome,
+		ClientID:     cl.id,
+		Name:         cl.name,
+		Revision:     rm.revision,
+		Seq:          cl.lastSeq,
+		Token:        token,
+		LastRun:      rm.lastRun,
+		Participants: rm.participants(),
+	}
+	if resumed && revision >= rm.historyBase && revision <= rm.revision {
+		for _, entry := range rm.history[revision-rm.historyBase:] {
+			welcome.Ops = append(welcome.Ops, Change{ClientID: entry.clientID, Seq: entry.seq, Op: entry.op})
+		}
+	} else {
+		document := unitsText(rm.doc)
+		welcome.Document = &document
+	}
+	if err := send(c, welcome); err != nil {
+		return "", err
+	}
+	h.broadcast(rm, Message{Type: MessagePresence, Revision: rm.revision, Participants: rm.participants()}, c)
+	return token, nil
+}
+
+// leave detaches a connection from its session if it is still the current one
+func (h *Hub) leave(roomID, token string, c *conn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	rm, ok := h.rooms[roomID]
+	if !ok {
+		return
+	}
+	cl, ok := rm.clients[token]
+	if !ok || cl.conn != c {
+		return
+	}
+	now := h.now()
+	cl.conn = nil
+	cl.cursor = nil
+	cl.lastSeen = now
+	if rm.connected() == 0 {
+		rm.idleSince = now
+	}
+	h.broadcast(rm, Message{Type: MessagePresence, Revision: rm.revision, Participants: rm.participants()}, nil)
+}
+
+// handle applies one client message; an error closes the connection
+func (h *Hub) handle(roomID, token string, c *conn, msg Message) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	rm, ok := h.rooms[roomID]
+	if !ok {
+		return ErrRoomNotFound
+	}
+	cl, ok := rm.clients[token]
+	if !ok || cl.conn != c {
+		return fmt.Errorf("connection replaced by a newer one")
+	}
+	cl.lastSeen = h.now()
+
+	switch msg.Type {
+	case MessageOp:
+		if msg.Op == nil || msg.Seq <= 0 {
+			return fmt.Errorf("op messages need an op and a positive seq")
+		}
+		if msg.Seq <= cl.lastSeq {
+			// Already applied before a reconnect; the client saw the echo
+			// in its welcome
+			return nil
+		}
+		op, err := rm.apply(cl, msg.Revision, msg.Seq, *msg.Op)
+		if err != nil {
+			return err
+		}
+		h.broadcast(rm, Message{Type: MessageOp, ClientID: cl.id, Revision: rm.revision, Seq: msg.Seq, Op: &op}, nil)
+	case MessageCursor:
+		if msg.Cursor == nil {
+			return fmt.Errorf("cursor messages need a cursor")
+		}
+		cursor, err := rm.transformCursor(msg.Revision, *msg.Cursor)
+		if err != nil {
+			return err
+		}
+		cl.cursor = &cursor
+		h.broadcast(rm, Message{Type: MessageCursor, ClientID: cl.id, Name: cl.name, Revision: rm.revision, Cursor: &cursor}, c)
+	case MessageRun:
+		if msg.Run == nil {
+			return fmt.Errorf("run messages need a run")
+		}
+		run := *msg.Run
+		run.Name = cl.name
+		run.Time = h.now()
+		if len(run.Output) > MaxRunOutput {
+			run.Output = run.Output[:MaxRunOutput] + "\n... (truncated)"
+		}
+		rm.lastRun = &run
+		h.broadcast(rm, Message{Type: MessageRun, ClientID: cl.id, Name: cl.name, Revision: rm.revision, Run: &run}, c)
+	default:
+		return fmt.Errorf("unknown message type %q", msg.Type)
+	}
+	return nil
+}
+
+// broadcast queues a message for every connection in the room but skip;
+// h.mu must be held
+func (h *Hub) broadcast(rm *room, msg Message, skip *conn) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		slog.Error("failed to encode pair message", "room", rm.id, "err", err)
+		return
+	}
+	for _, cl := range rm.clients {
+		if cl.conn != nil && cl.conn != skip {
+			cl.conn.enqueue(data)
+		}
+	}
+}
+
+func send(c *conn, msg Message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %v", err)
+	}
+	c.enqueue(data)
+	return nil
+}
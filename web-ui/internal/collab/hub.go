@@ -0,0 +1,346 @@
+package collab
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limits on the shared state a hub keeps in memory
+const (
+	// DefaultRoomTTL is how long a room nobody is connected to is kept
+	DefaultRoomTTL = 2 * time.Hour
+	// MaxDocumentUnits caps a room's document, in UTF-16 code units
+	MaxDocumentUnits = 256 << 10
+	// MaxParticipants caps the browsers connected to a room at once
+	MaxParticipants = 8
+	// MaxRooms caps the rooms a hub keeps
+	MaxRooms = 1000
+	// MaxRunOutput caps the run output relayed between participants
+	MaxRunOutput = 64 << 10
+
+	// historyLimit is how many operations a room keeps for clients catching
+	// up after a reconnect; clients further behind reload the document
+	historyLimit = 500
+	// clientGrace is how long a disconnected client may resume its session
+	// with edits it made while offline
+	clientGrace = 10 * time.Minute
+)
+
+var (
+	// ErrRoomNotFound is returned for unknown or expired rooms
+	ErrRoomNotFound = errors.New("pair room not found")
+	// ErrRoomFull is returned when a room already has MaxParticipants connected
+	ErrRoomFull = errors.New("pair room is full")
+	// ErrTooManyRooms is returned when the hub already keeps MaxRooms rooms
+	ErrTooManyRooms = errors.New("too many pair rooms")
+	// ErrDocumentTooLarge is returned for documents over MaxDocumentUnits
+	ErrDocumentTooLarge = errors.New("document too large")
+	// ErrStaleRevision is returned for messages based on a revision the room
+	// no longer keeps history for
+	ErrStaleRevision = errors.New("revision is not in the room's history")
+)
+
+// Cursor is a participant's caret and selection anchor as document offsets
+type Cursor struct {
+	Position int `json:"position"`
+	Anchor   int `json:"anchor"`
+}
+
+// RunResult is a test run a participant shares with the room
+type RunResult struct {
+	Name        string    `json:"name,omitempty"`
+	Time        time.Time `json:"time"`
+	Passed      bool      `json:"passed"`
+	TimedOut    bool      `json:"timedOut"`
+	Output      string    `json:"output"`
+	ExecutionMs int64     `json:"executionMs"`
+}
+
+// Participant is a browser connected to a room
+type Participant struct {
+	ClientID string  `json:"clientId"`
+	Name     string  `json:"name"`
+	Cursor   *Cursor `json:"cursor,omitempty"`
+}
+
+// RoomInfo describes a room without its document
+type RoomInfo struct {
+	ID           string        `json:"id"`
+	ChallengeID  int           `json:"challengeId"`
+	Owner        string        `json:"owner"`
+	CreatedAt    time.Time     `json:"createdAt"`
+	Revision     int           `json:"revision"`
+	Participants []Participant `json:"participants"`
+	// ExpiresAt is set while nobody is connected
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+// Hub keeps pair-programming rooms in memory. Each room holds one document
+// that connected clients edit concurrently: the server orders operations,
+// transforms each against those its sender had not seen yet, and relays the
+// result, so every client converges on the same text.
+type Hub struct {
+	mu    sync.Mutex
+	rooms map[string]*room
+	ttl   time.Duration
+	now   func() time.Time
+}
+
+type room struct {
+	id          string
+	challengeID int
+	owner       string
+	createdAt   time.Time
+	doc         []uint16
+	revision    int
+	// history holds the operations that took the document from revision
+	// historyBase to revision
+	history     []historyEntry
+	historyBase int
+	clients     map[string]*client
+	sessions    int
+	lastRun     *RunResult
+	idleSince   time.Time
+}
+
+type historyEntry struct {
+	clientID string
+	seq      int
+	op       Operation
+}
+
+// client is one participant's session, which outlives its connections so a
+// reconnecting browser can resume where it left off
+type client struct {
+	id       string
+	name     string
+	conn     *conn
+	lastSeq  int
+	cursor   *Cursor
+	lastSeen time.Time
+	order    int // when the session started, among the room's sessions
+}
+
+// NewHub creates a hub that drops rooms nobody has been connected to for ttl
+func NewHub(ttl time.Duration) *Hub {
+	if ttl <= 0 {
+		ttl = DefaultRoomTTL
+	}
+	return &Hub{rooms: make(map[string]*room), ttl: ttl, now: time.Now}
+}
+
+// CreateRoom opens a room for a challenge with code as its document. Line
+// endings are normalized to \n, as browser editors count offsets that way.
+func (h *Hub) CreateRoom(challengeID int, owner, code string) (RoomInfo, error) {
+	doc := textUnits(strings.ReplaceAll(strings.ReplaceAll(code, "\r\n", "\n"), "\r", "\n"))
+	if len(doc) > MaxDocumentUnits {
+		return RoomInfo{}, fmt.Errorf("%w: at most %d characters", ErrDocumentTooLarge, MaxDocumentUnits)
+	}
+	id, err := roomID()
+	if err != nil {
+		return RoomInfo{}, fmt.Errorf("failed to create room id: %v", err)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	now := h.now()
+	h.sweep(now)
+	if len(h.rooms) >= MaxRooms {
+		return RoomInfo{}, ErrTooManyRooms
+	}
+	rm := &room{
+		id:          id,
+		challengeID: challengeID,
+		owner:       owner,
+		createdAt:   now,
+		doc:         doc,
+		clients:     make(map[string]*client),
+		idleSince:   now,
+	}
+	h.rooms[id] = rm
+	return h.info(rm), nil
+}
+
+// Room returns a live room's description
+func (h *Hub) Room(id string) (RoomInfo, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	rm, err := h.room(id)
+	if err != nil {
+		return RoomInfo{}, err
+	}
+	return h.info(rm), nil
+}
+
+// Document returns a room's current text and revision
+func (h *Hub) Document(id string) (string, int, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	rm, err := h.room(id)
+	if err != nil {
+		return "", 0, err
+	}
+	return unitsText(rm.doc), rm.revision, nil
+}
+
+// room returns a live room, dropping expired ones first; h.mu must be held
+func (h *Hub) room(id string) (*room, error) {
+	h.sweep(h.now())
+	rm, ok := h.rooms[id]
+	if !ok {
+		return nil, ErrRoomNotFound
+	}
+	return rm, nil
+}
+
+// sweep drops rooms idle for longer than the TTL; h.mu must be held
+func (h *Hub) sweep(now time.Time) {
+	for id, rm := range h.rooms {
+		if rm.connected() == 0 && now.Sub(rm.idleSince) >= h.ttl {
+			delete(h.rooms, id)
+		}
+	}
+}
+
+func (h *Hub) info(rm *room) RoomInfo {
+	info := RoomInfo{
+		ID:           rm.id,
+		ChallengeID:  rm.challengeID,
+		Owner:        rm.owner,
+		CreatedAt:    rm.createdAt,
+		Revision:     rm.revision,
+		Participants: rm.participants(),
+	}
+	if len(info.Participants) == 0 {
+		expires := rm.idleSince.Add(h.ttl)
+		info.ExpiresAt = &expires
+	}
+	return info
+}
+
+func (rm *room) connected() int {
+	n := 0
+	for _, c := range rm.clients {
+		if c.conn != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// participants lists the connected clients in the order they joined
+func (rm *room) participants() []Participant {
+	var clients []*client
+	for _, c := range rm.clients {
+		if c.conn != nil {
+			clients = append(clients, c)
+		}
+	}
+	sort.Slice(clients, func(i, j int) bool { return clients[i].order < clients[j].order })
+	participants := make([]Participant, 0, len(clients))
+	for _, c := range clients {
+		p := Participant{ClientID: c.id, Name: c.name}
+		if c.cursor != nil {
+			cursor := *c.cursor
+			p.Cursor = &cursor
+		}
+		participants = append(participants, p)
+	}
+	return participants
+}
+
+// transformSince brings an operation based on revision up to the current one
+func (rm *room) transformSince(revision int, op Operation) (Operation, error) {
+	if revision < rm.historyBase || revision > rm.revision {
+		return Operation{}, ErrStaleRevision
+	}
+	for _, entry := range rm.history[revision-rm.historyBase:] {
+		var err error
+		if op, _, err = Transform(op, entry.op); err != nil {
+			return Operation{}, err
+		}
+	}
+	return op, nil
+}
+
+// transformCursor brings a cursor based on revision up to the current one
+func (rm *room) transformCursor(revision int, cursor Cursor) (Cursor, error) {
+	if revision < rm.historyBase || revision > rm.revision {
+		return Cursor{}, ErrStaleRevision
+	}
+	for _, entry := range rm.history[revision-rm.historyBase:] {
+		cursor = Cursor{Position: TransformIndex(cursor.Position, entry.op), Anchor: TransformIndex(cursor.Anchor, entry.op)}
+	}
+	return clampCursor(cursor, len(rm.doc)), nil
+}
+
+// apply applies an operation from a client at the given revision and
+// returns it as transformed onto the current document
+func (rm *room) apply(c *client, revision, seq int, op Operation) (Operation, error) {
+	if op.insertsCarriageReturn() {
+		return Operation{}, fmt.Errorf("%w: documents use \\n line endings", ErrInvalidOperation)
+	}
+	op, err := rm.transformSince(revision, op)
+	if err != nil {
+		return Operation{}, err
+	}
+	if op.TargetLen() > MaxDocumentUnits {
+		return Operation{}, fmt.Errorf("%w: at most %d characters", ErrDocumentTooLarge, MaxDocumentUnits)
+	}
+	doc, err := op.Apply(rm.doc)
+	if err != nil {
+		return Operation{}, err
+	}
+
+	rm.doc = doc
+	rm.revision++
+	rm.history = append(rm.history, historyEntry{clientID: c.id, seq: seq, op: op})
+	if len(rm.history) > historyLimit {
+		dropped := len(rm.history) - historyLimit
+		rm.history = append([]historyEntry(nil), rm.history[dropped:]...)
+		rm.historyBase += dropped
+	}
+	c.lastSeq = seq
+	for _, other := range rm.clients {
+		if other.cursor != nil {
+			moved := Cursor{Position: TransformIndex(other.cursor.Position, op), Anchor: TransformIndex(other.cursor.Anchor, op)}
+			other.cursor = &moved
+		}
+	}
+	return op, nil
+}
+
+// prune forgets clients that have been disconnected for longer than they
+// may resume
+func (rm *room) prune(now time.Time) {
+	for id, c := range rm.clients {
+		if c.conn == nil && now.Sub(c.lastSeen) > clientGrace {
+			delete(rm.clients, id)
+		}
+	}
+}
+
+func clampCursor(cursor Cursor, length int) Cursor {
+	clamp := func(n int) int { return max(0, min(n, length)) }
+	return Cursor{Position: clamp(cursor.Position), Anchor: clamp(cursor.Anchor)}
+}
+
+// roomID returns an unguessable room identifier; knowing it is what lets a
+// browser join, like an invite link
+func roomID() (string, error) {
+	return randomID(16)
+}
+
+func randomID(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
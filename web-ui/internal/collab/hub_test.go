@@ -0,0 +1,398 @@
+package collab
+
+import (
+	"errors"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// clock is a settable time source for hubs under test
+type clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// newTestHub serves a hub over an in-process HTTP server, naming each
+// participant after the name query parameter
+func newTestHub(t *testing.T) (*Hub, *clock, *httptest.Server) {
+	t.Helper()
+	clk := &clock{now: time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)}
+	hub := NewHub(time.Hour)
+	hub.now = clk.Now
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /ws/{room}", func(w http.ResponseWriter, r *http.Request) {
+		err := hub.ServeRoom(w, r, r.PathValue("room"), r.URL.Query().Get("name"))
+		switch {
+		case errors.Is(err, ErrRoomNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, ErrRoomFull):
+			http.Error(w, err.Error(), http.StatusConflict)
+		}
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return hub, clk, server
+}
+
+// peer is a test participant that keeps its own copy of the document the
+// way the browser client does: it applies its edits locally, transforms
+// incoming operations against its unacknowledged one, and waits for the
+// acknowledgement before sending another
+type peer struct {
+	t           *testing.T
+	ws          *websocket.Conn
+	welcome     Message
+	doc         []uint16
+	revision    int
+	seq         int
+	outstanding *Operation
+}
+
+func dial(t *testing.T, server *httptest.Server, roomID, query string) (*peer, *http.Response, error) {
+	t.Helper()
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws/" + roomID + "?" + query
+	ws, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		return nil, resp, err
+	}
+	t.Cleanup(func() { ws.Close() })
+	p := &peer{t: t, ws: ws}
+	p.welcome = p.readType(MessageWelcome)
+	return p, resp, nil
+}
+
+func join(t *testing.T, server *httptest.Server, roomID, name string) *peer {
+	t.Helper()
+	p, _, err := dial(t, server, roomID, "name="+name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.welcome.Document == nil {
+		t.Fatalf("welcome for a new participant = %+v, want the document", p.welcome)
+	}
+	p.doc = textUnits(*p.welcome.Document)
+	p.revision = p.welcome.Revision
+	return p
+}
+
+func (p *peer) read() Message {
+	p.t.Helper()
+	p.ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var msg Message
+	if err := p.ws.ReadJSON(&msg); err != nil {
+		p.t.Fatalf("read: %v", err)
+	}
+	return msg
+}
+
+// readType reads until a message of the given type, skipping presence
+// updates
+func (p *peer) readType(typ string) Message {
+	p.t.Helper()
+	for {
+		msg := p.read()
+		if msg.Type == typ {
+			return msg
+		}
+		if msg.Type != MessagePresence {
+			p.t.Fatalf("got %+v, want a %s message", msg, typ)
+		}
+	}
+}
+
+func (p *peer) send(msg Message) {
+	p.t.Helper()
+	if err := p.ws.WriteJSON(msg); err != nil {
+		p.t.Fatalf("write: %v", err)
+	}
+}
+
+// edit applies an operation locally and sends it
+func (p *peer) edit(op Operation) {
+	p.t.Helper()
+	if p.outstanding != nil {
+		p.t.Fatal("edit while an operation is unacknowledged")
+	}
+	doc, err := op.Apply(p.doc)
+	if err != nil {
+		p.t.Fatal(err)
+	}
+	p.doc = doc
+	p.seq++
+	p.outstanding = &op
+	p.send(Message{Type: MessageOp, Revision: p.revision, Seq: p.seq, Op: &op})
+}
+
+// receive handles an operation from the server
+func (p *peer) receive(clientID string, seq int, op Operation) {
+	p.t.Helper()
+	p.revision++
+	if clientID == p.welcome.ClientID && p.outstanding != nil && seq == p.seq {
+		p.outstanding = nil
+		return
+	}
+	if p.outstanding != nil {
+		outstanding, remote, err := Transform(*p.outstanding, op)
+		if err != nil {
+			p.t.Fatal(err)
+		}
+		p.outstanding, op = &outstanding, remote
+	}
+	doc, err := op.Apply(p.doc)
+	if err != nil {
+		p.t.Fatal(err)
+	}
+	p.doc = doc
+}
+
+func (p *peer) receiveOp() {
+	p.t.Helper()
+	msg := p.readType(MessageOp)
+	p.receive(msg.ClientID, msg.Seq, *msg.Op)
+}
+
+func TestHubConvergesConcurrentEdits(t *testing.T) {
+	hub, _, server := newTestHub(t)
+	room, err := hub.CreateRoom(1, "alice", "package main\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	peers := []*peer{
+		join(t, server, room.ID, "alice"),
+		join(t, server, room.ID, "bob"),
+		join(t, server, room.ID, "carol"),
+	}
+
+	// Every round each peer edits its own copy without seeing the others'
+	// edits first, so the server has to transform all but the first
+	rng := rand.New(rand.NewSource(7))
+	for round := 0; round < 30; round++ {
+		for _, p := range peers {
+			p.edit(randomOperation(rng, len(p.doc)))
+		}
+		for _, p := range peers {
+			for range peers {
+				p.receiveOp()
+			}
+		}
+	}
+
+	document, revision, err := hub.Document(room.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if revision != 90 {
+		t.Errorf("revision = %d, want 90", revision)
+	}
+	for _, p := range peers {
+		if unitsText(p.doc) != document || p.revision != revision {
+			t.Errorf("%s has %q at revision %d, want %q at %d", p.welcome.Name, unitsText(p.doc), p.revision, document, revision)
+		}
+	}
+}
+
+func TestHubCursorsAndRuns(t *testing.T) {
+	hub, _, server := newTestHub(t)
+	room, err := hub.CreateRoom(1, "alice", "hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice := join(t, server, room.ID, "alice")
+	bob := join(t, server, room.ID, "bob")
+	alice.readType(MessagePresence)
+
+	// Bob places his cursor before seeing Alice's insert, so the server moves it
+	var insert Operation
+	insert.Insert(">> ").Retain(5)
+	alice.edit(insert)
+	alice.receiveOp()
+	bob.send(Message{Type: MessageCursor, Revision: 0, Cursor: &Cursor{Position: 5, Anchor: 1}})
+	msg := alice.readType(MessageCursor)
+	if msg.ClientID != bob.welcome.ClientID || msg.Name != "bob" || msg.Revision != 1 || *msg.Cursor != (Cursor{Position: 8, Anchor: 4}) {
+		t.Errorf("cursor = %+v at revision %d, want bob's at 8 and 4 at revision 1", msg.Cursor, msg.Revision)
+	}
+	bob.receiveOp()
+
+	bob.send(Message{Type: MessageRun, Run: &RunResult{Passed: true, Output: "ok", ExecutionMs: 12, Name: "spoofed"}})
+	msg = alice.readType(MessageRun)
+	if msg.Run == nil || !msg.Run.Passed || msg.Run.Name != "bob" || msg.Run.Output != "ok" {
+		t.Errorf("run = %+v, want bob's passing run", msg.Run)
+	}
+
+	carol := join(t, server, room.ID, "carol")
+	if last := carol.welcome.LastRun; last == nil || last.Name != "bob" {
+		t.Errorf("welcome last run = %+v, want bob's", last)
+	}
+	if got := carol.welcome.Participants; len(got) != 3 || got[0].Name != "alice" || got[1].Cursor == nil || got[1].Cursor.Position != 8 {
+		t.Errorf("welcome participants = %+v, want alice, bob with his cursor and carol", got)
+	}
+	if unitsText(carol.doc) != ">> hello" {
+		t.Errorf("carol's document = %q, want %q", unitsText(carol.doc), ">> hello")
+	}
+}
+
+func TestHubReconnect(t *testing.T) {
+	hub, _, server := newTestHub(t)
+	room, err := hub.CreateRoom(1, "alice", "x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice := join(t, server, room.ID, "alice")
+	bob := join(t, server, room.ID, "bob")
+	alice.readType(MessagePresence)
+
+	var first Operation
+	first.Retain(1).Insert("1")
+	alice.edit(first)
+	alice.receiveOp()
+	bob.receiveOp()
+
+	// Alice drops off and Bob keeps typing
+	alice.ws.Close()
+	if msg := bob.readType(MessagePresence); len(msg.Participants) != 1 {
+		t.Errorf("presence after alice left = %+v, want just bob", msg.Participants)
+	}
+	for _, text := range []string{"2", "3"} {
+		var op Operation
+		op.Retain(len(bob.doc)).Insert(text)
+		bob.edit(op)
+		bob.receiveOp()
+	}
+
+	// Resuming replays what she missed instead of resending the document
+	resumed, _, err := dial(t, server, room.ID, "name=alice&token="+alice.welcome.Token+"&revision=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resumed.welcome.ClientID != alice.welcome.ClientID || resumed.welcome.Document != nil || len(resumed.welcome.Ops) != 2 {
+		t.Fatalf("resumed welcome = %+v, want alice's session with 2 missed operations", resumed.welcome)
+	}
+	if resumed.welcome.Seq != 1 {
+		t.Errorf("resumed seq = %d, want alice's last applied operation", resumed.welcome.Seq)
+	}
+	resumed.doc, resumed.revision, resumed.seq = alice.doc, alice.revision, alice.seq
+	for _, change := range resumed.welcome.Ops {
+		resumed.receive(change.ClientID, change.Seq, change.Op)
+	}
+	if unitsText(resumed.doc) != "x123" || resumed.revision != resumed.welcome.Revision {
+		t.Errorf("caught up to %q at %d, want %q at %d", unitsText(resumed.doc), resumed.revision, "x123", resumed.welcome.Revision)
+	}
+
+	// A resent operation that was already applied is not applied twice
+	resumed.send(Message{Type: MessageOp, Revision: 0, Seq: 1, Op: &first})
+	var last Operation
+	last.Retain(4).Insert("!")
+	resumed.edit(last)
+	resumed.receiveOp()
+	if document, _, _ := hub.Document(room.ID); document != "x123!" {
+		t.Errorf("document = %q, want %q", document, "x123!")
+	}
+
+	// Unknown tokens start a new session with the whole document
+	stranger, _, err := dial(t, server, room.ID, "name=alice&token=nope&revision=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stranger.welcome.Document == nil || *stranger.welcome.Document != "x123!" || stranger.welcome.ClientID == alice.welcome.ClientID {
+		t.Errorf("welcome for an unknown token = %+v, want a new session with the document", stranger.welcome)
+	}
+}
+
+func TestHubRejects(t *testing.T) {
+	hub, _, server := newTestHub(t)
+	if _, err := hub.CreateRoom(1, "alice", strings.Repeat("x", MaxDocumentUnits+1)); !errors.Is(err, ErrDocumentTooLarge) {
+		t.Errorf("CreateRoom with a huge document = %v, want ErrDocumentTooLarge", err)
+	}
+	if _, resp, err := dial(t, server, "missing", "name=alice"); err == nil || resp.StatusCode != http.StatusNotFound {
+		t.Errorf("dialing a missing room = %v, want 404", err)
+	}
+
+	room, err := hub.CreateRoom(1, "alice", "abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < MaxParticipants; i++ {
+		join(t, server, room.ID, "guest")
+	}
+	if _, resp, err := dial(t, server, room.ID, "name=late"); err == nil || resp.StatusCode != http.StatusConflict {
+		t.Errorf("dialing a full room = %v, want 409", err)
+	}
+
+	// An operation for a different document closes the connection
+	room, err = hub.CreateRoom(1, "alice", "abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice := join(t, server, room.ID, "alice")
+	var bad Operation
+	bad.Retain(10)
+	alice.send(Message{Type: MessageOp, Revision: 0, Seq: 1, Op: &bad})
+	if msg := alice.readType(MessageError); !strings.Contains(msg.Message, "invalid operation") {
+		t.Errorf("error = %q, want an invalid operation", msg.Message)
+	}
+	alice.ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, _, err := alice.ws.ReadMessage(); !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+		t.Errorf("read after the error = %v, want the connection closed", err)
+	}
+}
+
+func TestHubRoomTTL(t *testing.T) {
+	hub, clk, server := newTestHub(t)
+	room, err := hub.CreateRoom(1, "alice", "abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info, err := hub.Room(room.ID); err != nil || info.ExpiresAt == nil || !info.ExpiresAt.Equal(clk.Now().Add(time.Hour)) {
+		t.Fatalf("Room = %+v, %v, want an empty room expiring in an hour", info, err)
+	}
+
+	// Rooms with someone connected never expire
+	alice := join(t, server, room.ID, "alice")
+	clk.Advance(3 * time.Hour)
+	info, err := hub.Room(room.ID)
+	if err != nil || info.ExpiresAt != nil || len(info.Participants) != 1 {
+		t.Fatalf("Room while alice is connected = %+v, %v, want it live without an expiry", info, err)
+	}
+
+	alice.ws.Close()
+	deadline := time.Now().Add(5 * time.Second)
+	for len(info.Participants) > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		info, _ = hub.Room(room.ID)
+	}
+	if len(info.Participants) != 0 {
+		t.Fatal("alice never left the room")
+	}
+
+	clk.Advance(59 * time.Minute)
+	if _, err := hub.Room(room.ID); err != nil {
+		t.Errorf("Room before the TTL = %v, want it kept", err)
+	}
+	clk.Advance(time.Minute)
+	if _, err := hub.Room(room.ID); !errors.Is(err, ErrRoomNotFound) {
+		t.Errorf("Room after the TTL = %v, want ErrRoomNotFound", err)
+	}
+	if _, resp, err := dial(t, server, room.ID, "name=alice"); err == nil || resp.StatusCode != http.StatusNotFound {
+		t.Errorf("dialing an expired room = %v, want 404", err)
+	}
+}
@@ -0,0 +1,369 @@
+// Package collab keeps shared editing rooms in memory and syncs them between
+// browsers over WebSockets using operational transformation.
+package collab
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"unicode/utf16"
+)
+
+// ErrInvalidOperation is returned for an operation that does not fit the
+// document it is applied or transformed against
+var ErrInvalidOperation = errors.New("invalid operation")
+
+// component is one step of an operation: exactly one field is set
+type component struct {
+	retain int
+	insert []uint16
+	delete int
+}
+
+// Operation is an edit of a whole document, as a sequence of retains,
+// inserts and deletes. Lengths and positions count UTF-16 code units, as
+// browsers do, so clients and the server agree on offsets.
+//
+// In JSON an operation is an array in which a positive number retains, a
+// negative number deletes and a string inserts, e.g. [5, "x", -2, 10].
+type Operation struct {
+	components []component
+	// oversized marks an operation given a retain or delete longer than
+	// MaxDocumentUnits, which no document can satisfy
+	oversized bool
+}
+
+// Retain appends skipping n units. Retains longer than MaxDocumentUnits
+// make the operation invalid.
+func (op *Operation) Retain(n int) *Operation {
+	if n <= 0 {
+		return op
+	}
+	if last := op.last(); last != nil && last.retain > 0 {
+		if last.retain > MaxDocumentUnits-n {
+			op.oversized = true
+			return op
+		}
+		last.retain += n
+		return op
+	}
+	if n > MaxDocumentUnits {
+		op.oversized = true
+		return op
+	}
+	op.components = append(op.components, component{retain: n})
+	return op
+}
+
+// Insert appends inserting s
+func (op *Operation) Insert(s string) *Operation {
+	return op.insertUnits(utf16.Encode([]rune(s)))
+}
+
+func (op *Operation) insertUnits(units []uint16) *Operation {
+	if len(units) == 0 {
+		return op
+	}
+	n := len(op.components)
+	switch {
+	case n > 0 && op.components[n-1].insert != nil:
+		op.components[n-1].insert = append(op.components[n-1].insert, units...)
+	case n > 0 && op.components[n-1].delete > 0:
+		// Keep inserts before deletes so equal operations compare equal
+		if n > 1 && op.components[n-2].insert != nil {
+			op.components[n-2].insert = append(op.components[n-2].insert, units...)
+		} else {
+			deleted := op.components[n-1]
+			op.components[n-1] = component{insert: append([]uint16{}, units...)}
+			op.components = append(op.components, deleted)
+		}
+	default:
+		op.components = append(op.components, component{insert: append([]uint16{}, units...)})
+	}
+	return op
+}
+
+// Delete appends deleting n units. Deletes longer than MaxDocumentUnits
+// make the operation invalid.
+func (op *Operation) Delete(n int) *Operation {
+	if n <= 0 {
+		return op
+	}
+	if last := op.last(); last != nil && last.delete > 0 {
+		if last.delete > MaxDocumentUnits-n {
+			op.oversized = true
+			return op
+		}
+		last.delete += n
+		return op
+	}
+	if n > MaxDocumentUnits {
+		op.oversized = true
+		return op
+	}
+	op.components = append(op.components, component{delete: n})
+	return op
+}
+
+func (op *Operation) last() *component {
+	if len(op.components) == 0 {
+		return nil
+	}
+	return &op.components[len(op.components)-1]
+}
+
+// BaseLen is the length of the documents the operation applies to. It
+// saturates at math.MaxInt rather than overflow.
+func (op Operation) BaseLen() int {
+	n := 0
+	for _, c := range op.components {
+		n = addLen(n, c.retain+c.delete)
+	}
+	return n
+}
+
+// TargetLen is the length of the document after applying the operation. It
+// saturates at math.MaxInt rather than overflow.
+func (op Operation) TargetLen() int {
+	n := 0
+	for _, c := range op.components {
+		n = addLen(n, addLen(c.retain, len(c.insert)))
+	}
+	return n
+}
+
+// addLen adds non-negative lengths, saturating at math.MaxInt
+func addLen(a, b int) int {
+	if a > math.MaxInt-b {
+		return math.MaxInt
+	}
+	return a + b
+}
+
+// validate rejects an operation made invalid by an oversized component
+func (op Operation) validate() error {
+	if op.oversized {
+		return fmt.Errorf("%w: retains and deletes are at most %d units", ErrInvalidOperation, MaxDocumentUnits)
+	}
+	return nil
+}
+
+// insertsCarriageReturn reports whether the operation inserts a \r
+func (op Operation) insertsCarriageReturn() bool {
+	for _, c := range op.components {
+		for _, unit := range c.insert {
+			if unit == '\r' {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Apply returns doc with the operation applied
+func (op Operation) Apply(doc []uint16) ([]uint16, error) {
+	if err := op.validate(); err != nil {
+		return nil, err
+	}
+	if op.BaseLen() != len(doc) {
+		return nil, fmt.Errorf("%w: operation is for a document of %d units, not %d", ErrInvalidOperation, op.BaseLen(), len(doc))
+	}
+	result := make([]uint16, 0, op.TargetLen())
+	pos := 0
+	for _, c := range op.components {
+		switch {
+		case c.retain > 0:
+			result = append(result, doc[pos:pos+c.retain]...)
+			pos += c.retain
+		case c.insert != nil:
+			result = append(result, c.insert...)
+		default:
+			pos += c.delete
+		}
+	}
+	return result, nil
+}
+
+// Transform returns a' and b' such that applying a then b' gives the same
+// document as applying b then a'. Both must apply to the same document. When
+// both insert at the same position, a's insert comes first.
+func Transform(a, b Operation) (Operation, Operation, error) {
+	if err := a.validate(); err != nil {
+		return Operation{}, Operation{}, err
+	}
+	if err := b.validate(); err != nil {
+		return Operation{}, Operation{}, err
+	}
+	if a.BaseLen() != b.BaseLen() {
+		return Operation{}, Operation{}, fmt.Errorf("%w: concurrent operations are for documents of %d and %d units", ErrInvalidOperation, a.BaseLen(), b.BaseLen())
+	}
+
+	var aPrime, bPrime Operation
+	as, bs := newCursor(a), newCursor(b)
+	for !as.done() || !bs.done() {
+		// Inserts do not consume the other side, and a's go first
+		if ins := as.peekInsert(); ins != nil {
+			aPrime.insertUnits(ins)
+			bPrime.Retain(len(ins))
+			as.next()
+			continue
+		}
+		if ins := bs.peekInsert(); ins != nil {
+			aPrime.Retain(len(ins))
+			bPrime.insertUnits(ins)
+			bs.next()
+			continue
+		}
+
+		ac, bc := as.current(), bs.current()
+		n := min(ac.retain+ac.delete, bc.retain+bc.delete)
+		switch {
+		case ac.retain > 0 && bc.retain > 0:
+			aPrime.Retain(n)
+			bPrime.Retain(n)
+		case ac.delete > 0 && bc.retain > 0:
+			aPrime.Delete(n)
+		case ac.retain > 0 && bc.delete > 0:
+			bPrime.Delete(n)
+		}
+		// Both deleting the same text leaves nothing for either to delete
+		as.consume(n)
+		bs.consume(n)
+	}
+	return aPrime, bPrime, nil
+}
+
+// TransformIndex moves a position in the document the operation applies to
+// so it points at the same place afterwards. Inserts at the position push it
+// along.
+func TransformIndex(index int, op Operation) int {
+	pos, moved := 0, index
+	for _, c := range op.components {
+		if pos > index {
+			break
+		}
+		switch {
+		case c.retain > 0:
+			pos += c.retain
+		case c.insert != nil:
+			moved += len(c.insert)
+		default:
+			moved -= min(c.delete, index-pos)
+			pos += c.delete
+		}
+	}
+	return moved
+}
+
+// cursor walks an operation's components, splitting retains and deletes
+type cursor struct {
+	components []component
+	i          int
+	used       int // units of components[i] already consumed
+}
+
+func newCursor(op Operation) *cursor {
+	return &cursor{components: op.components}
+}
+
+func (c *cursor) done() bool {
+	return c.i >= len(c.components)
+}
+
+func (c *cursor) peekInsert() []uint16 {
+	if c.done() {
+		return nil
+	}
+	return c.components[c.i].insert
+}
+
+// current returns the unconsumed part of the current retain or delete, or
+// an empty component past the end
+func (c *cursor) current() component {
+	if c.done() {
+		return component{}
+	}
+	comp := c.components[c.i]
+	if comp.retain > 0 {
+		comp.retain -= c.used
+	} else {
+		comp.delete -= c.used
+	}
+	return comp
+}
+
+func (c *cursor) next() {
+	c.i++
+	c.used = 0
+}
+
+func (c *cursor) consume(n int) {
+	if c.done() {
+		return
+	}
+	comp := c.current()
+	if n >= comp.retain+comp.delete {
+		c.next()
+		return
+	}
+	c.used += n
+}
+
+// MarshalJSON encodes the operation in its compact array form
+func (op Operation) MarshalJSON() ([]byte, error) {
+	parts := make([]any, 0, len(op.components))
+	for _, c := range op.components {
+		switch {
+		case c.retain > 0:
+			parts = append(parts, c.retain)
+		case c.insert != nil:
+			parts = append(parts, string(utf16.Decode(c.insert)))
+		default:
+			parts = append(parts, -c.delete)
+		}
+	}
+	return json.Marshal(parts)
+}
+
+// UnmarshalJSON decodes the compact array form
+func (op *Operation) UnmarshalJSON(data []byte) error {
+	var parts []json.RawMessage
+	if err := json.Unmarshal(data, &parts); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidOperation, err)
+	}
+	*op = Operation{}
+	for _, part := range parts {
+		var n int
+		if err := json.Unmarshal(part, &n); err == nil {
+			switch {
+			case n > MaxDocumentUnits || n < -MaxDocumentUnits:
+				return fmt.Errorf("%w: retains and deletes are at most %d units", ErrInvalidOperation, MaxDocumentUnits)
+			case n > 0:
+				op.Retain(n)
+			case n < 0:
+				op.Delete(-n)
+			default:
+				return fmt.Errorf("%w: zero-length component", ErrInvalidOperation)
+			}
+			continue
+		}
+		var s string
+		if err := json.Unmarshal(part, &s); err != nil || s == "" {
+			return fmt.Errorf("%w: components must be non-zero integers or non-empty strings", ErrInvalidOperation)
+		}
+		op.Insert(s)
+	}
+	// Adjacent retains or deletes merge and may still add up to too many
+	return op.validate()
+}
+
+// textUnits returns s as UTF-16 code units
+func textUnits(s string) []uint16 {
+	return utf16.Encode([]rune(s))
+}
+
+// unitsText returns UTF-16 code units as a string
+func unitsText(units []uint16) string {
+	return string(utf16.Decode(units))
+}
@@ -0,0 +1,221 @@
+package collab
+
+import (
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+func TestOperationApply(t *testing.T) {
+	var op Operation
+	op.Retain(3).Insert("lo, wor").Delete(1).Retain(2).Insert("!")
+	got, err := op.Apply(textUnits("helXld"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unitsText(got) != "hello, world!" {
+		t.Errorf("Apply = %q, want %q", unitsText(got), "hello, world!")
+	}
+	if _, err := op.Apply(textUnits("too long")); !errors.Is(err, ErrInvalidOperation) {
+		t.Errorf("Apply to a document of the wrong length = %v, want ErrInvalidOperation", err)
+	}
+
+	// Offsets count UTF-16 code units, so astral characters take two
+	var emoji Operation
+	emoji.Retain(2).Insert("é")
+	if got, err := emoji.Apply(textUnits("😀")); err != nil || unitsText(got) != "😀é" {
+		t.Errorf("Apply after an emoji = %q, %v, want %q", unitsText(got), err, "😀é")
+	}
+}
+
+func TestOperationJSON(t *testing.T) {
+	var op Operation
+	if err := json.Unmarshal([]byte(`[2, "ab", "c", -1, -2, 4]`), &op); err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(op)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `[2,"abc",-3,4]` {
+		t.Errorf("round trip = %s, want adjacent components merged", data)
+	}
+	if op.BaseLen() != 9 || op.TargetLen() != 9 {
+		t.Errorf("lengths = %d -> %d, want 9 -> 9", op.BaseLen(), op.TargetLen())
+	}
+
+	for _, bad := range []string{`{}`, `[0]`, `[""]`, `[true]`, `[1.5]`,
+		// Components past any document, alone or merged, which once
+		// overflowed BaseLen to match a short document
+		`[9223372036854775807,"x",9223372036854775807,5]`,
+		`[-9223372036854775808]`,
+		`[262144, 262144]`,
+		`[-262144, -1]`,
+	} {
+		if err := json.Unmarshal([]byte(bad), &op); !errors.Is(err, ErrInvalidOperation) {
+			t.Errorf("Unmarshal(%s) = %v, want ErrInvalidOperation", bad, err)
+		}
+	}
+}
+
+func TestOversizedOperation(t *testing.T) {
+	var op Operation
+	op.Retain(MaxDocumentUnits).Retain(1)
+	if _, err := op.Apply(make([]uint16, MaxDocumentUnits+1)); !errors.Is(err, ErrInvalidOperation) {
+		t.Errorf("Apply of an oversized retain = %v, want ErrInvalidOperation", err)
+	}
+	var del Operation
+	del.Delete(MaxDocumentUnits + 1)
+	if _, _, err := Transform(del, del); !errors.Is(err, ErrInvalidOperation) {
+		t.Errorf("Transform of an oversized delete = %v, want ErrInvalidOperation", err)
+	}
+}
+
+// FuzzApply checks that decoded operations either fail cleanly or apply to
+// a document of the length they claim
+func FuzzApply(f *testing.F) {
+	for _, seed := range []string{
+		`[3]`, `["x", 3]`, `[1, -2]`, `[1, "é😀", -1, 1]`,
+		`[9223372036854775807,"x",9223372036854775807,5]`,
+		`[-9223372036854775807, 9223372036854775807, -5]`,
+	} {
+		f.Add(seed, "abc")
+	}
+	f.Fuzz(func(t *testing.T, data, text string) {
+		var op Operation
+		if err := json.Unmarshal([]byte(data), &op); err != nil {
+			return
+		}
+		doc := textUnits(text)
+		got, err := op.Apply(doc)
+		if err != nil {
+			if !errors.Is(err, ErrInvalidOperation) {
+				t.Fatalf("Apply(%s) = %v, want ErrInvalidOperation", data, err)
+			}
+			return
+		}
+		if op.BaseLen() != len(doc) || len(got) != op.TargetLen() {
+			t.Fatalf("Apply(%s) to %d units gave %d, want %d -> %d", data, len(doc), len(got), op.BaseLen(), op.TargetLen())
+		}
+		// An operation transformed against itself still applies
+		if aPrime, _, err := Transform(op, op); err != nil {
+			t.Fatalf("Transform(%s, itself) = %v", data, err)
+		} else if _, err := aPrime.Apply(got); err != nil {
+			t.Fatalf("transformed %s does not apply: %v", data, err)
+		}
+	})
+}
+
+func TestTransformTies(t *testing.T) {
+	doc := textUnits("ac")
+	var a, b Operation
+	a.Retain(1).Insert("x").Retain(1)
+	b.Retain(1).Insert("y").Retain(1)
+	aPrime, bPrime, err := Transform(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := applyAll(t, doc, a, bPrime); got != "axyc" {
+		t.Errorf("a then b' = %q, want a's insert first", got)
+	}
+	if got := applyAll(t, doc, b, aPrime); got != "axyc" {
+		t.Errorf("b then a' = %q, want a's insert first", got)
+	}
+
+	// Overlapping deletes remove the text once
+	var del1, del2 Operation
+	del1.Retain(1).Delete(3).Retain(2)
+	del2.Retain(2).Delete(3).Retain(1)
+	d1, d2, err := Transform(del1, del2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := applyAll(t, textUnits("abcdef"), del1, d2); got != "af" {
+		t.Errorf("overlapping deletes = %q, want %q", got, "af")
+	}
+	if got := applyAll(t, textUnits("abcdef"), del2, d1); got != "af" {
+		t.Errorf("overlapping deletes = %q, want %q", got, "af")
+	}
+
+	if _, _, err := Transform(a, del1); !errors.Is(err, ErrInvalidOperation) {
+		t.Errorf("Transform of operations on different documents = %v, want ErrInvalidOperation", err)
+	}
+}
+
+func TestTransformIndex(t *testing.T) {
+	var op Operation
+	op.Retain(2).Insert("xyz").Retain(2).Delete(3).Retain(1)
+	for index, want := range map[int]int{0: 0, 2: 5, 3: 6, 4: 7, 5: 7, 6: 7, 7: 7, 8: 8} {
+		if got := TransformIndex(index, op); got != want {
+			t.Errorf("TransformIndex(%d) = %d, want %d", index, got, want)
+		}
+	}
+}
+
+// TestTransformConverges checks the transform property on random concurrent
+// edits of random documents
+func TestTransformConverges(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		doc := textUnits(randomText(rng, rng.Intn(20)))
+		a, b := randomOperation(rng, len(doc)), randomOperation(rng, len(doc))
+		aPrime, bPrime, err := Transform(a, b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		left, right := applyAll(t, doc, a, bPrime), applyAll(t, doc, b, aPrime)
+		if left != right {
+			t.Fatalf("on %q: a=%v b=%v gives %q and %q", unitsText(doc), jsonOf(a), jsonOf(b), left, right)
+		}
+	}
+}
+
+func applyAll(t *testing.T, doc []uint16, ops ...Operation) string {
+	t.Helper()
+	for _, op := range ops {
+		var err error
+		if doc, err = op.Apply(doc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return unitsText(doc)
+}
+
+func randomText(rng *rand.Rand, n int) string {
+	const alphabet = "abcdefgh \n😀é"
+	runes := []rune(alphabet)
+	text := make([]rune, n)
+	for i := range text {
+		text[i] = runes[rng.Intn(len(runes))]
+	}
+	return string(text)
+}
+
+// randomOperation edits a document of length n; the edit may split a
+// surrogate pair, which the transform must still handle
+func randomOperation(rng *rand.Rand, n int) Operation {
+	var op Operation
+	for pos := 0; pos < n; {
+		step := 1 + rng.Intn(n-pos)
+		switch rng.Intn(3) {
+		case 0:
+			op.Retain(step)
+			pos += step
+		case 1:
+			op.Delete(step)
+			pos += step
+		default:
+			op.Insert(randomText(rng, 1+rng.Intn(3)))
+		}
+	}
+	if rng.Intn(2) == 0 {
+		op.Insert(randomText(rng, 1+rng.Intn(3)))
+	}
+	return op
+}
+
+func jsonOf(op Operation) string {
+	data, _ := json.Marshal(op)
+	return string(data)
+}
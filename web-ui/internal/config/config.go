@@ -0,0 +1,564 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Duration is a time.Duration that reads and writes as a string such as "30s"
+type Duration time.Duration
+
+// MarshalJSON encodes the duration as a string
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON accepts "30s"-style strings or a number of seconds
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		parsed, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		*d = Duration(parsed)
+		return nil
+	}
+
+	var seconds float64
+	if err := json.Unmarshal(data, &seconds); err != nil {
+		return fmt.Errorf("duration must be a string like \"30s\" or a number of seconds")
+	}
+	*d = Duration(seconds * float64(time.Second))
+	return nil
+}
+
+// Config is the complete server configuration
+type Config struct {
+	// RepoRoot is the directory containing the challenge-N directories
+	RepoRoot    string `json:"repoRoot"`
+	ListenAddr  string `json:"listenAddr"`
+	TLSCertFile string `json:"tlsCertFile"`
+	TLSKeyFile  string `json:"tlsKeyFile"`
+	// BaseURL is the address users reach the server at, for absolute links
+	// such as those in feeds. See PublicURL for the fallback when it is unset.
+	BaseURL string `json:"baseUrl"`
+
+	Timeouts   TimeoutConfig    `json:"timeouts"`
+	Execution  ExecutionConfig  `json:"execution"`
+	Storage    StorageConfig    `json:"storage"`
+	Auth       AuthConfig       `json:"auth"`
+	Logging    LoggingConfig    `json:"logging"`
+	Features   FeatureConfig    `json:"features"`
+	Scoring    ScoringConfig    `json:"scoring"`
+	Similarity SimilarityConfig `json:"similarity"`
+	Webhooks   WebhooksConfig   `json:"webhooks"`
+}
+
+// TimeoutConfig bounds HTTP connections and shutdown
+type TimeoutConfig struct {
+	Read     Duration `json:"read"`
+	Write    Duration `json:"write"`
+	Idle     Duration `json:"idle"`
+	Shutdown Duration `json:"shutdown"`
+}
+
+// ExecutionConfig limits how submitted code is run
+type ExecutionConfig struct {
+	Timeout        Duration `json:"timeout"`
+	MaxConcurrent  int      `json:"maxConcurrent"`
+	MaxOutputBytes int      `json:"maxOutputBytes"`
+}
+
+// StorageConfig selects where server-side state is kept
+type StorageConfig struct {
+	// Backend is "file" (persist under DataDir) or "memory" (lost on restart)
+	Backend string `json:"backend"`
+	DataDir string `json:"dataDir"`
+}
+
+// AuthConfig configures sessions and sign-in providers
+type AuthConfig struct {
+	SessionSecret string     `json:"sessionSecret"`
+	SecureCookies bool       `json:"secureCookies"`
+	OIDC          OIDCConfig `json:"oidc"`
+	// Admins may use the admin API, e.g. to query the audit log
+	Admins []string `json:"admins"`
+}
+
+// OIDCConfig configures the optional OpenID Connect provider
+type OIDCConfig struct {
+	Name         string `json:"name"`
+	Issuer       string `json:"issuer"`
+	ClientID     string `json:"clientId"`
+	ClientSecret string `json:"clientSecret"`
+	RedirectURL  string `json:"redirectUrl"`
+}
+
+// LoggingConfig selects the log format and verbosity
+type LoggingConfig struct {
+	Format string `json:"format"` // "text" or "json"
+	Level  string `json:"level"`  // "debug", "info", "warn" or "error"
+}
+
+// FeatureConfig toggles optional functionality
+type FeatureConfig struct {
+	PasswordAuth     bool `json:"passwordAuth"`
+	Registration     bool `json:"registration"`
+	SaveToFilesystem bool `json:"saveToFilesystem"`
+	Metrics          bool `json:"metrics"`
+}
+
+// ScoringConfig selects how the main leaderboard scores users
+type ScoringConfig struct {
+	// Model is "completion" (one point per fully passed challenge) or "weighted"
+	Model string `json:"model"`
+	// DifficultyPoints are the weighted model's points per difficulty, unless
+	// a challenge's metadata.json sets points
+	DifficultyPoints map[string]float64 `json:"difficultyPoints"`
+	PartialCredit    bool               `json:"partialCredit"`
+	FirstSolverBonus float64            `json:"firstSolverBonus"`
+	// HalfLife halves a solve's points every HalfLife; zero disables decay
+	HalfLife Duration `json:"halfLife"`
+	// Tiers replace the default achievement tiers when set
+	Tiers []TierConfig `json:"tiers"`
+}
+
+// SimilarityConfig tunes the detection of copied solutions
+type SimilarityConfig struct {
+	// Threshold is the similarity score, from 0 to 1, at which a pair of
+	// solutions is flagged
+	Threshold float64 `json:"threshold"`
+}
+
+// WebhooksConfig configures outgoing webhooks and how their deliveries are
+// retried
+type WebhooksConfig struct {
+	// MaxAttempts bounds the attempts per event, including the first
+	MaxAttempts int      `json:"maxAttempts"`
+	Timeout     Duration `json:"timeout"`
+	// Backoff is the wait before the first retry; it doubles on each one
+	Backoff Duration `json:"backoff"`
+	// RankInterval is how often the leaderboard is checked for rank changes
+	RankInterval  Duration        `json:"rankInterval"`
+	Subscriptions []WebhookConfig `json:"subscriptions"`
+}
+
+// WebhookConfig sends events of the listed types, or of every type when
+// none are listed, to a URL, signed with Secret
+type WebhookConfig struct {
+	Name   string   `json:"name"`
+	URL    string   `json:"url"`
+	Secret string   `json:"secret"`
+	Events []string `json:"events"`
+}
+
+// TierConfig is an achievement tier earned by completing MinSolved challenges
+type TierConfig struct {
+	Name      string `json:"name"`
+	MinSolved int    `json:"minSolved"`
+}
+
+// Storage backends
+const (
+	StorageFile   = "file"
+	StorageMemory = "memory"
+)
+
+// Default returns the configuration used when nothing is overridden
+func Default() *Config {
+	return &Config{
+		ListenAddr: ":8080",
+		Timeouts: TimeoutConfig{
+			Read:     Duration(15 * time.Second),
+			Write:    Duration(2 * time.Minute),
+			Idle:     Duration(2 * time.Minute),
+			Shutdown: Duration(90 * time.Second),
+		},
+		Execution: ExecutionConfig{
+			Timeout:        Duration(60 * time.Second),
+			MaxConcurrent:  4,
+			MaxOutputBytes: 1 << 20,
+		},
+		Storage: StorageConfig{
+			Backend: StorageFile,
+			DataDir: "data",
+		},
+		Logging: LoggingConfig{
+			Format: "text",
+			Level:  "info",
+		},
+		Features: FeatureConfig{
+			PasswordAuth:     true,
+			Registration:     false,
+			SaveToFilesystem: true,
+			Metrics:          true,
+		},
+		Scoring: ScoringConfig{
+			Model:            "completion",
+			DifficultyPoints: map[string]float64{"Beginner": 10, "Intermediate": 20, "Advanced": 30},
+			PartialCredit:    true,
+			FirstSolverBonus: 5,
+		},
+		Similarity: SimilarityConfig{
+			Threshold: 0.8,
+		},
+		Webhooks: WebhooksConfig{
+			MaxAttempts:  5,
+			Timeout:      Duration(10 * time.Second),
+			Backoff:      Duration(time.Second),
+			RankInterval: Duration(time.Minute),
+		},
+	}
+}
+
+// Load builds the configuration from defaults, an optional JSON config file,
+// WEBUI_* environment variables and command-line flags, in increasing order
+// of precedence. The config file is named by -config or WEBUI_CONFIG.
+func Load(args []string, getenv func(string) string) (*Config, error) {
+	cfg := Default()
+
+	fs := flag.NewFlagSet("web-ui", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	configFile := fs.String("config", getenv("WEBUI_CONFIG"), "path to a JSON config file")
+	flags := bindFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if *configFile != "" {
+		if err := cfg.loadFile(*configFile); err != nil {
+			return nil, err
+		}
+	}
+	if err := cfg.applyEnv(getenv); err != nil {
+		return nil, err
+	}
+
+	// Only flags that were set explicitly override file and env values
+	var flagErr error
+	fs.Visit(func(f *flag.Flag) {
+		if apply, ok := flags[f.Name]; ok && flagErr == nil {
+			flagErr = apply(cfg, f.Value.String())
+		}
+	})
+	if flagErr != nil {
+		return nil, flagErr
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Usage returns the flag help text
+func Usage() string {
+	fs := flag.NewFlagSet("web-ui", flag.ContinueOnError)
+	fs.String("config", "", "path to a JSON config file (env WEBUI_CONFIG)")
+	bindFlags(fs)
+
+	var b strings.Builder
+	fs.SetOutput(&b)
+	fs.PrintDefaults()
+	return b.String()
+}
+
+// setter applies a raw string value to a config field
+type setter func(cfg *Config, value string) error
+
+// settings maps each overridable option to its flag name, env var and setter
+var settings = []struct {
+	flag, env, usage string
+	set              setter
+}{
+	{"root", "WEBUI_REPO_ROOT", "repository root containing challenge-N directories", setString(func(c *Config) *string { return &c.RepoRoot })},
+	{"addr", "WEBUI_ADDR", "listen address", setString(func(c *Config) *string { return &c.ListenAddr })},
+	{"tls-cert", "WEBUI_TLS_CERT", "TLS certificate file", setString(func(c *Config) *string { return &c.TLSCertFile })},
+	{"tls-key", "WEBUI_TLS_KEY", "TLS key file", setString(func(c *Config) *string { return &c.TLSKeyFile })},
+	{"base-url", "WEBUI_BASE_URL", "address users reach the server at, for absolute links such as those in feeds", setString(func(c *Config) *string { return &c.BaseURL })},
+	{"read-timeout", "WEBUI_READ_TIMEOUT", "maximum duration for reading a request", setDuration(func(c *Config) *Duration { return &c.Timeouts.Read })},
+	{"write-timeout", "WEBUI_WRITE_TIMEOUT", "maximum duration for writing a response", setDuration(func(c *Config) *Duration { return &c.Timeouts.Write })},
+	{"idle-timeout", "WEBUI_IDLE_TIMEOUT", "keep-alive idle timeout", setDuration(func(c *Config) *Duration { return &c.Timeouts.Idle })},
+	{"shutdown-timeout", "WEBUI_SHUTDOWN_TIMEOUT", "time allowed for in-flight runs to finish on shutdown", setDuration(func(c *Config) *Duration { return &c.Timeouts.Shutdown })},
+	{"exec-timeout", "WEBUI_EXEC_TIMEOUT", "maximum duration of a test run", setDuration(func(c *Config) *Duration { return &c.Execution.Timeout })},
+	{"exec-max-concurrent", "WEBUI_EXEC_MAX_CONCURRENT", "maximum concurrent test runs", setInt(func(c *Config) *int { return &c.Execution.MaxConcurrent })},
+	{"exec-max-output", "WEBUI_EXEC_MAX_OUTPUT", "maximum bytes of test output returned", setInt(func(c *Config) *int { return &c.Execution.MaxOutputBytes })},
+	{"storage", "WEBUI_STORAGE", "storage backend: file or memory", setString(func(c *Config) *string { return &c.Storage.Backend })},
+	{"data-dir", "WEBUI_DATA_DIR", "directory for server-side state", setString(func(c *Config) *string { return &c.Storage.DataDir })},
+	{"session-secret", "WEBUI_SESSION_SECRET", "secret for signing cookies", setString(func(c *Config) *string { return &c.Auth.SessionSecret })},
+	{"secure-cookies", "WEBUI_SECURE_COOKIES", "mark cookies Secure", setBool(func(c *Config) *bool { return &c.Auth.SecureCookies })},
+	{"oidc-name", "WEBUI_OIDC_NAME", "OIDC provider name used in URLs", setString(func(c *Config) *string { return &c.Auth.OIDC.Name })},
+	{"oidc-issuer", "WEBUI_OIDC_ISSUER", "OIDC issuer URL", setString(func(c *Config) *string { return &c.Auth.OIDC.Issuer })},
+	{"oidc-client-id", "WEBUI_OIDC_CLIENT_ID", "OIDC client ID", setString(func(c *Config) *string { return &c.Auth.OIDC.ClientID })},
+	{"oidc-client-secret", "WEBUI_OIDC_CLIENT_SECRET", "OIDC client secret", setString(func(c *Config) *string { return &c.Auth.OIDC.ClientSecret })},
+	{"oidc-redirect-url", "WEBUI_OIDC_REDIRECT_URL", "OIDC redirect URL", setString(func(c *Config) *string { return &c.Auth.OIDC.RedirectURL })},
+	{"admins", "WEBUI_ADMINS", "comma-separated usernames allowed to use the admin API", setStringList(func(c *Config) *[]string { return &c.Auth.Admins })},
+	{"log-format", "WEBUI_LOG_FORMAT", "log format: text or json", setString(func(c *Config) *string { return &c.Logging.Format })},
+	{"log-level", "WEBUI_LOG_LEVEL", "log level: debug, info, warn or error", setString(func(c *Config) *string { return &c.Logging.Level })},
+	{"feature-password-auth", "WEBUI_FEATURE_PASSWORD_AUTH", "enable local password accounts", setBool(func(c *Config) *bool { return &c.Features.PasswordAuth })},
+	{"feature-registration", "WEBUI_FEATURE_REGISTRATION", "allow creating local accounts", setBool(func(c *Config) *bool { return &c.Features.Registration })},
+	{"feature-save-to-filesystem", "WEBUI_FEATURE_SAVE_TO_FILESYSTEM", "allow saving solutions into the repository", setBool(func(c *Config) *bool { return &c.Features.SaveToFilesystem })},
+	{"feature-metrics", "WEBUI_FEATURE_METRICS", "expose Prometheus metrics at /metrics", setBool(func(c *Config) *bool { return &c.Features.Metrics })},
+	{"scoring-model", "WEBUI_SCORING_MODEL", "leaderboard scoring model: completion or weighted", setString(func(c *Config) *string { return &c.Scoring.Model })},
+	{"scoring-half-life", "WEBUI_SCORING_HALF_LIFE", "halve weighted points every this long since the solve; 0 disables decay", setDuration(func(c *Config) *Duration { return &c.Scoring.HalfLife })},
+	{"similarity-threshold", "WEBUI_SIMILARITY_THRESHOLD", "similarity score from 0 to 1 at which solution pairs are flagged", setFloat(func(c *Config) *float64 { return &c.Similarity.Threshold })},
+	{"webhook-max-attempts", "WEBUI_WEBHOOK_MAX_ATTEMPTS", "attempts to deliver each webhook event, including the first", setInt(func(c *Config) *int { return &c.Webhooks.MaxAttempts })},
+	{"webhook-timeout", "WEBUI_WEBHOOK_TIMEOUT", "maximum duration of a webhook delivery attempt", setDuration(func(c *Config) *Duration { return &c.Webhooks.Timeout })},
+	{"webhook-backoff", "WEBUI_WEBHOOK_BACKOFF", "wait before the first webhook retry; doubles on each retry", setDuration(func(c *Config) *Duration { return &c.Webhooks.Backoff })},
+	{"webhook-rank-interval", "WEBUI_WEBHOOK_RANK_INTERVAL", "how often the leaderboard is checked for rank changes to send to webhooks", setDuration(func(c *Config) *Duration { return &c.Webhooks.RankInterval })},
+}
+
+// bindFlags registers every setting as a string flag and returns the setters by flag name
+func bindFlags(fs *flag.FlagSet) map[string]setter {
+	setters := make(map[string]setter, len(settings))
+	for _, s := range settings {
+		fs.String(s.flag, "", fmt.Sprintf("%s (env %s)", s.usage, s.env))
+		setters[s.flag] = s.set
+	}
+	return setters
+}
+
+// loadFile merges a JSON config file over the current values
+func (cfg *Config) loadFile(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %v", err)
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(string(content)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(cfg); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %v", path, err)
+	}
+
+	// Relative paths in the file are relative to the file itself
+	base := filepath.Dir(path)
+	for _, p := range []*string{&cfg.RepoRoot, &cfg.TLSCertFile, &cfg.TLSKeyFile, &cfg.Storage.DataDir} {
+		if *p != "" && !filepath.IsAbs(*p) {
+			*p = filepath.Join(base, *p)
+		}
+	}
+	return nil
+}
+
+// applyEnv overrides values from WEBUI_* environment variables
+func (cfg *Config) applyEnv(getenv func(string) string) error {
+	for _, s := range settings {
+		if value := getenv(s.env); value != "" {
+			if err := s.set(cfg, value); err != nil {
+				return fmt.Errorf("%s: %v", s.env, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Validate checks the configuration for inconsistent values
+func (cfg *Config) Validate() error {
+	var errs []string
+
+	if cfg.ListenAddr == "" {
+		errs = append(errs, "listen address must not be empty")
+	}
+	if (cfg.TLSCertFile == "") != (cfg.TLSKeyFile == "") {
+		errs = append(errs, "TLS requires both a certificate and a key file")
+	}
+	if cfg.BaseURL != "" {
+		if u, err := url.Parse(cfg.BaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.RawQuery != "" || u.Fragment != "" {
+			errs = append(errs, "base URL must be an http or https URL without a query or fragment")
+		}
+	}
+	if cfg.Timeouts.Read <= 0 || cfg.Timeouts.Write <= 0 || cfg.Timeouts.Idle <= 0 || cfg.Timeouts.Shutdown <= 0 {
+		errs = append(errs, "HTTP timeouts must be positive")
+	}
+	if cfg.Execution.Timeout <= 0 {
+		errs = append(errs, "execution timeout must be positive")
+	} else if cfg.Timeouts.Write <= cfg.Execution.Timeout {
+		errs = append(errs, "write timeout must exceed the execution timeout so run results can be delivered")
+	}
+	if cfg.Execution.MaxConcurrent < 1 {
+		errs = append(errs, "execution max concurrent must be at least 1")
+	}
+	if cfg.Execution.MaxOutputBytes < 1 {
+		errs = append(errs, "execution max output must be at least 1 byte")
+	}
+	switch cfg.Storage.Backend {
+	case StorageFile:
+		if cfg.Storage.DataDir == "" {
+			errs = append(errs, "file storage requires a data directory")
+		}
+	case StorageMemory:
+	default:
+		errs = append(errs, fmt.Sprintf("unknown storage backend %q", cfg.Storage.Backend))
+	}
+	if cfg.Auth.OIDC.Issuer != "" && (cfg.Auth.OIDC.ClientID == "" || cfg.Auth.OIDC.RedirectURL == "") {
+		errs = append(errs, "OIDC requires a client ID and redirect URL")
+	}
+	if cfg.Logging.Format != "text" && cfg.Logging.Format != "json" {
+		errs = append(errs, fmt.Sprintf("unknown log format %q", cfg.Logging.Format))
+	}
+	switch strings.ToLower(cfg.Logging.Level) {
+	case "debug", "info", "warn", "error":
+	default:
+		errs = append(errs, fmt.Sprintf("unknown log level %q", cfg.Logging.Level))
+	}
+
+	if cfg.Scoring.Model == "" {
+		errs = append(errs, "scoring model must not be empty")
+	}
+	for difficulty, points := range cfg.Scoring.DifficultyPoints {
+		if points < 0 {
+			errs = append(errs, fmt.Sprintf("points for %s challenges must not be negative", difficulty))
+		}
+	}
+	if cfg.Scoring.FirstSolverBonus < 0 || cfg.Scoring.HalfLife < 0 {
+		errs = append(errs, "first solver bonus and half-life must not be negative")
+	}
+	for i, tier := range cfg.Scoring.Tiers {
+		if tier.Name == "" {
+			errs = append(errs, "achievement tiers need a name")
+		}
+		if i > 0 && tier.MinSolved <= cfg.Scoring.Tiers[i-1].MinSolved {
+			errs = append(errs, "achievement tiers must be listed by increasing minSolved")
+		}
+	}
+	if cfg.Similarity.Threshold < 0 || cfg.Similarity.Threshold > 1 {
+		errs = append(errs, "similarity threshold must be between 0 and 1")
+	}
+	if cfg.Webhooks.MaxAttempts < 1 {
+		errs = append(errs, "webhook max attempts must be at least 1")
+	}
+	if cfg.Webhooks.Timeout <= 0 || cfg.Webhooks.Backoff <= 0 || cfg.Webhooks.RankInterval <= 0 {
+		errs = append(errs, "webhook timeout, backoff and rank interval must be positive")
+	}
+	for _, hook := range cfg.Webhooks.Subscriptions {
+		if hook.Name == "" || hook.URL == "" || hook.Secret == "" {
+			errs = append(errs, "webhooks need a name, a URL and a secret")
+			break
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.New("invalid configuration: " + strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// TLSEnabled reports whether the server should serve HTTPS
+func (cfg *Config) TLSEnabled() bool {
+	return cfg.TLSCertFile != "" && cfg.TLSKeyFile != ""
+}
+
+// PublicURL returns the base URL without a trailing slash. When none is
+// configured it is never taken from a request's Host header, which a client
+// controls; links point at localhost on the listen port instead.
+func (cfg *Config) PublicURL() string {
+	if cfg.BaseURL != "" {
+		return strings.TrimRight(cfg.BaseURL, "/")
+	}
+	scheme := "http"
+	if cfg.TLSEnabled() || cfg.Auth.SecureCookies {
+		scheme = "https"
+	}
+	host := "localhost"
+	if _, port, err := net.SplitHostPort(cfg.ListenAddr); err == nil && port != "" {
+		host = net.JoinHostPort(host, port)
+	}
+	return scheme + "://" + host
+}
+
+// IsAdmin reports whether username is listed in Auth.Admins
+func (cfg *Config) IsAdmin(username string) bool {
+	if username == "" {
+		return false
+	}
+	for _, admin := range cfg.Auth.Admins {
+		if strings.EqualFold(admin, username) {
+			return true
+		}
+	}
+	return false
+}
+
+// DataPath returns a path under the data directory, or "" for the memory backend
+func (cfg *Config) DataPath(name string) string {
+	if cfg.Storage.Backend == StorageMemory {
+		return ""
+	}
+	return filepath.Join(cfg.Storage.DataDir, name)
+}
+
+func setString(field func(*Config) *string) setter {
+	return func(cfg *Config, value string) error {
+		*field(cfg) = value
+		return nil
+	}
+}
+
+func setStringList(field func(*Config) *[]string) setter {
+	return func(cfg *Config, value string) error {
+		var list []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		*field(cfg) = list
+		return nil
+	}
+}
+
+func setInt(field func(*Config) *int) setter {
+	return func(cfg *Config, value string) error {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", value)
+		}
+		*field(cfg) = n
+		return nil
+	}
+}
+
+func setFloat(field func(*Config) *float64) setter {
+	return func(cfg *Config, value string) error {
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", value)
+		}
+		*field(cfg) = f
+		return nil
+	}
+}
+
+func setBool(field func(*Config) *bool) setter {
+	return func(cfg *Config, value string) error {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", value)
+		}
+		*field(cfg) = b
+		return nil
+	}
+}
+
+func setDuration(field func(*Config) *Duration) setter {
+	return func(cfg *Config, value string) error {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", value)
+		}
+		*field(cfg) = Duration(d)
+		return nil
+	}
+}
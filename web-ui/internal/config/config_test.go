@@ -0,0 +1,142 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func envFrom(values map[string]string) func(string) string {
+	return func(key string) string { return values[key] }
+}
+
+func TestLoadDefaults(t *testing.T) {
+	cfg, err := Load(nil, envFrom(nil))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.ListenAddr != ":8080" || cfg.Storage.Backend != StorageFile || !cfg.Features.SaveToFilesystem {
+		t.Errorf("unexpected defaults: %+v", cfg)
+	}
+	if cfg.DataPath("accounts.json") != filepath.Join("data", "accounts.json") {
+		t.Errorf("DataPath = %q", cfg.DataPath("accounts.json"))
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "web-ui.json")
+	err := os.WriteFile(configPath, []byte(`{
+		"repoRoot": "repo",
+		"listenAddr": ":9000",
+		"execution": {"timeout": "90s", "maxConcurrent": 2},
+		"storage": {"backend": "memory"},
+		"features": {"registration": false}
+	}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	env := envFrom(map[string]string{
+		"WEBUI_CONFIG":              configPath,
+		"WEBUI_ADDR":                ":9100",
+		"WEBUI_EXEC_MAX_CONCURRENT": "8",
+	})
+	cfg, err := Load([]string{"-addr", "127.0.0.1:9200"}, env)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	if cfg.RepoRoot != filepath.Join(dir, "repo") {
+		t.Errorf("RepoRoot = %q, want path relative to the config file", cfg.RepoRoot)
+	}
+	if cfg.ListenAddr != "127.0.0.1:9200" {
+		t.Errorf("ListenAddr = %q, want flag value", cfg.ListenAddr)
+	}
+	if cfg.Execution.MaxConcurrent != 8 {
+		t.Errorf("MaxConcurrent = %d, want env value", cfg.Execution.MaxConcurrent)
+	}
+	if time.Duration(cfg.Execution.Timeout) != 90*time.Second {
+		t.Errorf("Timeout = %v, want file value", time.Duration(cfg.Execution.Timeout))
+	}
+	if cfg.Features.Registration || !cfg.Features.PasswordAuth {
+		t.Errorf("Features = %+v, want file override merged with defaults", cfg.Features)
+	}
+	if cfg.DataPath("accounts.json") != "" {
+		t.Error("memory backend should not have data paths")
+	}
+}
+
+func TestAdmins(t *testing.T) {
+	cfg, err := Load([]string{"-admins", " alice, ,Bob "}, envFrom(nil))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(cfg.Auth.Admins) != 2 {
+		t.Fatalf("Admins = %q, want two entries", cfg.Auth.Admins)
+	}
+	if !cfg.IsAdmin("bob") || !cfg.IsAdmin("Alice") || cfg.IsAdmin("mallory") || cfg.IsAdmin("") {
+		t.Errorf("IsAdmin gave unexpected results for %q", cfg.Auth.Admins)
+	}
+}
+
+func TestPublicURL(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{nil, "http://localhost:8080"},
+		{[]string{"-addr", "0.0.0.0:9000", "-secure-cookies", "true"}, "https://localhost:9000"},
+		{[]string{"-base-url", "https://go.example.com/practice/"}, "https://go.example.com/practice"},
+	}
+	for _, tt := range tests {
+		cfg, err := Load(tt.args, envFrom(nil))
+		if err != nil {
+			t.Fatalf("Load(%q): %v", tt.args, err)
+		}
+		if got := cfg.PublicURL(); got != tt.want {
+			t.Errorf("PublicURL with %q = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	dir := t.TempDir()
+	unknownField := filepath.Join(dir, "unknown.json")
+	os.WriteFile(unknownField, []byte(`{"listenAdress": ":1"}`), 0644)
+	unorderedTiers := filepath.Join(dir, "tiers.json")
+	os.WriteFile(unorderedTiers, []byte(`{"scoring": {"tiers": [{"name": "Pro", "minSolved": 10}, {"name": "Rookie", "minSolved": 0}]}}`), 0644)
+
+	tests := []struct {
+		name string
+		args []string
+		env  map[string]string
+		want string
+	}{
+		{"bad duration", []string{"-exec-timeout", "soon"}, nil, "invalid duration"},
+		{"bad bool env", nil, map[string]string{"WEBUI_SECURE_COOKIES": "maybe"}, "WEBUI_SECURE_COOKIES"},
+		{"tls without key", []string{"-tls-cert", "cert.pem"}, nil, "TLS requires"},
+		{"unknown backend", []string{"-storage", "s3"}, nil, "unknown storage backend"},
+		{"zero concurrency", []string{"-exec-max-concurrent", "0"}, nil, "at least 1"},
+		{"unknown file field", []string{"-config", unknownField}, nil, "unknown field"},
+		{"oidc without client", []string{"-oidc-issuer", "https://idp.example"}, nil, "OIDC requires"},
+		{"unknown log format", []string{"-log-format", "xml"}, nil, "unknown log format"},
+		{"unknown log level", nil, map[string]string{"WEBUI_LOG_LEVEL": "loud"}, "unknown log level"},
+		{"negative half-life", []string{"-scoring-half-life", "-1h"}, nil, "must not be negative"},
+		{"bad threshold", []string{"-similarity-threshold", "high"}, nil, "invalid number"},
+		{"threshold above 1", nil, map[string]string{"WEBUI_SIMILARITY_THRESHOLD": "1.5"}, "between 0 and 1"},
+		{"no webhook attempts", []string{"-webhook-max-attempts", "0"}, nil, "at least 1"},
+		{"bad webhook backoff", nil, map[string]string{"WEBUI_WEBHOOK_BACKOFF": "soon"}, "invalid duration"},
+		{"relative base URL", []string{"-base-url", "example.com"}, nil, "base URL"},
+		{"base URL with query", nil, map[string]string{"WEBUI_BASE_URL": "https://example.com/?a=1"}, "base URL"},
+		{"unordered tiers", []string{"-config", unorderedTiers}, nil, "increasing minSolved"},
+	}
+	for _, tt := range tests {
+		_, err := Load(tt.args, envFrom(tt.env))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: err = %v, want it to mention %q", tt.name, err, tt.want)
+		}
+	}
+}
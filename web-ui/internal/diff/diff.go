@@ -0,0 +1,286 @@
+// Package diff compares texts line by line and formats the result as a
+// unified diff, as diff -u and git do.
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// contextLines is how many unchanged lines surround each change
+	contextLines = 3
+	// maxDistance bounds the edit search; texts further apart than this many
+	// inserted and deleted lines are diffed as a plain replacement of the
+	// lines between their common prefix and suffix
+	maxDistance = 2000
+)
+
+// Kinds of Line
+const (
+	Equal  = "equal"
+	Delete = "delete"
+	Insert = "insert"
+)
+
+// Line is one line of a diff, without its line terminator
+type Line struct {
+	Kind string `json:"kind" enum:"equal,delete,insert"`
+	Text string `json:"text"`
+}
+
+// Result is a line diff of two texts
+type Result struct {
+	// Unified is the diff in unified format, empty when the texts are equal
+	Unified string
+	// Lines holds every line of both texts in diff order, for side-by-side views
+	Lines   []Line
+	Added   int
+	Removed int
+}
+
+// Compare diffs from against to. The names label the texts in the
+// unified diff's header.
+func Compare(fromName, toName, from, to string) Result {
+	a, b := splitLines(from), splitLines(to)
+	edits := lineEdits(a, b)
+
+	result := Result{Lines: make([]Line, 0, len(edits))}
+	for _, e := range edits {
+		switch e.kind {
+		case opEqual:
+			result.Lines = append(result.Lines, Line{Kind: Equal, Text: strings.TrimSuffix(a[e.a], "\n")})
+		case opInsert:
+			result.Added++
+			result.Lines = append(result.Lines, Line{Kind: Insert, Text: strings.TrimSuffix(b[e.b], "\n")})
+		case opDelete:
+			result.Removed++
+			result.Lines = append(result.Lines, Line{Kind: Delete, Text: strings.TrimSuffix(a[e.a], "\n")})
+		}
+	}
+	if result.Added == 0 && result.Removed == 0 {
+		return result
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+	for i := 0; i < len(edits); {
+		if edits[i].kind == opEqual {
+			i++
+			continue
+		}
+		// Extend the hunk over changes separated by at most twice the
+		// context, so neighbouring hunks never overlap
+		last := i
+		for j := i; j < len(edits); j++ {
+			if edits[j].kind != opEqual {
+				last = j
+			} else if j-last > 2*contextLines {
+				break
+			}
+		}
+		start := max(0, i-contextLines)
+		stop := min(len(edits), last+contextLines+1)
+		writeHunk(&out, edits[start:stop], a, b)
+		i = stop
+	}
+	result.Unified = out.String()
+	return result
+}
+
+// MapLine follows line, numbered from 1, of from to the same line in to, so
+// a note on a line stays on it as the text is edited. When the line was
+// changed or removed it returns the line now at its place, or 0 for an empty
+// to, and false.
+func MapLine(from, to string, line int) (int, bool) {
+	a, b := splitLines(from), splitLines(to)
+	if line >= 1 && line <= len(a) {
+		for _, e := range lineEdits(a, b) {
+			if e.kind == opInsert || e.a != line-1 {
+				continue
+			}
+			if e.kind == opEqual {
+				return e.b + 1, true
+			}
+			return min(e.b+1, len(b)), false
+		}
+	}
+	return min(max(line, 1), len(b)), false
+}
+
+type opKind int
+
+const (
+	opEqual opKind = iota
+	opDelete
+	opInsert
+)
+
+// edit is one line of a diff; a and b are the line's index in each text, or
+// for lines missing from a text, the number of that text's lines before it
+type edit struct {
+	kind opKind
+	a, b int
+}
+
+func writeHunk(out *strings.Builder, edits []edit, a, b []string) {
+	aCount, bCount := 0, 0
+	for _, e := range edits {
+		if e.kind != opInsert {
+			aCount++
+		}
+		if e.kind != opDelete {
+			bCount++
+		}
+	}
+	fmt.Fprintf(out, "@@ -%s +%s @@\n", hunkRange(edits[0].a, aCount), hunkRange(edits[0].b, bCount))
+	for _, e := range edits {
+		switch e.kind {
+		case opEqual:
+			writeLine(out, ' ', a[e.a])
+		case opDelete:
+			writeLine(out, '-', a[e.a])
+		case opInsert:
+			writeLine(out, '+', b[e.b])
+		}
+	}
+}
+
+// hunkRange formats a hunk's start line and length. An empty range names
+// the line it follows.
+func hunkRange(start, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprint(start + 1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+func writeLine(out *strings.Builder, prefix byte, line string) {
+	out.WriteByte(prefix)
+	out.WriteString(line)
+	if !strings.HasSuffix(line, "\n") {
+		out.WriteString("\n\\ No newline at end of file\n")
+	}
+}
+
+// splitLines splits text after each \n, so the last line keeps whether it
+// was terminated
+func splitLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// lineEdits returns the edits that turn a into b, with as few inserted and
+// deleted lines as the search bound allows
+func lineEdits(a, b []string) []edit {
+	// Common prefixes and suffixes are kept as is, which is what people
+	// expect and keeps the search small for typical edits
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	edits := make([]edit, 0, len(a)+len(b))
+	for i := 0; i < prefix; i++ {
+		edits = append(edits, edit{kind: opEqual, a: i, b: i})
+	}
+	middle, ok := myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])
+	if !ok {
+		middle = middle[:0]
+		for i := prefix; i < len(a)-suffix; i++ {
+			middle = append(middle, edit{kind: opDelete, a: i - prefix, b: 0})
+		}
+		for j := prefix; j < len(b)-suffix; j++ {
+			middle = append(middle, edit{kind: opInsert, a: len(a) - suffix - prefix, b: j - prefix})
+		}
+	}
+	for _, e := range middle {
+		edits = append(edits, edit{kind: e.kind, a: e.a + prefix, b: e.b + prefix})
+	}
+	for i := 0; i < suffix; i++ {
+		edits = append(edits, edit{kind: opEqual, a: len(a) - suffix + i, b: len(b) - suffix + i})
+	}
+	return edits
+}
+
+// myers finds a shortest edit script with Myers' O(ND) algorithm. It gives
+// up, returning false, once more than maxDistance edits would be needed.
+func myers(a, b []string) ([]edit, bool) {
+	n, m := len(a), len(b)
+	offset := n + m + 1
+	// v[offset+k] is the furthest x reached on diagonal k = x - y
+	v := make([]int, 2*offset+1)
+	// trace[d] holds v over diagonals -d..d as it was before step d
+	var trace [][]int
+	for d := 0; d <= n+m; d++ {
+		if d > maxDistance {
+			return nil, false
+		}
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, n, m), true
+			}
+		}
+	}
+	return backtrack(trace, n, m), true
+}
+
+// backtrack walks the search from the end of both texts back to the start
+func backtrack(trace [][]int, n, m int) []edit {
+	var edits []edit
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		prev := trace[d]
+		k := x - y
+		prevK := k - 1
+		if k == -d || (k != d && prev[k-1+d] < prev[k+1+d]) {
+			prevK = k + 1
+		}
+		prevX := prev[prevK+d]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			edits = append(edits, edit{kind: opEqual, a: x, b: y})
+		}
+		if x == prevX {
+			y--
+			edits = append(edits, edit{kind: opInsert, a: x, b: y})
+		} else {
+			x--
+			edits = append(edits, edit{kind: opDelete, a: x, b: y})
+		}
+	}
+	for x > 0 {
+		x--
+		y--
+		edits = append(edits, edit{kind: opEqual, a: x, b: y})
+	}
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+	return edits
+}
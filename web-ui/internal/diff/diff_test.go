@@ -0,0 +1,190 @@
+package diff
+
+import (
+	"math/rand"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to string
+		want     string
+	}{
+		{name: "equal", from: "a\nb\n", to: "a\nb\n", want: ""},
+		{
+			name: "change in the middle",
+			from: "1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+			to:   "1\n2\n3\n4\nfive\n6\n7\n8\n9\n",
+			want: "--- v1\n+++ v2\n@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n",
+		},
+		{
+			name: "separate hunks",
+			from: "a\n1\n2\n3\n4\n5\n6\n7\nb\n",
+			to:   "A\n1\n2\n3\n4\n5\n6\n7\nB\n",
+			want: "--- v1\n+++ v2\n@@ -1,4 +1,4 @@\n-a\n+A\n 1\n 2\n 3\n@@ -6,4 +6,4 @@\n 5\n 6\n 7\n-b\n+B\n",
+		},
+		{
+			name: "close changes share a hunk",
+			from: "a\n1\n2\n3\n4\n5\n6\nb\n",
+			to:   "A\n1\n2\n3\n4\n5\n6\nB\n",
+			want: "--- v1\n+++ v2\n@@ -1,8 +1,8 @@\n-a\n+A\n 1\n 2\n 3\n 4\n 5\n 6\n-b\n+B\n",
+		},
+		{
+			name: "from empty",
+			from: "",
+			to:   "x\ny\n",
+			want: "--- v1\n+++ v2\n@@ -0,0 +1,2 @@\n+x\n+y\n",
+		},
+		{
+			name: "insert after a line",
+			from: "a\nb\n",
+			to:   "a\nb\nc\n",
+			want: "--- v1\n+++ v2\n@@ -1,2 +1,3 @@\n a\n b\n+c\n",
+		},
+		{
+			name: "missing final newline",
+			from: "a\nb",
+			to:   "a\nb\n",
+			want: "--- v1\n+++ v2\n@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+b\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Compare("v1", "v2", tt.from, tt.to)
+			if got.Unified != tt.want {
+				t.Errorf("Unified =\n%s\nwant\n%s", got.Unified, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompareCounts(t *testing.T) {
+	got := Compare("a", "b", "x\ny\nz\n", "x\nY\nz\nw\n")
+	if got.Added != 2 || got.Removed != 1 {
+		t.Errorf("Added, Removed = %d, %d, want 2, 1", got.Added, got.Removed)
+	}
+	want := []Line{{Equal, "x"}, {Delete, "y"}, {Insert, "Y"}, {Equal, "z"}, {Insert, "w"}}
+	if !reflect.DeepEqual(got.Lines, want) {
+		t.Errorf("Lines = %v, want %v", got.Lines, want)
+	}
+}
+
+func TestMapLine(t *testing.T) {
+	from := "a\nb\nc\nd\ne\n"
+	tests := []struct {
+		name string
+		to   string
+		line int
+		want int
+		ok   bool
+	}{
+		{"unchanged", from, 3, 3, true},
+		{"lines inserted above", "x\ny\na\nb\nc\nd\ne\n", 3, 5, true},
+		{"lines removed above", "c\nd\ne\n", 4, 2, true},
+		{"line changed", "a\nb\nC\nd\ne\n", 3, 3, false},
+		{"line removed", "a\nb\nd\ne\n", 3, 3, false},
+		{"last line removed", "a\nb\nc\nd\n", 5, 4, false},
+		{"everything removed", "", 2, 0, false},
+		{"out of range", from, 9, 5, false},
+	}
+	for _, tt := range tests {
+		got, ok := MapLine(from, tt.to, tt.line)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("%s: MapLine = %d, %v, want %d, %v", tt.name, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+// TestCompareRandom checks on random texts that diffs apply and are as
+// short as possible
+func TestCompareRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	randomText := func() string {
+		lines := make([]string, rng.Intn(12))
+		for i := range lines {
+			lines[i] = strconv.Itoa(rng.Intn(4)) + "\n"
+		}
+		return strings.Join(lines, "")
+	}
+	for i := 0; i < 2000; i++ {
+		from, to := randomText(), randomText()
+		result := Compare("a", "b", from, to)
+		if got := apply(t, from, result.Unified); got != to {
+			t.Fatalf("applying the diff of %q and %q gives %q:\n%s", from, to, got, result.Unified)
+		}
+		a, b := splitLines(from), splitLines(to)
+		if want := len(a) + len(b) - 2*lcs(a, b); result.Added+result.Removed != want {
+			t.Fatalf("diff of %q and %q has %d changed lines, want %d", from, to, result.Added+result.Removed, want)
+		}
+	}
+}
+
+func TestCompareFallsBackForDistantTexts(t *testing.T) {
+	var from, to strings.Builder
+	for i := 0; i < maxDistance; i++ {
+		from.WriteString("a" + strconv.Itoa(i) + "\n")
+		to.WriteString("b" + strconv.Itoa(i) + "\n")
+	}
+	result := Compare("a", "b", "same\n"+from.String()+"end\n", "same\n"+to.String()+"end\n")
+	if result.Added != maxDistance || result.Removed != maxDistance {
+		t.Errorf("Added, Removed = %d, %d, want %d each", result.Added, result.Removed, maxDistance)
+	}
+	if !strings.HasPrefix(result.Unified, "--- a\n+++ b\n@@ -1,2002 +1,2002 @@\n same\n-a0\n") {
+		t.Errorf("Unified starts %q", result.Unified[:60])
+	}
+}
+
+// apply applies a unified diff of texts whose lines all end in \n
+func apply(t *testing.T, text, unified string) string {
+	if unified == "" {
+		return text
+	}
+	lines := splitLines(text)
+	var out []string
+	pos := 0
+	for _, line := range strings.Split(strings.TrimSuffix(unified, "\n"), "\n")[2:] {
+		switch {
+		case strings.HasPrefix(line, "@@"):
+			var start int
+			fields := strings.Fields(line)
+			start, _ = strconv.Atoi(strings.Split(strings.TrimPrefix(fields[1], "-"), ",")[0])
+			if !strings.HasSuffix(fields[1], ",0") {
+				start--
+			}
+			out = append(out, lines[pos:start]...)
+			pos = start
+		case line[0] == ' ':
+			out = append(out, lines[pos])
+			pos++
+		case line[0] == '-':
+			if lines[pos] != line[1:]+"\n" {
+				t.Fatalf("diff deletes %q, text has %q", line[1:], lines[pos])
+			}
+			pos++
+		case line[0] == '+':
+			out = append(out, line[1:]+"\n")
+		}
+	}
+	return strings.Join(append(out, lines[pos:]...), "")
+}
+
+func lcs(a, b []string) int {
+	table := make([][]int, len(a)+1)
+	for i := range table {
+		table[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				table[i][j] = table[i+1][j+1] + 1
+			} else {
+				table[i][j] = max(table[i+1][j], table[i][j+1])
+			}
+		}
+	}
+	return table[0][0]
+}
@@ -0,0 +1,109 @@
+// Package events carries what happens on the server, such as passed
+// submissions and leaderboard moves, to whoever subscribes, such as the
+// outgoing webhooks.
+package events
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// Event types
+const (
+	SubmissionPassed = "submission.passed"
+	SubmissionFailed = "submission.failed"
+	RankChanged      = "leaderboard.rank_changed"
+	ChallengeAdded   = "challenge.added"
+	Ping             = "ping" // sent to one webhook on request, whatever it subscribes to
+)
+
+// Types lists the event types that can be subscribed to
+var Types = []string{SubmissionPassed, SubmissionFailed, RankChanged, ChallengeAdded}
+
+// Event is something that happened, with a payload that depends on its type
+type Event struct {
+	ID   string    `json:"id" doc:"Unique per event; repeated on every delivery attempt"`
+	Type string    `json:"type" enum:"submission.passed,submission.failed,leaderboard.rank_changed,challenge.added,ping"`
+	Time time.Time `json:"time"`
+	Data any       `json:"data" doc:"A Submission, RankChange or Challenge payload, by type"`
+}
+
+// Submission is the payload of submission.passed and submission.failed
+type Submission struct {
+	Username       string    `json:"username"`
+	ChallengeID    int       `json:"challengeId"`
+	ChallengeTitle string    `json:"challengeTitle"`
+	Contest        string    `json:"contest,omitempty"`
+	ExecutionMs    int64     `json:"executionMs"`
+	SubmittedAt    time.Time `json:"submittedAt"`
+}
+
+// RankChange is the payload of leaderboard.rank_changed
+type RankChange struct {
+	Username     string  `json:"username"`
+	PreviousRank int     `json:"previousRank" doc:"0 when the user was not on the leaderboard"`
+	Rank         int     `json:"rank" doc:"0 when the user left the leaderboard"`
+	Points       float64 `json:"points"`
+}
+
+// Challenge is the payload of challenge.added
+type Challenge struct {
+	ChallengeID int    `json:"challengeId"`
+	Title       string `json:"title"`
+	Difficulty  string `json:"difficulty"`
+}
+
+// Known reports whether eventType can be subscribed to
+func Known(eventType string) bool {
+	for _, t := range Types {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Bus hands every published event to each subscriber in turn. A nil Bus
+// drops events, so servers and tests without subscribers need not make one.
+type Bus struct {
+	mu          sync.RWMutex
+	subscribers []func(Event)
+}
+
+// NewBus creates a bus without subscribers
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe calls fn with every event published from now on. fn runs on the
+// publisher's goroutine, so it must not block.
+func (b *Bus) Subscribe(fn func(Event)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers = append(b.subscribers, fn)
+}
+
+// Publish stamps an event of eventType with an ID and the current time and
+// hands it to the subscribers
+func (b *Bus) Publish(eventType string, data any) Event {
+	e := New(eventType, data)
+	if b == nil {
+		return e
+	}
+	b.mu.RLock()
+	subscribers := b.subscribers
+	b.mu.RUnlock()
+	for _, fn := range subscribers {
+		fn(e)
+	}
+	return e
+}
+
+// New stamps an event without publishing it
+func New(eventType string, data any) Event {
+	id := make([]byte, 12)
+	rand.Read(id)
+	return Event{ID: hex.EncodeToString(id), Type: eventType, Time: time.Now().UTC(), Data: data}
+}
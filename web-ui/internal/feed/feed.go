@@ -0,0 +1,190 @@
+// Package feed renders syndication feeds as Atom 1.0 (RFC 4287) or RSS 2.0
+// from one description, so both formats list the same entries.
+package feed
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+// Content types of the rendered feeds
+const (
+	AtomContentType = "application/atom+xml; charset=utf-8"
+	RSSContentType  = "application/rss+xml; charset=utf-8"
+)
+
+// Feed is a list of entries, newest first
+type Feed struct {
+	ID       string // permanent, unique IRI; Atom only
+	Title    string
+	Subtitle string
+	Link     string // the HTML page the feed follows
+	Self     string // the feed's own URL
+	Updated  time.Time
+	Entries  []Entry
+}
+
+// Entry is one item of a feed
+type Entry struct {
+	ID        string
+	Title     string
+	Link      string
+	Author    string
+	Summary   string
+	Published time.Time // zero when unknown
+	Updated   time.Time
+}
+
+// LastUpdated returns the latest Updated time of the entries, zero for none
+func LastUpdated(entries []Entry) time.Time {
+	var latest time.Time
+	for _, e := range entries {
+		if e.Updated.After(latest) {
+			latest = e.Updated
+		}
+	}
+	return latest
+}
+
+type atomFeed struct {
+	XMLName  xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID       string      `xml:"id"`
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle,omitempty"`
+	Updated  string      `xml:"updated"`
+	Links    []atomLink  `xml:"link"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomEntry struct {
+	ID        string      `xml:"id"`
+	Title     string      `xml:"title"`
+	Link      atomLink    `xml:"link"`
+	Published string      `xml:"published,omitempty"`
+	Updated   string      `xml:"updated"`
+	Author    *atomAuthor `xml:"author"`
+	Summary   string      `xml:"summary,omitempty"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+// Atom renders the feed as an Atom document. Atom requires an author for
+// every entry, so entries without one are credited to the feed's title.
+func (f *Feed) Atom() ([]byte, error) {
+	doc := atomFeed{
+		ID:       f.ID,
+		Title:    f.Title,
+		Subtitle: f.Subtitle,
+		Updated:  atomTime(f.Updated),
+		Links: []atomLink{
+			{Href: f.Link, Rel: "alternate", Type: "text/html"},
+			{Href: f.Self, Rel: "self", Type: "application/atom+xml"},
+		},
+	}
+	for _, e := range f.Entries {
+		author := e.Author
+		if author == "" {
+			author = f.Title
+		}
+		entry := atomEntry{
+			ID:      e.ID,
+			Title:   e.Title,
+			Link:    atomLink{Href: e.Link, Rel: "alternate", Type: "text/html"},
+			Updated: atomTime(e.Updated),
+			Author:  &atomAuthor{Name: author},
+			Summary: e.Summary,
+		}
+		if !e.Published.IsZero() {
+			entry.Published = atomTime(e.Published)
+		}
+		doc.Entries = append(doc.Entries, entry)
+	}
+	return marshal(doc)
+}
+
+type rssDocument struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Atom    string     `xml:"xmlns:atom,attr"`
+	DC      string     `xml:"xmlns:dc,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	Self          atomLink  `xml:"atom:link"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate"`
+	Author      string  `xml:"dc:creator,omitempty"`
+	Description string  `xml:"description,omitempty"`
+}
+
+type rssGUID struct {
+	Value       string `xml:",chardata"`
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+}
+
+// RSS renders the feed as an RSS 2.0 document. RSS has a single date per
+// item, so items are dated when they were published, or else updated.
+func (f *Feed) RSS() ([]byte, error) {
+	description := f.Subtitle
+	if description == "" {
+		description = f.Title
+	}
+	doc := rssDocument{
+		Version: "2.0",
+		Atom:    "http://www.w3.org/2005/Atom",
+		DC:      "http://purl.org/dc/elements/1.1/",
+		Channel: rssChannel{
+			Title:         f.Title,
+			Link:          f.Link,
+			Description:   description,
+			Self:          atomLink{Href: f.Self, Rel: "self", Type: "application/rss+xml"},
+			LastBuildDate: f.Updated.UTC().Format(time.RFC1123Z),
+		},
+	}
+	for _, e := range f.Entries {
+		date := e.Published
+		if date.IsZero() {
+			date = e.Updated
+		}
+		doc.Channel.Items = append(doc.Channel.Items, rssItem{
+			Title:       e.Title,
+			Link:        e.Link,
+			GUID:        rssGUID{Value: e.ID},
+			PubDate:     date.UTC().Format(time.RFC1123Z),
+			Author:      e.Author,
+			Description: e.Summary,
+		})
+	}
+	return marshal(doc)
+}
+
+func atomTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+func marshal(doc any) ([]byte, error) {
+	body, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(body, '\n')...), nil
+}
@@ -0,0 +1,58 @@
+package feed
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAtomAndRSS(t *testing.T) {
+	f := &Feed{
+		ID:    "https://example.com/feeds/solves.atom",
+		Title: "Solves",
+		Link:  "https://example.com/scoreboard",
+		Self:  "https://example.com/feeds/solves.atom",
+		Entries: []Entry{
+			{ID: "https://example.com/a", Title: "alice solved <Sum> & more", Link: "https://example.com/a", Author: "alice", Updated: time.Unix(200, 0)},
+			{ID: "https://example.com/b", Title: "New challenge", Link: "https://example.com/b", Published: time.Unix(50, 0), Updated: time.Unix(100, 0)},
+		},
+	}
+	f.Updated = LastUpdated(f.Entries)
+	if !f.Updated.Equal(time.Unix(200, 0)) {
+		t.Fatalf("LastUpdated = %v", f.Updated)
+	}
+
+	atom, err := f.Atom()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var parsed struct {
+		Updated string `xml:"updated"`
+		Entries []struct {
+			Title  string `xml:"title"`
+			Author string `xml:"author>name"`
+		} `xml:"entry"`
+	}
+	if err := xml.Unmarshal(atom, &parsed); err != nil {
+		t.Fatalf("Atom is not valid XML: %v\n%s", err, atom)
+	}
+	if parsed.Updated != "1970-01-01T00:03:20Z" || len(parsed.Entries) != 2 || parsed.Entries[0].Title != "alice solved <Sum> & more" {
+		t.Errorf("Atom = %+v", parsed)
+	}
+	// Entries without an author are credited to the feed
+	if parsed.Entries[1].Author != "Solves" {
+		t.Errorf("default author = %q", parsed.Entries[1].Author)
+	}
+
+	rss, err := f.RSS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(rss), "<pubDate>Thu, 01 Jan 1970 00:00:50 +0000</pubDate>") {
+		t.Errorf("RSS does not date the second item when it was published:\n%s", rss)
+	}
+	if strings.Contains(string(rss), "<Sum>") {
+		t.Errorf("RSS does not escape titles:\n%s", rss)
+	}
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"web-ui/internal/audit"
+	"web-ui/internal/auth"
+	"web-ui/internal/services"
+	"web-ui/internal/webhook"
+)
+
+// AdminHandler serves endpoints restricted to configured administrators
+type AdminHandler struct {
+	sessions   *auth.SessionManager
+	auditLog   *audit.Log
+	teams      *services.TeamService
+	contests   *services.ContestService
+	similarity *services.SimilarityService
+	webhooks   *webhook.Dispatcher
+	deliveries *webhook.DeliveryLog
+	isAdmin    func(username string) bool
+}
+
+// AuditLogResponse is the body of GET /api/v1/admin/audit
+type AuditLogResponse struct {
+	Events []audit.Event `json:"events"`
+	Count  int           `json:"count"`
+}
+
+// NewAdminHandler creates a new admin handler
+func NewAdminHandler(sessions *auth.SessionManager, auditLog *audit.Log, teams *services.TeamService, contests *services.ContestService, similarity *services.SimilarityService, webhooks *webhook.Dispatcher, deliveries *webhook.DeliveryLog, isAdmin func(string) bool) *AdminHandler {
+	return &AdminHandler{
+		sessions:   sessions,
+		auditLog:   auditLog,
+		teams:      teams,
+		contests:   contests,
+		similarity: similarity,
+		webhooks:   webhooks,
+		deliveries: deliveries,
+		isAdmin:    isAdmin,
+	}
+}
+
+// AuditLog handles GET /api/v1/admin/audit, returning audit events newest
+// first. Supported filters are actor, action, outcome, challenge, since and
+// until (RFC 3339), before (an event's seq, for the next page) and limit.
+func (h *AdminHandler) AuditLog(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.requireAdmin(w, r); !ok {
+		return
+	}
+
+	params := r.URL.Query()
+	query := audit.Query{
+		Actor:   params.Get("actor"),
+		Action:  params.Get("action"),
+		Outcome: params.Get("outcome"),
+	}
+
+	var err error
+	if v := params.Get("challenge"); v != "" {
+		if query.ChallengeID, err = strconv.Atoi(v); err != nil {
+			writeError(w, r, http.StatusBadRequest, CodeBadRequest, "Invalid challenge ID", map[string]string{"challenge": v})
+			return
+		}
+	}
+	if v := params.Get("limit"); v != "" {
+		if query.Limit, err = strconv.Atoi(v); err != nil || query.Limit < 1 {
+			writeError(w, r, http.StatusBadRequest, CodeBadRequest, "Invalid limit", map[string]string{"limit": v})
+			return
+		}
+	}
+	if v := params.Get("before"); v != "" {
+		if query.Before, err = strconv.ParseInt(v, 10, 64); err != nil || query.Before < 1 {
+			writeError(w, r, http.StatusBadRequest, CodeBadRequest, "Invalid before, expected an event's seq", map[string]string{"before": v})
+			return
+		}
+	}
+	if v := params.Get("since"); v != "" {
+		if query.Since, err = time.Parse(time.RFC3339, v); err != nil {
+			writeError(w, r, http.StatusBadRequest, CodeBadRequest, "Invalid since time, expected RFC 3339", map[string]string{"since": v})
+			return
+		}
+	}
+	if v := params.Get("until"); v != "" {
+		if query.Until, err = time.Parse(time.RFC3339, v); err != nil {
+			writeError(w, r, http.StatusBadRequest, CodeBadRequest, "Invalid until time, expected RFC 3339", map[string]string{"until": v})
+			return
+		}
+	}
+
+	events, err := h.auditLog.Query(query)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "audit query failed", "err", err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Failed to read audit log", nil)
+		return
+	}
+	if events == nil {
+		events = []audit.Event{}
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, AuditLogResponse{Events: events, Count: len(events)})
+}
+
+// requireAdmin returns the signed-in admin's username or writes a 401/403 envelope
+func (h *AdminHandler) requireAdmin(w http.ResponseWriter, r *http.Request) (string, bool) {
+	username := h.sessions.Username(r)
+	if username == "" {
+		writeError(w, r, http.StatusUnauthorized, CodeUnauthorized, "Sign in required", nil)
+		return "", false
+	}
+	if !h.isAdmin(username) {
+		writeError(w, r, http.StatusForbidden, CodeForbidden, "Administrator access required", nil)
+		return "", false
+	}
+	return username, true
+}
+
+// recordChange writes an audit event for an admin change to the team or
+// contest with the given slug
+func (h *AdminHandler) recordChange(r *http.Request, actor, action, slug, outcome string) {
+	event := audit.Event{
+		Actor:      actor,
+		RemoteAddr: r.RemoteAddr,
+		Action:     action,
+		Outcome:    outcome,
+		Detail:     slug,
+	}
+	if err := h.auditLog.Record(r.Context(), event); err != nil {
+		slog.ErrorContext(r.Context(), "failed to record audit event", "action", event.Action, "err", err)
+	}
+}
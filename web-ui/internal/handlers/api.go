@@ -2,13 +2,18 @@ package handlers
 
 import (
 	"encoding/json"
-	"fmt"
-	"io/ioutil"
+	"errors"
+	"log/slog"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
+	"web-ui/internal/audit"
+	"web-ui/internal/auth"
+	"web-ui/internal/collab"
+	"web-ui/internal/config"
+	"web-ui/internal/events"
 	"web-ui/internal/models"
 	"web-ui/internal/services"
 	"web-ui/internal/utils"
@@ -16,11 +21,25 @@ import (
 
 // APIHandler handles all API endpoints
 type APIHandler struct {
-	challengeService  *services.ChallengeService
-	scoreboardService *services.ScoreboardService
-	userService       *services.UserService
-	executionService  *services.ExecutionService
-	submissions       []models.Submission
+	challengeService   *services.ChallengeService
+	scoreboardService  *services.ScoreboardService
+	userService        *services.UserService
+	executionService   *services.ExecutionService
+	submissionService  *services.SubmissionService
+	profileService     *services.ProfileService
+	leaderboardService *services.LeaderboardService
+	teamService        *services.TeamService
+	contestService     *services.ContestService
+	interviewService   *services.InterviewService
+	pairHub            *collab.Hub
+	draftService       *services.DraftService
+	solutionService    *services.SolutionService
+	reviewService      *services.ReviewService
+	sessions           *auth.SessionManager
+	auditLog           *audit.Log
+	bus                *events.Bus
+	features           config.FeatureConfig
+	repoRoot           string
 }
 
 // NewAPIHandler creates a new API handler
@@ -29,33 +48,60 @@ func NewAPIHandler(
 	scoreboardService *services.ScoreboardService,
 	userService *services.UserService,
 	executionService *services.ExecutionService,
+	submissionService *services.SubmissionService,
+	profileService *services.ProfileService,
+	leaderboardService *services.LeaderboardService,
+	teamService *services.TeamService,
+	contestService *services.ContestService,
+	interviewService *services.InterviewService,
+	pairHub *collab.Hub,
+	draftService *services.DraftService,
+	solutionService *services.SolutionService,
+	reviewService *services.ReviewService,
+	sessions *auth.SessionManager,
+	auditLog *audit.Log,
+	bus *events.Bus,
+	features config.FeatureConfig,
+	repoRoot string,
 ) *APIHandler {
 	return &APIHandler{
-		challengeService:  challengeService,
-		scoreboardService: scoreboardService,
-		userService:       userService,
-		executionService:  executionService,
-		submissions:       make([]models.Submission, 0),
+		challengeService:   challengeService,
+		scoreboardService:  scoreboardService,
+		userService:        userService,
+		executionService:   executionService,
+		submissionService:  submissionService,
+		profileService:     profileService,
+		leaderboardService: leaderboardService,
+		teamService:        teamService,
+		contestService:     contestService,
+		interviewService:   interviewService,
+		pairHub:            pairHub,
+		draftService:       draftService,
+		solutionService:    solutionService,
+		reviewService:      reviewService,
+		sessions:           sessions,
+		auditLog:           auditLog,
+		bus:                bus,
+		features:           features,
+		repoRoot:           repoRoot,
 	}
 }
 
-// GetAllChallenges returns all challenges
+// GetAllChallenges returns the challenges matching the list parameters, ordered by ID by default
 func (h *APIHandler) GetAllChallenges(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "GET" {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	challenges := h.challengeService.GetChallenges()
-
-	// Convert map to slice for JSON response
-	var challengeList []*models.Challenge
-	for _, challenge := range challenges {
-		challengeList = append(challengeList, challenge)
+	list, qerr := h.listChallenges(r)
+	if qerr != nil {
+		http.Error(w, qerr.message, qerr.status)
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(challengeList)
+	json.NewEncoder(w).Encode(list)
 }
 
 // GetChallengeByID returns a specific challenge by ID
@@ -97,6 +143,11 @@ func (h *APIHandler) HandleSubmissions(w http.ResponseWriter, r *http.Request) {
 
 // createSubmission creates a new submission
 func (h *APIHandler) createSubmission(w http.ResponseWriter, r *http.Request) {
+	username, ok := h.requireUser(w, r)
+	if !ok {
+		return
+	}
+
 	var submission models.Submission
 	err := json.NewDecoder(r.Body).Decode(&submission)
 	if err != nil {
@@ -104,6 +155,11 @@ func (h *APIHandler) createSubmission(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !h.isOwnUsername(w, username, submission.Username) {
+		return
+	}
+	submission.Username = username
+
 	// Set submission timestamp
 	submission.SubmittedAt = time.Now()
 
@@ -113,29 +169,67 @@ func (h *APIHandler) createSubmission(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Challenge not found", http.StatusNotFound)
 		return
 	}
+	if submission.Contest != "" {
+		if status, _, message, ok := h.checkContestSubmission(submission); !ok {
+			http.Error(w, message, status)
+			return
+		}
+	}
 
-	// Run the code
-	result := h.executionService.RunCode(submission.Code, challenge)
+	submission, err = h.submit(r, submission, challenge)
+	if err != nil {
+		h.writeRunError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(submission)
+}
+
+// submit runs a submission's code, stores the result, adds passing
+// submissions to the scoreboard and enters contest submissions in their
+// contest. Submissions made during a contest's freeze are held until the
+// contest ends.
+func (h *APIHandler) submit(r *http.Request, submission models.Submission, challenge *models.Challenge) (models.Submission, error) {
+	result, err := h.runAndRecord(r, audit.ActionSubmit, submission.Username, challenge, submission.Code)
+	if err != nil {
+		return submission, err
+	}
 	submission.Passed = result.Passed
 	submission.TestOutput = result.Output
 	submission.ExecutionMs = result.ExecutionMs
 
 	// Store submission
-	h.submissions = append(h.submissions, submission)
+	h.submissionService.Add(submission)
+
+	if submission.Contest != "" {
+		h.recordContestAttempt(r, submission)
+		// Results from a contest's freeze stay off the public scoreboards
+		// and out of events until the contest ends
+		if h.contestService.Hold(submission) {
+			return submission, nil
+		}
+	}
 
 	// Add to scoreboard if passed
 	if submission.Passed {
 		h.scoreboardService.AddSubmission(submission)
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(submission)
+	h.bus.Publish(services.SubmissionEvent(submission, challenge))
+	return submission, nil
 }
 
 // getSubmissions returns all submissions
 func (h *APIHandler) getSubmissions(w http.ResponseWriter, r *http.Request) {
+	// Only the caller's own: submissions carry code, which would otherwise
+	// open locked solution galleries to anyone
+	username, ok := h.requireUser(w, r)
+	if !ok {
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(h.submissions)
+	json.NewEncoder(w).Encode(h.submissionService.ByUser(username))
 }
 
 // GetScoreboard returns the scoreboard for a challenge
@@ -169,11 +263,7 @@ func (h *APIHandler) RunCode(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var request struct {
-		ChallengeID int    `json:"challengeId"`
-		Code        string `json:"code"`
-	}
-
+	var request RunRequest
 	err := json.NewDecoder(r.Body).Decode(&request)
 	if err != nil {
 		http.Error(w, "Invalid request data", http.StatusBadRequest)
@@ -185,8 +275,21 @@ func (h *APIHandler) RunCode(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Challenge not found", http.StatusNotFound)
 		return
 	}
+	if request.Interview != "" {
+		if status, _, message, ok := h.checkInterviewActivity(r, request.Interview, challenge.ID, time.Now()); !ok {
+			http.Error(w, message, status)
+			return
+		}
+	}
 
-	result := h.executionService.RunCode(request.Code, challenge)
+	result, err := h.runAndRecord(r, audit.ActionRun, h.sessions.Username(r), challenge, request.Code)
+	if err != nil {
+		h.writeRunError(w, err)
+		return
+	}
+	if request.Interview != "" {
+		h.recordInterviewRun(r, request.Interview, challenge.ID, request.Code, result)
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(result)
@@ -199,6 +302,16 @@ func (h *APIHandler) SaveSubmissionToFilesystem(w http.ResponseWriter, r *http.R
 		return
 	}
 
+	if !h.features.SaveToFilesystem {
+		http.Error(w, "Saving to the filesystem is disabled on this server", http.StatusForbidden)
+		return
+	}
+
+	username, ok := h.requireUser(w, r)
+	if !ok {
+		return
+	}
+
 	var request services.SaveSubmissionRequest
 	err := json.NewDecoder(r.Body).Decode(&request)
 	if err != nil {
@@ -206,13 +319,11 @@ func (h *APIHandler) SaveSubmissionToFilesystem(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	if request.Username == "" {
-		http.Error(w, "Username is required", http.StatusBadRequest)
+	// Writes are only allowed into the signed-in user's own submission directory
+	if !h.isOwnUsername(w, username, request.Username) {
 		return
 	}
-
-	// Set username cookie
-	h.setUsernameCookie(w, request.Username)
+	request.Username = username
 
 	// Validate challenge exists
 	_, exists := h.challengeService.GetChallenge(request.ChallengeID)
@@ -221,15 +332,52 @@ func (h *APIHandler) SaveSubmissionToFilesystem(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	response := h.executionService.SaveSubmissionToFilesystem(request)
-
-	// Clear user attempts cache
-	h.userService.RefreshUserAttempts(request.Username, h.challengeService.GetChallenges())
+	response := h.saveToFilesystem(r, request)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
 
+// saveToFilesystem writes a solution into the repository, records the save
+// and refreshes the user's attempt cache. The solution it overwrites and the
+// new one are both kept as draft versions.
+func (h *APIHandler) saveToFilesystem(r *http.Request, request services.SaveSubmissionRequest) services.SaveSubmissionResponse {
+	now := time.Now()
+	previous := 0
+	if existing := h.userService.GetExistingSolution(request.Username, request.ChallengeID); existing != "" && existing != request.Code {
+		version, err := h.draftService.Snapshot(request.Username, request.ChallengeID, existing, now)
+		if err != nil {
+			slog.WarnContext(r.Context(), "failed to keep overwritten solution as a draft", "user", request.Username, "challenge", request.ChallengeID, "err", err)
+		}
+		previous = version.Version
+	}
+
+	response := h.executionService.SaveSubmissionToFilesystem(r.Context(), request)
+	if response.Success {
+		response.PreviousDraftVersion = previous
+		if _, err := h.draftService.SaveSolution(request.Username, request.ChallengeID, request.Code, now); err != nil {
+			slog.WarnContext(r.Context(), "failed to keep saved solution as a draft", "user", request.Username, "challenge", request.ChallengeID, "err", err)
+		}
+	}
+
+	outcome := "saved"
+	if !response.Success {
+		outcome = "error"
+	}
+	h.recordAudit(r, audit.Event{
+		Actor:       request.Username,
+		Action:      audit.ActionSave,
+		ChallengeID: request.ChallengeID,
+		Outcome:     outcome,
+		CodeSHA256:  audit.HashCode(request.Code),
+		Detail:      response.Message,
+	})
+
+	// Clear user attempts cache
+	h.userService.RefreshUserAttempts(request.Username, h.challengeService.GetChallenges())
+	return response
+}
+
 // RefreshUserAttempts refreshes user's attempt cache
 func (h *APIHandler) RefreshUserAttempts(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "POST" {
@@ -237,10 +385,7 @@ func (h *APIHandler) RefreshUserAttempts(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	var request struct {
-		Username string `json:"username"`
-	}
-
+	var request LegacyRefreshRequest
 	err := json.NewDecoder(r.Body).Decode(&request)
 	if err != nil {
 		http.Error(w, "Invalid request data", http.StatusBadRequest)
@@ -254,12 +399,7 @@ func (h *APIHandler) RefreshUserAttempts(w http.ResponseWriter, r *http.Request)
 
 	attempts := h.userService.RefreshUserAttempts(request.Username, h.challengeService.GetChallenges())
 
-	response := struct {
-		Username     string       `json:"username"`
-		AttemptedIDs map[int]bool `json:"attemptedIds"`
-		Scores       map[int]int  `json:"scores"`
-		Success      bool         `json:"success"`
-	}{
+	response := LegacyAttemptsResponse{
 		Username:     request.Username,
 		AttemptedIDs: attempts.AttemptedIDs,
 		Scores:       attempts.Scores,
@@ -277,14 +417,9 @@ func (h *APIHandler) GetGitUsername(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	gitInfo := utils.GetGitUsername()
+	gitInfo := utils.GetGitUsername(h.repoRoot)
 
-	response := struct {
-		Username string `json:"username"`
-		Email    string `json:"email"`
-		Source   string `json:"source"`
-		Success  bool   `json:"success"`
-	}{
+	response := LegacyGitUsernameResponse{
 		Username: gitInfo.Username,
 		Email:    gitInfo.Email,
 		Source:   gitInfo.Source,
@@ -295,18 +430,74 @@ func (h *APIHandler) GetGitUsername(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
-// setUsernameCookie sets the username cookie
-func (h *APIHandler) setUsernameCookie(w http.ResponseWriter, username string) {
-	// Cookie expires in 30 days
-	expiration := time.Now().Add(30 * 24 * time.Hour)
-	cookie := http.Cookie{
-		Name:     "username",
-		Value:    username,
-		Expires:  expiration,
-		Path:     "/",
-		HttpOnly: false, // Allow JavaScript to access it
-	}
-	http.SetCookie(w, &cookie)
+// runAndRecord runs code against a challenge and writes an audit event for it
+func (h *APIHandler) runAndRecord(r *http.Request, action, username string, challenge *models.Challenge, code string) (services.ExecutionResult, error) {
+	result, err := h.executionService.RunCode(r.Context(), code, challenge)
+	h.recordRun(r, action, username, challenge.ID, code, result, err)
+	return result, err
+}
+
+// recordRun writes an audit event for a run or submission
+func (h *APIHandler) recordRun(r *http.Request, action, username string, challengeID int, code string, result services.ExecutionResult, runErr error) {
+	event := audit.Event{
+		Actor:       username,
+		Action:      action,
+		ChallengeID: challengeID,
+		CodeSHA256:  audit.HashCode(code),
+		DurationMs:  result.ExecutionMs,
+	}
+	switch {
+	case runErr != nil:
+		event.Outcome = "not_started"
+		event.Detail = runErr.Error()
+	case result.TimedOut:
+		event.Outcome = "timed_out"
+	case result.Passed:
+		event.Outcome = "passed"
+	default:
+		event.Outcome = "failed"
+	}
+	h.recordAudit(r, event)
+}
+
+// recordAudit appends an event to the audit log; failures are logged, not
+// surfaced, so auditing never breaks the request it describes
+func (h *APIHandler) recordAudit(r *http.Request, event audit.Event) {
+	event.RemoteAddr = r.RemoteAddr
+	if err := h.auditLog.Record(r.Context(), event); err != nil {
+		slog.ErrorContext(r.Context(), "failed to record audit event", "action", event.Action, "err", err)
+	}
+}
+
+// writeRunError reports a run that could not be started
+func (h *APIHandler) writeRunError(w http.ResponseWriter, err error) {
+	if errors.Is(err, services.ErrShuttingDown) {
+		w.Header().Set("Retry-After", "30")
+		http.Error(w, "Server is shutting down, please retry shortly", http.StatusServiceUnavailable)
+		return
+	}
+	// The client went away before the run started; nobody is listening
+	http.Error(w, "Run cancelled", http.StatusServiceUnavailable)
+}
+
+// requireUser returns the signed-in username or writes a 401 response
+func (h *APIHandler) requireUser(w http.ResponseWriter, r *http.Request) (string, bool) {
+	username := h.sessions.Username(r)
+	if username == "" {
+		http.Error(w, "Sign in required", http.StatusUnauthorized)
+		return "", false
+	}
+	return username, true
+}
+
+// isOwnUsername rejects requests that name a different user than the session.
+// An empty requested username defaults to the session user.
+func (h *APIHandler) isOwnUsername(w http.ResponseWriter, sessionUser, requested string) bool {
+	if requested != "" && !strings.EqualFold(requested, sessionUser) {
+		http.Error(w, "You can only write your own submissions", http.StatusForbidden)
+		return false
+	}
+	return true
 }
 
 // GetMainScoreboardRank returns the user's rank in the main scoreboard
@@ -325,11 +516,7 @@ func (h *APIHandler) GetMainScoreboardRank(w http.ResponseWriter, r *http.Reques
 	// Calculate user's rank in main scoreboard
 	rank := h.calculateMainScoreboardRank(username)
 
-	response := struct {
-		Username string `json:"username"`
-		Rank     int    `json:"rank"`
-		Success  bool   `json:"success"`
-	}{
+	response := LegacyRankResponse{
 		Username: username,
 		Rank:     rank,
 		Success:  true,
@@ -339,74 +526,9 @@ func (h *APIHandler) GetMainScoreboardRank(w http.ResponseWriter, r *http.Reques
 	json.NewEncoder(w).Encode(response)
 }
 
-// calculateMainScoreboardRank calculates the user's rank based on completed challenges
+// calculateMainScoreboardRank returns the user's rank on the main leaderboard
 func (h *APIHandler) calculateMainScoreboardRank(username string) int {
-	// Get all users and their completion counts (only count if ALL tests passed)
-	challenges := h.challengeService.GetChallenges()
-	userCompletions := make(map[string]int)
-
-	// Process all challenge scoreboards to count actual completions
-	for challengeID := range challenges {
-		// Read scoreboard file directly to check test results
-		scoreboardPath := fmt.Sprintf("../challenge-%d/SCOREBOARD.md", challengeID)
-		content, err := ioutil.ReadFile(scoreboardPath)
-		if err != nil {
-			// Try alternative path
-			scoreboardPath = fmt.Sprintf("challenge-%d/SCOREBOARD.md", challengeID)
-			content, err = ioutil.ReadFile(scoreboardPath)
-			if err != nil {
-				continue
-			}
-		}
-
-		// Parse scoreboard to find users who passed ALL tests
-		lines := strings.Split(string(content), "\n")
-		for _, line := range lines {
-			// Skip header and separator lines
-			if !strings.Contains(line, "|") || strings.Contains(line, "Username") || strings.Contains(line, "---") {
-				continue
-			}
-
-			parts := strings.Split(line, "|")
-			if len(parts) < 4 {
-				continue
-			}
-
-			username := strings.TrimSpace(parts[1])
-			passedTestsStr := strings.TrimSpace(parts[2])
-			totalTestsStr := strings.TrimSpace(parts[3])
-
-			// Skip empty usernames or placeholders
-			if username == "" || username == "------" {
-				continue
-			}
-
-			// Parse test counts
-			passedTests, err1 := strconv.Atoi(passedTestsStr)
-			totalTests, err2 := strconv.Atoi(totalTestsStr)
-
-			// Only count as completed if ALL tests passed
-			if err1 == nil && err2 == nil && passedTests > 0 && passedTests == totalTests {
-				userCompletions[username]++
-			}
-		}
-	}
-
-	// Get the target user's completion count
-	targetCompletions := userCompletions[username]
-	if targetCompletions == 0 {
-		return 0 // User is unranked
-	}
-
-	// Count how many users have more completions (following Python script logic)
-	rank := 1
-	for user, completions := range userCompletions {
-		if user != username && completions > targetCompletions {
-			rank++
-		}
-	}
-
-	return rank
+	return h.leaderboardService.Rank(username, time.Now())
 }
 
 // GetMainLeaderboard returns the main leaderboard data
@@ -419,10 +541,7 @@ func (h *APIHandler) GetMainLeaderboard(w http.ResponseWriter, r *http.Request)
 	// Calculate leaderboard data
 	leaderboard := h.calculateMainLeaderboard()
 
-	response := struct {
-		Leaderboard []LeaderboardUser `json:"leaderboard"`
-		Success     bool              `json:"success"`
-	}{
+	response := LegacyLeaderboardResponse{
 		Leaderboard: leaderboard,
 		Success:     true,
 	}
@@ -431,113 +550,82 @@ func (h *APIHandler) GetMainLeaderboard(w http.ResponseWriter, r *http.Request)
 	json.NewEncoder(w).Encode(response)
 }
 
+// LegacyRefreshRequest is the body of POST /api/refresh-attempts
+type LegacyRefreshRequest struct {
+	Username string `json:"username"`
+}
+
+// LegacyAttemptsResponse is the body returned by POST /api/refresh-attempts
+type LegacyAttemptsResponse struct {
+	Username     string       `json:"username"`
+	AttemptedIDs map[int]bool `json:"attemptedIds"`
+	Scores       map[int]int  `json:"scores"`
+	Success      bool         `json:"success"`
+}
+
+// LegacyGitUsernameResponse is the body returned by GET /api/git-username
+type LegacyGitUsernameResponse struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Source   string `json:"source" enum:"git-config,remote-origin,not-found"`
+	Success  bool   `json:"success" doc:"False when no username could be determined"`
+}
+
+// LegacyRankResponse is the body returned by GET /api/main-scoreboard-rank
+type LegacyRankResponse struct {
+	Username string `json:"username"`
+	Rank     int    `json:"rank" doc:"Position on the main leaderboard, 0 when unranked"`
+	Success  bool   `json:"success"`
+}
+
+// LegacyLeaderboardResponse is the body returned by GET /api/main-leaderboard
+type LegacyLeaderboardResponse struct {
+	Leaderboard []LeaderboardUser `json:"leaderboard" nullable:"true" doc:"null when nobody has completed a challenge"`
+	Success     bool              `json:"success"`
+}
+
 // LeaderboardUser represents a user in the leaderboard
 type LeaderboardUser struct {
 	Username            string       `json:"username"`
-	CompletedCount      int          `json:"completedCount"`
-	CompletionRate      float64      `json:"completionRate"`
-	CompletedChallenges map[int]bool `json:"completedChallenges"`
-	Achievement         string       `json:"achievement"`
-	Rank                int          `json:"rank"`
+	Points              float64      `json:"points" doc:"Score under the configured scoring model"`
+	CompletedCount      int          `json:"completedCount" doc:"Challenges whose scoreboard shows every test passing"`
+	CompletionRate      float64      `json:"completionRate" doc:"Completed challenges as a percentage of all challenges"`
+	CompletedChallenges map[int]bool `json:"completedChallenges" doc:"Completed challenge IDs"`
+	Achievement         string       `json:"achievement" doc:"Achievement tier earned by the completed count"`
+	Rank                int          `json:"rank" doc:"1-based position by points among all users; users with equal points share a rank"`
+	CurrentStreak       int          `json:"currentStreak" doc:"Consecutive days up to today with a solve, commit or submission"`
+	LongestStreak       int          `json:"longestStreak" doc:"Most consecutive days with a solve, commit or submission"`
 }
 
-// calculateMainLeaderboard calculates the main leaderboard data
+// calculateMainLeaderboard scores and ranks every user on the main leaderboard
 func (h *APIHandler) calculateMainLeaderboard() []LeaderboardUser {
-	challenges := h.challengeService.GetChallenges()
-	totalChallenges := len(challenges)
-	userCompletions := make(map[string]map[int]bool)
-
-	// Process all challenge scoreboards to find completions
-	for challengeID := range challenges {
-		// Read scoreboard file directly to check test results
-		scoreboardPath := fmt.Sprintf("../challenge-%d/SCOREBOARD.md", challengeID)
-		content, err := ioutil.ReadFile(scoreboardPath)
-		if err != nil {
-			// Try alternative path
-			scoreboardPath = fmt.Sprintf("challenge-%d/SCOREBOARD.md", challengeID)
-			content, err = ioutil.ReadFile(scoreboardPath)
-			if err != nil {
-				continue
-			}
-		}
-
-		// Parse scoreboard to find users who passed ALL tests
-		lines := strings.Split(string(content), "\n")
-		for _, line := range lines {
-			// Skip header and separator lines
-			if !strings.Contains(line, "|") || strings.Contains(line, "Username") || strings.Contains(line, "---") {
-				continue
-			}
-
-			parts := strings.Split(line, "|")
-			if len(parts) < 4 {
-				continue
-			}
-
-			username := strings.TrimSpace(parts[1])
-			passedTestsStr := strings.TrimSpace(parts[2])
-			totalTestsStr := strings.TrimSpace(parts[3])
-
-			// Skip empty usernames or placeholders
-			if username == "" || username == "------" {
-				continue
-			}
-
-			// Parse test counts
-			passedTests, err1 := strconv.Atoi(passedTestsStr)
-			totalTests, err2 := strconv.Atoi(totalTestsStr)
-
-			// Only count as completed if ALL tests passed
-			if err1 == nil && err2 == nil && passedTests > 0 && passedTests == totalTests {
-				if userCompletions[username] == nil {
-					userCompletions[username] = make(map[int]bool)
-				}
-				userCompletions[username][challengeID] = true
-			}
-		}
-	}
+	totalChallenges := len(h.challengeService.GetChallenges())
 
-	// Convert to leaderboard format
+	now := time.Now()
 	var leaderboard []LeaderboardUser
-	for username, completions := range userCompletions {
-		completedCount := len(completions)
-		completionRate := float64(completedCount) / float64(totalChallenges) * 100
-
-		// Determine achievement
-		achievement := "🌱 Beginner"
-		if completedCount >= 20 {
-			achievement = "🔥 Master"
-		} else if completedCount >= 15 {
-			achievement = "⭐ Expert"
-		} else if completedCount >= 10 {
-			achievement = "💪 Advanced"
-		} else if completedCount >= 5 {
-			achievement = "🚀 Intermediate"
-		}
-
-		leaderboard = append(leaderboard, LeaderboardUser{
-			Username:            username,
-			CompletedCount:      completedCount,
-			CompletionRate:      completionRate,
-			CompletedChallenges: completions,
-			Achievement:         achievement,
-		})
-	}
-
-	// Sort by completion count (descending), then by username
-	for i := 0; i < len(leaderboard); i++ {
-		for j := i + 1; j < len(leaderboard); j++ {
-			if leaderboard[j].CompletedCount > leaderboard[i].CompletedCount ||
-				(leaderboard[j].CompletedCount == leaderboard[i].CompletedCount && leaderboard[j].Username < leaderboard[i].Username) {
-				leaderboard[i], leaderboard[j] = leaderboard[j], leaderboard[i]
-			}
-		}
+	for _, standing := range h.leaderboardService.Standings(now) {
+		leaderboard = append(leaderboard, h.leaderboardUser(standing, totalChallenges, now))
 	}
+	return leaderboard
+}
 
-	// Assign ranks
-	for i := range leaderboard {
-		leaderboard[i].Rank = i + 1
+// leaderboardUser adds a user's completion rate and streaks to their standing
+func (h *APIHandler) leaderboardUser(standing services.Standing, totalChallenges int, now time.Time) LeaderboardUser {
+	completedCount := len(standing.CompletedChallenges)
+	completionRate := 0.0
+	if totalChallenges > 0 {
+		completionRate = float64(completedCount) / float64(totalChallenges) * 100
+	}
+	currentStreak, longestStreak := h.profileService.Streaks(standing.Username, now)
+	return LeaderboardUser{
+		Username:            standing.Username,
+		Points:              standing.Points,
+		CompletedCount:      completedCount,
+		CompletionRate:      completionRate,
+		CompletedChallenges: standing.CompletedChallenges,
+		Achievement:         standing.Achievement,
+		Rank:                standing.Rank,
+		CurrentStreak:       currentStreak,
+		LongestStreak:       longestStreak,
 	}
-
-	return leaderboard
 }
@@ -0,0 +1,357 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"web-ui/internal/audit"
+	"web-ui/internal/models"
+	"web-ui/internal/paths"
+	"web-ui/internal/services"
+	"web-ui/internal/utils"
+)
+
+// The /api/v1 handlers below rely on method+path routing, so they do not
+// check r.Method, and report every error with the JSON error envelope.
+
+// RunRequest is the body of POST /api/v1/runs
+type RunRequest struct {
+	ChallengeID int    `json:"challengeId"`
+	Code        string `json:"code" doc:"Go source of the solution"`
+	Interview   string `json:"interview,omitempty" doc:"ID of the interview this browser joined, to record the run in"`
+}
+
+// SubmissionRequest is the body of POST /api/v1/submissions
+type SubmissionRequest struct {
+	ChallengeID int    `json:"challengeId"`
+	Code        string `json:"code" doc:"Go source of the solution"`
+	Username    string `json:"username,omitempty" doc:"Must match the signed-in user when set"`
+	Contest     string `json:"contest,omitempty" doc:"Slug of a running contest to enter the submission in"`
+}
+
+// SaveSolutionRequest is the body of PUT /api/v1/challenges/{id}/solution
+type SaveSolutionRequest struct {
+	Code string `json:"code"`
+}
+
+// AttemptsResponse lists the challenges a user has attempted and their scores
+type AttemptsResponse struct {
+	Username     string       `json:"username"`
+	AttemptedIDs map[int]bool `json:"attemptedIds"`
+	Scores       map[int]int  `json:"scores" doc:"Score (0-100) per attempted challenge ID"`
+}
+
+// RankResponse is a user's position on the main leaderboard; 0 means unranked
+type RankResponse struct {
+	Username string `json:"username"`
+	Rank     int    `json:"rank" doc:"Position on the main leaderboard, 0 when unranked"`
+}
+
+// GitIdentityResponse is the git identity configured for the repository
+type GitIdentityResponse struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Source   string `json:"source" enum:"git-config,remote-origin"`
+}
+
+// ListChallenges handles GET /api/v1/challenges
+func (h *APIHandler) ListChallenges(w http.ResponseWriter, r *http.Request) {
+	list, qerr := h.listChallenges(r)
+	if qerr != nil {
+		writeError(w, r, qerr.status, qerr.code, qerr.message, nil)
+		return
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// GetChallenge handles GET /api/v1/challenges/{id}
+func (h *APIHandler) GetChallenge(w http.ResponseWriter, r *http.Request) {
+	challenge, ok := h.challengeFromPath(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, challenge)
+}
+
+// GetChallengeScoreboard handles GET /api/v1/challenges/{id}/scoreboard
+func (h *APIHandler) GetChallengeScoreboard(w http.ResponseWriter, r *http.Request) {
+	challenge, ok := h.challengeFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	team, ok := h.teamFromQuery(w, r)
+	if !ok {
+		return
+	}
+
+	scoreboard, _ := h.scoreboardService.GetScoreboard(challenge.ID)
+	writeJSON(w, http.StatusOK, teamEntries(scoreboard, team))
+}
+
+// CreateRun handles POST /api/v1/runs
+func (h *APIHandler) CreateRun(w http.ResponseWriter, r *http.Request) {
+	var request RunRequest
+	if !decodeJSON(w, r, &request) {
+		return
+	}
+
+	challenge, ok := h.challengeByID(w, r, request.ChallengeID)
+	if !ok {
+		return
+	}
+	if request.Interview != "" {
+		if status, code, message, ok := h.checkInterviewActivity(r, request.Interview, challenge.ID, time.Now()); !ok {
+			writeError(w, r, status, code, message, map[string]string{"interview": request.Interview})
+			return
+		}
+	}
+
+	result, err := h.runAndRecord(r, audit.ActionRun, h.sessions.Username(r), challenge, request.Code)
+	if err != nil {
+		writeRunErrorV1(w, r, err)
+		return
+	}
+	if request.Interview != "" {
+		h.recordInterviewRun(r, request.Interview, challenge.ID, request.Code, result)
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// ListSubmissions handles GET /api/v1/submissions, returning the signed-in
+// user's submissions from this server session, newest first
+func (h *APIHandler) ListSubmissions(w http.ResponseWriter, r *http.Request) {
+	username, ok := h.requireUserV1(w, r)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, h.submissionService.ByUser(username))
+}
+
+// CreateSubmission handles POST /api/v1/submissions
+func (h *APIHandler) CreateSubmission(w http.ResponseWriter, r *http.Request) {
+	username, ok := h.requireUserV1(w, r)
+	if !ok {
+		return
+	}
+
+	var request SubmissionRequest
+	if !decodeJSON(w, r, &request) {
+		return
+	}
+	if request.Username != "" && !strings.EqualFold(request.Username, username) {
+		writeError(w, r, http.StatusForbidden, CodeForbidden, "You can only write your own submissions", nil)
+		return
+	}
+
+	challenge, ok := h.challengeByID(w, r, request.ChallengeID)
+	if !ok {
+		return
+	}
+
+	submission := models.Submission{
+		Username:    username,
+		ChallengeID: challenge.ID,
+		Code:        request.Code,
+		SubmittedAt: time.Now(),
+		Contest:     request.Contest,
+	}
+	if submission.Contest != "" {
+		if status, code, message, ok := h.checkContestSubmission(submission); !ok {
+			writeError(w, r, status, code, message, map[string]string{"contest": submission.Contest})
+			return
+		}
+	}
+
+	submission, err := h.submit(r, submission, challenge)
+	if err != nil {
+		writeRunErrorV1(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, submission)
+}
+
+// SaveSolution handles PUT /api/v1/challenges/{id}/solution, writing the
+// signed-in user's solution into the repository
+func (h *APIHandler) SaveSolution(w http.ResponseWriter, r *http.Request) {
+	if !h.features.SaveToFilesystem {
+		writeError(w, r, http.StatusForbidden, CodeFeatureDisabled, "Saving to the filesystem is disabled on this server", nil)
+		return
+	}
+	username, ok := h.requireUserV1(w, r)
+	if !ok {
+		return
+	}
+	challenge, ok := h.challengeFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	var request SaveSolutionRequest
+	if !decodeJSON(w, r, &request) {
+		return
+	}
+
+	response := h.saveToFilesystem(r, services.SaveSubmissionRequest{
+		Username:    username,
+		ChallengeID: challenge.ID,
+		Code:        request.Code,
+	})
+	if !response.Success {
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, response.Message, nil)
+		return
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// RefreshAttempts handles POST /api/v1/users/{username}/attempts/refresh
+func (h *APIHandler) RefreshAttempts(w http.ResponseWriter, r *http.Request) {
+	username := r.PathValue("username")
+	if err := paths.ValidateUsername(username); err != nil {
+		writeError(w, r, http.StatusBadRequest, CodeBadRequest, "Invalid username", map[string]string{"username": username})
+		return
+	}
+
+	attempts := h.userService.RefreshUserAttempts(username, h.challengeService.GetChallenges())
+	writeJSON(w, http.StatusOK, AttemptsResponse{
+		Username:     username,
+		AttemptedIDs: attempts.AttemptedIDs,
+		Scores:       attempts.Scores,
+	})
+}
+
+// GetUserProfile handles GET /api/v1/users/{username}
+func (h *APIHandler) GetUserProfile(w http.ResponseWriter, r *http.Request) {
+	username := r.PathValue("username")
+	if err := paths.ValidateUsername(username); err != nil {
+		writeError(w, r, http.StatusBadRequest, CodeBadRequest, "Invalid username", map[string]string{"username": username})
+		return
+	}
+	writeJSON(w, http.StatusOK, h.profileService.Profile(username, time.Now()))
+}
+
+// Bounds of the days query parameter of the activity endpoints
+const (
+	defaultActivityDays = 365
+	maxActivityDays     = 731
+)
+
+// activityDays parses the days query parameter, writing a 400 when it is invalid
+func activityDays(w http.ResponseWriter, r *http.Request) (int, bool) {
+	v := r.URL.Query().Get("days")
+	if v == "" {
+		return defaultActivityDays, true
+	}
+	days, err := strconv.Atoi(v)
+	if err != nil || days < 1 || days > maxActivityDays {
+		writeError(w, r, http.StatusBadRequest, CodeBadRequest, "Invalid days", map[string]string{"days": v})
+		return 0, false
+	}
+	return days, true
+}
+
+// GetUserActivity handles GET /api/v1/users/{username}/activity
+func (h *APIHandler) GetUserActivity(w http.ResponseWriter, r *http.Request) {
+	username := r.PathValue("username")
+	if err := paths.ValidateUsername(username); err != nil {
+		writeError(w, r, http.StatusBadRequest, CodeBadRequest, "Invalid username", map[string]string{"username": username})
+		return
+	}
+	days, ok := activityDays(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, h.profileService.Activity(username, time.Now(), days))
+}
+
+// GetTeamActivity handles GET /api/v1/activity
+func (h *APIHandler) GetTeamActivity(w http.ResponseWriter, r *http.Request) {
+	days, ok := activityDays(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, h.profileService.TeamActivity(time.Now(), days))
+}
+
+// GetLeaderboard handles GET /api/v1/leaderboard. Filtering by team keeps
+// each member's overall rank.
+func (h *APIHandler) GetLeaderboard(w http.ResponseWriter, r *http.Request) {
+	team, ok := h.teamFromQuery(w, r)
+	if !ok {
+		return
+	}
+
+	leaderboard := []LeaderboardUser{}
+	for _, user := range h.calculateMainLeaderboard() {
+		if team == nil || team.HasMember(user.Username) {
+			leaderboard = append(leaderboard, user)
+		}
+	}
+	writeJSON(w, http.StatusOK, leaderboard)
+}
+
+// GetLeaderboardRank handles GET /api/v1/leaderboard/{username}
+func (h *APIHandler) GetLeaderboardRank(w http.ResponseWriter, r *http.Request) {
+	username := r.PathValue("username")
+	writeJSON(w, http.StatusOK, RankResponse{
+		Username: username,
+		Rank:     h.calculateMainScoreboardRank(username),
+	})
+}
+
+// GetGitIdentity handles GET /api/v1/git-username
+func (h *APIHandler) GetGitIdentity(w http.ResponseWriter, r *http.Request) {
+	gitInfo := utils.GetGitUsername(h.repoRoot)
+	if gitInfo.Username == "" {
+		writeError(w, r, http.StatusNotFound, CodeNotFound, "No git username could be determined for the repository", nil)
+		return
+	}
+	writeJSON(w, http.StatusOK, GitIdentityResponse{
+		Username: gitInfo.Username,
+		Email:    gitInfo.Email,
+		Source:   gitInfo.Source,
+	})
+}
+
+// challengeFromPath looks up the challenge named by the {id} path value
+func (h *APIHandler) challengeFromPath(w http.ResponseWriter, r *http.Request) (*models.Challenge, bool) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return nil, false
+	}
+	return h.challengeByID(w, r, id)
+}
+
+// challengeByID looks up a challenge, writing a 404 when it does not exist
+func (h *APIHandler) challengeByID(w http.ResponseWriter, r *http.Request, id int) (*models.Challenge, bool) {
+	challenge, exists := h.challengeService.GetChallenge(id)
+	if !exists {
+		writeError(w, r, http.StatusNotFound, CodeNotFound, "Challenge not found", map[string]int{"challengeId": id})
+		return nil, false
+	}
+	return challenge, true
+}
+
+// requireUserV1 returns the signed-in username or writes a 401 envelope
+func (h *APIHandler) requireUserV1(w http.ResponseWriter, r *http.Request) (string, bool) {
+	username := h.sessions.Username(r)
+	if username == "" {
+		writeError(w, r, http.StatusUnauthorized, CodeUnauthorized, "Sign in required", nil)
+		return "", false
+	}
+	return username, true
+}
+
+// writeRunErrorV1 reports a run that could not be started
+func writeRunErrorV1(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, services.ErrShuttingDown) {
+		w.Header().Set("Retry-After", "30")
+		writeError(w, r, http.StatusServiceUnavailable, CodeUnavailable, "Server is shutting down, please retry shortly", nil)
+		return
+	}
+	writeError(w, r, http.StatusServiceUnavailable, CodeUnavailable, "Run cancelled before it started", nil)
+}
@@ -0,0 +1,279 @@
+package handlers
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"web-ui/internal/auth"
+	"web-ui/internal/utils"
+)
+
+// AuthHandler handles sign-in, sign-out and session endpoints
+type AuthHandler struct {
+	content   embed.FS
+	sessions  *auth.SessionManager
+	providers *auth.Registry
+	// allowRegistration enables self-service creation of local accounts
+	allowRegistration bool
+}
+
+// NewAuthHandler creates a new auth handler
+func NewAuthHandler(content embed.FS, sessions *auth.SessionManager, providers *auth.Registry, allowRegistration bool) *AuthHandler {
+	return &AuthHandler{
+		content:           content,
+		sessions:          sessions,
+		providers:         providers,
+		allowRegistration: allowRegistration,
+	}
+}
+
+// LoginPage renders the sign-in page
+func (h *AuthHandler) LoginPage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	tmpl, err := template.New("").Funcs(utils.GetTemplateFuncs()).ParseFS(h.content, "templates/base.html", "templates/login.html")
+	if err != nil {
+		slog.ErrorContext(r.Context(), "template parse failed", "err", err)
+		http.Error(w, "Failed to parse template: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	_, hasPassword := h.providers.Password()
+	var redirectProviders []string
+	for _, name := range h.providers.Names() {
+		p, _ := h.providers.Get(name)
+		if _, ok := p.(auth.RedirectProvider); ok {
+			redirectProviders = append(redirectProviders, name)
+		}
+	}
+
+	data := struct {
+		Username            string
+		PasswordEnabled     bool
+		RegistrationEnabled bool
+		RedirectProviders   []string
+		Error               string
+	}{
+		Username:            h.sessions.Username(r),
+		PasswordEnabled:     hasPassword,
+		RegistrationEnabled: hasPassword && h.allowRegistration,
+		RedirectProviders:   redirectProviders,
+		Error:               r.URL.Query().Get("error"),
+	}
+
+	err = tmpl.ExecuteTemplate(w, "base", data)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "template execution failed", "err", err)
+	}
+}
+
+// Me returns the current session and a CSRF token for the front-end
+func (h *AuthHandler) Me(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	response := struct {
+		Authenticated bool     `json:"authenticated"`
+		Username      string   `json:"username,omitempty"`
+		Provider      string   `json:"provider,omitempty"`
+		Providers     []string `json:"providers"`
+		CSRFToken     string   `json:"csrfToken"`
+	}{
+		Providers: h.providers.Names(),
+		CSRFToken: h.sessions.CSRFToken(w, r),
+	}
+
+	if session, err := h.sessions.Get(r); err == nil {
+		response.Authenticated = true
+		response.Username = session.Username
+		response.Provider = session.Provider
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(response)
+}
+
+// credentialsRequest is the body of the login and register endpoints
+type credentialsRequest struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// Login signs a user in with the local password provider
+func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	provider, ok := h.providers.Password()
+	if !ok {
+		http.Error(w, "Password sign-in is not enabled", http.StatusNotFound)
+		return
+	}
+
+	var request credentialsRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request data", http.StatusBadRequest)
+		return
+	}
+
+	identity, err := provider.Authenticate(r.Context(), request.Username, request.Password)
+	if err != nil {
+		http.Error(w, "Invalid username or password", http.StatusUnauthorized)
+		return
+	}
+
+	h.startSession(w, identity)
+}
+
+// Register creates a local account and signs it in
+func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	provider, ok := h.providers.Password()
+	if !ok || !h.allowRegistration {
+		http.Error(w, "Registration is not enabled", http.StatusNotFound)
+		return
+	}
+
+	var request credentialsRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request data", http.StatusBadRequest)
+		return
+	}
+
+	identity, err := provider.Register(r.Context(), request.Username, request.Email, request.Password)
+	if errors.Is(err, auth.ErrUserExists) {
+		http.Error(w, "Username is already taken", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.startSession(w, identity)
+}
+
+// Logout clears the session cookie
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	h.sessions.Destroy(w)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RedirectFlow handles /auth/{provider}/login and /auth/{provider}/callback
+func (h *AuthHandler) RedirectFlow(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/auth/")
+	name, action, found := strings.Cut(path, "/")
+	if !found {
+		http.NotFound(w, r)
+		return
+	}
+
+	p, ok := h.providers.Get(name)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	provider, ok := p.(auth.RedirectProvider)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch action {
+	case "login":
+		state, nonce, err := h.sessions.BeginFlow(w, name)
+		if err != nil {
+			http.Error(w, "Failed to start sign-in", http.StatusInternalServerError)
+			return
+		}
+		http.Redirect(w, r, provider.AuthCodeURL(state, nonce), http.StatusFound)
+	case "callback":
+		h.finishRedirectFlow(w, r, provider)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// finishRedirectFlow validates the provider callback and signs the user in
+func (h *AuthHandler) finishRedirectFlow(w http.ResponseWriter, r *http.Request, provider auth.RedirectProvider) {
+	query := r.URL.Query()
+	if errCode := query.Get("error"); errCode != "" {
+		http.Redirect(w, r, "/login?error="+url.QueryEscape(errCode), http.StatusFound)
+		return
+	}
+
+	nonce, err := h.sessions.FinishFlow(w, r, provider.Name(), query.Get("state"))
+	if err != nil {
+		slog.WarnContext(r.Context(), "sign-in rejected", "provider", provider.Name(), "err", err)
+		http.Redirect(w, r, "/login?error=invalid_state", http.StatusFound)
+		return
+	}
+
+	identity, err := provider.Exchange(r.Context(), query.Get("code"), nonce)
+	if errors.Is(err, auth.ErrUsernameTaken) {
+		slog.WarnContext(r.Context(), "sign-in rejected", "provider", provider.Name(), "err", err)
+		http.Redirect(w, r, "/login?error=username_taken", http.StatusFound)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "sign-in failed", "provider", provider.Name(), "err", err)
+		http.Redirect(w, r, "/login?error=exchange_failed", http.StatusFound)
+		return
+	}
+
+	if err := h.sessions.Create(w, identity.Username, identity.Provider); err != nil {
+		http.Error(w, "Failed to create session", http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/", http.StatusFound)
+}
+
+// startSession sets the session cookie and writes the signed-in identity
+func (h *AuthHandler) startSession(w http.ResponseWriter, identity *auth.Identity) {
+	if err := h.sessions.Create(w, identity.Username, identity.Provider); err != nil {
+		http.Error(w, "Failed to create session", http.StatusInternalServerError)
+		return
+	}
+
+	response := struct {
+		Username string `json:"username"`
+		Provider string `json:"provider"`
+		Success  bool   `json:"success"`
+	}{
+		Username: identity.Username,
+		Provider: identity.Provider,
+		Success:  true,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"web-ui/internal/badge"
+	"web-ui/internal/paths"
+	"web-ui/internal/services"
+)
+
+// BadgeMaxAge is how long image proxies such as GitHub's camo may cache a
+// badge before revalidating it
+const BadgeMaxAge = 5 * time.Minute
+
+// BadgeHandler serves shields-style SVG badges of a user's progress and of a
+// challenge's solvers, for embedding in profiles and READMEs. Every badge
+// accepts ?style=, ?label=, ?color= and ?labelColor= to restyle it.
+type BadgeHandler struct {
+	challengeService  *services.ChallengeService
+	scoreboardService *services.ScoreboardService
+	leaderboard       *services.LeaderboardService
+}
+
+// NewBadgeHandler creates a new badge handler
+func NewBadgeHandler(challengeService *services.ChallengeService, scoreboardService *services.ScoreboardService, leaderboard *services.LeaderboardService) *BadgeHandler {
+	return &BadgeHandler{
+		challengeService:  challengeService,
+		scoreboardService: scoreboardService,
+		leaderboard:       leaderboard,
+	}
+}
+
+// UserBadge handles GET /badge/{username}.svg, e.g. "solved | 18/30 · Expert"
+func (h *BadgeHandler) UserBadge(w http.ResponseWriter, r *http.Request) {
+	username, ok := strings.CutSuffix(r.PathValue("file"), ".svg")
+	if !ok || paths.ValidateUsername(username) != nil {
+		h.serveError(w, r, http.StatusNotFound, "user not found")
+		return
+	}
+
+	challenges := h.challengeService.GetChallenges()
+	solved := len(h.scoreboardService.Completions(challenges)[username])
+	message := fmt.Sprintf("%d/%d", solved, len(challenges))
+	if tier := tierName(h.leaderboard.Achievement(solved)); tier != "" {
+		message += " · " + tier
+	}
+	h.serve(w, r, badge.Badge{
+		Label:   "solved",
+		Message: message,
+		Color:   progressColor(solved, len(challenges)),
+	})
+}
+
+// ChallengeBadge handles GET /badge/challenge/{id}.svg, e.g.
+// "challenge 3 | Beginner · 12 solvers"
+func (h *BadgeHandler) ChallengeBadge(w http.ResponseWriter, r *http.Request) {
+	idStr, ok := strings.CutSuffix(r.PathValue("file"), ".svg")
+	id, err := strconv.Atoi(idStr)
+	if !ok || err != nil {
+		h.serveError(w, r, http.StatusNotFound, "challenge not found")
+		return
+	}
+	challenge, ok := h.challengeService.GetChallenge(id)
+	if !ok {
+		h.serveError(w, r, http.StatusNotFound, "challenge not found")
+		return
+	}
+
+	solvers := 0
+	for _, solved := range h.scoreboardService.Completions(h.challengeService.GetChallenges()) {
+		if solved[id] {
+			solvers++
+		}
+	}
+	noun := "solvers"
+	if solvers == 1 {
+		noun = "solver"
+	}
+	h.serve(w, r, badge.Badge{
+		Label:   fmt.Sprintf("challenge %d", id),
+		Message: fmt.Sprintf("%s · %d %s", challenge.Difficulty, solvers, noun),
+		Color:   difficultyColor(challenge.Difficulty),
+	})
+}
+
+// serve applies the query's overrides to b and writes it with cache
+// validators, so proxies get 304 until the badge's text changes
+func (h *BadgeHandler) serve(w http.ResponseWriter, r *http.Request, b badge.Badge) {
+	query := r.URL.Query()
+	b.Style = query.Get("style")
+	if !badge.ValidStyle(b.Style) {
+		h.serveError(w, r, http.StatusBadRequest, "unknown style")
+		return
+	}
+	if query.Has("label") {
+		b.Label = query.Get("label")
+	}
+	for _, override := range []struct {
+		param string
+		color *string
+	}{{"color", &b.Color}, {"labelColor", &b.LabelColor}} {
+		if value := query.Get(override.param); value != "" {
+			if _, err := badge.ParseColor(value); err != nil {
+				h.serveError(w, r, http.StatusBadRequest, "invalid "+override.param)
+				return
+			}
+			*override.color = value
+		}
+	}
+	h.write(w, r, http.StatusOK, b)
+}
+
+// serveError answers with a red badge rather than text, so a broken embed
+// still says what is wrong
+func (h *BadgeHandler) serveError(w http.ResponseWriter, r *http.Request, status int, message string) {
+	style := r.URL.Query().Get("style")
+	if !badge.ValidStyle(style) {
+		style = ""
+	}
+	h.write(w, r, status, badge.Badge{Label: "badge", Message: message, Color: "red", Style: style})
+}
+
+func (h *BadgeHandler) write(w http.ResponseWriter, r *http.Request, status int, b badge.Badge) {
+	body, err := badge.Render(b)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "rendering badge failed", "path", r.URL.Path, "err", err)
+		http.Error(w, "Failed to render badge", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", badge.ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	// Badges opened directly are documents of this origin; forbid scripts
+	// should escaping ever miss something
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'")
+	if status != http.StatusOK {
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(status)
+		w.Write(body)
+		return
+	}
+	sum := sha256.Sum256(body)
+	w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:12])+`"`)
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(BadgeMaxAge.Seconds())))
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(body))
+}
+
+// tierName drops the emoji the achievement tiers start with, which badge
+// fonts render poorly
+func tierName(achievement string) string {
+	return strings.TrimLeftFunc(achievement, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// progressColor shades a user badge from grey to bright green as the user
+// works through the challenges
+func progressColor(solved, total int) string {
+	switch {
+	case solved == 0 || total == 0:
+		return "lightgrey"
+	case solved*4 >= total*3:
+		return "brightgreen"
+	case solved*2 >= total:
+		return "green"
+	case solved*4 >= total:
+		return "yellowgreen"
+	default:
+		return "yellow"
+	}
+}
+
+// difficultyColor matches the difficulty colours of the challenge list
+func difficultyColor(difficulty string) string {
+	switch difficulty {
+	case "Beginner":
+		return "brightgreen"
+	case "Intermediate":
+		return "orange"
+	case "Advanced":
+		return "red"
+	default:
+		return "blue"
+	}
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"web-ui/internal/paths"
+	"web-ui/internal/services"
+)
+
+func newBadgeHandler(t *testing.T) *http.ServeMux {
+	t.Helper()
+	root := writeRepo(t, map[string]string{
+		"challenge-1/README.md":            "# Challenge 1: Sum\n",
+		"challenge-1/SCOREBOARD.md":        "| Username | Passed Tests | Total Tests |\n|---|---|---|\n| gopher | 1 | 1 |\n| ada | 2 | 3 |\n",
+		"challenge-2/README.md":            "# Challenge 2: Reverse\n",
+		"challenge-1/solution-template.go": "package main\n",
+		"challenge-2/solution-template.go": "package main\n",
+	})
+
+	resolver, err := paths.NewResolver(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	challengeService := services.NewChallengeService(resolver)
+	if err := challengeService.LoadChallenges(); err != nil {
+		t.Fatal(err)
+	}
+	scoreboardService := services.NewScoreboardService(resolver)
+	scorer, err := services.NewScorer("completion", services.ScoringRules{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaderboard := services.NewLeaderboardService(challengeService, scoreboardService, scorer, []services.Tier{{Name: "🌱 Beginner", MinSolved: 0}, {Name: "⭐ Expert", MinSolved: 1}})
+	h := NewBadgeHandler(challengeService, scoreboardService, leaderboard)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /badge/{file}", h.UserBadge)
+	mux.HandleFunc("GET /badge/challenge/{file}", h.ChallengeBadge)
+	return mux
+}
+
+func TestBadges(t *testing.T) {
+	mux := newBadgeHandler(t)
+	get := func(target string, header http.Header) (*httptest.ResponseRecorder, string) {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		for name, values := range header {
+			req.Header[name] = values
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		var svg struct {
+			Title string `xml:"title"`
+		}
+		if rec.Code != http.StatusNotModified {
+			if err := xml.Unmarshal(rec.Body.Bytes(), &svg); err != nil {
+				t.Fatalf("%s is not valid XML: %v\n%s", target, err, rec.Body)
+			}
+		}
+		return rec, svg.Title
+	}
+
+	rec, title := get("/badge/gopher.svg", nil)
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "image/svg+xml; charset=utf-8" {
+		t.Fatalf("gopher.svg = %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	// The tier comes from the leaderboard, without its emoji
+	if title != "solved: 1/2 · Expert" {
+		t.Errorf("gopher's badge = %q", title)
+	}
+	if rec.Header().Get("Cache-Control") != "public, max-age=300" {
+		t.Errorf("Cache-Control = %q", rec.Header().Get("Cache-Control"))
+	}
+	etag := rec.Header().Get("ETag")
+	if rec, _ := get("/badge/gopher.svg", http.Header{"If-None-Match": {etag}}); etag == "" || rec.Code != http.StatusNotModified {
+		t.Errorf("If-None-Match %q = %d, want 304", etag, rec.Code)
+	}
+
+	// Partial solves do not count
+	if _, title := get("/badge/ada.svg", nil); title != "solved: 0/2 · Beginner" {
+		t.Errorf("ada's badge = %q", title)
+	}
+	if _, title := get("/badge/challenge/1.svg", nil); title != "challenge 1: Beginner · 1 solver" {
+		t.Errorf("challenge 1's badge = %q", title)
+	}
+
+	// Labels from the query are escaped
+	rec, title = get("/badge/gopher.svg?style=for-the-badge&label=%3Cscript%3E&color=ff69b4", nil)
+	if rec.Code != http.StatusOK || title != "<SCRIPT>: 1/2 · EXPERT" || strings.Contains(rec.Body.String(), "<SCRIPT>") {
+		t.Errorf("restyled badge = %d %q:\n%s", rec.Code, title, rec.Body)
+	}
+
+	for target, want := range map[string]int{
+		"/badge/gopher.svg?style=round":      http.StatusBadRequest,
+		"/badge/gopher.svg?color=javascript": http.StatusBadRequest,
+		"/badge/gopher.png":                  http.StatusNotFound,
+		"/badge/..%2Fetc.svg":                http.StatusNotFound,
+		"/badge/challenge/99.svg":            http.StatusNotFound,
+		"/badge/challenge/one.svg":           http.StatusNotFound,
+	} {
+		// Errors are badges too, so broken embeds say what is wrong
+		if rec, title := get(target, nil); rec.Code != want || !strings.HasPrefix(title, "badge: ") {
+			t.Errorf("%s = %d %q, want %d", target, rec.Code, title, want)
+		}
+	}
+}
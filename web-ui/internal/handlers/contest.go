@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"web-ui/internal/audit"
+	"web-ui/internal/models"
+	"web-ui/internal/services"
+)
+
+// ContestSummary is a contest with its phase at the time of the request
+type ContestSummary struct {
+	models.Contest
+	Status string `json:"status" enum:"upcoming,running,frozen,ended"`
+}
+
+// ContestRequest is the body of PUT /api/v1/admin/contests/{slug}
+type ContestRequest struct {
+	Name           string    `json:"name,omitempty" doc:"Display name, whose slug must be the path slug; defaults to the slug"`
+	ChallengeIDs   []int     `json:"challengeIds" doc:"Between 1 and 26 challenges, in problem order"`
+	StartsAt       time.Time `json:"startsAt"`
+	EndsAt         time.Time `json:"endsAt"`
+	FreezeMinutes  int       `json:"freezeMinutes,omitempty" doc:"Minutes before the end during which the public scoreboard stops updating"`
+	PenaltyMinutes int       `json:"penaltyMinutes,omitempty" doc:"Minutes added per rejected submission before a problem is solved"`
+}
+
+// ListContests handles GET /api/v1/contests
+func (h *APIHandler) ListContests(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	contests := h.contestService.Contests()
+	summaries := make([]ContestSummary, 0, len(contests))
+	for _, contest := range contests {
+		summaries = append(summaries, ContestSummary{Contest: contest, Status: contest.Status(now)})
+	}
+	writeJSON(w, http.StatusOK, summaries)
+}
+
+// GetContest handles GET /api/v1/contests/{slug}
+func (h *APIHandler) GetContest(w http.ResponseWriter, r *http.Request) {
+	slug := r.PathValue("slug")
+	contest, ok := h.contestService.Contest(slug)
+	if !ok {
+		writeError(w, r, http.StatusNotFound, CodeNotFound, "Contest not found", map[string]string{"slug": slug})
+		return
+	}
+	writeJSON(w, http.StatusOK, ContestSummary{Contest: contest, Status: contest.Status(time.Now())})
+}
+
+// GetContestScoreboard handles GET /api/v1/contests/{slug}/scoreboard, the
+// public standings that stop updating during the freeze
+func (h *APIHandler) GetContestScoreboard(w http.ResponseWriter, r *http.Request) {
+	writeContestScoreboard(w, r, h.contestService, false)
+}
+
+// writeContestScoreboard writes the public or live standings of the contest
+// named by the slug path parameter
+func writeContestScoreboard(w http.ResponseWriter, r *http.Request, contests *services.ContestService, live bool) {
+	slug := r.PathValue("slug")
+	board, err := contests.Scoreboard(slug, time.Now(), live)
+	if err != nil {
+		writeError(w, r, http.StatusNotFound, CodeNotFound, "Contest not found", map[string]string{"slug": slug})
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, board)
+}
+
+// checkContestSubmission validates a submission entered in a contest before
+// its code runs, returning the status, error code and message to reject it
+// with
+func (h *APIHandler) checkContestSubmission(submission models.Submission) (int, string, string, bool) {
+	_, err := h.contestService.CheckSubmission(submission.Contest, submission.ChallengeID, submission.SubmittedAt)
+	switch {
+	case errors.Is(err, services.ErrContestNotFound):
+		return http.StatusNotFound, CodeNotFound, "Contest not found", false
+	case errors.Is(err, services.ErrContestNotRunning):
+		return http.StatusConflict, CodeConflict, "Contest is not running", false
+	case errors.Is(err, services.ErrNotInContest):
+		return http.StatusBadRequest, CodeBadRequest, "Challenge is not part of the contest", false
+	}
+	return 0, "", "", true
+}
+
+// recordContestAttempt enters a judged submission in its contest
+func (h *APIHandler) recordContestAttempt(r *http.Request, submission models.Submission) {
+	attempt := models.ContestAttempt{
+		Contest:     submission.Contest,
+		Username:    submission.Username,
+		ChallengeID: submission.ChallengeID,
+		Passed:      submission.Passed,
+		SubmittedAt: submission.SubmittedAt,
+	}
+	if err := h.contestService.Record(attempt); err != nil {
+		slog.ErrorContext(r.Context(), "failed to record contest attempt", "contest", attempt.Contest, "err", err)
+	}
+}
+
+// GetLiveContestScoreboard handles GET /api/v1/admin/contests/{slug}/scoreboard,
+// the standings including results hidden by the freeze
+func (h *AdminHandler) GetLiveContestScoreboard(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.requireAdmin(w, r); !ok {
+		return
+	}
+	writeContestScoreboard(w, r, h.contests, true)
+}
+
+// SaveContest handles PUT /api/v1/admin/contests/{slug}, creating or
+// replacing a contest
+func (h *AdminHandler) SaveContest(w http.ResponseWriter, r *http.Request) {
+	actor, ok := h.requireAdmin(w, r)
+	if !ok {
+		return
+	}
+	var request ContestRequest
+	if !decodeJSON(w, r, &request) {
+		return
+	}
+
+	slug := r.PathValue("slug")
+	if request.Name == "" {
+		request.Name = slug
+	}
+	if nameSlug := services.ContestSlug(request.Name); nameSlug != slug {
+		writeError(w, r, http.StatusBadRequest, CodeBadRequest, "Contest name does not match the slug",
+			map[string]string{"slug": slug, "nameSlug": nameSlug})
+		return
+	}
+
+	contest, created, err := h.contests.SaveContest(models.Contest{
+		Name:           request.Name,
+		ChallengeIDs:   request.ChallengeIDs,
+		StartsAt:       request.StartsAt,
+		EndsAt:         request.EndsAt,
+		FreezeMinutes:  request.FreezeMinutes,
+		PenaltyMinutes: request.PenaltyMinutes,
+	})
+	switch {
+	case errors.Is(err, services.ErrInvalidContest):
+		writeError(w, r, http.StatusBadRequest, CodeBadRequest, err.Error(), nil)
+		return
+	case err != nil:
+		slog.ErrorContext(r.Context(), "contest save failed", "contest", slug, "err", err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Failed to save contest", nil)
+		return
+	}
+
+	outcome := "updated"
+	if created {
+		outcome = "created"
+	}
+	h.recordChange(r, actor, audit.ActionContest, contest.Slug, outcome)
+	writeJSON(w, http.StatusOK, contest)
+}
+
+// DeleteContest handles DELETE /api/v1/admin/contests/{slug}
+func (h *AdminHandler) DeleteContest(w http.ResponseWriter, r *http.Request) {
+	actor, ok := h.requireAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	slug := r.PathValue("slug")
+	contest, exists := h.contests.Contest(slug)
+	if !exists {
+		writeError(w, r, http.StatusNotFound, CodeNotFound, "Contest not found", map[string]string{"slug": slug})
+		return
+	}
+	err := h.contests.DeleteContest(slug)
+	switch {
+	case errors.Is(err, services.ErrContestNotFound):
+		writeError(w, r, http.StatusNotFound, CodeNotFound, "Contest not found", map[string]string{"slug": slug})
+		return
+	case err != nil:
+		slog.ErrorContext(r.Context(), "contest delete failed", "contest", slug, "err", err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Failed to delete contest", nil)
+		return
+	}
+
+	h.recordChange(r, actor, audit.ActionContest, slug, "deleted")
+	writeJSON(w, http.StatusOK, contest)
+}
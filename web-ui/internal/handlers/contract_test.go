@@ -0,0 +1,539 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"web-ui/internal/audit"
+	"web-ui/internal/auth"
+	"web-ui/internal/collab"
+	"web-ui/internal/config"
+	"web-ui/internal/events"
+	"web-ui/internal/models"
+	"web-ui/internal/openapi"
+	"web-ui/internal/paths"
+	"web-ui/internal/services"
+	"web-ui/internal/webhook"
+)
+
+var updateOpenAPI = flag.Bool("update", false, "rewrite openapi.json from the route table")
+
+// openAPIFile is the checked-in copy of the document served at /api/openapi.json
+const openAPIFile = "../../openapi.json"
+
+const contractSolution = `package main
+
+func Sum(a, b int) int { return a + b }
+
+func main() {}
+`
+
+// candidateUser prefixes the users of contract cases sent from a candidate's
+// browser admitted to the interview that follows, e.g. "candidate:{running}"
+const candidateUser = "candidate:"
+
+// rivalContestCode is the code of the seeded submission in the running contest
+const rivalContestCode = "package main\n\n// rival's contest entry\n"
+
+// contractServer serves the real route table over a one-challenge repository
+type contractServer struct {
+	handler  http.Handler
+	routes   []Route
+	sessions *auth.SessionManager
+	bus      *events.Bus
+	contests *services.ContestService
+
+	// placeholders maps names like {running} in targets and bodies to the
+	// random IDs and invite tokens of the seeded interviews and pair room
+	placeholders map[string]string
+}
+
+// writeRepo lays out a repository checkout in a temporary directory, files
+// mapping slash-separated paths to their content, and returns its root
+func writeRepo(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func newContractServer(t *testing.T) *contractServer {
+	t.Helper()
+	root := writeRepo(t, map[string]string{
+		"challenge-1/README.md":                               "# Challenge 1: Sum\n\nAdd two numbers.\n",
+		"challenge-1/solution-template.go":                    "package main\n\nfunc Sum(a, b int) int { return 0 }\n\nfunc main() {}\n",
+		"challenge-1/solution-template_test.go":               "package main\n\nimport \"testing\"\n\nfunc TestSum(t *testing.T) {\n\tif Sum(1, 2) != 3 {\n\t\tt.Fatal(\"Sum(1, 2) != 3\")\n\t}\n}\n\nfunc BenchmarkSum(b *testing.B) {\n\tfor i := 0; i < b.N; i++ {\n\t\tSum(1, 2)\n\t}\n}\n",
+		"challenge-1/metadata.json":                           `{"paths": [{"name": "Basics", "step": 1}]}`,
+		"challenge-1/SCOREBOARD.md":                           "# Scoreboard for challenge-1\n| Username   | Passed Tests | Total Tests |\n|------------|--------------|-------------|\n| gopher | 1 | 1 |\n",
+		"challenge-1/submissions/gopher/solution-template.go": contractSolution,
+		"challenge-1/submissions/ada/solution-template.go":    "package main\n\nfunc Sum(a, b int) int {\n\treturn b + a\n}\n\nfunc main() {}\n",
+		"teams.json": `[{"name": "Gophers", "members": ["gopher", "newcomer"]}]`,
+	})
+
+	resolver, err := paths.NewResolver(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	challengeService := services.NewChallengeService(resolver)
+	if err := challengeService.LoadChallenges(); err != nil {
+		t.Fatal(err)
+	}
+	scoreboardService := services.NewScoreboardService(resolver)
+	scoreboardService.LoadScoreboards(challengeService.GetChallenges())
+	executionService := services.NewExecutionService(resolver, services.ExecutionLimits{
+		Timeout: time.Minute, MaxConcurrent: 2, MaxOutputBytes: 64 << 10,
+	})
+	sessions, err := auth.NewSessionManager([]byte("contract-test-secret"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auditLog, err := audit.Open("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	userService := services.NewUserService(resolver)
+	submissionService := services.NewSubmissionService()
+	scorer, err := services.NewScorer(services.ScoringCompletion, services.ScoringRules{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaderboard := services.NewLeaderboardService(challengeService, scoreboardService, scorer, nil)
+	teamService, err := services.NewTeamService(resolver, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	contestService, err := services.NewContestService(challengeService, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	for _, contest := range []models.Contest{
+		{Name: "Weekly", ChallengeIDs: []int{1}, StartsAt: now.Add(-time.Hour), EndsAt: now.Add(time.Hour), FreezeMinutes: 30, PenaltyMinutes: 20},
+		{Name: "Next Month", ChallengeIDs: []int{1}, StartsAt: now.Add(30 * 24 * time.Hour), EndsAt: now.Add(30*24*time.Hour + time.Hour)},
+		{Name: "Finals", ChallengeIDs: []int{1}, StartsAt: now.Add(-time.Hour), EndsAt: now.Add(10 * time.Minute), FreezeMinutes: 30},
+	} {
+		if _, _, err := contestService.SaveContest(contest); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A rival's entry in the running contest, which no one else may read
+	submissionService.Add(models.Submission{Username: "rival", ChallengeID: 1, Code: rivalContestCode, Contest: "weekly", SubmittedAt: now})
+	interviewService, err := services.NewInterviewService(challengeService, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	placeholders := make(map[string]string)
+	for _, name := range []string{"invited", "running", "ended"} {
+		interview, token, err := interviewService.CreateInterview("gopher", "Ada", []int{1}, 45, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		placeholders["{"+name+"}"] = interview.ID
+		placeholders["{"+name+"-invite}"] = token
+		switch name {
+		case "running":
+			_, err = interviewService.Join(token, now)
+		case "ended":
+			_, err = interviewService.End(interview.ID, now)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	pairHub := collab.NewHub(0)
+	pairRoom, err := pairHub.CreateRoom(1, "gopher", "package main\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	placeholders["{pair}"] = pairRoom.ID
+	draftService := services.NewDraftService(challengeService, "")
+	if _, err := draftService.SaveSolution("gopher", 1, "package main\n", now); err != nil {
+		t.Fatal(err)
+	}
+	solutionService, err := services.NewSolutionService(resolver, scoreboardService, executionService, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	reviewService, err := services.NewReviewService(draftService, teamService, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	review, err := reviewService.Create("newcomer", "gopher", 1, 0, "", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	placeholders["{review}"] = review.ID
+	bus := events.NewBus()
+	profileService := services.NewProfileService(challengeService, scoreboardService, leaderboard, userService, submissionService, services.NewHistoryService(resolver))
+	api := NewAPIHandler(challengeService, scoreboardService, userService, executionService, submissionService, profileService, leaderboard, teamService, contestService, interviewService,
+		pairHub, draftService, solutionService, reviewService, sessions, auditLog, bus, config.FeatureConfig{SaveToFilesystem: true}, root)
+	similarityService := services.NewSimilarityService(resolver, challengeService, 0.8)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	t.Cleanup(receiver.Close)
+	deliveries, err := webhook.OpenDeliveryLog("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	webhooks, err := webhook.New([]webhook.Subscription{{Name: "chat", URL: receiver.URL, Secret: "s3cret"}}, deliveries, webhook.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { webhooks.Close(context.Background()) })
+	admin := NewAdminHandler(sessions, auditLog, teamService, contestService, similarityService, webhooks, deliveries, func(username string) bool { return username == "admin" })
+
+	routes := APIRoutes(api, admin)
+	mux := http.NewServeMux()
+	RegisterRoutes(mux, routes)
+	mux.HandleFunc("GET /api/openapi.json", ServeOpenAPI(OpenAPI(routes)))
+	return &contractServer{
+		handler:      sessions.CSRFProtect(mux, CSRFFailure),
+		routes:       routes,
+		sessions:     sessions,
+		bus:          bus,
+		contests:     contestService,
+		placeholders: placeholders,
+	}
+}
+
+// expand replaces the seeded interviews' and pair room's placeholders in s
+func (cs *contractServer) expand(s string) string {
+	for placeholder, value := range cs.placeholders {
+		s = strings.ReplaceAll(s, placeholder, value)
+	}
+	return s
+}
+
+// do sends a request as username ("" for anonymous) with a valid CSRF token
+func (cs *contractServer) do(t *testing.T, method, target, username string, body any) *httptest.ResponseRecorder {
+	t.Helper()
+	var reader *bytes.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reader = bytes.NewReader([]byte(cs.expand(string(data))))
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+
+	// Collect session and CSRF cookies as a browser would
+	cookies := httptest.NewRecorder()
+	token := cs.sessions.CSRFToken(cookies, httptest.NewRequest("GET", "/", nil))
+	if interview, ok := strings.CutPrefix(username, candidateUser); ok {
+		if err := cs.sessions.AdmitCandidate(cookies, cs.expand(interview), time.Now().Add(time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+	} else if username != "" {
+		if err := cs.sessions.Create(cookies, username, "local"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	req := httptest.NewRequest(method, cs.expand(target), reader)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set(auth.CSRFHeaderName, token)
+	for _, cookie := range cookies.Result().Cookies() {
+		req.AddCookie(cookie)
+	}
+
+	rec := httptest.NewRecorder()
+	cs.handler.ServeHTTP(rec, req)
+	return rec
+}
+
+// contractCase exercises one route; status 0 accepts any documented status
+type contractCase struct {
+	target string
+	user   string
+	body   any
+	status int
+}
+
+// contractCases has a case for every route, keyed by operationId
+var contractCases = map[string]contractCase{
+	"listChallenges":           {target: "/api/v1/challenges?q=sum&fields=id,title,score&status=solved", user: "gopher", status: 200},
+	"listTags":                 {target: "/api/v1/tags", status: 200},
+	"listTopics":               {target: "/api/v1/topics", status: 200},
+	"getChallenge":             {target: "/api/v1/challenges/1", status: 200},
+	"listLearningPaths":        {target: "/api/v1/paths", status: 200},
+	"getLearningPath":          {target: "/api/v1/paths/basics", status: 200},
+	"getUserProfile":           {target: "/api/v1/users/gopher", status: 200},
+	"getUserActivity":          {target: "/api/v1/users/gopher/activity?days=30", status: 200},
+	"getTeamActivity":          {target: "/api/v1/activity", status: 200},
+	"getNextChallenge":         {target: "/api/v1/users/gopher/next?path=basics", status: 200},
+	"getChallengeScoreboard":   {target: "/api/v1/challenges/1/scoreboard?team=gophers", status: 200},
+	"saveSolution":             {target: "/api/v1/challenges/1/solution", user: "gopher", body: SaveSolutionRequest{Code: contractSolution}, status: 200},
+	"createRun":                {target: "/api/v1/runs", body: RunRequest{ChallengeID: 1, Code: contractSolution}, status: 200},
+	"listSubmissions":          {target: "/api/v1/submissions", user: "gopher", status: 200},
+	"createSubmission":         {target: "/api/v1/submissions", user: "gopher", body: SubmissionRequest{ChallengeID: 1, Code: contractSolution, Contest: "weekly"}, status: 201},
+	"refreshAttempts":          {target: "/api/v1/users/gopher/attempts/refresh", status: 200},
+	"getLeaderboard":           {target: "/api/v1/leaderboard?team=gophers", status: 200},
+	"listTeams":                {target: "/api/v1/teams", status: 200},
+	"getTeam":                  {target: "/api/v1/teams/gophers", status: 200},
+	"listContests":             {target: "/api/v1/contests", status: 200},
+	"getContest":               {target: "/api/v1/contests/weekly", status: 200},
+	"getContestScoreboard":     {target: "/api/v1/contests/weekly/scoreboard", status: 200},
+	"getLeaderboardRank":       {target: "/api/v1/leaderboard/gopher", status: 200},
+	"getGitIdentity":           {target: "/api/v1/git-username"},
+	"listAuditEvents":          {target: "/api/v1/admin/audit?limit=5", user: "admin", status: 200},
+	"saveTeam":                 {target: "/api/v1/admin/teams/night-owls", user: "admin", body: TeamRequest{Name: "Night Owls", Members: []string{"gopher"}}, status: 200},
+	"deleteTeam":               {target: "/api/v1/admin/teams/night-owls", user: "admin", status: 200},
+	"getLiveContestScoreboard": {target: "/api/v1/admin/contests/weekly/scoreboard", user: "admin", status: 200},
+	"saveContest": {target: "/api/v1/admin/contests/spring-cup", user: "admin", status: 200, body: ContestRequest{
+		Name: "Spring Cup", ChallengeIDs: []int{1}, StartsAt: time.Now(), EndsAt: time.Now().Add(time.Hour), PenaltyMinutes: 20,
+	}},
+	"deleteContest":         {target: "/api/v1/admin/contests/spring-cup", user: "admin", status: 200},
+	"listFlaggedSimilarity": {target: "/api/v1/admin/similarity?threshold=0.5", user: "admin", status: 200},
+	"getSimilarityReport":   {target: "/api/v1/admin/similarity/1?flagged=true", user: "admin", status: 200},
+	"listWebhooks":          {target: "/api/v1/admin/webhooks", user: "admin", status: 200},
+	"listWebhookDeliveries": {target: "/api/v1/admin/webhooks/deliveries?subscription=chat&limit=10", user: "admin", status: 200},
+	"pingWebhook":           {target: "/api/v1/admin/webhooks/chat/ping", user: "admin", status: 202},
+	"createInterview":       {target: "/api/v1/interviews", user: "gopher", body: InterviewRequest{Candidate: "Grace", ChallengeIDs: []int{1}, DurationMinutes: 30}, status: 201},
+	"listInterviews":        {target: "/api/v1/interviews", user: "gopher", status: 200},
+	"getInterview":          {target: "/api/v1/interviews/{running}", user: "gopher", status: 200},
+	"getInterviewReport":    {target: "/api/v1/interviews/{running}/report", user: "gopher", status: 200},
+	"exportInterview":       {target: "/api/v1/interviews/{running}/export", user: "gopher", status: 200},
+	"joinInterview":         {target: "/api/v1/interviews/join", body: JoinInterviewRequest{Token: "{invited-invite}"}, status: 200},
+	"saveEditorSnapshot":    {target: "/api/v1/interviews/{running}/editor", user: candidateUser + "{running}", body: EditorSnapshotRequest{ChallengeID: 1, Code: contractSolution}, status: 200},
+	"endInterview":          {target: "/api/v1/interviews/{invited}/end", user: "gopher", status: 200},
+	"createPairRoom":        {target: "/api/v1/pair-rooms", user: "gopher", body: PairRoomRequest{ChallengeID: 1}, status: 201},
+	"getPairRoom":           {target: "/api/v1/pair-rooms/{pair}", status: 200},
+	"listDrafts":            {target: "/api/v1/drafts", user: "gopher", status: 200},
+	"listDraftVersions":     {target: "/api/v1/drafts/1", user: "gopher", status: 200},
+	"saveDraft":             {target: "/api/v1/drafts/1", user: "gopher", body: DraftRequest{Code: contractSolution, Autosave: true}, status: 200},
+	"getDraftVersion":       {target: "/api/v1/drafts/1/versions/1", user: "gopher", status: 200},
+	"diffDraftVersions":     {target: "/api/v1/drafts/1/diff?from=1&to=1", user: "gopher", status: 200},
+	"restoreDraftVersion":   {target: "/api/v1/drafts/1/versions/1/restore", user: "gopher", status: 201},
+	"listSolutions":         {target: "/api/v1/challenges/1/solutions", user: "gopher", status: 200},
+	"getSolution":           {target: "/api/v1/challenges/1/solutions/ada", user: "gopher", status: 200},
+	"compareSolutions":      {target: "/api/v1/challenges/1/solutions/gopher/compare/ada", user: "gopher", status: 200},
+	"benchmarkSolution":     {target: "/api/v1/challenges/1/solutions/ada/benchmarks", user: "gopher", status: 200},
+	"createReview":          {target: "/api/v1/reviews", user: "newcomer", body: ReviewRequest{Author: "gopher", ChallengeID: 1, Summary: "Second look"}, status: 201},
+	"listReviews":           {target: "/api/v1/reviews?challenge=1", user: "gopher", status: 200},
+	"getReview":             {target: "/api/v1/reviews/{review}", user: "gopher", status: 200},
+	"setReviewVerdict":      {target: "/api/v1/reviews/{review}/verdict", user: "newcomer", body: VerdictRequest{Verdict: "changes_requested"}, status: 200},
+	"createReviewThread":    {target: "/api/v1/reviews/{review}/threads", user: "newcomer", body: ThreadRequest{Line: 1, Body: "Missing a function"}, status: 201},
+	"replyToReviewThread":   {target: "/api/v1/reviews/{review}/threads/1/comments", user: "gopher", body: CommentRequest{Body: "Added"}, status: 201},
+	"resolveReviewThread":   {target: "/api/v1/reviews/{review}/threads/1/resolve", user: "gopher", status: 200},
+	"unresolveReviewThread": {target: "/api/v1/reviews/{review}/threads/1/unresolve", user: "newcomer", status: 200},
+	"listNotifications":     {target: "/api/v1/notifications?unread=true", user: "gopher", status: 200},
+	"markNotificationsRead": {target: "/api/v1/notifications/read", user: "gopher", body: MarkReadRequest{}, status: 200},
+
+	"legacyListChallenges":        {target: "/api/challenges", status: 200},
+	"legacyGetChallenge":          {target: "/api/challenges/1", status: 200},
+	"legacyGetUserProfile":        {target: "/api/users/gopher", status: 200},
+	"legacyListTeams":             {target: "/api/teams", status: 200},
+	"legacyListSubmissions":       {target: "/api/submissions", user: "gopher", status: 200},
+	"legacyCreateSubmission":      {target: "/api/submissions", user: "gopher", body: SubmissionRequest{ChallengeID: 1, Code: contractSolution}, status: 200},
+	"legacyGetScoreboard":         {target: "/api/scoreboard/1", status: 200},
+	"legacyRunCode":               {target: "/api/run", body: RunRequest{ChallengeID: 1, Code: contractSolution}, status: 200},
+	"legacySaveToFilesystem":      {target: "/api/save-to-filesystem", user: "gopher", body: services.SaveSubmissionRequest{ChallengeID: 1, Code: contractSolution}, status: 200},
+	"legacyRefreshAttempts":       {target: "/api/refresh-attempts", body: LegacyRefreshRequest{Username: "gopher"}, status: 200},
+	"legacyGetGitUsername":        {target: "/api/git-username", status: 200},
+	"legacyGetMainScoreboardRank": {target: "/api/main-scoreboard-rank?username=gopher", status: 200},
+	"legacyGetMainLeaderboard":    {target: "/api/main-leaderboard", status: 200},
+	"legacyListAuditEvents":       {target: "/api/admin/audit", user: "admin", status: 200},
+}
+
+// checkContract fails unless rec is a documented response of route whose
+// body matches the documented schema
+func checkContract(t *testing.T, doc *openapi.Document, route Route, rec *httptest.ResponseRecorder) {
+	t.Helper()
+	schema, ok := doc.ResponseSchema(route.Method, route.Path, rec.Code)
+	if !ok {
+		t.Errorf("%s %s returned undocumented status %d: %s", route.Method, route.Path, rec.Code, rec.Body.String())
+		return
+	}
+
+	contentType := rec.Header().Get("Content-Type")
+	if schema == nil {
+		if !strings.HasPrefix(contentType, "text/plain") {
+			t.Errorf("%s %s %d: Content-Type = %q, want text/plain", route.Method, route.Path, rec.Code, contentType)
+		}
+		return
+	}
+	if contentType != "application/json" {
+		t.Errorf("%s %s %d: Content-Type = %q, want application/json", route.Method, route.Path, rec.Code, contentType)
+		return
+	}
+	if err := doc.Validate(schema, rec.Body.Bytes()); err != nil {
+		t.Errorf("%s %s %d: response does not match the OpenAPI document: %v\nbody: %s",
+			route.Method, route.Path, rec.Code, err, rec.Body.String())
+	}
+}
+
+func TestHandlersMatchOpenAPIDocument(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs go test for submitted solutions")
+	}
+	cs := newContractServer(t)
+	doc := OpenAPI(cs.routes)
+
+	for _, route := range cs.routes {
+		tc, ok := contractCases[route.Name]
+		if !ok {
+			t.Errorf("no contract case for %s (%s %s)", route.Name, route.Method, route.Path)
+			continue
+		}
+		rec := cs.do(t, route.Method, tc.target, tc.user, tc.body)
+		if tc.status != 0 && rec.Code != tc.status {
+			t.Errorf("%s: status = %d, want %d: %s", route.Name, rec.Code, tc.status, rec.Body.String())
+			continue
+		}
+		checkContract(t, doc, route, rec)
+	}
+}
+
+func TestErrorResponsesMatchOpenAPIDocument(t *testing.T) {
+	cs := newContractServer(t)
+	doc := OpenAPI(cs.routes)
+	routes := make(map[string]Route)
+	for _, route := range cs.routes {
+		routes[route.Name] = route
+	}
+
+	tests := []struct {
+		name string
+		contractCase
+	}{
+		{"listChallenges", contractCase{target: "/api/v1/challenges?fields=id,secret", status: 400}},
+		{"listChallenges", contractCase{target: "/api/v1/challenges?status=solved", status: 401}},
+		{"legacyListChallenges", contractCase{target: "/api/challenges?sort=size", status: 400}},
+		{"getChallenge", contractCase{target: "/api/v1/challenges/99", status: 404}},
+		{"getLearningPath", contractCase{target: "/api/v1/paths/nope", status: 404}},
+		{"getNextChallenge", contractCase{target: "/api/v1/users/gopher/next?path=nope", status: 404}},
+		{"getNextChallenge", contractCase{target: "/api/v1/users/bad%20name/next", status: 400}},
+		{"getUserProfile", contractCase{target: "/api/v1/users/bad%20name", status: 400}},
+		{"getUserActivity", contractCase{target: "/api/v1/users/gopher/activity?days=0", status: 400}},
+		{"getTeamActivity", contractCase{target: "/api/v1/activity?days=week", status: 400}},
+		{"getChallenge", contractCase{target: "/api/v1/challenges/abc", status: 400}},
+		{"createRun", contractCase{target: "/api/v1/runs", body: "not an object", status: 400}},
+		{"createSubmission", contractCase{target: "/api/v1/submissions", body: SubmissionRequest{ChallengeID: 1}, status: 401}},
+		{"listAuditEvents", contractCase{target: "/api/v1/admin/audit", user: "gopher", status: 403}},
+		{"listAuditEvents", contractCase{target: "/api/v1/admin/audit?limit=0", user: "admin", status: 400}},
+		{"getLeaderboard", contractCase{target: "/api/v1/leaderboard?team=nope", status: 404}},
+		{"getTeam", contractCase{target: "/api/v1/teams/nope", status: 404}},
+		{"saveTeam", contractCase{target: "/api/v1/admin/teams/gophers", user: "admin", body: TeamRequest{Members: []string{"gopher"}}, status: 409}},
+		{"saveTeam", contractCase{target: "/api/v1/admin/teams/owls", user: "admin", body: TeamRequest{Name: "Night Owls"}, status: 400}},
+		{"saveTeam", contractCase{target: "/api/v1/admin/teams/owls", user: "admin", body: TeamRequest{Members: []string{"bad name"}}, status: 400}},
+		{"saveTeam", contractCase{target: "/api/v1/admin/teams/owls", user: "gopher", body: TeamRequest{}, status: 403}},
+		{"deleteTeam", contractCase{target: "/api/v1/admin/teams/gophers", user: "admin", status: 409}},
+		{"deleteTeam", contractCase{target: "/api/v1/admin/teams/nope", user: "admin", status: 404}},
+		{"getContest", contractCase{target: "/api/v1/contests/nope", status: 404}},
+		{"getContestScoreboard", contractCase{target: "/api/v1/contests/nope/scoreboard", status: 404}},
+		{"getLiveContestScoreboard", contractCase{target: "/api/v1/admin/contests/weekly/scoreboard", user: "gopher", status: 403}},
+		{"createSubmission", contractCase{target: "/api/v1/submissions", user: "gopher", body: SubmissionRequest{ChallengeID: 1, Contest: "nope"}, status: 404}},
+		{"createSubmission", contractCase{target: "/api/v1/submissions", user: "gopher", body: SubmissionRequest{ChallengeID: 1, Contest: "next-month"}, status: 409}},
+		{"legacyCreateSubmission", contractCase{target: "/api/submissions", user: "gopher", body: SubmissionRequest{ChallengeID: 1, Contest: "next-month"}, status: 409}},
+		{"legacyListSubmissions", contractCase{target: "/api/submissions", status: 401}},
+		{"saveContest", contractCase{target: "/api/v1/admin/contests/spring-cup", user: "admin", body: ContestRequest{ChallengeIDs: []int{1}}, status: 400}},
+		{"saveContest", contractCase{target: "/api/v1/admin/contests/spring-cup", user: "admin", body: ContestRequest{Name: "Autumn Cup"}, status: 400}},
+		{"deleteContest", contractCase{target: "/api/v1/admin/contests/nope", user: "admin", status: 404}},
+		{"listFlaggedSimilarity", contractCase{target: "/api/v1/admin/similarity?threshold=2", user: "admin", status: 400}},
+		{"getSimilarityReport", contractCase{target: "/api/v1/admin/similarity/1?flagged=maybe", user: "admin", status: 400}},
+		{"getSimilarityReport", contractCase{target: "/api/v1/admin/similarity/99", user: "admin", status: 404}},
+		{"getSimilarityReport", contractCase{target: "/api/v1/admin/similarity/1", user: "gopher", status: 403}},
+		{"listWebhooks", contractCase{target: "/api/v1/admin/webhooks", user: "gopher", status: 403}},
+		{"listWebhookDeliveries", contractCase{target: "/api/v1/admin/webhooks/deliveries?limit=0", user: "admin", status: 400}},
+		{"pingWebhook", contractCase{target: "/api/v1/admin/webhooks/nope/ping", user: "admin", status: 404}},
+		{"createInterview", contractCase{target: "/api/v1/interviews", user: "gopher", body: InterviewRequest{ChallengeIDs: []int{1, 1}, DurationMinutes: 30}, status: 400}},
+		{"createInterview", contractCase{target: "/api/v1/interviews", body: InterviewRequest{ChallengeIDs: []int{1}, DurationMinutes: 30}, status: 401}},
+		{"getInterview", contractCase{target: "/api/v1/interviews/{running}", user: "newcomer", status: 404}},
+		{"getInterviewReport", contractCase{target: "/api/v1/interviews/nope/report", user: "gopher", status: 404}},
+		{"joinInterview", contractCase{target: "/api/v1/interviews/join", body: JoinInterviewRequest{Token: "nope"}, status: 404}},
+		{"joinInterview", contractCase{target: "/api/v1/interviews/join", body: JoinInterviewRequest{Token: "{running-invite}"}, status: 409}},
+		{"saveEditorSnapshot", contractCase{target: "/api/v1/interviews/{running}/editor", body: EditorSnapshotRequest{ChallengeID: 1}, status: 403}},
+		{"saveEditorSnapshot", contractCase{target: "/api/v1/interviews/{running}/editor", user: candidateUser + "{running}", body: EditorSnapshotRequest{ChallengeID: 2}, status: 400}},
+		{"saveEditorSnapshot", contractCase{target: "/api/v1/interviews/{ended}/editor", user: candidateUser + "{ended}", body: EditorSnapshotRequest{ChallengeID: 1}, status: 409}},
+		{"endInterview", contractCase{target: "/api/v1/interviews/{ended}/end", user: "gopher", status: 409}},
+		{"createRun", contractCase{target: "/api/v1/runs", body: RunRequest{ChallengeID: 1, Interview: "{running}"}, status: 403}},
+		{"createPairRoom", contractCase{target: "/api/v1/pair-rooms", user: "gopher", body: PairRoomRequest{ChallengeID: 99}, status: 404}},
+		{"createPairRoom", contractCase{target: "/api/v1/pair-rooms", body: PairRoomRequest{ChallengeID: 1}, status: 401}},
+		{"getPairRoom", contractCase{target: "/api/v1/pair-rooms/nope", status: 404}},
+		{"listDrafts", contractCase{target: "/api/v1/drafts", status: 401}},
+		{"listDraftVersions", contractCase{target: "/api/v1/drafts/99", user: "gopher", status: 404}},
+		{"saveDraft", contractCase{target: "/api/v1/drafts/1", user: "gopher", body: DraftRequest{Code: "x", Label: strings.Repeat("l", 101)}, status: 400}},
+		{"getDraftVersion", contractCase{target: "/api/v1/drafts/1/versions/0", user: "gopher", status: 400}},
+		{"getDraftVersion", contractCase{target: "/api/v1/drafts/1/versions/1", user: "newcomer", status: 404}},
+		{"diffDraftVersions", contractCase{target: "/api/v1/drafts/1/diff?from=1", user: "gopher", status: 400}},
+		{"diffDraftVersions", contractCase{target: "/api/v1/drafts/1/diff?from=1&to=99", user: "gopher", status: 404}},
+		{"restoreDraftVersion", contractCase{target: "/api/v1/drafts/1/versions/99/restore", user: "gopher", status: 404}},
+		{"listSolutions", contractCase{target: "/api/v1/challenges/1/solutions", status: 401}},
+		{"listSolutions", contractCase{target: "/api/v1/challenges/1/solutions", user: "newcomer", status: 403}},
+		{"listSolutions", contractCase{target: "/api/v1/challenges/99/solutions", user: "gopher", status: 404}},
+		{"getSolution", contractCase{target: "/api/v1/challenges/1/solutions/bad_name", user: "gopher", status: 400}},
+		{"getSolution", contractCase{target: "/api/v1/challenges/1/solutions/nobody", user: "gopher", status: 404}},
+		{"compareSolutions", contractCase{target: "/api/v1/challenges/1/solutions/gopher/compare/nobody", user: "gopher", status: 404}},
+		{"benchmarkSolution", contractCase{target: "/api/v1/challenges/1/solutions/ada/benchmarks", user: "newcomer", status: 403}},
+		{"createReview", contractCase{target: "/api/v1/reviews", body: ReviewRequest{Author: "gopher", ChallengeID: 1}, status: 401}},
+		{"createReview", contractCase{target: "/api/v1/reviews", user: "newcomer", body: ReviewRequest{Author: "bad name", ChallengeID: 1}, status: 400}},
+		{"createReview", contractCase{target: "/api/v1/reviews", user: "gopher", body: ReviewRequest{Author: "gopher", ChallengeID: 1}, status: 400}},
+		{"createReview", contractCase{target: "/api/v1/reviews", user: "admin", body: ReviewRequest{Author: "gopher", ChallengeID: 1}, status: 403}},
+		{"createReview", contractCase{target: "/api/v1/reviews", user: "newcomer", body: ReviewRequest{Author: "gopher", ChallengeID: 99}, status: 404}},
+		{"listReviews", contractCase{target: "/api/v1/reviews?challenge=first", user: "gopher", status: 400}},
+		{"getReview", contractCase{target: "/api/v1/reviews/{review}", user: "admin", status: 404}},
+		{"setReviewVerdict", contractCase{target: "/api/v1/reviews/{review}/verdict", user: "gopher", body: VerdictRequest{Verdict: "approved"}, status: 403}},
+		{"setReviewVerdict", contractCase{target: "/api/v1/reviews/{review}/verdict", user: "newcomer", body: VerdictRequest{Verdict: "lgtm"}, status: 400}},
+		{"createReviewThread", contractCase{target: "/api/v1/reviews/{review}/threads", user: "newcomer", body: ThreadRequest{Line: 99, Body: "Here"}, status: 400}},
+		{"createReviewThread", contractCase{target: "/api/v1/reviews/nope/threads", user: "newcomer", body: ThreadRequest{Line: 1, Body: "Here"}, status: 404}},
+		{"replyToReviewThread", contractCase{target: "/api/v1/reviews/{review}/threads/9/comments", user: "gopher", body: CommentRequest{Body: "Done"}, status: 404}},
+		{"resolveReviewThread", contractCase{target: "/api/v1/reviews/{review}/threads/first/resolve", user: "gopher", status: 400}},
+		{"listNotifications", contractCase{target: "/api/v1/notifications?unread=maybe", user: "gopher", status: 400}},
+		{"legacyRunCode", contractCase{target: "/api/run", user: candidateUser + "{ended}", body: RunRequest{ChallengeID: 1, Interview: "{ended}"}, status: 409}},
+		{"legacyGetChallenge", contractCase{target: "/api/challenges/99", status: 404}},
+		{"legacyRunCode", contractCase{target: "/api/run", body: RunRequest{ChallengeID: 99}, status: 404}},
+		{"legacyGetMainScoreboardRank", contractCase{target: "/api/main-scoreboard-rank", status: 400}},
+	}
+	for _, tt := range tests {
+		route := routes[tt.name]
+		rec := cs.do(t, route.Method, tt.target, tt.user, tt.body)
+		if rec.Code != tt.status {
+			t.Errorf("%s %s: status = %d, want %d: %s", route.Method, tt.target, rec.Code, tt.status, rec.Body.String())
+			continue
+		}
+		checkContract(t, doc, route, rec)
+	}
+}
+
+func TestOpenAPIDocumentIsUpToDate(t *testing.T) {
+	cs := newContractServer(t)
+	rec := cs.do(t, "GET", "/api/openapi.json", "", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /api/openapi.json = %d", rec.Code)
+	}
+
+	if *updateOpenAPI {
+		if err := os.WriteFile(openAPIFile, rec.Body.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(openAPIFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(rec.Body.Bytes(), want) {
+		t.Errorf("openapi.json is out of date with the route table; run\n\tgo test ./internal/handlers -run TestOpenAPIDocumentIsUpToDate -update")
+	}
+}
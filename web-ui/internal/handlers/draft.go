@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"web-ui/internal/models"
+	"web-ui/internal/services"
+)
+
+// DraftRequest is the body of PUT /api/v1/drafts/{id}
+type DraftRequest struct {
+	Code     string `json:"code"`
+	Label    string `json:"label,omitempty" doc:"Names the version; labeled versions are kept longest"`
+	Autosave bool   `json:"autosave,omitempty" doc:"Fold into the latest version when that is an unlabeled autosave from the last few minutes"`
+}
+
+// DraftVersionSummary describes a draft version without its code
+type DraftVersionSummary struct {
+	Version      int       `json:"version"`
+	Label        string    `json:"label,omitempty"`
+	Source       string    `json:"source" enum:"autosave,manual,restore,filesystem,saved"`
+	RestoredFrom int       `json:"restoredFrom,omitempty"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+	Lines        int       `json:"lines"`
+}
+
+func newDraftVersionSummary(v models.DraftVersion) DraftVersionSummary {
+	lines := strings.Count(v.Code, "\n")
+	if v.Code != "" && !strings.HasSuffix(v.Code, "\n") {
+		lines++
+	}
+	return DraftVersionSummary{
+		Version:      v.Version,
+		Label:        v.Label,
+		Source:       v.Source,
+		RestoredFrom: v.RestoredFrom,
+		CreatedAt:    v.CreatedAt,
+		UpdatedAt:    v.UpdatedAt,
+		Lines:        lines,
+	}
+}
+
+// ListDrafts handles GET /api/v1/drafts
+func (h *APIHandler) ListDrafts(w http.ResponseWriter, r *http.Request) {
+	username, ok := h.requireUserV1(w, r)
+	if !ok {
+		return
+	}
+	summaries, err := h.draftService.Drafts(username)
+	if err != nil {
+		h.draftFailed(w, r, err)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, summaries)
+}
+
+// ListDraftVersions handles GET /api/v1/drafts/{id}
+func (h *APIHandler) ListDraftVersions(w http.ResponseWriter, r *http.Request) {
+	username, challenge, ok := h.draftChallenge(w, r)
+	if !ok {
+		return
+	}
+	versions, err := h.draftService.Versions(username, challenge.ID)
+	if err != nil {
+		h.draftFailed(w, r, err)
+		return
+	}
+	summaries := make([]DraftVersionSummary, 0, len(versions))
+	for _, v := range versions {
+		summaries = append(summaries, newDraftVersionSummary(v))
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, summaries)
+}
+
+// SaveDraft handles PUT /api/v1/drafts/{id}
+func (h *APIHandler) SaveDraft(w http.ResponseWriter, r *http.Request) {
+	username, challenge, ok := h.draftChallenge(w, r)
+	if !ok {
+		return
+	}
+	var request DraftRequest
+	if !decodeJSON(w, r, &request) {
+		return
+	}
+	version, err := h.draftService.Save(username, challenge.ID, request.Code, request.Label, request.Autosave, time.Now())
+	if err != nil {
+		h.draftFailed(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, version)
+}
+
+// GetDraftVersion handles GET /api/v1/drafts/{id}/versions/{version}
+func (h *APIHandler) GetDraftVersion(w http.ResponseWriter, r *http.Request) {
+	username, challenge, ok := h.draftChallenge(w, r)
+	if !ok {
+		return
+	}
+	number, ok := pathID(w, r, "version")
+	if !ok {
+		return
+	}
+	version, err := h.draftService.Version(username, challenge.ID, number)
+	if err != nil {
+		h.draftFailed(w, r, err)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, version)
+}
+
+// DiffDraftVersions handles GET /api/v1/drafts/{id}/diff?from=&to=
+func (h *APIHandler) DiffDraftVersions(w http.ResponseWriter, r *http.Request) {
+	username, challenge, ok := h.draftChallenge(w, r)
+	if !ok {
+		return
+	}
+	params := r.URL.Query()
+	var numbers [2]int
+	for i, name := range []string{"from", "to"} {
+		v := params.Get(name)
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			writeError(w, r, http.StatusBadRequest, CodeBadRequest, "Invalid "+name+" version", map[string]string{name: v})
+			return
+		}
+		numbers[i] = n
+	}
+	result, err := h.draftService.Diff(username, challenge.ID, numbers[0], numbers[1])
+	if err != nil {
+		h.draftFailed(w, r, err)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, result)
+}
+
+// RestoreDraftVersion handles POST /api/v1/drafts/{id}/versions/{version}/restore,
+// copying the version into a new latest version
+func (h *APIHandler) RestoreDraftVersion(w http.ResponseWriter, r *http.Request) {
+	username, challenge, ok := h.draftChallenge(w, r)
+	if !ok {
+		return
+	}
+	number, ok := pathID(w, r, "version")
+	if !ok {
+		return
+	}
+	version, err := h.draftService.Restore(username, challenge.ID, number, time.Now())
+	if err != nil {
+		h.draftFailed(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, version)
+}
+
+// draftChallenge returns the signed-in user and the challenge in the path,
+// writing an error when either is missing
+func (h *APIHandler) draftChallenge(w http.ResponseWriter, r *http.Request) (string, *models.Challenge, bool) {
+	username, ok := h.requireUserV1(w, r)
+	if !ok {
+		return "", nil, false
+	}
+	challenge, ok := h.challengeFromPath(w, r)
+	if !ok {
+		return "", nil, false
+	}
+	return username, challenge, true
+}
+
+// draftFailed writes the error for a failed draft operation
+func (h *APIHandler) draftFailed(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, services.ErrDraftNotFound):
+		writeError(w, r, http.StatusNotFound, CodeNotFound, "Draft version not found", nil)
+	case errors.Is(err, services.ErrInvalidDraft):
+		writeError(w, r, http.StatusBadRequest, CodeBadRequest, err.Error(), nil)
+	default:
+		slog.ErrorContext(r.Context(), "draft operation failed", "err", err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Failed to access drafts", nil)
+	}
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"web-ui/internal/feed"
+	"web-ui/internal/paths"
+	"web-ui/internal/services"
+)
+
+// FeedMaxAge is how long feed readers and proxies may cache a feed before
+// revalidating it
+const FeedMaxAge = 5 * time.Minute
+
+// FeedHandler serves Atom and RSS feeds of new challenges and recent solves.
+// Each feed is served as .atom and .rss from the same entries.
+type FeedHandler struct {
+	feeds   *services.FeedService
+	baseURL string // prefix of every link, without a trailing slash
+}
+
+// NewFeedHandler creates a new feed handler whose links start with baseURL.
+// Feeds are cached publicly, so links must not depend on the request.
+func NewFeedHandler(feeds *services.FeedService, baseURL string) *FeedHandler {
+	return &FeedHandler{feeds: feeds, baseURL: strings.TrimRight(baseURL, "/")}
+}
+
+// ChallengesFeed handles GET /feeds/challenges.atom and .rss, the most
+// recently added challenges
+func (h *FeedHandler) ChallengesFeed(w http.ResponseWriter, r *http.Request) {
+	f := &feed.Feed{
+		Title:    "Go Interview Practice: New Challenges",
+		Subtitle: "Challenges added to Go Interview Practice",
+		Link:     h.baseURL + "/",
+	}
+	for _, release := range h.feeds.Challenges() {
+		link := fmt.Sprintf("%s/challenge/%d", h.baseURL, release.Challenge.ID)
+		summary := release.Challenge.Difficulty
+		if len(release.Challenge.Topics) > 0 {
+			summary += " · " + strings.Join(release.Challenge.Topics, ", ")
+		}
+		f.Entries = append(f.Entries, feed.Entry{
+			ID:        link,
+			Title:     fmt.Sprintf("Challenge %d: %s", release.Challenge.ID, release.Challenge.Title),
+			Link:      link,
+			Summary:   summary,
+			Published: release.Added,
+			Updated:   release.Changed,
+		})
+	}
+	h.serve(w, r, f)
+}
+
+// SolvesFeed handles GET /feeds/solves.atom and .rss, the latest completed
+// challenges of every user
+func (h *FeedHandler) SolvesFeed(w http.ResponseWriter, r *http.Request) {
+	f := &feed.Feed{
+		Title:    "Go Interview Practice: Recent Solves",
+		Subtitle: "Challenges completed on the scoreboards",
+		Link:     h.baseURL + "/scoreboard",
+		Entries:  h.solveEntries(h.baseURL, h.feeds.Solves("")),
+	}
+	h.serve(w, r, f)
+}
+
+// UserSolvesFeed handles GET /feeds/users/{username}/solves.atom and .rss,
+// one user's latest completed challenges
+func (h *FeedHandler) UserSolvesFeed(w http.ResponseWriter, r *http.Request) {
+	username := r.PathValue("username")
+	if err := paths.ValidateUsername(username); err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	f := &feed.Feed{
+		Title:    fmt.Sprintf("Go Interview Practice: Solves by %s", username),
+		Subtitle: fmt.Sprintf("Challenges completed by %s", username),
+		Link:     h.baseURL + "/user/" + url.PathEscape(username),
+		Entries:  h.solveEntries(h.baseURL, h.feeds.Solves(username)),
+	}
+	h.serve(w, r, f)
+}
+
+func (h *FeedHandler) solveEntries(origin string, solves []services.Solve) []feed.Entry {
+	var entries []feed.Entry
+	for _, solve := range solves {
+		title := fmt.Sprintf("Challenge %d: %s", solve.Challenge.ID, solve.Challenge.Title)
+		entries = append(entries, feed.Entry{
+			ID:        fmt.Sprintf("%s/user/%s#challenge-%d", origin, url.PathEscape(solve.Username), solve.Challenge.ID),
+			Title:     fmt.Sprintf("%s solved %s", solve.Username, title),
+			Link:      fmt.Sprintf("%s/challenge/%d", origin, solve.Challenge.ID),
+			Author:    solve.Username,
+			Summary:   solve.Challenge.Difficulty,
+			Published: solve.SolvedAt,
+			Updated:   solve.SolvedAt,
+		})
+	}
+	return entries
+}
+
+// serve renders f in the format the path asks for. The ETag is a hash of the
+// body and Last-Modified the newest entry's date, so readers polling with
+// If-None-Match or If-Modified-Since get 304 until something changes.
+func (h *FeedHandler) serve(w http.ResponseWriter, r *http.Request, f *feed.Feed) {
+	f.Self = h.baseURL + r.URL.Path
+	f.ID = f.Self
+	f.Updated = feed.LastUpdated(f.Entries)
+	if f.Updated.IsZero() {
+		// An empty feed still needs a date; http.ServeContent omits
+		// Last-Modified for the epoch
+		f.Updated = time.Unix(0, 0)
+	}
+
+	var body []byte
+	var err error
+	contentType := feed.AtomContentType
+	if path.Ext(r.URL.Path) == ".rss" {
+		body, err = f.RSS()
+		contentType = feed.RSSContentType
+	} else {
+		body, err = f.Atom()
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "rendering feed failed", "path", r.URL.Path, "err", err)
+		http.Error(w, "Failed to render feed", http.StatusInternalServerError)
+		return
+	}
+
+	sum := sha256.Sum256(body)
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:12])+`"`)
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(FeedMaxAge.Seconds())))
+	http.ServeContent(w, r, "", f.Updated, bytes.NewReader(body))
+}
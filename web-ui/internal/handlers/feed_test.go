@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"web-ui/internal/paths"
+	"web-ui/internal/services"
+)
+
+func newFeedHandler(t *testing.T) *http.ServeMux {
+	t.Helper()
+	files := map[string]string{
+		"challenge-1/README.md":                               "# Challenge 1: Sum <of> & more\n\nAdd two numbers.\n",
+		"challenge-1/solution-template.go":                    "package main\n",
+		"challenge-1/SCOREBOARD.md":                           "| Username | Passed Tests | Total Tests |\n|---|---|---|\n| gopher | 1 | 1 |\n",
+		"challenge-1/submissions/gopher/solution-template.go": contractSolution,
+	}
+	root := writeRepo(t, files)
+	solvedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for name := range files {
+		if err := os.Chtimes(filepath.Join(root, name), solvedAt, solvedAt); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	resolver, err := paths.NewResolver(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	challengeService := services.NewChallengeService(resolver)
+	if err := challengeService.LoadChallenges(); err != nil {
+		t.Fatal(err)
+	}
+	feeds := services.NewFeedService(resolver, challengeService, services.NewScoreboardService(resolver), services.NewHistoryService(resolver), services.NewUserService(resolver))
+	h := NewFeedHandler(feeds, "https://go.example.com/")
+
+	mux := http.NewServeMux()
+	for _, format := range []string{"atom", "rss"} {
+		mux.HandleFunc("GET /feeds/challenges."+format, h.ChallengesFeed)
+		mux.HandleFunc("GET /feeds/solves."+format, h.SolvesFeed)
+		mux.HandleFunc("GET /feeds/users/{username}/solves."+format, h.UserSolvesFeed)
+	}
+	return mux
+}
+
+func TestFeeds(t *testing.T) {
+	mux := newFeedHandler(t)
+	get := func(target string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		for name, values := range header {
+			req.Header[name] = values
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := get("/feeds/challenges.atom", nil)
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/atom+xml; charset=utf-8" {
+		t.Fatalf("challenges.atom = %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	var atom struct {
+		ID      string `xml:"id"`
+		Updated string `xml:"updated"`
+		Entries []struct {
+			Title   string `xml:"title"`
+			Updated string `xml:"updated"`
+		} `xml:"entry"`
+	}
+	if err := xml.Unmarshal(rec.Body.Bytes(), &atom); err != nil {
+		t.Fatalf("challenges.atom is not valid XML: %v\n%s", err, rec.Body)
+	}
+	// Without git history, the challenge is dated by its README
+	if len(atom.Entries) != 1 || atom.Entries[0].Title != "Challenge 1: Sum <of> & more" || atom.Updated != "2024-05-01T12:00:00Z" {
+		t.Errorf("challenges.atom = %+v", atom)
+	}
+	// Links come from the configured base URL, never the request's Host
+	if atom.ID != "https://go.example.com/feeds/challenges.atom" {
+		t.Errorf("feed ID = %q, want the feed's absolute URL on the base URL", atom.ID)
+	}
+
+	// Conditional requests are answered with 304 until the feed changes
+	etag, modified := rec.Header().Get("ETag"), rec.Header().Get("Last-Modified")
+	if etag == "" || modified != "Wed, 01 May 2024 12:00:00 GMT" {
+		t.Fatalf("ETag = %q, Last-Modified = %q", etag, modified)
+	}
+	if rec := get("/feeds/challenges.atom", http.Header{"If-None-Match": {etag}}); rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Errorf("If-None-Match = %d, want 304", rec.Code)
+	}
+	if rec := get("/feeds/challenges.atom", http.Header{"If-Modified-Since": {modified}}); rec.Code != http.StatusNotModified {
+		t.Errorf("If-Modified-Since = %d, want 304", rec.Code)
+	}
+	if rec := get("/feeds/challenges.atom", http.Header{"If-None-Match": {`"stale"`}}); rec.Code != http.StatusOK {
+		t.Errorf("stale If-None-Match = %d, want 200", rec.Code)
+	}
+
+	rec = get("/feeds/solves.rss", nil)
+	var rss struct {
+		Items []struct {
+			Title   string `xml:"title"`
+			Creator string `xml:"http://purl.org/dc/elements/1.1/ creator"`
+			PubDate string `xml:"pubDate"`
+		} `xml:"channel>item"`
+	}
+	if err := xml.Unmarshal(rec.Body.Bytes(), &rss); err != nil {
+		t.Fatalf("solves.rss is not valid XML: %v\n%s", err, rec.Body)
+	}
+	if len(rss.Items) != 1 || rss.Items[0].Creator != "gopher" || rss.Items[0].PubDate != "Wed, 01 May 2024 12:00:00 +0000" {
+		t.Errorf("solves.rss = %+v", rss)
+	}
+
+	if rec := get("/feeds/users/gopher/solves.atom", nil); rec.Code != http.StatusOK || !xmlHasEntries(t, rec, 1) {
+		t.Errorf("gopher's feed = %d: %s", rec.Code, rec.Body)
+	}
+	// A user without solves has an empty feed, with no Last-Modified
+	if rec := get("/feeds/users/ada/solves.atom", nil); rec.Code != http.StatusOK || !xmlHasEntries(t, rec, 0) || rec.Header().Get("Last-Modified") != "" {
+		t.Errorf("ada's feed = %d %v: %s", rec.Code, rec.Header(), rec.Body)
+	}
+	if rec := get("/feeds/users/..%2Fetc/solves.atom", nil); rec.Code != http.StatusNotFound {
+		t.Errorf("invalid username = %d, want 404", rec.Code)
+	}
+}
+
+func xmlHasEntries(t *testing.T, rec *httptest.ResponseRecorder, n int) bool {
+	t.Helper()
+	var atom struct {
+		Entries []struct{} `xml:"entry"`
+	}
+	if err := xml.Unmarshal(rec.Body.Bytes(), &atom); err != nil {
+		t.Fatal(err)
+	}
+	return len(atom.Entries) == n
+}
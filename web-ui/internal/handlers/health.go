@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+
+	"web-ui/internal/services"
+)
+
+// HealthHandler serves liveness and readiness probes
+type HealthHandler struct {
+	challengeService *services.ChallengeService
+	executionService *services.ExecutionService
+	shuttingDown     atomic.Bool
+}
+
+// NewHealthHandler creates a new health handler
+func NewHealthHandler(
+	challengeService *services.ChallengeService,
+	executionService *services.ExecutionService,
+) *HealthHandler {
+	return &HealthHandler{
+		challengeService: challengeService,
+		executionService: executionService,
+	}
+}
+
+// SetShuttingDown marks the server as draining so readiness fails
+func (h *HealthHandler) SetShuttingDown() {
+	h.shuttingDown.Store(true)
+}
+
+// Healthz reports that the process is alive
+func (h *HealthHandler) Healthz(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" && r.Method != "HEAD" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(struct {
+		Status string `json:"status"`
+	}{Status: "ok"})
+}
+
+// readinessCheck is the outcome of a single readiness check
+type readinessCheck struct {
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Readyz reports whether the server can serve traffic: challenges are
+// loaded, a Go toolchain is available and the server is not shutting down
+func (h *HealthHandler) Readyz(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" && r.Method != "HEAD" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	checks := make(map[string]readinessCheck)
+
+	challengeCount := len(h.challengeService.GetChallenges())
+	checks["challenges"] = readinessCheck{OK: challengeCount > 0, Detail: fmt.Sprintf("%d loaded", challengeCount)}
+
+	if version, err := h.executionService.CheckToolchain(r.Context()); err != nil {
+		checks["goToolchain"] = readinessCheck{OK: false, Detail: err.Error()}
+	} else {
+		checks["goToolchain"] = readinessCheck{OK: true, Detail: version}
+	}
+
+	if h.shuttingDown.Load() || h.executionService.IsDraining() {
+		checks["accepting"] = readinessCheck{OK: false, Detail: "shutting down"}
+	} else {
+		checks["accepting"] = readinessCheck{OK: true}
+	}
+
+	ready := true
+	for _, check := range checks {
+		ready = ready && check.OK
+	}
+
+	status := "ready"
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if !ready {
+		status = "not ready"
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	json.NewEncoder(w).Encode(struct {
+		Status string                    `json:"status"`
+		Checks map[string]readinessCheck `json:"checks"`
+	}{
+		Status: status,
+		Checks: checks,
+	})
+}
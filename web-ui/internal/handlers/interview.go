@@ -0,0 +1,262 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"web-ui/internal/models"
+	"web-ui/internal/services"
+)
+
+// InterviewSummary is an interview with its phase and remaining time at the
+// time of the request
+type InterviewSummary struct {
+	models.Interview
+	Status           string     `json:"status" enum:"invited,in_progress,ended"`
+	EndsAt           *time.Time `json:"endsAt,omitempty" doc:"When the candidate's time runs out, once they have joined"`
+	RemainingSeconds int        `json:"remainingSeconds" doc:"Seconds left while the interview is in progress"`
+}
+
+// InterviewRequest is the body of POST /api/v1/interviews
+type InterviewRequest struct {
+	Candidate       string `json:"candidate,omitempty" doc:"Name shown to the interviewer; the candidate needs no account"`
+	ChallengeIDs    []int  `json:"challengeIds" doc:"Between 1 and 5 challenges, in the order the candidate sees them"`
+	DurationMinutes int    `json:"durationMinutes" doc:"Time limit from when the candidate joins, at most 480"`
+}
+
+// InterviewInvite is a new interview with its one-time invite link
+type InterviewInvite struct {
+	InterviewSummary
+	InviteToken string `json:"inviteToken" doc:"Shown only once; the server keeps just its hash"`
+	InvitePath  string `json:"invitePath" doc:"Page the candidate opens to join"`
+}
+
+// JoinInterviewRequest is the body of POST /api/v1/interviews/join
+type JoinInterviewRequest struct {
+	Token string `json:"token"`
+}
+
+// EditorSnapshotRequest is the body of PUT /api/v1/interviews/{interview}/editor
+type EditorSnapshotRequest struct {
+	ChallengeID int    `json:"challengeId"`
+	Code        string `json:"code"`
+}
+
+func newInterviewSummary(interview models.Interview, now time.Time) InterviewSummary {
+	summary := InterviewSummary{Interview: interview, Status: interview.Status(now), EndsAt: interview.Deadline()}
+	if summary.Status == models.InterviewInProgress {
+		summary.RemainingSeconds = int(summary.EndsAt.Sub(now).Seconds())
+	}
+	return summary
+}
+
+// CreateInterview handles POST /api/v1/interviews
+func (h *APIHandler) CreateInterview(w http.ResponseWriter, r *http.Request) {
+	username, ok := h.requireUserV1(w, r)
+	if !ok {
+		return
+	}
+	var request InterviewRequest
+	if !decodeJSON(w, r, &request) {
+		return
+	}
+
+	now := time.Now()
+	interview, token, err := h.interviewService.CreateInterview(username, request.Candidate, request.ChallengeIDs, request.DurationMinutes, now)
+	switch {
+	case errors.Is(err, services.ErrInvalidInterview):
+		writeError(w, r, http.StatusBadRequest, CodeBadRequest, err.Error(), nil)
+		return
+	case err != nil:
+		slog.ErrorContext(r.Context(), "interview create failed", "err", err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Failed to create interview", nil)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, InterviewInvite{
+		InterviewSummary: newInterviewSummary(interview, now),
+		InviteToken:      token,
+		InvitePath:       "/invite/" + token,
+	})
+}
+
+// ListInterviews handles GET /api/v1/interviews, returning the interviews
+// the signed-in user created, newest first
+func (h *APIHandler) ListInterviews(w http.ResponseWriter, r *http.Request) {
+	username, ok := h.requireUserV1(w, r)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	interviews := h.interviewService.InterviewsBy(username)
+	summaries := make([]InterviewSummary, 0, len(interviews))
+	for _, interview := range interviews {
+		summaries = append(summaries, newInterviewSummary(interview, now))
+	}
+	writeJSON(w, http.StatusOK, summaries)
+}
+
+// GetInterview handles GET /api/v1/interviews/{interview}
+func (h *APIHandler) GetInterview(w http.ResponseWriter, r *http.Request) {
+	interview, ok := h.ownInterview(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, newInterviewSummary(interview, time.Now()))
+}
+
+// GetInterviewReport handles GET /api/v1/interviews/{interview}/report
+func (h *APIHandler) GetInterviewReport(w http.ResponseWriter, r *http.Request) {
+	interview, ok := h.ownInterview(w, r)
+	if !ok {
+		return
+	}
+	report, err := h.interviewService.Report(interview.ID, time.Now())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "interview report failed", "interview", interview.ID, "err", err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Failed to read the interview recording", nil)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, report)
+}
+
+// ExportInterview handles GET /api/v1/interviews/{interview}/export,
+// downloading the interview and its full recording
+func (h *APIHandler) ExportInterview(w http.ResponseWriter, r *http.Request) {
+	interview, ok := h.ownInterview(w, r)
+	if !ok {
+		return
+	}
+	export, err := h.interviewService.Export(interview.ID, time.Now())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "interview export failed", "interview", interview.ID, "err", err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Failed to read the interview recording", nil)
+		return
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="interview-%s.json"`, interview.ID))
+	writeJSON(w, http.StatusOK, export)
+}
+
+// EndInterview handles POST /api/v1/interviews/{interview}/end, stopping the
+// candidate's clock early or revoking an unused invite
+func (h *APIHandler) EndInterview(w http.ResponseWriter, r *http.Request) {
+	interview, ok := h.ownInterview(w, r)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	interview, err := h.interviewService.End(interview.ID, now)
+	switch {
+	case errors.Is(err, services.ErrInterviewNotRunning):
+		writeError(w, r, http.StatusConflict, CodeConflict, "Interview has already ended", nil)
+		return
+	case err != nil:
+		slog.ErrorContext(r.Context(), "interview end failed", "err", err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Failed to end interview", nil)
+		return
+	}
+	writeJSON(w, http.StatusOK, newInterviewSummary(interview, now))
+}
+
+// JoinInterview handles POST /api/v1/interviews/join, redeeming a one-time
+// invite and admitting the browser to the interview's workspace
+func (h *APIHandler) JoinInterview(w http.ResponseWriter, r *http.Request) {
+	var request JoinInterviewRequest
+	if !decodeJSON(w, r, &request) {
+		return
+	}
+
+	now := time.Now()
+	interview, err := h.interviewService.Join(request.Token, now)
+	switch {
+	case errors.Is(err, services.ErrInterviewNotFound):
+		writeError(w, r, http.StatusNotFound, CodeNotFound, "Invite not found", nil)
+		return
+	case errors.Is(err, services.ErrInviteUsed):
+		writeError(w, r, http.StatusConflict, CodeConflict, "This invite link has already been used", nil)
+		return
+	case err != nil:
+		slog.ErrorContext(r.Context(), "interview join failed", "err", err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Failed to join interview", nil)
+		return
+	}
+
+	if err := h.sessions.AdmitCandidate(w, interview.ID, *interview.Deadline()); err != nil {
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Failed to admit candidate", nil)
+		return
+	}
+	writeJSON(w, http.StatusOK, newInterviewSummary(interview, now))
+}
+
+// SaveEditorSnapshot handles PUT /api/v1/interviews/{interview}/editor,
+// recording the candidate's editor contents
+func (h *APIHandler) SaveEditorSnapshot(w http.ResponseWriter, r *http.Request) {
+	var request EditorSnapshotRequest
+	if !decodeJSON(w, r, &request) {
+		return
+	}
+
+	id := r.PathValue("interview")
+	now := time.Now()
+	if status, code, message, ok := h.checkInterviewActivity(r, id, request.ChallengeID, now); !ok {
+		writeError(w, r, status, code, message, map[string]string{"interview": id})
+		return
+	}
+	if err := h.interviewService.RecordEditor(id, request.ChallengeID, request.Code, now); err != nil {
+		slog.ErrorContext(r.Context(), "editor snapshot failed", "interview", id, "err", err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Failed to record the editor", nil)
+		return
+	}
+
+	interview, _ := h.interviewService.Interview(id)
+	writeJSON(w, http.StatusOK, newInterviewSummary(interview, now))
+}
+
+// ownInterview returns the interview named by the path if the signed-in user
+// created it, writing a 404 response for anyone else's
+func (h *APIHandler) ownInterview(w http.ResponseWriter, r *http.Request) (models.Interview, bool) {
+	username, ok := h.requireUserV1(w, r)
+	if !ok {
+		return models.Interview{}, false
+	}
+	id := r.PathValue("interview")
+	interview, ok := h.interviewService.Interview(id)
+	if !ok || !strings.EqualFold(interview.Interviewer, username) {
+		writeError(w, r, http.StatusNotFound, CodeNotFound, "Interview not found", map[string]string{"interview": id})
+		return models.Interview{}, false
+	}
+	return interview, true
+}
+
+// checkInterviewActivity validates candidate activity on a challenge before
+// it is recorded or run, returning the status, error code and message to
+// reject it with
+func (h *APIHandler) checkInterviewActivity(r *http.Request, id string, challengeID int, now time.Time) (int, string, string, bool) {
+	if h.sessions.Candidate(r) != id {
+		return http.StatusForbidden, CodeForbidden, "This browser has not joined the interview", false
+	}
+	_, err := h.interviewService.CheckActivity(id, challengeID, now)
+	switch {
+	case errors.Is(err, services.ErrInterviewNotFound):
+		return http.StatusNotFound, CodeNotFound, "Interview not found", false
+	case errors.Is(err, services.ErrInterviewNotRunning):
+		return http.StatusConflict, CodeConflict, "Interview is not in progress", false
+	case errors.Is(err, services.ErrNotInInterview):
+		return http.StatusBadRequest, CodeBadRequest, "Challenge is not part of the interview", false
+	}
+	return 0, "", "", true
+}
+
+// recordInterviewRun adds a run to its interview's recording
+func (h *APIHandler) recordInterviewRun(r *http.Request, id string, challengeID int, code string, result services.ExecutionResult) {
+	if err := h.interviewService.RecordRun(id, challengeID, code, result, time.Now()); err != nil {
+		slog.ErrorContext(r.Context(), "failed to record interview run", "interview", id, "err", err)
+	}
+}
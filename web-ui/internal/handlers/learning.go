@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"web-ui/internal/models"
+	"web-ui/internal/paths"
+	"web-ui/internal/services"
+)
+
+// NextChallengeResponse is the challenge recommended to a user; the
+// challenge fields are omitted once there is nothing left to recommend
+type NextChallengeResponse struct {
+	Username    string               `json:"username"`
+	Reason      string               `json:"reason" enum:"continue,path,prerequisite,unlocked,complete"`
+	ChallengeID int                  `json:"challengeId,omitempty"`
+	Title       string               `json:"title,omitempty"`
+	Difficulty  string               `json:"difficulty,omitempty"`
+	Path        *models.LearningPath `json:"path,omitempty" doc:"The learning path the recommendation follows"`
+}
+
+// ListLearningPaths handles GET /api/v1/paths
+func (h *APIHandler) ListLearningPaths(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.challengeService.LearningPaths())
+}
+
+// GetLearningPath handles GET /api/v1/paths/{slug}
+func (h *APIHandler) GetLearningPath(w http.ResponseWriter, r *http.Request) {
+	slug := r.PathValue("slug")
+	path, ok := h.challengeService.LearningPath(slug)
+	if !ok {
+		writeError(w, r, http.StatusNotFound, CodeNotFound, "Learning path not found", map[string]string{"slug": slug})
+		return
+	}
+	writeJSON(w, http.StatusOK, path)
+}
+
+// GetNextChallenge handles GET /api/v1/users/{username}/next
+func (h *APIHandler) GetNextChallenge(w http.ResponseWriter, r *http.Request) {
+	username := r.PathValue("username")
+	if err := paths.ValidateUsername(username); err != nil {
+		writeError(w, r, http.StatusBadRequest, CodeBadRequest, "Invalid username", map[string]string{"username": username})
+		return
+	}
+
+	attempts := h.userService.GetUserAttempts(username, h.challengeService.GetChallenges())
+	slug := r.URL.Query().Get("path")
+	recommendation, err := h.challengeService.Recommend(attempts, slug)
+	if errors.Is(err, services.ErrPathNotFound) {
+		writeError(w, r, http.StatusNotFound, CodeNotFound, "Learning path not found", map[string]string{"slug": slug})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, nextChallengeResponse(username, recommendation))
+}
+
+func nextChallengeResponse(username string, recommendation services.Recommendation) NextChallengeResponse {
+	response := NextChallengeResponse{
+		Username: username,
+		Reason:   recommendation.Reason,
+		Path:     recommendation.Path,
+	}
+	if challenge := recommendation.Challenge; challenge != nil {
+		response.ChallengeID = challenge.ID
+		response.Title = challenge.Title
+		response.Difficulty = challenge.Difficulty
+	}
+	return response
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"web-ui/internal/collab"
+)
+
+// PairRoomRequest is the body of POST /api/v1/pair-rooms
+type PairRoomRequest struct {
+	ChallengeID int    `json:"challengeId"`
+	Code        string `json:"code,omitempty" doc:"Starting document; the challenge template when empty"`
+}
+
+// PairRoom is a live pair-programming room
+type PairRoom struct {
+	collab.RoomInfo
+	Path       string `json:"path" doc:"Challenge page that joins the room; share it with the other participants"`
+	SocketPath string `json:"socketPath" doc:"WebSocket endpoint that syncs the room's edits, cursors and runs"`
+}
+
+func newPairRoom(info collab.RoomInfo) PairRoom {
+	return PairRoom{
+		RoomInfo:   info,
+		Path:       pairPath(info.ChallengeID, info.ID),
+		SocketPath: "/ws/pair/" + url.PathEscape(info.ID),
+	}
+}
+
+func pairPath(challengeID int, roomID string) string {
+	return "/challenge/" + strconv.Itoa(challengeID) + "?pair=" + url.QueryEscape(roomID)
+}
+
+// CreatePairRoom handles POST /api/v1/pair-rooms
+func (h *APIHandler) CreatePairRoom(w http.ResponseWriter, r *http.Request) {
+	username, ok := h.requireUserV1(w, r)
+	if !ok {
+		return
+	}
+	var request PairRoomRequest
+	if !decodeJSON(w, r, &request) {
+		return
+	}
+	challenge, exists := h.challengeService.GetChallenge(request.ChallengeID)
+	if !exists {
+		writeError(w, r, http.StatusNotFound, CodeNotFound, "Challenge not found", nil)
+		return
+	}
+	code := request.Code
+	if code == "" {
+		code = challenge.Template
+	}
+
+	info, err := h.pairHub.CreateRoom(challenge.ID, username, code)
+	switch {
+	case errors.Is(err, collab.ErrDocumentTooLarge):
+		writeError(w, r, http.StatusBadRequest, CodeBadRequest, err.Error(), nil)
+		return
+	case errors.Is(err, collab.ErrTooManyRooms):
+		writeError(w, r, http.StatusServiceUnavailable, CodeUnavailable, "Too many pair rooms are open; try again later", nil)
+		return
+	case err != nil:
+		slog.ErrorContext(r.Context(), "pair room create failed", "err", err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Failed to create pair room", nil)
+		return
+	}
+	writeJSON(w, http.StatusCreated, newPairRoom(info))
+}
+
+// GetPairRoom handles GET /api/v1/pair-rooms/{room}. Room IDs are
+// unguessable, so knowing one is enough to see and join the room.
+func (h *APIHandler) GetPairRoom(w http.ResponseWriter, r *http.Request) {
+	info, err := h.pairHub.Room(r.PathValue("room"))
+	if err != nil {
+		writeError(w, r, http.StatusNotFound, CodeNotFound, "Pair room not found or expired", nil)
+		return
+	}
+	writeJSON(w, http.StatusOK, newPairRoom(info))
+}
+
+// PairSocket handles GET /ws/pair/{room}, upgrading to the WebSocket that
+// syncs the room. Signed-in participants appear under their username and
+// everyone else as a guest.
+func (h *APIHandler) PairSocket(w http.ResponseWriter, r *http.Request) {
+	name := h.sessions.Username(r)
+	if name == "" {
+		name = "Guest"
+	}
+	err := h.pairHub.ServeRoom(w, r, r.PathValue("room"), name)
+	switch {
+	case errors.Is(err, collab.ErrRoomNotFound):
+		writeError(w, r, http.StatusNotFound, CodeNotFound, "Pair room not found or expired", nil)
+	case errors.Is(err, collab.ErrRoomFull):
+		writeError(w, r, http.StatusConflict, CodeConflict, err.Error(), nil)
+	case err != nil:
+		slog.ErrorContext(r.Context(), "pair socket failed", "err", err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Failed to join pair room", nil)
+	}
+}
@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Error codes used in the /api/v1 error envelope
+const (
+	CodeBadRequest           = "bad_request"
+	CodeInvalidID            = "invalid_id"
+	CodeUnauthorized         = "unauthorized"
+	CodeForbidden            = "forbidden"
+	CodeCSRF                 = "csrf_failed"
+	CodeNotFound             = "not_found"
+	CodeConflict             = "conflict"
+	CodeMethodNotAllowed     = "method_not_allowed"
+	CodeNotAcceptable        = "not_acceptable"
+	CodeUnsupportedMediaType = "unsupported_media_type"
+	CodeRequestTooLarge      = "request_too_large"
+	CodeFeatureDisabled      = "feature_disabled"
+	CodeUnavailable          = "unavailable"
+	CodeInternal             = "internal_error"
+)
+
+// maxRequestBytes bounds JSON request bodies on /api/v1
+const maxRequestBytes = 2 << 20
+
+// APIError is the body of every /api/v1 error response
+type APIError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Details any    `json:"details,omitempty"`
+}
+
+// ErrorEnvelope wraps an APIError as {"error": {...}}
+type ErrorEnvelope struct {
+	Error APIError `json:"error"`
+}
+
+// writeJSON writes v as a JSON response with the given status
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes the error envelope, or plain text when the client
+// prefers text/plain over JSON
+func writeError(w http.ResponseWriter, r *http.Request, status int, code, message string, details any) {
+	if negotiate(r.Header.Get("Accept"), "application/json", "text/plain") == "text/plain" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.WriteHeader(status)
+		fmt.Fprintf(w, "%s: %s\n", code, message)
+		return
+	}
+	writeJSON(w, status, ErrorEnvelope{Error: APIError{Code: code, Message: message, Details: details}})
+}
+
+// CSRFFailure rejects a request without a valid CSRF token, using the error
+// envelope on /api/v1 and plain text elsewhere
+func CSRFFailure(w http.ResponseWriter, r *http.Request) {
+	if strings.HasPrefix(r.URL.Path, "/api/v1/") {
+		writeError(w, r, http.StatusForbidden, CodeCSRF, "Invalid or missing CSRF token", nil)
+		return
+	}
+	http.Error(w, "Invalid or missing CSRF token", http.StatusForbidden)
+}
+
+// decodeJSON reads a JSON request body into dst, writing a 400, 413 or 415
+// error and returning false when it cannot
+func decodeJSON(w http.ResponseWriter, r *http.Request, dst any) bool {
+	if ct := r.Header.Get("Content-Type"); ct != "" {
+		mediaType, _, err := mime.ParseMediaType(ct)
+		if err != nil || mediaType != "application/json" {
+			writeError(w, r, http.StatusUnsupportedMediaType, CodeUnsupportedMediaType,
+				"Request body must be application/json", map[string]string{"contentType": ct})
+			return false
+		}
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBytes)
+	if err := json.NewDecoder(r.Body).Decode(dst); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, r, http.StatusRequestEntityTooLarge, CodeRequestTooLarge,
+				"Request body is too large", map[string]int64{"limitBytes": tooLarge.Limit})
+			return false
+		}
+		writeError(w, r, http.StatusBadRequest, CodeBadRequest, "Invalid JSON request body", map[string]string{"reason": err.Error()})
+		return false
+	}
+	return true
+}
+
+// pathID parses the {name} path value as a positive integer ID
+func pathID(w http.ResponseWriter, r *http.Request, name string) (int, bool) {
+	raw := r.PathValue(name)
+	id, err := strconv.Atoi(raw)
+	if err != nil || id < 1 {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidID, "Invalid "+name, map[string]string{name: raw})
+		return 0, false
+	}
+	return id, true
+}
+
+// negotiate returns the offer the Accept header ranks highest, "" if none is
+// acceptable. Ties go to the earlier offer; an empty header accepts the first.
+func negotiate(accept string, offers ...string) string {
+	if strings.TrimSpace(accept) == "" {
+		return offers[0]
+	}
+
+	type mediaRange struct {
+		typ, subtype string
+		q            float64
+	}
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+		typ, subtype, _ := strings.Cut(mediaType, "/")
+		ranges = append(ranges, mediaRange{typ, subtype, q})
+	}
+	// Most specific ranges first so "text/plain;q=0, */*" excludes text/plain
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return specificity(ranges[i].typ, ranges[i].subtype) > specificity(ranges[j].typ, ranges[j].subtype)
+	})
+
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		typ, subtype, _ := strings.Cut(offer, "/")
+		for _, mr := range ranges {
+			if (mr.typ == "*" || mr.typ == typ) && (mr.subtype == "*" || mr.subtype == subtype) {
+				if mr.q > bestQ {
+					best, bestQ = offer, mr.q
+				}
+				break
+			}
+		}
+	}
+	return best
+}
+
+func specificity(typ, subtype string) int {
+	switch {
+	case typ == "*":
+		return 0
+	case subtype == "*":
+		return 1
+	}
+	return 2
+}
+
+// NewV1Router wraps the /api/v1 mux so every response is JSON: clients that
+// cannot accept JSON get 406, and unmatched paths or methods get enveloped
+// 404 and 405 errors instead of the mux's plain-text ones.
+func NewV1Router(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if negotiate(r.Header.Get("Accept"), "application/json") == "" {
+			writeJSON(w, http.StatusNotAcceptable, ErrorEnvelope{Error: APIError{
+				Code:    CodeNotAcceptable,
+				Message: "This API only produces application/json",
+				Details: map[string]string{"accept": r.Header.Get("Accept")},
+			}})
+			return
+		}
+
+		if _, pattern := mux.Handler(r); pattern == "" {
+			if allowed := allowedMethods(mux, r); len(allowed) > 0 {
+				w.Header().Set("Allow", strings.Join(allowed, ", "))
+				writeError(w, r, http.StatusMethodNotAllowed, CodeMethodNotAllowed,
+					r.Method+" is not supported for this resource", map[string][]string{"allowed": allowed})
+				return
+			}
+			writeError(w, r, http.StatusNotFound, CodeNotFound, "No such API endpoint", map[string]string{"path": r.URL.Path})
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// allowedMethods lists the methods the mux would accept for r's path
+func allowedMethods(mux *http.ServeMux, r *http.Request) []string {
+	var allowed []string
+	for _, method := range []string{"GET", "POST", "PUT", "PATCH", "DELETE"} {
+		probe := r.Clone(r.Context())
+		probe.Method = method
+		if _, pattern := mux.Handler(probe); pattern != "" {
+			allowed = append(allowed, method)
+		}
+	}
+	return allowed
+}
+
+// Deprecated marks a legacy route as an alias of its /api/v1 successor
+func Deprecated(successor string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", successor))
+		next(w, r)
+	}
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		accept string
+		offers []string
+		want   string
+	}{
+		{"", []string{"application/json", "text/plain"}, "application/json"},
+		{"*/*", []string{"application/json", "text/plain"}, "application/json"},
+		{"text/plain", []string{"application/json", "text/plain"}, "text/plain"},
+		{"application/json;q=0.5, text/plain", []string{"application/json", "text/plain"}, "text/plain"},
+		{"text/*;q=0.9, application/json", []string{"application/json", "text/plain"}, "application/json"},
+		{"application/*", []string{"application/json"}, "application/json"},
+		{"application/json;q=0, */*", []string{"application/json"}, ""},
+		{"text/html", []string{"application/json"}, ""},
+		{"garbage;;, application/json", []string{"application/json"}, "application/json"},
+	}
+	for _, tt := range tests {
+		if got := negotiate(tt.accept, tt.offers...); got != tt.want {
+			t.Errorf("negotiate(%q, %v) = %q, want %q", tt.accept, tt.offers, got, tt.want)
+		}
+	}
+}
+
+func decodeEnvelope(t *testing.T, rec *httptest.ResponseRecorder) APIError {
+	t.Helper()
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("Content-Type = %q, want application/json", ct)
+	}
+	var envelope ErrorEnvelope
+	if err := json.Unmarshal(rec.Body.Bytes(), &envelope); err != nil {
+		t.Fatalf("body %q is not an error envelope: %v", rec.Body.String(), err)
+	}
+	return envelope.Error
+}
+
+func TestV1Router(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/things/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := pathID(w, r, "id"); !ok {
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"ok": "yes"})
+	})
+	mux.HandleFunc("POST /api/v1/things", func(w http.ResponseWriter, r *http.Request) {
+		var body struct{ Name string }
+		if !decodeJSON(w, r, &body) {
+			return
+		}
+		writeJSON(w, http.StatusCreated, body)
+	})
+	router := NewV1Router(mux)
+
+	serve := func(method, path, accept, contentType, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := serve("GET", "/api/v1/things/3", "", "", ""); rec.Code != http.StatusOK {
+		t.Errorf("GET thing = %d %s", rec.Code, rec.Body)
+	}
+
+	errorCases := []struct {
+		name                        string
+		method, path, accept, ctype string
+		body                        string
+		status                      int
+		code                        string
+	}{
+		{"unknown path", "GET", "/api/v1/nothing", "", "", "", http.StatusNotFound, CodeNotFound},
+		{"wrong method", "DELETE", "/api/v1/things/3", "", "", "", http.StatusMethodNotAllowed, CodeMethodNotAllowed},
+		{"bad id", "GET", "/api/v1/things/abc", "", "", "", http.StatusBadRequest, CodeInvalidID},
+		{"not acceptable", "GET", "/api/v1/things/3", "text/html", "", "", http.StatusNotAcceptable, CodeNotAcceptable},
+		{"wrong content type", "POST", "/api/v1/things", "", "text/plain", `{"name":"x"}`, http.StatusUnsupportedMediaType, CodeUnsupportedMediaType},
+		{"malformed body", "POST", "/api/v1/things", "", "application/json", `{"name":`, http.StatusBadRequest, CodeBadRequest},
+		{"oversized body", "POST", "/api/v1/things", "", "application/json", `{"name":"` + strings.Repeat("x", maxRequestBytes) + `"}`, http.StatusRequestEntityTooLarge, CodeRequestTooLarge},
+	}
+	for _, tt := range errorCases {
+		rec := serve(tt.method, tt.path, tt.accept, tt.ctype, tt.body)
+		if rec.Code != tt.status {
+			t.Errorf("%s: status = %d, want %d", tt.name, rec.Code, tt.status)
+			continue
+		}
+		if got := decodeEnvelope(t, rec); got.Code != tt.code || got.Message == "" {
+			t.Errorf("%s: error = %+v, want code %q", tt.name, got, tt.code)
+		}
+	}
+
+	if rec := serve("DELETE", "/api/v1/things/3", "", "", ""); rec.Header().Get("Allow") != "GET" {
+		t.Errorf("Allow = %q, want GET", rec.Header().Get("Allow"))
+	}
+
+	// Errors are plain text for clients that prefer it
+	rec := serve("GET", "/api/v1/nothing", "text/plain, application/json;q=0.5", "", "")
+	if !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain") || !strings.HasPrefix(rec.Body.String(), CodeNotFound+": ") {
+		t.Errorf("text/plain error = %q %q", rec.Header().Get("Content-Type"), rec.Body)
+	}
+}
+
+func TestCSRFFailure(t *testing.T) {
+	rec := httptest.NewRecorder()
+	CSRFFailure(rec, httptest.NewRequest("POST", "/api/v1/runs", nil))
+	if got := decodeEnvelope(t, rec); rec.Code != http.StatusForbidden || got.Code != CodeCSRF {
+		t.Errorf("v1 CSRF failure = %d %+v", rec.Code, got)
+	}
+
+	rec = httptest.NewRecorder()
+	CSRFFailure(rec, httptest.NewRequest("POST", "/api/run", nil))
+	if rec.Code != http.StatusForbidden || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain") {
+		t.Errorf("legacy CSRF failure = %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+}
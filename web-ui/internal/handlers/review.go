@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"web-ui/internal/models"
+	"web-ui/internal/paths"
+	"web-ui/internal/services"
+)
+
+// ReviewRequest is the body of POST /api/v1/reviews
+type ReviewRequest struct {
+	Author      string `json:"author" doc:"Teammate whose saved solution is reviewed"`
+	ChallengeID int    `json:"challengeId"`
+	Version     int    `json:"version,omitempty" doc:"Draft version to review, the latest when omitted"`
+	Summary     string `json:"summary,omitempty"`
+}
+
+// VerdictRequest is the body of PUT /api/v1/reviews/{review}/verdict
+type VerdictRequest struct {
+	Verdict string `json:"verdict" enum:"pending,approved,changes_requested,commented"`
+	Summary string `json:"summary,omitempty" doc:"Replaces the review's summary when set"`
+}
+
+// ThreadRequest is the body of POST /api/v1/reviews/{review}/threads
+type ThreadRequest struct {
+	File    string `json:"file,omitempty" doc:"Defaults to solution-template.go, the only file that can be commented on"`
+	Line    int    `json:"line" doc:"Numbered from 1"`
+	Version int    `json:"version,omitempty" doc:"Draft version the line is numbered in, the latest when omitted"`
+	Body    string `json:"body"`
+}
+
+// CommentRequest is the body of POST /api/v1/reviews/{review}/threads/{thread}/comments
+type CommentRequest struct {
+	Body string `json:"body"`
+}
+
+// MarkReadRequest is the body of POST /api/v1/notifications/read
+type MarkReadRequest struct {
+	UpTo int `json:"upTo,omitempty" doc:"Mark notifications up to and including this ID; all of them when omitted"`
+}
+
+// MarkReadResponse reports the notifications left unread
+type MarkReadResponse struct {
+	Unread int `json:"unread"`
+}
+
+// CreateReview handles POST /api/v1/reviews
+func (h *APIHandler) CreateReview(w http.ResponseWriter, r *http.Request) {
+	username, ok := h.requireUserV1(w, r)
+	if !ok {
+		return
+	}
+	var request ReviewRequest
+	if !decodeJSON(w, r, &request) {
+		return
+	}
+	if err := paths.ValidateUsername(request.Author); err != nil {
+		writeError(w, r, http.StatusBadRequest, CodeBadRequest, "Invalid author", map[string]string{"author": request.Author})
+		return
+	}
+	if _, ok := h.challengeByID(w, r, request.ChallengeID); !ok {
+		return
+	}
+	review, err := h.reviewService.Create(username, request.Author, request.ChallengeID, request.Version, request.Summary, time.Now())
+	if err != nil {
+		h.reviewFailed(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, review)
+}
+
+// ListReviews handles GET /api/v1/reviews, returning the reviews the
+// signed-in user wrote or received
+func (h *APIHandler) ListReviews(w http.ResponseWriter, r *http.Request) {
+	username, ok := h.requireUserV1(w, r)
+	if !ok {
+		return
+	}
+	challengeID := 0
+	if v := r.URL.Query().Get("challenge"); v != "" {
+		var err error
+		if challengeID, err = strconv.Atoi(v); err != nil || challengeID < 1 {
+			writeError(w, r, http.StatusBadRequest, CodeBadRequest, "Invalid challenge", map[string]string{"challenge": v})
+			return
+		}
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, h.reviewService.Reviews(username, challengeID))
+}
+
+// GetReview handles GET /api/v1/reviews/{review}
+func (h *APIHandler) GetReview(w http.ResponseWriter, r *http.Request) {
+	username, ok := h.requireUserV1(w, r)
+	if !ok {
+		return
+	}
+	review, err := h.reviewService.Review(username, r.PathValue("review"))
+	if err != nil {
+		h.reviewFailed(w, r, err)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, review)
+}
+
+// SetReviewVerdict handles PUT /api/v1/reviews/{review}/verdict
+func (h *APIHandler) SetReviewVerdict(w http.ResponseWriter, r *http.Request) {
+	username, ok := h.requireUserV1(w, r)
+	if !ok {
+		return
+	}
+	var request VerdictRequest
+	if !decodeJSON(w, r, &request) {
+		return
+	}
+	review, err := h.reviewService.SetVerdict(username, r.PathValue("review"), request.Verdict, request.Summary, time.Now())
+	if err != nil {
+		h.reviewFailed(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, review)
+}
+
+// CreateReviewThread handles POST /api/v1/reviews/{review}/threads
+func (h *APIHandler) CreateReviewThread(w http.ResponseWriter, r *http.Request) {
+	username, ok := h.requireUserV1(w, r)
+	if !ok {
+		return
+	}
+	var request ThreadRequest
+	if !decodeJSON(w, r, &request) {
+		return
+	}
+	anchor := models.ReviewAnchor{File: request.File, Line: request.Line, Version: request.Version}
+	thread, err := h.reviewService.AddThread(username, r.PathValue("review"), anchor, request.Body, time.Now())
+	if err != nil {
+		h.reviewFailed(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, thread)
+}
+
+// ReplyToReviewThread handles POST /api/v1/reviews/{review}/threads/{thread}/comments
+func (h *APIHandler) ReplyToReviewThread(w http.ResponseWriter, r *http.Request) {
+	username, ok := h.requireUserV1(w, r)
+	if !ok {
+		return
+	}
+	threadID, ok := pathID(w, r, "thread")
+	if !ok {
+		return
+	}
+	var request CommentRequest
+	if !decodeJSON(w, r, &request) {
+		return
+	}
+	thread, err := h.reviewService.Reply(username, r.PathValue("review"), threadID, request.Body, time.Now())
+	if err != nil {
+		h.reviewFailed(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, thread)
+}
+
+// ResolveReviewThread handles POST /api/v1/reviews/{review}/threads/{thread}/resolve
+func (h *APIHandler) ResolveReviewThread(w http.ResponseWriter, r *http.Request) {
+	h.setThreadResolved(w, r, true)
+}
+
+// UnresolveReviewThread handles POST /api/v1/reviews/{review}/threads/{thread}/unresolve
+func (h *APIHandler) UnresolveReviewThread(w http.ResponseWriter, r *http.Request) {
+	h.setThreadResolved(w, r, false)
+}
+
+func (h *APIHandler) setThreadResolved(w http.ResponseWriter, r *http.Request, resolved bool) {
+	username, ok := h.requireUserV1(w, r)
+	if !ok {
+		return
+	}
+	threadID, ok := pathID(w, r, "thread")
+	if !ok {
+		return
+	}
+	thread, err := h.reviewService.SetResolved(username, r.PathValue("review"), threadID, resolved, time.Now())
+	if err != nil {
+		h.reviewFailed(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, thread)
+}
+
+// ListNotifications handles GET /api/v1/notifications
+func (h *APIHandler) ListNotifications(w http.ResponseWriter, r *http.Request) {
+	username, ok := h.requireUserV1(w, r)
+	if !ok {
+		return
+	}
+	unreadOnly := false
+	if v := r.URL.Query().Get("unread"); v != "" {
+		var err error
+		if unreadOnly, err = strconv.ParseBool(v); err != nil {
+			writeError(w, r, http.StatusBadRequest, CodeBadRequest, "Invalid unread, expected a boolean", map[string]string{"unread": v})
+			return
+		}
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, h.reviewService.Notifications(username, unreadOnly))
+}
+
+// MarkNotificationsRead handles POST /api/v1/notifications/read
+func (h *APIHandler) MarkNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	username, ok := h.requireUserV1(w, r)
+	if !ok {
+		return
+	}
+	var request MarkReadRequest
+	if !decodeJSON(w, r, &request) {
+		return
+	}
+	unread, err := h.reviewService.MarkRead(username, request.UpTo)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "marking notifications read failed", "err", err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Failed to update notifications", nil)
+		return
+	}
+	writeJSON(w, http.StatusOK, MarkReadResponse{Unread: unread})
+}
+
+// reviewFailed writes the error for a failed review operation
+func (h *APIHandler) reviewFailed(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, services.ErrReviewNotFound):
+		writeError(w, r, http.StatusNotFound, CodeNotFound, "Review not found", nil)
+	case errors.Is(err, services.ErrDraftNotFound):
+		writeError(w, r, http.StatusNotFound, CodeNotFound, "Saved solution not found", nil)
+	case errors.Is(err, services.ErrNotTeammate), errors.Is(err, services.ErrNotReviewer):
+		writeError(w, r, http.StatusForbidden, CodeForbidden, err.Error(), nil)
+	case errors.Is(err, services.ErrInvalidReview):
+		writeError(w, r, http.StatusBadRequest, CodeBadRequest, err.Error(), nil)
+	default:
+		slog.ErrorContext(r.Context(), "review operation failed", "err", err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Failed to update the review", nil)
+	}
+}
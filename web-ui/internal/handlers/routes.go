@@ -0,0 +1,516 @@
+package handlers
+
+import (
+	"net/http"
+
+	"web-ui/internal/models"
+	"web-ui/internal/services"
+	"web-ui/internal/webhook"
+)
+
+// Access is the authentication a route requires
+type Access int
+
+const (
+	// Public routes need no session
+	Public Access = iota
+	// SignedIn routes need a session cookie
+	SignedIn
+	// Admin routes need the session of a configured administrator
+	Admin
+)
+
+// Param documents a query parameter
+type Param struct {
+	Name        string
+	Type        string // JSON schema type, "string" when empty
+	Description string
+	Required    bool
+}
+
+// Route is one JSON API operation. The server registers handlers from the
+// route table and /api/openapi.json is generated from it, so the documented
+// paths cannot drift from the served ones.
+type Route struct {
+	Name    string // operationId
+	Method  string
+	Path    string // OpenAPI path template, e.g. /api/v1/challenges/{id}
+	Handler http.HandlerFunc
+	Summary string
+	Tag     string
+	Access  Access
+	Query   []Param
+
+	Request  any   // zero value of the JSON request body type, nil for none
+	Response any   // zero value of the JSON success body type
+	Status   int   // success status, 200 when zero
+	Errors   []int // error statuses besides those implied by the route
+
+	// Legacy aliases set the mux pattern they are served on and their /api/v1
+	// successor; their errors are plain text unless they share a v1 handler
+	Pattern    string
+	Successor  string
+	TextErrors bool
+}
+
+// APIRoutes returns the /api/v1 routes followed by their deprecated aliases
+func APIRoutes(api *APIHandler, admin *AdminHandler) []Route {
+	auditQuery := []Param{
+		{Name: "actor", Description: "Only events by this user"},
+		{Name: "action", Description: "Only events with this action (run, submit, save, team or contest)"},
+		{Name: "outcome", Description: "Only events with this outcome"},
+		{Name: "challenge", Type: "integer", Description: "Only events for this challenge ID"},
+		{Name: "since", Description: "Only events at or after this RFC 3339 time"},
+		{Name: "until", Description: "Only events before this RFC 3339 time"},
+		{Name: "before", Type: "integer", Description: "Only events with a lower seq; pass the last event's seq for the next page"},
+		{Name: "limit", Type: "integer", Description: "Maximum events to return (default 100, at most 1000)"},
+	}
+	thresholdParam := Param{Name: "threshold", Type: "number", Description: "Flag pairs scoring at least this, from 0 to 1 (default from the similarity-threshold setting)"}
+	activityDaysParam := Param{Name: "days", Type: "integer", Description: "Days of activity to return, ending today (default 365, at most 731)"}
+
+	return []Route{
+		{
+			Name: "listChallenges", Method: "GET", Path: "/api/v1/challenges", Handler: api.ListChallenges,
+			Summary: "Search and filter challenges", Tag: "challenges", Query: challengeListParams,
+			Response: []ChallengeListItem{}, Errors: []int{400, 401},
+		},
+		{
+			Name: "listTags", Method: "GET", Path: "/api/v1/tags", Handler: api.ListTags,
+			Summary: "List challenge tags with their challenge counts", Tag: "challenges",
+			Response: []services.FacetCount{},
+		},
+		{
+			Name: "listTopics", Method: "GET", Path: "/api/v1/topics", Handler: api.ListTopics,
+			Summary: "List challenge topics with their challenge counts", Tag: "challenges",
+			Response: []services.FacetCount{},
+		},
+		{
+			Name: "listLearningPaths", Method: "GET", Path: "/api/v1/paths", Handler: api.ListLearningPaths,
+			Summary: "List learning paths ordered by name", Tag: "challenges",
+			Response: []models.LearningPath{},
+		},
+		{
+			Name: "getLearningPath", Method: "GET", Path: "/api/v1/paths/{slug}", Handler: api.GetLearningPath,
+			Summary: "Get a learning path", Tag: "challenges",
+			Response: models.LearningPath{}, Errors: []int{404},
+		},
+		{
+			Name: "getUserProfile", Method: "GET", Path: "/api/v1/users/{username}", Handler: api.GetUserProfile,
+			Summary: "Get a user's solved challenges, scores, rank, streaks and recent activity", Tag: "users",
+			Response: services.UserProfile{}, Errors: []int{400},
+		},
+		{
+			Name: "getUserActivity", Method: "GET", Path: "/api/v1/users/{username}/activity", Handler: api.GetUserActivity,
+			Summary: "Get a user's streaks and activity per day", Tag: "users",
+			Query:    []Param{activityDaysParam},
+			Response: services.ActivitySummary{}, Errors: []int{400},
+		},
+		{
+			Name: "getNextChallenge", Method: "GET", Path: "/api/v1/users/{username}/next", Handler: api.GetNextChallenge,
+			Summary: "Recommend the challenge a user should attempt next", Tag: "challenges",
+			Query:    []Param{{Name: "path", Description: "Follow this learning path slug instead of choosing one"}},
+			Response: NextChallengeResponse{}, Errors: []int{400, 404},
+		},
+		{
+			Name: "getChallenge", Method: "GET", Path: "/api/v1/challenges/{id}", Handler: api.GetChallenge,
+			Summary: "Get a challenge", Tag: "challenges",
+			Response: models.Challenge{}, Errors: []int{404},
+		},
+		{
+			Name: "getChallengeScoreboard", Method: "GET", Path: "/api/v1/challenges/{id}/scoreboard", Handler: api.GetChallengeScoreboard,
+			Summary: "Get a challenge's scoreboard", Tag: "challenges",
+			Query:    []Param{teamQueryParam},
+			Response: []models.ScoreboardEntry{}, Errors: []int{404},
+		},
+		{
+			Name: "saveSolution", Method: "PUT", Path: "/api/v1/challenges/{id}/solution", Handler: api.SaveSolution,
+			Summary: "Save the signed-in user's solution into the repository", Tag: "challenges", Access: SignedIn,
+			Request: SaveSolutionRequest{}, Response: services.SaveSubmissionResponse{}, Errors: []int{404, 500},
+		},
+		{
+			Name: "createRun", Method: "POST", Path: "/api/v1/runs", Handler: api.CreateRun,
+			Summary: "Run code against a challenge's tests; interview runs are recorded for the interviewer", Tag: "runs",
+			Request: RunRequest{}, Response: services.ExecutionResult{}, Errors: []int{404, 409, 503},
+		},
+		{
+			Name: "listSubmissions", Method: "GET", Path: "/api/v1/submissions", Handler: api.ListSubmissions,
+			Summary: "List the signed-in user's submissions, newest first", Tag: "submissions", Access: SignedIn,
+			Response: []models.Submission{},
+		},
+		{
+			Name: "createSubmission", Method: "POST", Path: "/api/v1/submissions", Handler: api.CreateSubmission,
+			Summary: "Submit a solution; passing submissions join the scoreboard and contest submissions are entered in the contest", Tag: "submissions", Access: SignedIn,
+			Request: SubmissionRequest{}, Response: models.Submission{}, Status: http.StatusCreated, Errors: []int{404, 409, 503},
+		},
+		{
+			Name: "refreshAttempts", Method: "POST", Path: "/api/v1/users/{username}/attempts/refresh", Handler: api.RefreshAttempts,
+			Summary: "Rescan a user's attempted challenges", Tag: "users",
+			Response: AttemptsResponse{}, Errors: []int{400},
+		},
+		{
+			Name: "getLeaderboard", Method: "GET", Path: "/api/v1/leaderboard", Handler: api.GetLeaderboard,
+			Summary: "Get the main leaderboard", Tag: "leaderboard",
+			Query:    []Param{teamQueryParam},
+			Response: []LeaderboardUser{}, Errors: []int{404},
+		},
+		{
+			Name: "listTeams", Method: "GET", Path: "/api/v1/teams", Handler: api.ListTeams,
+			Summary: "List teams with their members, combined solves and coverage, ranked by average score", Tag: "teams",
+			Response: []TeamSummary{},
+		},
+		{
+			Name: "getTeam", Method: "GET", Path: "/api/v1/teams/{slug}", Handler: api.GetTeam,
+			Summary: "Get a team's aggregates and its members' standings", Tag: "teams",
+			Response: TeamDetail{}, Errors: []int{404},
+		},
+		{
+			Name: "listContests", Method: "GET", Path: "/api/v1/contests", Handler: api.ListContests,
+			Summary: "List contests, most recent start first", Tag: "contests",
+			Response: []ContestSummary{},
+		},
+		{
+			Name: "getContest", Method: "GET", Path: "/api/v1/contests/{slug}", Handler: api.GetContest,
+			Summary: "Get a contest", Tag: "contests",
+			Response: ContestSummary{}, Errors: []int{404},
+		},
+		{
+			Name: "getContestScoreboard", Method: "GET", Path: "/api/v1/contests/{slug}/scoreboard", Handler: api.GetContestScoreboard,
+			Summary: "Get a contest's ICPC-style scoreboard; results of submissions made during the freeze stay hidden until the end", Tag: "contests",
+			Response: services.ContestScoreboard{}, Errors: []int{404},
+		},
+		{
+			Name: "createInterview", Method: "POST", Path: "/api/v1/interviews", Handler: api.CreateInterview,
+			Summary: "Schedule a mock interview and get its one-time invite link", Tag: "interviews", Access: SignedIn,
+			Request: InterviewRequest{}, Response: InterviewInvite{}, Status: http.StatusCreated, Errors: []int{500},
+		},
+		{
+			Name: "listInterviews", Method: "GET", Path: "/api/v1/interviews", Handler: api.ListInterviews,
+			Summary: "List the interviews the signed-in user created, newest first", Tag: "interviews", Access: SignedIn,
+			Response: []InterviewSummary{},
+		},
+		{
+			Name: "getInterview", Method: "GET", Path: "/api/v1/interviews/{interview}", Handler: api.GetInterview,
+			Summary: "Get one of the signed-in user's interviews", Tag: "interviews", Access: SignedIn,
+			Response: InterviewSummary{}, Errors: []int{404},
+		},
+		{
+			Name: "getInterviewReport", Method: "GET", Path: "/api/v1/interviews/{interview}/report", Handler: api.GetInterviewReport,
+			Summary: "Get an interview's per-challenge summary and timeline of editor states and runs", Tag: "interviews", Access: SignedIn,
+			Response: services.InterviewReport{}, Errors: []int{404, 500},
+		},
+		{
+			Name: "exportInterview", Method: "GET", Path: "/api/v1/interviews/{interview}/export", Handler: api.ExportInterview,
+			Summary: "Download an interview and its full recording as a JSON attachment", Tag: "interviews", Access: SignedIn,
+			Response: services.InterviewExport{}, Errors: []int{404, 500},
+		},
+		{
+			Name: "joinInterview", Method: "POST", Path: "/api/v1/interviews/join", Handler: api.JoinInterview,
+			Summary: "Redeem a one-time invite, starting the candidate's clock and admitting the browser to the interview", Tag: "interviews",
+			Request: JoinInterviewRequest{}, Response: InterviewSummary{}, Errors: []int{404, 409, 500},
+		},
+		{
+			Name: "saveEditorSnapshot", Method: "PUT", Path: "/api/v1/interviews/{interview}/editor", Handler: api.SaveEditorSnapshot,
+			Summary: "Record the candidate's editor contents for a challenge", Tag: "interviews",
+			Request: EditorSnapshotRequest{}, Response: InterviewSummary{}, Errors: []int{404, 409, 500},
+		},
+		{
+			Name: "endInterview", Method: "POST", Path: "/api/v1/interviews/{interview}/end", Handler: api.EndInterview,
+			Summary: "End an interview early or revoke its unused invite", Tag: "interviews", Access: SignedIn,
+			Response: InterviewSummary{}, Errors: []int{404, 409, 500},
+		},
+		{
+			Name: "createPairRoom", Method: "POST", Path: "/api/v1/pair-rooms", Handler: api.CreatePairRoom,
+			Summary: "Open an in-memory pair-programming room on a challenge; edits sync over the room's WebSocket", Tag: "pairing", Access: SignedIn,
+			Request: PairRoomRequest{}, Response: PairRoom{}, Status: http.StatusCreated, Errors: []int{404, 500, 503},
+		},
+		{
+			Name: "getPairRoom", Method: "GET", Path: "/api/v1/pair-rooms/{room}", Handler: api.GetPairRoom,
+			Summary: "Get a pair-programming room's participants and revision", Tag: "pairing",
+			Response: PairRoom{}, Errors: []int{404},
+		},
+		{
+			Name: "listDrafts", Method: "GET", Path: "/api/v1/drafts", Handler: api.ListDrafts,
+			Summary: "List the challenges the signed-in user has drafts for, most recently updated first", Tag: "drafts", Access: SignedIn,
+			Response: []services.DraftSummary{}, Errors: []int{500},
+		},
+		{
+			Name: "listDraftVersions", Method: "GET", Path: "/api/v1/drafts/{id}", Handler: api.ListDraftVersions,
+			Summary: "List the signed-in user's draft versions of a challenge, newest first", Tag: "drafts", Access: SignedIn,
+			Response: []DraftVersionSummary{}, Errors: []int{404, 500},
+		},
+		{
+			Name: "saveDraft", Method: "PUT", Path: "/api/v1/drafts/{id}", Handler: api.SaveDraft,
+			Summary: "Save the signed-in user's code for a challenge as a new draft version", Tag: "drafts", Access: SignedIn,
+			Request: DraftRequest{}, Response: models.DraftVersion{}, Errors: []int{404, 500},
+		},
+		{
+			Name: "getDraftVersion", Method: "GET", Path: "/api/v1/drafts/{id}/versions/{version}", Handler: api.GetDraftVersion,
+			Summary: "Get a draft version with its code", Tag: "drafts", Access: SignedIn,
+			Response: models.DraftVersion{}, Errors: []int{404, 500},
+		},
+		{
+			Name: "diffDraftVersions", Method: "GET", Path: "/api/v1/drafts/{id}/diff", Handler: api.DiffDraftVersions,
+			Summary: "Compare two draft versions as a unified diff", Tag: "drafts", Access: SignedIn,
+			Query: []Param{
+				{Name: "from", Type: "integer", Required: true, Description: "Version to diff from"},
+				{Name: "to", Type: "integer", Required: true, Description: "Version to diff to"},
+			},
+			Response: services.DraftDiff{}, Errors: []int{404, 500},
+		},
+		{
+			Name: "restoreDraftVersion", Method: "POST", Path: "/api/v1/drafts/{id}/versions/{version}/restore", Handler: api.RestoreDraftVersion,
+			Summary: "Make a copy of a draft version the latest version", Tag: "drafts", Access: SignedIn,
+			Response: models.DraftVersion{}, Status: http.StatusCreated, Errors: []int{404, 500},
+		},
+		{
+			Name: "listSolutions", Method: "GET", Path: "/api/v1/challenges/{id}/solutions", Handler: api.ListSolutions,
+			Summary: "List the solutions submitted for a challenge the signed-in user has solved", Tag: "solutions", Access: SignedIn,
+			Response: services.SolutionGallery{}, Errors: []int{403, 404, 500},
+		},
+		{
+			Name: "getSolution", Method: "GET", Path: "/api/v1/challenges/{id}/solutions/{username}", Handler: api.GetSolution,
+			Summary: "Get a user's solution to a challenge the signed-in user has solved", Tag: "solutions", Access: SignedIn,
+			Response: services.Solution{}, Errors: []int{400, 403, 404, 500},
+		},
+		{
+			Name: "compareSolutions", Method: "GET", Path: "/api/v1/challenges/{id}/solutions/{username}/compare/{other}", Handler: api.CompareSolutions,
+			Summary: "Compare two users' solutions declaration by declaration", Tag: "solutions", Access: SignedIn,
+			Response: services.SolutionComparison{}, Errors: []int{400, 403, 404, 500},
+		},
+		{
+			Name: "benchmarkSolution", Method: "POST", Path: "/api/v1/challenges/{id}/solutions/{username}/benchmarks", Handler: api.BenchmarkSolution,
+			Summary: "Run a challenge's benchmarks against a user's solution, reusing the last passed run of unchanged code", Tag: "solutions", Access: SignedIn,
+			Response: models.BenchmarkRun{}, Errors: []int{400, 403, 404, 409, 500, 503},
+		},
+		{
+			Name: "createReview", Method: "POST", Path: "/api/v1/reviews", Handler: api.CreateReview,
+			Summary: "Start reviewing a teammate's saved solution to a challenge", Tag: "reviews", Access: SignedIn,
+			Request: ReviewRequest{}, Response: services.ReviewDetail{}, Status: http.StatusCreated, Errors: []int{400, 403, 404, 500},
+		},
+		{
+			Name: "listReviews", Method: "GET", Path: "/api/v1/reviews", Handler: api.ListReviews,
+			Summary: "List the reviews the signed-in user wrote or received, most recently updated first", Tag: "reviews", Access: SignedIn,
+			Query:    []Param{{Name: "challenge", Type: "integer", Description: "Only reviews of this challenge ID"}},
+			Response: []services.ReviewSummary{}, Errors: []int{400},
+		},
+		{
+			Name: "getReview", Method: "GET", Path: "/api/v1/reviews/{review}", Handler: api.GetReview,
+			Summary: "Get a review with its threads placed on the author's latest version", Tag: "reviews", Access: SignedIn,
+			Response: services.ReviewDetail{}, Errors: []int{404},
+		},
+		{
+			Name: "setReviewVerdict", Method: "PUT", Path: "/api/v1/reviews/{review}/verdict", Handler: api.SetReviewVerdict,
+			Summary: "Set the verdict of a review the signed-in user wrote", Tag: "reviews", Access: SignedIn,
+			Request: VerdictRequest{}, Response: services.ReviewDetail{}, Errors: []int{400, 403, 404, 500},
+		},
+		{
+			Name: "createReviewThread", Method: "POST", Path: "/api/v1/reviews/{review}/threads", Handler: api.CreateReviewThread,
+			Summary: "Start a comment thread on a line of the reviewed solution", Tag: "reviews", Access: SignedIn,
+			Request: ThreadRequest{}, Response: services.ReviewThreadDetail{}, Status: http.StatusCreated, Errors: []int{400, 404, 500},
+		},
+		{
+			Name: "replyToReviewThread", Method: "POST", Path: "/api/v1/reviews/{review}/threads/{thread}/comments", Handler: api.ReplyToReviewThread,
+			Summary: "Reply to a review thread", Tag: "reviews", Access: SignedIn,
+			Request: CommentRequest{}, Response: services.ReviewThreadDetail{}, Status: http.StatusCreated, Errors: []int{400, 404, 500},
+		},
+		{
+			Name: "resolveReviewThread", Method: "POST", Path: "/api/v1/reviews/{review}/threads/{thread}/resolve", Handler: api.ResolveReviewThread,
+			Summary: "Mark a review thread resolved", Tag: "reviews", Access: SignedIn,
+			Response: services.ReviewThreadDetail{}, Errors: []int{400, 404, 500},
+		},
+		{
+			Name: "unresolveReviewThread", Method: "POST", Path: "/api/v1/reviews/{review}/threads/{thread}/unresolve", Handler: api.UnresolveReviewThread,
+			Summary: "Reopen a resolved review thread", Tag: "reviews", Access: SignedIn,
+			Response: services.ReviewThreadDetail{}, Errors: []int{400, 404, 500},
+		},
+		{
+			Name: "listNotifications", Method: "GET", Path: "/api/v1/notifications", Handler: api.ListNotifications,
+			Summary: "List the signed-in user's review notifications, newest first", Tag: "reviews", Access: SignedIn,
+			Query:    []Param{{Name: "unread", Type: "boolean", Description: "Only unread notifications"}},
+			Response: services.NotificationFeed{}, Errors: []int{400},
+		},
+		{
+			Name: "markNotificationsRead", Method: "POST", Path: "/api/v1/notifications/read", Handler: api.MarkNotificationsRead,
+			Summary: "Mark the signed-in user's notifications read", Tag: "reviews", Access: SignedIn,
+			Request: MarkReadRequest{}, Response: MarkReadResponse{}, Errors: []int{500},
+		},
+		{
+			Name: "getTeamActivity", Method: "GET", Path: "/api/v1/activity", Handler: api.GetTeamActivity,
+			Summary: "Get the team's streaks and activity per day", Tag: "leaderboard",
+			Query:    []Param{activityDaysParam},
+			Response: services.ActivitySummary{}, Errors: []int{400},
+		},
+		{
+			Name: "getLeaderboardRank", Method: "GET", Path: "/api/v1/leaderboard/{username}", Handler: api.GetLeaderboardRank,
+			Summary: "Get a user's main leaderboard rank", Tag: "leaderboard",
+			Response: RankResponse{},
+		},
+		{
+			Name: "getGitIdentity", Method: "GET", Path: "/api/v1/git-username", Handler: api.GetGitIdentity,
+			Summary: "Get the git identity configured for the repository", Tag: "repository",
+			Response: GitIdentityResponse{}, Errors: []int{404},
+		},
+		{
+			Name: "listAuditEvents", Method: "GET", Path: "/api/v1/admin/audit", Handler: admin.AuditLog,
+			Summary: "Query the audit log, newest first", Tag: "admin", Access: Admin, Query: auditQuery,
+			Response: AuditLogResponse{}, Errors: []int{400, 500},
+		},
+		{
+			Name: "listFlaggedSimilarity", Method: "GET", Path: "/api/v1/admin/similarity", Handler: admin.ListFlaggedSimilarity,
+			Summary: "List the pairs of solutions flagged as similar across every challenge", Tag: "admin", Access: Admin,
+			Query:    []Param{thresholdParam},
+			Response: FlaggedSimilarityResponse{}, Errors: []int{400, 500},
+		},
+		{
+			Name: "getSimilarityReport", Method: "GET", Path: "/api/v1/admin/similarity/{id}", Handler: admin.GetSimilarityReport,
+			Summary: "Score how similar every pair of a challenge's solutions is, most similar first", Tag: "admin", Access: Admin,
+			Query: []Param{
+				thresholdParam,
+				{Name: "flagged", Type: "boolean", Description: "Only return flagged pairs"},
+			},
+			Response: services.SimilarityReport{}, Errors: []int{400, 404, 500},
+		},
+		{
+			Name: "listWebhooks", Method: "GET", Path: "/api/v1/admin/webhooks", Handler: admin.ListWebhooks,
+			Summary: "List the configured webhook subscriptions, without their secrets", Tag: "admin", Access: Admin,
+			Response: []webhook.Info{},
+		},
+		{
+			Name: "listWebhookDeliveries", Method: "GET", Path: "/api/v1/admin/webhooks/deliveries", Handler: admin.ListWebhookDeliveries,
+			Summary: "Query webhook delivery attempts, newest first", Tag: "admin", Access: Admin,
+			Query: []Param{
+				{Name: "subscription", Description: "Only deliveries to this webhook"},
+				{Name: "event", Description: "Only deliveries of this event type"},
+				{Name: "outcome", Description: "Only deliveries with this outcome: delivered, retrying, failed or dropped"},
+				{Name: "limit", Type: "integer", Description: "Maximum deliveries to return (default 100, at most 1000)"},
+			},
+			Response: WebhookDeliveriesResponse{}, Errors: []int{400, 500},
+		},
+		{
+			Name: "pingWebhook", Method: "POST", Path: "/api/v1/admin/webhooks/{name}/ping", Handler: admin.PingWebhook,
+			Summary: "Queue a ping event for one webhook to check that it receives deliveries", Tag: "admin", Access: Admin,
+			Response: WebhookPing{}, Status: http.StatusAccepted, Errors: []int{404, 503},
+		},
+		{
+			Name: "saveTeam", Method: "PUT", Path: "/api/v1/admin/teams/{slug}", Handler: admin.SaveTeam,
+			Summary: "Create or replace a team; teams from teams.json cannot be changed", Tag: "admin", Access: Admin,
+			Request: TeamRequest{}, Response: models.Team{}, Errors: []int{409, 500},
+		},
+		{
+			Name: "deleteTeam", Method: "DELETE", Path: "/api/v1/admin/teams/{slug}", Handler: admin.DeleteTeam,
+			Summary: "Delete a team created through the API", Tag: "admin", Access: Admin,
+			Response: models.Team{}, Errors: []int{404, 409, 500},
+		},
+		{
+			Name: "getLiveContestScoreboard", Method: "GET", Path: "/api/v1/admin/contests/{slug}/scoreboard", Handler: admin.GetLiveContestScoreboard,
+			Summary: "Get a contest's scoreboard including the results hidden by the freeze", Tag: "admin", Access: Admin,
+			Response: services.ContestScoreboard{}, Errors: []int{404},
+		},
+		{
+			Name: "saveContest", Method: "PUT", Path: "/api/v1/admin/contests/{slug}", Handler: admin.SaveContest,
+			Summary: "Create or replace a contest", Tag: "admin", Access: Admin,
+			Request: ContestRequest{}, Response: models.Contest{}, Errors: []int{500},
+		},
+		{
+			Name: "deleteContest", Method: "DELETE", Path: "/api/v1/admin/contests/{slug}", Handler: admin.DeleteContest,
+			Summary: "Delete a contest", Tag: "admin", Access: Admin,
+			Response: models.Contest{}, Errors: []int{404, 500},
+		},
+
+		// Deprecated aliases kept for existing scripts
+		{
+			Name: "legacyListChallenges", Method: "GET", Path: "/api/challenges", Pattern: "/api/challenges", Handler: api.GetAllChallenges,
+			Summary: "Search and filter challenges", Tag: "challenges", Successor: "/api/v1/challenges", TextErrors: true,
+			Query: challengeListParams, Response: []ChallengeListItem{}, Errors: []int{400, 401},
+		},
+		{
+			Name: "legacyGetChallenge", Method: "GET", Path: "/api/challenges/{id}", Pattern: "/api/challenges/", Handler: api.GetChallengeByID,
+			Summary: "Get a challenge", Tag: "challenges", Successor: "/api/v1/challenges/{id}", TextErrors: true,
+			Response: models.Challenge{}, Errors: []int{400, 404},
+		},
+		{
+			Name: "legacyGetUserProfile", Method: "GET", Path: "/api/users/{username}", Pattern: "GET /api/users/{username}", Handler: api.GetUserProfile,
+			Summary: "Get a user's solved challenges, scores, rank, streaks and recent activity", Tag: "users", Successor: "/api/v1/users/{username}",
+			Response: services.UserProfile{}, Errors: []int{400},
+		},
+		{
+			Name: "legacyListTeams", Method: "GET", Path: "/api/teams", Pattern: "GET /api/teams", Handler: api.ListTeams,
+			Summary: "List teams with their members, combined solves and coverage, ranked by average score", Tag: "teams", Successor: "/api/v1/teams",
+			Response: []TeamSummary{},
+		},
+		{
+			Name: "legacyListSubmissions", Method: "GET", Path: "/api/submissions", Pattern: "/api/submissions", Handler: api.HandleSubmissions,
+			Summary: "List the signed-in user's submissions, newest first", Tag: "submissions", Access: SignedIn, Successor: "/api/v1/submissions", TextErrors: true,
+			Response: []models.Submission{},
+		},
+		{
+			Name: "legacyCreateSubmission", Method: "POST", Path: "/api/submissions", Pattern: "/api/submissions", Handler: api.HandleSubmissions,
+			Summary: "Submit a solution", Tag: "submissions", Access: SignedIn, Successor: "/api/v1/submissions", TextErrors: true,
+			Request: SubmissionRequest{}, Response: models.Submission{}, Errors: []int{404, 409, 503},
+		},
+		{
+			Name: "legacyGetScoreboard", Method: "GET", Path: "/api/scoreboard/{id}", Pattern: "/api/scoreboard/", Handler: api.GetScoreboard,
+			Summary: "Get a challenge's scoreboard", Tag: "challenges", Successor: "/api/v1/challenges/{id}/scoreboard", TextErrors: true,
+			Response: []models.ScoreboardEntry{}, Errors: []int{400},
+		},
+		{
+			Name: "legacyRunCode", Method: "POST", Path: "/api/run", Pattern: "/api/run", Handler: api.RunCode,
+			Summary: "Run code against a challenge's tests", Tag: "runs", Successor: "/api/v1/runs", TextErrors: true,
+			Request: RunRequest{}, Response: services.ExecutionResult{}, Errors: []int{404, 409, 503},
+		},
+		{
+			Name: "legacySaveToFilesystem", Method: "POST", Path: "/api/save-to-filesystem", Pattern: "/api/save-to-filesystem", Handler: api.SaveSubmissionToFilesystem,
+			Summary: "Save the signed-in user's solution into the repository", Tag: "challenges", Access: SignedIn,
+			Successor: "/api/v1/challenges/{id}/solution", TextErrors: true,
+			Request: services.SaveSubmissionRequest{}, Response: services.SaveSubmissionResponse{}, Errors: []int{404},
+		},
+		{
+			Name: "legacyRefreshAttempts", Method: "POST", Path: "/api/refresh-attempts", Pattern: "/api/refresh-attempts", Handler: api.RefreshUserAttempts,
+			Summary: "Rescan a user's attempted challenges", Tag: "users", Successor: "/api/v1/users/{username}/attempts/refresh", TextErrors: true,
+			Request: LegacyRefreshRequest{}, Response: LegacyAttemptsResponse{},
+		},
+		{
+			Name: "legacyGetGitUsername", Method: "GET", Path: "/api/git-username", Pattern: "/api/git-username", Handler: api.GetGitUsername,
+			Summary: "Get the git identity configured for the repository", Tag: "repository", Successor: "/api/v1/git-username", TextErrors: true,
+			Response: LegacyGitUsernameResponse{},
+		},
+		{
+			Name: "legacyGetMainScoreboardRank", Method: "GET", Path: "/api/main-scoreboard-rank", Pattern: "/api/main-scoreboard-rank", Handler: api.GetMainScoreboardRank,
+			Summary: "Get a user's main leaderboard rank", Tag: "leaderboard", Successor: "/api/v1/leaderboard/{username}", TextErrors: true,
+			Query:    []Param{{Name: "username", Required: true, Description: "User to rank"}},
+			Response: LegacyRankResponse{}, Errors: []int{400},
+		},
+		{
+			Name: "legacyGetMainLeaderboard", Method: "GET", Path: "/api/main-leaderboard", Pattern: "/api/main-leaderboard", Handler: api.GetMainLeaderboard,
+			Summary: "Get the main leaderboard", Tag: "leaderboard", Successor: "/api/v1/leaderboard", TextErrors: true,
+			Response: LegacyLeaderboardResponse{},
+		},
+		{
+			Name: "legacyListAuditEvents", Method: "GET", Path: "/api/admin/audit", Pattern: "GET /api/admin/audit", Handler: admin.AuditLog,
+			Summary: "Query the audit log, newest first", Tag: "admin", Access: Admin, Query: auditQuery, Successor: "/api/v1/admin/audit",
+			Response: AuditLogResponse{}, Errors: []int{400, 500},
+		},
+	}
+}
+
+// RegisterRoutes serves the /api/v1 routes from their own mux, mounted on mux
+// behind NewV1Router, and the legacy aliases directly on mux. It returns the
+// /api/v1 mux so callers can label metrics with its patterns.
+func RegisterRoutes(mux *http.ServeMux, routes []Route) *http.ServeMux {
+	v1 := http.NewServeMux()
+	registered := make(map[string]bool)
+	for _, route := range routes {
+		if route.Successor == "" {
+			v1.HandleFunc(route.Method+" "+route.Path, route.Handler)
+			continue
+		}
+		// Legacy handlers dispatch on the method themselves, so aliases that
+		// share a pattern are registered once
+		if !registered[route.Pattern] {
+			mux.HandleFunc(route.Pattern, Deprecated(route.Successor, route.Handler))
+			registered[route.Pattern] = true
+		}
+	}
+	mux.Handle("/api/v1/", NewV1Router(v1))
+	return v1
+}
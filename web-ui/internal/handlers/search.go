@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"web-ui/internal/models"
+	"web-ui/internal/services"
+)
+
+// ChallengeListItem documents the elements of a challenge list: a challenge
+// limited to the requested fields, with a relevance score for text searches
+type ChallengeListItem struct {
+	ID                int      `json:"id,omitempty"`
+	Title             string   `json:"title,omitempty"`
+	Description       string   `json:"description,omitempty"`
+	Difficulty        string   `json:"difficulty,omitempty" enum:"Beginner,Intermediate,Advanced"`
+	Template          string   `json:"template,omitempty"`
+	TestFile          string   `json:"testFile,omitempty"`
+	LearningMaterials string   `json:"learningMaterials,omitempty"`
+	Hints             string   `json:"hints,omitempty"`
+	Tags              []string `json:"tags,omitempty"`
+	Topics            []string `json:"topics,omitempty"`
+	Prerequisites     []int    `json:"prerequisites,omitempty"`
+	Points            int      `json:"points,omitempty"`
+	Score             float64  `json:"score,omitempty" doc:"Relevance to q, higher is better; only set when q is"`
+}
+
+// challengeListParams are the query parameters accepted by challenge lists
+var challengeListParams = []Param{
+	{Name: "q", Description: "Full-text search over title, description, tags and learning material; the last word also matches as a prefix"},
+	{Name: "difficulty", Description: "Comma-separated difficulties (Beginner, Intermediate, Advanced)"},
+	{Name: "tag", Description: "Comma-separated tags, see /api/v1/tags"},
+	{Name: "topic", Description: "Comma-separated topics, see /api/v1/topics"},
+	{Name: "status", Description: "solved or unsolved by the signed-in user"},
+	{Name: "sort", Description: "id, title, difficulty or relevance, prefixed with - to reverse; relevance when q is set, else id"},
+	{Name: "fields", Description: "Comma-separated fields to return, e.g. id,title,difficulty; all when empty"},
+}
+
+// challengeListQuery is a parsed challenge list request
+type challengeListQuery struct {
+	search services.ChallengeQuery
+	fields []string
+	scored bool
+}
+
+// queryError is a rejected list query and the status to report it with
+type queryError struct {
+	status  int
+	code    string
+	message string
+}
+
+// challengeFieldNames are the JSON names of the challenge fields ?fields= may select
+var challengeFieldNames = func() map[string]bool {
+	data, _ := json.Marshal(models.Challenge{})
+	var fields map[string]json.RawMessage
+	json.Unmarshal(data, &fields)
+	names := map[string]bool{"score": true}
+	for name := range fields {
+		names[name] = true
+	}
+	return names
+}()
+
+// parseChallengeQuery reads the challenge list parameters from r
+func (h *APIHandler) parseChallengeQuery(r *http.Request) (challengeListQuery, *queryError) {
+	values := r.URL.Query()
+	q := challengeListQuery{
+		search: services.ChallengeQuery{
+			Text:         strings.TrimSpace(values.Get("q")),
+			Difficulties: listParam(values["difficulty"]),
+			Tags:         listParam(values["tag"]),
+			Topics:       listParam(values["topic"]),
+			Sort:         values.Get("sort"),
+		},
+		fields: listParam(values["fields"]),
+	}
+	q.scored = q.search.Text != ""
+
+	for _, field := range q.fields {
+		if !challengeFieldNames[field] {
+			return q, &queryError{http.StatusBadRequest, CodeBadRequest, "Unknown field " + field}
+		}
+	}
+
+	switch status := values.Get("status"); status {
+	case "":
+	case "solved", "unsolved":
+		username := h.sessions.Username(r)
+		if username == "" {
+			return q, &queryError{http.StatusUnauthorized, CodeUnauthorized, "Sign in to filter by solved status"}
+		}
+		solved := h.scoreboardService.SolvedBy(username, h.challengeService.GetChallenges())
+		want := status == "solved"
+		q.search.Include = func(id int) bool { return solved[id] == want }
+	default:
+		return q, &queryError{http.StatusBadRequest, CodeBadRequest, "status must be solved or unsolved"}
+	}
+	return q, nil
+}
+
+// listChallenges runs the challenge list request in r and shapes the
+// matches for the response
+func (h *APIHandler) listChallenges(r *http.Request) (any, *queryError) {
+	q, qerr := h.parseChallengeQuery(r)
+	if qerr != nil {
+		return nil, qerr
+	}
+	matches, err := h.challengeService.Search(q.search)
+	if err != nil {
+		return nil, &queryError{http.StatusBadRequest, CodeBadRequest, err.Error()}
+	}
+
+	// Without a projection or score the challenges are returned as they are
+	if len(q.fields) == 0 && !q.scored {
+		list := make([]*models.Challenge, 0, len(matches))
+		for _, match := range matches {
+			list = append(list, match.Challenge)
+		}
+		return list, nil
+	}
+
+	items := make([]map[string]any, 0, len(matches))
+	for _, match := range matches {
+		item, err := projectChallenge(match.Challenge, q.fields)
+		if err != nil {
+			return nil, &queryError{http.StatusInternalServerError, CodeInternal, "Could not encode challenge"}
+		}
+		if q.scored && (len(q.fields) == 0 || contains(q.fields, "score")) {
+			item["score"] = match.Score
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// projectChallenge returns the requested JSON fields of a challenge, all when fields is empty
+func projectChallenge(challenge *models.Challenge, fields []string) (map[string]any, error) {
+	data, err := json.Marshal(challenge)
+	if err != nil {
+		return nil, err
+	}
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+
+	item := make(map[string]any, len(fields))
+	if len(fields) == 0 {
+		for name, value := range all {
+			item[name] = value
+		}
+		return item, nil
+	}
+	for _, field := range fields {
+		if value, ok := all[field]; ok {
+			item[field] = value
+		}
+	}
+	return item, nil
+}
+
+// listParam splits repeated and comma-separated query values, dropping blanks
+func listParam(values []string) []string {
+	var list []string
+	for _, value := range values {
+		for _, part := range strings.Split(value, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				list = append(list, part)
+			}
+		}
+	}
+	return list
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ListTags handles GET /api/v1/tags
+func (h *APIHandler) ListTags(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.challengeService.Tags())
+}
+
+// ListTopics handles GET /api/v1/topics
+func (h *APIHandler) ListTopics(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.challengeService.Topics())
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestListChallengesQuery(t *testing.T) {
+	cs := newContractServer(t)
+
+	tests := []struct {
+		name   string
+		target string
+		user   string
+		want   []map[string]any
+	}{
+		{"fields", "/api/v1/challenges?fields=id,title", "", []map[string]any{{"id": 1.0, "title": "Sum"}}},
+		{"search", "/api/v1/challenges?q=add+su&fields=id", "", []map[string]any{{"id": 1.0}}},
+		{"no match", "/api/v1/challenges?q=goroutine&fields=id", "", []map[string]any{}},
+		{"solved", "/api/v1/challenges?status=solved&fields=id", "gopher", []map[string]any{{"id": 1.0}}},
+		{"unsolved", "/api/v1/challenges?status=unsolved&fields=id", "gopher", []map[string]any{}},
+		{"difficulty", "/api/challenges?difficulty=advanced,intermediate&fields=id", "", []map[string]any{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := cs.do(t, http.MethodGet, tt.target, tt.user, nil)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+			}
+			var got []map[string]any
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("body = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// Text searches report relevance alongside the full challenge
+	rec := cs.do(t, http.MethodGet, "/api/v1/challenges?q=sum", "", nil)
+	var scored []ChallengeListItem
+	if err := json.Unmarshal(rec.Body.Bytes(), &scored); err != nil {
+		t.Fatal(err)
+	}
+	if len(scored) != 1 || scored[0].Score <= 0 || scored[0].Template == "" {
+		t.Errorf("q=sum returned %+v, want challenge 1 with a score", scored)
+	}
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"web-ui/internal/services"
+)
+
+// FlaggedSimilarityResponse is the body of GET /api/v1/admin/similarity
+type FlaggedSimilarityResponse struct {
+	Threshold  float64                     `json:"threshold"`
+	Challenges []services.SimilarityReport `json:"challenges" doc:"Challenges with flagged pairs, listing only those pairs"`
+}
+
+// GetSimilarityReport handles GET /api/v1/admin/similarity/{id}, scoring
+// every pair of a challenge's solutions
+func (h *AdminHandler) GetSimilarityReport(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.requireAdmin(w, r); !ok {
+		return
+	}
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+	threshold, ok := h.similarityThreshold(w, r)
+	if !ok {
+		return
+	}
+	flaggedOnly := false
+	if v := r.URL.Query().Get("flagged"); v != "" {
+		var err error
+		if flaggedOnly, err = strconv.ParseBool(v); err != nil {
+			writeError(w, r, http.StatusBadRequest, CodeBadRequest, "Invalid flagged, expected a boolean", map[string]string{"flagged": v})
+			return
+		}
+	}
+
+	report, err := h.similarity.Report(id, threshold)
+	if errors.Is(err, services.ErrChallengeNotFound) {
+		writeError(w, r, http.StatusNotFound, CodeNotFound, "Challenge not found", map[string]int{"challengeId": id})
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "similarity report failed", "challenge", id, "err", err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Failed to compare solutions", nil)
+		return
+	}
+	if flaggedOnly {
+		report.Pairs = report.FlaggedPairs()
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, report)
+}
+
+// ListFlaggedSimilarity handles GET /api/v1/admin/similarity, the flagged
+// pairs of solutions across every challenge
+func (h *AdminHandler) ListFlaggedSimilarity(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.requireAdmin(w, r); !ok {
+		return
+	}
+	threshold, ok := h.similarityThreshold(w, r)
+	if !ok {
+		return
+	}
+
+	reports, err := h.similarity.Flagged(threshold)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "similarity scan failed", "err", err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Failed to compare solutions", nil)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, FlaggedSimilarityResponse{Threshold: threshold, Challenges: reports})
+}
+
+// similarityThreshold parses the threshold query parameter, defaulting to the
+// configured one
+func (h *AdminHandler) similarityThreshold(w http.ResponseWriter, r *http.Request) (float64, bool) {
+	v := r.URL.Query().Get("threshold")
+	if v == "" {
+		return h.similarity.Threshold(), true
+	}
+	threshold, err := strconv.ParseFloat(v, 64)
+	if err != nil || threshold < 0 || threshold > 1 {
+		writeError(w, r, http.StatusBadRequest, CodeBadRequest, "Invalid threshold, expected a number from 0 to 1", map[string]string{"threshold": v})
+		return 0, false
+	}
+	return threshold, true
+}
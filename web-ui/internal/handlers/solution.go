@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"web-ui/internal/models"
+	"web-ui/internal/paths"
+	"web-ui/internal/services"
+)
+
+// ListSolutions handles GET /api/v1/challenges/{id}/solutions
+func (h *APIHandler) ListSolutions(w http.ResponseWriter, r *http.Request) {
+	challenge, ok := h.solutionChallenge(w, r)
+	if !ok {
+		return
+	}
+	gallery, err := h.solutionService.Gallery(challenge)
+	if err != nil {
+		h.solutionFailed(w, r, err)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, gallery)
+}
+
+// GetSolution handles GET /api/v1/challenges/{id}/solutions/{username}
+func (h *APIHandler) GetSolution(w http.ResponseWriter, r *http.Request) {
+	challenge, ok := h.solutionChallenge(w, r)
+	if !ok {
+		return
+	}
+	username, ok := pathUsername(w, r, "username")
+	if !ok {
+		return
+	}
+	solution, err := h.solutionService.Solution(challenge.ID, username)
+	if err != nil {
+		h.solutionFailed(w, r, err)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, solution)
+}
+
+// CompareSolutions handles GET /api/v1/challenges/{id}/solutions/{username}/compare/{other}
+func (h *APIHandler) CompareSolutions(w http.ResponseWriter, r *http.Request) {
+	challenge, ok := h.solutionChallenge(w, r)
+	if !ok {
+		return
+	}
+	left, ok := pathUsername(w, r, "username")
+	if !ok {
+		return
+	}
+	right, ok := pathUsername(w, r, "other")
+	if !ok {
+		return
+	}
+	comparison, err := h.solutionService.Compare(challenge.ID, left, right)
+	if err != nil {
+		h.solutionFailed(w, r, err)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, comparison)
+}
+
+// BenchmarkSolution handles POST /api/v1/challenges/{id}/solutions/{username}/benchmarks
+func (h *APIHandler) BenchmarkSolution(w http.ResponseWriter, r *http.Request) {
+	challenge, ok := h.solutionChallenge(w, r)
+	if !ok {
+		return
+	}
+	username, ok := pathUsername(w, r, "username")
+	if !ok {
+		return
+	}
+	run, err := h.solutionService.Benchmark(r.Context(), challenge, username)
+	switch {
+	case errors.Is(err, services.ErrShuttingDown), errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		// The run never started
+		writeRunErrorV1(w, r, err)
+		return
+	case err != nil:
+		h.solutionFailed(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, run)
+}
+
+// solutionChallenge returns the challenge in the path once the signed-in
+// user has solved it, writing an error otherwise
+func (h *APIHandler) solutionChallenge(w http.ResponseWriter, r *http.Request) (*models.Challenge, bool) {
+	username, ok := h.requireUserV1(w, r)
+	if !ok {
+		return nil, false
+	}
+	challenge, ok := h.challengeFromPath(w, r)
+	if !ok {
+		return nil, false
+	}
+	if !h.solutionService.Unlocked(username, challenge.ID) {
+		writeError(w, r, http.StatusForbidden, CodeForbidden, "Solve this challenge to unlock its solutions", nil)
+		return nil, false
+	}
+	return challenge, true
+}
+
+// pathUsername returns the username in the named path parameter, writing a
+// 400 when it is invalid
+func pathUsername(w http.ResponseWriter, r *http.Request, name string) (string, bool) {
+	username := r.PathValue(name)
+	if err := paths.ValidateUsername(username); err != nil {
+		writeError(w, r, http.StatusBadRequest, CodeBadRequest, "Invalid username", map[string]string{name: username})
+		return "", false
+	}
+	return username, true
+}
+
+// solutionFailed writes the error for a failed solution operation
+func (h *APIHandler) solutionFailed(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, services.ErrSolutionNotFound):
+		writeError(w, r, http.StatusNotFound, CodeNotFound, "Solution not found", nil)
+	case errors.Is(err, services.ErrNoBenchmarks):
+		writeError(w, r, http.StatusConflict, CodeConflict, "This challenge has no benchmarks", nil)
+	default:
+		slog.ErrorContext(r.Context(), "solution operation failed", "err", err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Failed to read solutions", nil)
+	}
+}
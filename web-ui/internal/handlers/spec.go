@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"web-ui/internal/auth"
+	"web-ui/internal/openapi"
+)
+
+// APIVersion is the version reported in the OpenAPI document
+const APIVersion = "1.0.0"
+
+var pathParamRe = regexp.MustCompile(`\{(\w+)\}`)
+
+// integerPathParams are the path parameters parsed with pathID, which
+// rejects anything but a positive integer with a 400
+var integerPathParams = map[string]bool{"id": true, "version": true}
+
+// OpenAPI generates the OpenAPI document describing routes
+func OpenAPI(routes []Route) *openapi.Document {
+	g := openapi.NewGenerator()
+	envelope := g.SchemaOf(ErrorEnvelope{})
+
+	doc := &openapi.Document{
+		OpenAPI: openapi.Version,
+		Info: openapi.Info{
+			Title:   "Go Interview Practice Web UI API",
+			Version: APIVersion,
+			Description: "Errors on /api/v1 use the ErrorEnvelope body. Unsafe methods must echo the " +
+				auth.CSRFCookieName + " cookie in the " + auth.CSRFHeaderName + " header. " +
+				"Operations marked deprecated are legacy aliases of an /api/v1 operation.",
+		},
+		Paths: make(map[string]openapi.PathItem),
+	}
+
+	for _, route := range routes {
+		op := &openapi.Operation{
+			OperationID: route.Name,
+			Summary:     route.Summary,
+			Tags:        []string{route.Tag},
+			Deprecated:  route.Successor != "",
+			Responses:   make(map[string]*openapi.Response),
+		}
+		if route.Successor != "" {
+			op.Description = "Deprecated: use " + route.Successor + "."
+		}
+
+		for _, match := range pathParamRe.FindAllStringSubmatch(route.Path, -1) {
+			schema := &openapi.Schema{Type: "string"}
+			if integerPathParams[match[1]] {
+				schema = &openapi.Schema{Type: "integer"}
+			}
+			op.Parameters = append(op.Parameters, openapi.Parameter{Name: match[1], In: "path", Required: true, Schema: schema})
+		}
+		for _, param := range route.Query {
+			typ := param.Type
+			if typ == "" {
+				typ = "string"
+			}
+			op.Parameters = append(op.Parameters, openapi.Parameter{
+				Name:        param.Name,
+				In:          "query",
+				Description: param.Description,
+				Required:    param.Required,
+				Schema:      &openapi.Schema{Type: typ},
+			})
+		}
+
+		if route.Request != nil {
+			op.RequestBody = &openapi.RequestBody{Required: true, Content: openapi.JSON(g.SchemaOf(route.Request))}
+		}
+		if route.Access != Public {
+			op.Security = []map[string][]string{{"session": {}}}
+		}
+
+		status := route.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		op.Responses[strconv.Itoa(status)] = &openapi.Response{
+			Description: http.StatusText(status),
+			Content:     openapi.JSON(g.SchemaOf(route.Response)),
+		}
+		for _, code := range errorStatuses(route) {
+			response := &openapi.Response{Description: http.StatusText(code)}
+			if route.TextErrors {
+				response.Content = openapi.Text()
+			} else {
+				response.Content = openapi.JSON(envelope)
+			}
+			op.Responses[strconv.Itoa(code)] = response
+		}
+
+		item := doc.Paths[route.Path]
+		if item == nil {
+			item = make(openapi.PathItem)
+			doc.Paths[route.Path] = item
+		}
+		item[strings.ToLower(route.Method)] = op
+	}
+
+	doc.Components = openapi.Components{
+		Schemas: g.Components(),
+		SecuritySchemes: map[string]openapi.SecurityScheme{
+			"session": {
+				Type:        "apiKey",
+				In:          "cookie",
+				Name:        auth.SessionCookieName,
+				Description: "Signed session cookie set by /auth/login",
+			},
+		},
+	}
+	return doc
+}
+
+// errorStatuses lists the error statuses a route can return: its own plus
+// those implied by how it is served, authenticated and decoded
+func errorStatuses(route Route) []int {
+	codes := map[int]bool{}
+	for _, code := range route.Errors {
+		codes[code] = true
+	}
+	if route.Successor == "" {
+		codes[http.StatusNotAcceptable] = true
+		for _, match := range pathParamRe.FindAllStringSubmatch(route.Path, -1) {
+			if integerPathParams[match[1]] {
+				codes[http.StatusBadRequest] = true
+			}
+		}
+		if route.Request != nil {
+			codes[http.StatusUnsupportedMediaType] = true
+			codes[http.StatusRequestEntityTooLarge] = true
+		}
+	}
+	if route.Request != nil {
+		codes[http.StatusBadRequest] = true
+	}
+	switch route.Access {
+	case SignedIn:
+		codes[http.StatusUnauthorized] = true
+	case Admin:
+		codes[http.StatusUnauthorized] = true
+		codes[http.StatusForbidden] = true
+	}
+	switch route.Method {
+	case "POST", "PUT", "PATCH", "DELETE":
+		// Missing or invalid CSRF token
+		codes[http.StatusForbidden] = true
+	}
+
+	list := make([]int, 0, len(codes))
+	for code := range codes {
+		list = append(list, code)
+	}
+	sort.Ints(list)
+	return list
+}
+
+// ServeOpenAPI serves doc as JSON
+func ServeOpenAPI(doc *openapi.Document) http.HandlerFunc {
+	body, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		panic("openapi: " + err.Error())
+	}
+	body = append(body, '\n')
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		w.Write(body)
+	}
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"web-ui/internal/events"
+	"web-ui/internal/models"
+)
+
+func TestSubmissionListsHideOthersCode(t *testing.T) {
+	cs := newContractServer(t)
+
+	// Neither listing may show the rival's entry while the contest runs
+	for _, target := range []string{"/api/submissions", "/api/v1/submissions"} {
+		if rec := cs.do(t, http.MethodGet, target, "", nil); rec.Code != http.StatusUnauthorized {
+			t.Errorf("anonymous %s = %d, want 401", target, rec.Code)
+		}
+		rec := cs.do(t, http.MethodGet, target, "gopher", nil)
+		var submissions []models.Submission
+		if err := json.Unmarshal(rec.Body.Bytes(), &submissions); err != nil {
+			t.Fatalf("%s: %v: %s", target, err, rec.Body)
+		}
+		if len(submissions) != 0 || strings.Contains(rec.Body.String(), "rival") {
+			t.Errorf("gopher's %s = %s, want none of the rival's", target, rec.Body)
+		}
+	}
+
+	// The rival still sees their own entry
+	rec := cs.do(t, http.MethodGet, "/api/submissions", "rival", nil)
+	var own []models.Submission
+	if err := json.Unmarshal(rec.Body.Bytes(), &own); err != nil {
+		t.Fatal(err)
+	}
+	if len(own) != 1 || own[0].Code != rivalContestCode || own[0].Contest != "weekly" {
+		t.Errorf("rival's /api/submissions = %+v", own)
+	}
+}
+
+func TestFrozenContestResultsAreHeld(t *testing.T) {
+	cs := newContractServer(t)
+	var published []events.Event
+	cs.bus.Subscribe(func(e events.Event) { published = append(published, e) })
+
+	// A passing submission during the finals' freeze reaches neither the
+	// public scoreboard nor the webhooks
+	body := SubmissionRequest{ChallengeID: 1, Code: contractSolution, Contest: "finals"}
+	if rec := cs.do(t, http.MethodPost, "/api/v1/submissions", "newcomer", body); rec.Code != http.StatusCreated {
+		t.Fatalf("frozen submission = %d: %s", rec.Code, rec.Body)
+	}
+	for _, target := range []string{"/api/scoreboard/1", "/api/v1/challenges/1/scoreboard"} {
+		if rec := cs.do(t, http.MethodGet, target, "", nil); strings.Contains(rec.Body.String(), "newcomer") {
+			t.Errorf("%s shows the frozen result: %s", target, rec.Body)
+		}
+	}
+	if len(published) != 0 {
+		t.Errorf("published %d events during the freeze, want none", len(published))
+	}
+
+	// Submissions to a contest that is not frozen are published at once
+	body.Contest = "weekly"
+	if rec := cs.do(t, http.MethodPost, "/api/v1/submissions", "ada", body); rec.Code != http.StatusCreated {
+		t.Fatalf("running submission = %d: %s", rec.Code, rec.Body)
+	}
+	if len(published) != 1 || published[0].Data.(events.Submission).Username != "ada" {
+		t.Errorf("published %+v, want ada's submission", published)
+	}
+
+	// The result is released once the contest ends
+	if held := cs.contests.Release(time.Now()); len(held) != 0 {
+		t.Errorf("released %d submissions before the end", len(held))
+	}
+	held := cs.contests.Release(time.Now().Add(time.Hour))
+	if len(held) != 1 || held[0].Username != "newcomer" || !held[0].Passed {
+		t.Errorf("released %+v, want newcomer's passing submission", held)
+	}
+}
@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"web-ui/internal/audit"
+	"web-ui/internal/models"
+	"web-ui/internal/services"
+)
+
+// TeamSummary is a team's aggregate position on the team leaderboard
+type TeamSummary struct {
+	models.Team
+	SolvedCount  int     `json:"solvedCount" doc:"Challenges completed by at least one member"`
+	Solved       []int   `json:"solved" doc:"IDs of the challenges completed by at least one member"`
+	Coverage     float64 `json:"coverage" doc:"Solved challenges as a percentage of all challenges"`
+	AverageScore float64 `json:"averageScore" doc:"Mean member points; members without points count as 0"`
+	Rank         int     `json:"rank" doc:"1-based position by average score; teams with equal averages share a rank"`
+}
+
+// TeamDetail is a team's aggregates with each member's leaderboard standing
+type TeamDetail struct {
+	TeamSummary
+	Standings []LeaderboardUser `json:"standings" doc:"Every member, best first; members without points have rank 0"`
+}
+
+// TeamRequest is the body of PUT /api/v1/admin/teams/{slug}
+type TeamRequest struct {
+	Name    string   `json:"name,omitempty" doc:"Display name, whose slug must be the path slug; defaults to the slug"`
+	Members []string `json:"members" doc:"GitHub usernames"`
+}
+
+// teamQueryParam filters leaderboards to one team's members
+var teamQueryParam = Param{Name: "team", Description: "Only members of this team slug, see /api/v1/teams"}
+
+// ListTeams handles GET /api/v1/teams
+func (h *APIHandler) ListTeams(w http.ResponseWriter, r *http.Request) {
+	standings := h.leaderboardService.TeamStandings(h.teamService.Teams(), time.Now())
+	summaries := make([]TeamSummary, 0, len(standings))
+	for _, standing := range standings {
+		summaries = append(summaries, teamSummary(standing))
+	}
+	writeJSON(w, http.StatusOK, summaries)
+}
+
+// GetTeam handles GET /api/v1/teams/{slug}
+func (h *APIHandler) GetTeam(w http.ResponseWriter, r *http.Request) {
+	slug := r.PathValue("slug")
+	team, ok := h.teamService.Team(slug)
+	if !ok {
+		writeError(w, r, http.StatusNotFound, CodeNotFound, "Team not found", map[string]string{"slug": slug})
+		return
+	}
+	writeJSON(w, http.StatusOK, h.teamDetail(team, time.Now()))
+}
+
+// teamDetail ranks team among every team and lists its members' standings
+func (h *APIHandler) teamDetail(team models.Team, now time.Time) TeamDetail {
+	var detail TeamDetail
+	for _, standing := range h.leaderboardService.TeamStandings(h.teamService.Teams(), now) {
+		if standing.Team.Slug != team.Slug {
+			continue
+		}
+		detail.TeamSummary = teamSummary(standing)
+		total := len(h.challengeService.GetChallenges())
+		detail.Standings = make([]LeaderboardUser, 0, len(standing.Members))
+		for _, member := range standing.Members {
+			detail.Standings = append(detail.Standings, h.leaderboardUser(member, total, now))
+		}
+	}
+	return detail
+}
+
+func teamSummary(standing services.TeamStanding) TeamSummary {
+	return TeamSummary{
+		Team:         standing.Team,
+		SolvedCount:  len(standing.Solved),
+		Solved:       standing.Solved,
+		Coverage:     standing.Coverage,
+		AverageScore: standing.AverageScore,
+		Rank:         standing.Rank,
+	}
+}
+
+// teamFromQuery looks up the team named by the team query parameter,
+// returning nil when it is not set and writing a 404 when it is unknown
+func (h *APIHandler) teamFromQuery(w http.ResponseWriter, r *http.Request) (*models.Team, bool) {
+	slug := r.URL.Query().Get("team")
+	if slug == "" {
+		return nil, true
+	}
+	team, ok := h.teamService.Team(slug)
+	if !ok {
+		writeError(w, r, http.StatusNotFound, CodeNotFound, "Team not found", map[string]string{"slug": slug})
+		return nil, false
+	}
+	return &team, true
+}
+
+// teamEntries returns the scoreboard entries of team's members, all of them
+// when team is nil
+func teamEntries(entries []models.ScoreboardEntry, team *models.Team) []models.ScoreboardEntry {
+	filtered := []models.ScoreboardEntry{}
+	for _, entry := range entries {
+		if team == nil || team.HasMember(entry.Username) {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered
+}
+
+// SaveTeam handles PUT /api/v1/admin/teams/{slug}, creating or replacing
+// a team managed through the API
+func (h *AdminHandler) SaveTeam(w http.ResponseWriter, r *http.Request) {
+	actor, ok := h.requireAdmin(w, r)
+	if !ok {
+		return
+	}
+	var request TeamRequest
+	if !decodeJSON(w, r, &request) {
+		return
+	}
+
+	slug := r.PathValue("slug")
+	if request.Name == "" {
+		request.Name = slug
+	}
+	if nameSlug := services.TeamSlug(request.Name); nameSlug != slug {
+		writeError(w, r, http.StatusBadRequest, CodeBadRequest, "Team name does not match the slug",
+			map[string]string{"slug": slug, "nameSlug": nameSlug})
+		return
+	}
+
+	team, created, err := h.teams.SaveTeam(request.Name, request.Members)
+	switch {
+	case errors.Is(err, services.ErrInvalidTeam):
+		writeError(w, r, http.StatusBadRequest, CodeBadRequest, err.Error(), nil)
+		return
+	case errors.Is(err, services.ErrTeamReadOnly):
+		writeError(w, r, http.StatusConflict, CodeConflict, "Team is defined in the repository's teams.json", map[string]string{"slug": slug})
+		return
+	case err != nil:
+		slog.ErrorContext(r.Context(), "team save failed", "team", slug, "err", err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Failed to save team", nil)
+		return
+	}
+
+	outcome := "updated"
+	if created {
+		outcome = "created"
+	}
+	h.recordChange(r, actor, audit.ActionTeam, team.Slug, outcome)
+	writeJSON(w, http.StatusOK, team)
+}
+
+// DeleteTeam handles DELETE /api/v1/admin/teams/{slug}
+func (h *AdminHandler) DeleteTeam(w http.ResponseWriter, r *http.Request) {
+	actor, ok := h.requireAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	slug := r.PathValue("slug")
+	team, exists := h.teams.Team(slug)
+	if !exists {
+		writeError(w, r, http.StatusNotFound, CodeNotFound, "Team not found", map[string]string{"slug": slug})
+		return
+	}
+	err := h.teams.DeleteTeam(slug)
+	switch {
+	case errors.Is(err, services.ErrTeamNotFound):
+		writeError(w, r, http.StatusNotFound, CodeNotFound, "Team not found", map[string]string{"slug": slug})
+		return
+	case errors.Is(err, services.ErrTeamReadOnly):
+		writeError(w, r, http.StatusConflict, CodeConflict, "Team is defined in the repository's teams.json", map[string]string{"slug": slug})
+		return
+	case err != nil:
+		slog.ErrorContext(r.Context(), "team delete failed", "team", slug, "err", err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Failed to delete team", nil)
+		return
+	}
+
+	h.recordChange(r, actor, audit.ActionTeam, slug, "deleted")
+	writeJSON(w, http.StatusOK, team)
+}
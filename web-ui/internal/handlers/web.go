@@ -2,14 +2,19 @@ package handlers
 
 import (
 	"embed"
+	"errors"
 	"html/template"
-	"log"
+	"log/slog"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
+	"web-ui/internal/auth"
+	"web-ui/internal/collab"
 	"web-ui/internal/models"
+	"web-ui/internal/paths"
 	"web-ui/internal/services"
 	"web-ui/internal/utils"
 )
@@ -20,6 +25,16 @@ type WebHandler struct {
 	challengeService  *services.ChallengeService
 	scoreboardService *services.ScoreboardService
 	userService       *services.UserService
+	profileService    *services.ProfileService
+	leaderboard       *services.LeaderboardService
+	teamService       *services.TeamService
+	contestService    *services.ContestService
+	interviewService  *services.InterviewService
+	pairHub           *collab.Hub
+	draftService      *services.DraftService
+	solutionService   *services.SolutionService
+	sessions          *auth.SessionManager
+	isAdmin           func(username string) bool
 }
 
 // NewWebHandler creates a new web handler
@@ -28,12 +43,32 @@ func NewWebHandler(
 	challengeService *services.ChallengeService,
 	scoreboardService *services.ScoreboardService,
 	userService *services.UserService,
+	profileService *services.ProfileService,
+	leaderboard *services.LeaderboardService,
+	teamService *services.TeamService,
+	contestService *services.ContestService,
+	interviewService *services.InterviewService,
+	pairHub *collab.Hub,
+	draftService *services.DraftService,
+	solutionService *services.SolutionService,
+	sessions *auth.SessionManager,
+	isAdmin func(string) bool,
 ) *WebHandler {
 	return &WebHandler{
 		content:           content,
 		challengeService:  challengeService,
 		scoreboardService: scoreboardService,
 		userService:       userService,
+		profileService:    profileService,
+		leaderboard:       leaderboard,
+		teamService:       teamService,
+		contestService:    contestService,
+		interviewService:  interviewService,
+		pairHub:           pairHub,
+		draftService:      draftService,
+		solutionService:   solutionService,
+		sessions:          sessions,
+		isAdmin:           isAdmin,
 	}
 }
 
@@ -46,7 +81,7 @@ func (h *WebHandler) HomePage(w http.ResponseWriter, r *http.Request) {
 
 	tmpl, err := template.New("").Funcs(utils.GetTemplateFuncs()).ParseFS(h.content, "templates/base.html", "templates/home.html")
 	if err != nil {
-		log.Printf("Template error: %v", err)
+		slog.ErrorContext(r.Context(), "template parse failed", "err", err)
 		http.Error(w, "Failed to parse template: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -57,8 +92,8 @@ func (h *WebHandler) HomePage(w http.ResponseWriter, r *http.Request) {
 		challengeList = append(challengeList, challenge)
 	}
 
-	// Get the username from cookie if available
-	username := h.getUsernameFromCookie(r)
+	// Get the signed-in username if available
+	username := h.sessions.Username(r)
 
 	// Get user attempts if username is set
 	var userAttempt *models.UserAttemptedChallenges
@@ -66,19 +101,29 @@ func (h *WebHandler) HomePage(w http.ResponseWriter, r *http.Request) {
 		userAttempt = h.userService.GetUserAttempts(username, h.challengeService.GetChallenges())
 	}
 
+	// Suggest where a signed-in user should go next
+	var next *services.Recommendation
+	if username != "" {
+		if recommendation, err := h.challengeService.Recommend(userAttempt, ""); err == nil && recommendation.Challenge != nil {
+			next = &recommendation
+		}
+	}
+
 	data := struct {
 		Challenges   []*models.Challenge
 		Username     string
 		UserAttempts *models.UserAttemptedChallenges
+		Next         *services.Recommendation
 	}{
 		Challenges:   challengeList,
 		Username:     username,
 		UserAttempts: userAttempt,
+		Next:         next,
 	}
 
 	err = tmpl.ExecuteTemplate(w, "base", data)
 	if err != nil {
-		log.Printf("Template execution error: %v", err)
+		slog.ErrorContext(r.Context(), "template execution failed", "err", err)
 		// Don't call http.Error here since headers may already be sent during template execution
 	}
 }
@@ -99,51 +144,131 @@ func (h *WebHandler) ChallengePage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get username from cookie first
-	username := h.getUsernameFromCookie(r)
-
-	// If no username from cookie, try to get it from Git config
-	if username == "" {
-		gitInfo := utils.GetGitUsername()
-		if gitInfo.Username != "" {
-			username = gitInfo.Username
-			// Set the cookie for future requests
-			h.setUsernameCookie(w, username)
-		}
-	}
+	// Only the signed-in user's own solution is loaded into the editor
+	username := h.sessions.Username(r)
 
 	existingSolution := ""
 	hasAttempted := false
+	var draft *models.DraftVersion
 
 	if username != "" {
 		existingSolution = h.userService.GetExistingSolution(username, id)
 		// Check if user has attempted this challenge
 		userAttempts := h.userService.GetUserAttempts(username, h.challengeService.GetChallenges())
 		hasAttempted = userAttempts.AttemptedIDs[id]
+
+		// The latest draft is loaded instead of the solution file, which it
+		// is at least as recent as unless the file was changed outside the app
+		latest, err := h.draftService.Latest(username, id)
+		switch {
+		case err == nil:
+			draft = &latest
+		case !errors.Is(err, services.ErrDraftNotFound):
+			slog.ErrorContext(r.Context(), "failed to load draft", "user", username, "challenge", id, "err", err)
+		}
 	}
 
 	tmpl, err := template.New("").Funcs(utils.GetTemplateFuncs()).ParseFS(h.content, "templates/base.html", "templates/challenge.html")
 	if err != nil {
-		log.Printf("Template error: %v", err)
+		slog.ErrorContext(r.Context(), "template parse failed", "err", err)
 		http.Error(w, "Failed to parse template: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	// Submissions from a contest's problem links are entered in the contest
+	var contest *models.Contest
+	if slug := r.URL.Query().Get("contest"); slug != "" {
+		if c, ok := h.contestService.Contest(slug); ok && c.HasChallenge(id) {
+			contest = &c
+		}
+	}
+
+	// Pair links join a shared room; the page says so when the room is gone
+	var pairRoom *collab.RoomInfo
+	pairMissing := false
+	if roomID := r.URL.Query().Get("pair"); roomID != "" {
+		if room, err := h.pairHub.Room(roomID); err == nil && room.ChallengeID == id {
+			pairRoom = &room
+		} else {
+			pairMissing = true
+		}
+	}
+
 	data := struct {
 		Challenge        *models.Challenge
 		Username         string
 		ExistingSolution string
+		Draft            *models.DraftVersion
+		DraftDiffers     bool
 		HasAttempted     bool
+		Contest          *models.Contest
+		PairRoom         *collab.RoomInfo
+		PairMissing      bool
 	}{
 		Challenge:        challenge,
 		Username:         username,
 		ExistingSolution: existingSolution,
+		Draft:            draft,
+		DraftDiffers:     draft != nil && existingSolution != "" && draft.Code != existingSolution,
 		HasAttempted:     hasAttempted,
+		Contest:          contest,
+		PairRoom:         pairRoom,
+		PairMissing:      pairMissing,
+	}
+
+	err = tmpl.ExecuteTemplate(w, "base", data)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "template execution failed", "err", err)
+		// Don't call http.Error here since headers may already be sent during template execution
+	}
+}
+
+// SolutionsPage renders the gallery of a challenge's solutions, which is
+// locked until the signed-in user has solved the challenge
+func (h *WebHandler) SolutionsPage(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid challenge ID", http.StatusBadRequest)
+		return
+	}
+	challenge, exists := h.challengeService.GetChallenge(id)
+	if !exists {
+		http.NotFound(w, r)
+		return
+	}
+
+	username := h.sessions.Username(r)
+	unlocked := username != "" && h.solutionService.Unlocked(username, id)
+	gallery := services.SolutionGallery{ChallengeID: id, HasBenchmarks: services.HasBenchmarks(challenge)}
+	if unlocked {
+		if gallery, err = h.solutionService.Gallery(challenge); err != nil {
+			slog.ErrorContext(r.Context(), "failed to list solutions", "challenge", id, "err", err)
+		}
+	}
+
+	tmpl, err := template.New("").Funcs(utils.GetTemplateFuncs()).ParseFS(h.content, "templates/base.html", "templates/solutions.html")
+	if err != nil {
+		slog.ErrorContext(r.Context(), "template parse failed", "err", err)
+		http.Error(w, "Failed to parse template: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data := struct {
+		Challenge *models.Challenge
+		Username  string
+		Unlocked  bool
+		Gallery   services.SolutionGallery
+	}{
+		Challenge: challenge,
+		Username:  username,
+		Unlocked:  unlocked,
+		Gallery:   gallery,
 	}
 
+	w.Header().Set("Cache-Control", "no-store")
 	err = tmpl.ExecuteTemplate(w, "base", data)
 	if err != nil {
-		log.Printf("Template execution error: %v", err)
+		slog.ErrorContext(r.Context(), "template execution failed", "err", err)
 		// Don't call http.Error here since headers may already be sent during template execution
 	}
 }
@@ -152,7 +277,7 @@ func (h *WebHandler) ChallengePage(w http.ResponseWriter, r *http.Request) {
 func (h *WebHandler) ScoreboardPage(w http.ResponseWriter, r *http.Request) {
 	tmpl, err := template.New("").Funcs(utils.GetTemplateFuncs()).ParseFS(h.content, "templates/base.html", "templates/scoreboard.html")
 	if err != nil {
-		log.Printf("Template error: %v", err)
+		slog.ErrorContext(r.Context(), "template parse failed", "err", err)
 		http.Error(w, "Failed to parse template: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -164,14 +289,18 @@ func (h *WebHandler) ScoreboardPage(w http.ResponseWriter, r *http.Request) {
 	data := struct {
 		Challenges  models.ChallengeMap
 		Scoreboards models.ScoreboardMap
+		Teams       []models.Team
+		Team        string
 	}{
 		Challenges:  challenges,
 		Scoreboards: scoreboards,
+		Teams:       h.teamService.Teams(),
+		Team:        r.URL.Query().Get("team"),
 	}
 
 	err = tmpl.ExecuteTemplate(w, "base", data)
 	if err != nil {
-		log.Printf("Template execution error: %v", err)
+		slog.ErrorContext(r.Context(), "template execution failed", "err", err)
 		// Don't call http.Error here since headers may already be sent during template execution
 	}
 }
@@ -192,11 +321,20 @@ func (h *WebHandler) ScoreChallengeHandler(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	var team *models.Team
+	if slug := r.URL.Query().Get("team"); slug != "" {
+		t, ok := h.teamService.Team(slug)
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		team = &t
+	}
 	scoreboard, _ := h.scoreboardService.GetScoreboard(id)
 
 	tmpl, err := template.New("").Funcs(utils.GetTemplateFuncs()).ParseFS(h.content, "templates/base.html", "templates/challenge_scoreboard.html")
 	if err != nil {
-		log.Printf("Template error: %v", err)
+		slog.ErrorContext(r.Context(), "template parse failed", "err", err)
 		http.Error(w, "Failed to parse template: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -204,37 +342,357 @@ func (h *WebHandler) ScoreChallengeHandler(w http.ResponseWriter, r *http.Reques
 	data := struct {
 		Challenge *models.Challenge
 		Entries   []models.ScoreboardEntry
+		Teams     []models.Team
+		Team      *models.Team
 	}{
 		Challenge: challenge,
-		Entries:   scoreboard,
+		Entries:   teamEntries(scoreboard, team),
+		Teams:     h.teamService.Teams(),
+		Team:      team,
+	}
+
+	err = tmpl.ExecuteTemplate(w, "base", data)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "template execution failed", "err", err)
+		// Don't call http.Error here since headers may already be sent during template execution
+	}
+}
+
+// UserProfilePage renders a user's profile
+func (h *WebHandler) UserProfilePage(w http.ResponseWriter, r *http.Request) {
+	username := r.PathValue("username")
+	if err := paths.ValidateUsername(username); err != nil {
+		http.Error(w, "Invalid username", http.StatusBadRequest)
+		return
+	}
+
+	tmpl, err := template.New("").Funcs(utils.GetTemplateFuncs()).ParseFS(h.content, "templates/base.html", "templates/user_profile.html")
+	if err != nil {
+		slog.ErrorContext(r.Context(), "template parse failed", "err", err)
+		http.Error(w, "Failed to parse template: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	profile := h.profileService.Profile(username, time.Now())
+	solvedPercent := 0
+	if profile.TotalChallenges > 0 {
+		solvedPercent = profile.SolvedCount * 100 / profile.TotalChallenges
+	}
+
+	data := struct {
+		Username      string
+		Profile       *services.UserProfile
+		SolvedPercent int
+		IsOwnProfile  bool
+		Teams         []models.Team
+	}{
+		Username:      username,
+		Profile:       profile,
+		SolvedPercent: solvedPercent,
+		Teams:         h.teamService.TeamsOf(username),
+		IsOwnProfile:  strings.EqualFold(h.sessions.Username(r), username),
+	}
+
+	err = tmpl.ExecuteTemplate(w, "base", data)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "template execution failed", "err", err)
+		// Don't call http.Error here since headers may already be sent during template execution
+	}
+}
+
+// TeamsPage renders the team leaderboard
+func (h *WebHandler) TeamsPage(w http.ResponseWriter, r *http.Request) {
+	tmpl, err := template.New("").Funcs(utils.GetTemplateFuncs()).ParseFS(h.content, "templates/base.html", "templates/teams.html")
+	if err != nil {
+		slog.ErrorContext(r.Context(), "template parse failed", "err", err)
+		http.Error(w, "Failed to parse template: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data := struct {
+		Standings       []services.TeamStanding
+		TotalChallenges int
+	}{
+		Standings:       h.leaderboard.TeamStandings(h.teamService.Teams(), time.Now()),
+		TotalChallenges: len(h.challengeService.GetChallenges()),
+	}
+
+	err = tmpl.ExecuteTemplate(w, "base", data)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "template execution failed", "err", err)
+		// Don't call http.Error here since headers may already be sent during template execution
+	}
+}
+
+// TeamPage renders a team's aggregates and members
+func (h *WebHandler) TeamPage(w http.ResponseWriter, r *http.Request) {
+	team, ok := h.teamService.Team(r.PathValue("slug"))
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	tmpl, err := template.New("").Funcs(utils.GetTemplateFuncs()).ParseFS(h.content, "templates/base.html", "templates/team.html")
+	if err != nil {
+		slog.ErrorContext(r.Context(), "template parse failed", "err", err)
+		http.Error(w, "Failed to parse template: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var standing services.TeamStanding
+	for _, ts := range h.leaderboard.TeamStandings(h.teamService.Teams(), time.Now()) {
+		if ts.Team.Slug == team.Slug {
+			standing = ts
+		}
+	}
+
+	data := struct {
+		Standing        services.TeamStanding
+		Challenges      models.ChallengeMap
+		TotalChallenges int
+		TeamCount       int
+	}{
+		Standing:        standing,
+		Challenges:      h.challengeService.GetChallenges(),
+		TotalChallenges: len(h.challengeService.GetChallenges()),
+		TeamCount:       len(h.teamService.Teams()),
+	}
+
+	err = tmpl.ExecuteTemplate(w, "base", data)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "template execution failed", "err", err)
+		// Don't call http.Error here since headers may already be sent during template execution
+	}
+}
+
+// ContestsPage renders the list of contests
+func (h *WebHandler) ContestsPage(w http.ResponseWriter, r *http.Request) {
+	tmpl, err := template.New("").Funcs(utils.GetTemplateFuncs()).ParseFS(h.content, "templates/base.html", "templates/contests.html")
+	if err != nil {
+		slog.ErrorContext(r.Context(), "template parse failed", "err", err)
+		http.Error(w, "Failed to parse template: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now()
+	var contests []ContestSummary
+	for _, contest := range h.contestService.Contests() {
+		contests = append(contests, ContestSummary{Contest: contest, Status: contest.Status(now)})
+	}
+
+	data := struct {
+		Contests []ContestSummary
+	}{
+		Contests: contests,
+	}
+
+	err = tmpl.ExecuteTemplate(w, "base", data)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "template execution failed", "err", err)
+		// Don't call http.Error here since headers may already be sent during template execution
+	}
+}
+
+// ContestPage renders a contest's scoreboard. Administrators can see the
+// live standings during the freeze with ?view=admin.
+func (h *WebHandler) ContestPage(w http.ResponseWriter, r *http.Request) {
+	isAdmin := false
+	if username := h.sessions.Username(r); username != "" {
+		isAdmin = h.isAdmin(username)
+	}
+	live := isAdmin && r.URL.Query().Get("view") == "admin"
+
+	board, err := h.contestService.Scoreboard(r.PathValue("slug"), time.Now(), live)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	tmpl, err := template.New("").Funcs(utils.GetTemplateFuncs()).ParseFS(h.content, "templates/base.html", "templates/contest.html")
+	if err != nil {
+		slog.ErrorContext(r.Context(), "template parse failed", "err", err)
+		http.Error(w, "Failed to parse template: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data := struct {
+		Board   services.ContestScoreboard
+		IsAdmin bool
+		Live    bool
+	}{
+		Board:   board,
+		IsAdmin: isAdmin,
+		Live:    live,
+	}
+
+	err = tmpl.ExecuteTemplate(w, "base", data)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "template execution failed", "err", err)
+		// Don't call http.Error here since headers may already be sent during template execution
+	}
+}
+
+// InterviewsPage renders the signed-in user's interviews and the form to
+// schedule one
+func (h *WebHandler) InterviewsPage(w http.ResponseWriter, r *http.Request) {
+	tmpl, err := template.New("").Funcs(utils.GetTemplateFuncs()).ParseFS(h.content, "templates/base.html", "templates/interviews.html")
+	if err != nil {
+		slog.ErrorContext(r.Context(), "template parse failed", "err", err)
+		http.Error(w, "Failed to parse template: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	username := h.sessions.Username(r)
+	now := time.Now()
+	var interviews []InterviewSummary
+	var challenges []*models.Challenge
+	if username != "" {
+		for _, interview := range h.interviewService.InterviewsBy(username) {
+			interviews = append(interviews, newInterviewSummary(interview, now))
+		}
+		for _, challenge := range h.challengeService.GetChallenges() {
+			challenges = append(challenges, challenge)
+		}
+		sort.Slice(challenges, func(i, j int) bool { return challenges[i].ID < challenges[j].ID })
+	}
+
+	data := struct {
+		Username   string
+		Interviews []InterviewSummary
+		Challenges []*models.Challenge
+	}{
+		Username:   username,
+		Interviews: interviews,
+		Challenges: challenges,
+	}
+
+	err = tmpl.ExecuteTemplate(w, "base", data)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "template execution failed", "err", err)
+		// Don't call http.Error here since headers may already be sent during template execution
+	}
+}
+
+// InterviewReportPage renders an interview's report and replay for the
+// interviewer who created it
+func (h *WebHandler) InterviewReportPage(w http.ResponseWriter, r *http.Request) {
+	username := h.sessions.Username(r)
+	interview, ok := h.interviewService.Interview(r.PathValue("interview"))
+	if !ok || username == "" || !strings.EqualFold(interview.Interviewer, username) {
+		http.NotFound(w, r)
+		return
+	}
+	now := time.Now()
+	report, err := h.interviewService.Report(interview.ID, now)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "interview report failed", "interview", interview.ID, "err", err)
+		http.Error(w, "Failed to read the interview recording", http.StatusInternalServerError)
+		return
+	}
+
+	tmpl, err := template.New("").Funcs(utils.GetTemplateFuncs()).ParseFS(h.content, "templates/base.html", "templates/interview_report.html")
+	if err != nil {
+		slog.ErrorContext(r.Context(), "template parse failed", "err", err)
+		http.Error(w, "Failed to parse template: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data := struct {
+		Summary InterviewSummary
+		Report  services.InterviewReport
+	}{
+		Summary: newInterviewSummary(interview, now),
+		Report:  report,
 	}
 
 	err = tmpl.ExecuteTemplate(w, "base", data)
 	if err != nil {
-		log.Printf("Template execution error: %v", err)
+		slog.ErrorContext(r.Context(), "template execution failed", "err", err)
 		// Don't call http.Error here since headers may already be sent during template execution
 	}
 }
 
-// getUsernameFromCookie retrieves the username from cookie
-func (h *WebHandler) getUsernameFromCookie(r *http.Request) string {
-	cookie, err := r.Cookie("username")
+// InvitePage renders the page a candidate opens from their invite link.
+// Joining is a POST from the page so link previews cannot start the clock.
+func (h *WebHandler) InvitePage(w http.ResponseWriter, r *http.Request) {
+	token := r.PathValue("token")
+	interview, inviteErr := h.interviewService.Invite(token)
+	if errors.Is(inviteErr, services.ErrInterviewNotFound) {
+		http.NotFound(w, r)
+		return
+	}
+
+	tmpl, err := template.New("").Funcs(utils.GetTemplateFuncs()).ParseFS(h.content, "templates/base.html", "templates/interview_invite.html")
+	if err != nil {
+		slog.ErrorContext(r.Context(), "template parse failed", "err", err)
+		http.Error(w, "Failed to parse template: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data := struct {
+		Interview models.Interview
+		Token     string
+		Used      bool
+	}{
+		Interview: interview,
+		Token:     token,
+		Used:      errors.Is(inviteErr, services.ErrInviteUsed),
+	}
+
+	err = tmpl.ExecuteTemplate(w, "base", data)
 	if err != nil {
-		return ""
+		slog.ErrorContext(r.Context(), "template execution failed", "err", err)
+		// Don't call http.Error here since headers may already be sent during template execution
 	}
-	return cookie.Value
 }
 
-// setUsernameCookie sets the username cookie
-func (h *WebHandler) setUsernameCookie(w http.ResponseWriter, username string) {
-	// Cookie expires in 30 days
-	expiration := time.Now().Add(30 * 24 * time.Hour)
-	cookie := http.Cookie{
-		Name:     "username",
-		Value:    username,
-		Expires:  expiration,
-		Path:     "/",
-		HttpOnly: false, // Allow JavaScript to access it
+// InterviewWorkspacePage renders the candidate's editors for an interview
+// their browser has joined
+func (h *WebHandler) InterviewWorkspacePage(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("interview")
+	interview, ok := h.interviewService.Interview(id)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if h.sessions.Candidate(r) != id {
+		http.Error(w, "Open the interview from your invite link", http.StatusForbidden)
+		return
+	}
+	code, err := h.interviewService.LatestCode(id)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "interview recording read failed", "interview", id, "err", err)
+		http.Error(w, "Failed to read the interview recording", http.StatusInternalServerError)
+		return
+	}
+
+	var challenges []*models.Challenge
+	for _, challengeID := range interview.ChallengeIDs {
+		if challenge, ok := h.challengeService.GetChallenge(challengeID); ok {
+			challenges = append(challenges, challenge)
+		}
+	}
+
+	tmpl, err := template.New("").Funcs(utils.GetTemplateFuncs()).ParseFS(h.content, "templates/base.html", "templates/interview_workspace.html")
+	if err != nil {
+		slog.ErrorContext(r.Context(), "template parse failed", "err", err)
+		http.Error(w, "Failed to parse template: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data := struct {
+		Summary    InterviewSummary
+		Challenges []*models.Challenge
+		Code       map[int]string
+	}{
+		Summary:    newInterviewSummary(interview, time.Now()),
+		Challenges: challenges,
+		Code:       code,
+	}
+
+	err = tmpl.ExecuteTemplate(w, "base", data)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "template execution failed", "err", err)
+		// Don't call http.Error here since headers may already be sent during template execution
 	}
-	http.SetCookie(w, &cookie)
 }
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"web-ui/internal/events"
+	"web-ui/internal/webhook"
+)
+
+// WebhookDeliveriesResponse is the body of GET /api/v1/admin/webhooks/deliveries
+type WebhookDeliveriesResponse struct {
+	Deliveries []webhook.Delivery `json:"deliveries"`
+	Count      int                `json:"count"`
+}
+
+// WebhookPing is the event queued by POST /api/v1/admin/webhooks/{name}/ping,
+// named apart from audit events in the OpenAPI document
+type WebhookPing events.Event
+
+// ListWebhooks handles GET /api/v1/admin/webhooks, describing the configured
+// subscriptions without their secrets
+func (h *AdminHandler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.requireAdmin(w, r); !ok {
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, h.webhooks.Subscriptions())
+}
+
+// ListWebhookDeliveries handles GET /api/v1/admin/webhooks/deliveries,
+// returning delivery attempts newest first. Supported filters are
+// subscription, event, outcome and limit.
+func (h *AdminHandler) ListWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.requireAdmin(w, r); !ok {
+		return
+	}
+
+	params := r.URL.Query()
+	query := webhook.DeliveryQuery{
+		Subscription: params.Get("subscription"),
+		EventType:    params.Get("event"),
+		Outcome:      params.Get("outcome"),
+	}
+	if v := params.Get("limit"); v != "" {
+		var err error
+		if query.Limit, err = strconv.Atoi(v); err != nil || query.Limit < 1 {
+			writeError(w, r, http.StatusBadRequest, CodeBadRequest, "Invalid limit", map[string]string{"limit": v})
+			return
+		}
+	}
+
+	deliveries, err := h.deliveries.Query(query)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "webhook delivery query failed", "err", err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Failed to read webhook deliveries", nil)
+		return
+	}
+	if deliveries == nil {
+		deliveries = []webhook.Delivery{}
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, WebhookDeliveriesResponse{Deliveries: deliveries, Count: len(deliveries)})
+}
+
+// PingWebhook handles POST /api/v1/admin/webhooks/{name}/ping, queueing a
+// ping event for one subscription. The delivery shows up in the delivery log.
+func (h *AdminHandler) PingWebhook(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.requireAdmin(w, r); !ok {
+		return
+	}
+	name := r.PathValue("name")
+	event, err := h.webhooks.Ping(name)
+	switch {
+	case errors.Is(err, webhook.ErrUnknownSubscription):
+		writeError(w, r, http.StatusNotFound, CodeNotFound, "Webhook not found", map[string]string{"name": name})
+	case errors.Is(err, webhook.ErrClosed):
+		writeError(w, r, http.StatusServiceUnavailable, CodeUnavailable, "Server is shutting down", nil)
+	default:
+		writeJSON(w, http.StatusAccepted, WebhookPing(event))
+	}
+}
@@ -0,0 +1,97 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMiddlewarePropagatesRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, "json", "info")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	var seen string
+	handler := Middleware(logger, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = RequestID(r.Context())
+		logger.InfoContext(r.Context(), "inside handler")
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	tests := []struct {
+		name, header string
+		keep         bool
+	}{
+		{"generated", "", false},
+		{"client supplied", "abc-123_x.y", true},
+		{"unsafe client value", "bad id\nforged=1", false},
+		{"too long", strings.Repeat("a", maxRequestIDLength+1), false},
+	}
+	for _, tt := range tests {
+		buf.Reset()
+		req := httptest.NewRequest("GET", "/api/challenges", nil)
+		if tt.header != "" {
+			req.Header.Set(RequestIDHeader, tt.header)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		id := rec.Header().Get(RequestIDHeader)
+		if id == "" || id != seen {
+			t.Errorf("%s: response ID %q, handler saw %q", tt.name, id, seen)
+		}
+		if (id == tt.header) != tt.keep {
+			t.Errorf("%s: ID = %q, keep client value = %v", tt.name, id, tt.keep)
+		}
+
+		// Both the handler's line and the access line carry the ID
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		if len(lines) != 2 {
+			t.Fatalf("%s: got %d log lines, want 2:\n%s", tt.name, len(lines), buf.String())
+		}
+		for _, line := range lines {
+			var record map[string]any
+			if err := json.Unmarshal([]byte(line), &record); err != nil {
+				t.Fatalf("%s: invalid JSON log line %q", tt.name, line)
+			}
+			if record["request_id"] != id {
+				t.Errorf("%s: log line %q missing request_id %q", tt.name, line, id)
+			}
+		}
+		var access map[string]any
+		json.Unmarshal([]byte(lines[1]), &access)
+		if access["msg"] != "request" || access["status"] != float64(http.StatusTeapot) {
+			t.Errorf("%s: unexpected access line %q", tt.name, lines[1])
+		}
+	}
+}
+
+func TestQuietPathsLogAtDebug(t *testing.T) {
+	var buf bytes.Buffer
+	logger, _ := New(&buf, "text", "info")
+	handler := Middleware(logger, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/healthz", nil))
+	if buf.Len() != 0 {
+		t.Errorf("health check logged at info: %q", buf.String())
+	}
+}
+
+func TestNewRejectsBadSettings(t *testing.T) {
+	if _, err := New(&bytes.Buffer{}, "xml", "info"); err == nil {
+		t.Error("New accepted format xml")
+	}
+	if _, err := New(&bytes.Buffer{}, "text", "loud"); err == nil {
+		t.Error("New accepted level loud")
+	}
+	if logger, err := New(&bytes.Buffer{}, "text", "DEBUG"); err != nil || !logger.Enabled(context.Background(), slog.LevelDebug) {
+		t.Errorf("New(DEBUG) = %v, want debug enabled", err)
+	}
+}
@@ -0,0 +1,94 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// InstrumentHandler records request counts and latencies for next, labelled
+// by route(r) so IDs in paths do not create a series per URL.
+func InstrumentHandler(reg *Registry, route func(*http.Request) string, next http.Handler) http.Handler {
+	requests := reg.NewCounterVec("webui_http_requests_total",
+		"HTTP requests by route pattern, method and status code.", "route", "method", "code")
+	latency := reg.NewHistogramVec("webui_http_request_duration_seconds",
+		"HTTP request latency by route pattern.", DefBuckets, "route")
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		label := route(r)
+		requests.Inc(label, methodLabel(r.Method), strconv.Itoa(rec.status))
+		latency.Observe(time.Since(start).Seconds(), label)
+	})
+}
+
+// MuxRoutes labels a request with the first pattern that matches it in the
+// given muxes, so a mux mounted inside another can be listed before its parent
+func MuxRoutes(muxes ...*http.ServeMux) func(*http.Request) string {
+	return func(r *http.Request) string {
+		for _, mux := range muxes {
+			if _, pattern := mux.Handler(r); pattern != "" {
+				return pattern
+			}
+		}
+		return "unmatched"
+	}
+}
+
+// methodLabel bounds the method label to the standard verbs
+func methodLabel(method string) string {
+	switch method {
+	case "GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS":
+		return method
+	}
+	return "other"
+}
+
+// statusRecorder captures the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (rec *statusRecorder) WriteHeader(status int) {
+	if !rec.wroteHeader {
+		rec.status = status
+		rec.wroteHeader = true
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *statusRecorder) Write(p []byte) (int, error) {
+	rec.wroteHeader = true
+	return rec.ResponseWriter.Write(p)
+}
+
+// Flush lets streaming handlers flush through the recorder
+func (rec *statusRecorder) Flush() {
+	if f, ok := rec.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack lets connection upgrades (e.g. WebSockets) through the recorder
+func (rec *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := rec.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("response writer does not support hijacking")
+	}
+	rec.status = http.StatusSwitchingProtocols
+	rec.wroteHeader = true
+	return h.Hijack()
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (rec *statusRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
@@ -0,0 +1,328 @@
+// Package metrics implements a small in-process metrics registry that renders
+// the Prometheus text exposition format, so the web UI can be scraped without
+// running any additional service.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefBuckets are latency buckets in seconds suited to HTTP requests
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// RunBuckets are duration buckets in seconds suited to go test runs
+var RunBuckets = []float64{.5, 1, 2, 5, 10, 20, 30, 60, 120, 300}
+
+// collector is a metric family that can write itself in the text format
+type collector interface {
+	name() string
+	write(w *bufio.Writer)
+}
+
+// Registry holds metric families and renders them for scraping
+type Registry struct {
+	mu         sync.Mutex
+	collectors map[string]collector
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{collectors: make(map[string]collector)}
+}
+
+// register adds c, panicking on a duplicate name since that is a programming error
+func (reg *Registry) register(c collector) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	if _, exists := reg.collectors[c.name()]; exists {
+		panic(fmt.Sprintf("metrics: duplicate metric %q", c.name()))
+	}
+	reg.collectors[c.name()] = c
+}
+
+// NewCounterVec registers a counter family partitioned by the given labels
+func (reg *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	cv := &CounterVec{family: newFamily(name, help, labels), values: make(map[string]*counterSeries)}
+	reg.register(cv)
+	return cv
+}
+
+// NewHistogramVec registers a histogram family with the given upper bounds
+func (reg *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+	hv := &HistogramVec{family: newFamily(name, help, labels), buckets: sorted, values: make(map[string]*histogramSeries)}
+	reg.register(hv)
+	return hv
+}
+
+// NewGaugeFunc registers a gauge whose value is read from fn at scrape time
+func (reg *Registry) NewGaugeFunc(name, help string, fn func() float64) {
+	reg.register(&gaugeFunc{family: newFamily(name, help, nil), fn: fn})
+}
+
+// WriteTo renders every registered family in name order
+func (reg *Registry) WriteTo(w io.Writer) (int64, error) {
+	reg.mu.Lock()
+	names := make([]string, 0, len(reg.collectors))
+	for name := range reg.collectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	collectors := make([]collector, len(names))
+	for i, name := range names {
+		collectors[i] = reg.collectors[name]
+	}
+	reg.mu.Unlock()
+
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	for _, c := range collectors {
+		c.write(bw)
+	}
+	err := bw.Flush()
+	return cw.n, err
+}
+
+// ServeHTTP serves the registry in the Prometheus text format
+func (reg *Registry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" && r.Method != "HEAD" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	reg.WriteTo(w)
+}
+
+// family holds the metadata shared by every series of a metric
+type family struct {
+	metricName string
+	help       string
+	labels     []string
+}
+
+func newFamily(name, help string, labels []string) family {
+	return family{metricName: name, help: help, labels: labels}
+}
+
+func (f family) name() string { return f.metricName }
+
+// writeHeader writes the HELP and TYPE lines
+func (f family) writeHeader(w *bufio.Writer, typ string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", f.metricName, escapeHelp(f.help), f.metricName, typ)
+}
+
+// key joins label values into a map key; values are checked against the label count
+func (f family) key(values []string) string {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", f.metricName, len(f.labels), len(values)))
+	}
+	return strings.Join(values, "\xff")
+}
+
+// labelPairs renders {a="x",b="y"} plus any extra pair, or "" when there are none
+func (f family) labelPairs(values []string, extraName, extraValue string) string {
+	if len(f.labels) == 0 && extraName == "" {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, label := range f.labels {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, `%s="%s"`, label, escapeLabelValue(values[i]))
+	}
+	if extraName != "" {
+		if len(f.labels) > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, `%s="%s"`, extraName, escapeLabelValue(extraValue))
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// CounterVec is a monotonically increasing counter partitioned by labels.
+// Methods on a nil CounterVec are no-ops so instrumentation is optional.
+type CounterVec struct {
+	family
+	mu     sync.Mutex
+	values map[string]*counterSeries
+}
+
+type counterSeries struct {
+	labels []string
+	value  float64
+}
+
+// Inc adds one to the series with the given label values
+func (cv *CounterVec) Inc(labelValues ...string) {
+	cv.Add(1, labelValues...)
+}
+
+// Add adds delta, which must not be negative, to the series
+func (cv *CounterVec) Add(delta float64, labelValues ...string) {
+	if cv == nil {
+		return
+	}
+	if delta < 0 {
+		panic("metrics: counters cannot decrease")
+	}
+	key := cv.key(labelValues)
+
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+	series, ok := cv.values[key]
+	if !ok {
+		series = &counterSeries{labels: append([]string(nil), labelValues...)}
+		cv.values[key] = series
+	}
+	series.value += delta
+}
+
+// Value returns the current value of a series, mainly for tests
+func (cv *CounterVec) Value(labelValues ...string) float64 {
+	if cv == nil {
+		return 0
+	}
+	key := cv.key(labelValues)
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+	if series, ok := cv.values[key]; ok {
+		return series.value
+	}
+	return 0
+}
+
+func (cv *CounterVec) write(w *bufio.Writer) {
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+
+	cv.writeHeader(w, "counter")
+	for _, key := range sortedKeys(cv.values) {
+		series := cv.values[key]
+		fmt.Fprintf(w, "%s%s %s\n", cv.metricName, cv.labelPairs(series.labels, "", ""), formatFloat(series.value))
+	}
+}
+
+// HistogramVec counts observations into cumulative buckets, partitioned by
+// labels. Methods on a nil HistogramVec are no-ops.
+type HistogramVec struct {
+	family
+	buckets []float64
+	mu      sync.Mutex
+	values  map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	labels []string
+	counts []uint64 // per bucket, not cumulative
+	count  uint64
+	sum    float64
+}
+
+// Observe records v in the series with the given label values
+func (hv *HistogramVec) Observe(v float64, labelValues ...string) {
+	if hv == nil {
+		return
+	}
+	key := hv.key(labelValues)
+
+	hv.mu.Lock()
+	defer hv.mu.Unlock()
+	series, ok := hv.values[key]
+	if !ok {
+		series = &histogramSeries{
+			labels: append([]string(nil), labelValues...),
+			counts: make([]uint64, len(hv.buckets)),
+		}
+		hv.values[key] = series
+	}
+	if i := sort.SearchFloat64s(hv.buckets, v); i < len(hv.buckets) {
+		series.counts[i]++
+	}
+	series.count++
+	series.sum += v
+}
+
+func (hv *HistogramVec) write(w *bufio.Writer) {
+	hv.mu.Lock()
+	defer hv.mu.Unlock()
+
+	hv.writeHeader(w, "histogram")
+	for _, key := range sortedKeys(hv.values) {
+		series := hv.values[key]
+		var cumulative uint64
+		for i, upper := range hv.buckets {
+			cumulative += series.counts[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", hv.metricName, hv.labelPairs(series.labels, "le", formatFloat(upper)), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", hv.metricName, hv.labelPairs(series.labels, "le", "+Inf"), series.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", hv.metricName, hv.labelPairs(series.labels, "", ""), formatFloat(series.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", hv.metricName, hv.labelPairs(series.labels, "", ""), series.count)
+	}
+}
+
+// gaugeFunc is a gauge sampled at scrape time
+type gaugeFunc struct {
+	family
+	fn func() float64
+}
+
+func (g *gaugeFunc) write(w *bufio.Writer) {
+	g.writeHeader(w, "gauge")
+	fmt.Fprintf(w, "%s %s\n", g.metricName, formatFloat(g.fn()))
+}
+
+// sortedKeys returns map keys in order so output is stable between scrapes
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// formatFloat renders a sample value the way Prometheus parses it
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string       { return helpEscaper.Replace(s) }
+func escapeLabelValue(s string) string { return labelEscaper.Replace(s) }
+
+// countingWriter tracks bytes written for WriteTo
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
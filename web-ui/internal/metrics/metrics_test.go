@@ -0,0 +1,122 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func render(t *testing.T, reg *Registry) string {
+	t.Helper()
+	var b strings.Builder
+	if _, err := reg.WriteTo(&b); err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+	return b.String()
+}
+
+func TestCounterExposition(t *testing.T) {
+	reg := NewRegistry()
+	runs := reg.NewCounterVec("runs_total", "Runs by challenge.\nSecond line.", "challenge")
+	runs.Inc("2")
+	runs.Inc("10")
+	runs.Add(2, "2")
+	runs.Inc(`a"b\c` + "\n")
+
+	want := `# HELP runs_total Runs by challenge.\nSecond line.
+# TYPE runs_total counter
+runs_total{challenge="10"} 1
+runs_total{challenge="2"} 3
+runs_total{challenge="a\"b\\c\n"} 1
+`
+	if got := render(t, reg); got != want {
+		t.Errorf("exposition =\n%s\nwant\n%s", got, want)
+	}
+	if v := runs.Value("2"); v != 3 {
+		t.Errorf("Value = %v, want 3", v)
+	}
+}
+
+func TestHistogramExposition(t *testing.T) {
+	reg := NewRegistry()
+	h := reg.NewHistogramVec("run_seconds", "Run durations.", []float64{5, 1}, "challenge")
+	h.Observe(0.5, "1")
+	h.Observe(1, "1")
+	h.Observe(3, "1")
+	h.Observe(60, "1")
+
+	want := `# HELP run_seconds Run durations.
+# TYPE run_seconds histogram
+run_seconds_bucket{challenge="1",le="1"} 2
+run_seconds_bucket{challenge="1",le="5"} 3
+run_seconds_bucket{challenge="1",le="+Inf"} 4
+run_seconds_sum{challenge="1"} 64.5
+run_seconds_count{challenge="1"} 4
+`
+	if got := render(t, reg); got != want {
+		t.Errorf("exposition =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestNilVecsAreNoops(t *testing.T) {
+	var cv *CounterVec
+	var hv *HistogramVec
+	cv.Inc("x")
+	hv.Observe(1, "x")
+	if cv.Value("x") != 0 {
+		t.Error("nil CounterVec reported a value")
+	}
+}
+
+func TestInstrumentHandlerUsesRoutePatterns(t *testing.T) {
+	reg := NewRegistry()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/challenges/", func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/404") {
+			http.NotFound(w, r)
+		}
+	})
+	mux.Handle("/metrics", reg)
+
+	// A versioned mux mounted inside the main one reports its own patterns
+	v1 := http.NewServeMux()
+	v1.HandleFunc("GET /api/v1/challenges/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	mux.Handle("/api/v1/", v1)
+
+	handler := InstrumentHandler(reg, MuxRoutes(v1, mux), mux)
+
+	for _, path := range []string{"/api/challenges/1", "/api/challenges/2", "/api/challenges/404", "/api/v1/challenges/7", "/nowhere"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	body := rec.Body.String()
+	for _, want := range []string{
+		`webui_http_requests_total{route="/api/challenges/",method="GET",code="200"} 2`,
+		`webui_http_requests_total{route="/api/challenges/",method="GET",code="404"} 1`,
+		`webui_http_request_duration_seconds_count{route="/api/challenges/"} 3`,
+		`webui_http_requests_total{route="GET /api/v1/challenges/{id}",method="GET",code="200"} 1`,
+		`webui_http_requests_total{route="unmatched",method="GET",code="404"} 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics output missing %q:\n%s", want, body)
+		}
+	}
+}
+
+func TestDuplicateRegistrationPanics(t *testing.T) {
+	reg := NewRegistry()
+	reg.NewCounterVec("dup_total", "First.")
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a duplicate metric did not panic")
+		}
+	}()
+	reg.NewGaugeFunc("dup_total", "Second.", func() float64 { return 0 })
+}
@@ -1,19 +1,182 @@
 package models
 
 import (
+	"strings"
 	"time"
 )
 
 // Challenge represents a coding challenge
 type Challenge struct {
-	ID                int    `json:"id"`
-	Title             string `json:"title"`
-	Description       string `json:"description"`
-	Difficulty        string `json:"difficulty"`
-	Template          string `json:"template"`
-	TestFile          string `json:"testFile"`
-	LearningMaterials string `json:"learningMaterials"`
-	Hints             string `json:"hints"`
+	ID                int      `json:"id"`
+	Title             string   `json:"title"`
+	Description       string   `json:"description"`
+	Difficulty        string   `json:"difficulty"`
+	Template          string   `json:"template"`
+	TestFile          string   `json:"testFile"`
+	LearningMaterials string   `json:"learningMaterials"`
+	Hints             string   `json:"hints"`
+	Tags              []string `json:"tags" doc:"Curated tags plus the non-standard packages the challenge imports"`
+	Topics            []string `json:"topics" doc:"Broad topics such as concurrency, generics or http"`
+	Prerequisites     []int    `json:"prerequisites" doc:"IDs of the challenges to complete first"`
+	Points            int      `json:"points" doc:"Leaderboard points set in metadata.json; 0 uses the points for the difficulty"`
+}
+
+// LearningPath is a named, ordered sequence of challenges
+type LearningPath struct {
+	Slug         string `json:"slug"`
+	Name         string `json:"name"`
+	ChallengeIDs []int  `json:"challengeIds" doc:"Challenges in the order they should be attempted"`
+}
+
+// Team is a named group of GitHub users compared on the leaderboard
+type Team struct {
+	Slug    string   `json:"slug"`
+	Name    string   `json:"name"`
+	Members []string `json:"members" doc:"GitHub usernames"`
+	Source  string   `json:"source" enum:"repository,admin" doc:"repository teams come from teams.json and cannot be changed through the API"`
+}
+
+// HasMember reports whether username is on the team; GitHub usernames are
+// case-insensitive
+func (t Team) HasMember(username string) bool {
+	for _, member := range t.Members {
+		if strings.EqualFold(member, username) {
+			return true
+		}
+	}
+	return false
+}
+
+// Contest phases
+const (
+	ContestUpcoming = "upcoming"
+	ContestRunning  = "running"
+	ContestFrozen   = "frozen"
+	ContestEnded    = "ended"
+)
+
+// Contest is a timed event on a fixed set of challenges, scored ICPC-style
+type Contest struct {
+	Slug           string    `json:"slug"`
+	Name           string    `json:"name"`
+	ChallengeIDs   []int     `json:"challengeIds" doc:"Problems in the order they are labelled A, B, C..."`
+	StartsAt       time.Time `json:"startsAt"`
+	EndsAt         time.Time `json:"endsAt"`
+	FreezeMinutes  int       `json:"freezeMinutes" doc:"Minutes before the end during which the public scoreboard stops updating"`
+	PenaltyMinutes int       `json:"penaltyMinutes" doc:"Minutes added to a solved problem's time for each rejected submission before it"`
+}
+
+// FreezesAt returns when the public scoreboard stops updating; it is EndsAt
+// when the contest has no freeze
+func (c Contest) FreezesAt() time.Time {
+	return c.EndsAt.Add(-time.Duration(c.FreezeMinutes) * time.Minute)
+}
+
+// Status returns the contest's phase at now
+func (c Contest) Status(now time.Time) string {
+	switch {
+	case now.Before(c.StartsAt):
+		return ContestUpcoming
+	case !now.Before(c.EndsAt):
+		return ContestEnded
+	case !now.Before(c.FreezesAt()):
+		return ContestFrozen
+	}
+	return ContestRunning
+}
+
+// HasChallenge reports whether challengeID is one of the contest's problems
+func (c Contest) HasChallenge(challengeID int) bool {
+	for _, id := range c.ChallengeIDs {
+		if id == challengeID {
+			return true
+		}
+	}
+	return false
+}
+
+// ContestAttempt is one submission entered in a contest
+type ContestAttempt struct {
+	Contest     string    `json:"contest"`
+	Username    string    `json:"username"`
+	ChallengeID int       `json:"challengeId"`
+	Passed      bool      `json:"passed"`
+	SubmittedAt time.Time `json:"submittedAt"`
+}
+
+// Interview phases
+const (
+	InterviewInvited    = "invited"
+	InterviewInProgress = "in_progress"
+	InterviewEnded      = "ended"
+)
+
+// Interview is a timed mock interview. The interviewer invites a candidate
+// through a one-time link and reviews a recording of their work afterwards.
+type Interview struct {
+	ID              string     `json:"id"`
+	Interviewer     string     `json:"interviewer"`
+	Candidate       string     `json:"candidate" doc:"Name the interviewer gave the candidate"`
+	ChallengeIDs    []int      `json:"challengeIds"`
+	DurationMinutes int        `json:"durationMinutes" doc:"Time limit, counted from when the candidate joins"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	StartedAt       *time.Time `json:"startedAt,omitempty" doc:"When the candidate used the invite link"`
+	EndedAt         *time.Time `json:"endedAt,omitempty" doc:"When the interviewer ended the interview"`
+}
+
+// Deadline returns when the candidate's time runs out, nil before they join
+func (iv Interview) Deadline() *time.Time {
+	if iv.StartedAt == nil {
+		return nil
+	}
+	deadline := iv.StartedAt.Add(time.Duration(iv.DurationMinutes) * time.Minute)
+	if iv.EndedAt != nil && iv.EndedAt.Before(deadline) {
+		deadline = *iv.EndedAt
+	}
+	return &deadline
+}
+
+// Status returns the interview's phase at now
+func (iv Interview) Status(now time.Time) string {
+	switch {
+	case iv.EndedAt != nil:
+		return InterviewEnded
+	case iv.StartedAt == nil:
+		return InterviewInvited
+	case !now.Before(*iv.Deadline()):
+		return InterviewEnded
+	}
+	return InterviewInProgress
+}
+
+// HasChallenge reports whether challengeID is one of the interview's challenges
+func (iv Interview) HasChallenge(challengeID int) bool {
+	for _, id := range iv.ChallengeIDs {
+		if id == challengeID {
+			return true
+		}
+	}
+	return false
+}
+
+// Kinds of interview event
+const (
+	InterviewEventJoined = "joined"
+	InterviewEventEditor = "editor"
+	InterviewEventRun    = "run"
+	InterviewEventEnded  = "ended"
+)
+
+// InterviewEvent is one timestamped entry of an interview's recording
+type InterviewEvent struct {
+	Time        time.Time `json:"time"`
+	Kind        string    `json:"kind" enum:"joined,editor,run,ended"`
+	ChallengeID int       `json:"challengeId,omitempty"`
+	Code        string    `json:"code,omitempty" doc:"Editor contents for editor events, the code that ran for run events"`
+	Passed      bool      `json:"passed,omitempty"`
+	TimedOut    bool      `json:"timedOut,omitempty"`
+	Output      string    `json:"output,omitempty" doc:"go test output of a run"`
+	ExecutionMs int64     `json:"executionMs,omitempty"`
 }
 
 // Submission represents a user's submitted solution
@@ -25,13 +188,121 @@ type Submission struct {
 	Passed      bool      `json:"passed"`
 	TestOutput  string    `json:"testOutput"`
 	ExecutionMs int64     `json:"executionMs"`
+	Contest     string    `json:"contest,omitempty" doc:"Slug of the contest the submission was entered in"`
+}
+
+// Sources of a draft version
+const (
+	DraftSourceAutosave   = "autosave"
+	DraftSourceManual     = "manual"
+	DraftSourceRestore    = "restore"
+	DraftSourceFilesystem = "filesystem"
+	DraftSourceSaved      = "saved"
+)
+
+// DraftVersion is one saved version of a user's code for a challenge
+type DraftVersion struct {
+	Version      int       `json:"version" doc:"Numbered from 1 per user and challenge"`
+	ChallengeID  int       `json:"challengeId"`
+	Label        string    `json:"label,omitempty"`
+	Source       string    `json:"source" enum:"autosave,manual,restore,filesystem,saved" doc:"saved versions are solutions saved to the repository; filesystem versions keep a solution file before it was overwritten"`
+	RestoredFrom int       `json:"restoredFrom,omitempty" doc:"Version a restore copied"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt" doc:"Last autosave folded into this version"`
+	Code         string    `json:"code"`
+}
+
+// Benchmark is one benchmark's result from go test -bench -benchmem
+type Benchmark struct {
+	Name        string  `json:"name" doc:"Without the Benchmark prefix and GOMAXPROCS suffix"`
+	Iterations  int64   `json:"iterations"`
+	NsPerOp     float64 `json:"nsPerOp"`
+	BytesPerOp  int64   `json:"bytesPerOp"`
+	AllocsPerOp int64   `json:"allocsPerOp"`
+}
+
+// BenchmarkRun is the outcome of running a solution's benchmarks
+type BenchmarkRun struct {
+	CodeSHA256 string      `json:"codeSha256" doc:"Hash of the solution the benchmarks ran against"`
+	RanAt      time.Time   `json:"ranAt"`
+	Passed     bool        `json:"passed" doc:"Whether go test -bench exited successfully"`
+	Benchmarks []Benchmark `json:"benchmarks"`
+	Output     string      `json:"output,omitempty" doc:"go test output of a failed run"`
+}
+
+// Review verdicts
+const (
+	VerdictPending          = "pending"
+	VerdictApproved         = "approved"
+	VerdictChangesRequested = "changes_requested"
+	VerdictCommented        = "commented"
+)
+
+// Review is a teammate's review of a user's saved solution to a challenge
+type Review struct {
+	ID          string    `json:"id"`
+	ChallengeID int       `json:"challengeId"`
+	Author      string    `json:"author" doc:"Whose solution is reviewed"`
+	Reviewer    string    `json:"reviewer"`
+	Version     int       `json:"version" doc:"Draft version the review was started on"`
+	Verdict     string    `json:"verdict" enum:"pending,approved,changes_requested,commented"`
+	Summary     string    `json:"summary,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// ReviewAnchor is the line of a solution version a thread was started on
+type ReviewAnchor struct {
+	File    string `json:"file"`
+	Line    int    `json:"line" doc:"Numbered from 1"`
+	Version int    `json:"version" doc:"Draft version the line is numbered in"`
+}
+
+// ReviewComment is one comment of a review thread
+type ReviewComment struct {
+	ID        int       `json:"id" doc:"Numbered from 1 per review"`
+	Author    string    `json:"author"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// ReviewThread is a discussion anchored to a line of the reviewed solution
+type ReviewThread struct {
+	ID         int             `json:"id" doc:"Numbered from 1 per review"`
+	Anchor     ReviewAnchor    `json:"anchor"`
+	Resolved   bool            `json:"resolved"`
+	ResolvedBy string          `json:"resolvedBy,omitempty"`
+	ResolvedAt *time.Time      `json:"resolvedAt,omitempty"`
+	Comments   []ReviewComment `json:"comments" doc:"Oldest first; the first one started the thread"`
+}
+
+// Kinds of notification
+const (
+	NotificationReview     = "review"
+	NotificationComment    = "comment"
+	NotificationVerdict    = "verdict"
+	NotificationResolved   = "resolved"
+	NotificationUnresolved = "unresolved"
+)
+
+// Notification tells a user about activity on a review they take part in
+type Notification struct {
+	ID          int       `json:"id" doc:"Numbered from 1 per user"`
+	Kind        string    `json:"kind" enum:"review,comment,verdict,resolved,unresolved"`
+	ReviewID    string    `json:"reviewId"`
+	ChallengeID int       `json:"challengeId"`
+	ThreadID    int       `json:"threadId,omitempty"`
+	Actor       string    `json:"actor"`
+	Message     string    `json:"message"`
+	CreatedAt   time.Time `json:"createdAt"`
+	Read        bool      `json:"read"`
 }
 
 // ScoreboardEntry represents an entry in the scoreboard
 type ScoreboardEntry struct {
-	Username    string    `json:"username"`
-	ChallengeID int       `json:"challengeId"`
-	SubmittedAt time.Time `json:"submittedAt"`
+	Username    string     `json:"username"`
+	ChallengeID int        `json:"challengeId"`
+	SubmittedAt *time.Time `json:"submittedAt,omitempty" doc:"First commit of the user's submission, when the git history has it"`
 }
 
 // UserAttemptedChallenges tracks attempted challenges by username
@@ -0,0 +1,156 @@
+package openapi
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// Generator turns Go types into schemas, collecting named structs as
+// reusable components
+type Generator struct {
+	schemas map[string]*Schema
+	types   map[string]reflect.Type
+}
+
+// NewGenerator creates a generator with no components
+func NewGenerator() *Generator {
+	return &Generator{
+		schemas: make(map[string]*Schema),
+		types:   make(map[string]reflect.Type),
+	}
+}
+
+// SchemaOf returns the schema of v's type; named structs become references
+func (g *Generator) SchemaOf(v any) *Schema {
+	return g.schema(reflect.TypeOf(v))
+}
+
+// Components returns the component schemas generated so far
+func (g *Generator) Components() map[string]*Schema {
+	return g.schemas
+}
+
+func (g *Generator) schema(t reflect.Type) *Schema {
+	if t == nil {
+		return &Schema{}
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.schema(t.Elem())}
+	case reflect.Map:
+		// encoding/json writes integer keys as strings, so only the values are typed
+		return &Schema{Type: "object", AdditionalProperties: g.schema(t.Elem())}
+	case reflect.Interface:
+		return &Schema{}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		return g.component(t)
+	}
+	panic(fmt.Sprintf("openapi: unsupported type %s", t))
+}
+
+// component registers a named struct and returns a reference to it
+func (g *Generator) component(t reflect.Type) *Schema {
+	name := t.Name()
+	ref := &Schema{Ref: "#/components/schemas/" + name}
+	if existing, ok := g.types[name]; ok {
+		if existing != t {
+			panic(fmt.Sprintf("openapi: %s and %s share the component name %s", existing, t, name))
+		}
+		return ref
+	}
+	// Register before recursing so self-referencing types terminate
+	g.types[name] = t
+	g.schemas[name] = nil
+	g.schemas[name] = g.structSchema(t)
+	return ref
+}
+
+func (g *Generator) structSchema(t reflect.Type) *Schema {
+	s := &Schema{
+		Type:                 "object",
+		Properties:           make(map[string]*Schema),
+		AdditionalProperties: false,
+	}
+	g.addFields(s, t)
+	return s
+}
+
+// addFields adds t's JSON properties to s, flattening embedded structs
+func (g *Generator) addFields(s *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				g.addFields(s, ft)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		prop := g.schema(field.Type)
+		if prop.Ref == "" {
+			prop.Description = field.Tag.Get("doc")
+			if enum := field.Tag.Get("enum"); enum != "" {
+				prop.Enum = strings.Split(enum, ",")
+			}
+			prop.Nullable = field.Tag.Get("nullable") == "true"
+		}
+		s.Properties[name] = prop
+
+		if !hasOption(opts, "omitempty") {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
+
+func hasOption(opts, option string) bool {
+	for _, opt := range strings.Split(opts, ",") {
+		if opt == option {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,116 @@
+package openapi
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+type testResult struct {
+	Passed   bool      `json:"passed" doc:"Whether every test passed"`
+	Output   string    `json:"output"`
+	Ms       int64     `json:"ms"`
+	Outcome  string    `json:"outcome,omitempty" enum:"passed,failed"`
+	At       time.Time `json:"at"`
+	Scores   map[int]int
+	Tags     []string    `json:"tags" nullable:"true"`
+	Next     *testResult `json:"next,omitempty"`
+	internal int
+}
+
+type testPage struct {
+	testPaging
+	Results []testResult `json:"results"`
+	Skip    string       `json:"-"`
+}
+
+type testPaging struct {
+	Total int `json:"total"`
+}
+
+func newTestDocument(v any) (*Document, *Schema) {
+	g := NewGenerator()
+	schema := g.SchemaOf(v)
+	return &Document{Components: Components{Schemas: g.Components()}}, schema
+}
+
+func TestGeneratorFollowsJSONTags(t *testing.T) {
+	doc, schema := newTestDocument(testPage{})
+	if schema.Ref != "#/components/schemas/testPage" {
+		t.Fatalf("schema = %+v, want a reference", schema)
+	}
+
+	page := doc.Components.Schemas["testPage"]
+	if got := strings.Join(page.Required, ","); got != "total,results" {
+		t.Errorf("testPage required = %s, want total,results (embedded fields flattened, \"-\" skipped)", got)
+	}
+
+	result := doc.Components.Schemas["testResult"]
+	if got := strings.Join(result.Required, ","); got != "passed,output,ms,at,Scores,tags" {
+		t.Errorf("testResult required = %s", got)
+	}
+	if p := result.Properties["passed"]; p.Type != "boolean" || p.Description != "Whether every test passed" {
+		t.Errorf("passed = %+v", p)
+	}
+	if p := result.Properties["ms"]; p.Type != "integer" || p.Format != "int64" {
+		t.Errorf("ms = %+v", p)
+	}
+	if p := result.Properties["at"]; p.Type != "string" || p.Format != "date-time" {
+		t.Errorf("at = %+v", p)
+	}
+	if p := result.Properties["outcome"]; len(p.Enum) != 2 {
+		t.Errorf("outcome enum = %v", p.Enum)
+	}
+	if p := result.Properties["Scores"]; p.Type != "object" || p.AdditionalProperties.(*Schema).Type != "integer" {
+		t.Errorf("Scores = %+v", p)
+	}
+	if p := result.Properties["tags"]; !p.Nullable || p.Items.Type != "string" {
+		t.Errorf("tags = %+v", p)
+	}
+	if p := result.Properties["next"]; p.Ref != "#/components/schemas/testResult" {
+		t.Errorf("next = %+v, want a self reference", p)
+	}
+	if _, ok := result.Properties["internal"]; ok {
+		t.Error("unexported field was documented")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	doc, schema := newTestDocument(testPage{})
+	valid := `{"total":1,"results":[{"passed":true,"output":"ok","ms":12,"at":"2024-05-01T10:00:00Z","Scores":{"1":100},"tags":null}]}`
+	if err := doc.Validate(schema, []byte(valid)); err != nil {
+		t.Fatalf("Validate(valid) = %v", err)
+	}
+
+	tests := []struct {
+		name, body, want string
+	}{
+		{"missing", `{"total":1}`, "$: missing property results"},
+		{"extra", `{"total":1,"results":[],"page":2}`, "$.page: undocumented property"},
+		{"type", `{"total":"1","results":[]}`, "$.total: got string, want integer"},
+		{"integer", `{"total":1.5,"results":[]}`, "$.total: 1.5 is not an integer"},
+		{"null array", `{"total":1,"results":null}`, "$.results: got null, want array"},
+		{"nested", `{"total":1,"results":[{"passed":true,"output":"","ms":1,"at":"2024-05-01T10:00:00Z","Scores":{},"tags":[]},{"passed":1}]}`, "$.results[1]: missing property output"},
+		{"enum", `{"total":1,"results":[{"passed":true,"output":"","ms":1,"at":"2024-05-01T10:00:00Z","Scores":{},"tags":[],"outcome":"skipped"}]}`, `$.results[0].outcome: "skipped" is not one of passed, failed`},
+		{"date", `{"total":1,"results":[{"passed":true,"output":"","ms":1,"at":"yesterday","Scores":{},"tags":[]}]}`, `$.results[0].at: "yesterday" is not an RFC 3339 date-time`},
+		{"map value", `{"total":1,"results":[{"passed":true,"output":"","ms":1,"at":"2024-05-01T10:00:00Z","Scores":{"1":"full"},"tags":[]}]}`, "$.results[0].Scores.1: got string, want integer"},
+	}
+	for _, tt := range tests {
+		err := doc.Validate(schema, []byte(tt.body))
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("%s: Validate = %v, want %s", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestComponentNameCollisionPanics(t *testing.T) {
+	type testResult struct{ Other bool }
+	g := NewGenerator()
+	g.SchemaOf(testPage{})
+	defer func() {
+		if recover() == nil {
+			t.Error("two types with the same component name did not panic")
+		}
+	}()
+	g.SchemaOf(testResult{})
+}
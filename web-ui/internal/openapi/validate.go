@@ -0,0 +1,192 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ResponseSchema returns the JSON schema documented for an operation's
+// response, or nil when that status has no JSON body. ok is false when the
+// operation or status is not documented at all.
+func (d *Document) ResponseSchema(method, path string, status int) (schema *Schema, ok bool) {
+	op := d.Paths[path][strings.ToLower(method)]
+	if op == nil {
+		return nil, false
+	}
+	response := op.Responses[strconv.Itoa(status)]
+	if response == nil {
+		return nil, false
+	}
+	if media, found := response.Content["application/json"]; found {
+		return media.Schema, true
+	}
+	return nil, true
+}
+
+// Validate checks that data is JSON matching schema, returning the first
+// mismatch with its location, e.g. "$.leaderboard[0]: missing property rank"
+func (d *Document) Validate(schema *Schema, data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var value any
+	if err := dec.Decode(&value); err != nil {
+		return fmt.Errorf("invalid JSON: %v", err)
+	}
+	return d.validate(schema, value, "$")
+}
+
+func (d *Document) validate(schema *Schema, value any, at string) error {
+	if schema.Ref != "" {
+		resolved, err := d.resolve(schema.Ref)
+		if err != nil {
+			return fmt.Errorf("%s: %v", at, err)
+		}
+		return d.validate(resolved, value, at)
+	}
+
+	if value == nil {
+		if schema.Type == "" || schema.Nullable {
+			return nil
+		}
+		return fmt.Errorf("%s: got null, want %s", at, schema.Type)
+	}
+
+	switch schema.Type {
+	case "":
+		return nil
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return mismatch(at, schema.Type, value)
+		}
+	case "integer":
+		n, ok := value.(json.Number)
+		if !ok {
+			return mismatch(at, schema.Type, value)
+		}
+		if _, err := n.Int64(); err != nil {
+			return fmt.Errorf("%s: %s is not an integer", at, n)
+		}
+	case "number":
+		if _, ok := value.(json.Number); !ok {
+			return mismatch(at, schema.Type, value)
+		}
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return mismatch(at, schema.Type, value)
+		}
+		return validateString(schema, s, at)
+	case "array":
+		items, ok := value.([]any)
+		if !ok {
+			return mismatch(at, schema.Type, value)
+		}
+		for i, item := range items {
+			if err := d.validate(schema.Items, item, fmt.Sprintf("%s[%d]", at, i)); err != nil {
+				return err
+			}
+		}
+	case "object":
+		object, ok := value.(map[string]any)
+		if !ok {
+			return mismatch(at, schema.Type, value)
+		}
+		return d.validateObject(schema, object, at)
+	default:
+		return fmt.Errorf("%s: unsupported schema type %q", at, schema.Type)
+	}
+	return nil
+}
+
+func (d *Document) validateObject(schema *Schema, object map[string]any, at string) error {
+	for _, name := range schema.Required {
+		if _, ok := object[name]; !ok {
+			return fmt.Errorf("%s: missing property %s", at, name)
+		}
+	}
+
+	// Check keys in order so the reported error is deterministic
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		child := at + "." + key
+		if prop, ok := schema.Properties[key]; ok {
+			if err := d.validate(prop, object[key], child); err != nil {
+				return err
+			}
+			continue
+		}
+		switch extra := schema.AdditionalProperties.(type) {
+		case *Schema:
+			if err := d.validate(extra, object[key], child); err != nil {
+				return err
+			}
+		case bool:
+			if !extra {
+				return fmt.Errorf("%s: undocumented property", child)
+			}
+		}
+	}
+	return nil
+}
+
+func validateString(schema *Schema, s, at string) error {
+	if len(schema.Enum) > 0 {
+		found := false
+		for _, allowed := range schema.Enum {
+			if s == allowed {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s: %q is not one of %s", at, s, strings.Join(schema.Enum, ", "))
+		}
+	}
+	if schema.Format == "date-time" {
+		if _, err := time.Parse(time.RFC3339Nano, s); err != nil {
+			return fmt.Errorf("%s: %q is not an RFC 3339 date-time", at, s)
+		}
+	}
+	return nil
+}
+
+func (d *Document) resolve(ref string) (*Schema, error) {
+	name, ok := strings.CutPrefix(ref, "#/components/schemas/")
+	if !ok {
+		return nil, fmt.Errorf("unsupported reference %s", ref)
+	}
+	schema := d.Components.Schemas[name]
+	if schema == nil {
+		return nil, fmt.Errorf("unknown schema %s", name)
+	}
+	return schema, nil
+}
+
+func mismatch(at, want string, value any) error {
+	var got string
+	switch value.(type) {
+	case bool:
+		got = "boolean"
+	case json.Number:
+		got = "number"
+	case string:
+		got = "string"
+	case []any:
+		got = "array"
+	case map[string]any:
+		got = "object"
+	default:
+		got = fmt.Sprintf("%T", value)
+	}
+	return fmt.Errorf("%s: got %s, want %s", at, got, want)
+}
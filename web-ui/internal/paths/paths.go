@@ -0,0 +1,196 @@
+package paths
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	// SolutionFileName is the file a submission is stored in
+	SolutionFileName = "solution-template.go"
+	// ScoreboardFileName is the per-challenge scoreboard file
+	ScoreboardFileName = "SCOREBOARD.md"
+
+	maxUsernameLength = 39
+)
+
+var (
+	// ErrInvalidUsername is returned for names that are not valid GitHub usernames
+	ErrInvalidUsername = errors.New("invalid GitHub username")
+	// ErrInvalidChallengeID is returned for non-positive challenge IDs
+	ErrInvalidChallengeID = errors.New("invalid challenge ID")
+	// ErrOutsideRoot is returned when a path resolves outside the repository root
+	ErrOutsideRoot = errors.New("path escapes repository root")
+)
+
+// usernamePattern follows GitHub's rules: alphanumerics and single hyphens,
+// no leading or trailing hyphen
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9](?:[A-Za-z0-9]|-[A-Za-z0-9])*$`)
+
+// ValidateUsername checks that name is a valid GitHub username, which also
+// guarantees it is a single, safe path element
+func ValidateUsername(name string) error {
+	if name == "" || len(name) > maxUsernameLength || !usernamePattern.MatchString(name) {
+		return fmt.Errorf("%w: %q", ErrInvalidUsername, name)
+	}
+	return nil
+}
+
+// Resolver maps challenges and submissions to paths inside the repository root
+type Resolver struct {
+	root string
+}
+
+// NewResolver creates a resolver for the repository at root. The root is made
+// absolute and has its symlinks resolved so containment checks are reliable.
+func NewResolver(root string) (*Resolver, error) {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve repository root: %v", err)
+	}
+	resolved, err := filepath.EvalSymlinks(abs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve repository root: %v", err)
+	}
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve repository root: %v", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("repository root %s is not a directory", resolved)
+	}
+	return &Resolver{root: resolved}, nil
+}
+
+// DetectRoot finds the repository root by looking for challenge directories in
+// the working directory and its parent (the web-ui is usually run from web-ui/)
+func DetectRoot() (string, error) {
+	for _, candidate := range []string{"..", "."} {
+		if matches, _ := filepath.Glob(filepath.Join(candidate, "challenge-*")); len(matches) > 0 {
+			return candidate, nil
+		}
+	}
+	return "", errors.New("could not find challenge directories in . or ..")
+}
+
+// Root returns the absolute repository root
+func (r *Resolver) Root() string {
+	return r.root
+}
+
+// ChallengeDir returns the directory of a challenge
+func (r *Resolver) ChallengeDir(challengeID int) (string, error) {
+	if challengeID <= 0 {
+		return "", fmt.Errorf("%w: %d", ErrInvalidChallengeID, challengeID)
+	}
+	return r.contain(filepath.Join(r.root, fmt.Sprintf("challenge-%d", challengeID)))
+}
+
+// ScoreboardFile returns the SCOREBOARD.md path of a challenge
+func (r *Resolver) ScoreboardFile(challengeID int) (string, error) {
+	dir, err := r.ChallengeDir(challengeID)
+	if err != nil {
+		return "", err
+	}
+	return r.contain(filepath.Join(dir, ScoreboardFileName))
+}
+
+// SubmissionDir returns challenge-N/submissions/<username> after validating the username
+func (r *Resolver) SubmissionDir(challengeID int, username string) (string, error) {
+	if err := ValidateUsername(username); err != nil {
+		return "", err
+	}
+	dir, err := r.ChallengeDir(challengeID)
+	if err != nil {
+		return "", err
+	}
+	return r.contain(filepath.Join(dir, "submissions", username))
+}
+
+// SubmissionFile returns the solution file of a user's submission
+func (r *Resolver) SubmissionFile(challengeID int, username string) (string, error) {
+	dir, err := r.SubmissionDir(challengeID, username)
+	if err != nil {
+		return "", err
+	}
+	return r.contain(filepath.Join(dir, SolutionFileName))
+}
+
+// HasSubmissions reports whether any challenge has a submission directory
+// for username, compared case-insensitively like scoreboard rows
+func (r *Resolver) HasSubmissions(username string) bool {
+	dirs, _ := filepath.Glob(filepath.Join(r.root, "challenge-*", "submissions"))
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if entry.IsDir() && strings.EqualFold(entry.Name(), username) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Rel returns path relative to the repository root, using forward slashes
+func (r *Resolver) Rel(path string) string {
+	rel, err := filepath.Rel(r.root, path)
+	if err != nil {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
+
+// contain returns path if it, and every symlink along it, stays inside the root.
+// Components that do not exist yet are checked lexically, so the result is safe
+// to pass to os.MkdirAll and os.WriteFile.
+func (r *Resolver) contain(path string) (string, error) {
+	clean := filepath.Clean(path)
+	if !r.within(clean) {
+		return "", fmt.Errorf("%w: %s", ErrOutsideRoot, path)
+	}
+
+	// Resolve the longest existing prefix and re-attach the missing tail
+	existing, tail := clean, ""
+	for {
+		resolved, err := filepath.EvalSymlinks(existing)
+		if err == nil {
+			if !r.within(filepath.Join(resolved, tail)) {
+				return "", fmt.Errorf("%w: %s", ErrOutsideRoot, path)
+			}
+			return clean, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return "", err
+		}
+		// A dangling symlink would be followed by a later create
+		if _, lerr := os.Lstat(existing); lerr == nil {
+			return "", fmt.Errorf("%w: %s", ErrOutsideRoot, path)
+		}
+
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			return "", fmt.Errorf("%w: %s", ErrOutsideRoot, path)
+		}
+		tail = filepath.Join(filepath.Base(existing), tail)
+		existing = parent
+	}
+}
+
+// within reports whether path is the root or lexically below it
+func (r *Resolver) within(path string) bool {
+	if path == r.root {
+		return true
+	}
+	prefix := r.root
+	if !strings.HasSuffix(prefix, string(filepath.Separator)) {
+		prefix += string(filepath.Separator)
+	}
+	return strings.HasPrefix(path, prefix)
+}
@@ -0,0 +1,153 @@
+package paths
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newTestRepo creates a repository root with a single challenge directory
+func newTestRepo(t *testing.T) *Resolver {
+	t.Helper()
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "challenge-1", "submissions"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	resolver, err := NewResolver(root)
+	if err != nil {
+		t.Fatalf("NewResolver: %v", err)
+	}
+	return resolver
+}
+
+func TestValidateUsername(t *testing.T) {
+	valid := []string{"a", "RezaSi", "go-pher", "a1-b2-c3", strings.Repeat("x", 39)}
+	for _, name := range valid {
+		if err := ValidateUsername(name); err != nil {
+			t.Errorf("ValidateUsername(%q) = %v, want nil", name, err)
+		}
+	}
+
+	invalid := []string{
+		"", "-gopher", "gopher-", "go--pher", "go_pher", "go.pher", "..", ".",
+		"../../web-ui", "a/b", `a\b`, "gopher ", "gö", strings.Repeat("x", 40), "a\x00b",
+	}
+	for _, name := range invalid {
+		if err := ValidateUsername(name); !errors.Is(err, ErrInvalidUsername) {
+			t.Errorf("ValidateUsername(%q) = %v, want ErrInvalidUsername", name, err)
+		}
+	}
+}
+
+func TestSubmissionFile(t *testing.T) {
+	r := newTestRepo(t)
+
+	got, err := r.SubmissionFile(1, "gopher")
+	if err != nil {
+		t.Fatalf("SubmissionFile: %v", err)
+	}
+	want := filepath.Join(r.Root(), "challenge-1", "submissions", "gopher", SolutionFileName)
+	if got != want {
+		t.Errorf("SubmissionFile = %q, want %q", got, want)
+	}
+	if rel := r.Rel(got); rel != "challenge-1/submissions/gopher/solution-template.go" {
+		t.Errorf("Rel = %q", rel)
+	}
+
+	if _, err := r.SubmissionFile(1, "../../web-ui"); !errors.Is(err, ErrInvalidUsername) {
+		t.Errorf("traversal username error = %v, want ErrInvalidUsername", err)
+	}
+	if _, err := r.SubmissionFile(0, "gopher"); !errors.Is(err, ErrInvalidChallengeID) {
+		t.Errorf("challenge 0 error = %v, want ErrInvalidChallengeID", err)
+	}
+}
+
+func TestSubmissionDirRejectsSymlinkEscape(t *testing.T) {
+	r := newTestRepo(t)
+	outside := t.TempDir()
+	submissions := filepath.Join(r.Root(), "challenge-1", "submissions")
+
+	// A user directory that links outside the repository
+	if err := os.Symlink(outside, filepath.Join(submissions, "escaper")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	if _, err := r.SubmissionFile(1, "escaper"); !errors.Is(err, ErrOutsideRoot) {
+		t.Errorf("symlinked user dir error = %v, want ErrOutsideRoot", err)
+	}
+
+	// A dangling link would be followed by MkdirAll
+	if err := os.Symlink(filepath.Join(outside, "missing"), filepath.Join(submissions, "dangler")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.SubmissionDir(1, "dangler"); !errors.Is(err, ErrOutsideRoot) {
+		t.Errorf("dangling link error = %v, want ErrOutsideRoot", err)
+	}
+
+	// A challenge directory that links outside the repository
+	if err := os.Symlink(outside, filepath.Join(r.Root(), "challenge-2")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.SubmissionDir(2, "gopher"); !errors.Is(err, ErrOutsideRoot) {
+		t.Errorf("symlinked challenge dir error = %v, want ErrOutsideRoot", err)
+	}
+
+	// Links that stay inside the root are fine
+	if err := os.Symlink(filepath.Join(r.Root(), "challenge-1"), filepath.Join(r.Root(), "challenge-3")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.SubmissionDir(3, "gopher"); err != nil {
+		t.Errorf("in-root symlink error = %v, want nil", err)
+	}
+}
+
+func FuzzValidateUsername(f *testing.F) {
+	for _, seed := range []string{"gopher", "go-pher", "../x", "", "-a", "a-", strings.Repeat("z", 40)} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, name string) {
+		if ValidateUsername(name) != nil {
+			return
+		}
+		// Anything accepted must be a single plain path element
+		if filepath.Base(name) != name || name == "." || name == ".." || strings.ContainsAny(name, `/\:`+"\x00") {
+			t.Errorf("ValidateUsername accepted unsafe name %q", name)
+		}
+		if len(name) > maxUsernameLength {
+			t.Errorf("ValidateUsername accepted %d-character name", len(name))
+		}
+	})
+}
+
+func FuzzSubmissionFile(f *testing.F) {
+	for _, seed := range []struct {
+		id   int
+		name string
+	}{{1, "gopher"}, {1, "../../etc"}, {-1, "a"}, {1, "..%2f"}, {42, "x"}} {
+		f.Add(seed.id, seed.name)
+	}
+
+	root := f.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "challenge-1", "submissions"), 0755); err != nil {
+		f.Fatal(err)
+	}
+	r, err := NewResolver(root)
+	if err != nil {
+		f.Fatal(err)
+	}
+
+	f.Fuzz(func(t *testing.T, id int, name string) {
+		path, err := r.SubmissionFile(id, name)
+		if err != nil {
+			return
+		}
+		rel, err := filepath.Rel(r.Root(), path)
+		if err != nil || strings.HasPrefix(rel, "..") || filepath.IsAbs(rel) {
+			t.Errorf("SubmissionFile(%d, %q) = %q escapes root %q", id, name, path, r.Root())
+		}
+		if filepath.Base(filepath.Dir(path)) != name {
+			t.Errorf("SubmissionFile(%d, %q) = %q does not end in the user directory", id, name, path)
+		}
+	})
+}
@@ -1,44 +1,138 @@
 package server
 
 import (
+	"context"
 	"embed"
+	"errors"
 	"io/fs"
-	"log"
+	"log/slog"
 	"net/http"
+	"runtime"
 	"strings"
+	"sync"
+	"time"
 
+	"web-ui/internal/audit"
+	"web-ui/internal/auth"
+	"web-ui/internal/collab"
+	"web-ui/internal/config"
+	"web-ui/internal/events"
 	"web-ui/internal/handlers"
+	"web-ui/internal/logging"
+	"web-ui/internal/metrics"
+	"web-ui/internal/paths"
 	"web-ui/internal/services"
+	"web-ui/internal/webhook"
 )
 
 // Server represents the web server with all its dependencies
 type Server struct {
 	content           embed.FS
+	config            *config.Config
+	paths             *paths.Resolver
 	challengeService  *services.ChallengeService
 	scoreboardService *services.ScoreboardService
 	userService       *services.UserService
 	executionService  *services.ExecutionService
+	submissionService *services.SubmissionService
+	profileService    *services.ProfileService
+	historyService    *services.HistoryService
+	leaderboard       *services.LeaderboardService
+	teamService       *services.TeamService
+	contestService    *services.ContestService
+	interviewService  *services.InterviewService
+	pairHub           *collab.Hub
+	draftService      *services.DraftService
+	solutionService   *services.SolutionService
+	reviewService     *services.ReviewService
+	similarityService *services.SimilarityService
+	sessions          *auth.SessionManager
+	authProviders     *auth.Registry
+	auditLog          *audit.Log
+	bus               *events.Bus
+	webhooks          *webhook.Dispatcher
+	deliveries        *webhook.DeliveryLog
+	healthHandler     *handlers.HealthHandler
+	metrics           *metrics.Registry
 }
 
 // NewServer creates a new server instance
 func NewServer(
 	content embed.FS,
+	cfg *config.Config,
+	resolver *paths.Resolver,
 	challengeService *services.ChallengeService,
 	scoreboardService *services.ScoreboardService,
+	historyService *services.HistoryService,
+	leaderboard *services.LeaderboardService,
+	teamService *services.TeamService,
+	contestService *services.ContestService,
+	interviewService *services.InterviewService,
+	pairHub *collab.Hub,
+	draftService *services.DraftService,
+	solutionService *services.SolutionService,
+	reviewService *services.ReviewService,
+	similarityService *services.SimilarityService,
 	userService *services.UserService,
 	executionService *services.ExecutionService,
+	sessions *auth.SessionManager,
+	authProviders *auth.Registry,
+	auditLog *audit.Log,
+	bus *events.Bus,
+	webhooks *webhook.Dispatcher,
+	deliveries *webhook.DeliveryLog,
 ) *Server {
-	return &Server{
+	s := &Server{
 		content:           content,
+		config:            cfg,
+		paths:             resolver,
 		challengeService:  challengeService,
 		scoreboardService: scoreboardService,
+		historyService:    historyService,
+		leaderboard:       leaderboard,
+		teamService:       teamService,
+		contestService:    contestService,
+		interviewService:  interviewService,
+		pairHub:           pairHub,
+		draftService:      draftService,
+		solutionService:   solutionService,
+		reviewService:     reviewService,
+		similarityService: similarityService,
 		userService:       userService,
 		executionService:  executionService,
+		sessions:          sessions,
+		authProviders:     authProviders,
+		auditLog:          auditLog,
+		bus:               bus,
+		webhooks:          webhooks,
+		deliveries:        deliveries,
+		submissionService: services.NewSubmissionService(),
+		healthHandler:     handlers.NewHealthHandler(challengeService, executionService),
 	}
+	s.profileService = services.NewProfileService(challengeService, scoreboardService, leaderboard, userService, s.submissionService, historyService)
+	if cfg.Features.Metrics {
+		s.setupMetrics()
+	}
+	return s
+}
+
+// setupMetrics creates the metrics registry and instruments the services
+func (s *Server) setupMetrics() {
+	s.metrics = metrics.NewRegistry()
+	s.executionService.RegisterMetrics(s.metrics)
+	s.userService.RegisterMetrics(s.metrics)
+
+	s.metrics.NewGaugeFunc("webui_challenges_loaded", "Challenges loaded from the repository.", func() float64 {
+		return float64(len(s.challengeService.GetChallenges()))
+	})
+	s.metrics.NewGaugeFunc("go_goroutines", "Number of goroutines that currently exist.", func() float64 {
+		return float64(runtime.NumGoroutine())
+	})
 }
 
-// SetupRoutes configures all HTTP routes
-func (s *Server) SetupRoutes() *http.ServeMux {
+// SetupRoutes configures all HTTP routes. Every unsafe request must carry a
+// valid CSRF token.
+func (s *Server) SetupRoutes() http.Handler {
 	mux := http.NewServeMux()
 
 	// Setup static file handling
@@ -50,6 +144,21 @@ func (s *Server) SetupRoutes() *http.ServeMux {
 		s.scoreboardService,
 		s.userService,
 		s.executionService,
+		s.submissionService,
+		s.profileService,
+		s.leaderboard,
+		s.teamService,
+		s.contestService,
+		s.interviewService,
+		s.pairHub,
+		s.draftService,
+		s.solutionService,
+		s.reviewService,
+		s.sessions,
+		s.auditLog,
+		s.bus,
+		s.config.Features,
+		s.paths.Root(),
 	)
 
 	webHandler := handlers.NewWebHandler(
@@ -57,34 +166,155 @@ func (s *Server) SetupRoutes() *http.ServeMux {
 		s.challengeService,
 		s.scoreboardService,
 		s.userService,
+		s.profileService,
+		s.leaderboard,
+		s.teamService,
+		s.contestService,
+		s.interviewService,
+		s.pairHub,
+		s.draftService,
+		s.solutionService,
+		s.sessions,
+		s.config.IsAdmin,
 	)
 
-	// API routes
-	mux.HandleFunc("/api/challenges", apiHandler.GetAllChallenges)
-	mux.HandleFunc("/api/challenges/", apiHandler.GetChallengeByID)
-	mux.HandleFunc("/api/submissions", apiHandler.HandleSubmissions)
-	mux.HandleFunc("/api/scoreboard/", apiHandler.GetScoreboard)
-	mux.HandleFunc("/api/run", apiHandler.RunCode)
-	mux.HandleFunc("/api/save-to-filesystem", apiHandler.SaveSubmissionToFilesystem)
-	mux.HandleFunc("/api/refresh-attempts", apiHandler.RefreshUserAttempts)
-	mux.HandleFunc("/api/git-username", apiHandler.GetGitUsername)
-	mux.HandleFunc("/api/main-scoreboard-rank", apiHandler.GetMainScoreboardRank)
-	mux.HandleFunc("/api/main-leaderboard", apiHandler.GetMainLeaderboard)
+	authHandler := handlers.NewAuthHandler(s.content, s.sessions, s.authProviders, s.config.Features.Registration)
+	feedHandler := handlers.NewFeedHandler(
+		services.NewFeedService(s.paths, s.challengeService, s.scoreboardService, s.historyService, s.userService),
+		s.config.PublicURL(),
+	)
+	badgeHandler := handlers.NewBadgeHandler(s.challengeService, s.scoreboardService, s.leaderboard)
+	adminHandler := handlers.NewAdminHandler(s.sessions, s.auditLog, s.teamService, s.contestService, s.similarityService, s.webhooks, s.deliveries, s.config.IsAdmin)
+
+	// Health routes
+	mux.HandleFunc("/healthz", s.healthHandler.Healthz)
+	mux.HandleFunc("/readyz", s.healthHandler.Readyz)
+
+	// Auth routes
+	mux.HandleFunc("/login", authHandler.LoginPage)
+	mux.HandleFunc("/auth/me", authHandler.Me)
+	mux.HandleFunc("/auth/login", authHandler.Login)
+	mux.HandleFunc("/auth/register", authHandler.Register)
+	mux.HandleFunc("/auth/logout", authHandler.Logout)
+	mux.HandleFunc("/auth/", authHandler.RedirectFlow)
+
+	// API routes: /api/v1 plus deprecated unversioned aliases, all described
+	// by the OpenAPI document generated from the same route table
+	routes := handlers.APIRoutes(apiHandler, adminHandler)
+	v1 := handlers.RegisterRoutes(mux, routes)
+	mux.HandleFunc("GET /api/openapi.json", handlers.ServeOpenAPI(handlers.OpenAPI(routes)))
 
 	// Web routes
 	mux.HandleFunc("/", webHandler.HomePage)
 	mux.HandleFunc("/challenge/", webHandler.ChallengePage)
+	mux.HandleFunc("GET /challenge/{id}/solutions", webHandler.SolutionsPage)
 	mux.HandleFunc("/scoreboard", webHandler.ScoreboardPage)
 	mux.HandleFunc("/scoreboard/", webHandler.ScoreChallengeHandler)
+	mux.HandleFunc("GET /user/{username}", webHandler.UserProfilePage)
+	mux.HandleFunc("GET /teams", webHandler.TeamsPage)
+	mux.HandleFunc("GET /teams/{slug}", webHandler.TeamPage)
+	mux.HandleFunc("GET /contests", webHandler.ContestsPage)
+	mux.HandleFunc("GET /contests/{slug}", webHandler.ContestPage)
+	mux.HandleFunc("GET /interviews", webHandler.InterviewsPage)
+	mux.HandleFunc("GET /interviews/{interview}", webHandler.InterviewReportPage)
+	mux.HandleFunc("GET /interviews/{interview}/workspace", webHandler.InterviewWorkspacePage)
+	mux.HandleFunc("GET /invite/{token}", webHandler.InvitePage)
+
+	// Atom and RSS feeds
+	for _, format := range []string{"atom", "rss"} {
+		mux.HandleFunc("GET /feeds/challenges."+format, feedHandler.ChallengesFeed)
+		mux.HandleFunc("GET /feeds/solves."+format, feedHandler.SolvesFeed)
+		mux.HandleFunc("GET /feeds/users/{username}/solves."+format, feedHandler.UserSolvesFeed)
+	}
+
+	// SVG badges; wildcards must span a whole segment, so the handlers strip
+	// the .svg suffix themselves
+	mux.HandleFunc("GET /badge/{file}", badgeHandler.UserBadge)
+	mux.HandleFunc("GET /badge/challenge/{file}", badgeHandler.ChallengeBadge)
+
+	// Pair-programming rooms sync over a WebSocket rather than JSON requests
+	mux.HandleFunc("GET /ws/pair/{room}", apiHandler.PairSocket)
+
+	var handler http.Handler = s.sessions.CSRFProtect(mux, handlers.CSRFFailure)
+	if s.metrics != nil {
+		mux.Handle("/metrics", s.metrics)
+		handler = metrics.InstrumentHandler(s.metrics, metrics.MuxRoutes(v1, mux), handler)
+	}
+	return logging.Middleware(slog.Default(), handler)
+}
 
-	return mux
+// Run serves HTTP until ctx is cancelled, then shuts down gracefully: readiness
+// starts failing, new connections are refused, and in-flight requests and test
+// runs get up to the configured shutdown timeout to finish.
+func (s *Server) Run(ctx context.Context) error {
+	httpServer := &http.Server{
+		Addr:              s.config.ListenAddr,
+		Handler:           s.SetupRoutes(),
+		ReadHeaderTimeout: time.Duration(s.config.Timeouts.Read),
+		ReadTimeout:       time.Duration(s.config.Timeouts.Read),
+		WriteTimeout:      time.Duration(s.config.Timeouts.Write),
+		IdleTimeout:       time.Duration(s.config.Timeouts.Idle),
+		ErrorLog:          slog.NewLogLogger(slog.Default().Handler(), slog.LevelWarn),
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		if s.config.TLSEnabled() {
+			slog.Info("server starting", "url", "https://"+s.config.ListenAddr)
+			serveErr <- httpServer.ListenAndServeTLS(s.config.TLSCertFile, s.config.TLSKeyFile)
+		} else {
+			slog.Info("server starting", "url", "http://"+s.config.ListenAddr)
+			serveErr <- httpServer.ListenAndServe()
+		}
+	}()
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	slog.Info("shutting down", "grace", time.Duration(s.config.Timeouts.Shutdown))
+	s.healthHandler.SetShuttingDown()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Duration(s.config.Timeouts.Shutdown))
+	defer cancel()
+
+	// Drain the execution queue while the HTTP server waits for its handlers;
+	// if the deadline passes, cancelled runs unblock their handlers.
+	var wg sync.WaitGroup
+	var drainErr error
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		drainErr = s.executionService.Shutdown(shutdownCtx)
+	}()
+
+	httpErr := httpServer.Shutdown(shutdownCtx)
+	wg.Wait()
+	if httpErr != nil {
+		httpServer.Close()
+	}
+
+	if err := <-serveErr; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	if drainErr != nil {
+		slog.Warn("cancelled test runs that did not finish in time", "err", drainErr)
+	}
+	if httpErr != nil {
+		return httpErr
+	}
+	slog.Info("server stopped")
+	return nil
 }
 
 // setupStaticFiles configures static file serving
 func (s *Server) setupStaticFiles(mux *http.ServeMux) {
 	fsys, err := fs.Sub(s.content, "static")
 	if err != nil {
-		log.Fatal(err)
+		// The static directory is embedded at build time, so this cannot fail at runtime
+		panic(err)
 	}
 
 	staticHandler := http.FileServer(http.FS(fsys))
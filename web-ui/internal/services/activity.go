@@ -0,0 +1,79 @@
+package services
+
+import (
+	"sort"
+	"time"
+)
+
+// dayKey truncates t to its calendar day in loc
+func dayKey(t time.Time, loc *time.Location) time.Time {
+	y, m, d := t.In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, loc)
+}
+
+// Streaks counts runs of consecutive active days in now's time zone. The
+// current streak ends today, or yesterday when there is no activity yet
+// today, so a streak is not broken before the day is over.
+func Streaks(times []time.Time, now time.Time) (current, longest int) {
+	loc := now.Location()
+	active := make(map[time.Time]bool, len(times))
+	for _, t := range times {
+		active[dayKey(t, loc)] = true
+	}
+	if len(active) == 0 {
+		return 0, 0
+	}
+
+	days := make([]time.Time, 0, len(active))
+	for day := range active {
+		days = append(days, day)
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+
+	run := 0
+	for i, day := range days {
+		// AddDate rather than 24h so daylight saving changes keep days adjacent
+		if i > 0 && days[i-1].AddDate(0, 0, 1).Equal(day) {
+			run++
+		} else {
+			run = 1
+		}
+		if run > longest {
+			longest = run
+		}
+	}
+
+	day := dayKey(now, loc)
+	if !active[day] {
+		day = day.AddDate(0, 0, -1)
+	}
+	for active[day] {
+		current++
+		day = day.AddDate(0, 0, -1)
+	}
+	return current, longest
+}
+
+// DayCount is the number of activity events on one calendar day
+type DayCount struct {
+	Date  string `json:"date" doc:"Calendar day as YYYY-MM-DD"`
+	Count int    `json:"count"`
+}
+
+// DailySeries counts events per day for the days days ending today in now's
+// time zone, oldest first, including days without events
+func DailySeries(times []time.Time, now time.Time, days int) []DayCount {
+	loc := now.Location()
+	counts := make(map[time.Time]int, len(times))
+	for _, t := range times {
+		counts[dayKey(t, loc)]++
+	}
+
+	series := make([]DayCount, 0, days)
+	today := dayKey(now, loc)
+	for offset := days - 1; offset >= 0; offset-- {
+		day := today.AddDate(0, 0, -offset)
+		series = append(series, DayCount{Date: day.Format("2006-01-02"), Count: counts[day]})
+	}
+	return series
+}
@@ -1,41 +1,50 @@
 package services
 
 import (
+	"errors"
 	"fmt"
 	"io/ioutil"
-	"log"
+	"log/slog"
 	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
 
 	"web-ui/internal/models"
+	"web-ui/internal/paths"
 )
 
 // ChallengeService handles challenge-related operations
 type ChallengeService struct {
-	challenges models.ChallengeMap
+	paths         *paths.Resolver
+	challenges    models.ChallengeMap
+	index         *challengeIndex
+	learningPaths map[string]*models.LearningPath
 }
 
 // NewChallengeService creates a new challenge service
-func NewChallengeService() *ChallengeService {
+func NewChallengeService(resolver *paths.Resolver) *ChallengeService {
 	return &ChallengeService{
-		challenges: make(models.ChallengeMap),
+		paths:         resolver,
+		challenges:    make(models.ChallengeMap),
+		learningPaths: make(map[string]*models.LearningPath),
 	}
 }
 
 // LoadChallenges loads all challenges from the filesystem
 func (cs *ChallengeService) LoadChallenges() error {
 	// Find challenge directories (challenge-1, challenge-2, etc.)
-	challengeDirs, err := filepath.Glob("../challenge-*")
+	challengeDirs, err := filepath.Glob(filepath.Join(cs.paths.Root(), "challenge-*"))
 	if err != nil {
 		return fmt.Errorf("failed to find challenge directories: %v", err)
 	}
 
+	var steps []pathStep
+	var problems []error
 	for _, dir := range challengeDirs {
 		// Extract challenge number
-		re := regexp.MustCompile(`challenge-(\d+)`)
-		match := re.FindStringSubmatch(dir)
+		re := regexp.MustCompile(`^challenge-(\d+)$`)
+		match := re.FindStringSubmatch(filepath.Base(dir))
 		if len(match) < 2 {
 			continue
 		}
@@ -45,16 +54,40 @@ func (cs *ChallengeService) LoadChallenges() error {
 			continue
 		}
 
+		// Reject directories that resolve outside the repository root
+		dir, err = cs.paths.ChallengeDir(id)
+		if err != nil {
+			slog.Warn("skipping challenge", "challenge", id, "err", err)
+			continue
+		}
+
 		challenge, err := cs.loadSingleChallenge(id, dir)
 		if err != nil {
-			log.Printf("Warning: Could not load challenge %d: %v", id, err)
+			slog.Warn("could not load challenge", "challenge", id, "err", err)
 			continue
 		}
 
+		// Broken metadata fails the load rather than silently dropping edges
+		meta, err := readChallengeMetadata(dir)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("challenge %d: %v", id, err))
+		}
+		challenge.Prerequisites = append([]int{}, meta.Prerequisites...)
+		challenge.Points = meta.Points
+		for _, path := range meta.Paths {
+			steps = append(steps, pathStep{path: path.Name, step: path.Step, challengeID: id})
+		}
+
 		cs.challenges[id] = challenge
 	}
+	cs.index = buildChallengeIndex(cs.challenges)
+	cs.learningPaths = buildLearningPaths(steps)
 
-	log.Printf("Loaded %d challenges", len(cs.challenges))
+	if err := errors.Join(append(problems, validateLearningGraph(cs.challenges, cs.learningPaths))...); err != nil {
+		return fmt.Errorf("invalid challenge metadata: %v", err)
+	}
+
+	slog.Info("loaded challenges", "count", len(cs.challenges))
 	return nil
 }
 
@@ -84,7 +117,7 @@ func (cs *ChallengeService) loadSingleChallenge(id int, dir string) (*models.Cha
 	testPath := filepath.Join(dir, "solution-template_test.go")
 	testContent, err := ioutil.ReadFile(testPath)
 	if err != nil {
-		log.Printf("Warning: Could not read test file for challenge %d: %v", id, err)
+		slog.Warn("could not read test file", "challenge", id, "err", err)
 	}
 
 	// Read learning materials if available
@@ -113,6 +146,11 @@ func (cs *ChallengeService) loadSingleChallenge(id int, dir string) (*models.Cha
 		Hints:             string(hintsContent),
 	}
 
+	// Tag from the optional tags.txt and the packages the challenge uses
+	tagsContent, _ := ioutil.ReadFile(filepath.Join(dir, "tags.txt"))
+	challenge.Tags = challengeTags(string(tagsContent), challengeImports(challenge.Template))
+	challenge.Topics = challengeTopics(challenge, challengeImports(challenge.Template, challenge.TestFile))
+
 	return challenge, nil
 }
 
@@ -0,0 +1,503 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"web-ui/internal/models"
+	"web-ui/internal/store"
+)
+
+// MaxContestProblems is the most challenges a contest can have, one per
+// problem letter
+const MaxContestProblems = 26
+
+var (
+	// ErrContestNotFound is returned for an unknown contest slug
+	ErrContestNotFound = errors.New("contest not found")
+	// ErrContestNotRunning is returned for a submission outside the contest's
+	// start and end times
+	ErrContestNotRunning = errors.New("contest is not running")
+	// ErrNotInContest is returned for a submission to a challenge that is not
+	// one of the contest's problems
+	ErrNotInContest = errors.New("challenge is not part of the contest")
+	// ErrInvalidContest is returned for a contest without a usable name, with
+	// unknown or repeated challenges, or with inconsistent times
+	ErrInvalidContest = errors.New("invalid contest")
+)
+
+// ContestProblem is one column of a contest scoreboard
+type ContestProblem struct {
+	Label       string `json:"label" doc:"Problem letter, A for the first challenge"`
+	ChallengeID int    `json:"challengeId"`
+	Title       string `json:"title"`
+	Solved      int    `json:"solved" doc:"Contestants who solved the problem"`
+	Attempts    int    `json:"attempts" doc:"Counted submissions to the problem"`
+}
+
+// ContestCell is one contestant's result on one problem
+type ContestCell struct {
+	ChallengeID  int  `json:"challengeId"`
+	Attempts     int  `json:"attempts" doc:"Counted submissions, including the accepted one"`
+	Solved       bool `json:"solved"`
+	SolvedMinute int  `json:"solvedMinute" doc:"Minutes from the start to the accepted submission"`
+	FirstSolve   bool `json:"firstSolve" doc:"No contestant solved the problem earlier"`
+	Pending      int  `json:"pending" doc:"Submissions made during the freeze whose results are hidden"`
+}
+
+// ContestRow is one contestant's line on a contest scoreboard
+type ContestRow struct {
+	Rank     int           `json:"rank" doc:"1-based position; contestants with equal solved counts and penalties share a rank"`
+	Username string        `json:"username"`
+	Solved   int           `json:"solved"`
+	Penalty  int           `json:"penalty" doc:"Sum over solved problems of the solve minute plus the penalty minutes for each earlier rejected submission"`
+	Cells    []ContestCell `json:"cells" doc:"One per problem, in problem order"`
+}
+
+// ContestScoreboard is the ICPC-style standings of a contest
+type ContestScoreboard struct {
+	Contest  models.Contest   `json:"contest"`
+	Status   string           `json:"status" enum:"upcoming,running,frozen,ended"`
+	Frozen   bool             `json:"frozen" doc:"Results of submissions made during the freeze are hidden"`
+	Problems []ContestProblem `json:"problems"`
+	Rows     []ContestRow     `json:"rows"`
+}
+
+// ContestService stores contests, which are managed through the admin API,
+// and the attempts entered in them. Contests are rewritten atomically on
+// every change; attempts are appended to a JSON lines file and never
+// modified.
+type ContestService struct {
+	challengeService *ChallengeService
+	contestsPath     string
+
+	mu       sync.RWMutex
+	contests map[string]*models.Contest
+	attempts []models.ContestAttempt
+	file     *os.File
+	held     []models.Submission // made during a freeze, until the contest ends
+}
+
+// NewContestService loads the contests at contestsPath and the attempts at
+// attemptsPath, opening the latter for appending. Missing files hold no
+// contests or attempts; empty paths keep them in memory only.
+func NewContestService(challengeService *ChallengeService, contestsPath, attemptsPath string) (*ContestService, error) {
+	cs := &ContestService{
+		challengeService: challengeService,
+		contestsPath:     contestsPath,
+		contests:         make(map[string]*models.Contest),
+	}
+	if err := cs.loadContests(); err != nil {
+		return nil, err
+	}
+	if attemptsPath != "" {
+		if err := cs.openAttempts(attemptsPath); err != nil {
+			return nil, err
+		}
+	}
+	return cs, nil
+}
+
+// loadContests reads the contest store
+func (cs *ContestService) loadContests() error {
+	if cs.contestsPath == "" {
+		return nil
+	}
+	content, err := os.ReadFile(cs.contestsPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read contests file: %v", err)
+	}
+
+	var contests []models.Contest
+	if err := json.Unmarshal(content, &contests); err != nil {
+		return fmt.Errorf("failed to parse %s: %v", cs.contestsPath, err)
+	}
+	for i := range contests {
+		contest := contests[i]
+		if contest.Slug == "" || cs.contests[contest.Slug] != nil {
+			return fmt.Errorf("%s: missing or duplicate contest slug %q", cs.contestsPath, contest.Slug)
+		}
+		cs.contests[contest.Slug] = &contest
+	}
+	return nil
+}
+
+// openAttempts reads the recorded attempts and opens the file for appending
+func (cs *ContestService) openAttempts(path string) error {
+	err := store.ScanFile(path, 0, func(attempt models.ContestAttempt) {
+		cs.attempts = append(cs.attempts, attempt)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read contest attempts: %v", err)
+	}
+	file, err := store.OpenLog(path)
+	if err != nil {
+		return fmt.Errorf("failed to open contest attempts: %v", err)
+	}
+	cs.file = file
+	return nil
+}
+
+// Close closes the attempts file
+func (cs *ContestService) Close() error {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	if cs.file == nil {
+		return nil
+	}
+	err := cs.file.Close()
+	cs.file = nil
+	return err
+}
+
+// ContestSlug returns the slug a contest with the given name is addressed by
+func ContestSlug(name string) string {
+	return pathSlug(strings.TrimSpace(name))
+}
+
+// Contests returns every contest, most recent start first
+func (cs *ContestService) Contests() []models.Contest {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+
+	contests := make([]models.Contest, 0, len(cs.contests))
+	for _, contest := range cs.contests {
+		contests = append(contests, copyContest(contest))
+	}
+	sort.Slice(contests, func(i, j int) bool {
+		if !contests[i].StartsAt.Equal(contests[j].StartsAt) {
+			return contests[i].StartsAt.After(contests[j].StartsAt)
+		}
+		return contests[i].Slug < contests[j].Slug
+	})
+	return contests
+}
+
+// Contest returns the contest with the given slug
+func (cs *ContestService) Contest(slug string) (models.Contest, bool) {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+
+	contest, ok := cs.contests[slug]
+	if !ok {
+		return models.Contest{}, false
+	}
+	return copyContest(contest), true
+}
+
+// SaveContest validates contest, derives its slug from its name, creates or
+// replaces it and persists the store. It reports whether the contest is new.
+func (cs *ContestService) SaveContest(contest models.Contest) (models.Contest, bool, error) {
+	contest.Name = strings.TrimSpace(contest.Name)
+	contest.Slug = ContestSlug(contest.Name)
+	if err := cs.validate(contest); err != nil {
+		return models.Contest{}, false, err
+	}
+	contest.ChallengeIDs = append([]int{}, contest.ChallengeIDs...)
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	previous, exists := cs.contests[contest.Slug]
+	cs.contests[contest.Slug] = &contest
+	if err := cs.save(); err != nil {
+		if exists {
+			cs.contests[contest.Slug] = previous
+		} else {
+			delete(cs.contests, contest.Slug)
+		}
+		return models.Contest{}, false, err
+	}
+	return copyContest(&contest), !exists, nil
+}
+
+// validate checks a contest before it is saved
+func (cs *ContestService) validate(contest models.Contest) error {
+	if contest.Slug == "" {
+		return fmt.Errorf("%w: name %q has no letters or digits", ErrInvalidContest, contest.Name)
+	}
+	if len(contest.ChallengeIDs) == 0 || len(contest.ChallengeIDs) > MaxContestProblems {
+		return fmt.Errorf("%w: a contest needs between 1 and %d challenges", ErrInvalidContest, MaxContestProblems)
+	}
+	seen := make(map[int]bool)
+	for _, id := range contest.ChallengeIDs {
+		if _, ok := cs.challengeService.GetChallenge(id); !ok {
+			return fmt.Errorf("%w: unknown challenge %d", ErrInvalidContest, id)
+		}
+		if seen[id] {
+			return fmt.Errorf("%w: challenge %d is listed twice", ErrInvalidContest, id)
+		}
+		seen[id] = true
+	}
+	if contest.StartsAt.IsZero() || !contest.EndsAt.After(contest.StartsAt) {
+		return fmt.Errorf("%w: the end time must be after the start time", ErrInvalidContest)
+	}
+	if contest.FreezeMinutes < 0 || contest.FreezesAt().Before(contest.StartsAt) {
+		return fmt.Errorf("%w: the freeze must be between 0 minutes and the contest's length", ErrInvalidContest)
+	}
+	if contest.PenaltyMinutes < 0 {
+		return fmt.Errorf("%w: penalty minutes cannot be negative", ErrInvalidContest)
+	}
+	return nil
+}
+
+// DeleteContest removes a contest and persists the store. Its recorded
+// attempts stay in the attempts file but no longer count anywhere.
+func (cs *ContestService) DeleteContest(slug string) error {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	contest, ok := cs.contests[slug]
+	if !ok {
+		return ErrContestNotFound
+	}
+	delete(cs.contests, slug)
+	if err := cs.save(); err != nil {
+		cs.contests[slug] = contest
+		return err
+	}
+	return nil
+}
+
+// save writes the contests atomically. Callers must hold cs.mu.
+func (cs *ContestService) save() error {
+	if cs.contestsPath == "" {
+		return nil
+	}
+
+	contests := make([]models.Contest, 0, len(cs.contests))
+	for _, contest := range cs.contests {
+		contests = append(contests, *contest)
+	}
+	sort.Slice(contests, func(i, j int) bool { return contests[i].Slug < contests[j].Slug })
+
+	content, err := json.MarshalIndent(contests, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := store.WriteFile(cs.contestsPath, content); err != nil {
+		return fmt.Errorf("failed to write contests file: %v", err)
+	}
+	return nil
+}
+
+// CheckSubmission returns the contest a submission made at the given time
+// would be entered in, or the reason it cannot be
+func (cs *ContestService) CheckSubmission(slug string, challengeID int, at time.Time) (models.Contest, error) {
+	contest, ok := cs.Contest(slug)
+	if !ok {
+		return models.Contest{}, ErrContestNotFound
+	}
+	if status := contest.Status(at); status == models.ContestUpcoming || status == models.ContestEnded {
+		return models.Contest{}, ErrContestNotRunning
+	}
+	if !contest.HasChallenge(challengeID) {
+		return models.Contest{}, ErrNotInContest
+	}
+	return contest, nil
+}
+
+// Record appends an attempt. The attempt counts even when it cannot be
+// written to the attempts file, in which case the error is returned.
+func (cs *ContestService) Record(attempt models.ContestAttempt) error {
+	attempt.SubmittedAt = attempt.SubmittedAt.UTC()
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	cs.attempts = append(cs.attempts, attempt)
+	if cs.file == nil {
+		return nil
+	}
+	if err := store.Append(cs.file, attempt); err != nil {
+		return fmt.Errorf("failed to write contest attempt: %v", err)
+	}
+	return nil
+}
+
+// Hold keeps back a submission made during its contest's freeze, so that
+// its result reaches the public scoreboards and events only when the
+// contest scoreboard reveals it. It reports whether the submission was held.
+// Like scoreboard updates, held submissions are kept in memory only.
+func (cs *ContestService) Hold(submission models.Submission) bool {
+	if submission.Contest == "" {
+		return false
+	}
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	contest, ok := cs.contests[submission.Contest]
+	if !ok || contest.Status(submission.SubmittedAt) != models.ContestFrozen {
+		return false
+	}
+	cs.held = append(cs.held, submission)
+	return true
+}
+
+// Release stops holding the submissions whose contest has ended at now, or
+// was deleted, and returns them oldest first
+func (cs *ContestService) Release(now time.Time) []models.Submission {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	var released []models.Submission
+	kept := cs.held[:0]
+	for _, submission := range cs.held {
+		if contest, ok := cs.contests[submission.Contest]; ok && contest.Status(now) != models.ContestEnded {
+			kept = append(kept, submission)
+		} else {
+			released = append(released, submission)
+		}
+	}
+	cs.held = kept
+	return released
+}
+
+// WatchReleases checks every interval until ctx ends and calls fn with each
+// held submission once its contest has ended
+func (cs *ContestService) WatchReleases(ctx context.Context, interval time.Duration, fn func(models.Submission)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			for _, submission := range cs.Release(now) {
+				fn(submission)
+			}
+		}
+	}
+}
+
+// Attempts returns the attempts entered in a contest during its start and
+// end times, oldest first
+func (cs *ContestService) Attempts(slug string) []models.ContestAttempt {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+
+	contest, ok := cs.contests[slug]
+	if !ok {
+		return nil
+	}
+	var attempts []models.ContestAttempt
+	for _, attempt := range cs.attempts {
+		if attempt.Contest == slug && contest.HasChallenge(attempt.ChallengeID) &&
+			!attempt.SubmittedAt.Before(contest.StartsAt) && attempt.SubmittedAt.Before(contest.EndsAt) {
+			attempts = append(attempts, attempt)
+		}
+	}
+	sort.SliceStable(attempts, func(i, j int) bool { return attempts[i].SubmittedAt.Before(attempts[j].SubmittedAt) })
+	return attempts
+}
+
+// Scoreboard computes a contest's standings at now. The public view hides
+// the results of submissions made during the freeze until the contest ends;
+// the live view, for administrators, always shows every result.
+func (cs *ContestService) Scoreboard(slug string, now time.Time, live bool) (ContestScoreboard, error) {
+	contest, ok := cs.Contest(slug)
+	if !ok {
+		return ContestScoreboard{}, ErrContestNotFound
+	}
+	status := contest.Status(now)
+	board := ContestScoreboard{
+		Contest:  contest,
+		Status:   status,
+		Frozen:   !live && status == models.ContestFrozen,
+		Problems: make([]ContestProblem, len(contest.ChallengeIDs)),
+		Rows:     []ContestRow{},
+	}
+
+	column := make(map[int]int)
+	for i, id := range contest.ChallengeIDs {
+		column[id] = i
+		board.Problems[i] = ContestProblem{Label: problemLabel(i), ChallengeID: id}
+		if challenge, ok := cs.challengeService.GetChallenge(id); ok {
+			board.Problems[i].Title = challenge.Title
+		}
+	}
+
+	rows := make(map[string]*ContestRow)
+	freezesAt := contest.FreezesAt()
+	for _, attempt := range cs.Attempts(slug) {
+		key := strings.ToLower(attempt.Username)
+		row, ok := rows[key]
+		if !ok {
+			row = &ContestRow{Username: attempt.Username, Cells: make([]ContestCell, len(contest.ChallengeIDs))}
+			for i, id := range contest.ChallengeIDs {
+				row.Cells[i].ChallengeID = id
+			}
+			rows[key] = row
+		}
+
+		i := column[attempt.ChallengeID]
+		cell := &row.Cells[i]
+		if cell.Solved {
+			continue
+		}
+		if board.Frozen && !attempt.SubmittedAt.Before(freezesAt) {
+			cell.Pending++
+			continue
+		}
+		cell.Attempts++
+		board.Problems[i].Attempts++
+		if attempt.Passed {
+			cell.Solved = true
+			cell.SolvedMinute = int(attempt.SubmittedAt.Sub(contest.StartsAt) / time.Minute)
+			row.Solved++
+			row.Penalty += cell.SolvedMinute + contest.PenaltyMinutes*(cell.Attempts-1)
+			board.Problems[i].Solved++
+			// Attempts arrive oldest first, so the first accepted one wins
+			cell.FirstSolve = board.Problems[i].Solved == 1
+		}
+	}
+
+	for _, row := range rows {
+		board.Rows = append(board.Rows, *row)
+	}
+	rankContestRows(board.Rows)
+	return board, nil
+}
+
+// rankContestRows orders rows by problems solved, then penalty, sharing
+// ranks between equal rows
+func rankContestRows(rows []ContestRow) {
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Solved != rows[j].Solved {
+			return rows[i].Solved > rows[j].Solved
+		}
+		if rows[i].Penalty != rows[j].Penalty {
+			return rows[i].Penalty < rows[j].Penalty
+		}
+		return strings.ToLower(rows[i].Username) < strings.ToLower(rows[j].Username)
+	})
+	for i := range rows {
+		if i > 0 && rows[i].Solved == rows[i-1].Solved && rows[i].Penalty == rows[i-1].Penalty {
+			rows[i].Rank = rows[i-1].Rank
+		} else {
+			rows[i].Rank = i + 1
+		}
+	}
+}
+
+// problemLabel returns the letter of the i-th problem
+func problemLabel(i int) string {
+	return string(rune('A' + i))
+}
+
+func copyContest(contest *models.Contest) models.Contest {
+	copied := *contest
+	copied.ChallengeIDs = append([]int{}, contest.ChallengeIDs...)
+	return copied
+}
@@ -0,0 +1,198 @@
+package services
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"web-ui/internal/models"
+)
+
+func newTestContestService(t *testing.T, contestsPath, attemptsPath string) *ContestService {
+	t.Helper()
+	root := t.TempDir()
+	for id := 1; id <= 3; id++ {
+		writeChallenge(t, root, id, "")
+	}
+	challengeService := NewChallengeService(mustResolver(t, root))
+	if err := challengeService.LoadChallenges(); err != nil {
+		t.Fatal(err)
+	}
+	contests, err := NewContestService(challengeService, contestsPath, attemptsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { contests.Close() })
+	return contests
+}
+
+func TestContestService(t *testing.T) {
+	dir := t.TempDir()
+	contestsPath := filepath.Join(dir, "contests.json")
+	attemptsPath := filepath.Join(dir, "contest-attempts.jsonl")
+	contests := newTestContestService(t, contestsPath, attemptsPath)
+
+	start := time.Date(2026, 3, 1, 18, 0, 0, 0, time.UTC)
+	valid := models.Contest{Name: "March Cup", ChallengeIDs: []int{2, 1}, StartsAt: start, EndsAt: start.Add(2 * time.Hour), FreezeMinutes: 30}
+
+	invalid := map[string]func(*models.Contest){
+		"no name":          func(c *models.Contest) { c.Name = "!" },
+		"no challenges":    func(c *models.Contest) { c.ChallengeIDs = nil },
+		"unknown":          func(c *models.Contest) { c.ChallengeIDs = []int{1, 9} },
+		"repeated":         func(c *models.Contest) { c.ChallengeIDs = []int{1, 1} },
+		"ends before":      func(c *models.Contest) { c.EndsAt = c.StartsAt },
+		"freeze too long":  func(c *models.Contest) { c.FreezeMinutes = 121 },
+		"negative penalty": func(c *models.Contest) { c.PenaltyMinutes = -1 },
+	}
+	for name, mutate := range invalid {
+		contest := valid
+		mutate(&contest)
+		if _, _, err := contests.SaveContest(contest); !errors.Is(err, ErrInvalidContest) {
+			t.Errorf("SaveContest with %s: err = %v, want ErrInvalidContest", name, err)
+		}
+	}
+
+	saved, created, err := contests.SaveContest(valid)
+	if err != nil || !created || saved.Slug != "march-cup" {
+		t.Fatalf("SaveContest = %+v, %v, %v", saved, created, err)
+	}
+	if _, created, _ := contests.SaveContest(valid); created {
+		t.Error("replacing a contest reported it as created")
+	}
+
+	checks := []struct {
+		challengeID int
+		at          time.Time
+		want        error
+	}{
+		{1, start.Add(-time.Second), ErrContestNotRunning},
+		{1, start.Add(2 * time.Hour), ErrContestNotRunning},
+		{3, start, ErrNotInContest},
+		{2, start.Add(time.Hour), nil},
+	}
+	for _, check := range checks {
+		if _, err := contests.CheckSubmission("march-cup", check.challengeID, check.at); !errors.Is(err, check.want) {
+			t.Errorf("CheckSubmission(%d, %v) = %v, want %v", check.challengeID, check.at, err, check.want)
+		}
+	}
+	if _, err := contests.CheckSubmission("april-cup", 1, start); !errors.Is(err, ErrContestNotFound) {
+		t.Errorf("CheckSubmission(april-cup) = %v, want ErrContestNotFound", err)
+	}
+
+	if err := contests.Record(models.ContestAttempt{Contest: "march-cup", Username: "alice", ChallengeID: 1, Passed: true, SubmittedAt: start.Add(time.Minute)}); err != nil {
+		t.Fatal(err)
+	}
+	contests.Close()
+
+	// Contests and attempts survive a restart, including after a torn write
+	file, err := os.OpenFile(attemptsPath, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.WriteString(`{"contest": "march-cup", "user`)
+	file.Close()
+
+	reloaded := newTestContestService(t, contestsPath, attemptsPath)
+	if contest, ok := reloaded.Contest("march-cup"); !ok || contest.FreezeMinutes != 30 || len(contest.ChallengeIDs) != 2 {
+		t.Fatalf("reloaded Contest(march-cup) = %+v, %v", contest, ok)
+	}
+	if err := reloaded.Record(models.ContestAttempt{Contest: "march-cup", Username: "bob", ChallengeID: 2, SubmittedAt: start.Add(2 * time.Minute)}); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(reloaded.Attempts("march-cup")); got != 2 {
+		t.Errorf("reloaded %d attempts, want 2", got)
+	}
+
+	if err := reloaded.DeleteContest("march-cup"); err != nil {
+		t.Fatal(err)
+	}
+	if err := reloaded.DeleteContest("march-cup"); !errors.Is(err, ErrContestNotFound) {
+		t.Errorf("second DeleteContest = %v, want ErrContestNotFound", err)
+	}
+}
+
+func TestContestScoreboard(t *testing.T) {
+	contests := newTestContestService(t, "", "")
+	start := time.Date(2026, 3, 1, 18, 0, 0, 0, time.UTC)
+	if _, _, err := contests.SaveContest(models.Contest{
+		Name: "March Cup", ChallengeIDs: []int{2, 1}, StartsAt: start, EndsAt: start.Add(2 * time.Hour),
+		FreezeMinutes: 60, PenaltyMinutes: 20,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	attempt := func(username string, challengeID int, minute int, passed bool) {
+		contests.Record(models.ContestAttempt{
+			Contest: "march-cup", Username: username, ChallengeID: challengeID, Passed: passed,
+			SubmittedAt: start.Add(time.Duration(minute)*time.Minute + 30*time.Second),
+		})
+	}
+	attempt("alice", 2, 10, false)
+	attempt("alice", 2, 25, true) // 25 + 20 penalty
+	attempt("alice", 2, 30, false)
+	attempt("bob", 1, 40, true)
+	attempt("bob", 2, 45, true)   // 40 + 45
+	attempt("carol", 1, 70, true) // during the freeze
+	attempt("alice", 1, 90, false)
+	attempt("alice", 1, 91, true) // 91 + 20 during the freeze
+	attempt("dave", 1, -5, true)  // before the start
+	attempt("dave", 3, 20, true)  // not a contest problem
+	contests.Record(models.ContestAttempt{Contest: "other", Username: "erin", ChallengeID: 1, SubmittedAt: start})
+
+	frozenAt := start.Add(100 * time.Minute)
+	public, err := contests.Scoreboard("march-cup", frozenAt, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if public.Status != models.ContestFrozen || !public.Frozen {
+		t.Fatalf("public status = %s frozen %v, want a frozen scoreboard", public.Status, public.Frozen)
+	}
+	if len(public.Rows) != 3 {
+		t.Fatalf("public rows = %+v, want alice, bob and carol", public.Rows)
+	}
+	bob, alice, carol := public.Rows[0], public.Rows[1], public.Rows[2]
+	if bob.Username != "bob" || bob.Rank != 1 || bob.Solved != 2 || bob.Penalty != 85 {
+		t.Errorf("first = %+v, want bob rank 1 with 2 solved and 85 penalty", bob)
+	}
+	if alice.Username != "alice" || alice.Rank != 2 || alice.Solved != 1 || alice.Penalty != 45 {
+		t.Errorf("second = %+v, want alice rank 2 with 1 solved and 45 penalty", alice)
+	}
+	if cell := alice.Cells[1]; cell.ChallengeID != 1 || cell.Attempts != 0 || cell.Pending != 2 || cell.Solved {
+		t.Errorf("alice's frozen cell = %+v, want 2 pending submissions", cell)
+	}
+	if cell := alice.Cells[0]; !cell.FirstSolve || cell.Attempts != 2 || cell.SolvedMinute != 25 {
+		t.Errorf("alice's problem A = %+v, want a first solve at minute 25 on the second attempt", cell)
+	}
+	if carol.Rank != 3 || carol.Solved != 0 || carol.Cells[1].Pending != 1 {
+		t.Errorf("third = %+v, want carol with a pending submission", carol)
+	}
+	if problem := public.Problems[0]; problem.Label != "A" || problem.ChallengeID != 2 || problem.Solved != 2 || problem.Attempts != 3 {
+		t.Errorf("problem A = %+v, want challenge 2 solved twice in 3 attempts", problem)
+	}
+
+	live, err := contests.Scoreboard("march-cup", frozenAt, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if live.Frozen || live.Rows[1].Username != "alice" || live.Rows[1].Solved != 2 || live.Rows[1].Penalty != 156 {
+		t.Errorf("live second = %+v, want alice with 2 solved and 156 penalty", live.Rows[1])
+	}
+	if carol := live.Rows[2]; carol.Rank != 3 || carol.Solved != 1 || carol.Penalty != 70 || carol.Cells[1].Pending != 0 {
+		t.Errorf("live third = %+v, want carol with 1 solved and 70 penalty", carol)
+	}
+
+	// The freeze lifts when the contest ends
+	ended, err := contests.Scoreboard("march-cup", start.Add(3*time.Hour), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ended.Status != models.ContestEnded || ended.Frozen || ended.Rows[1].Penalty != 156 {
+		t.Errorf("ended scoreboard = %s frozen %v rows %+v, want alice's freeze solve revealed", ended.Status, ended.Frozen, ended.Rows)
+	}
+
+	if _, err := contests.Scoreboard("april-cup", frozenAt, false); !errors.Is(err, ErrContestNotFound) {
+		t.Errorf("Scoreboard(april-cup) = %v, want ErrContestNotFound", err)
+	}
+}
@@ -0,0 +1,433 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"web-ui/internal/diff"
+	"web-ui/internal/models"
+	"web-ui/internal/paths"
+	"web-ui/internal/store"
+)
+
+// Draft limits
+const (
+	MaxDraftBytes       = 256 << 10
+	MaxDraftVersions    = 200
+	maxDraftLabelLength = 100
+	// autosaveWindow is how long autosaves keep folding into the same
+	// version, so a burst of typing becomes one version rather than dozens
+	autosaveWindow = 10 * time.Minute
+)
+
+var (
+	// ErrDraftNotFound is returned for a version or draft that does not exist
+	ErrDraftNotFound = errors.New("draft not found")
+	// ErrInvalidDraft is returned for code or a label over the limits
+	ErrInvalidDraft = errors.New("invalid draft")
+)
+
+// DraftSummary describes one challenge a user has drafts for
+type DraftSummary struct {
+	ChallengeID   int       `json:"challengeId"`
+	Title         string    `json:"title"`
+	Versions      int       `json:"versions"`
+	LatestVersion int       `json:"latestVersion"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+// DraftDiff compares two versions of a draft
+type DraftDiff struct {
+	ChallengeID int    `json:"challengeId"`
+	From        int    `json:"from"`
+	To          int    `json:"to"`
+	Added       int    `json:"added" doc:"Lines only in the to version"`
+	Removed     int    `json:"removed" doc:"Lines only in the from version"`
+	Unified     string `json:"unified" doc:"Unified diff from one version to the other, empty when they are equal"`
+}
+
+// DraftService keeps versions of users' code per challenge. A user's
+// versions of a challenge are appended to <dir>/<username>/<challengeId>.jsonl,
+// where a later record with a version's number replaces the earlier one; the
+// file is rewritten atomically once replaced records pile up or versions are
+// dropped. An empty dir keeps everything in memory.
+type DraftService struct {
+	challengeService *ChallengeService
+	dir              string
+
+	mu     sync.Mutex
+	drafts map[draftKey][]models.DraftVersion // memory only
+}
+
+type draftKey struct {
+	username    string
+	challengeID int
+}
+
+// NewDraftService creates a draft store in dir
+func NewDraftService(challengeService *ChallengeService, dir string) *DraftService {
+	return &DraftService{
+		challengeService: challengeService,
+		dir:              dir,
+		drafts:           make(map[draftKey][]models.DraftVersion),
+	}
+}
+
+// Save records code as the user's latest version of a challenge. Code equal
+// to the latest version only adds a label to it, if it has none. An
+// unlabeled autosave within a few minutes of a previous one replaces it.
+func (ds *DraftService) Save(username string, challengeID int, code, label string, autosave bool, now time.Time) (models.DraftVersion, error) {
+	label = strings.TrimSpace(label)
+	if len(label) > maxDraftLabelLength {
+		return models.DraftVersion{}, fmt.Errorf("%w: the label is longer than %d characters", ErrInvalidDraft, maxDraftLabelLength)
+	}
+	source := models.DraftSourceManual
+	if autosave {
+		source = models.DraftSourceAutosave
+	}
+	return ds.record(draftKey{username, challengeID}, models.DraftVersion{Code: code, Label: label, Source: source}, now)
+}
+
+// SaveSolution records code the user saved to the repository as their
+// solution. Only these versions can be reviewed by teammates.
+func (ds *DraftService) SaveSolution(username string, challengeID int, code string, now time.Time) (models.DraftVersion, error) {
+	return ds.record(draftKey{username, challengeID}, models.DraftVersion{Code: code, Source: models.DraftSourceSaved}, now)
+}
+
+// Snapshot records code a user is about to lose, such as a solution file
+// about to be overwritten, and returns the version that holds it
+func (ds *DraftService) Snapshot(username string, challengeID int, code string, now time.Time) (models.DraftVersion, error) {
+	return ds.record(draftKey{username, challengeID}, models.DraftVersion{Code: code, Source: models.DraftSourceFilesystem}, now)
+}
+
+// Restore makes a copy of an earlier version the latest one
+func (ds *DraftService) Restore(username string, challengeID, version int, now time.Time) (models.DraftVersion, error) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+
+	key := draftKey{username, challengeID}
+	versions, records, err := ds.load(key)
+	if err != nil {
+		return models.DraftVersion{}, err
+	}
+	source, ok := findVersion(versions, version)
+	if !ok {
+		return models.DraftVersion{}, ErrDraftNotFound
+	}
+	restored := models.DraftVersion{
+		Version:      versions[len(versions)-1].Version + 1,
+		ChallengeID:  challengeID,
+		Source:       models.DraftSourceRestore,
+		RestoredFrom: source.Version,
+		CreatedAt:    now.UTC(),
+		UpdatedAt:    now.UTC(),
+		Code:         source.Code,
+	}
+	return restored, ds.store(key, versions, records, restored)
+}
+
+// Latest returns the user's latest version of a challenge
+func (ds *DraftService) Latest(username string, challengeID int) (models.DraftVersion, error) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+
+	versions, _, err := ds.load(draftKey{username, challengeID})
+	if err != nil {
+		return models.DraftVersion{}, err
+	}
+	if len(versions) == 0 {
+		return models.DraftVersion{}, ErrDraftNotFound
+	}
+	return versions[len(versions)-1], nil
+}
+
+// LatestSaved returns the user's latest solution saved to the repository,
+// skipping private drafts, autosaves and restores
+func (ds *DraftService) LatestSaved(username string, challengeID int) (models.DraftVersion, error) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+
+	versions, _, err := ds.load(draftKey{username, challengeID})
+	if err != nil {
+		return models.DraftVersion{}, err
+	}
+	for i := len(versions) - 1; i >= 0; i-- {
+		if versions[i].Source == models.DraftSourceSaved {
+			return versions[i], nil
+		}
+	}
+	return models.DraftVersion{}, ErrDraftNotFound
+}
+
+// Versions lists the user's versions of a challenge, newest first
+func (ds *DraftService) Versions(username string, challengeID int) ([]models.DraftVersion, error) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+
+	versions, _, err := ds.load(draftKey{username, challengeID})
+	if err != nil {
+		return nil, err
+	}
+	newest := make([]models.DraftVersion, len(versions))
+	for i, v := range versions {
+		newest[len(versions)-1-i] = v
+	}
+	return newest, nil
+}
+
+// Version returns one of the user's versions of a challenge
+func (ds *DraftService) Version(username string, challengeID, version int) (models.DraftVersion, error) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+
+	versions, _, err := ds.load(draftKey{username, challengeID})
+	if err != nil {
+		return models.DraftVersion{}, err
+	}
+	v, ok := findVersion(versions, version)
+	if !ok {
+		return models.DraftVersion{}, ErrDraftNotFound
+	}
+	return v, nil
+}
+
+// Diff compares two of the user's versions of a challenge
+func (ds *DraftService) Diff(username string, challengeID, from, to int) (DraftDiff, error) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+
+	versions, _, err := ds.load(draftKey{username, challengeID})
+	if err != nil {
+		return DraftDiff{}, err
+	}
+	a, ok := findVersion(versions, from)
+	if !ok {
+		return DraftDiff{}, fmt.Errorf("%w: version %d", ErrDraftNotFound, from)
+	}
+	b, ok := findVersion(versions, to)
+	if !ok {
+		return DraftDiff{}, fmt.Errorf("%w: version %d", ErrDraftNotFound, to)
+	}
+	result := diff.Compare("v"+strconv.Itoa(from), "v"+strconv.Itoa(to), a.Code, b.Code)
+	return DraftDiff{
+		ChallengeID: challengeID,
+		From:        from,
+		To:          to,
+		Added:       result.Added,
+		Removed:     result.Removed,
+		Unified:     result.Unified,
+	}, nil
+}
+
+// Drafts lists the challenges the user has drafts for, most recently
+// updated first
+func (ds *DraftService) Drafts(username string) ([]DraftSummary, error) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+
+	var ids []int
+	if ds.dir == "" {
+		for key := range ds.drafts {
+			if key.username == username {
+				ids = append(ids, key.challengeID)
+			}
+		}
+	} else {
+		if err := paths.ValidateUsername(username); err != nil {
+			return nil, err
+		}
+		files, err := filepath.Glob(filepath.Join(ds.dir, username, "*.jsonl"))
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			if id, err := strconv.Atoi(strings.TrimSuffix(filepath.Base(file), ".jsonl")); err == nil {
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	summaries := []DraftSummary{}
+	for _, id := range ids {
+		versions, _, err := ds.load(draftKey{username, id})
+		if err != nil {
+			return nil, err
+		}
+		if len(versions) == 0 {
+			continue
+		}
+		latest := versions[len(versions)-1]
+		summary := DraftSummary{
+			ChallengeID:   id,
+			Versions:      len(versions),
+			LatestVersion: latest.Version,
+			UpdatedAt:     latest.UpdatedAt,
+		}
+		if challenge, ok := ds.challengeService.GetChallenge(id); ok {
+			summary.Title = challenge.Title
+		}
+		summaries = append(summaries, summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].UpdatedAt.After(summaries[j].UpdatedAt) })
+	return summaries, nil
+}
+
+// record adds draft as the latest version unless it repeats the latest
+// version's code, or folds it into the latest version when both are
+// unlabeled autosaves
+func (ds *DraftService) record(key draftKey, draft models.DraftVersion, now time.Time) (models.DraftVersion, error) {
+	if len(draft.Code) > MaxDraftBytes {
+		return models.DraftVersion{}, fmt.Errorf("%w: code is larger than %d bytes", ErrInvalidDraft, MaxDraftBytes)
+	}
+
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+
+	versions, records, err := ds.load(key)
+	if err != nil {
+		return models.DraftVersion{}, err
+	}
+	now = now.UTC()
+	draft.ChallengeID = key.challengeID
+	draft.CreatedAt, draft.UpdatedAt = now, now
+	draft.Version = 1
+	if len(versions) > 0 {
+		latest := versions[len(versions)-1]
+		draft.Version = latest.Version + 1
+		switch {
+		case draft.Source == models.DraftSourceSaved && latest.Source != models.DraftSourceSaved:
+			// A saved solution is a version of its own even when a private
+			// draft already holds the same code
+		case latest.Code == draft.Code && (draft.Label == "" || latest.Label != ""):
+			return latest, nil
+		case latest.Code == draft.Code:
+			latest.Label = draft.Label
+			draft = latest
+		case draft.Source == models.DraftSourceAutosave && draft.Label == "" &&
+			latest.Source == models.DraftSourceAutosave && latest.Label == "" &&
+			now.Sub(latest.CreatedAt) < autosaveWindow:
+			latest.Code, latest.UpdatedAt = draft.Code, now
+			draft = latest
+		}
+	}
+	return draft, ds.store(key, versions, records, draft)
+}
+
+// store adds or replaces a version, dropping the oldest versions over
+// MaxDraftVersions. Callers must hold ds.mu.
+func (ds *DraftService) store(key draftKey, versions []models.DraftVersion, records int, draft models.DraftVersion) error {
+	if n := len(versions); n > 0 && versions[n-1].Version == draft.Version {
+		versions[n-1] = draft
+	} else {
+		versions = append(versions, draft)
+	}
+	trimmed := len(versions) > MaxDraftVersions
+	if trimmed {
+		versions = trimVersions(versions)
+	}
+
+	if ds.dir == "" {
+		ds.drafts[key] = versions
+		return nil
+	}
+	if trimmed || records+1 > 2*len(versions)+20 {
+		return ds.rewrite(key, versions)
+	}
+	return ds.append(key, draft)
+}
+
+// trimVersions drops the oldest unlabeled version, or the oldest version
+// when all are labeled
+func trimVersions(versions []models.DraftVersion) []models.DraftVersion {
+	drop := 0
+	for i, v := range versions[:len(versions)-1] {
+		if v.Label == "" {
+			drop = i
+			break
+		}
+	}
+	return append(versions[:drop:drop], versions[drop+1:]...)
+}
+
+// load returns a draft's versions, oldest first, and how many records its
+// file holds. Callers must hold ds.mu.
+func (ds *DraftService) load(key draftKey) ([]models.DraftVersion, int, error) {
+	if ds.dir == "" {
+		return append([]models.DraftVersion{}, ds.drafts[key]...), 0, nil
+	}
+
+	path, err := ds.path(key)
+	if err != nil {
+		return nil, 0, err
+	}
+	var versions []models.DraftVersion
+	records := 0
+	err = store.ScanFile(path, 8*MaxDraftBytes, func(v models.DraftVersion) {
+		records++
+		if n := len(versions); n > 0 && versions[n-1].Version == v.Version {
+			versions[n-1] = v
+		} else {
+			versions = append(versions, v)
+		}
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read drafts: %v", err)
+	}
+	return versions, records, nil
+}
+
+// append adds a version record to a draft's file. Callers must hold ds.mu.
+func (ds *DraftService) append(key draftKey, draft models.DraftVersion) error {
+	path, err := ds.path(key)
+	if err != nil {
+		return err
+	}
+	if err := store.AppendFile(path, draft); err != nil {
+		return fmt.Errorf("failed to write draft: %v", err)
+	}
+	return nil
+}
+
+// rewrite replaces a draft's file with one record per version. Callers must
+// hold ds.mu.
+func (ds *DraftService) rewrite(key draftKey, versions []models.DraftVersion) error {
+	path, err := ds.path(key)
+	if err != nil {
+		return err
+	}
+	var content []byte
+	for _, v := range versions {
+		line, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		content = append(append(content, line...), '\n')
+	}
+	if err := store.WriteFile(path, content); err != nil {
+		return fmt.Errorf("failed to write drafts: %v", err)
+	}
+	return nil
+}
+
+func (ds *DraftService) path(key draftKey) (string, error) {
+	if err := paths.ValidateUsername(key.username); err != nil {
+		return "", err
+	}
+	return filepath.Join(ds.dir, key.username, strconv.Itoa(key.challengeID)+".jsonl"), nil
+}
+
+func findVersion(versions []models.DraftVersion, version int) (models.DraftVersion, bool) {
+	for _, v := range versions {
+		if v.Version == version {
+			return v, true
+		}
+	}
+	return models.DraftVersion{}, false
+}
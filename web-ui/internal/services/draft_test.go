@@ -0,0 +1,179 @@
+package services
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"web-ui/internal/models"
+)
+
+func newTestDraftService(t *testing.T, dir string) *DraftService {
+	t.Helper()
+	root := t.TempDir()
+	for id := 1; id <= 2; id++ {
+		writeChallenge(t, root, id, "")
+	}
+	challengeService := NewChallengeService(mustResolver(t, root))
+	if err := challengeService.LoadChallenges(); err != nil {
+		t.Fatal(err)
+	}
+	return NewDraftService(challengeService, dir)
+}
+
+func TestDraftService(t *testing.T) {
+	for name, dir := range map[string]string{"memory": "", "disk": filepath.Join(t.TempDir(), "drafts")} {
+		t.Run(name, func(t *testing.T) {
+			drafts := newTestDraftService(t, dir)
+			start := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+
+			if _, err := drafts.Latest("alice", 1); !errors.Is(err, ErrDraftNotFound) {
+				t.Fatalf("Latest without drafts = %v, want ErrDraftNotFound", err)
+			}
+
+			// Autosaves within the window fold into one version
+			v1, err := drafts.Save("alice", 1, "a\n", "", true, start)
+			if err != nil {
+				t.Fatal(err)
+			}
+			folded, err := drafts.Save("alice", 1, "a\nb\n", "", true, start.Add(time.Minute))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if v1.Version != 1 || folded.Version != 1 || folded.Code != "a\nb\n" || !folded.UpdatedAt.Equal(start.Add(time.Minute)) {
+				t.Fatalf("autosaves = %+v, %+v, want both version 1 with the later code", v1, folded)
+			}
+			v2, err := drafts.Save("alice", 1, "a\nb\nc\n", "", true, start.Add(autosaveWindow))
+			if err != nil || v2.Version != 2 {
+				t.Fatalf("autosave after the window = %+v, %v, want version 2", v2, err)
+			}
+
+			// Unchanged code only labels the latest version
+			labeled, err := drafts.Save("alice", 1, "a\nb\nc\n", " before refactor ", false, start.Add(11*time.Minute))
+			if err != nil || labeled.Version != 2 || labeled.Label != "before refactor" {
+				t.Fatalf("labeling = %+v, %v, want version 2 labeled", labeled, err)
+			}
+			if again, _ := drafts.Save("alice", 1, "a\nb\nc\n", "", false, start.Add(12*time.Minute)); again.Version != 2 {
+				t.Errorf("saving unchanged code made version %d", again.Version)
+			}
+			v3, err := drafts.Save("alice", 1, "x\n", "", true, start.Add(13*time.Minute))
+			if err != nil || v3.Version != 3 {
+				t.Fatalf("autosave after a labeled version = %+v, %v, want version 3", v3, err)
+			}
+
+			restored, err := drafts.Restore("alice", 1, 1, start.Add(14*time.Minute))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if restored.Version != 4 || restored.RestoredFrom != 1 || restored.Source != models.DraftSourceRestore || restored.Code != "a\nb\n" {
+				t.Errorf("Restore = %+v, want version 4 copying version 1", restored)
+			}
+			if _, err := drafts.Restore("alice", 1, 9, start); !errors.Is(err, ErrDraftNotFound) {
+				t.Errorf("Restore of a missing version = %v, want ErrDraftNotFound", err)
+			}
+
+			d, err := drafts.Diff("alice", 1, 1, 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d.Added != 1 || d.Removed != 0 || !strings.Contains(d.Unified, "--- v1\n+++ v2\n") || !strings.Contains(d.Unified, "+c\n") {
+				t.Errorf("Diff = %+v", d)
+			}
+			if _, err := drafts.Diff("alice", 1, 1, 7); !errors.Is(err, ErrDraftNotFound) {
+				t.Errorf("Diff with a missing version = %v, want ErrDraftNotFound", err)
+			}
+
+			versions, err := drafts.Versions("alice", 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(versions) != 4 || versions[0].Version != 4 || versions[3].Version != 1 {
+				t.Errorf("Versions = %+v, want 4 versions newest first", versions)
+			}
+			if v, err := drafts.Version("alice", 1, 2); err != nil || v.Label != "before refactor" {
+				t.Errorf("Version 2 = %+v, %v", v, err)
+			}
+
+			if _, err := drafts.Snapshot("alice", 2, "old\n", start); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := drafts.Save("bob", 1, "bob's\n", "", false, start); err != nil {
+				t.Fatal(err)
+			}
+			summaries, err := drafts.Drafts("alice")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(summaries) != 2 || summaries[0].ChallengeID != 1 || summaries[0].Versions != 4 || summaries[0].Title == "" || summaries[1].ChallengeID != 2 {
+				t.Errorf("Drafts = %+v, want challenge 1 then 2", summaries)
+			}
+
+			if _, err := drafts.Save("alice", 1, strings.Repeat("x", MaxDraftBytes+1), "", false, start); !errors.Is(err, ErrInvalidDraft) {
+				t.Errorf("Save of oversized code = %v, want ErrInvalidDraft", err)
+			}
+			if _, err := drafts.Save("alice", 1, "y", strings.Repeat("l", maxDraftLabelLength+1), false, start); !errors.Is(err, ErrInvalidDraft) {
+				t.Errorf("Save with a long label = %v, want ErrInvalidDraft", err)
+			}
+		})
+	}
+}
+
+func TestDraftServicePersistence(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "drafts")
+	drafts := newTestDraftService(t, dir)
+	start := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+
+	// Many folded autosaves get compacted into one record per version
+	for i := 0; i < 50; i++ {
+		if _, err := drafts.Save("alice", 1, strings.Repeat("a\n", i+1), "", true, start.Add(time.Duration(i)*time.Second)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := filepath.Join(dir, "alice", "1.jsonl")
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(string(content), "\n"); lines > 22 {
+		t.Errorf("draft file has %d records for one version, want it compacted", lines)
+	}
+
+	// A torn final line is skipped and terminated before the next record
+	if err := os.WriteFile(path, append(content, `{"version":2,"co`...), 0600); err != nil {
+		t.Fatal(err)
+	}
+	reopened := newTestDraftService(t, dir)
+	latest, err := reopened.Latest("alice", 1)
+	if err != nil || latest.Version != 1 || latest.Code != strings.Repeat("a\n", 50) {
+		t.Fatalf("Latest after reopening = %+v, %v", latest, err)
+	}
+	if _, err := reopened.Save("alice", 1, "b\n", "", false, start.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if versions, err := reopened.Versions("alice", 1); err != nil || len(versions) != 2 || versions[0].Code != "b\n" {
+		t.Errorf("Versions after a torn line = %+v, %v", versions, err)
+	}
+
+	// The oldest unlabeled versions are dropped over the limit
+	if _, err := reopened.Save("alice", 2, "keep\n", "first", false, start); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < MaxDraftVersions; i++ {
+		if _, err := reopened.Save("alice", 2, strings.Repeat("c", i+1), "", false, start.Add(time.Duration(i)*time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	versions, err := reopened.Versions("alice", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != MaxDraftVersions || versions[len(versions)-1].Label != "first" || versions[len(versions)-2].Version != 3 {
+		t.Errorf("after %d saves: %d versions, oldest %+v", MaxDraftVersions+1, len(versions), versions[len(versions)-1])
+	}
+	if _, err := drafts.Save("../x", 1, "a", "", false, start); err == nil {
+		t.Error("Save for an invalid username succeeded")
+	}
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"web-ui/internal/events"
+	"web-ui/internal/models"
+	"web-ui/internal/store"
+)
+
+// RankChanges compares the standings before and after a change and returns
+// a change for every user whose rank moved, including users who joined or
+// left the leaderboard, best new rank first
+func RankChanges(before, after []Standing) []events.RankChange {
+	previous := make(map[string]int, len(before))
+	for _, standing := range before {
+		previous[standing.Username] = standing.Rank
+	}
+
+	var changes []events.RankChange
+	for _, standing := range after {
+		if rank := previous[standing.Username]; rank != standing.Rank {
+			changes = append(changes, events.RankChange{Username: standing.Username, PreviousRank: rank, Rank: standing.Rank, Points: standing.Points})
+		}
+		delete(previous, standing.Username)
+	}
+	var left []string
+	for username := range previous {
+		left = append(left, username)
+	}
+	sort.Strings(left)
+	for _, username := range left {
+		changes = append(changes, events.RankChange{Username: username, PreviousRank: previous[username]})
+	}
+	return changes
+}
+
+// SubmissionEvent returns the type and payload of the event published for a
+// judged submission to challenge
+func SubmissionEvent(submission models.Submission, challenge *models.Challenge) (string, events.Submission) {
+	eventType := events.SubmissionFailed
+	if submission.Passed {
+		eventType = events.SubmissionPassed
+	}
+	return eventType, events.Submission{
+		Username:       submission.Username,
+		ChallengeID:    challenge.ID,
+		ChallengeTitle: challenge.Title,
+		Contest:        submission.Contest,
+		ExecutionMs:    submission.ExecutionMs,
+		SubmittedAt:    submission.SubmittedAt,
+	}
+}
+
+// WatchRanks checks the standings every interval until ctx ends and calls fn
+// with each rank change since the previous check. Standings move when the
+// SCOREBOARD.md files are updated or, with the decay model, as solves age.
+func (ls *LeaderboardService) WatchRanks(ctx context.Context, interval time.Duration, fn func(events.RankChange)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	previous := ls.Standings(time.Now())
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			current := ls.Standings(now)
+			for _, change := range RankChanges(previous, current) {
+				fn(change)
+			}
+			previous = current
+		}
+	}
+}
+
+// NewChallenges returns the challenges not recorded in the state file at
+// statePath, by ID, and records them. The first run, without a state file,
+// only records the challenges, so that a fresh install does not announce
+// every challenge at once. An empty statePath has no memory of earlier runs
+// and reports nothing.
+func NewChallenges(challenges models.ChallengeMap, statePath string) ([]events.Challenge, error) {
+	if statePath == "" {
+		return nil, nil
+	}
+
+	var known []int
+	content, err := os.ReadFile(statePath)
+	firstRun := errors.Is(err, os.ErrNotExist)
+	if err != nil && !firstRun {
+		return nil, fmt.Errorf("failed to read known challenges: %v", err)
+	}
+	if !firstRun {
+		if err := json.Unmarshal(content, &known); err != nil {
+			return nil, fmt.Errorf("failed to parse known challenges: %v", err)
+		}
+	}
+	seen := make(map[int]bool, len(known))
+	for _, id := range known {
+		seen[id] = true
+	}
+
+	ids := make([]int, 0, len(challenges))
+	for id := range challenges {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	var added []events.Challenge
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		known = append(known, id)
+		if !firstRun {
+			challenge := challenges[id]
+			added = append(added, events.Challenge{ChallengeID: id, Title: challenge.Title, Difficulty: challenge.Difficulty})
+		}
+	}
+	if len(added) == 0 && !firstRun {
+		return nil, nil
+	}
+
+	sort.Ints(known)
+	content, err = json.Marshal(known)
+	if err != nil {
+		return nil, err
+	}
+	if err := store.WriteFile(statePath, content); err != nil {
+		return nil, fmt.Errorf("failed to write known challenges: %v", err)
+	}
+	return added, nil
+}
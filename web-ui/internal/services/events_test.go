@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"web-ui/internal/events"
+	"web-ui/internal/models"
+	"web-ui/internal/paths"
+)
+
+func TestRankChanges(t *testing.T) {
+	before := []Standing{
+		{Username: "alice", Points: 30, Rank: 1},
+		{Username: "bob", Points: 20, Rank: 2},
+		{Username: "carol", Points: 10, Rank: 3},
+	}
+	after := []Standing{
+		{Username: "bob", Points: 40, Rank: 1},
+		{Username: "alice", Points: 30, Rank: 2},
+		{Username: "dave", Points: 15, Rank: 3},
+	}
+	want := []events.RankChange{
+		{Username: "bob", PreviousRank: 2, Rank: 1, Points: 40},
+		{Username: "alice", PreviousRank: 1, Rank: 2, Points: 30},
+		{Username: "dave", PreviousRank: 0, Rank: 3, Points: 15},
+		{Username: "carol", PreviousRank: 3, Rank: 0},
+	}
+	if got := RankChanges(before, after); !reflect.DeepEqual(got, want) {
+		t.Errorf("RankChanges = %+v, want %+v", got, want)
+	}
+	if got := RankChanges(after, after); len(got) != 0 {
+		t.Errorf("RankChanges without moves = %+v", got)
+	}
+}
+
+func TestWatchRanks(t *testing.T) {
+	root := t.TempDir()
+	writeChallenge(t, root, 1, "")
+	scoreboard := filepath.Join(root, "challenge-1", paths.ScoreboardFileName)
+	header := "| Username | Passed Tests | Total Tests |\n|------|------|------|\n"
+	writeFile(t, scoreboard, header+"| alice | 2 | 2 |\n")
+
+	resolver := mustResolver(t, root)
+	challengeService := NewChallengeService(resolver)
+	if err := challengeService.LoadChallenges(); err != nil {
+		t.Fatal(err)
+	}
+	leaderboard := NewLeaderboardService(challengeService, NewScoreboardService(resolver), ScorerFunc(completionPoints), nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes := make(chan events.RankChange, 4)
+	go leaderboard.WatchRanks(ctx, 5*time.Millisecond, func(change events.RankChange) { changes <- change })
+	time.Sleep(20 * time.Millisecond)
+
+	// bob joins the leaderboard when the scoreboard is next updated
+	if err := os.WriteFile(scoreboard, []byte(header+"| alice | 2 | 2 |\n| bob | 2 | 2 |\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case change := <-changes:
+		if change.Username != "bob" || change.PreviousRank != 0 || change.Rank != 1 {
+			t.Errorf("change = %+v, want bob entering tied first", change)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no rank change was reported")
+	}
+}
+
+func TestNewChallenges(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "known-challenges.json")
+	challenges := models.ChallengeMap{
+		1: {ID: 1, Title: "Sum", Difficulty: "Beginner"},
+		2: {ID: 2, Title: "Reverse", Difficulty: "Beginner"},
+	}
+
+	// The first run only records what exists
+	if added, err := NewChallenges(challenges, statePath); err != nil || len(added) != 0 {
+		t.Fatalf("first run = %+v, %v, want nothing announced", added, err)
+	}
+
+	challenges[5] = &models.Challenge{ID: 5, Title: "Worker pool", Difficulty: "Advanced"}
+	added, err := NewChallenges(challenges, statePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []events.Challenge{{ChallengeID: 5, Title: "Worker pool", Difficulty: "Advanced"}}; !reflect.DeepEqual(added, want) {
+		t.Errorf("added = %+v, want %+v", added, want)
+	}
+	if added, err := NewChallenges(challenges, statePath); err != nil || len(added) != 0 {
+		t.Errorf("rerun = %+v, %v, want nothing new", added, err)
+	}
+
+	if added, err := NewChallenges(challenges, ""); err != nil || added != nil {
+		t.Errorf("without state = %+v, %v", added, err)
+	}
+}
@@ -1,34 +1,233 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io/ioutil"
+	"log/slog"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"web-ui/internal/metrics"
 	"web-ui/internal/models"
+	"web-ui/internal/paths"
 )
 
+// ExecutionLimits bounds the resources a test run may use
+type ExecutionLimits struct {
+	Timeout        time.Duration // maximum wall-clock time of a run, including dependency installs
+	MaxConcurrent  int           // maximum number of runs executing at once
+	MaxOutputBytes int           // test output beyond this is truncated
+}
+
+// ErrShuttingDown is returned for runs submitted after Shutdown has begun
+var ErrShuttingDown = errors.New("server is shutting down")
+
 // ExecutionService handles code execution and testing
-type ExecutionService struct{}
+type ExecutionService struct {
+	paths  *paths.Resolver
+	limits ExecutionLimits
+	slots  chan struct{}
+
+	// baseCtx is cancelled to abort every run when draining times out
+	baseCtx context.Context
+	cancel  context.CancelFunc
+
+	mu       sync.Mutex
+	draining bool
+	runs     sync.WaitGroup
+	queued   atomic.Int64
+
+	runsStarted   *metrics.CounterVec
+	runsCompleted *metrics.CounterVec
+	runDuration   *metrics.HistogramVec
+
+	toolchainMu      sync.Mutex
+	toolchainVersion string
+	toolchainErr     error
+	toolchainChecked time.Time
+}
 
 // NewExecutionService creates a new execution service
-func NewExecutionService() *ExecutionService {
-	return &ExecutionService{}
+func NewExecutionService(resolver *paths.Resolver, limits ExecutionLimits) *ExecutionService {
+	baseCtx, cancel := context.WithCancel(context.Background())
+	return &ExecutionService{
+		paths:   resolver,
+		limits:  limits,
+		slots:   make(chan struct{}, limits.MaxConcurrent),
+		baseCtx: baseCtx,
+		cancel:  cancel,
+	}
+}
+
+// Shutdown stops accepting new runs and waits for queued and in-flight runs
+// to finish. If ctx expires first, remaining runs are cancelled (which kills
+// their go processes and removes their temp dirs) before Shutdown returns.
+func (es *ExecutionService) Shutdown(ctx context.Context) error {
+	es.mu.Lock()
+	es.draining = true
+	es.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		es.runs.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		es.cancel()
+		return nil
+	case <-ctx.Done():
+		es.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// RegisterMetrics exposes run counters, queue depth and run durations
+func (es *ExecutionService) RegisterMetrics(reg *metrics.Registry) {
+	es.runsStarted = reg.NewCounterVec("webui_runs_started_total",
+		"Test runs that acquired an execution slot, by challenge.", "challenge")
+	es.runsCompleted = reg.NewCounterVec("webui_runs_completed_total",
+		"Finished test runs by challenge and outcome (passed, failed, timed_out).", "challenge", "outcome")
+	es.runDuration = reg.NewHistogramVec("webui_run_duration_seconds",
+		"Wall-clock duration of test runs, including dependency installs.", metrics.RunBuckets, "challenge")
+	reg.NewGaugeFunc("webui_run_queue_depth",
+		"Test runs waiting for a free execution slot.", func() float64 { return float64(es.queued.Load()) })
+	reg.NewGaugeFunc("webui_runs_in_flight",
+		"Test runs currently executing.", func() float64 { return float64(len(es.slots)) })
+	reg.NewGaugeFunc("webui_run_slots",
+		"Maximum number of concurrent test runs.", func() float64 { return float64(cap(es.slots)) })
+}
+
+// IsDraining reports whether Shutdown has been called
+func (es *ExecutionService) IsDraining() bool {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	return es.draining
+}
+
+// CheckToolchain reports the installed Go version, caching the result briefly
+func (es *ExecutionService) CheckToolchain(ctx context.Context) (string, error) {
+	es.toolchainMu.Lock()
+	defer es.toolchainMu.Unlock()
+
+	if time.Since(es.toolchainChecked) < 30*time.Second {
+		return es.toolchainVersion, es.toolchainErr
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, "go", "version").Output()
+
+	es.toolchainVersion = strings.TrimSpace(string(output))
+	es.toolchainErr = err
+	es.toolchainChecked = time.Now()
+	return es.toolchainVersion, es.toolchainErr
+}
+
+// acquire registers a run and waits for a free execution slot
+func (es *ExecutionService) acquire(ctx context.Context) error {
+	es.mu.Lock()
+	if es.draining {
+		es.mu.Unlock()
+		return ErrShuttingDown
+	}
+	es.runs.Add(1)
+	es.mu.Unlock()
+
+	es.queued.Add(1)
+	defer es.queued.Add(-1)
+
+	select {
+	case es.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		es.runs.Done()
+		return ctx.Err()
+	case <-es.baseCtx.Done():
+		es.runs.Done()
+		return ErrShuttingDown
+	}
+}
+
+// release frees the slot taken by acquire
+func (es *ExecutionService) release() {
+	<-es.slots
+	es.runs.Done()
 }
 
 // ExecutionResult represents the result of code execution
 type ExecutionResult struct {
-	Passed      bool   `json:"passed"`
-	Output      string `json:"output"`
-	ExecutionMs int64  `json:"executionMs"`
+	Passed      bool   `json:"passed" doc:"Whether go test exited successfully"`
+	Output      string `json:"output" doc:"Combined go test output, truncated to the configured limit"`
+	ExecutionMs int64  `json:"executionMs" doc:"Wall-clock run time in milliseconds"`
+	TimedOut    bool   `json:"timedOut,omitempty" doc:"Set when the run exceeded the time limit"`
+}
+
+// RunCode executes the provided code against a challenge's tests. Runs beyond
+// the concurrency limit wait for a free slot; an error is returned only if the
+// run never started because ctx was cancelled or the service is shutting down.
+func (es *ExecutionService) RunCode(ctx context.Context, code string, challenge *models.Challenge) (ExecutionResult, error) {
+	return es.run(ctx, code, challenge, "-v")
+}
+
+// benchmarkTime is how long each benchmark runs, kept short so that a
+// challenge's benchmarks fit within the run time limit
+const benchmarkTime = "200ms"
+
+// RunBenchmarks runs a challenge's benchmarks against the code without its
+// tests. Runs queue and fail like RunCode.
+func (es *ExecutionService) RunBenchmarks(ctx context.Context, code string, challenge *models.Challenge) (ExecutionResult, error) {
+	return es.run(ctx, code, challenge, "-run", "^$", "-bench", ".", "-benchmem", "-benchtime", benchmarkTime)
 }
 
-// RunCode executes the provided code against a challenge's tests
-func (es *ExecutionService) RunCode(code string, challenge *models.Challenge) ExecutionResult {
+// run waits for a slot and runs go test with args on the code
+func (es *ExecutionService) run(ctx context.Context, code string, challenge *models.Challenge, args ...string) (ExecutionResult, error) {
+	if err := es.acquire(ctx); err != nil {
+		return ExecutionResult{}, err
+	}
+	defer es.release()
+
+	ctx, cancel := context.WithTimeout(ctx, es.limits.Timeout)
+	defer cancel()
+	stop := context.AfterFunc(es.baseCtx, cancel)
+	defer stop()
+
+	label := strconv.Itoa(challenge.ID)
+	es.runsStarted.Inc(label)
+	start := time.Now()
+
+	result := es.runTests(ctx, code, challenge, args)
+
+	es.runDuration.Observe(time.Since(start).Seconds(), label)
+	es.runsCompleted.Inc(label, runOutcome(result))
+	return result, nil
+}
+
+// runOutcome classifies a result for metrics
+func runOutcome(result ExecutionResult) string {
+	switch {
+	case result.TimedOut:
+		return "timed_out"
+	case result.Passed:
+		return "passed"
+	default:
+		return "failed"
+	}
+}
+
+// runTests writes the code and tests to a temp dir and runs go test there
+// with args
+func (es *ExecutionService) runTests(ctx context.Context, code string, challenge *models.Challenge, args []string) ExecutionResult {
 	start := time.Now()
 
 	// Create temporary directory for execution
@@ -62,7 +261,7 @@ func (es *ExecutionService) RunCode(code string, challenge *models.Challenge) Ex
 	}
 
 	// Initialize Go module
-	err = es.initGoModule(tempDir, challenge.ID)
+	err = es.initGoModule(ctx, tempDir, challenge.ID)
 	if err != nil {
 		return ExecutionResult{
 			Passed: false,
@@ -71,27 +270,38 @@ func (es *ExecutionService) RunCode(code string, challenge *models.Challenge) Ex
 	}
 
 	// Automatically detect and install dependencies based on imports
-	err = es.installDependencies(tempDir, code, challenge.ID)
+	err = es.installDependencies(ctx, tempDir, code, challenge.ID)
 	if err != nil {
 		return ExecutionResult{
-			Passed: false,
-			Output: fmt.Sprintf("Failed to install dependencies: %v", err),
+			Passed:   false,
+			Output:   fmt.Sprintf("Failed to install dependencies: %v", err),
+			TimedOut: errors.Is(ctx.Err(), context.DeadlineExceeded),
 		}
 	}
 
 	// Run tests
-	cmd := exec.Command("go", "test", "-v")
+	cmd := exec.CommandContext(ctx, "go", append([]string{"test"}, args...)...)
 	cmd.Dir = tempDir
 
 	output, err := cmd.CombinedOutput()
 	executionTime := time.Since(start).Milliseconds()
-	outputStr := string(output)
+	outputStr := es.truncateOutput(output)
 
 	result := ExecutionResult{
 		Output:      outputStr,
 		ExecutionMs: executionTime,
 	}
 
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		result.TimedOut = true
+		result.Output = fmt.Sprintf("%s\nTest run exceeded the %s time limit", outputStr, es.limits.Timeout)
+		return result
+	}
+	if ctx.Err() != nil {
+		result.Output = fmt.Sprintf("%s\nTest run was cancelled", outputStr)
+		return result
+	}
+
 	if err == nil {
 		result.Passed = true
 	} else {
@@ -109,16 +319,24 @@ func (es *ExecutionService) RunCode(code string, challenge *models.Challenge) Ex
 	return result
 }
 
+// truncateOutput caps test output at the configured size
+func (es *ExecutionService) truncateOutput(output []byte) string {
+	if len(output) <= es.limits.MaxOutputBytes {
+		return string(output)
+	}
+	return string(output[:es.limits.MaxOutputBytes]) + fmt.Sprintf("\n... output truncated (%d bytes omitted)", len(output)-es.limits.MaxOutputBytes)
+}
+
 // initGoModule initializes a Go module in the temporary directory
-func (es *ExecutionService) initGoModule(tempDir string, challengeID int) error {
+func (es *ExecutionService) initGoModule(ctx context.Context, tempDir string, challengeID int) error {
 	// Initialize go.mod
-	cmd := exec.Command("go", "mod", "init", fmt.Sprintf("challenge-%d", challengeID))
+	cmd := exec.CommandContext(ctx, "go", "mod", "init", fmt.Sprintf("challenge-%d", challengeID))
 	cmd.Dir = tempDir
 	return cmd.Run()
 }
 
 // installDependencies installs dependencies for the given challenge
-func (es *ExecutionService) installDependencies(tempDir string, code string, challengeID int) error {
+func (es *ExecutionService) installDependencies(ctx context.Context, tempDir string, code string, challengeID int) error {
 	// Detect imports from the code
 	requiredPackages := es.detectRequiredPackages(code, challengeID)
 
@@ -128,8 +346,8 @@ func (es *ExecutionService) installDependencies(tempDir string, code string, cha
 
 	// Install each required package
 	for _, pkg := range requiredPackages {
-		fmt.Printf("Installing dependency: %s\n", pkg)
-		cmd := exec.Command("go", "get", pkg)
+		slog.InfoContext(ctx, "installing dependency", "challenge", challengeID, "package", pkg)
+		cmd := exec.CommandContext(ctx, "go", "get", pkg)
 		cmd.Dir = tempDir
 
 		output, err := cmd.CombinedOutput()
@@ -139,7 +357,7 @@ func (es *ExecutionService) installDependencies(tempDir string, code string, cha
 	}
 
 	// Run go mod tidy to clean up dependencies
-	tidyCmd := exec.Command("go", "mod", "tidy")
+	tidyCmd := exec.CommandContext(ctx, "go", "mod", "tidy")
 	tidyCmd.Dir = tempDir
 	tidyCmd.Run() // Ignore errors for tidy
 
@@ -286,61 +504,46 @@ type SaveSubmissionRequest struct {
 type SaveSubmissionResponse struct {
 	Success     bool     `json:"success"`
 	Message     string   `json:"message"`
-	FilePath    string   `json:"filePath"`
-	GitCommands []string `json:"gitCommands"`
+	FilePath    string   `json:"filePath" doc:"Absolute path of the saved file"`
+	GitCommands []string `json:"gitCommands" nullable:"true" doc:"Commands that commit and push the solution, null on failure"`
+	// PreviousDraftVersion is set by the handlers, which keep drafts
+	PreviousDraftVersion int `json:"previousDraftVersion,omitempty" doc:"Draft version holding the solution file this save overwrote"`
 }
 
 // SaveSubmissionToFilesystem saves a user's submission to the filesystem
-func (es *ExecutionService) SaveSubmissionToFilesystem(request SaveSubmissionRequest) SaveSubmissionResponse {
-	// Get working directory for correct relative paths
-	workDir, _ := os.Getwd()
-
-	// Try different path approaches to handle potential path issues
-	var submissionDir string
-	var fileSaved bool
-
-	// Try multiple path options to ensure it works in different environments
-	pathOptions := []string{
-		// Option 1: From web-ui directory (standard case)
-		filepath.Join("..", fmt.Sprintf("challenge-%d", request.ChallengeID), "submissions", request.Username),
-		// Option 2: From root workspace
-		filepath.Join(fmt.Sprintf("challenge-%d", request.ChallengeID), "submissions", request.Username),
-		// Option 3: Absolute path from detected workspace root
-		filepath.Join(workDir, "..", fmt.Sprintf("challenge-%d", request.ChallengeID), "submissions", request.Username),
-	}
-
-	for _, dirPath := range pathOptions {
-		err := os.MkdirAll(dirPath, 0755)
-		if err != nil {
-			continue
+func (es *ExecutionService) SaveSubmissionToFilesystem(ctx context.Context, request SaveSubmissionRequest) SaveSubmissionResponse {
+	submissionFile, err := es.paths.SubmissionFile(request.ChallengeID, request.Username)
+	if err != nil {
+		return SaveSubmissionResponse{
+			Success: false,
+			Message: fmt.Sprintf("Invalid submission path: %v", err),
 		}
+	}
 
-		solutionFile := filepath.Join(dirPath, "solution-template.go")
-		err = ioutil.WriteFile(solutionFile, []byte(request.Code), 0644)
-		if err != nil {
-			continue
+	if err := os.MkdirAll(filepath.Dir(submissionFile), 0755); err != nil {
+		return SaveSubmissionResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to create submission directory: %v", err),
 		}
-
-		submissionDir = dirPath
-		fileSaved = true
-		break
 	}
 
-	if !fileSaved {
+	if err := ioutil.WriteFile(submissionFile, []byte(request.Code), 0644); err != nil {
 		return SaveSubmissionResponse{
 			Success: false,
-			Message: "Failed to save solution to any available path",
+			Message: fmt.Sprintf("Failed to save solution: %v", err),
 		}
 	}
 
 	// Return success response with git commands
+	relPath := es.paths.Rel(submissionFile)
+	slog.InfoContext(ctx, "saved submission", "user", request.Username, "challenge", request.ChallengeID, "path", relPath)
 	return SaveSubmissionResponse{
 		Success:  true,
 		Message:  "Solution saved to filesystem",
-		FilePath: filepath.Join(submissionDir, "solution-template.go"),
+		FilePath: submissionFile,
 		GitCommands: []string{
-			"cd " + filepath.Join(workDir, ".."),
-			fmt.Sprintf("git add %s", filepath.Join(fmt.Sprintf("challenge-%d", request.ChallengeID), "submissions", request.Username, "solution-template.go")),
+			"cd " + es.paths.Root(),
+			fmt.Sprintf("git add %s", relPath),
 			fmt.Sprintf("git commit -m \"Add solution for Challenge %d\"", request.ChallengeID),
 			"git push origin main",
 		},
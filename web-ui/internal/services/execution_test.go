@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func newTestExecutionService() *ExecutionService {
+	return NewExecutionService(nil, ExecutionLimits{Timeout: time.Second, MaxConcurrent: 1, MaxOutputBytes: 1024})
+}
+
+func TestShutdownWaitsForInFlightRuns(t *testing.T) {
+	es := newTestExecutionService()
+	if err := es.acquire(context.Background()); err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- es.Shutdown(context.Background()) }()
+
+	// New runs are refused as soon as draining starts
+	deadline := time.Now().Add(time.Second)
+	for !es.IsDraining() && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if _, err := es.RunCode(context.Background(), "package main", nil); !errors.Is(err, ErrShuttingDown) {
+		t.Errorf("RunCode while draining = %v, want ErrShuttingDown", err)
+	}
+
+	select {
+	case err := <-done:
+		t.Fatalf("Shutdown returned %v before the run finished", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	es.release()
+	if err := <-done; err != nil {
+		t.Errorf("Shutdown = %v, want nil", err)
+	}
+}
+
+func TestShutdownCancelsRunsAfterDeadline(t *testing.T) {
+	es := newTestExecutionService()
+	if err := es.acquire(context.Background()); err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+
+	// A queued run waiting for the only slot is released with ErrShuttingDown
+	queued := make(chan error, 1)
+	go func() { queued <- es.acquire(context.Background()) }()
+	time.Sleep(10 * time.Millisecond)
+
+	// The in-flight run finishes once its context is cancelled
+	go func() {
+		<-es.baseCtx.Done()
+		es.release()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := es.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown = %v, want DeadlineExceeded", err)
+	}
+	if err := <-queued; !errors.Is(err, ErrShuttingDown) {
+		t.Errorf("queued acquire = %v, want ErrShuttingDown", err)
+	}
+}
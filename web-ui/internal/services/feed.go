@@ -0,0 +1,123 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"web-ui/internal/models"
+	"web-ui/internal/paths"
+)
+
+// FeedLimit is the number of entries a feed lists
+const FeedLimit = 50
+
+// ChallengeRelease is a challenge dated by the git history of its own files
+type ChallengeRelease struct {
+	Challenge *models.Challenge
+	Added     time.Time
+	Changed   time.Time
+}
+
+// Solve is a user's completion of a challenge according to its SCOREBOARD.md
+type Solve struct {
+	Username  string
+	Challenge *models.Challenge
+	SolvedAt  time.Time
+}
+
+// FeedService lists recent challenges and solves for the syndication feeds.
+// Dates come from git history so that they stay put between requests; without
+// history they fall back to file modification times, and solves that cannot
+// be dated at all are left out.
+type FeedService struct {
+	paths             *paths.Resolver
+	challengeService  *ChallengeService
+	scoreboardService *ScoreboardService
+	historyService    *HistoryService
+	userService       *UserService
+}
+
+// NewFeedService creates a feed service
+func NewFeedService(resolver *paths.Resolver, challengeService *ChallengeService, scoreboardService *ScoreboardService, historyService *HistoryService, userService *UserService) *FeedService {
+	return &FeedService{
+		paths:             resolver,
+		challengeService:  challengeService,
+		scoreboardService: scoreboardService,
+		historyService:    historyService,
+		userService:       userService,
+	}
+}
+
+// Challenges returns the most recently added challenges, newest first
+func (fs *FeedService) Challenges() []ChallengeRelease {
+	var releases []ChallengeRelease
+	for _, challenge := range fs.challengeService.GetChallenges() {
+		release := ChallengeRelease{Challenge: challenge}
+		if dates, ok := fs.historyService.ChallengeDates(challenge.ID); ok {
+			release.Added, release.Changed = dates.Added, dates.Changed
+		} else if modified, ok := fs.readmeTime(challenge.ID); ok {
+			release.Added, release.Changed = modified, modified
+		} else {
+			continue
+		}
+		releases = append(releases, release)
+	}
+	sort.Slice(releases, func(i, j int) bool {
+		if !releases[i].Added.Equal(releases[j].Added) {
+			return releases[i].Added.After(releases[j].Added)
+		}
+		return releases[i].Challenge.ID > releases[j].Challenge.ID
+	})
+	if len(releases) > FeedLimit {
+		releases = releases[:FeedLimit]
+	}
+	return releases
+}
+
+// Solves returns the most recent completions, newest first, of one user or
+// of everyone when username is empty
+func (fs *FeedService) Solves(username string) []Solve {
+	var solves []Solve
+	for _, result := range fs.scoreboardService.Results(fs.challengeService.GetChallenges()) {
+		if !result.Complete() || (username != "" && !strings.EqualFold(result.Username, username)) {
+			continue
+		}
+		solvedAt := result.SolvedAt
+		if solvedAt.IsZero() {
+			var ok bool
+			if solvedAt, ok = fs.userService.SubmissionTime(result.Username, result.Challenge.ID); !ok {
+				continue
+			}
+		}
+		solves = append(solves, Solve{Username: result.Username, Challenge: result.Challenge, SolvedAt: solvedAt})
+	}
+	sort.Slice(solves, func(i, j int) bool {
+		if !solves[i].SolvedAt.Equal(solves[j].SolvedAt) {
+			return solves[i].SolvedAt.After(solves[j].SolvedAt)
+		}
+		if solves[i].Challenge.ID != solves[j].Challenge.ID {
+			return solves[i].Challenge.ID > solves[j].Challenge.ID
+		}
+		return solves[i].Username < solves[j].Username
+	})
+	if len(solves) > FeedLimit {
+		solves = solves[:FeedLimit]
+	}
+	return solves
+}
+
+// readmeTime returns when a challenge's README was last written
+func (fs *FeedService) readmeTime(challengeID int) (time.Time, bool) {
+	dir, err := fs.paths.ChallengeDir(challengeID)
+	if err != nil {
+		return time.Time{}, false
+	}
+	info, err := os.Stat(filepath.Join(dir, "README.md"))
+	if err != nil {
+		return time.Time{}, false
+	}
+	return info.ModTime(), true
+}
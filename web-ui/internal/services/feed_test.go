@@ -0,0 +1,75 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"web-ui/internal/paths"
+)
+
+func TestFeedService(t *testing.T) {
+	root := t.TempDir()
+	for id := 1; id <= 3; id++ {
+		writeChallenge(t, root, id, "")
+	}
+	header := "| Username | Passed Tests | Total Tests |\n|------|------|------|\n"
+	writeFile(t, filepath.Join(root, "challenge-1", paths.ScoreboardFileName), header+"| alice | 2 | 2 |\n| bob | 2 | 2 |\n| carol | 1 | 2 |\n")
+	writeFile(t, filepath.Join(root, "challenge-2", paths.ScoreboardFileName), header+"| alice | 2 | 2 |\n| dave | 2 | 2 |\n")
+
+	// dave has no commit, so his solve is dated by his submission file
+	daveSolved := time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)
+	daveFile := filepath.Join(root, "challenge-2", "submissions", "dave", "solution-template.go")
+	writeFile(t, daveFile, "package main\n")
+	if err := os.Chtimes(daveFile, daveSolved, daveSolved); err != nil {
+		t.Fatal(err)
+	}
+	// challenge 3 is not committed, so it is dated by its README
+	readmeTime := time.Date(2024, 3, 9, 0, 0, 0, 0, time.UTC)
+	if err := os.Chtimes(filepath.Join(root, "challenge-3", "README.md"), readmeTime, readmeTime); err != nil {
+		t.Fatal(err)
+	}
+
+	resolver := mustResolver(t, root)
+	challengeService := NewChallengeService(resolver)
+	if err := challengeService.LoadChallenges(); err != nil {
+		t.Fatal(err)
+	}
+	history := NewHistoryService(resolver)
+	history.solves = map[string]map[int]time.Time{
+		"alice": {1: time.Unix(300, 0), 2: time.Unix(400, 0)},
+		"bob":   {1: time.Unix(100, 0)},
+	}
+	history.challenges = map[int]ChallengeDates{
+		1: {Added: time.Unix(10, 0), Changed: time.Unix(50, 0)},
+		2: {Added: time.Unix(20, 0), Changed: time.Unix(20, 0)},
+	}
+	scoreboardService := NewScoreboardService(resolver)
+	scoreboardService.UseHistory(history)
+	fs := NewFeedService(resolver, challengeService, scoreboardService, history, NewUserService(resolver))
+
+	var challenges []int
+	for _, release := range fs.Challenges() {
+		challenges = append(challenges, release.Challenge.ID)
+	}
+	if want := []int{3, 2, 1}; !reflect.DeepEqual(challenges, want) {
+		t.Errorf("challenges = %v, want %v, newest first", challenges, want)
+	}
+	if release := fs.Challenges()[2]; !release.Changed.Equal(time.Unix(50, 0)) {
+		t.Errorf("challenge 1 changed %v, want the latest commit", release.Changed)
+	}
+
+	// carol has not completed anything
+	var solves []string
+	for _, solve := range fs.Solves("") {
+		solves = append(solves, solve.Username)
+	}
+	if want := []string{"dave", "alice", "alice", "bob"}; !reflect.DeepEqual(solves, want) {
+		t.Errorf("solves = %v, want %v", solves, want)
+	}
+	if got := fs.Solves("ALICE"); len(got) != 2 || got[0].Challenge.ID != 2 || !got[0].SolvedAt.Equal(time.Unix(400, 0)) {
+		t.Errorf("alice's solves = %+v", got)
+	}
+}
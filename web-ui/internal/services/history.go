@@ -0,0 +1,214 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"web-ui/internal/paths"
+)
+
+// commitSeparator starts each commit in the git log output read by Load
+const commitSeparator = "\x1e"
+
+var (
+	submissionPathRe = regexp.MustCompile(`^challenge-(\d+)/submissions/([^/]+)/`)
+	challengePathRe  = regexp.MustCompile(`^challenge-(\d+)/`)
+)
+
+// ChallengeDates are the commit times of a challenge's own files, such as its
+// README and template, ignoring submissions and the scoreboard
+type ChallengeDates struct {
+	Added   time.Time // first commit
+	Changed time.Time // latest commit
+}
+
+// HistoryService reads when users committed their submissions, and when
+// challenges were added and changed, from the repository's git history
+type HistoryService struct {
+	paths *paths.Resolver
+
+	mu         sync.RWMutex
+	solves     map[string]map[int]time.Time // username -> challenge -> first commit
+	commits    map[string][]time.Time       // username -> commits touching their submissions
+	challenges map[int]ChallengeDates
+}
+
+// NewHistoryService creates a history service with no history loaded
+func NewHistoryService(resolver *paths.Resolver) *HistoryService {
+	return &HistoryService{
+		paths:      resolver,
+		solves:     make(map[string]map[int]time.Time),
+		commits:    make(map[string][]time.Time),
+		challenges: make(map[int]ChallengeDates),
+	}
+}
+
+// Load reads the commits that added or changed files under
+// challenge-*/submissions/<username>/ and the challenges' own files,
+// replacing any history loaded before
+func (hs *HistoryService) Load(ctx context.Context) error {
+	output, err := hs.gitLog(ctx, "challenge-*/submissions/*")
+	if err != nil {
+		return err
+	}
+	solves, commits, err := parseSubmissionLog(output)
+	if err != nil {
+		return err
+	}
+
+	output, err = hs.gitLog(ctx, "challenge-*", ":(exclude)challenge-*/submissions/*", ":(exclude)challenge-*/SCOREBOARD.md")
+	if err != nil {
+		return err
+	}
+	challenges, err := parseChallengeLog(output)
+	if err != nil {
+		return err
+	}
+
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	hs.solves, hs.commits, hs.challenges = solves, commits, challenges
+	return nil
+}
+
+// gitLog lists the commits that added or changed files matching pathspecs,
+// each a timestamp followed by the file names
+func (hs *HistoryService) gitLog(ctx context.Context, pathspecs ...string) ([]byte, error) {
+	args := append([]string{"log", "--relative", "--no-renames", "--diff-filter=AM",
+		"--name-only", "--format=" + commitSeparator + "%ct", "--"}, pathspecs...)
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = hs.paths.Root()
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read git history: %v", err)
+	}
+	return output, nil
+}
+
+// parseSubmissionLog turns git log output of commit timestamps followed by
+// file names into first-commit times per user and challenge, and each
+// user's commit times
+func parseSubmissionLog(output []byte) (map[string]map[int]time.Time, map[string][]time.Time, error) {
+	solves := make(map[string]map[int]time.Time)
+	commits := make(map[string][]time.Time)
+
+	for _, record := range strings.Split(string(output), commitSeparator) {
+		scanner := bufio.NewScanner(bytes.NewBufferString(record))
+		if !scanner.Scan() {
+			continue
+		}
+		seconds, err := strconv.ParseInt(strings.TrimSpace(scanner.Text()), 10, 64)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid commit timestamp %q", scanner.Text())
+		}
+		committedAt := time.Unix(seconds, 0)
+
+		users := make(map[string]bool)
+		for scanner.Scan() {
+			match := submissionPathRe.FindStringSubmatch(scanner.Text())
+			if match == nil {
+				continue
+			}
+			id, _ := strconv.Atoi(match[1])
+			username := match[2]
+			if paths.ValidateUsername(username) != nil {
+				continue
+			}
+
+			if solves[username] == nil {
+				solves[username] = make(map[int]time.Time)
+			}
+			if first, ok := solves[username][id]; !ok || committedAt.Before(first) {
+				solves[username][id] = committedAt
+			}
+			if !users[username] {
+				users[username] = true
+				commits[username] = append(commits[username], committedAt)
+			}
+		}
+	}
+
+	for _, times := range commits {
+		sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+	}
+	return solves, commits, nil
+}
+
+// parseChallengeLog turns git log output of commit timestamps followed by
+// file names into the first and latest commit of each challenge
+func parseChallengeLog(output []byte) (map[int]ChallengeDates, error) {
+	challenges := make(map[int]ChallengeDates)
+	for _, record := range strings.Split(string(output), commitSeparator) {
+		scanner := bufio.NewScanner(bytes.NewBufferString(record))
+		if !scanner.Scan() {
+			continue
+		}
+		seconds, err := strconv.ParseInt(strings.TrimSpace(scanner.Text()), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid commit timestamp %q", scanner.Text())
+		}
+		committedAt := time.Unix(seconds, 0)
+
+		for scanner.Scan() {
+			match := challengePathRe.FindStringSubmatch(scanner.Text())
+			if match == nil {
+				continue
+			}
+			id, _ := strconv.Atoi(match[1])
+			dates, ok := challenges[id]
+			if !ok || committedAt.Before(dates.Added) {
+				dates.Added = committedAt
+			}
+			if committedAt.After(dates.Changed) {
+				dates.Changed = committedAt
+			}
+			challenges[id] = dates
+		}
+	}
+	return challenges, nil
+}
+
+// SolvedAt returns when a user first committed a submission for a challenge
+func (hs *HistoryService) SolvedAt(username string, challengeID int) (time.Time, bool) {
+	hs.mu.RLock()
+	defer hs.mu.RUnlock()
+	solvedAt, ok := hs.solves[username][challengeID]
+	return solvedAt, ok
+}
+
+// ChallengeDates returns when a challenge's own files were first committed
+// and last changed
+func (hs *HistoryService) ChallengeDates(challengeID int) (ChallengeDates, bool) {
+	hs.mu.RLock()
+	defer hs.mu.RUnlock()
+	dates, ok := hs.challenges[challengeID]
+	return dates, ok
+}
+
+// Commits returns the times of a user's submission commits, oldest first
+func (hs *HistoryService) Commits(username string) []time.Time {
+	hs.mu.RLock()
+	defer hs.mu.RUnlock()
+	return append([]time.Time(nil), hs.commits[username]...)
+}
+
+// Users returns every user with a submission commit, sorted
+func (hs *HistoryService) Users() []string {
+	hs.mu.RLock()
+	defer hs.mu.RUnlock()
+	users := make([]string, 0, len(hs.commits))
+	for username := range hs.commits {
+		users = append(users, username)
+	}
+	sort.Strings(users)
+	return users
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"web-ui/internal/paths"
+)
+
+func TestParseSubmissionLog(t *testing.T) {
+	// git log prints newest commits first
+	output := commitSeparator + "200\n\nchallenge-1/submissions/gopher/solution-template.go\nchallenge-2/submissions/gopher/solution-template.go\n" +
+		commitSeparator + "100\n\nchallenge-1/submissions/gopher/solution-template.go\nchallenge-1/submissions/rival/solution-template.go\nchallenge-1/README.md\n" +
+		commitSeparator + "50\n\nchallenge-3/submissions/../escape.go\n"
+
+	solves, commits, err := parseSubmissionLog([]byte(output))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]map[int]time.Time{
+		"gopher": {1: time.Unix(100, 0), 2: time.Unix(200, 0)},
+		"rival":  {1: time.Unix(100, 0)},
+	}
+	if !reflect.DeepEqual(solves, want) {
+		t.Errorf("solves = %v, want %v", solves, want)
+	}
+	if got := commits["gopher"]; !reflect.DeepEqual(got, []time.Time{time.Unix(100, 0), time.Unix(200, 0)}) {
+		t.Errorf("commits = %v, want one per commit, oldest first", got)
+	}
+
+	if _, _, err := parseSubmissionLog([]byte(commitSeparator + "yesterday\n")); err == nil {
+		t.Error("parseSubmissionLog accepted a malformed timestamp")
+	}
+}
+
+func TestParseChallengeLog(t *testing.T) {
+	output := commitSeparator + "300\n\nchallenge-1/README.md\n" +
+		commitSeparator + "100\n\nchallenge-1/README.md\nchallenge-1/solution-template.go\nchallenge-2/README.md\nteams.json\n"
+
+	challenges, err := parseChallengeLog([]byte(output))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[int]ChallengeDates{
+		1: {Added: time.Unix(100, 0), Changed: time.Unix(300, 0)},
+		2: {Added: time.Unix(100, 0), Changed: time.Unix(100, 0)},
+	}
+	if !reflect.DeepEqual(challenges, want) {
+		t.Errorf("challenges = %v, want %v", challenges, want)
+	}
+}
+
+func TestHistoryLoad(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	root := t.TempDir()
+	git := func(date string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = root
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com", "GIT_AUTHOR_DATE="+date,
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com", "GIT_COMMITTER_DATE="+date,
+		)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, output)
+		}
+	}
+	commit := func(date, file string) {
+		t.Helper()
+		writeFile(t, filepath.Join(root, file), date)
+		git(date, "add", "-A")
+		git(date, "commit", "-q", "-m", file)
+	}
+
+	git("", "init", "-q")
+	commit("2024-03-01T10:00:00Z", "challenge-1/submissions/gopher/solution-template.go")
+	commit("2024-03-02T10:00:00Z", "challenge-1/submissions/gopher/solution-template.go")
+	commit("2024-03-03T10:00:00Z", "challenge-1/README.md")
+	commit("2024-03-04T10:00:00Z", "challenge-1/SCOREBOARD.md")
+
+	hs := NewHistoryService(mustResolver(t, root))
+	if err := hs.Load(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	solvedAt, ok := hs.SolvedAt("gopher", 1)
+	if want := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC); !ok || !solvedAt.Equal(want) {
+		t.Errorf("SolvedAt = %v, %v, want %v", solvedAt, ok, want)
+	}
+	if got := len(hs.Commits("gopher")); got != 2 {
+		t.Errorf("Commits = %d, want 2", got)
+	}
+	if got := hs.Users(); !reflect.DeepEqual(got, []string{"gopher"}) {
+		t.Errorf("Users = %v", got)
+	}
+	// Only the README counts: submissions and the scoreboard are not the
+	// challenge's own files
+	dates, ok := hs.ChallengeDates(1)
+	if want := time.Date(2024, 3, 3, 10, 0, 0, 0, time.UTC); !ok || !dates.Added.Equal(want) || !dates.Changed.Equal(want) {
+		t.Errorf("ChallengeDates = %+v, %v, want %v", dates, ok, want)
+	}
+
+	if err := NewHistoryService(mustResolver(t, t.TempDir())).Load(context.Background()); err == nil {
+		t.Error("Load outside a git repository succeeded")
+	}
+}
+
+func mustResolver(t *testing.T, root string) *paths.Resolver {
+	t.Helper()
+	resolver, err := paths.NewResolver(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resolver
+}
+
+func TestDailySeries(t *testing.T) {
+	now := time.Date(2024, 3, 10, 18, 0, 0, 0, time.UTC)
+	times := []time.Time{now, now.Add(-time.Hour), now.AddDate(0, 0, -2), now.AddDate(0, 0, -9)}
+	want := []DayCount{{Date: "2024-03-08", Count: 1}, {Date: "2024-03-09", Count: 0}, {Date: "2024-03-10", Count: 2}}
+	if got := DailySeries(times, now, 3); !reflect.DeepEqual(got, want) {
+		t.Errorf("DailySeries = %v, want %v", got, want)
+	}
+}
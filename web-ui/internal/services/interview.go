@@ -0,0 +1,507 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"web-ui/internal/models"
+	"web-ui/internal/store"
+)
+
+// Interview limits
+const (
+	MaxInterviewChallenges = 5
+	MaxInterviewMinutes    = 8 * 60
+	maxCandidateNameLength = 100
+)
+
+var (
+	// ErrInterviewNotFound is returned for an unknown interview or invite
+	ErrInterviewNotFound = errors.New("interview not found")
+	// ErrInviteUsed is returned when an invite link is opened a second time
+	ErrInviteUsed = errors.New("invite link has already been used")
+	// ErrInterviewNotRunning is returned for candidate activity before the
+	// candidate joins or after the interview ends
+	ErrInterviewNotRunning = errors.New("interview is not in progress")
+	// ErrNotInInterview is returned for activity on a challenge that is not
+	// one of the interview's
+	ErrNotInInterview = errors.New("challenge is not part of the interview")
+	// ErrInvalidInterview is returned for an interview with unknown or
+	// repeated challenges or an out-of-range time limit
+	ErrInvalidInterview = errors.New("invalid interview")
+)
+
+// interviewRecord is an interview as stored, with the hash of its invite token
+type interviewRecord struct {
+	models.Interview
+	InviteSHA256 string `json:"inviteSha256"`
+	InviteUsed   bool   `json:"inviteUsed"`
+}
+
+// InterviewChallengeReport summarizes the candidate's work on one challenge
+type InterviewChallengeReport struct {
+	ChallengeID     int        `json:"challengeId"`
+	Title           string     `json:"title"`
+	Snapshots       int        `json:"snapshots" doc:"Recorded editor states"`
+	Runs            int        `json:"runs"`
+	PassedRuns      int        `json:"passedRuns"`
+	FirstPassAt     *time.Time `json:"firstPassAt,omitempty" doc:"Time of the first run whose tests passed"`
+	FirstPassMinute *int       `json:"firstPassMinute,omitempty" doc:"Minutes from the start to the first passing run"`
+	FinalCode       string     `json:"finalCode" doc:"The last recorded editor state or run"`
+}
+
+// InterviewReport is an interviewer's review of an interview: a summary per
+// challenge and the full timeline, oldest first
+type InterviewReport struct {
+	Interview  models.Interview           `json:"interview"`
+	Status     string                     `json:"status" enum:"invited,in_progress,ended"`
+	Challenges []InterviewChallengeReport `json:"challenges"`
+	Timeline   []models.InterviewEvent    `json:"timeline"`
+}
+
+// InterviewExport is everything recorded for an interview, for archiving
+type InterviewExport struct {
+	ExportedAt time.Time               `json:"exportedAt"`
+	Interview  models.Interview        `json:"interview"`
+	Events     []models.InterviewEvent `json:"events"`
+}
+
+// InterviewService stores mock interviews and their recordings. Each
+// interview is kept in dir as <id>.json, rewritten atomically on every
+// change, with its events appended to <id>.events.jsonl. An empty dir keeps
+// everything in memory.
+type InterviewService struct {
+	challengeService *ChallengeService
+	dir              string
+
+	mu         sync.Mutex
+	interviews map[string]*interviewRecord
+	events     map[string][]models.InterviewEvent // memory only
+	lastCode   map[string]string                  // last editor state per interview and challenge
+}
+
+// NewInterviewService loads the interviews stored in dir
+func NewInterviewService(challengeService *ChallengeService, dir string) (*InterviewService, error) {
+	is := &InterviewService{
+		challengeService: challengeService,
+		dir:              dir,
+		interviews:       make(map[string]*interviewRecord),
+		events:           make(map[string][]models.InterviewEvent),
+		lastCode:         make(map[string]string),
+	}
+	if dir == "" {
+		return is, nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read interview: %v", err)
+		}
+		var record interviewRecord
+		if err := json.Unmarshal(content, &record); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %v", file, err)
+		}
+		if record.ID+".json" != filepath.Base(file) {
+			return nil, fmt.Errorf("%s: interview ID %q does not match the file name", file, record.ID)
+		}
+		is.interviews[record.ID] = &record
+	}
+	return is, nil
+}
+
+// CreateInterview schedules an interview and returns it with the invite
+// token, which is not stored and cannot be retrieved again
+func (is *InterviewService) CreateInterview(interviewer, candidate string, challengeIDs []int, durationMinutes int, now time.Time) (models.Interview, string, error) {
+	candidate = strings.TrimSpace(candidate)
+	if len(candidate) > maxCandidateNameLength {
+		return models.Interview{}, "", fmt.Errorf("%w: the candidate name is longer than %d characters", ErrInvalidInterview, maxCandidateNameLength)
+	}
+	if len(challengeIDs) == 0 || len(challengeIDs) > MaxInterviewChallenges {
+		return models.Interview{}, "", fmt.Errorf("%w: an interview needs between 1 and %d challenges", ErrInvalidInterview, MaxInterviewChallenges)
+	}
+	seen := make(map[int]bool)
+	for _, id := range challengeIDs {
+		if _, ok := is.challengeService.GetChallenge(id); !ok {
+			return models.Interview{}, "", fmt.Errorf("%w: unknown challenge %d", ErrInvalidInterview, id)
+		}
+		if seen[id] {
+			return models.Interview{}, "", fmt.Errorf("%w: challenge %d is listed twice", ErrInvalidInterview, id)
+		}
+		seen[id] = true
+	}
+	if durationMinutes < 1 || durationMinutes > MaxInterviewMinutes {
+		return models.Interview{}, "", fmt.Errorf("%w: the time limit must be between 1 and %d minutes", ErrInvalidInterview, MaxInterviewMinutes)
+	}
+
+	id, err := randomString(8)
+	if err != nil {
+		return models.Interview{}, "", err
+	}
+	token, err := randomString(32)
+	if err != nil {
+		return models.Interview{}, "", err
+	}
+	record := &interviewRecord{
+		Interview: models.Interview{
+			ID:              id,
+			Interviewer:     interviewer,
+			Candidate:       candidate,
+			ChallengeIDs:    append([]int{}, challengeIDs...),
+			DurationMinutes: durationMinutes,
+			CreatedAt:       now.UTC(),
+		},
+		InviteSHA256: hashToken(token),
+	}
+
+	is.mu.Lock()
+	defer is.mu.Unlock()
+	if err := is.save(record); err != nil {
+		return models.Interview{}, "", err
+	}
+	is.interviews[id] = record
+	return copyInterview(record), token, nil
+}
+
+// Interview returns the interview with the given ID
+func (is *InterviewService) Interview(id string) (models.Interview, bool) {
+	is.mu.Lock()
+	defer is.mu.Unlock()
+
+	record, ok := is.interviews[id]
+	if !ok {
+		return models.Interview{}, false
+	}
+	return copyInterview(record), true
+}
+
+// InterviewsBy returns the interviews interviewer created, newest first
+func (is *InterviewService) InterviewsBy(interviewer string) []models.Interview {
+	is.mu.Lock()
+	defer is.mu.Unlock()
+
+	interviews := []models.Interview{}
+	for _, record := range is.interviews {
+		if strings.EqualFold(record.Interviewer, interviewer) {
+			interviews = append(interviews, copyInterview(record))
+		}
+	}
+	sort.Slice(interviews, func(i, j int) bool {
+		if !interviews[i].CreatedAt.Equal(interviews[j].CreatedAt) {
+			return interviews[i].CreatedAt.After(interviews[j].CreatedAt)
+		}
+		return interviews[i].ID < interviews[j].ID
+	})
+	return interviews
+}
+
+// Invite returns the interview an unused invite token admits to
+func (is *InterviewService) Invite(token string) (models.Interview, error) {
+	is.mu.Lock()
+	defer is.mu.Unlock()
+
+	record, err := is.invite(token)
+	if err != nil {
+		return models.Interview{}, err
+	}
+	return copyInterview(record), nil
+}
+
+// invite finds the interview of an unused invite token. Callers must hold
+// is.mu.
+func (is *InterviewService) invite(token string) (*interviewRecord, error) {
+	hash := hashToken(token)
+	for _, record := range is.interviews {
+		if record.InviteSHA256 != hash {
+			continue
+		}
+		if record.InviteUsed || record.EndedAt != nil {
+			return nil, ErrInviteUsed
+		}
+		return record, nil
+	}
+	return nil, ErrInterviewNotFound
+}
+
+// Join redeems an invite token, starting the candidate's clock
+func (is *InterviewService) Join(token string, now time.Time) (models.Interview, error) {
+	is.mu.Lock()
+	defer is.mu.Unlock()
+
+	record, err := is.invite(token)
+	if err != nil {
+		return models.Interview{}, err
+	}
+	started := now.UTC()
+	record.InviteUsed = true
+	record.StartedAt = &started
+	if err := is.save(record); err != nil {
+		record.InviteUsed = false
+		record.StartedAt = nil
+		return models.Interview{}, err
+	}
+	if err := is.append(record.ID, models.InterviewEvent{Time: started, Kind: models.InterviewEventJoined}); err != nil {
+		return models.Interview{}, err
+	}
+	return copyInterview(record), nil
+}
+
+// End stops an interview before its time limit; an unused invite can no
+// longer be redeemed
+func (is *InterviewService) End(id string, now time.Time) (models.Interview, error) {
+	is.mu.Lock()
+	defer is.mu.Unlock()
+
+	record, ok := is.interviews[id]
+	if !ok {
+		return models.Interview{}, ErrInterviewNotFound
+	}
+	if record.Status(now) == models.InterviewEnded {
+		return models.Interview{}, ErrInterviewNotRunning
+	}
+	ended := now.UTC()
+	record.EndedAt = &ended
+	if err := is.save(record); err != nil {
+		record.EndedAt = nil
+		return models.Interview{}, err
+	}
+	if err := is.append(id, models.InterviewEvent{Time: ended, Kind: models.InterviewEventEnded}); err != nil {
+		return models.Interview{}, err
+	}
+	return copyInterview(record), nil
+}
+
+// CheckActivity returns the interview the candidate is working on, or the
+// reason they cannot work on challengeID at now
+func (is *InterviewService) CheckActivity(id string, challengeID int, now time.Time) (models.Interview, error) {
+	interview, ok := is.Interview(id)
+	if !ok {
+		return models.Interview{}, ErrInterviewNotFound
+	}
+	if interview.Status(now) != models.InterviewInProgress {
+		return models.Interview{}, ErrInterviewNotRunning
+	}
+	if !interview.HasChallenge(challengeID) {
+		return models.Interview{}, ErrNotInInterview
+	}
+	return interview, nil
+}
+
+// RecordEditor records the candidate's editor contents for a challenge,
+// skipping contents identical to the last recorded state
+func (is *InterviewService) RecordEditor(id string, challengeID int, code string, now time.Time) error {
+	if _, err := is.CheckActivity(id, challengeID, now); err != nil {
+		return err
+	}
+
+	is.mu.Lock()
+	defer is.mu.Unlock()
+
+	key := fmt.Sprintf("%s/%d", id, challengeID)
+	hash := hashToken(code)
+	if is.lastCode[key] == hash {
+		return nil
+	}
+	event := models.InterviewEvent{Time: now.UTC(), Kind: models.InterviewEventEditor, ChallengeID: challengeID, Code: code}
+	if err := is.append(id, event); err != nil {
+		return err
+	}
+	is.lastCode[key] = hash
+	return nil
+}
+
+// RecordRun records a run of the candidate's code and its result
+func (is *InterviewService) RecordRun(id string, challengeID int, code string, result ExecutionResult, now time.Time) error {
+	is.mu.Lock()
+	defer is.mu.Unlock()
+
+	if _, ok := is.interviews[id]; !ok {
+		return ErrInterviewNotFound
+	}
+	return is.append(id, models.InterviewEvent{
+		Time:        now.UTC(),
+		Kind:        models.InterviewEventRun,
+		ChallengeID: challengeID,
+		Code:        code,
+		Passed:      result.Passed,
+		TimedOut:    result.TimedOut,
+		Output:      result.Output,
+		ExecutionMs: result.ExecutionMs,
+	})
+}
+
+// Events returns an interview's recording, oldest first
+func (is *InterviewService) Events(id string) ([]models.InterviewEvent, error) {
+	is.mu.Lock()
+	defer is.mu.Unlock()
+
+	if _, ok := is.interviews[id]; !ok {
+		return nil, ErrInterviewNotFound
+	}
+	if is.dir == "" {
+		return append([]models.InterviewEvent{}, is.events[id]...), nil
+	}
+
+	events := []models.InterviewEvent{}
+	err := store.ScanFile(is.eventsPath(id), 4*1024*1024, func(event models.InterviewEvent) {
+		events = append(events, event)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read interview events: %v", err)
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Time.Before(events[j].Time) })
+	return events, nil
+}
+
+// LatestCode returns the last recorded editor state or run per challenge, so
+// a candidate who reloads the workspace continues where they left off
+func (is *InterviewService) LatestCode(id string) (map[int]string, error) {
+	events, err := is.Events(id)
+	if err != nil {
+		return nil, err
+	}
+	code := make(map[int]string)
+	for _, event := range events {
+		if event.Kind == models.InterviewEventEditor || event.Kind == models.InterviewEventRun {
+			code[event.ChallengeID] = event.Code
+		}
+	}
+	return code, nil
+}
+
+// Report summarizes an interview's recording per challenge
+func (is *InterviewService) Report(id string, now time.Time) (InterviewReport, error) {
+	interview, ok := is.Interview(id)
+	if !ok {
+		return InterviewReport{}, ErrInterviewNotFound
+	}
+	events, err := is.Events(id)
+	if err != nil {
+		return InterviewReport{}, err
+	}
+
+	report := InterviewReport{
+		Interview:  interview,
+		Status:     interview.Status(now),
+		Challenges: make([]InterviewChallengeReport, len(interview.ChallengeIDs)),
+		Timeline:   events,
+	}
+	index := make(map[int]int)
+	for i, challengeID := range interview.ChallengeIDs {
+		index[challengeID] = i
+		report.Challenges[i].ChallengeID = challengeID
+		if challenge, ok := is.challengeService.GetChallenge(challengeID); ok {
+			report.Challenges[i].Title = challenge.Title
+			report.Challenges[i].FinalCode = challenge.Template
+		}
+	}
+
+	for _, event := range events {
+		i, ok := index[event.ChallengeID]
+		if !ok {
+			continue
+		}
+		summary := &report.Challenges[i]
+		switch event.Kind {
+		case models.InterviewEventEditor:
+			summary.Snapshots++
+		case models.InterviewEventRun:
+			summary.Runs++
+			if event.Passed {
+				summary.PassedRuns++
+				if summary.FirstPassAt == nil && interview.StartedAt != nil {
+					at := event.Time
+					minute := int(at.Sub(*interview.StartedAt) / time.Minute)
+					summary.FirstPassAt, summary.FirstPassMinute = &at, &minute
+				}
+			}
+		default:
+			continue
+		}
+		summary.FinalCode = event.Code
+	}
+	return report, nil
+}
+
+// Export returns everything recorded for an interview
+func (is *InterviewService) Export(id string, now time.Time) (InterviewExport, error) {
+	interview, ok := is.Interview(id)
+	if !ok {
+		return InterviewExport{}, ErrInterviewNotFound
+	}
+	events, err := is.Events(id)
+	if err != nil {
+		return InterviewExport{}, err
+	}
+	return InterviewExport{ExportedAt: now.UTC(), Interview: interview, Events: events}, nil
+}
+
+// save writes an interview atomically. Callers must hold is.mu.
+func (is *InterviewService) save(record *interviewRecord) error {
+	if is.dir == "" {
+		return nil
+	}
+
+	content, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := store.WriteFile(filepath.Join(is.dir, record.ID+".json"), content); err != nil {
+		return fmt.Errorf("failed to write interview: %v", err)
+	}
+	return nil
+}
+
+// append adds an event to an interview's recording. Callers must hold is.mu.
+func (is *InterviewService) append(id string, event models.InterviewEvent) error {
+	if is.dir == "" {
+		is.events[id] = append(is.events[id], event)
+		return nil
+	}
+
+	if err := store.AppendFile(is.eventsPath(id), event); err != nil {
+		return fmt.Errorf("failed to write interview event: %v", err)
+	}
+	return nil
+}
+
+func (is *InterviewService) eventsPath(id string) string {
+	return filepath.Join(is.dir, id+".events.jsonl")
+}
+
+func copyInterview(record *interviewRecord) models.Interview {
+	copied := record.Interview
+	copied.ChallengeIDs = append([]int{}, record.ChallengeIDs...)
+	return copied
+}
+
+// randomString returns n random bytes encoded as base64url
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken returns the hex SHA-256 of a secret, which is stored instead of
+// the secret itself
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"web-ui/internal/models"
+)
+
+func newTestInterviewService(t *testing.T, dir string) *InterviewService {
+	t.Helper()
+	root := t.TempDir()
+	for id := 1; id <= 3; id++ {
+		writeChallenge(t, root, id, "")
+	}
+	challengeService := NewChallengeService(mustResolver(t, root))
+	if err := challengeService.LoadChallenges(); err != nil {
+		t.Fatal(err)
+	}
+	interviews, err := NewInterviewService(challengeService, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return interviews
+}
+
+func TestInterviewService(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "interviews")
+	interviews := newTestInterviewService(t, dir)
+	start := time.Date(2026, 3, 1, 18, 0, 0, 0, time.UTC)
+
+	invalid := map[string]struct {
+		challengeIDs []int
+		minutes      int
+	}{
+		"no challenges": {nil, 30},
+		"unknown":       {[]int{1, 9}, 30},
+		"repeated":      {[]int{1, 1}, 30},
+		"no time":       {[]int{1}, 0},
+		"too long":      {[]int{1}, MaxInterviewMinutes + 1},
+	}
+	for name, tc := range invalid {
+		if _, _, err := interviews.CreateInterview("alice", "Bob", tc.challengeIDs, tc.minutes, start); !errors.Is(err, ErrInvalidInterview) {
+			t.Errorf("CreateInterview with %s: err = %v, want ErrInvalidInterview", name, err)
+		}
+	}
+
+	interview, token, err := interviews.CreateInterview("alice", "Bob", []int{2, 1}, 30, start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if interview.Status(start) != models.InterviewInvited || interview.Deadline() != nil {
+		t.Fatalf("new interview = %+v, want an invited interview without a deadline", interview)
+	}
+	if _, err := interviews.CheckActivity(interview.ID, 1, start); !errors.Is(err, ErrInterviewNotRunning) {
+		t.Errorf("CheckActivity before joining = %v, want ErrInterviewNotRunning", err)
+	}
+	if _, err := interviews.Join("nope", start); !errors.Is(err, ErrInterviewNotFound) {
+		t.Errorf("Join with an unknown token = %v, want ErrInterviewNotFound", err)
+	}
+
+	joinedAt := start.Add(time.Hour)
+	joined, err := interviews.Join(token, joinedAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deadline := joined.Deadline(); deadline == nil || !deadline.Equal(joinedAt.Add(30*time.Minute)) {
+		t.Errorf("deadline = %v, want 30 minutes after joining", deadline)
+	}
+	if _, err := interviews.Join(token, joinedAt); !errors.Is(err, ErrInviteUsed) {
+		t.Errorf("second Join = %v, want ErrInviteUsed", err)
+	}
+	if _, err := interviews.CheckActivity(interview.ID, 3, joinedAt); !errors.Is(err, ErrNotInInterview) {
+		t.Errorf("CheckActivity on another challenge = %v, want ErrNotInInterview", err)
+	}
+
+	at := func(minute int) time.Time { return joinedAt.Add(time.Duration(minute) * time.Minute) }
+	for _, snapshot := range []struct {
+		minute int
+		code   string
+	}{{1, "v1"}, {2, "v1"}, {3, "v2"}} {
+		if err := interviews.RecordEditor(interview.ID, 2, snapshot.code, at(snapshot.minute)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	interviews.RecordRun(interview.ID, 2, "v2", ExecutionResult{Output: "FAIL"}, at(4))
+	interviews.RecordRun(interview.ID, 2, "v3", ExecutionResult{Passed: true, Output: "ok"}, at(7))
+	if err := interviews.RecordEditor(interview.ID, 1, "late", at(30)); !errors.Is(err, ErrInterviewNotRunning) {
+		t.Errorf("RecordEditor after the deadline = %v, want ErrInterviewNotRunning", err)
+	}
+
+	// The recording survives a restart, including after a torn write
+	file, err := os.OpenFile(filepath.Join(dir, interview.ID+".events.jsonl"), os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.WriteString(`{"time": "2026-03-01T19:10:00Z", "ki`)
+	file.Close()
+
+	reloaded := newTestInterviewService(t, dir)
+	if got := reloaded.InterviewsBy("ALICE"); len(got) != 1 || got[0].StartedAt == nil {
+		t.Fatalf("reloaded InterviewsBy(alice) = %+v, want the joined interview", got)
+	}
+	if _, err := reloaded.Join(token, joinedAt); !errors.Is(err, ErrInviteUsed) {
+		t.Errorf("Join after a restart = %v, want ErrInviteUsed", err)
+	}
+
+	report, err := reloaded.Report(interview.ID, at(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Status != models.InterviewInProgress || len(report.Timeline) != 5 {
+		t.Fatalf("report status %s with %d events, want in progress with joined, 2 snapshots and 2 runs", report.Status, len(report.Timeline))
+	}
+	first := report.Challenges[0]
+	if first.ChallengeID != 2 || first.Snapshots != 2 || first.Runs != 2 || first.PassedRuns != 1 || first.FinalCode != "v3" {
+		t.Errorf("first challenge = %+v, want 2 snapshots, 1 of 2 runs passed and v3 as the final code", first)
+	}
+	if first.FirstPassMinute == nil || *first.FirstPassMinute != 7 {
+		t.Errorf("first pass minute = %v, want 7", first.FirstPassMinute)
+	}
+	if second := report.Challenges[1]; second.Runs != 0 || second.FinalCode == "" {
+		t.Errorf("untouched challenge = %+v, want its template as the final code", second)
+	}
+	if code, _ := reloaded.LatestCode(interview.ID); code[2] != "v3" || len(code) != 1 {
+		t.Errorf("LatestCode = %v, want v3 for challenge 2", code)
+	}
+
+	ended, err := reloaded.End(interview.ID, at(12))
+	if err != nil || ended.Status(at(13)) != models.InterviewEnded || !ended.Deadline().Equal(at(12)) {
+		t.Fatalf("End = %+v, %v, want an interview ended at minute 12", ended, err)
+	}
+	if _, err := reloaded.End(interview.ID, at(13)); !errors.Is(err, ErrInterviewNotRunning) {
+		t.Errorf("second End = %v, want ErrInterviewNotRunning", err)
+	}
+	export, err := reloaded.Export(interview.ID, at(20))
+	if err != nil || len(export.Events) != 6 || export.Events[5].Kind != models.InterviewEventEnded {
+		t.Errorf("Export = %d events, %v, want the recording ending with the end event", len(export.Events), err)
+	}
+}
+
+func TestInterviewInviteRevoked(t *testing.T) {
+	interviews := newTestInterviewService(t, "")
+	now := time.Now()
+	interview, token, err := interviews.CreateInterview("alice", "", []int{1}, 30, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := interviews.End(interview.ID, now); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := interviews.Join(token, now); !errors.Is(err, ErrInviteUsed) {
+		t.Errorf("Join after the interview ended = %v, want ErrInviteUsed", err)
+	}
+	if events, _ := interviews.Events(interview.ID); len(events) != 1 {
+		t.Errorf("events = %+v, want just the end", events)
+	}
+}
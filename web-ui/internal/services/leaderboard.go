@@ -0,0 +1,150 @@
+package services
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"web-ui/internal/models"
+)
+
+// LeaderboardService ranks users on the main leaderboard with a pluggable
+// scoring model
+type LeaderboardService struct {
+	challengeService  *ChallengeService
+	scoreboardService *ScoreboardService
+	scorer            Scorer
+	tiers             []Tier
+}
+
+// NewLeaderboardService creates a leaderboard scored by scorer. Tiers must be
+// sorted by MinSolved; nil uses DefaultTiers.
+func NewLeaderboardService(challengeService *ChallengeService, scoreboardService *ScoreboardService, scorer Scorer, tiers []Tier) *LeaderboardService {
+	if tiers == nil {
+		tiers = DefaultTiers
+	}
+	return &LeaderboardService{
+		challengeService:  challengeService,
+		scoreboardService: scoreboardService,
+		scorer:            scorer,
+		tiers:             tiers,
+	}
+}
+
+// Standings scores every user with points on a scoreboard as of now, best first
+func (ls *LeaderboardService) Standings(now time.Time) []Standing {
+	byUser := make(map[string]*Standing)
+	var order []string
+	for _, result := range ls.scoreboardService.Results(ls.challengeService.GetChallenges()) {
+		standing := byUser[result.Username]
+		if standing == nil {
+			standing = &Standing{Username: result.Username, CompletedChallenges: make(map[int]bool)}
+			byUser[result.Username] = standing
+			order = append(order, result.Username)
+		}
+		standing.Points += ls.scorer.Points(result, now)
+		if result.Complete() {
+			standing.CompletedChallenges[result.Challenge.ID] = true
+		}
+	}
+
+	standings := make([]Standing, 0, len(order))
+	for _, username := range order {
+		standing := byUser[username]
+		// Round so that users with the same solves tie despite float error
+		standing.Points = math.Round(standing.Points*100) / 100
+		if standing.Points <= 0 {
+			continue
+		}
+		standing.Achievement = ls.Achievement(len(standing.CompletedChallenges))
+		standings = append(standings, *standing)
+	}
+	competitionRanks(standings)
+	return standings
+}
+
+// Rank returns a user's rank as of now, 0 when the user has no points
+func (ls *LeaderboardService) Rank(username string, now time.Time) int {
+	for _, standing := range ls.Standings(now) {
+		if standing.Username == username {
+			return standing.Rank
+		}
+	}
+	return 0
+}
+
+// Achievement names the tier earned by completing count challenges
+func (ls *LeaderboardService) Achievement(count int) string {
+	return achievementTier(ls.tiers, count)
+}
+
+// TeamStanding is a team's position on the team leaderboard
+type TeamStanding struct {
+	Team         models.Team
+	Members      []Standing // every member, best first; members without points have rank 0
+	Solved       []int      // challenges completed by any member, by ID
+	Coverage     float64    // solved challenges as a percentage of all challenges
+	AverageScore float64    // mean member points, counting members without points as 0
+	Rank         int
+}
+
+// TeamStandings aggregates the standings of each team's members as of now
+// and ranks the teams by average score, so that small teams can compete
+// with large ones
+func (ls *LeaderboardService) TeamStandings(teams []models.Team, now time.Time) []TeamStanding {
+	byUser := make(map[string]Standing)
+	for _, standing := range ls.Standings(now) {
+		byUser[strings.ToLower(standing.Username)] = standing
+	}
+	total := len(ls.challengeService.GetChallenges())
+
+	standings := make([]TeamStanding, 0, len(teams))
+	for _, team := range teams {
+		ts := TeamStanding{Team: team, Members: []Standing{}, Solved: []int{}}
+		solved := make(map[int]bool)
+		var points float64
+		for _, member := range team.Members {
+			standing, ok := byUser[strings.ToLower(member)]
+			if !ok {
+				standing = Standing{Username: member, CompletedChallenges: map[int]bool{}, Achievement: ls.Achievement(0)}
+			}
+			for id := range standing.CompletedChallenges {
+				solved[id] = true
+			}
+			points += standing.Points
+			ts.Members = append(ts.Members, standing)
+		}
+		sort.SliceStable(ts.Members, func(i, j int) bool {
+			return ts.Members[i].Points > ts.Members[j].Points
+		})
+
+		for id := range solved {
+			ts.Solved = append(ts.Solved, id)
+		}
+		sort.Ints(ts.Solved)
+		if total > 0 {
+			ts.Coverage = math.Round(float64(len(ts.Solved))/float64(total)*1000) / 10
+		}
+		if len(team.Members) > 0 {
+			ts.AverageScore = math.Round(points/float64(len(team.Members))*100) / 100
+		}
+		standings = append(standings, ts)
+	}
+
+	// Rank like users: equal averages share a rank, ties ordered by slug
+	sort.Slice(standings, func(i, j int) bool {
+		if standings[i].AverageScore != standings[j].AverageScore {
+			return standings[i].AverageScore > standings[j].AverageScore
+		}
+		return standings[i].Team.Slug < standings[j].Team.Slug
+	})
+	for i := range standings {
+		if i > 0 && standings[i].AverageScore == standings[i-1].AverageScore {
+			standings[i].Rank = standings[i-1].Rank
+		} else {
+			standings[i].Rank = i + 1
+		}
+	}
+	return standings
+}
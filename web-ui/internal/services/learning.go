@@ -0,0 +1,313 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"web-ui/internal/models"
+)
+
+// metadataFile is the optional per-challenge file declaring prerequisites,
+// learning path membership and leaderboard points
+const metadataFile = "metadata.json"
+
+// Reasons a challenge is recommended
+const (
+	ReasonContinue     = "continue"     // attempted but not yet passing every test
+	ReasonPath         = "path"         // next step on a learning path
+	ReasonPrerequisite = "prerequisite" // needed before the next step on the requested path
+	ReasonUnlocked     = "unlocked"     // easiest challenge whose prerequisites are complete
+	ReasonComplete     = "complete"     // nothing left to recommend
+)
+
+// ErrPathNotFound is returned for an unknown learning path slug
+var ErrPathNotFound = errors.New("learning path not found")
+
+var slugRe = regexp.MustCompile(`[^a-z0-9]+`)
+
+// challengeMetadata is the content of a challenge's metadata.json
+type challengeMetadata struct {
+	Prerequisites []int `json:"prerequisites"`
+	Points        int   `json:"points"`
+	Paths         []struct {
+		Name string `json:"name"`
+		Step int    `json:"step"`
+	} `json:"paths"`
+}
+
+// pathStep places a challenge on a named learning path
+type pathStep struct {
+	path        string
+	step        int
+	challengeID int
+}
+
+// Recommendation is the challenge a user should attempt next
+type Recommendation struct {
+	Challenge *models.Challenge // nil when Reason is ReasonComplete
+	Reason    string
+	Path      *models.LearningPath // the path the recommendation follows, if any
+}
+
+// readChallengeMetadata reads dir's metadata.json; a missing file is empty metadata
+func readChallengeMetadata(dir string) (challengeMetadata, error) {
+	var meta challengeMetadata
+	data, err := os.ReadFile(filepath.Join(dir, metadataFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return meta, nil
+	}
+	if err != nil {
+		return meta, err
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(string(data)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&meta); err != nil {
+		return meta, fmt.Errorf("invalid %s: %v", metadataFile, err)
+	}
+	if meta.Points < 0 {
+		return meta, fmt.Errorf("invalid %s: negative points", metadataFile)
+	}
+	for _, path := range meta.Paths {
+		if strings.TrimSpace(path.Name) == "" {
+			return meta, fmt.Errorf("invalid %s: learning path without a name", metadataFile)
+		}
+	}
+	return meta, nil
+}
+
+// pathSlug turns a learning path name into its URL slug
+func pathSlug(name string) string {
+	return strings.Trim(slugRe.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
+
+// buildLearningPaths orders each path's challenges by step, then ID
+func buildLearningPaths(steps []pathStep) map[string]*models.LearningPath {
+	sort.SliceStable(steps, func(i, j int) bool {
+		if steps[i].step != steps[j].step {
+			return steps[i].step < steps[j].step
+		}
+		return steps[i].challengeID < steps[j].challengeID
+	})
+
+	paths := make(map[string]*models.LearningPath)
+	for _, step := range steps {
+		slug := pathSlug(step.path)
+		path := paths[slug]
+		if path == nil {
+			path = &models.LearningPath{Slug: slug, Name: strings.TrimSpace(step.path)}
+			paths[slug] = path
+		}
+		path.ChallengeIDs = append(path.ChallengeIDs, step.challengeID)
+	}
+	return paths
+}
+
+// validateLearningGraph reports prerequisites naming unknown challenges,
+// prerequisite cycles, and paths that list a challenge before one of its
+// prerequisites
+func validateLearningGraph(challenges models.ChallengeMap, paths map[string]*models.LearningPath) error {
+	var problems []error
+
+	ids := sortedIDs(challenges)
+	for _, id := range ids {
+		for _, prerequisite := range challenges[id].Prerequisites {
+			if prerequisite == id {
+				problems = append(problems, fmt.Errorf("challenge %d lists itself as a prerequisite", id))
+			} else if _, ok := challenges[prerequisite]; !ok {
+				problems = append(problems, fmt.Errorf("challenge %d requires unknown challenge %d", id, prerequisite))
+			}
+		}
+	}
+
+	// Depth-first search; meeting a challenge still on the stack closes a cycle
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[int]int, len(challenges))
+	var stack []int
+	var visit func(id int)
+	visit = func(id int) {
+		state[id] = visiting
+		stack = append(stack, id)
+		for _, prerequisite := range challenges[id].Prerequisites {
+			if _, ok := challenges[prerequisite]; !ok || prerequisite == id {
+				continue
+			}
+			switch state[prerequisite] {
+			case unvisited:
+				visit(prerequisite)
+			case visiting:
+				problems = append(problems, fmt.Errorf("prerequisite cycle: %s", formatCycle(stack, prerequisite)))
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[id] = done
+	}
+	for _, id := range ids {
+		if state[id] == unvisited {
+			visit(id)
+		}
+	}
+
+	for _, slug := range sortedSlugs(paths) {
+		path := paths[slug]
+		position := make(map[int]int, len(path.ChallengeIDs))
+		for i, id := range path.ChallengeIDs {
+			position[id] = i
+		}
+		for i, id := range path.ChallengeIDs {
+			for _, prerequisite := range challenges[id].Prerequisites {
+				if j, ok := position[prerequisite]; ok && j > i {
+					problems = append(problems, fmt.Errorf("learning path %q lists challenge %d before its prerequisite %d", path.Name, id, prerequisite))
+				}
+			}
+		}
+	}
+
+	return errors.Join(problems...)
+}
+
+// formatCycle renders the part of stack from start back to start, e.g. "30 -> 11 -> 30"
+func formatCycle(stack []int, start int) string {
+	var parts []string
+	for i := len(stack) - 1; i >= 0; i-- {
+		if stack[i] == start {
+			for _, id := range stack[i:] {
+				parts = append(parts, strconv.Itoa(id))
+			}
+			break
+		}
+	}
+	return strings.Join(append(parts, strconv.Itoa(start)), " -> ")
+}
+
+func sortedIDs(challenges models.ChallengeMap) []int {
+	ids := make([]int, 0, len(challenges))
+	for id := range challenges {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+func sortedSlugs(paths map[string]*models.LearningPath) []string {
+	slugs := make([]string, 0, len(paths))
+	for slug := range paths {
+		slugs = append(slugs, slug)
+	}
+	sort.Strings(slugs)
+	return slugs
+}
+
+// LearningPaths returns every learning path ordered by name
+func (cs *ChallengeService) LearningPaths() []*models.LearningPath {
+	paths := make([]*models.LearningPath, 0, len(cs.learningPaths))
+	for _, path := range cs.learningPaths {
+		paths = append(paths, path)
+	}
+	sort.Slice(paths, func(i, j int) bool { return paths[i].Name < paths[j].Name })
+	return paths
+}
+
+// LearningPath returns a learning path by slug
+func (cs *ChallengeService) LearningPath(slug string) (*models.LearningPath, bool) {
+	path, ok := cs.learningPaths[slug]
+	return path, ok
+}
+
+// Recommend picks the challenge a user should attempt next. A challenge is
+// complete once the user's score on it reaches 100. With a path slug, the
+// first incomplete step on that path is recommended, or its first incomplete
+// prerequisite when it is still locked. Otherwise, in order of preference:
+// an attempted challenge that is not yet complete, the next step on the path
+// the user has progressed furthest on, and the easiest unlocked challenge.
+func (cs *ChallengeService) Recommend(attempts *models.UserAttemptedChallenges, pathSlug string) (Recommendation, error) {
+	completed := func(id int) bool { return attempts != nil && attempts.Scores[id] >= 100 }
+	attempted := func(id int) bool { return attempts != nil && attempts.AttemptedIDs[id] }
+	unlocked := func(id int) bool {
+		for _, prerequisite := range cs.challenges[id].Prerequisites {
+			if !completed(prerequisite) {
+				return false
+			}
+		}
+		return true
+	}
+
+	if pathSlug != "" {
+		path, ok := cs.learningPaths[pathSlug]
+		if !ok {
+			return Recommendation{}, ErrPathNotFound
+		}
+		for _, id := range path.ChallengeIDs {
+			if completed(id) {
+				continue
+			}
+			if next := cs.firstIncompletePrerequisite(id, completed); next != id {
+				return Recommendation{Challenge: cs.challenges[next], Reason: ReasonPrerequisite, Path: path}, nil
+			}
+			return Recommendation{Challenge: cs.challenges[id], Reason: ReasonPath, Path: path}, nil
+		}
+		return Recommendation{Reason: ReasonComplete, Path: path}, nil
+	}
+
+	// Easiest first, so the fallbacks below prefer gentle next steps
+	ids := sortedIDs(cs.challenges)
+	sort.SliceStable(ids, func(i, j int) bool {
+		return difficultyRank[cs.challenges[ids[i]].Difficulty] < difficultyRank[cs.challenges[ids[j]].Difficulty]
+	})
+
+	for _, id := range ids {
+		if attempted(id) && !completed(id) && unlocked(id) {
+			return Recommendation{Challenge: cs.challenges[id], Reason: ReasonContinue}, nil
+		}
+	}
+
+	var best *models.LearningPath
+	var bestNext, bestDone int
+	for _, path := range cs.LearningPaths() {
+		done, next := 0, 0
+		for _, id := range path.ChallengeIDs {
+			if completed(id) {
+				done++
+			} else if next == 0 && unlocked(id) {
+				next = id
+			}
+		}
+		if done > bestDone && next != 0 {
+			best, bestNext, bestDone = path, next, done
+		}
+	}
+	if best != nil {
+		return Recommendation{Challenge: cs.challenges[bestNext], Reason: ReasonPath, Path: best}, nil
+	}
+
+	for _, id := range ids {
+		if !attempted(id) && unlocked(id) {
+			return Recommendation{Challenge: cs.challenges[id], Reason: ReasonUnlocked}, nil
+		}
+	}
+	return Recommendation{Reason: ReasonComplete}, nil
+}
+
+// firstIncompletePrerequisite follows incomplete prerequisites from id down
+// to one whose own prerequisites are all complete; id itself when unlocked
+func (cs *ChallengeService) firstIncompletePrerequisite(id int, completed func(int) bool) int {
+	prerequisites := append([]int(nil), cs.challenges[id].Prerequisites...)
+	sort.Ints(prerequisites)
+	for _, prerequisite := range prerequisites {
+		if !completed(prerequisite) {
+			return cs.firstIncompletePrerequisite(prerequisite, completed)
+		}
+	}
+	return id
+}
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"web-ui/internal/models"
+	"web-ui/internal/paths"
+)
+
+// writeChallenge creates a loadable challenge directory with optional metadata
+func writeChallenge(t *testing.T, root string, id int, metadata string) {
+	t.Helper()
+	dir := filepath.Join(root, "challenge-"+strconv.Itoa(id))
+	files := map[string]string{
+		"README.md":            "# Challenge\n",
+		"solution-template.go": "package main\n",
+	}
+	if metadata != "" {
+		files[metadataFile] = metadata
+	}
+	writeFiles(t, dir, files)
+}
+
+func loadTestChallenges(t *testing.T, metadata map[int]string) (*ChallengeService, error) {
+	t.Helper()
+	root := t.TempDir()
+	for id := 1; id <= 4; id++ {
+		writeChallenge(t, root, id, metadata[id])
+	}
+	resolver, err := paths.NewResolver(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs := NewChallengeService(resolver)
+	return cs, cs.LoadChallenges()
+}
+
+func TestLoadLearningPaths(t *testing.T) {
+	cs, err := loadTestChallenges(t, map[int]string{
+		2: `{"paths": [{"name": "Concurrency track", "step": 2}]}`,
+		3: `{"prerequisites": [2], "paths": [{"name": "Concurrency track", "step": 3}, {"name": "Basics", "step": 1}]}`,
+		4: `{"paths": [{"name": "Concurrency track", "step": 1}]}`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path, ok := cs.LearningPath("concurrency-track")
+	if !ok || path.Name != "Concurrency track" || !reflect.DeepEqual(path.ChallengeIDs, []int{4, 2, 3}) {
+		t.Errorf("concurrency-track = %+v, %v", path, ok)
+	}
+	if got := cs.LearningPaths(); len(got) != 2 || got[0].Slug != "basics" {
+		t.Errorf("LearningPaths = %+v, want basics first", got)
+	}
+	if challenge, _ := cs.GetChallenge(3); !reflect.DeepEqual(challenge.Prerequisites, []int{2}) {
+		t.Errorf("prerequisites = %v, want [2]", challenge.Prerequisites)
+	}
+}
+
+func TestLoadRejectsBrokenGraphs(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata map[int]string
+		want     string
+	}{
+		{"dangling", map[int]string{1: `{"prerequisites": [9]}`}, "challenge 1 requires unknown challenge 9"},
+		{"self", map[int]string{1: `{"prerequisites": [1]}`}, "challenge 1 lists itself as a prerequisite"},
+		{"cycle", map[int]string{1: `{"prerequisites": [3]}`, 2: `{"prerequisites": [1]}`, 3: `{"prerequisites": [2]}`}, "prerequisite cycle: 1 -> 3 -> 2 -> 1"},
+		{"path order", map[int]string{
+			1: `{"paths": [{"name": "Track", "step": 1}]}`,
+			2: `{"prerequisites": [3], "paths": [{"name": "Track", "step": 2}]}`,
+			3: `{"paths": [{"name": "Track", "step": 3}]}`,
+		}, `learning path "Track" lists challenge 2 before its prerequisite 3`},
+		{"malformed", map[int]string{4: `{"prereqs": [1]}`}, `challenge 4: invalid metadata.json: json: unknown field "prereqs"`},
+		{"unnamed path", map[int]string{4: `{"paths": [{"step": 1}]}`}, "learning path without a name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadTestChallenges(t, tt.metadata)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("LoadChallenges error = %v, want it to mention %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// newTestLearningService has challenges 1-5 where 4 requires 2 and 3, and
+// a "Track" path of 1, 2, 3, 4
+func newTestLearningService() *ChallengeService {
+	cs := NewChallengeService(nil)
+	difficulties := map[int]string{1: "Beginner", 2: "Beginner", 3: "Intermediate", 4: "Advanced", 5: "Beginner"}
+	for id, difficulty := range difficulties {
+		cs.challenges[id] = &models.Challenge{ID: id, Difficulty: difficulty, Prerequisites: []int{}}
+	}
+	cs.challenges[4].Prerequisites = []int{3, 2}
+	cs.learningPaths = map[string]*models.LearningPath{
+		"track": {Slug: "track", Name: "Track", ChallengeIDs: []int{1, 2, 3, 4}},
+	}
+	return cs
+}
+
+func attemptsWith(scores map[int]int) *models.UserAttemptedChallenges {
+	attempts := &models.UserAttemptedChallenges{AttemptedIDs: map[int]bool{}, Scores: scores}
+	for id := range scores {
+		attempts.AttemptedIDs[id] = true
+	}
+	return attempts
+}
+
+func TestRecommend(t *testing.T) {
+	cs := newTestLearningService()
+
+	tests := []struct {
+		name       string
+		scores     map[int]int
+		path       string
+		wantID     int
+		wantReason string
+	}{
+		{"new user gets the easiest challenge", nil, "", 1, ReasonUnlocked},
+		{"unfinished attempt comes first", map[int]int{1: 100, 3: 40}, "", 3, ReasonContinue},
+		{"progress on a path continues it", map[int]int{1: 100}, "", 2, ReasonPath},
+		{"locked challenges are skipped", map[int]int{1: 100, 2: 100, 5: 100}, "", 3, ReasonPath},
+		{"requested path", map[int]int{5: 100}, "track", 1, ReasonPath},
+		{"path skips completed steps", map[int]int{1: 100, 2: 100}, "track", 3, ReasonPath},
+		{"path complete", map[int]int{1: 100, 2: 100, 3: 100, 4: 100}, "track", 0, ReasonComplete},
+		{"everything complete", map[int]int{1: 100, 2: 100, 3: 100, 4: 100, 5: 100}, "", 0, ReasonComplete},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := cs.Recommend(attemptsWith(tt.scores), tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			gotID := 0
+			if got.Challenge != nil {
+				gotID = got.Challenge.ID
+			}
+			if gotID != tt.wantID || got.Reason != tt.wantReason {
+				t.Errorf("Recommend = %d (%s), want %d (%s)", gotID, got.Reason, tt.wantID, tt.wantReason)
+			}
+		})
+	}
+
+	// A path step whose prerequisite lies outside the path points at that prerequisite
+	cs.learningPaths["finale"] = &models.LearningPath{Slug: "finale", Name: "Finale", ChallengeIDs: []int{4}}
+	got, err := cs.Recommend(attemptsWith(map[int]int{3: 100}), "finale")
+	if err != nil || got.Challenge == nil || got.Challenge.ID != 2 || got.Reason != ReasonPrerequisite {
+		t.Errorf("Recommend(finale) = %+v, %v, want challenge 2 as a prerequisite", got, err)
+	}
+
+	if _, err := cs.Recommend(nil, "nope"); err != ErrPathNotFound {
+		t.Errorf("Recommend(nope) error = %v, want ErrPathNotFound", err)
+	}
+}
@@ -0,0 +1,281 @@
+package services
+
+import (
+	"sort"
+	"time"
+
+	"web-ui/internal/models"
+)
+
+// recentActivityLimit bounds UserProfile.RecentActivity
+const recentActivityLimit = 10
+
+// Challenge statuses in a profile
+const (
+	StatusSolved    = "solved"
+	StatusAttempted = "attempted"
+)
+
+// Activity types in a profile
+const (
+	ActivitySolved    = "solved"
+	ActivitySubmitted = "submitted"
+)
+
+// UserProfile summarises a user's progress
+type UserProfile struct {
+	Username        string              `json:"username"`
+	Rank            int                 `json:"rank" doc:"Position on the main leaderboard, 0 when unranked"`
+	Achievement     string              `json:"achievement" doc:"Achievement tier earned by the solved count"`
+	SolvedCount     int                 `json:"solvedCount"`
+	TotalChallenges int                 `json:"totalChallenges"`
+	CurrentStreak   int                 `json:"currentStreak" doc:"Consecutive days up to today with a solve or submission"`
+	LongestStreak   int                 `json:"longestStreak" doc:"Most consecutive days with a solve or submission"`
+	Challenges      []ChallengeProgress `json:"challenges" doc:"Attempted and solved challenges ordered by ID"`
+	RecentActivity  []Activity          `json:"recentActivity" doc:"Latest solves and submissions, newest first"`
+}
+
+// ChallengeProgress is a user's standing on one challenge
+type ChallengeProgress struct {
+	ChallengeID   int        `json:"challengeId"`
+	Title         string     `json:"title"`
+	Difficulty    string     `json:"difficulty"`
+	Status        string     `json:"status" enum:"solved,attempted"`
+	Score         int        `json:"score" doc:"Percentage of tests passed (0-100)"`
+	FirstSolvedAt *time.Time `json:"firstSolvedAt,omitempty" doc:"When the challenge was first solved, if known"`
+}
+
+// Activity is a solve or submission in a user's history
+type Activity struct {
+	Type        string    `json:"type" enum:"solved,submitted"`
+	ChallengeID int       `json:"challengeId"`
+	Title       string    `json:"title"`
+	Time        time.Time `json:"time"`
+	Passed      bool      `json:"passed"`
+}
+
+// ActivitySummary is a user's, or the whole team's, daily activity
+type ActivitySummary struct {
+	Username      string     `json:"username,omitempty" doc:"Empty for the whole team"`
+	CurrentStreak int        `json:"currentStreak" doc:"Consecutive days up to today with a solve, commit or submission"`
+	LongestStreak int        `json:"longestStreak" doc:"Most consecutive days with a solve, commit or submission"`
+	ActiveDays    int        `json:"activeDays" doc:"Days in the series with at least one event"`
+	Days          []DayCount `json:"days" doc:"One entry per day, oldest first, ending today"`
+}
+
+// ProfileService builds user profiles from scoreboards, git history,
+// submission files and the submissions made since the server started
+type ProfileService struct {
+	challengeService   *ChallengeService
+	scoreboardService  *ScoreboardService
+	leaderboardService *LeaderboardService
+	userService        *UserService
+	submissionService  *SubmissionService
+	historyService     *HistoryService
+}
+
+// NewProfileService creates a new profile service
+func NewProfileService(
+	challengeService *ChallengeService,
+	scoreboardService *ScoreboardService,
+	leaderboardService *LeaderboardService,
+	userService *UserService,
+	submissionService *SubmissionService,
+	historyService *HistoryService,
+) *ProfileService {
+	return &ProfileService{
+		challengeService:   challengeService,
+		scoreboardService:  scoreboardService,
+		leaderboardService: leaderboardService,
+		userService:        userService,
+		submissionService:  submissionService,
+		historyService:     historyService,
+	}
+}
+
+// userHistory is everything known about when a user worked on challenges
+type userHistory struct {
+	attempts   map[int]int // challenge -> score from the submission file
+	solved     map[int]bool
+	solvedAt   map[int]time.Time
+	activity   []Activity
+	timestamps []time.Time // every activity event and submission commit
+}
+
+// history gathers a user's solves and activity. A challenge is solved when
+// its scoreboard shows every test passing or the user submitted a passing
+// solution to this server. A solve is dated by the earlier of the first
+// passing submission and the first commit of the user's submission, or else
+// by the time the submission file was written.
+func (ps *ProfileService) history(username string, challenges models.ChallengeMap, completions map[string]map[int]bool) *userHistory {
+	attempts := ps.userService.GetUserAttempts(username, challenges)
+	submissions := ps.submissionService.ByUser(username)
+	h := &userHistory{
+		attempts: make(map[int]int),
+		solved:   make(map[int]bool),
+		solvedAt: make(map[int]time.Time),
+	}
+	for id := range attempts.AttemptedIDs {
+		h.attempts[id] = attempts.Scores[id]
+	}
+	for id := range completions[username] {
+		h.solved[id] = true
+	}
+
+	// Submissions are newest first, so the last passing one is the first solve
+	firstPass := make(map[int]int)
+	for i, submission := range submissions {
+		if _, ok := challenges[submission.ChallengeID]; !ok {
+			continue
+		}
+		if _, ok := h.attempts[submission.ChallengeID]; !ok {
+			h.attempts[submission.ChallengeID] = 0
+		}
+		if submission.Passed {
+			h.solved[submission.ChallengeID] = true
+			firstPass[submission.ChallengeID] = i
+			h.solvedAt[submission.ChallengeID] = submission.SubmittedAt
+		}
+	}
+
+	for id := range h.solved {
+		if committedAt, ok := ps.historyService.SolvedAt(username, id); ok {
+			if passedAt, passed := h.solvedAt[id]; !passed || committedAt.Before(passedAt) {
+				h.solvedAt[id] = committedAt
+				delete(firstPass, id)
+			}
+		} else if _, passed := h.solvedAt[id]; !passed {
+			if writtenAt, ok := ps.userService.SubmissionTime(username, id); ok {
+				h.solvedAt[id] = writtenAt
+			}
+		}
+		if _, ok := firstPass[id]; !ok {
+			if solvedAt, ok := h.solvedAt[id]; ok {
+				h.activity = append(h.activity, Activity{Type: ActivitySolved, ChallengeID: id, Title: challenges[id].Title, Time: solvedAt, Passed: true})
+			}
+		}
+	}
+
+	for i, submission := range submissions {
+		challenge, ok := challenges[submission.ChallengeID]
+		if !ok {
+			continue
+		}
+		kind := ActivitySubmitted
+		if first, ok := firstPass[submission.ChallengeID]; ok && first == i {
+			kind = ActivitySolved
+		}
+		h.activity = append(h.activity, Activity{
+			Type:        kind,
+			ChallengeID: submission.ChallengeID,
+			Title:       challenge.Title,
+			Time:        submission.SubmittedAt,
+			Passed:      submission.Passed,
+		})
+	}
+	sort.SliceStable(h.activity, func(i, j int) bool { return h.activity[i].Time.After(h.activity[j].Time) })
+
+	for _, event := range h.activity {
+		h.timestamps = append(h.timestamps, event.Time)
+	}
+	h.timestamps = append(h.timestamps, ps.historyService.Commits(username)...)
+	return h
+}
+
+// Profile computes a user's profile as of now
+func (ps *ProfileService) Profile(username string, now time.Time) *UserProfile {
+	challenges := ps.challengeService.GetChallenges()
+	h := ps.history(username, challenges, ps.scoreboardService.Completions(challenges))
+
+	profile := &UserProfile{
+		Username:        username,
+		Rank:            ps.leaderboardService.Rank(username, now),
+		TotalChallenges: len(challenges),
+		Challenges:      []ChallengeProgress{},
+		RecentActivity:  []Activity{},
+	}
+
+	for _, id := range sortedIDs(challenges) {
+		score, attempted := h.attempts[id]
+		if !h.solved[id] && !attempted {
+			continue
+		}
+		challenge := challenges[id]
+		progress := ChallengeProgress{
+			ChallengeID: id,
+			Title:       challenge.Title,
+			Difficulty:  challenge.Difficulty,
+			Status:      StatusAttempted,
+			Score:       score,
+		}
+		if h.solved[id] {
+			profile.SolvedCount++
+			progress.Status = StatusSolved
+			progress.Score = 100
+			if solvedAt, ok := h.solvedAt[id]; ok {
+				progress.FirstSolvedAt = &solvedAt
+			}
+		}
+		profile.Challenges = append(profile.Challenges, progress)
+	}
+
+	profile.CurrentStreak, profile.LongestStreak = Streaks(h.timestamps, now)
+	profile.Achievement = ps.leaderboardService.Achievement(profile.SolvedCount)
+
+	activity := h.activity
+	if len(activity) > recentActivityLimit {
+		activity = activity[:recentActivityLimit]
+	}
+	profile.RecentActivity = append(profile.RecentActivity, activity...)
+	return profile
+}
+
+// Activity summarises a user's activity over the days days ending today
+func (ps *ProfileService) Activity(username string, now time.Time, days int) *ActivitySummary {
+	challenges := ps.challengeService.GetChallenges()
+	h := ps.history(username, challenges, ps.scoreboardService.Completions(challenges))
+	summary := summarizeActivity(h.timestamps, now, days)
+	summary.Username = username
+	return summary
+}
+
+// Streaks returns a user's current and longest activity streaks
+func (ps *ProfileService) Streaks(username string, now time.Time) (current, longest int) {
+	challenges := ps.challengeService.GetChallenges()
+	return Streaks(ps.history(username, challenges, ps.scoreboardService.Completions(challenges)).timestamps, now)
+}
+
+// TeamActivity summarises the activity of every user with a solve, commit
+// or submission; a day counts towards the team's streaks if anyone was active
+func (ps *ProfileService) TeamActivity(now time.Time, days int) *ActivitySummary {
+	challenges := ps.challengeService.GetChallenges()
+	completions := ps.scoreboardService.Completions(challenges)
+
+	users := make(map[string]bool)
+	for username := range completions {
+		users[username] = true
+	}
+	for _, username := range ps.historyService.Users() {
+		users[username] = true
+	}
+	for _, submission := range ps.submissionService.All() {
+		users[submission.Username] = true
+	}
+
+	var timestamps []time.Time
+	for username := range users {
+		timestamps = append(timestamps, ps.history(username, challenges, completions).timestamps...)
+	}
+	return summarizeActivity(timestamps, now, days)
+}
+
+func summarizeActivity(timestamps []time.Time, now time.Time, days int) *ActivitySummary {
+	summary := &ActivitySummary{Days: DailySeries(timestamps, now, days)}
+	summary.CurrentStreak, summary.LongestStreak = Streaks(timestamps, now)
+	for _, day := range summary.Days {
+		if day.Count > 0 {
+			summary.ActiveDays++
+		}
+	}
+	return summary
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"web-ui/internal/models"
+	"web-ui/internal/paths"
+)
+
+func TestStreaks(t *testing.T) {
+	now := time.Date(2024, 3, 10, 18, 0, 0, 0, time.UTC)
+	day := func(offset int) time.Time { return now.AddDate(0, 0, offset) }
+
+	tests := []struct {
+		name                  string
+		times                 []time.Time
+		wantCurrent, wantLong int
+	}{
+		{"no activity", nil, 0, 0},
+		{"today only", []time.Time{day(0)}, 1, 1},
+		{"streak ending yesterday is kept", []time.Time{day(-1), day(-2)}, 2, 2},
+		{"gap breaks the current streak", []time.Time{day(-2), day(-3), day(-4)}, 0, 3},
+		{"same day counts once", []time.Time{day(0), day(0).Add(-time.Hour), day(-1)}, 2, 2},
+		{"longest run in the past", []time.Time{day(0), day(-5), day(-6), day(-7)}, 1, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current, longest := Streaks(tt.times, now)
+			if current != tt.wantCurrent || longest != tt.wantLong {
+				t.Errorf("Streaks = %d, %d, want %d, %d", current, longest, tt.wantCurrent, tt.wantLong)
+			}
+		})
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// writeFiles writes files, mapping slash-separated paths to their content,
+// under dir
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		writeFile(t, filepath.Join(dir, name), content)
+	}
+}
+
+func TestProfile(t *testing.T) {
+	root := t.TempDir()
+	for id := 1; id <= 3; id++ {
+		writeChallenge(t, root, id, "")
+	}
+	header := "| Username | Passed Tests | Total Tests |\n|------|------|------|\n"
+	writeFile(t, filepath.Join(root, "challenge-1", paths.ScoreboardFileName), header+"| gopher | 2 | 2 |\n| rival | 2 | 2 |\n")
+	writeFile(t, filepath.Join(root, "challenge-2", paths.ScoreboardFileName), header+"| rival | 2 | 2 |\n")
+
+	// The scoreboard solve is dated by the submission file
+	solutionFile := filepath.Join(root, "challenge-1", "submissions", "gopher", paths.SolutionFileName)
+	writeFile(t, solutionFile, "package main\n")
+	now := time.Date(2024, 3, 10, 18, 0, 0, 0, time.UTC)
+	fileTime := now.AddDate(0, 0, -1)
+	if err := os.Chtimes(solutionFile, fileTime, fileTime); err != nil {
+		t.Fatal(err)
+	}
+
+	resolver, err := paths.NewResolver(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	challengeService := NewChallengeService(resolver)
+	if err := challengeService.LoadChallenges(); err != nil {
+		t.Fatal(err)
+	}
+	submissions := NewSubmissionService()
+	submissions.Add(models.Submission{Username: "gopher", ChallengeID: 2, SubmittedAt: now.Add(-3 * time.Hour)})
+	submissions.Add(models.Submission{Username: "gopher", ChallengeID: 3, SubmittedAt: now.Add(-2 * time.Hour), Passed: true})
+	submissions.Add(models.Submission{Username: "gopher", ChallengeID: 3, SubmittedAt: now.Add(-time.Hour), Passed: true})
+	submissions.Add(models.Submission{Username: "rival", ChallengeID: 3, SubmittedAt: now, Passed: true})
+
+	scoreboardService := NewScoreboardService(resolver)
+	leaderboard := NewLeaderboardService(challengeService, scoreboardService, ScorerFunc(completionPoints), nil)
+	ps := NewProfileService(challengeService, scoreboardService, leaderboard, NewUserService(resolver), submissions, NewHistoryService(resolver))
+	profile := ps.Profile("gopher", now)
+
+	if profile.Rank != 2 || profile.SolvedCount != 2 || profile.TotalChallenges != 3 {
+		t.Errorf("rank, solved, total = %d, %d, %d, want 2, 2, 3", profile.Rank, profile.SolvedCount, profile.TotalChallenges)
+	}
+	if profile.CurrentStreak != 2 || profile.LongestStreak != 2 {
+		t.Errorf("streaks = %d, %d, want 2, 2", profile.CurrentStreak, profile.LongestStreak)
+	}
+	if profile.Achievement != DefaultTiers[0].Name {
+		t.Errorf("achievement = %q", profile.Achievement)
+	}
+
+	if len(profile.Challenges) != 3 {
+		t.Fatalf("challenges = %+v, want 3", profile.Challenges)
+	}
+	wantStatus := []string{StatusSolved, StatusAttempted, StatusSolved}
+	wantSolvedAt := []time.Time{fileTime, {}, now.Add(-2 * time.Hour)}
+	for i, progress := range profile.Challenges {
+		if progress.ChallengeID != i+1 || progress.Status != wantStatus[i] {
+			t.Errorf("challenge %d = %+v, want status %s", i+1, progress, wantStatus[i])
+		}
+		switch {
+		case wantSolvedAt[i].IsZero() && progress.FirstSolvedAt != nil:
+			t.Errorf("challenge %d first solved at %v, want unsolved", i+1, progress.FirstSolvedAt)
+		case !wantSolvedAt[i].IsZero() && (progress.FirstSolvedAt == nil || !progress.FirstSolvedAt.Equal(wantSolvedAt[i])):
+			t.Errorf("challenge %d first solved at %v, want %v", i+1, progress.FirstSolvedAt, wantSolvedAt[i])
+		}
+	}
+
+	var kinds []string
+	for _, event := range profile.RecentActivity {
+		kinds = append(kinds, event.Type)
+	}
+	if want := []string{ActivitySubmitted, ActivitySolved, ActivitySubmitted, ActivitySolved}; !reflect.DeepEqual(kinds, want) {
+		t.Errorf("activity = %v, want %v", kinds, want)
+	}
+
+	if empty := ps.Profile("nobody", now); empty.Rank != 0 || empty.SolvedCount != 0 || len(empty.Challenges) != 0 {
+		t.Errorf("Profile(nobody) = %+v", empty)
+	}
+}
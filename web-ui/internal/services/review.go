@@ -0,0 +1,647 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"web-ui/internal/diff"
+	"web-ui/internal/models"
+	"web-ui/internal/paths"
+	"web-ui/internal/store"
+)
+
+// Review limits
+const (
+	MaxReviewTextLength     = 4000
+	maxNotificationsPerUser = 200
+)
+
+var (
+	// ErrReviewNotFound is returned for an unknown review or thread, and for
+	// reviews the user takes no part in
+	ErrReviewNotFound = errors.New("review not found")
+	// ErrInvalidReview is returned for an empty or overlong comment, an
+	// unknown verdict or an anchor outside the solution
+	ErrInvalidReview = errors.New("invalid review")
+	// ErrNotTeammate is returned when reviewing a user who shares no team
+	// with the reviewer
+	ErrNotTeammate = errors.New("only teammates can review a solution")
+	// ErrNotReviewer is returned when anyone but the reviewer sets the verdict
+	ErrNotReviewer = errors.New("only the reviewer can set the verdict")
+)
+
+// ReviewSummary describes a review without its threads
+type ReviewSummary struct {
+	models.Review
+	Threads     int `json:"threads"`
+	OpenThreads int `json:"openThreads"`
+}
+
+// ReviewThreadDetail is a thread placed on the author's latest version
+type ReviewThreadDetail struct {
+	models.ReviewThread
+	Line     int  `json:"line" doc:"The anchored line in the latest version; for outdated threads, the line now at its place"`
+	Outdated bool `json:"outdated" doc:"The anchored line was changed or removed since the thread was started"`
+}
+
+// ReviewDetail is a review with its threads placed on the author's latest
+// version of the solution
+type ReviewDetail struct {
+	models.Review
+	LatestVersion int                  `json:"latestVersion"`
+	Code          string               `json:"code" doc:"The author's latest version, which thread lines refer to"`
+	Threads       []ReviewThreadDetail `json:"threads" doc:"Oldest first"`
+}
+
+// NotificationFeed is a user's review notifications
+type NotificationFeed struct {
+	Notifications []models.Notification `json:"notifications" doc:"Newest first"`
+	Unread        int                   `json:"unread"`
+}
+
+// reviewRecord is a review as stored. It keeps the code of every version a
+// thread is anchored to, so anchors still map onto later versions once the
+// author's drafts no longer hold them.
+type reviewRecord struct {
+	models.Review
+	Threads []models.ReviewThread `json:"threads"`
+	Code    map[int]string        `json:"code"`
+}
+
+// ReviewService stores reviews of users' saved solutions and the
+// notifications they raise. Each review is kept in dir as <id>.json and the
+// notifications in notifications.json, all rewritten atomically on every
+// change. An empty dir keeps everything in memory.
+type ReviewService struct {
+	drafts *DraftService
+	teams  *TeamService
+	dir    string
+
+	mu            sync.Mutex
+	reviews       map[string]*reviewRecord
+	notifications map[string][]models.Notification // by lowercase username, oldest first
+}
+
+// NewReviewService loads the reviews and notifications stored in dir
+func NewReviewService(drafts *DraftService, teams *TeamService, dir string) (*ReviewService, error) {
+	rs := &ReviewService{
+		drafts:        drafts,
+		teams:         teams,
+		dir:           dir,
+		reviews:       make(map[string]*reviewRecord),
+		notifications: make(map[string][]models.Notification),
+	}
+	if dir == "" {
+		return rs, nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read review: %v", err)
+		}
+		if filepath.Base(file) == "notifications.json" {
+			if err := json.Unmarshal(content, &rs.notifications); err != nil {
+				return nil, fmt.Errorf("failed to parse %s: %v", file, err)
+			}
+			continue
+		}
+		var record reviewRecord
+		if err := json.Unmarshal(content, &record); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %v", file, err)
+		}
+		if record.ID+".json" != filepath.Base(file) {
+			return nil, fmt.Errorf("%s: review ID %q does not match the file name", file, record.ID)
+		}
+		rs.reviews[record.ID] = &record
+	}
+	return rs, nil
+}
+
+// Teammates reports whether two different users share a team
+func (rs *ReviewService) Teammates(a, b string) bool {
+	if strings.EqualFold(a, b) {
+		return false
+	}
+	for _, team := range rs.teams.TeamsOf(a) {
+		if team.HasMember(b) {
+			return true
+		}
+	}
+	return false
+}
+
+// Create starts a review of a version of author's saved solution to a
+// challenge; version 0 reviews the latest one. Autosaves cannot be reviewed.
+func (rs *ReviewService) Create(reviewer, author string, challengeID, version int, summary string, now time.Time) (ReviewDetail, error) {
+	summary = strings.TrimSpace(summary)
+	if len(summary) > MaxReviewTextLength {
+		return ReviewDetail{}, fmt.Errorf("%w: the summary is longer than %d characters", ErrInvalidReview, MaxReviewTextLength)
+	}
+	if strings.EqualFold(reviewer, author) {
+		return ReviewDetail{}, fmt.Errorf("%w: you cannot review your own solution", ErrInvalidReview)
+	}
+	if !rs.Teammates(reviewer, author) {
+		return ReviewDetail{}, ErrNotTeammate
+	}
+	draft, err := rs.draft(author, challengeID, version)
+	if err != nil {
+		return ReviewDetail{}, err
+	}
+
+	id, err := randomString(8)
+	if err != nil {
+		return ReviewDetail{}, err
+	}
+	record := &reviewRecord{
+		Review: models.Review{
+			ID:          id,
+			ChallengeID: challengeID,
+			Author:      author,
+			Reviewer:    reviewer,
+			Version:     draft.Version,
+			Verdict:     models.VerdictPending,
+			Summary:     summary,
+			CreatedAt:   now.UTC(),
+			UpdatedAt:   now.UTC(),
+		},
+		Threads: []models.ReviewThread{},
+		Code:    map[int]string{draft.Version: draft.Code},
+	}
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if err := rs.save(record); err != nil {
+		return ReviewDetail{}, err
+	}
+	rs.reviews[id] = record
+	rs.notify([]string{author}, models.Notification{
+		Kind:    models.NotificationReview,
+		Actor:   reviewer,
+		Message: fmt.Sprintf("%s started reviewing your solution to challenge %d", reviewer, challengeID),
+	}, record, now)
+	return rs.detail(record), nil
+}
+
+// Reviews lists the reviews username wrote or received, most recently
+// updated first. A challengeID of 0 lists every challenge's.
+func (rs *ReviewService) Reviews(username string, challengeID int) []ReviewSummary {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	summaries := []ReviewSummary{}
+	for _, record := range rs.reviews {
+		if challengeID != 0 && record.ChallengeID != challengeID {
+			continue
+		}
+		if !strings.EqualFold(record.Author, username) && !strings.EqualFold(record.Reviewer, username) {
+			continue
+		}
+		summary := ReviewSummary{Review: record.Review, Threads: len(record.Threads)}
+		for _, thread := range record.Threads {
+			if !thread.Resolved {
+				summary.OpenThreads++
+			}
+		}
+		summaries = append(summaries, summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if !summaries[i].UpdatedAt.Equal(summaries[j].UpdatedAt) {
+			return summaries[i].UpdatedAt.After(summaries[j].UpdatedAt)
+		}
+		return summaries[i].ID < summaries[j].ID
+	})
+	return summaries
+}
+
+// Review returns a review the viewer takes part in: as its author, its
+// reviewer or a teammate of the author
+func (rs *ReviewService) Review(viewer, id string) (ReviewDetail, error) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	record, err := rs.record(viewer, id)
+	if err != nil {
+		return ReviewDetail{}, err
+	}
+	return rs.detail(record), nil
+}
+
+// SetVerdict records the reviewer's verdict and, unless empty, a new summary
+func (rs *ReviewService) SetVerdict(reviewer, id, verdict, summary string, now time.Time) (ReviewDetail, error) {
+	switch verdict {
+	case models.VerdictPending, models.VerdictApproved, models.VerdictChangesRequested, models.VerdictCommented:
+	default:
+		return ReviewDetail{}, fmt.Errorf("%w: unknown verdict %q", ErrInvalidReview, verdict)
+	}
+	summary = strings.TrimSpace(summary)
+	if len(summary) > MaxReviewTextLength {
+		return ReviewDetail{}, fmt.Errorf("%w: the summary is longer than %d characters", ErrInvalidReview, MaxReviewTextLength)
+	}
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	record, err := rs.record(reviewer, id)
+	if err != nil {
+		return ReviewDetail{}, err
+	}
+	if !strings.EqualFold(record.Reviewer, reviewer) {
+		return ReviewDetail{}, ErrNotReviewer
+	}
+	updated := copyReview(record)
+	updated.Verdict = verdict
+	if summary != "" {
+		updated.Summary = summary
+	}
+	updated.UpdatedAt = now.UTC()
+	if err := rs.save(updated); err != nil {
+		return ReviewDetail{}, err
+	}
+	rs.reviews[id] = updated
+
+	action := map[string]string{
+		models.VerdictPending:          "withdrew the verdict on",
+		models.VerdictApproved:         "approved",
+		models.VerdictChangesRequested: "requested changes to",
+		models.VerdictCommented:        "commented on",
+	}[verdict]
+	rs.notify([]string{record.Author}, models.Notification{
+		Kind:    models.NotificationVerdict,
+		Actor:   reviewer,
+		Message: fmt.Sprintf("%s %s your solution to challenge %d", reviewer, action, record.ChallengeID),
+	}, updated, now)
+	return rs.detail(updated), nil
+}
+
+// AddThread starts a thread on a line of a version of the reviewed
+// solution. An empty file names the solution file and version 0 the
+// author's latest version.
+func (rs *ReviewService) AddThread(actor, id string, anchor models.ReviewAnchor, body string, now time.Time) (ReviewThreadDetail, error) {
+	body, err := commentBody(body)
+	if err != nil {
+		return ReviewThreadDetail{}, err
+	}
+	if anchor.File == "" {
+		anchor.File = paths.SolutionFileName
+	}
+	if anchor.File != paths.SolutionFileName {
+		return ReviewThreadDetail{}, fmt.Errorf("%w: only %s can be commented on", ErrInvalidReview, paths.SolutionFileName)
+	}
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	record, err := rs.record(actor, id)
+	if err != nil {
+		return ReviewThreadDetail{}, err
+	}
+	code, ok := record.Code[anchor.Version]
+	if !ok || anchor.Version == 0 {
+		draft, err := rs.draft(record.Author, record.ChallengeID, anchor.Version)
+		if err != nil {
+			return ReviewThreadDetail{}, err
+		}
+		anchor.Version, code = draft.Version, draft.Code
+	}
+	if lines := len(strings.SplitAfter(strings.TrimSuffix(code, "\n"), "\n")); anchor.Line < 1 || anchor.Line > lines {
+		return ReviewThreadDetail{}, fmt.Errorf("%w: line %d is outside version %d, which has %d lines", ErrInvalidReview, anchor.Line, anchor.Version, lines)
+	}
+
+	updated := copyReview(record)
+	updated.Code[anchor.Version] = code
+	thread := models.ReviewThread{
+		ID:       len(updated.Threads) + 1,
+		Anchor:   anchor,
+		Comments: []models.ReviewComment{{ID: countComments(updated) + 1, Author: actor, Body: body, CreatedAt: now.UTC()}},
+	}
+	updated.Threads = append(updated.Threads, thread)
+	updated.UpdatedAt = now.UTC()
+	if err := rs.save(updated); err != nil {
+		return ReviewThreadDetail{}, err
+	}
+	rs.reviews[id] = updated
+
+	rs.notifyThread(actor, updated, thread, models.NotificationComment, fmt.Sprintf("commented on line %d of", anchor.Line), now)
+	return rs.threadDetail(updated, thread), nil
+}
+
+// Reply adds a comment to a thread
+func (rs *ReviewService) Reply(actor, id string, threadID int, body string, now time.Time) (ReviewThreadDetail, error) {
+	body, err := commentBody(body)
+	if err != nil {
+		return ReviewThreadDetail{}, err
+	}
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	record, err := rs.record(actor, id)
+	if err != nil {
+		return ReviewThreadDetail{}, err
+	}
+	if threadID < 1 || threadID > len(record.Threads) {
+		return ReviewThreadDetail{}, fmt.Errorf("%w: thread %d", ErrReviewNotFound, threadID)
+	}
+	updated := copyReview(record)
+	thread := &updated.Threads[threadID-1]
+	thread.Comments = append(thread.Comments, models.ReviewComment{ID: countComments(updated) + 1, Author: actor, Body: body, CreatedAt: now.UTC()})
+	updated.UpdatedAt = now.UTC()
+	if err := rs.save(updated); err != nil {
+		return ReviewThreadDetail{}, err
+	}
+	rs.reviews[id] = updated
+
+	rs.notifyThread(actor, updated, *thread, models.NotificationComment, "replied to a thread on", now)
+	return rs.threadDetail(updated, *thread), nil
+}
+
+// SetResolved resolves or reopens a thread
+func (rs *ReviewService) SetResolved(actor, id string, threadID int, resolved bool, now time.Time) (ReviewThreadDetail, error) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	record, err := rs.record(actor, id)
+	if err != nil {
+		return ReviewThreadDetail{}, err
+	}
+	if threadID < 1 || threadID > len(record.Threads) {
+		return ReviewThreadDetail{}, fmt.Errorf("%w: thread %d", ErrReviewNotFound, threadID)
+	}
+	if record.Threads[threadID-1].Resolved == resolved {
+		return rs.threadDetail(record, record.Threads[threadID-1]), nil
+	}
+
+	updated := copyReview(record)
+	thread := &updated.Threads[threadID-1]
+	thread.Resolved, thread.ResolvedBy, thread.ResolvedAt = resolved, "", nil
+	kind, action := models.NotificationUnresolved, "reopened a thread on"
+	if resolved {
+		at := now.UTC()
+		thread.ResolvedBy, thread.ResolvedAt = actor, &at
+		kind, action = models.NotificationResolved, "resolved a thread on"
+	}
+	updated.UpdatedAt = now.UTC()
+	if err := rs.save(updated); err != nil {
+		return ReviewThreadDetail{}, err
+	}
+	rs.reviews[id] = updated
+
+	rs.notifyThread(actor, updated, *thread, kind, action, now)
+	return rs.threadDetail(updated, *thread), nil
+}
+
+// Notifications returns username's notifications, newest first
+func (rs *ReviewService) Notifications(username string, unreadOnly bool) NotificationFeed {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	feed := NotificationFeed{Notifications: []models.Notification{}}
+	stored := rs.notifications[strings.ToLower(username)]
+	for i := len(stored) - 1; i >= 0; i-- {
+		if !stored[i].Read {
+			feed.Unread++
+		} else if unreadOnly {
+			continue
+		}
+		feed.Notifications = append(feed.Notifications, stored[i])
+	}
+	return feed
+}
+
+// MarkRead marks username's notifications up to and including upTo as read;
+// 0 marks them all. It returns how many stay unread.
+func (rs *ReviewService) MarkRead(username string, upTo int) (int, error) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	key := strings.ToLower(username)
+	stored := rs.notifications[key]
+	updated := make([]models.Notification, len(stored))
+	unread, changed := 0, false
+	for i, notification := range stored {
+		if !notification.Read && (upTo == 0 || notification.ID <= upTo) {
+			notification.Read, changed = true, true
+		}
+		if !notification.Read {
+			unread++
+		}
+		updated[i] = notification
+	}
+	if !changed {
+		return unread, nil
+	}
+	rs.notifications[key] = updated
+	if err := rs.saveNotifications(); err != nil {
+		rs.notifications[key] = stored
+		return 0, err
+	}
+	return unread, nil
+}
+
+// draft returns a version of a user's saved solution, the latest for 0.
+// Drafts, autosaves and restores are the author's private work in progress
+// and are never reviewed.
+func (rs *ReviewService) draft(username string, challengeID, version int) (models.DraftVersion, error) {
+	if version == 0 {
+		return rs.drafts.LatestSaved(username, challengeID)
+	}
+	draft, err := rs.drafts.Version(username, challengeID, version)
+	if err == nil && draft.Source != models.DraftSourceSaved {
+		return models.DraftVersion{}, ErrDraftNotFound
+	}
+	return draft, err
+}
+
+// record returns a review the viewer may see. Callers must hold rs.mu.
+func (rs *ReviewService) record(viewer, id string) (*reviewRecord, error) {
+	record, ok := rs.reviews[id]
+	if !ok {
+		return nil, ErrReviewNotFound
+	}
+	if !strings.EqualFold(record.Author, viewer) && !strings.EqualFold(record.Reviewer, viewer) && !rs.Teammates(viewer, record.Author) {
+		return nil, ErrReviewNotFound
+	}
+	return record, nil
+}
+
+// detail places a review's threads on the author's latest saved version, or
+// on the reviewed version once the author has no saved drafts left. Callers
+// must hold rs.mu.
+func (rs *ReviewService) detail(record *reviewRecord) ReviewDetail {
+	detail := ReviewDetail{
+		Review:        record.Review,
+		LatestVersion: record.Version,
+		Code:          record.Code[record.Version],
+		Threads:       []ReviewThreadDetail{},
+	}
+	if latest, err := rs.drafts.LatestSaved(record.Author, record.ChallengeID); err == nil {
+		detail.LatestVersion, detail.Code = latest.Version, latest.Code
+	}
+	for _, thread := range record.Threads {
+		detail.Threads = append(detail.Threads, placeThread(record, thread, detail.LatestVersion, detail.Code))
+	}
+	return detail
+}
+
+// threadDetail places one thread on the author's latest saved version. Callers
+// must hold rs.mu.
+func (rs *ReviewService) threadDetail(record *reviewRecord, thread models.ReviewThread) ReviewThreadDetail {
+	latestVersion, code := record.Version, record.Code[record.Version]
+	if latest, err := rs.drafts.LatestSaved(record.Author, record.ChallengeID); err == nil {
+		latestVersion, code = latest.Version, latest.Code
+	}
+	return placeThread(record, thread, latestVersion, code)
+}
+
+// placeThread maps a thread's anchor onto a later version through a diff of
+// the two versions
+func placeThread(record *reviewRecord, thread models.ReviewThread, version int, code string) ReviewThreadDetail {
+	detail := ReviewThreadDetail{ReviewThread: thread, Line: thread.Anchor.Line}
+	if thread.Anchor.Version == version {
+		return detail
+	}
+	line, ok := diff.MapLine(record.Code[thread.Anchor.Version], code, thread.Anchor.Line)
+	detail.Line, detail.Outdated = line, !ok
+	return detail
+}
+
+// notifyThread tells the review's author and reviewer and everyone in the
+// thread, other than the actor, about activity on it. Callers must hold
+// rs.mu.
+func (rs *ReviewService) notifyThread(actor string, record *reviewRecord, thread models.ReviewThread, kind, action string, now time.Time) {
+	recipients := []string{record.Author, record.Reviewer}
+	for _, comment := range thread.Comments {
+		recipients = append(recipients, comment.Author)
+	}
+	var notified []string
+	for _, recipient := range recipients {
+		if strings.EqualFold(recipient, actor) || containsFold(notified, recipient) {
+			continue
+		}
+		notified = append(notified, recipient)
+		whose := record.Author + "'s"
+		if strings.EqualFold(recipient, record.Author) {
+			whose = "your"
+		}
+		rs.notify([]string{recipient}, models.Notification{
+			Kind:     kind,
+			ThreadID: thread.ID,
+			Actor:    actor,
+			Message:  fmt.Sprintf("%s %s %s solution to challenge %d", actor, action, whose, record.ChallengeID),
+		}, record, now)
+	}
+}
+
+// notify adds a notification about a review to each recipient's feed. A
+// feed that cannot be saved is only logged, since the review change it
+// reports has been. Callers must hold rs.mu.
+func (rs *ReviewService) notify(recipients []string, notification models.Notification, record *reviewRecord, now time.Time) {
+	notification.ReviewID = record.ID
+	notification.ChallengeID = record.ChallengeID
+	notification.CreatedAt = now.UTC()
+	for _, recipient := range recipients {
+		key := strings.ToLower(recipient)
+		feed := rs.notifications[key]
+		notification.ID = 1
+		if len(feed) > 0 {
+			notification.ID = feed[len(feed)-1].ID + 1
+		}
+		feed = append(feed, notification)
+		if len(feed) > maxNotificationsPerUser {
+			feed = append([]models.Notification{}, feed[len(feed)-maxNotificationsPerUser:]...)
+		}
+		rs.notifications[key] = feed
+	}
+	if err := rs.saveNotifications(); err != nil {
+		slog.Error("failed to save review notifications", "review", record.ID, "err", err)
+	}
+}
+
+// save writes a review atomically. Callers must hold rs.mu.
+func (rs *ReviewService) save(record *reviewRecord) error {
+	if rs.dir == "" {
+		return nil
+	}
+	content, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return err
+	}
+	return rs.write(record.ID+".json", content)
+}
+
+// saveNotifications writes every feed atomically. Callers must hold rs.mu.
+func (rs *ReviewService) saveNotifications() error {
+	if rs.dir == "" {
+		return nil
+	}
+	content, err := json.MarshalIndent(rs.notifications, "", "  ")
+	if err != nil {
+		return err
+	}
+	return rs.write("notifications.json", content)
+}
+
+func (rs *ReviewService) write(name string, content []byte) error {
+	if err := store.WriteFile(filepath.Join(rs.dir, name), content); err != nil {
+		return fmt.Errorf("failed to write %s: %v", name, err)
+	}
+	return nil
+}
+
+// commentBody trims a comment and checks it is within the limits
+func commentBody(body string) (string, error) {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return "", fmt.Errorf("%w: the comment is empty", ErrInvalidReview)
+	}
+	if len(body) > MaxReviewTextLength {
+		return "", fmt.Errorf("%w: the comment is longer than %d characters", ErrInvalidReview, MaxReviewTextLength)
+	}
+	return body, nil
+}
+
+func countComments(record *reviewRecord) int {
+	count := 0
+	for _, thread := range record.Threads {
+		count += len(thread.Comments)
+	}
+	return count
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// copyReview deep-copies a review so it can be changed and saved before
+// replacing the original
+func copyReview(record *reviewRecord) *reviewRecord {
+	copied := *record
+	copied.Threads = make([]models.ReviewThread, len(record.Threads))
+	for i, thread := range record.Threads {
+		thread.Comments = append([]models.ReviewComment{}, thread.Comments...)
+		copied.Threads[i] = thread
+	}
+	copied.Code = make(map[int]string, len(record.Code))
+	for version, code := range record.Code {
+		copied.Code[version] = code
+	}
+	return &copied
+}
@@ -0,0 +1,218 @@
+package services
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"web-ui/internal/models"
+)
+
+func newTestReviewService(t *testing.T, dir string) (*ReviewService, *DraftService) {
+	t.Helper()
+	root := t.TempDir()
+	writeChallenge(t, root, 1, "")
+	writeFile(t, filepath.Join(root, TeamsFileName), `[{"name": "Gophers", "members": ["alice", "bob", "carol"]}]`)
+	challengeService := NewChallengeService(mustResolver(t, root))
+	if err := challengeService.LoadChallenges(); err != nil {
+		t.Fatal(err)
+	}
+	teams, err := NewTeamService(mustResolver(t, root), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	drafts := NewDraftService(challengeService, "")
+	reviews, err := NewReviewService(drafts, teams, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return reviews, drafts
+}
+
+func TestReviewService(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "reviews")
+	reviews, drafts := newTestReviewService(t, dir)
+	start := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+
+	if _, err := reviews.Create("bob", "alice", 1, 0, "", start); !errors.Is(err, ErrDraftNotFound) {
+		t.Fatalf("Create without a saved solution = %v, want ErrDraftNotFound", err)
+	}
+	if _, err := drafts.SaveSolution("alice", 1, "a\nb\nc\nd\n", start); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reviews.Create("dave", "alice", 1, 0, "", start); !errors.Is(err, ErrNotTeammate) {
+		t.Errorf("Create by a non-teammate = %v, want ErrNotTeammate", err)
+	}
+	if _, err := reviews.Create("alice", "alice", 1, 0, "", start); !errors.Is(err, ErrInvalidReview) {
+		t.Errorf("Create of one's own solution = %v, want ErrInvalidReview", err)
+	}
+
+	review, err := reviews.Create("bob", "alice", 1, 0, "  first pass ", start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if review.Version != 1 || review.Verdict != models.VerdictPending || review.Summary != "first pass" {
+		t.Fatalf("Create = %+v", review.Review)
+	}
+
+	anchor := models.ReviewAnchor{Line: 3}
+	if _, err := reviews.AddThread("bob", review.ID, models.ReviewAnchor{Line: 9}, "x", start); !errors.Is(err, ErrInvalidReview) {
+		t.Errorf("AddThread past the last line = %v, want ErrInvalidReview", err)
+	}
+	if _, err := reviews.AddThread("bob", review.ID, anchor, " ", start); !errors.Is(err, ErrInvalidReview) {
+		t.Errorf("AddThread with an empty comment = %v, want ErrInvalidReview", err)
+	}
+	if _, err := reviews.AddThread("dave", review.ID, anchor, "x", start); !errors.Is(err, ErrReviewNotFound) {
+		t.Errorf("AddThread by an outsider = %v, want ErrReviewNotFound", err)
+	}
+	onC, err := reviews.AddThread("bob", review.ID, anchor, "rename c", start.Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	onB, err := reviews.AddThread("bob", review.ID, models.ReviewAnchor{Line: 2}, "drop b", start.Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if onC.ID != 1 || onC.Anchor.File != "solution-template.go" || onC.Anchor.Version != 1 || onC.Line != 3 || onC.Outdated {
+		t.Errorf("AddThread = %+v", onC)
+	}
+	if _, err := reviews.Reply("carol", review.ID, 1, "+1", start.Add(2*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reviews.Reply("alice", review.ID, 3, "?", start); !errors.Is(err, ErrReviewNotFound) {
+		t.Errorf("Reply to a missing thread = %v, want ErrReviewNotFound", err)
+	}
+
+	// Anchors follow their lines into later versions
+	if _, err := drafts.SaveSolution("alice", 1, "h1\nh2\na\nc\nd\n", start.Add(3*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	detail, err := reviews.Review("alice", review.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if detail.LatestVersion != 2 || len(detail.Threads) != 2 {
+		t.Fatalf("Review = %+v", detail)
+	}
+	if c := detail.Threads[0]; c.Line != 4 || c.Outdated || len(c.Comments) != 2 || c.Comments[1].ID != 3 {
+		t.Errorf("thread on a kept line = %+v, want line 4", c)
+	}
+	if b := detail.Threads[onB.ID-1]; b.Line != 4 || !b.Outdated {
+		t.Errorf("thread on a removed line = %+v, want outdated at line 4", b)
+	}
+
+	if _, err := reviews.SetResolved("alice", review.ID, 1, true, start.Add(4*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reviews.SetVerdict("alice", review.ID, models.VerdictApproved, "", start); !errors.Is(err, ErrNotReviewer) {
+		t.Errorf("SetVerdict by the author = %v, want ErrNotReviewer", err)
+	}
+	if _, err := reviews.SetVerdict("bob", review.ID, "great", "", start); !errors.Is(err, ErrInvalidReview) {
+		t.Errorf("SetVerdict with an unknown verdict = %v, want ErrInvalidReview", err)
+	}
+	if _, err := reviews.SetVerdict("bob", review.ID, models.VerdictChangesRequested, "", start.Add(5*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	summaries := reviews.Reviews("alice", 0)
+	if len(summaries) != 1 || summaries[0].Threads != 2 || summaries[0].OpenThreads != 1 || summaries[0].Verdict != models.VerdictChangesRequested {
+		t.Errorf("Reviews = %+v", summaries)
+	}
+	if len(reviews.Reviews("alice", 2)) != 0 || len(reviews.Reviews("carol", 0)) != 0 {
+		t.Error("Reviews listed reviews of another challenge or user")
+	}
+
+	// The author hears about everything but their own actions
+	feed := reviews.Notifications("alice", false)
+	kinds := []string{models.NotificationVerdict, models.NotificationComment, models.NotificationComment, models.NotificationComment, models.NotificationReview}
+	if len(feed.Notifications) != len(kinds) || feed.Unread != len(kinds) {
+		t.Fatalf("alice's feed = %+v", feed)
+	}
+	for i, kind := range kinds {
+		if feed.Notifications[i].Kind != kind {
+			t.Errorf("notification %d is %q, want %q", i, feed.Notifications[i].Kind, kind)
+		}
+	}
+	if bob := reviews.Notifications("bob", false); len(bob.Notifications) != 2 || bob.Notifications[0].Kind != models.NotificationResolved {
+		t.Errorf("bob's feed = %+v, want carol's reply and alice's resolve", bob)
+	}
+	if unread, err := reviews.MarkRead("alice", feed.Notifications[1].ID); err != nil || unread != 1 {
+		t.Errorf("MarkRead = %d, %v, want 1 unread", unread, err)
+	}
+
+	// Reviews and feeds survive a restart
+	reloaded, err := NewReviewService(drafts, reviews.teams, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := reloaded.Review("carol", review.ID)
+	if err != nil || !again.Threads[0].Resolved || again.Threads[1].Line != 4 {
+		t.Errorf("reloaded Review = %+v, %v", again, err)
+	}
+	if unread := reloaded.Notifications("alice", true); len(unread.Notifications) != 1 || unread.Notifications[0].Kind != models.NotificationVerdict {
+		t.Errorf("reloaded unread feed = %+v", unread)
+	}
+}
+
+func TestReviewsShowOnlySavedSolutions(t *testing.T) {
+	reviews, drafts := newTestReviewService(t, "")
+	start := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	const secret = "// half-written idea\n"
+
+	// Autosaves and the editor's private drafts are not saved solutions
+	if _, err := drafts.Save("alice", 1, secret, "", true, start); err != nil {
+		t.Fatal(err)
+	}
+	manual, err := drafts.Save("alice", 1, "private\n"+secret, "", false, start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reviews.Create("bob", "alice", 1, 0, "", start); !errors.Is(err, ErrDraftNotFound) {
+		t.Errorf("Create with only private drafts = %v, want ErrDraftNotFound", err)
+	}
+
+	saved, err := drafts.SaveSolution("alice", 1, "a\nb\n", start.Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	autosave, err := drafts.Save("alice", 1, "a\nb\n"+secret, "", true, start.Add(2*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored, err := drafts.Restore("alice", 1, manual.Version, start.Add(2*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, private := range []models.DraftVersion{manual, autosave, restored} {
+		if _, err := reviews.Create("bob", "alice", 1, private.Version, "", start); !errors.Is(err, ErrDraftNotFound) {
+			t.Errorf("Create of a %s version = %v, want ErrDraftNotFound", private.Source, err)
+		}
+	}
+
+	review, err := reviews.Create("bob", "alice", 1, 0, "", start.Add(3*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if review.Version != saved.Version || review.LatestVersion != saved.Version || strings.Contains(review.Code, secret) {
+		t.Errorf("Create = version %d, latest %d, code %q; want the saved version", review.Version, review.LatestVersion, review.Code)
+	}
+	if _, err := reviews.AddThread("bob", review.ID, models.ReviewAnchor{Version: autosave.Version, Line: 3}, "x", start); !errors.Is(err, ErrDraftNotFound) {
+		t.Errorf("AddThread on an autosave = %v, want ErrDraftNotFound", err)
+	}
+	thread, err := reviews.AddThread("bob", review.ID, models.ReviewAnchor{Line: 2}, "ok", start)
+	if err != nil || thread.Anchor.Version != saved.Version {
+		t.Errorf("AddThread = %+v, %v, want it anchored to the saved version", thread, err)
+	}
+
+	// A later private draft stays out of what the reviewer and teammates see
+	if _, err := drafts.Save("alice", 1, secret+"more\n", "", false, start.Add(10*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	for _, viewer := range []string{"bob", "carol"} {
+		detail, err := reviews.Review(viewer, review.ID)
+		if err != nil || detail.LatestVersion != saved.Version || strings.Contains(detail.Code, secret) {
+			t.Errorf("Review as %s = latest %d, code %q, %v; want the saved version", viewer, detail.LatestVersion, detail.Code, err)
+		}
+	}
+}
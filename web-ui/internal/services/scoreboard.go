@@ -2,46 +2,65 @@ package services
 
 import (
 	"io/ioutil"
-	"path/filepath"
 	"strconv"
 	"strings"
-	"time"
+	"sync"
 
 	"web-ui/internal/models"
+	"web-ui/internal/paths"
 )
 
 // ScoreboardService handles scoreboard-related operations
 type ScoreboardService struct {
+	paths       *paths.Resolver
+	history     *HistoryService
+	mu          sync.RWMutex // guards scoreboards
 	scoreboards models.ScoreboardMap
 }
 
 // NewScoreboardService creates a new scoreboard service
-func NewScoreboardService() *ScoreboardService {
+func NewScoreboardService(resolver *paths.Resolver) *ScoreboardService {
 	return &ScoreboardService{
+		paths:       resolver,
 		scoreboards: make(models.ScoreboardMap),
 	}
 }
 
+// UseHistory dates scoreboard entries loaded afterwards by their first
+// commit in hs; without it, loaded entries have no date
+func (ss *ScoreboardService) UseHistory(hs *HistoryService) {
+	ss.history = hs
+}
+
 // LoadScoreboards loads all scoreboards from the filesystem
 func (ss *ScoreboardService) LoadScoreboards(challenges models.ChallengeMap) error {
 	for id := range challenges {
-		challengeDir := filepath.Join("..", "challenge-"+strconv.Itoa(id))
-		ss.loadScoreboardForChallenge(id, challengeDir)
+		ss.loadScoreboardForChallenge(id)
 	}
 	return nil
 }
 
+// ReadScoreboardFile returns the raw SCOREBOARD.md content of a challenge
+func (ss *ScoreboardService) ReadScoreboardFile(challengeID int) ([]byte, error) {
+	scoreboardPath, err := ss.paths.ScoreboardFile(challengeID)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadFile(scoreboardPath)
+}
+
 // loadScoreboardForChallenge loads the scoreboard for a specific challenge
-func (ss *ScoreboardService) loadScoreboardForChallenge(id int, dir string) {
-	scoreboardPath := filepath.Join(dir, "SCOREBOARD.md")
-	scoreboardContent, err := ioutil.ReadFile(scoreboardPath)
+func (ss *ScoreboardService) loadScoreboardForChallenge(id int) {
+	scoreboardContent, err := ss.ReadScoreboardFile(id)
 	if err != nil {
 		return
 	}
 
 	// Parse scoreboard markdown table
 	entries := ss.parseScoreboardMarkdown(string(scoreboardContent), id)
+	ss.mu.Lock()
 	ss.scoreboards[id] = entries
+	ss.mu.Unlock()
 }
 
 // parseScoreboardMarkdown parses the scoreboard markdown table
@@ -99,11 +118,14 @@ func (ss *ScoreboardService) parseScoreboardMarkdown(content string, challengeID
 			continue
 		}
 
-		// Use current time for existing entries
 		entry := models.ScoreboardEntry{
 			Username:    username,
 			ChallengeID: challengeID,
-			SubmittedAt: time.Now(),
+		}
+		if ss.history != nil {
+			if solvedAt, ok := ss.history.SolvedAt(username, challengeID); ok {
+				entry.SubmittedAt = &solvedAt
+			}
 		}
 
 		entries = append(entries, entry)
@@ -122,15 +144,23 @@ func (ss *ScoreboardService) isNumeric(s string) bool {
 	return true
 }
 
-// GetScoreboard returns the scoreboard for a specific challenge
+// GetScoreboard returns a copy of the scoreboard for a specific challenge
 func (ss *ScoreboardService) GetScoreboard(challengeID int) ([]models.ScoreboardEntry, bool) {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
 	scoreboard, exists := ss.scoreboards[challengeID]
-	return scoreboard, exists
+	return append([]models.ScoreboardEntry(nil), scoreboard...), exists
 }
 
-// GetAllScoreboards returns all scoreboards
+// GetAllScoreboards returns a copy of all scoreboards
 func (ss *ScoreboardService) GetAllScoreboards() models.ScoreboardMap {
-	return ss.scoreboards
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+	scoreboards := make(models.ScoreboardMap, len(ss.scoreboards))
+	for id, entries := range ss.scoreboards {
+		scoreboards[id] = append([]models.ScoreboardEntry(nil), entries...)
+	}
+	return scoreboards
 }
 
 // AddSubmission adds a submission to the scoreboard
@@ -138,13 +168,126 @@ func (ss *ScoreboardService) AddSubmission(submission models.Submission) {
 	entry := models.ScoreboardEntry{
 		Username:    submission.Username,
 		ChallengeID: submission.ChallengeID,
-		SubmittedAt: submission.SubmittedAt,
+	}
+	if !submission.SubmittedAt.IsZero() {
+		submittedAt := submission.SubmittedAt
+		entry.SubmittedAt = &submittedAt
 	}
 
 	// Add to the scoreboard for this challenge
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
 	if ss.scoreboards[submission.ChallengeID] == nil {
 		ss.scoreboards[submission.ChallengeID] = []models.ScoreboardEntry{}
 	}
 
 	ss.scoreboards[submission.ChallengeID] = append(ss.scoreboards[submission.ChallengeID], entry)
 }
+
+// SolvedBy returns the IDs of the challenges whose SCOREBOARD.md shows
+// username passing every test, as Completions counts them
+func (ss *ScoreboardService) SolvedBy(username string, challenges models.ChallengeMap) map[int]bool {
+	solved := make(map[int]bool)
+	for id := range challenges {
+		if ss.Solved(username, id) {
+			solved[id] = true
+		}
+	}
+	return solved
+}
+
+// Solved reports whether a challenge's SCOREBOARD.md shows username passing
+// every test; a partial pass does not count
+func (ss *ScoreboardService) Solved(username string, challengeID int) bool {
+	for _, row := range ss.rows(challengeID) {
+		if strings.EqualFold(row.username, username) && (ChallengeResult{Passed: row.passed, Total: row.total}).Complete() {
+			return true
+		}
+	}
+	return false
+}
+
+// scoreboardRow is a user's passed and total test counts from a SCOREBOARD.md
+type scoreboardRow struct {
+	username      string
+	passed, total int
+}
+
+// rows reads the rows of a challenge's SCOREBOARD.md that record test counts
+func (ss *ScoreboardService) rows(challengeID int) []scoreboardRow {
+	content, err := ss.ReadScoreboardFile(challengeID)
+	if err != nil {
+		return nil
+	}
+
+	var rows []scoreboardRow
+	for _, line := range strings.Split(string(content), "\n") {
+		// Skip header and separator lines
+		if !strings.Contains(line, "|") || strings.Contains(line, "Username") || strings.Contains(line, "---") {
+			continue
+		}
+
+		parts := strings.Split(line, "|")
+		if len(parts) < 4 {
+			continue
+		}
+
+		username := strings.TrimSpace(parts[1])
+		passedTests, err1 := strconv.Atoi(strings.TrimSpace(parts[2]))
+		totalTests, err2 := strconv.Atoi(strings.TrimSpace(parts[3]))
+
+		// Skip empty usernames, placeholders and rows without test counts
+		if username == "" || username == "------" || err1 != nil || err2 != nil {
+			continue
+		}
+		rows = append(rows, scoreboardRow{username: username, passed: passedTests, total: totalTests})
+	}
+	return rows
+}
+
+// Results returns every user's test counts from the SCOREBOARD.md files,
+// dated by the git history when the scoreboard service has one. The
+// earliest dated completions of each challenge are marked FirstSolver.
+func (ss *ScoreboardService) Results(challenges models.ChallengeMap) []ChallengeResult {
+	var results []ChallengeResult
+	for _, id := range sortedIDs(challenges) {
+		first := -1
+		start := len(results)
+		for _, row := range ss.rows(id) {
+			result := ChallengeResult{Username: row.username, Challenge: challenges[id], Passed: row.passed, Total: row.total}
+			if ss.history != nil {
+				result.SolvedAt, _ = ss.history.SolvedAt(row.username, id)
+			}
+			results = append(results, result)
+			if result.Complete() && !result.SolvedAt.IsZero() &&
+				(first < 0 || result.SolvedAt.Before(results[first].SolvedAt)) {
+				first = len(results) - 1
+			}
+		}
+		if first < 0 {
+			continue
+		}
+		for i := start; i < len(results); i++ {
+			if results[i].Complete() && results[i].SolvedAt.Equal(results[first].SolvedAt) {
+				results[i].FirstSolver = true
+			}
+		}
+	}
+	return results
+}
+
+// Completions returns, per user, the challenges whose SCOREBOARD.md shows
+// every test passing
+func (ss *ScoreboardService) Completions(challenges models.ChallengeMap) map[string]map[int]bool {
+	userCompletions := make(map[string]map[int]bool)
+	for _, result := range ss.Results(challenges) {
+		if !result.Complete() {
+			continue
+		}
+		if userCompletions[result.Username] == nil {
+			userCompletions[result.Username] = make(map[int]bool)
+		}
+		userCompletions[result.Username][result.Challenge.ID] = true
+	}
+	return userCompletions
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+
+	"web-ui/internal/models"
+	"web-ui/internal/paths"
+)
+
+func TestSolvedBy(t *testing.T) {
+	cs, err := loadTestChallenges(t, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := cs.paths.Root()
+	header := "| Username | Passed Tests | Total Tests |\n|------|------|------|\n"
+	writeFile(t, filepath.Join(root, "challenge-1", paths.ScoreboardFileName), header+"| gopher | 2 | 2 |\n")
+	writeFile(t, filepath.Join(root, "challenge-2", paths.ScoreboardFileName), header+"| gopher | 1 | 2 |\n| rival | 2 | 2 |\n")
+	ss := NewScoreboardService(cs.paths)
+	ss.LoadScoreboards(cs.GetChallenges())
+
+	// A partial pass is listed on the scoreboard but is not a solve
+	if got := ss.SolvedBy("Gopher", cs.GetChallenges()); !reflect.DeepEqual(got, map[int]bool{1: true}) {
+		t.Errorf("SolvedBy = %v, want challenge 1 only", got)
+	}
+	if ss.Solved("gopher", 2) || !ss.Solved("rival", 2) {
+		t.Error("Solved counts partial passes")
+	}
+
+	// Passing submissions are added while requests read the scoreboards
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				ss.AddSubmission(models.Submission{Username: "gopher", ChallengeID: 3})
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				ss.GetScoreboard(3)
+				ss.GetAllScoreboards()
+			}
+		}()
+	}
+	wg.Wait()
+	if entries, _ := ss.GetScoreboard(3); len(entries) != 200 {
+		t.Errorf("scoreboard has %d entries, want 200", len(entries))
+	}
+}
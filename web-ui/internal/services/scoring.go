@@ -0,0 +1,180 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"web-ui/internal/models"
+)
+
+// Built-in scoring models
+const (
+	ScoringCompletion = "completion" // one point per fully passed challenge
+	ScoringWeighted   = "weighted"   // difficulty points, partial credit, bonuses and decay
+)
+
+// ChallengeResult is one user's scoreboard result on one challenge
+type ChallengeResult struct {
+	Username    string
+	Challenge   *models.Challenge
+	Passed      int
+	Total       int
+	SolvedAt    time.Time // first commit of the submission, zero when unknown
+	FirstSolver bool      // no one completed the challenge earlier
+}
+
+// Complete reports whether every test passed
+func (r ChallengeResult) Complete() bool {
+	return r.Total > 0 && r.Passed == r.Total
+}
+
+// Scorer awards leaderboard points for a challenge result
+type Scorer interface {
+	Points(result ChallengeResult, now time.Time) float64
+}
+
+// ScorerFunc adapts a function to the Scorer interface
+type ScorerFunc func(result ChallengeResult, now time.Time) float64
+
+// Points calls f
+func (f ScorerFunc) Points(result ChallengeResult, now time.Time) float64 {
+	return f(result, now)
+}
+
+// ScoringRules parameterise the scoring models
+type ScoringRules struct {
+	// DifficultyPoints are the points for completing a challenge of each
+	// difficulty, unless its metadata.json sets points
+	DifficultyPoints map[string]float64
+	// PartialCredit awards the passed fraction of the points for incomplete results
+	PartialCredit bool
+	// FirstSolverBonus is added for the earliest completion of a challenge
+	FirstSolverBonus float64
+	// HalfLife halves the points of a solve every HalfLife since it was
+	// solved; zero disables decay
+	HalfLife time.Duration
+}
+
+// ScoringModel builds a Scorer from the rules
+type ScoringModel func(rules ScoringRules) Scorer
+
+var (
+	scoringModelsMu sync.RWMutex
+	scoringModels   = map[string]ScoringModel{
+		ScoringCompletion: func(ScoringRules) Scorer { return ScorerFunc(completionPoints) },
+		ScoringWeighted:   func(rules ScoringRules) Scorer { return weightedScorer{rules} },
+	}
+)
+
+// RegisterScoringModel makes a scoring model available to NewScorer by name
+func RegisterScoringModel(name string, model ScoringModel) {
+	scoringModelsMu.Lock()
+	defer scoringModelsMu.Unlock()
+	scoringModels[name] = model
+}
+
+// NewScorer returns the named scoring model configured with rules
+func NewScorer(name string, rules ScoringRules) (Scorer, error) {
+	scoringModelsMu.RLock()
+	defer scoringModelsMu.RUnlock()
+	model, ok := scoringModels[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown scoring model %q", name)
+	}
+	return model(rules), nil
+}
+
+func completionPoints(result ChallengeResult, _ time.Time) float64 {
+	if result.Complete() {
+		return 1
+	}
+	return 0
+}
+
+type weightedScorer struct {
+	rules ScoringRules
+}
+
+func (ws weightedScorer) Points(result ChallengeResult, now time.Time) float64 {
+	if result.Total <= 0 || result.Passed <= 0 {
+		return 0
+	}
+
+	points := ws.rules.DifficultyPoints[result.Challenge.Difficulty]
+	if result.Challenge.Points > 0 {
+		points = float64(result.Challenge.Points)
+	}
+	if !result.Complete() {
+		if !ws.rules.PartialCredit {
+			return 0
+		}
+		points *= float64(result.Passed) / float64(result.Total)
+	} else if result.FirstSolver {
+		points += ws.rules.FirstSolverBonus
+	}
+
+	if ws.rules.HalfLife > 0 && !result.SolvedAt.IsZero() {
+		if age := now.Sub(result.SolvedAt); age > 0 {
+			points *= math.Pow(0.5, float64(age)/float64(ws.rules.HalfLife))
+		}
+	}
+	return points
+}
+
+// Tier is an achievement tier earned by completing MinSolved challenges
+type Tier struct {
+	Name      string
+	MinSolved int
+}
+
+// DefaultTiers are the achievement tiers used unless configured otherwise
+var DefaultTiers = []Tier{
+	{Name: "🌱 Beginner", MinSolved: 0},
+	{Name: "🚀 Intermediate", MinSolved: 5},
+	{Name: "💪 Advanced", MinSolved: 10},
+	{Name: "⭐ Expert", MinSolved: 15},
+	{Name: "🔥 Master", MinSolved: 20},
+}
+
+// achievementTier names the highest of tiers, sorted by MinSolved, reached
+// by completing count challenges
+func achievementTier(tiers []Tier, count int) string {
+	name := ""
+	for _, tier := range tiers {
+		if count >= tier.MinSolved {
+			name = tier.Name
+		}
+	}
+	return name
+}
+
+// Standing is a user's position on the main leaderboard
+type Standing struct {
+	Username            string
+	Points              float64
+	CompletedChallenges map[int]bool
+	Achievement         string
+	Rank                int
+}
+
+// competitionRanks sorts standings by points, then username, and gives
+// users with equal points the same rank, skipping the ranks after them
+// (1, 1, 3)
+func competitionRanks(standings []Standing) {
+	sort.Slice(standings, func(i, j int) bool {
+		if standings[i].Points != standings[j].Points {
+			return standings[i].Points > standings[j].Points
+		}
+		return standings[i].Username < standings[j].Username
+	})
+	for i := range standings {
+		if i > 0 && standings[i].Points == standings[i-1].Points {
+			standings[i].Rank = standings[i-1].Rank
+		} else {
+			standings[i].Rank = i + 1
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"math"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"web-ui/internal/models"
+	"web-ui/internal/paths"
+)
+
+func TestCompetitionRanks(t *testing.T) {
+	standings := []Standing{
+		{Username: "carol", Points: 10},
+		{Username: "bob", Points: 30},
+		{Username: "dave", Points: 10},
+		{Username: "alice", Points: 30},
+		{Username: "erin", Points: 5},
+	}
+	competitionRanks(standings)
+
+	var got []string
+	var ranks []int
+	for _, standing := range standings {
+		got = append(got, standing.Username)
+		ranks = append(ranks, standing.Rank)
+	}
+	if want := []string{"alice", "bob", "carol", "dave", "erin"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("order = %v, want %v", got, want)
+	}
+	if want := []int{1, 1, 3, 3, 5}; !reflect.DeepEqual(ranks, want) {
+		t.Errorf("ranks = %v, want %v", ranks, want)
+	}
+}
+
+func TestWeightedScorer(t *testing.T) {
+	now := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+	beginner := &models.Challenge{ID: 1, Difficulty: "Beginner"}
+	custom := &models.Challenge{ID: 2, Difficulty: "Advanced", Points: 50}
+	rules := ScoringRules{
+		DifficultyPoints: map[string]float64{"Beginner": 10, "Advanced": 30},
+		PartialCredit:    true,
+		FirstSolverBonus: 5,
+	}
+
+	tests := []struct {
+		name   string
+		rules  func(*ScoringRules)
+		result ChallengeResult
+		want   float64
+	}{
+		{"difficulty points", nil, ChallengeResult{Challenge: beginner, Passed: 4, Total: 4}, 10},
+		{"metadata points", nil, ChallengeResult{Challenge: custom, Passed: 4, Total: 4}, 50},
+		{"partial credit", nil, ChallengeResult{Challenge: beginner, Passed: 1, Total: 4}, 2.5},
+		{"no partial credit", func(r *ScoringRules) { r.PartialCredit = false }, ChallengeResult{Challenge: beginner, Passed: 1, Total: 4}, 0},
+		{"first solver bonus", nil, ChallengeResult{Challenge: beginner, Passed: 4, Total: 4, FirstSolver: true}, 15},
+		{"nothing passed", nil, ChallengeResult{Challenge: beginner, Passed: 0, Total: 4}, 0},
+		{"decay", func(r *ScoringRules) { r.HalfLife = 24 * time.Hour },
+			ChallengeResult{Challenge: beginner, Passed: 4, Total: 4, SolvedAt: now.Add(-48 * time.Hour)}, 2.5},
+		{"no decay without a date", func(r *ScoringRules) { r.HalfLife = 24 * time.Hour },
+			ChallengeResult{Challenge: beginner, Passed: 4, Total: 4}, 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := rules
+			if tt.rules != nil {
+				tt.rules(&rules)
+			}
+			scorer, err := NewScorer(ScoringWeighted, rules)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := scorer.Points(tt.result, now); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Points = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := NewScorer("elo", rules); err == nil {
+		t.Error("NewScorer accepted an unknown model")
+	}
+}
+
+func TestLeaderboardStandings(t *testing.T) {
+	root := t.TempDir()
+	for id := 1; id <= 2; id++ {
+		writeChallenge(t, root, id, "")
+	}
+	header := "| Username | Passed Tests | Total Tests |\n|------|------|------|\n"
+	writeFile(t, filepath.Join(root, "challenge-1", paths.ScoreboardFileName), header+"| alice | 2 | 2 |\n| bob | 2 | 2 |\n| carol | 1 | 2 |\n")
+	writeFile(t, filepath.Join(root, "challenge-2", paths.ScoreboardFileName), header+"| carol | 2 | 2 |\n| dave | 0 | 2 |\n")
+
+	resolver := mustResolver(t, root)
+	challengeService := NewChallengeService(resolver)
+	if err := challengeService.LoadChallenges(); err != nil {
+		t.Fatal(err)
+	}
+	scoreboardService := NewScoreboardService(resolver)
+	history := NewHistoryService(resolver)
+	history.solves = map[string]map[int]time.Time{
+		"alice": {1: time.Unix(200, 0)},
+		"bob":   {1: time.Unix(100, 0)},
+	}
+	scoreboardService.UseHistory(history)
+
+	var first []string
+	for _, result := range scoreboardService.Results(challengeService.GetChallenges()) {
+		if result.FirstSolver {
+			first = append(first, result.Username)
+		}
+	}
+	if !reflect.DeepEqual(first, []string{"bob"}) {
+		t.Errorf("first solvers = %v, want [bob]", first)
+	}
+
+	// A custom model: the first solver of anything wins outright
+	RegisterScoringModel("test-first", func(ScoringRules) Scorer {
+		return ScorerFunc(func(result ChallengeResult, _ time.Time) float64 {
+			if result.FirstSolver {
+				return 100
+			}
+			return completionPoints(result, time.Time{})
+		})
+	})
+	scorer, err := NewScorer("test-first", ScoringRules{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tiers := []Tier{{Name: "Rookie", MinSolved: 0}, {Name: "Solver", MinSolved: 1}}
+	leaderboard := NewLeaderboardService(challengeService, scoreboardService, scorer, tiers)
+
+	standings := leaderboard.Standings(time.Now())
+	var got []string
+	for _, standing := range standings {
+		got = append(got, standing.Username)
+	}
+	if want := []string{"bob", "alice", "carol"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("standings = %v, want %v (dave has no points)", got, want)
+	}
+	if standings[1].Rank != 2 || standings[2].Rank != 2 || standings[2].Achievement != "Solver" {
+		t.Errorf("standings = %+v, want alice and carol tied at 2", standings)
+	}
+	if rank := leaderboard.Rank("dave", time.Now()); rank != 0 {
+		t.Errorf("Rank(dave) = %d, want 0", rank)
+	}
+	if tier := leaderboard.Achievement(0); tier != "Rookie" {
+		t.Errorf("Achievement(0) = %q", tier)
+	}
+}
@@ -0,0 +1,486 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"web-ui/internal/models"
+)
+
+// Relevance weights per field: a title hit counts three times a description hit
+const (
+	weightTitle       = 3.0
+	weightTag         = 2.0
+	weightDescription = 1.0
+	weightLearning    = 0.5
+
+	// prefixPenalty scales hits on terms the last query word is only a prefix of
+	prefixPenalty = 0.5
+)
+
+// Sort orders accepted by ChallengeQuery.Sort; prefix with "-" to reverse
+const (
+	SortID         = "id"
+	SortTitle      = "title"
+	SortDifficulty = "difficulty"
+	SortRelevance  = "relevance"
+)
+
+// difficultyRank orders difficulty levels from easiest to hardest
+var difficultyRank = map[string]int{"Beginner": 1, "Intermediate": 2, "Advanced": 3}
+
+// stopWords are too common in challenge text to be worth indexing
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "be": true, "by": true,
+	"each": true, "for": true, "from": true, "if": true, "in": true, "is": true, "it": true,
+	"of": true, "on": true, "or": true, "should": true, "that": true, "the": true,
+	"this": true, "to": true, "will": true, "with": true, "you": true, "your": true,
+}
+
+// commonImports are imported by nearly every challenge and make poor tags
+var commonImports = map[string]bool{"fmt": true, "testing": true, "reflect": true}
+
+var (
+	importLineRe  = regexp.MustCompile(`(?m)^\s*(?:import\s+)?(?:\w+\s+)?"([\w./-]+)"`)
+	typeParamRe   = regexp.MustCompile(`\[\s*\w+(?:\s*,\s*\w+)*\s+(?:any|comparable|constraints\.\w+|~?\w+\s*\|)`)
+	importBlockRe = regexp.MustCompile(`(?s)import\s*\((.*?)\)|import\s+(?:\w+\s+)?"[^"]+"`)
+)
+
+// topicRule assigns a topic when a challenge imports one of the packages or
+// its title or description uses one of the words
+type topicRule struct {
+	topic      string
+	imports    []string
+	titleWords []string
+	textWords  []string
+}
+
+// topicRules are the curated topics challenges are classified into
+var topicRules = []topicRule{
+	{topic: "concurrency", imports: []string{"sync", "sync/atomic"},
+		titleWords: []string{"concurrent", "channel", "parallel", "pipeline"},
+		textWords:  []string{"goroutine", "concurrency", "concurrently", "mutex", "channel"}},
+	{topic: "generics", titleWords: []string{"generic"}, textWords: []string{"generic", "generics"}},
+	{topic: "http", imports: []string{"net/http", "net/http/httptest"},
+		titleWords: []string{"http", "rest", "restful", "api", "middleware", "web"}},
+	{topic: "context", imports: []string{"context"}, titleWords: []string{"context"}},
+	{topic: "databases", imports: []string{"database/sql"}, titleWords: []string{"sql", "database"}},
+	{topic: "errors", titleWords: []string{"error"}},
+	{topic: "interfaces", titleWords: []string{"polymorphic", "interface"}},
+	{topic: "algorithms", titleWords: []string{"algorithm", "search", "sort", "greedy", "dynamic", "graph", "path", "subsequence"}},
+	{topic: "data-structures", titleWords: []string{"cache", "queue", "stack", "tree", "slice", "structure", "map"}},
+	{topic: "strings", imports: []string{"regexp", "unicode"}, titleWords: []string{"string", "word", "text", "regular", "palindrome"}},
+	{topic: "security", titleWords: []string{"authentication", "oauth2", "auth"}},
+	{topic: "resilience", titleWords: []string{"circuit", "limiter", "retry"}},
+	{topic: "performance", titleWords: []string{"performance", "optimization"}, textWords: []string{"benchmark"}},
+	{topic: "networking", imports: []string{"net", "google.golang.org/grpc"}, titleWords: []string{"grpc", "microservice", "server"}},
+	{topic: "files", imports: []string{"io/fs", "path/filepath"}, titleWords: []string{"file"}},
+}
+
+// ChallengeQuery selects and orders challenges. Each filter matches any of
+// its values and challenges must pass every filter; zero values match all.
+type ChallengeQuery struct {
+	Text         string
+	Difficulties []string
+	Tags         []string
+	Topics       []string
+	Include      func(challengeID int) bool // extra filter, e.g. the user's solved challenges
+	Sort         string                     // one of the Sort constants, "-" prefix for descending
+}
+
+// ChallengeMatch is a challenge selected by a query with its text relevance
+type ChallengeMatch struct {
+	Challenge *models.Challenge
+	Score     float64
+}
+
+// FacetCount is a tag or topic with the number of challenges carrying it
+type FacetCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// challengeIndex is an inverted index from terms, tags, topics and
+// difficulties to challenge IDs. It is rebuilt whenever challenges are loaded.
+type challengeIndex struct {
+	postings     map[string]map[int]float64 // term -> challenge -> weight
+	terms        []string                   // sorted keys of postings, for prefix lookups
+	tags         map[string]map[int]bool
+	topics       map[string]map[int]bool
+	difficulties map[string]map[int]bool
+}
+
+// buildChallengeIndex indexes challenges, which must already carry their tags and topics
+func buildChallengeIndex(challenges models.ChallengeMap) *challengeIndex {
+	idx := &challengeIndex{
+		postings:     make(map[string]map[int]float64),
+		tags:         make(map[string]map[int]bool),
+		topics:       make(map[string]map[int]bool),
+		difficulties: make(map[string]map[int]bool),
+	}
+
+	for id, challenge := range challenges {
+		frequencies := make(map[string]float64)
+		addField := func(text string, weight float64) {
+			for _, term := range tokenize(text) {
+				frequencies[term] += weight
+			}
+		}
+		addField(challenge.Title, weightTitle)
+		addField(challenge.Description, weightDescription)
+		addField(challenge.LearningMaterials, weightLearning)
+		for _, label := range append(append([]string{}, challenge.Tags...), challenge.Topics...) {
+			addField(label, weightTag)
+		}
+
+		for term, frequency := range frequencies {
+			if idx.postings[term] == nil {
+				idx.postings[term] = make(map[int]float64)
+			}
+			// Dampen long documents that repeat a term many times
+			idx.postings[term][id] = 1 + math.Log(frequency)
+		}
+
+		addToSet(idx.difficulties, strings.ToLower(challenge.Difficulty), id)
+		for _, tag := range challenge.Tags {
+			addToSet(idx.tags, tag, id)
+		}
+		for _, topic := range challenge.Topics {
+			addToSet(idx.topics, topic, id)
+		}
+	}
+
+	idx.terms = make([]string, 0, len(idx.postings))
+	for term := range idx.postings {
+		idx.terms = append(idx.terms, term)
+	}
+	sort.Strings(idx.terms)
+	return idx
+}
+
+func addToSet(sets map[string]map[int]bool, key string, id int) {
+	if sets[key] == nil {
+		sets[key] = make(map[int]bool)
+	}
+	sets[key][id] = true
+}
+
+// search scores challenges containing every query term. The last term also
+// matches as a prefix so results update while the user is typing.
+func (idx *challengeIndex) search(text string, total int) map[int]float64 {
+	terms := tokenize(text)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	var scores map[int]float64
+	for i, term := range terms {
+		termScores := idx.termScores(term, total, i == len(terms)-1)
+		if scores == nil {
+			scores = termScores
+			continue
+		}
+		for id := range scores {
+			if s, ok := termScores[id]; ok {
+				scores[id] += s
+			} else {
+				delete(scores, id)
+			}
+		}
+	}
+	return scores
+}
+
+// termScores returns tf-idf scores for one query term
+func (idx *challengeIndex) termScores(term string, total int, prefix bool) map[int]float64 {
+	scores := make(map[int]float64)
+	add := func(indexed string, penalty float64) {
+		postings := idx.postings[indexed]
+		idf := math.Log(1 + float64(total)/float64(len(postings)))
+		for id, weight := range postings {
+			if s := weight * idf * penalty; s > scores[id] {
+				scores[id] = s
+			}
+		}
+	}
+
+	add(term, 1)
+	if prefix {
+		for i := sort.SearchStrings(idx.terms, term); i < len(idx.terms) && strings.HasPrefix(idx.terms[i], term); i++ {
+			if idx.terms[i] != term {
+				add(idx.terms[i], prefixPenalty)
+			}
+		}
+	}
+	return scores
+}
+
+// filter returns the IDs in any of the named sets, or nil when names is empty
+func filterSets(sets map[string]map[int]bool, names []string) map[int]bool {
+	if len(names) == 0 {
+		return nil
+	}
+	matched := make(map[int]bool)
+	for _, name := range names {
+		for id := range sets[strings.ToLower(strings.TrimSpace(name))] {
+			matched[id] = true
+		}
+	}
+	return matched
+}
+
+// facets counts how many challenges carry each key, most common first
+func facets(sets map[string]map[int]bool) []FacetCount {
+	counts := make([]FacetCount, 0, len(sets))
+	for name, ids := range sets {
+		counts = append(counts, FacetCount{Name: name, Count: len(ids)})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Name < counts[j].Name
+	})
+	return counts
+}
+
+// tokenize splits text into lower-case, singular terms without stop words
+func tokenize(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	terms := fields[:0]
+	for _, field := range fields {
+		if stopWords[field] {
+			continue
+		}
+		terms = append(terms, stem(field))
+	}
+	return terms
+}
+
+// stem strips a plural "s" so "channels" finds "channel"
+func stem(term string) string {
+	if len(term) > 3 && strings.HasSuffix(term, "s") && !strings.HasSuffix(term, "ss") {
+		return term[:len(term)-1]
+	}
+	return term
+}
+
+// validSort reports whether sort names a supported order
+func validSort(order string) bool {
+	switch strings.TrimPrefix(order, "-") {
+	case "", SortID, SortTitle, SortDifficulty, SortRelevance:
+		return true
+	}
+	return false
+}
+
+// sortMatches orders matches by the query's sort, defaulting to relevance
+// for text searches and to ID otherwise. Ties fall back to ID.
+func sortMatches(matches []ChallengeMatch, order, text string) {
+	desc := strings.HasPrefix(order, "-")
+	field := strings.TrimPrefix(order, "-")
+	if field == "" {
+		field = SortID
+		if strings.TrimSpace(text) != "" {
+			field = SortRelevance
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		var cmp int
+		switch field {
+		case SortID:
+			cmp = a.Challenge.ID - b.Challenge.ID
+		case SortTitle:
+			cmp = strings.Compare(strings.ToLower(a.Challenge.Title), strings.ToLower(b.Challenge.Title))
+		case SortDifficulty:
+			cmp = difficultyRank[a.Challenge.Difficulty] - difficultyRank[b.Challenge.Difficulty]
+		case SortRelevance:
+			// Higher scores first unless reversed
+			switch {
+			case a.Score > b.Score:
+				cmp = -1
+			case a.Score < b.Score:
+				cmp = 1
+			}
+		}
+		if cmp == 0 {
+			return a.Challenge.ID < b.Challenge.ID
+		}
+		if desc {
+			return cmp > 0
+		}
+		return cmp < 0
+	})
+}
+
+// challengeImports lists the packages imported by a challenge's template and tests
+func challengeImports(sources ...string) []string {
+	seen := make(map[string]bool)
+	var imports []string
+	for _, source := range sources {
+		for _, block := range importBlockRe.FindAllString(source, -1) {
+			for _, match := range importLineRe.FindAllStringSubmatch(block, -1) {
+				if path := match[1]; !seen[path] {
+					seen[path] = true
+					imports = append(imports, path)
+				}
+			}
+		}
+	}
+	sort.Strings(imports)
+	return imports
+}
+
+// challengeTags combines curated tags with the non-trivial packages a challenge imports
+func challengeTags(curated string, imports []string) []string {
+	seen := make(map[string]bool)
+	tags := []string{}
+	add := func(tag string) {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	for _, line := range strings.Split(curated, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		for _, tag := range strings.Split(line, ",") {
+			add(tag)
+		}
+	}
+	for _, path := range imports {
+		if !commonImports[path] {
+			add(path)
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// challengeTopics classifies a challenge with topicRules
+func challengeTopics(challenge *models.Challenge, imports []string) []string {
+	imported := make(map[string]bool, len(imports))
+	for _, path := range imports {
+		imported[path] = true
+	}
+	titleTerms := termSet(challenge.Title)
+	textTerms := termSet(challenge.Description)
+
+	topics := []string{}
+	for _, rule := range topicRules {
+		if ruleMatches(rule, imported, titleTerms, textTerms) {
+			topics = append(topics, rule.topic)
+		}
+	}
+	if typeParamRe.MatchString(challenge.Template) && !contains(topics, "generics") {
+		topics = append(topics, "generics")
+	}
+	sort.Strings(topics)
+	return topics
+}
+
+func ruleMatches(rule topicRule, imported, titleTerms, textTerms map[string]bool) bool {
+	for _, path := range rule.imports {
+		if imported[path] {
+			return true
+		}
+	}
+	for _, word := range rule.titleWords {
+		if titleTerms[stem(word)] {
+			return true
+		}
+	}
+	for _, word := range rule.textWords {
+		if textTerms[stem(word)] {
+			return true
+		}
+	}
+	return false
+}
+
+func termSet(text string) map[string]bool {
+	set := make(map[string]bool)
+	for _, term := range tokenize(text) {
+		set[term] = true
+	}
+	return set
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Search returns the challenges matching q in the requested order
+func (cs *ChallengeService) Search(q ChallengeQuery) ([]ChallengeMatch, error) {
+	if !validSort(q.Sort) {
+		return nil, fmt.Errorf("unknown sort order %q", q.Sort)
+	}
+
+	idx := cs.index
+	if idx == nil {
+		idx = buildChallengeIndex(cs.challenges)
+	}
+
+	// A query of only stop words or punctuation does not filter
+	scores := idx.search(q.Text, len(cs.challenges))
+	filters := []map[int]bool{
+		filterSets(idx.difficulties, q.Difficulties),
+		filterSets(idx.tags, q.Tags),
+		filterSets(idx.topics, q.Topics),
+	}
+
+	matches := make([]ChallengeMatch, 0, len(cs.challenges))
+	for id, challenge := range cs.challenges {
+		score, found := scores[id]
+		if scores != nil && !found {
+			continue
+		}
+		if !passesFilters(id, filters) || (q.Include != nil && !q.Include(id)) {
+			continue
+		}
+		matches = append(matches, ChallengeMatch{Challenge: challenge, Score: math.Round(score*1000) / 1000})
+	}
+
+	sortMatches(matches, q.Sort, q.Text)
+	return matches, nil
+}
+
+func passesFilters(id int, filters []map[int]bool) bool {
+	for _, filter := range filters {
+		if filter != nil && !filter[id] {
+			return false
+		}
+	}
+	return true
+}
+
+// Tags returns every tag with its challenge count, most common first
+func (cs *ChallengeService) Tags() []FacetCount {
+	if cs.index == nil {
+		return []FacetCount{}
+	}
+	return facets(cs.index.tags)
+}
+
+// Topics returns every topic with its challenge count, most common first
+func (cs *ChallengeService) Topics() []FacetCount {
+	if cs.index == nil {
+		return []FacetCount{}
+	}
+	return facets(cs.index.topics)
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"web-ui/internal/models"
+)
+
+// newTestChallengeService indexes a small fixed set of challenges
+func newTestChallengeService() *ChallengeService {
+	cs := NewChallengeService(nil)
+	add := func(id int, title, difficulty, description, learning, template string) {
+		challenge := &models.Challenge{
+			ID: id, Title: title, Difficulty: difficulty, Description: description,
+			LearningMaterials: learning, Template: template,
+		}
+		imports := challengeImports(template)
+		challenge.Tags = challengeTags("", imports)
+		challenge.Topics = challengeTopics(challenge, imports)
+		cs.challenges[id] = challenge
+	}
+	add(1, "Sum of Two Numbers", "Beginner", "Add two integers.", "Functions take parameters.",
+		"package main\n\nfunc Sum(a, b int) int { return 0 }\n")
+	add(2, "Concurrent Web Crawler", "Advanced", "Fetch pages in parallel using goroutines.", "Channels connect goroutines.",
+		"package main\n\nimport (\n\t\"net/http\"\n\t\"sync\"\n)\n")
+	add(3, "Bank Account with Error Handling", "Intermediate", "Return errors for overdrafts.", "Mutexes protect balances from concurrent access.",
+		"package main\n\nimport \"sync\"\n")
+	add(4, "Generic Stack", "Intermediate", "Implement a stack.", "",
+		"package main\n\ntype Stack[T any] struct{ items []T }\n")
+	cs.index = buildChallengeIndex(cs.challenges)
+	return cs
+}
+
+func matchIDs(matches []ChallengeMatch) []int {
+	ids := make([]int, 0, len(matches))
+	for _, match := range matches {
+		ids = append(ids, match.Challenge.ID)
+	}
+	return ids
+}
+
+func TestTokenize(t *testing.T) {
+	got := tokenize("The Channels, goroutines & HTTP/2 in Go's class")
+	want := []string{"channel", "goroutine", "http", "2", "go", "s", "class"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("tokenize = %q, want %q", got, want)
+	}
+}
+
+func TestChallengeTagsAndTopics(t *testing.T) {
+	cs := newTestChallengeService()
+
+	crawler, _ := cs.GetChallenge(2)
+	if want := []string{"net/http", "sync"}; !reflect.DeepEqual(crawler.Tags, want) {
+		t.Errorf("tags = %q, want %q", crawler.Tags, want)
+	}
+	if want := []string{"concurrency", "http"}; !reflect.DeepEqual(crawler.Topics, want) {
+		t.Errorf("topics = %q, want %q", crawler.Topics, want)
+	}
+
+	stack, _ := cs.GetChallenge(4)
+	if want := []string{"data-structures", "generics"}; !reflect.DeepEqual(stack.Topics, want) {
+		t.Errorf("topics = %q, want %q", stack.Topics, want)
+	}
+
+	if got := challengeTags("# curated\nWorker Pools, pipelines\n", []string{"fmt", "sync"}); !reflect.DeepEqual(got, []string{"pipelines", "sync", "worker pools"}) {
+		t.Errorf("challengeTags = %q", got)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	cs := newTestChallengeService()
+
+	tests := []struct {
+		name  string
+		query ChallengeQuery
+		want  []int
+	}{
+		{"all by ID", ChallengeQuery{}, []int{1, 2, 3, 4}},
+		{"title outranks learning material", ChallengeQuery{Text: "concurrent"}, []int{2, 3}},
+		{"plural matches singular", ChallengeQuery{Text: "goroutine"}, []int{2}},
+		{"terms are ANDed", ChallengeQuery{Text: "concurrent bank"}, []int{3}},
+		{"last term matches as prefix", ChallengeQuery{Text: "gen"}, []int{4}},
+		{"only stop words", ChallengeQuery{Text: "the"}, []int{1, 2, 3, 4}},
+		{"no match", ChallengeQuery{Text: "sql"}, []int{}},
+		{"difficulty filter", ChallengeQuery{Difficulties: []string{"intermediate", "Beginner"}}, []int{1, 3, 4}},
+		{"tag filter", ChallengeQuery{Tags: []string{"sync"}}, []int{2, 3}},
+		{"topic filter", ChallengeQuery{Topics: []string{"generics", "http"}}, []int{2, 4}},
+		{"filters are ANDed", ChallengeQuery{Tags: []string{"sync"}, Difficulties: []string{"Advanced"}}, []int{2}},
+		{"include", ChallengeQuery{Include: func(id int) bool { return id%2 == 0 }}, []int{2, 4}},
+		{"sort by title", ChallengeQuery{Sort: SortTitle}, []int{3, 2, 4, 1}},
+		{"sort by difficulty descending", ChallengeQuery{Sort: "-" + SortDifficulty}, []int{2, 3, 4, 1}},
+		{"sort text by ID", ChallengeQuery{Text: "concurrent", Sort: "-" + SortID}, []int{3, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matches, err := cs.Search(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := matchIDs(matches); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Search = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := cs.Search(ChallengeQuery{Sort: "size"}); err == nil {
+		t.Error("Search with an unknown sort succeeded")
+	}
+}
+
+func TestFacets(t *testing.T) {
+	cs := newTestChallengeService()
+	want := []FacetCount{
+		{Name: "concurrency", Count: 2},
+		{Name: "data-structures", Count: 1},
+		{Name: "errors", Count: 1},
+		{Name: "generics", Count: 1},
+		{Name: "http", Count: 1},
+	}
+	if got := cs.Topics(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Topics = %v, want %v", got, want)
+	}
+}
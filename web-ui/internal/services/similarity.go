@@ -0,0 +1,193 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"web-ui/internal/paths"
+	"web-ui/internal/similarity"
+)
+
+// ErrChallengeNotFound is returned for a challenge ID that does not exist
+var ErrChallengeNotFound = errors.New("challenge not found")
+
+// minSharedFingerprints is how many fingerprints a pair must share to be
+// flagged. Solutions to small challenges are often identical once the
+// template is discounted, and a copy of a few lines proves nothing.
+const minSharedFingerprints = 10
+
+// SimilarityPair scores how alike two users' solutions to a challenge are
+type SimilarityPair struct {
+	Left    string  `json:"left"`
+	Right   string  `json:"right"`
+	Score   float64 `json:"score" doc:"Jaccard similarity of the solutions' fingerprints, from 0 to 1"`
+	Shared  int     `json:"shared" doc:"Fingerprints the solutions have in common"`
+	Flagged bool    `json:"flagged" doc:"The score is at least the report's threshold and the solutions share at least 10 fingerprints"`
+}
+
+// SimilarityReport lists the pairwise similarity of a challenge's solutions
+type SimilarityReport struct {
+	ChallengeID int              `json:"challengeId"`
+	Threshold   float64          `json:"threshold"`
+	Solutions   int              `json:"solutions" doc:"Solutions that were compared"`
+	Unparsed    []string         `json:"unparsed" doc:"Users whose solution is not valid Go, so it was left out"`
+	Flagged     int              `json:"flagged"`
+	Pairs       []SimilarityPair `json:"pairs" doc:"Most similar first"`
+}
+
+// SimilarityService compares the solutions in challenge-N/submissions to spot
+// copies. Code the challenge's template already holds is not counted, since
+// every solution starts from it.
+type SimilarityService struct {
+	paths            *paths.Resolver
+	challengeService *ChallengeService
+	threshold        float64
+
+	mu sync.Mutex
+	// fingerprints caches the fingerprints of sources by code hash
+	fingerprints map[string]similarity.Fingerprint
+}
+
+// NewSimilarityService creates a similarity service that flags pairs scoring
+// at least threshold by default
+func NewSimilarityService(resolver *paths.Resolver, challengeService *ChallengeService, threshold float64) *SimilarityService {
+	return &SimilarityService{
+		paths:            resolver,
+		challengeService: challengeService,
+		threshold:        threshold,
+		fingerprints:     make(map[string]similarity.Fingerprint),
+	}
+}
+
+// Threshold returns the default score at which pairs are flagged
+func (ss *SimilarityService) Threshold() float64 {
+	return ss.threshold
+}
+
+// Report compares every pair of solutions to a challenge, flagging those
+// scoring at least threshold
+func (ss *SimilarityService) Report(challengeID int, threshold float64) (SimilarityReport, error) {
+	challenge, ok := ss.challengeService.GetChallenge(challengeID)
+	if !ok {
+		return SimilarityReport{}, fmt.Errorf("%w: %d", ErrChallengeNotFound, challengeID)
+	}
+	report := SimilarityReport{ChallengeID: challenge.ID, Threshold: threshold, Unparsed: []string{}, Pairs: []SimilarityPair{}}
+	usernames, err := submitters(ss.paths, challenge.ID)
+	if err != nil {
+		return report, err
+	}
+	sort.Slice(usernames, func(i, j int) bool {
+		return strings.ToLower(usernames[i]) < strings.ToLower(usernames[j])
+	})
+
+	// A template that does not parse leaves nothing to discount
+	template, _ := ss.fingerprint(challenge.Template)
+
+	type solution struct {
+		username    string
+		fingerprint similarity.Fingerprint
+	}
+	var solutions []solution
+	for _, username := range usernames {
+		path, err := ss.paths.SubmissionFile(challenge.ID, username)
+		if err != nil {
+			continue
+		}
+		content, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return report, fmt.Errorf("failed to read solution: %v", err)
+		}
+		fp, err := ss.fingerprint(string(content))
+		if err != nil {
+			report.Unparsed = append(report.Unparsed, username)
+			continue
+		}
+		solutions = append(solutions, solution{username, fp.Without(template)})
+	}
+	report.Solutions = len(solutions)
+
+	for i := range solutions {
+		for j := i + 1; j < len(solutions); j++ {
+			score, shared := similarity.Compare(solutions[i].fingerprint, solutions[j].fingerprint)
+			pair := SimilarityPair{
+				Left:    solutions[i].username,
+				Right:   solutions[j].username,
+				Score:   math.Round(score*1000) / 1000,
+				Shared:  shared,
+				Flagged: shared >= minSharedFingerprints && score >= threshold,
+			}
+			if pair.Flagged {
+				report.Flagged++
+			}
+			report.Pairs = append(report.Pairs, pair)
+		}
+	}
+	sort.SliceStable(report.Pairs, func(i, j int) bool {
+		return report.Pairs[i].Score > report.Pairs[j].Score
+	})
+	return report, nil
+}
+
+// Flagged reports every challenge with solutions scoring at least threshold,
+// keeping only the flagged pairs
+func (ss *SimilarityService) Flagged(threshold float64) ([]SimilarityReport, error) {
+	var ids []int
+	for id := range ss.challengeService.GetChallenges() {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	reports := []SimilarityReport{}
+	for _, id := range ids {
+		report, err := ss.Report(id, threshold)
+		if err != nil {
+			return nil, err
+		}
+		if report.Flagged == 0 {
+			continue
+		}
+		report.Pairs = report.FlaggedPairs()
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+// FlaggedPairs returns the report's flagged pairs, most similar first
+func (r SimilarityReport) FlaggedPairs() []SimilarityPair {
+	pairs := []SimilarityPair{}
+	for _, pair := range r.Pairs {
+		if pair.Flagged {
+			pairs = append(pairs, pair)
+		}
+	}
+	return pairs
+}
+
+// fingerprint returns the fingerprints of Go source, cached by its hash
+func (ss *SimilarityService) fingerprint(code string) (similarity.Fingerprint, error) {
+	hash := codeHash(code)
+	ss.mu.Lock()
+	fp, ok := ss.fingerprints[hash]
+	ss.mu.Unlock()
+	if ok {
+		return fp, nil
+	}
+
+	tokens, err := similarity.Tokens([]byte(code))
+	if err != nil {
+		return nil, err
+	}
+	fp = similarity.Fingerprints(tokens)
+	ss.mu.Lock()
+	ss.fingerprints[hash] = fp
+	ss.mu.Unlock()
+	return fp, nil
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const similarityTemplate = `package main
+
+import "fmt"
+
+// Stats returns the minimum, maximum and mean of numbers
+func Stats(numbers []int) (int, int, float64) {
+	// TODO: implement
+	return 0, 0, 0
+}
+
+func main() {
+	fmt.Println(Stats([]int{3, 1, 2}))
+}
+`
+
+func TestSimilarityReport(t *testing.T) {
+	root := t.TempDir()
+	writeChallenge(t, root, 1, "")
+	writeChallenge(t, root, 2, "")
+	dir := filepath.Join(root, "challenge-1")
+	writeFile(t, filepath.Join(dir, "solution-template.go"), similarityTemplate)
+	solutions := map[string]string{
+		"alice": `package main
+
+import "fmt"
+
+// Stats returns the minimum, maximum and mean of numbers
+func Stats(numbers []int) (int, int, float64) {
+	if len(numbers) == 0 {
+		return 0, 0, 0
+	}
+	lo, hi, sum := numbers[0], numbers[0], 0
+	for _, n := range numbers {
+		if n < lo {
+			lo = n
+		}
+		if n > hi {
+			hi = n
+		}
+		sum += n
+	}
+	return lo, hi, float64(sum) / float64(len(numbers))
+}
+
+func main() {
+	fmt.Println(Stats([]int{3, 1, 2}))
+}
+`,
+		// bob renamed and recommented alice's solution
+		"Bob": `package main
+
+import "fmt"
+
+func Stats(xs []int) (int, int, float64) {
+	if len(xs) == 0 { return 0, 0, 0 }
+	min, max, total := xs[0], xs[0], 0
+	for _, x := range xs {
+		if x < min { min = x } // smallest
+		if x > max { max = x }
+		total += x
+	}
+	return min, max, float64(total) / float64(len(xs))
+}
+
+func main() {
+	fmt.Println(Stats([]int{3, 1, 2}))
+}
+`,
+		"carol": `package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// Stats returns the minimum, maximum and mean of numbers
+func Stats(numbers []int) (int, int, float64) {
+	if len(numbers) == 0 {
+		return 0, 0, 0
+	}
+	sorted := slices.Clone(numbers)
+	slices.Sort(sorted)
+	mean := 0.0
+	for i := range sorted {
+		mean += (float64(sorted[i]) - mean) / float64(i+1)
+	}
+	return sorted[0], sorted[len(sorted)-1], mean
+}
+
+func main() {
+	fmt.Println(Stats([]int{3, 1, 2}))
+}
+`,
+		"dave": "package main\n\nfunc Stats(",
+	}
+	for username, code := range solutions {
+		writeFile(t, filepath.Join(dir, "submissions", username, "solution-template.go"), code)
+	}
+	writeFile(t, filepath.Join(dir, "submissions", "erin", "README.md"), "no solution\n")
+
+	resolver := mustResolver(t, root)
+	challengeService := NewChallengeService(resolver)
+	if err := challengeService.LoadChallenges(); err != nil {
+		t.Fatal(err)
+	}
+	similarity := NewSimilarityService(resolver, challengeService, 0.8)
+	report, err := similarity.Report(1, similarity.Threshold())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Solutions != 3 || !reflect.DeepEqual(report.Unparsed, []string{"dave"}) || len(report.Pairs) != 3 {
+		t.Fatalf("Report = %+v, want 3 solutions, dave unparsed and 3 pairs", report)
+	}
+	if top := report.Pairs[0]; top.Left != "alice" || top.Right != "Bob" || top.Score != 1 || !top.Flagged {
+		t.Errorf("most similar pair = %+v, want alice and Bob flagged with score 1", top)
+	}
+	for _, pair := range report.Pairs[1:] {
+		if pair.Flagged || pair.Score > 0.3 {
+			t.Errorf("pair %+v should not be flagged", pair)
+		}
+	}
+	if report.Flagged != 1 {
+		t.Errorf("Flagged = %d, want 1", report.Flagged)
+	}
+
+	lenient, err := similarity.Report(1, 0.1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// carol's pairs score above a low threshold but share too little to flag
+	if lenient.Flagged != 1 || lenient.Threshold != 0.1 || lenient.Pairs[1].Score < 0.1 {
+		t.Errorf("Report with a low threshold = %+v, want only alice and Bob flagged", lenient)
+	}
+	if pairs := lenient.FlaggedPairs(); len(pairs) != 1 || pairs[0].Right != "Bob" {
+		t.Errorf("FlaggedPairs = %+v", pairs)
+	}
+
+	flagged, err := similarity.Flagged(0.8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(flagged) != 1 || flagged[0].ChallengeID != 1 || len(flagged[0].Pairs) != 1 {
+		t.Errorf("Flagged = %+v, want the alice and Bob pair of challenge 1", flagged)
+	}
+
+	if report, err := similarity.Report(2, 0.8); err != nil || report.Solutions != 0 || len(report.Pairs) != 0 {
+		t.Errorf("Report without submissions = %+v, %v", report, err)
+	}
+	if _, err := similarity.Report(3, 0.8); !errors.Is(err, ErrChallengeNotFound) {
+		t.Errorf("Report for a missing challenge = %v, want ErrChallengeNotFound", err)
+	}
+}
@@ -0,0 +1,556 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"web-ui/internal/diff"
+	"web-ui/internal/models"
+	"web-ui/internal/paths"
+	"web-ui/internal/store"
+)
+
+var (
+	// ErrSolutionNotFound is returned for a user without a solution file
+	ErrSolutionNotFound = errors.New("solution not found")
+	// ErrNoBenchmarks is returned when benchmarking a challenge whose tests
+	// define no benchmarks
+	ErrNoBenchmarks = errors.New("challenge has no benchmarks")
+)
+
+// Test statuses of a solution, from the challenge's SCOREBOARD.md
+const (
+	SolutionPassed  = "passed"
+	SolutionFailed  = "failed"
+	SolutionUnknown = "unknown"
+)
+
+// Statuses of a DeclarationDiff
+const (
+	DeclarationSame    = "same"
+	DeclarationChanged = "changed"
+	DeclarationAdded   = "added"
+	DeclarationRemoved = "removed"
+)
+
+// SolutionSummary describes a user's solution to a challenge without its code
+type SolutionSummary struct {
+	Username    string               `json:"username"`
+	Lines       int                  `json:"lines"`
+	CodeLines   int                  `json:"codeLines" doc:"Lines holding Go code rather than only comments or blanks"`
+	TestStatus  string               `json:"testStatus" enum:"passed,failed,unknown" doc:"From the challenge's SCOREBOARD.md"`
+	Passed      int                  `json:"passed,omitempty"`
+	Total       int                  `json:"total,omitempty"`
+	SubmittedAt *time.Time           `json:"submittedAt,omitempty" doc:"First commit of the submission, when the git history has it"`
+	Benchmarks  *models.BenchmarkRun `json:"benchmarks,omitempty" doc:"Latest benchmark run of the current code"`
+}
+
+// Solution is a user's solution to a challenge
+type Solution struct {
+	SolutionSummary
+	ChallengeID int    `json:"challengeId"`
+	Code        string `json:"code"`
+}
+
+// SolutionGallery lists the solutions submitted for a challenge
+type SolutionGallery struct {
+	ChallengeID   int               `json:"challengeId"`
+	HasBenchmarks bool              `json:"hasBenchmarks" doc:"Whether the challenge's tests define benchmarks"`
+	Solutions     []SolutionSummary `json:"solutions"`
+}
+
+// DeclarationDiff compares one top-level declaration of two solutions
+type DeclarationDiff struct {
+	Name    string      `json:"name" doc:"Such as func Sum, func (*Stack) Push or type Stack; file when the solutions are compared as plain text"`
+	Status  string      `json:"status" enum:"same,changed,added,removed" doc:"added and removed declarations are only in the right or left solution"`
+	Added   int         `json:"added"`
+	Removed int         `json:"removed"`
+	Lines   []diff.Line `json:"lines"`
+}
+
+// SolutionComparison is a diff of two solutions to a challenge
+type SolutionComparison struct {
+	ChallengeID  int               `json:"challengeId"`
+	Left         SolutionSummary   `json:"left"`
+	Right        SolutionSummary   `json:"right"`
+	Formatted    bool              `json:"formatted" doc:"Both solutions parsed, so they were gofmt-formatted and matched up by declaration; otherwise their text is diffed as is"`
+	Added        int               `json:"added" doc:"Lines only in the right solution"`
+	Removed      int               `json:"removed" doc:"Lines only in the left solution"`
+	Declarations []DeclarationDiff `json:"declarations"`
+}
+
+// SolutionService serves the solutions users have submitted to
+// challenge-N/submissions, and keeps their benchmark results in storePath.
+// An empty storePath keeps benchmark results in memory.
+type SolutionService struct {
+	paths             *paths.Resolver
+	scoreboardService *ScoreboardService
+	executionService  *ExecutionService
+	storePath         string
+
+	mu         sync.Mutex
+	benchmarks map[solutionKey]models.BenchmarkRun
+}
+
+type solutionKey struct {
+	challengeID int
+	username    string
+}
+
+// benchmarkRecord is a benchmark run as stored in the benchmarks file
+type benchmarkRecord struct {
+	ChallengeID int                 `json:"challengeId"`
+	Username    string              `json:"username"`
+	Run         models.BenchmarkRun `json:"run"`
+}
+
+// NewSolutionService creates a solution service, loading the benchmark
+// results stored in storePath
+func NewSolutionService(resolver *paths.Resolver, scoreboardService *ScoreboardService, executionService *ExecutionService, storePath string) (*SolutionService, error) {
+	ss := &SolutionService{
+		paths:             resolver,
+		scoreboardService: scoreboardService,
+		executionService:  executionService,
+		storePath:         storePath,
+		benchmarks:        make(map[solutionKey]models.BenchmarkRun),
+	}
+	if storePath == "" {
+		return ss, nil
+	}
+
+	content, err := os.ReadFile(storePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return ss, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read benchmarks file: %v", err)
+	}
+	var records []benchmarkRecord
+	if err := json.Unmarshal(content, &records); err != nil {
+		return nil, fmt.Errorf("failed to parse benchmarks file: %v", err)
+	}
+	for _, record := range records {
+		ss.benchmarks[solutionKey{record.ChallengeID, record.Username}] = record.Run
+	}
+	return ss, nil
+}
+
+// Unlocked reports whether username may browse a challenge's solutions,
+// which takes having solved it
+func (ss *SolutionService) Unlocked(username string, challengeID int) bool {
+	return ss.scoreboardService.Solved(username, challengeID)
+}
+
+// HasBenchmarks reports whether a challenge's tests define benchmarks
+func HasBenchmarks(challenge *models.Challenge) bool {
+	return strings.Contains(challenge.TestFile, "\nfunc Benchmark")
+}
+
+// Gallery lists every solution submitted for a challenge, by username
+func (ss *SolutionService) Gallery(challenge *models.Challenge) (SolutionGallery, error) {
+	gallery := SolutionGallery{ChallengeID: challenge.ID, HasBenchmarks: HasBenchmarks(challenge), Solutions: []SolutionSummary{}}
+	usernames, err := submitters(ss.paths, challenge.ID)
+	if err != nil {
+		return gallery, err
+	}
+
+	rows := ss.scoreboardService.rows(challenge.ID)
+	for _, username := range usernames {
+		solution, err := ss.solution(challenge.ID, username, rows)
+		if errors.Is(err, ErrSolutionNotFound) {
+			continue
+		}
+		if err != nil {
+			return gallery, err
+		}
+		gallery.Solutions = append(gallery.Solutions, solution.SolutionSummary)
+	}
+	sort.Slice(gallery.Solutions, func(i, j int) bool {
+		return strings.ToLower(gallery.Solutions[i].Username) < strings.ToLower(gallery.Solutions[j].Username)
+	})
+	return gallery, nil
+}
+
+// submitters returns the usernames with a directory in a challenge's
+// submissions, whether or not it holds a solution file
+func submitters(resolver *paths.Resolver, challengeID int) ([]string, error) {
+	dir, err := resolver.ChallengeDir(challengeID)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(filepath.Join(dir, "submissions"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list submissions: %v", err)
+	}
+	var usernames []string
+	for _, entry := range entries {
+		if entry.IsDir() && paths.ValidateUsername(entry.Name()) == nil {
+			usernames = append(usernames, entry.Name())
+		}
+	}
+	return usernames, nil
+}
+
+// Solution returns a user's solution to a challenge
+func (ss *SolutionService) Solution(challengeID int, username string) (Solution, error) {
+	return ss.solution(challengeID, username, ss.scoreboardService.rows(challengeID))
+}
+
+// solution reads a user's solution, taking its test status from the
+// challenge's scoreboard rows
+func (ss *SolutionService) solution(challengeID int, username string, rows []scoreboardRow) (Solution, error) {
+	path, err := ss.paths.SubmissionFile(challengeID, username)
+	if err != nil {
+		return Solution{}, fmt.Errorf("%w: %v", ErrSolutionNotFound, err)
+	}
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Solution{}, ErrSolutionNotFound
+	}
+	if err != nil {
+		return Solution{}, fmt.Errorf("failed to read solution: %v", err)
+	}
+
+	code := string(content)
+	lines, codeLines := countLines(code)
+	summary := SolutionSummary{
+		Username:   username,
+		Lines:      lines,
+		CodeLines:  codeLines,
+		TestStatus: SolutionUnknown,
+	}
+	for _, row := range rows {
+		if !strings.EqualFold(row.username, username) {
+			continue
+		}
+		summary.Passed, summary.Total = row.passed, row.total
+		summary.TestStatus = SolutionFailed
+		if row.total > 0 && row.passed == row.total {
+			summary.TestStatus = SolutionPassed
+		}
+		break
+	}
+	if entries, ok := ss.scoreboardService.GetScoreboard(challengeID); ok {
+		for _, entry := range entries {
+			if strings.EqualFold(entry.Username, username) {
+				summary.SubmittedAt = entry.SubmittedAt
+				break
+			}
+		}
+	}
+
+	ss.mu.Lock()
+	if run, ok := ss.benchmarks[solutionKey{challengeID, username}]; ok && run.CodeSHA256 == codeHash(code) {
+		summary.Benchmarks = &run
+	}
+	ss.mu.Unlock()
+
+	return Solution{SolutionSummary: summary, ChallengeID: challengeID, Code: code}, nil
+}
+
+// Compare diffs two users' solutions to a challenge
+func (ss *SolutionService) Compare(challengeID int, left, right string) (SolutionComparison, error) {
+	a, err := ss.Solution(challengeID, left)
+	if err != nil {
+		return SolutionComparison{}, err
+	}
+	b, err := ss.Solution(challengeID, right)
+	if err != nil {
+		return SolutionComparison{}, err
+	}
+	comparison := CompareSolutions(a.Code, b.Code)
+	comparison.ChallengeID = challengeID
+	comparison.Left, comparison.Right = a.SolutionSummary, b.SolutionSummary
+	return comparison, nil
+}
+
+// Benchmark runs a challenge's benchmarks against a user's solution. The
+// last run is returned instead while the solution is unchanged, unless it
+// failed.
+func (ss *SolutionService) Benchmark(ctx context.Context, challenge *models.Challenge, username string) (models.BenchmarkRun, error) {
+	if !HasBenchmarks(challenge) {
+		return models.BenchmarkRun{}, ErrNoBenchmarks
+	}
+	solution, err := ss.Solution(challenge.ID, username)
+	if err != nil {
+		return models.BenchmarkRun{}, err
+	}
+	if solution.Benchmarks != nil && solution.Benchmarks.Passed {
+		return *solution.Benchmarks, nil
+	}
+
+	result, err := ss.executionService.RunBenchmarks(ctx, solution.Code, challenge)
+	if err != nil {
+		return models.BenchmarkRun{}, err
+	}
+	run := models.BenchmarkRun{
+		CodeSHA256: codeHash(solution.Code),
+		RanAt:      time.Now().UTC(),
+		Passed:     result.Passed,
+		Benchmarks: ParseBenchmarks(result.Output),
+	}
+	if !run.Passed {
+		run.Output = result.Output
+	}
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	ss.benchmarks[solutionKey{challenge.ID, username}] = run
+	return run, ss.save()
+}
+
+// save writes the benchmark results to the store; callers hold ss.mu
+func (ss *SolutionService) save() error {
+	if ss.storePath == "" {
+		return nil
+	}
+
+	records := make([]benchmarkRecord, 0, len(ss.benchmarks))
+	for key, run := range ss.benchmarks {
+		records = append(records, benchmarkRecord{ChallengeID: key.challengeID, Username: key.username, Run: run})
+	}
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].ChallengeID != records[j].ChallengeID {
+			return records[i].ChallengeID < records[j].ChallengeID
+		}
+		return records[i].Username < records[j].Username
+	})
+
+	content, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := store.WriteFile(ss.storePath, content); err != nil {
+		return fmt.Errorf("failed to write benchmarks file: %v", err)
+	}
+	return nil
+}
+
+func codeHash(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+// benchmarkLine matches a result line of go test -bench -benchmem, such as
+// "BenchmarkSum/large-8   1000000   1042 ns/op   64 B/op   2 allocs/op"
+var benchmarkLine = regexp.MustCompile(`^Benchmark(\S+?)(?:-\d+)?\s+(\d+)\s+([0-9.]+) ns/op(?:.*?\s(\d+) B/op)?(?:.*?\s(\d+) allocs/op)?`)
+
+// ParseBenchmarks reads the benchmark results from go test -bench output
+func ParseBenchmarks(output string) []models.Benchmark {
+	benchmarks := []models.Benchmark{}
+	for _, line := range strings.Split(output, "\n") {
+		m := benchmarkLine.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			continue
+		}
+		benchmark := models.Benchmark{Name: m[1]}
+		benchmark.Iterations, _ = strconv.ParseInt(m[2], 10, 64)
+		benchmark.NsPerOp, _ = strconv.ParseFloat(m[3], 64)
+		benchmark.BytesPerOp, _ = strconv.ParseInt(m[4], 10, 64)
+		benchmark.AllocsPerOp, _ = strconv.ParseInt(m[5], 10, 64)
+		benchmarks = append(benchmarks, benchmark)
+	}
+	return benchmarks
+}
+
+// countLines returns how many lines code has, and how many of them hold a
+// Go token other than a comment
+func countLines(code string) (lines, codeLines int) {
+	if code == "" {
+		return 0, 0
+	}
+	lines = strings.Count(code, "\n")
+	if !strings.HasSuffix(code, "\n") {
+		lines++
+	}
+
+	fset := token.NewFileSet()
+	file := fset.AddFile("", -1, len(code))
+	var s scanner.Scanner
+	s.Init(file, []byte(code), nil, 0)
+	seen := make(map[int]bool)
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		if tok == token.SEMICOLON && lit == "\n" {
+			continue
+		}
+		first := fset.Position(pos).Line
+		// Raw strings and other multi-line tokens count every line they span
+		last := first + strings.Count(lit, "\n")
+		for line := first; line <= last; line++ {
+			seen[line] = true
+		}
+	}
+	return lines, len(seen)
+}
+
+// declaration is the source of one top-level declaration of a file
+type declaration struct {
+	name   string
+	source string
+}
+
+// CompareSolutions diffs two solutions declaration by declaration, after
+// formatting both with gofmt, so layout changes and reordered functions do
+// not show up as differences. Solutions that do not parse are diffed as
+// plain text.
+func CompareSolutions(left, right string) SolutionComparison {
+	a, okA := declarations(left)
+	b, okB := declarations(right)
+	if !okA || !okB {
+		result := diff.Compare("left", "right", left, right)
+		status := DeclarationChanged
+		if result.Added == 0 && result.Removed == 0 {
+			status = DeclarationSame
+		}
+		return SolutionComparison{
+			Added:   result.Added,
+			Removed: result.Removed,
+			Declarations: []DeclarationDiff{
+				{Name: "file", Status: status, Added: result.Added, Removed: result.Removed, Lines: result.Lines},
+			},
+		}
+	}
+
+	// Declarations only in the right solution follow the nearest earlier
+	// declaration both solutions share, or lead when there is none
+	inLeft := make(map[string]int, len(a))
+	for i, d := range a {
+		inLeft[d.name] = i
+	}
+	inRight := make(map[string]bool, len(b))
+	added := make(map[int][]declaration) // keyed by index in a, -1 for the start
+	after := -1
+	for _, d := range b {
+		inRight[d.name] = true
+		if i, ok := inLeft[d.name]; ok {
+			after = i
+			continue
+		}
+		added[after] = append(added[after], d)
+	}
+	rightSource := make(map[string]string, len(b))
+	for _, d := range b {
+		rightSource[d.name] = d.source
+	}
+
+	comparison := SolutionComparison{Formatted: true, Declarations: []DeclarationDiff{}}
+	appendDiff := func(name, status, from, to string) {
+		result := diff.Compare(name, name, from, to)
+		if status == DeclarationChanged && result.Added == 0 && result.Removed == 0 {
+			status = DeclarationSame
+		}
+		comparison.Added += result.Added
+		comparison.Removed += result.Removed
+		comparison.Declarations = append(comparison.Declarations, DeclarationDiff{
+			Name: name, Status: status, Added: result.Added, Removed: result.Removed, Lines: result.Lines,
+		})
+	}
+	for _, d := range added[-1] {
+		appendDiff(d.name, DeclarationAdded, "", d.source)
+	}
+	for i, d := range a {
+		if inRight[d.name] {
+			appendDiff(d.name, DeclarationChanged, d.source, rightSource[d.name])
+		} else {
+			appendDiff(d.name, DeclarationRemoved, d.source, "")
+		}
+		for _, added := range added[i] {
+			appendDiff(added.name, DeclarationAdded, "", added.source)
+		}
+	}
+	return comparison
+}
+
+// declarations splits gofmt-formatted code into its package clause and
+// top-level declarations, each with the comments before it. It reports
+// false for code that does not parse.
+func declarations(code string) ([]declaration, bool) {
+	formatted, err := format.Source([]byte(code))
+	if err != nil {
+		return nil, false
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", formatted, parser.ParseComments)
+	if err != nil {
+		return nil, false
+	}
+	offset := func(pos token.Pos) int { return fset.Position(pos).Offset }
+
+	names := []string{"package"}
+	ends := []int{offset(file.Name.End())}
+	for _, decl := range file.Decls {
+		names = append(names, declarationName(decl))
+		ends = append(ends, offset(decl.End()))
+	}
+	// Comments after the last declaration stay with it
+	ends[len(ends)-1] = len(formatted)
+
+	decls := make([]declaration, 0, len(names))
+	count := make(map[string]int)
+	start := 0
+	for i, name := range names {
+		// Repeated names, such as several init funcs, are told apart by number
+		count[name]++
+		if count[name] > 1 {
+			name = fmt.Sprintf("%s (%d)", name, count[name])
+		}
+		source := strings.TrimLeft(string(formatted[start:ends[i]]), "\n")
+		decls = append(decls, declaration{name: name, source: strings.TrimRight(source, "\n") + "\n"})
+		start = ends[i]
+	}
+	return decls, true
+}
+
+// declarationName names a top-level declaration the way it is written,
+// such as "func (*Stack) Push" or "var a, b"
+func declarationName(decl ast.Decl) string {
+	switch decl := decl.(type) {
+	case *ast.FuncDecl:
+		if decl.Recv != nil && len(decl.Recv.List) > 0 {
+			return fmt.Sprintf("func (%s) %s", types.ExprString(decl.Recv.List[0].Type), decl.Name.Name)
+		}
+		return "func " + decl.Name.Name
+	case *ast.GenDecl:
+		if decl.Tok == token.IMPORT {
+			return "import"
+		}
+		var names []string
+		for _, spec := range decl.Specs {
+			switch spec := spec.(type) {
+			case *ast.TypeSpec:
+				names = append(names, spec.Name.Name)
+			case *ast.ValueSpec:
+				for _, name := range spec.Names {
+					names = append(names, name.Name)
+				}
+			}
+		}
+		return decl.Tok.String() + " " + strings.Join(names, ", ")
+	}
+	return "declaration"
+}
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"web-ui/internal/diff"
+	"web-ui/internal/models"
+)
+
+func newTestSolutionService(t *testing.T, storePath string) (*SolutionService, *models.Challenge) {
+	t.Helper()
+	root := t.TempDir()
+	writeChallenge(t, root, 1, "")
+	writeFiles(t, filepath.Join(root, "challenge-1"), map[string]string{
+		"SCOREBOARD.md":                              "# Scoreboard\n| Username | Passed Tests | Total Tests |\n|---|---|---|\n| alice | 3 | 3 |\n| Bob | 1 | 3 |\n",
+		"solution-template_test.go":                  "package main\n\nimport \"testing\"\n\nfunc BenchmarkSum(b *testing.B) {}\n",
+		"submissions/alice/solution-template.go":     "package main\n\n// Sum adds\nfunc Sum(a, b int) int {\n\treturn a + b\n}\n",
+		"submissions/bob/solution-template.go":       "package main\n\nfunc Sum(a, b int) int { return b + a }\n",
+		"submissions/carol/README.md":                "no solution\n",
+		"submissions/not_valid/solution-template.go": "package main\n",
+	})
+	resolver := mustResolver(t, root)
+	challengeService := NewChallengeService(resolver)
+	if err := challengeService.LoadChallenges(); err != nil {
+		t.Fatal(err)
+	}
+	scoreboardService := NewScoreboardService(resolver)
+	scoreboardService.LoadScoreboards(challengeService.GetChallenges())
+	solutions, err := NewSolutionService(resolver, scoreboardService, nil, storePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	challenge, _ := challengeService.GetChallenge(1)
+	return solutions, challenge
+}
+
+func TestSolutionGallery(t *testing.T) {
+	solutions, challenge := newTestSolutionService(t, "")
+
+	gallery, err := solutions.Gallery(challenge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []SolutionSummary{
+		{Username: "alice", Lines: 6, CodeLines: 4, TestStatus: SolutionPassed, Passed: 3, Total: 3},
+		{Username: "bob", Lines: 3, CodeLines: 2, TestStatus: SolutionFailed, Passed: 1, Total: 3},
+	}
+	if !gallery.HasBenchmarks || !reflect.DeepEqual(gallery.Solutions, want) {
+		t.Errorf("Gallery = %+v, want benchmarks and %+v", gallery, want)
+	}
+
+	// Bob's partial pass is on the scoreboard but does not unlock the gallery
+	if !solutions.Unlocked("Alice", 1) || solutions.Unlocked("dave", 1) || solutions.Unlocked("bob", 1) {
+		t.Error("Unlocked should take a complete solve on the scoreboard")
+	}
+	if _, err := solutions.Solution(1, "carol"); !errors.Is(err, ErrSolutionNotFound) {
+		t.Errorf("Solution without a file = %v, want ErrSolutionNotFound", err)
+	}
+	if _, err := solutions.Solution(1, "../alice"); !errors.Is(err, ErrSolutionNotFound) {
+		t.Errorf("Solution for an invalid username = %v, want ErrSolutionNotFound", err)
+	}
+
+	comparison, err := solutions.Compare(1, "alice", "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !comparison.Formatted || comparison.Left.Username != "alice" || comparison.Right.Username != "bob" ||
+		len(comparison.Declarations) != 2 || comparison.Declarations[1].Status != DeclarationChanged {
+		t.Errorf("Compare = %+v", comparison)
+	}
+}
+
+func TestCompareSolutions(t *testing.T) {
+	left := "package main\n\nimport \"fmt\"\n\nfunc A() {\n\tfmt.Println(1)\n}\n\nfunc B() {}\n\ntype T struct{}\n\nfunc (t *T) M() {}\n"
+	// Reordered, reformatted, B changed, T's method gone and C added
+	right := "package main\nimport \"fmt\"\nfunc B() { fmt.Println(2) }\nfunc C() {}\nfunc A() {\n    fmt.Println(1)\n}\ntype T struct{}\n"
+
+	comparison := CompareSolutions(left, right)
+	if !comparison.Formatted {
+		t.Fatal("Formatted = false for solutions that parse")
+	}
+	var got []string
+	for _, d := range comparison.Declarations {
+		got = append(got, d.Name+":"+d.Status)
+	}
+	want := []string{"package:same", "import:same", "func A:same", "func B:changed", "func C:added", "type T:same", "func (*T) M:removed"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("declarations = %v, want %v", got, want)
+	}
+	if comparison.Added != 2 || comparison.Removed != 2 {
+		t.Errorf("Added, Removed = %d, %d, want 2, 2", comparison.Added, comparison.Removed)
+	}
+
+	// Solutions that do not parse are diffed as text
+	plain := CompareSolutions("package main\nfunc {\n", "package main\n")
+	wantLines := []diff.Line{{Kind: diff.Equal, Text: "package main"}, {Kind: diff.Delete, Text: "func {"}}
+	if plain.Formatted || len(plain.Declarations) != 1 || plain.Declarations[0].Name != "file" || !reflect.DeepEqual(plain.Declarations[0].Lines, wantLines) {
+		t.Errorf("CompareSolutions of unparsable code = %+v", plain)
+	}
+}
+
+func TestParseBenchmarks(t *testing.T) {
+	output := "goos: linux\nBenchmarkSum-8   \t 1000000\t      1042 ns/op\t      64 B/op\t       2 allocs/op\n" +
+		"BenchmarkSort/size=10-8  500  2.5 ns/op  0 B/op  0 allocs/op\nBenchmarkPlain 10 7 ns/op\nPASS\n"
+	want := []models.Benchmark{
+		{Name: "Sum", Iterations: 1000000, NsPerOp: 1042, BytesPerOp: 64, AllocsPerOp: 2},
+		{Name: "Sort/size=10", Iterations: 500, NsPerOp: 2.5},
+		{Name: "Plain", Iterations: 10, NsPerOp: 7},
+	}
+	if got := ParseBenchmarks(output); !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseBenchmarks = %+v, want %+v", got, want)
+	}
+}
+
+func TestSolutionBenchmarks(t *testing.T) {
+	storePath := filepath.Join(t.TempDir(), "benchmarks.json")
+	solutions, challenge := newTestSolutionService(t, storePath)
+
+	if _, err := solutions.Benchmark(context.Background(), &models.Challenge{ID: 1}, "alice"); !errors.Is(err, ErrNoBenchmarks) {
+		t.Errorf("Benchmark without benchmarks = %v, want ErrNoBenchmarks", err)
+	}
+
+	// A passed run of the current code is reused without running anything
+	alice, err := solutions.Solution(1, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	run := models.BenchmarkRun{CodeSHA256: codeHash(alice.Code), RanAt: time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC), Passed: true,
+		Benchmarks: []models.Benchmark{{Name: "Sum", Iterations: 10, NsPerOp: 3}}}
+	solutions.mu.Lock()
+	solutions.benchmarks[solutionKey{1, "alice"}] = run
+	solutions.benchmarks[solutionKey{1, "bob"}] = models.BenchmarkRun{CodeSHA256: "stale", Passed: true}
+	err = solutions.save()
+	solutions.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := solutions.Benchmark(context.Background(), challenge, "alice")
+	if err != nil || !reflect.DeepEqual(got, run) {
+		t.Errorf("Benchmark = %+v, %v, want the stored run", got, err)
+	}
+
+	reopened, _ := newTestSolutionService(t, storePath)
+	gallery, err := reopened.Gallery(challenge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := gallery.Solutions[0].Benchmarks; b == nil || !reflect.DeepEqual(*b, run) {
+		t.Errorf("alice's benchmarks after reopening = %+v, want %+v", b, run)
+	}
+	if gallery.Solutions[1].Benchmarks != nil {
+		t.Error("benchmarks of changed code are listed")
+	}
+}
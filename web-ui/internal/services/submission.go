@@ -0,0 +1,47 @@
+package services
+
+import (
+	"strings"
+	"sync"
+
+	"web-ui/internal/models"
+)
+
+// SubmissionService keeps the submissions made since the server started
+type SubmissionService struct {
+	mu          sync.Mutex
+	submissions []models.Submission
+}
+
+// NewSubmissionService creates an empty submission log
+func NewSubmissionService() *SubmissionService {
+	return &SubmissionService{submissions: make([]models.Submission, 0)}
+}
+
+// Add records a submission
+func (ss *SubmissionService) Add(submission models.Submission) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	ss.submissions = append(ss.submissions, submission)
+}
+
+// All returns every submission, oldest first
+func (ss *SubmissionService) All() []models.Submission {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	return append([]models.Submission{}, ss.submissions...)
+}
+
+// ByUser returns a user's submissions, newest first
+func (ss *SubmissionService) ByUser(username string) []models.Submission {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	own := make([]models.Submission, 0)
+	for i := len(ss.submissions) - 1; i >= 0; i-- {
+		if strings.EqualFold(ss.submissions[i].Username, username) {
+			own = append(own, ss.submissions[i])
+		}
+	}
+	return own
+}
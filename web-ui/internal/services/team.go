@@ -0,0 +1,244 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"web-ui/internal/models"
+	"web-ui/internal/paths"
+	"web-ui/internal/store"
+)
+
+// TeamsFileName is the optional repository file defining teams
+const TeamsFileName = "teams.json"
+
+// Where a team is defined
+const (
+	TeamSourceRepository = "repository"
+	TeamSourceAdmin      = "admin"
+)
+
+var (
+	// ErrTeamNotFound is returned for an unknown team slug
+	ErrTeamNotFound = errors.New("team not found")
+	// ErrTeamReadOnly is returned when changing a team defined in teams.json
+	ErrTeamReadOnly = errors.New("team is defined in the repository")
+	// ErrInvalidTeam is returned for a team without a usable name or with
+	// members that are not GitHub usernames
+	ErrInvalidTeam = errors.New("invalid team")
+)
+
+// teamDefinition is one entry of a teams file
+type teamDefinition struct {
+	Name    string   `json:"name"`
+	Members []string `json:"members"`
+}
+
+// TeamService maps GitHub usernames to teams. Teams come from teams.json in
+// the repository root, which is read-only, and from a store managed through
+// the admin API.
+type TeamService struct {
+	storePath string
+	mu        sync.RWMutex
+	teams     map[string]*models.Team
+}
+
+// NewTeamService loads the repository's teams.json and the admin team store
+// at storePath. Missing files define no teams; an empty storePath keeps
+// admin teams in memory only.
+func NewTeamService(resolver *paths.Resolver, storePath string) (*TeamService, error) {
+	ts := &TeamService{
+		storePath: storePath,
+		teams:     make(map[string]*models.Team),
+	}
+
+	repoPath := filepath.Join(resolver.Root(), TeamsFileName)
+	if err := ts.load(repoPath, TeamSourceRepository); err != nil {
+		return nil, err
+	}
+	if storePath != "" {
+		if err := ts.load(storePath, TeamSourceAdmin); err != nil {
+			return nil, err
+		}
+	}
+	return ts, nil
+}
+
+// load adds the teams defined in path. Repository teams take precedence
+// over admin teams of the same name.
+func (ts *TeamService) load(path, source string) error {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read teams file: %v", err)
+	}
+
+	var definitions []teamDefinition
+	if err := json.Unmarshal(content, &definitions); err != nil {
+		return fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+	for _, definition := range definitions {
+		team, err := newTeam(definition.Name, definition.Members, source)
+		if err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+		if existing, ok := ts.teams[team.Slug]; ok {
+			if existing.Source == source {
+				return fmt.Errorf("%s: duplicate team %q", path, team.Name)
+			}
+			continue
+		}
+		ts.teams[team.Slug] = team
+	}
+	return nil
+}
+
+// TeamSlug returns the slug a team with the given name is addressed by
+func TeamSlug(name string) string {
+	return pathSlug(strings.TrimSpace(name))
+}
+
+// newTeam validates a team definition, dropping repeated members
+func newTeam(name string, members []string, source string) (*models.Team, error) {
+	name = strings.TrimSpace(name)
+	slug := TeamSlug(name)
+	if slug == "" {
+		return nil, fmt.Errorf("%w: name %q has no letters or digits", ErrInvalidTeam, name)
+	}
+
+	team := &models.Team{Slug: slug, Name: name, Members: []string{}, Source: source}
+	seen := make(map[string]bool)
+	for _, member := range members {
+		member = strings.TrimSpace(member)
+		if err := paths.ValidateUsername(member); err != nil {
+			return nil, fmt.Errorf("%w: team %q: %v", ErrInvalidTeam, name, err)
+		}
+		if key := strings.ToLower(member); !seen[key] {
+			seen[key] = true
+			team.Members = append(team.Members, member)
+		}
+	}
+	return team, nil
+}
+
+// Teams returns every team ordered by slug
+func (ts *TeamService) Teams() []models.Team {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+
+	teams := make([]models.Team, 0, len(ts.teams))
+	for _, team := range ts.teams {
+		teams = append(teams, copyTeam(team))
+	}
+	sort.Slice(teams, func(i, j int) bool { return teams[i].Slug < teams[j].Slug })
+	return teams
+}
+
+// Team returns the team with the given slug
+func (ts *TeamService) Team(slug string) (models.Team, bool) {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+
+	team, ok := ts.teams[slug]
+	if !ok {
+		return models.Team{}, false
+	}
+	return copyTeam(team), true
+}
+
+// TeamsOf returns the teams username belongs to, ordered by slug
+func (ts *TeamService) TeamsOf(username string) []models.Team {
+	var teams []models.Team
+	for _, team := range ts.Teams() {
+		if team.HasMember(username) {
+			teams = append(teams, team)
+		}
+	}
+	return teams
+}
+
+// SaveTeam creates or replaces an admin team and persists the store. It
+// reports whether the team is new.
+func (ts *TeamService) SaveTeam(name string, members []string) (models.Team, bool, error) {
+	team, err := newTeam(name, members, TeamSourceAdmin)
+	if err != nil {
+		return models.Team{}, false, err
+	}
+
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	previous, exists := ts.teams[team.Slug]
+	if exists && previous.Source == TeamSourceRepository {
+		return models.Team{}, false, ErrTeamReadOnly
+	}
+	ts.teams[team.Slug] = team
+	if err := ts.save(); err != nil {
+		if exists {
+			ts.teams[team.Slug] = previous
+		} else {
+			delete(ts.teams, team.Slug)
+		}
+		return models.Team{}, false, err
+	}
+	return copyTeam(team), !exists, nil
+}
+
+// DeleteTeam removes an admin team and persists the store
+func (ts *TeamService) DeleteTeam(slug string) error {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	team, ok := ts.teams[slug]
+	if !ok {
+		return ErrTeamNotFound
+	}
+	if team.Source == TeamSourceRepository {
+		return ErrTeamReadOnly
+	}
+	delete(ts.teams, slug)
+	if err := ts.save(); err != nil {
+		ts.teams[slug] = team
+		return err
+	}
+	return nil
+}
+
+// save writes the admin teams atomically. Callers must hold ts.mu.
+func (ts *TeamService) save() error {
+	if ts.storePath == "" {
+		return nil
+	}
+
+	definitions := []teamDefinition{}
+	for _, team := range ts.teams {
+		if team.Source == TeamSourceAdmin {
+			definitions = append(definitions, teamDefinition{Name: team.Name, Members: team.Members})
+		}
+	}
+	sort.Slice(definitions, func(i, j int) bool { return definitions[i].Name < definitions[j].Name })
+
+	content, err := json.MarshalIndent(definitions, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := store.WriteFile(ts.storePath, content); err != nil {
+		return fmt.Errorf("failed to write teams file: %v", err)
+	}
+	return nil
+}
+
+func copyTeam(team *models.Team) models.Team {
+	copied := *team
+	copied.Members = append([]string{}, team.Members...)
+	return copied
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"web-ui/internal/paths"
+)
+
+func TestTeamService(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, TeamsFileName), `[{"name": "Gophers", "members": ["alice", "Bob", "bob"]}]`)
+	store := filepath.Join(t.TempDir(), "data", "teams.json")
+
+	teams, err := NewTeamService(mustResolver(t, root), store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gophers, ok := teams.Team("gophers")
+	if !ok || gophers.Source != TeamSourceRepository || !reflect.DeepEqual(gophers.Members, []string{"alice", "Bob"}) {
+		t.Fatalf("Team(gophers) = %+v, %v", gophers, ok)
+	}
+
+	if _, _, err := teams.SaveTeam("Gophers", []string{"carol"}); !errors.Is(err, ErrTeamReadOnly) {
+		t.Errorf("SaveTeam over a repository team: err = %v, want ErrTeamReadOnly", err)
+	}
+	if _, _, err := teams.SaveTeam("Owls", []string{"not a user"}); !errors.Is(err, ErrInvalidTeam) {
+		t.Errorf("SaveTeam with an invalid member: err = %v, want ErrInvalidTeam", err)
+	}
+	owls, created, err := teams.SaveTeam("Night Owls", []string{"carol", "bob"})
+	if err != nil || !created || owls.Slug != "night-owls" {
+		t.Fatalf("SaveTeam = %+v, %v, %v", owls, created, err)
+	}
+	if _, created, _ := teams.SaveTeam("Night Owls", []string{"carol"}); created {
+		t.Error("replacing a team reported it as created")
+	}
+
+	var names []string
+	for _, team := range teams.TeamsOf("BOB") {
+		names = append(names, team.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"Gophers"}) {
+		t.Errorf("TeamsOf(BOB) = %v, want [Gophers]", names)
+	}
+
+	// Admin teams survive a restart; repository teams are not copied into the store
+	reloaded, err := NewTeamService(mustResolver(t, root), store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if owls, ok := reloaded.Team("night-owls"); !ok || owls.Source != TeamSourceAdmin || !reflect.DeepEqual(owls.Members, []string{"carol"}) {
+		t.Errorf("reloaded Team(night-owls) = %+v, %v", owls, ok)
+	}
+	if got := len(reloaded.Teams()); got != 2 {
+		t.Errorf("reloaded %d teams, want 2", got)
+	}
+
+	if err := reloaded.DeleteTeam("gophers"); !errors.Is(err, ErrTeamReadOnly) {
+		t.Errorf("DeleteTeam(gophers) = %v, want ErrTeamReadOnly", err)
+	}
+	if err := reloaded.DeleteTeam("night-owls"); err != nil {
+		t.Fatal(err)
+	}
+	if err := reloaded.DeleteTeam("night-owls"); !errors.Is(err, ErrTeamNotFound) {
+		t.Errorf("second DeleteTeam = %v, want ErrTeamNotFound", err)
+	}
+}
+
+func TestTeamsFileErrors(t *testing.T) {
+	tests := map[string]string{
+		"not json":       `{"Gophers": ["alice"]}`,
+		"no name":        `[{"name": "  ", "members": ["alice"]}]`,
+		"invalid member": `[{"name": "Gophers", "members": ["../alice"]}]`,
+		"duplicate":      `[{"name": "Gophers", "members": []}, {"name": "gophers!", "members": []}]`,
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			root := t.TempDir()
+			writeFile(t, filepath.Join(root, TeamsFileName), content)
+			if _, err := NewTeamService(mustResolver(t, root), ""); err == nil {
+				t.Error("NewTeamService accepted an invalid teams.json")
+			}
+		})
+	}
+}
+
+func TestTeamStandings(t *testing.T) {
+	root := t.TempDir()
+	for id := 1; id <= 4; id++ {
+		writeChallenge(t, root, id, "")
+	}
+	header := "| Username | Passed Tests | Total Tests |\n|------|------|------|\n"
+	writeFile(t, filepath.Join(root, "challenge-1", paths.ScoreboardFileName), header+"| alice | 2 | 2 |\n| bob | 2 | 2 |\n| carol | 2 | 2 |\n")
+	writeFile(t, filepath.Join(root, "challenge-2", paths.ScoreboardFileName), header+"| alice | 2 | 2 |\n| dave | 2 | 2 |\n")
+	writeFile(t, filepath.Join(root, "challenge-3", paths.ScoreboardFileName), header+"| bob | 2 | 2 |\n")
+	writeFile(t, filepath.Join(root, TeamsFileName), `[
+		{"name": "Gophers", "members": ["Alice", "bob", "erin"]},
+		{"name": "Owls", "members": ["carol", "dave"]},
+		{"name": "Empty", "members": []}
+	]`)
+
+	resolver := mustResolver(t, root)
+	challengeService := NewChallengeService(resolver)
+	if err := challengeService.LoadChallenges(); err != nil {
+		t.Fatal(err)
+	}
+	scoreboardService := NewScoreboardService(resolver)
+	scorer, err := NewScorer(ScoringCompletion, ScoringRules{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaderboard := NewLeaderboardService(challengeService, scoreboardService, scorer, nil)
+	teams, err := NewTeamService(resolver, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	standings := leaderboard.TeamStandings(teams.Teams(), time.Now())
+	if len(standings) != 3 {
+		t.Fatalf("got %d team standings, want 3", len(standings))
+	}
+
+	// Gophers average 4/3 points, Owls 2/2
+	gophers, owls, empty := standings[0], standings[1], standings[2]
+	if gophers.Team.Slug != "gophers" || gophers.Rank != 1 || gophers.AverageScore != 1.33 {
+		t.Errorf("first = %s rank %d average %v, want gophers rank 1 average 1.33", gophers.Team.Slug, gophers.Rank, gophers.AverageScore)
+	}
+	if !reflect.DeepEqual(gophers.Solved, []int{1, 2, 3}) || gophers.Coverage != 75 {
+		t.Errorf("gophers solved %v (%v%%), want [1 2 3] (75%%)", gophers.Solved, gophers.Coverage)
+	}
+	var members []string
+	for _, member := range gophers.Members {
+		members = append(members, member.Username)
+	}
+	if !reflect.DeepEqual(members, []string{"alice", "bob", "erin"}) || gophers.Members[2].Rank != 0 {
+		t.Errorf("gophers members = %v, want alice, bob and an unranked erin", gophers.Members)
+	}
+	if owls.Team.Slug != "owls" || owls.Rank != 2 || owls.Coverage != 50 {
+		t.Errorf("second = %s rank %d coverage %v, want owls rank 2 coverage 50", owls.Team.Slug, owls.Rank, owls.Coverage)
+	}
+	if empty.Team.Slug != "empty" || empty.Rank != 3 || empty.AverageScore != 0 || len(empty.Solved) != 0 {
+		t.Errorf("third = %+v, want an empty team ranked 3", empty)
+	}
+}
@@ -1,34 +1,56 @@
 package services
 
 import (
-	"fmt"
 	"io/ioutil"
 	"os"
-	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
+	"web-ui/internal/metrics"
 	"web-ui/internal/models"
+	"web-ui/internal/paths"
 )
 
+// maxCachedUsers bounds the attempts cache. Profiles are public, so
+// requests for made-up usernames would otherwise grow it without limit.
+const maxCachedUsers = 1000
+
 // UserService handles user-related operations
 type UserService struct {
+	paths        *paths.Resolver
+	mu           sync.RWMutex // guards userAttempts
 	userAttempts models.UserAttemptsMap
+
+	cacheLookups *metrics.CounterVec
 }
 
 // NewUserService creates a new user service
-func NewUserService() *UserService {
+func NewUserService(resolver *paths.Resolver) *UserService {
 	return &UserService{
+		paths:        resolver,
 		userAttempts: make(models.UserAttemptsMap),
 	}
 }
 
+// RegisterMetrics exposes attempt cache hits and misses
+func (us *UserService) RegisterMetrics(reg *metrics.Registry) {
+	us.cacheLookups = reg.NewCounterVec("webui_user_attempts_cache_lookups_total",
+		"User attempt cache lookups by result (hit or miss).", "result")
+}
+
 // LoadUserAttempts checks the filesystem for submission directories
 func (us *UserService) LoadUserAttempts(username string, challenges models.ChallengeMap) *models.UserAttemptedChallenges {
 	// If we already loaded this user's attempts, return from cache
-	if attempts, ok := us.userAttempts[username]; ok {
+	us.mu.RLock()
+	attempts, ok := us.userAttempts[username]
+	us.mu.RUnlock()
+	if ok {
+		us.cacheLookups.Inc("hit")
 		return attempts
 	}
+	us.cacheLookups.Inc("miss")
 
 	// Create new tracking structure
 	userAttempt := &models.UserAttemptedChallenges{
@@ -47,86 +69,86 @@ func (us *UserService) LoadUserAttempts(username string, challenges models.Chall
 		}
 	}
 
-	// Cache the results
+	// Cache the results. The scan ran unlocked, so a concurrent load may
+	// have cached the same user first; either result is equally fresh.
+	us.mu.Lock()
+	if _, cached := us.userAttempts[username]; !cached && len(us.userAttempts) >= maxCachedUsers {
+		// Evict an arbitrary user; they are rescanned on their next lookup
+		for evicted := range us.userAttempts {
+			delete(us.userAttempts, evicted)
+			break
+		}
+	}
 	us.userAttempts[username] = userAttempt
+	us.mu.Unlock()
 	return userAttempt
 }
 
 // hasUserSubmission checks if a user has a submission for a challenge
 func (us *UserService) hasUserSubmission(username string, challengeID int) bool {
-	// Try different path formats to handle potential path issues
-	// Absolute path
-	submissionDir := filepath.Join("..", fmt.Sprintf("challenge-%d", challengeID), "submissions", username)
-	submissionFile := filepath.Join(submissionDir, "solution-template.go")
-
-	// Check if the file exists
-	if _, err := os.Stat(submissionFile); err == nil {
-		return true
+	submissionFile, err := us.paths.SubmissionFile(challengeID, username)
+	if err != nil {
+		return false
 	}
 
-	// Alternative path (direct from workspace root)
-	altSubmissionFile := filepath.Join(fmt.Sprintf("challenge-%d", challengeID), "submissions", username, "solution-template.go")
+	_, err = os.Stat(submissionFile)
+	return err == nil
+}
 
-	if _, err := os.Stat(altSubmissionFile); err == nil {
-		return true
+// SubmissionTime returns when a user's submission file for a challenge was last written
+func (us *UserService) SubmissionTime(username string, challengeID int) (time.Time, bool) {
+	submissionFile, err := us.paths.SubmissionFile(challengeID, username)
+	if err != nil {
+		return time.Time{}, false
 	}
 
-	return false
+	info, err := os.Stat(submissionFile)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return info.ModTime(), true
 }
 
 // GetExistingSolution returns the content of an existing solution file if it exists
 func (us *UserService) GetExistingSolution(username string, challengeID int) string {
-	if username == "" {
+	submissionFile, err := us.paths.SubmissionFile(challengeID, username)
+	if err != nil {
 		return ""
 	}
 
-	// Try different path formats
-	// First try the relative path from web-ui
-	submissionFile := filepath.Join("..", fmt.Sprintf("challenge-%d", challengeID), "submissions", username, "solution-template.go")
 	content, err := ioutil.ReadFile(submissionFile)
-	if err == nil {
-		return string(content)
-	}
-
-	// Try alternative path from root directory
-	altSubmissionFile := filepath.Join(fmt.Sprintf("challenge-%d", challengeID), "submissions", username, "solution-template.go")
-	content, err = ioutil.ReadFile(altSubmissionFile)
-	if err == nil {
-		return string(content)
+	if err != nil {
+		return ""
 	}
-
-	return ""
+	return string(content)
 }
 
 // RefreshUserAttempts clears the cache for a user and reloads their attempts
 func (us *UserService) RefreshUserAttempts(username string, challenges models.ChallengeMap) *models.UserAttemptedChallenges {
 	// Clear cache
+	us.mu.Lock()
 	delete(us.userAttempts, username)
+	us.mu.Unlock()
 	// Reload and return
 	return us.LoadUserAttempts(username, challenges)
 }
 
 // GetUserAttempts returns the cached user attempts or loads them if not cached
 func (us *UserService) GetUserAttempts(username string, challenges models.ChallengeMap) *models.UserAttemptedChallenges {
-	if attempts, ok := us.userAttempts[username]; ok {
-		return attempts
-	}
 	return us.LoadUserAttempts(username, challenges)
 }
 
 // calculateScore calculates the score for a user's submission for a challenge
 func (us *UserService) calculateScore(username string, challengeID int) int {
 	// Read the scoreboard file for this challenge
-	scoreboardPath := filepath.Join("..", fmt.Sprintf("challenge-%d", challengeID), "SCOREBOARD.md")
+	scoreboardPath, err := us.paths.ScoreboardFile(challengeID)
+	if err != nil {
+		return 50
+	}
 	content, err := ioutil.ReadFile(scoreboardPath)
 	if err != nil {
-		// Try alternative path
-		scoreboardPath = filepath.Join(fmt.Sprintf("challenge-%d", challengeID), "SCOREBOARD.md")
-		content, err = ioutil.ReadFile(scoreboardPath)
-		if err != nil {
-			// No scoreboard file, return default score
-			return 50
-		}
+		// No scoreboard file, return default score
+		return 50
 	}
 
 	scoreboardContent := string(content)
@@ -134,22 +156,19 @@ func (us *UserService) calculateScore(username string, challengeID int) int {
 	// Parse the scoreboard to find this user's results
 	lines := strings.Split(scoreboardContent, "\n")
 	for _, line := range lines {
-		// Look for lines that contain the username
-		if strings.Contains(line, username) && strings.Contains(line, "|") {
-			// Parse the table row: | Username | Passed Tests | Total Tests |
-			parts := strings.Split(line, "|")
-			if len(parts) >= 4 {
-				passedStr := strings.TrimSpace(parts[2])
-				totalStr := strings.TrimSpace(parts[3])
-
-				passed, err1 := strconv.Atoi(passedStr)
-				total, err2 := strconv.Atoi(totalStr)
-
-				if err1 == nil && err2 == nil && total > 0 {
-					// Calculate percentage score
-					score := (passed * 100) / total
-					return score
-				}
+		// Parse the table row: | Username | Passed Tests | Total Tests |
+		parts := strings.Split(line, "|")
+		if len(parts) >= 4 && strings.TrimSpace(parts[1]) == username {
+			passedStr := strings.TrimSpace(parts[2])
+			totalStr := strings.TrimSpace(parts[3])
+
+			passed, err1 := strconv.Atoi(passedStr)
+			total, err2 := strconv.Atoi(totalStr)
+
+			if err1 == nil && err2 == nil && total > 0 {
+				// Calculate percentage score
+				score := (passed * 100) / total
+				return score
 			}
 		}
 	}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"web-ui/internal/paths"
+)
+
+func TestUserAttemptsConcurrent(t *testing.T) {
+	cs, err := loadTestChallenges(t, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := cs.paths.Root()
+	writeFile(t, filepath.Join(root, "challenge-1", "submissions", "gopher", paths.SolutionFileName), "package main\n")
+	us := NewUserService(cs.paths)
+
+	// Profiles and team activity load attempts for many users at once
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				us.GetUserAttempts(fmt.Sprintf("user%d", j%4), cs.GetChallenges())
+				if i%2 == 0 {
+					us.RefreshUserAttempts("gopher", cs.GetChallenges())
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if attempts := us.GetUserAttempts("gopher", cs.GetChallenges()); !attempts.AttemptedIDs[1] || len(attempts.AttemptedIDs) != 1 {
+		t.Errorf("gopher attempted %v, want challenge 1", attempts.AttemptedIDs)
+	}
+}
+
+func TestUserAttemptsScoreMatchesWholeUsername(t *testing.T) {
+	cs, err := loadTestChallenges(t, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := cs.paths.Root()
+	for _, username := range []string{"go", "gopher"} {
+		writeFile(t, filepath.Join(root, "challenge-1", "submissions", username, paths.SolutionFileName), "package main\n")
+	}
+	writeFile(t, filepath.Join(root, "challenge-1", "SCOREBOARD.md"),
+		"# Scoreboard\n| Username | Passed Tests | Total Tests |\n|------|------|------|\n| gopher | 1 | 4 |\n| go | 4 | 4 |\n")
+	us := NewUserService(cs.paths)
+
+	for username, want := range map[string]int{"go": 100, "gopher": 25} {
+		if score := us.GetUserAttempts(username, cs.GetChallenges()).Scores[1]; score != want {
+			t.Errorf("%s scored %d, want %d", username, score, want)
+		}
+	}
+}
+
+func TestUserAttemptsCacheIsBounded(t *testing.T) {
+	cs, err := loadTestChallenges(t, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	us := NewUserService(cs.paths)
+
+	// Anyone can look up a profile, so made-up names must not pile up
+	for i := 0; i < maxCachedUsers+50; i++ {
+		us.GetUserAttempts(fmt.Sprintf("visitor%d", i), cs.GetChallenges())
+	}
+	if n := len(us.userAttempts); n != maxCachedUsers {
+		t.Errorf("cached %d users, want at most %d", n, maxCachedUsers)
+	}
+}
@@ -0,0 +1,170 @@
+// Package similarity scores how alike Go sources are regardless of naming,
+// comments, literal values and layout. Sources are reduced to the tokens of
+// a normalized syntax tree and fingerprinted by winnowing their k-grams, as
+// described in "Winnowing: Local Algorithms for Document Fingerprinting"
+// (Schleimer, Wilkerson and Aiken, 2003).
+package similarity
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/scanner"
+	"go/token"
+	"hash/fnv"
+)
+
+const (
+	// K is how many tokens each hashed k-gram spans. Matches shorter than
+	// this are too common in Go to mean anything.
+	K = 6
+	// Window is how many consecutive k-gram hashes each fingerprint is
+	// picked from. Any match of at least K+Window-1 tokens is guaranteed to
+	// share a fingerprint.
+	Window = 4
+)
+
+// Tokens normalizes Go source and returns its tokens. Comments, the package
+// clause and imports are dropped, every identifier becomes the same name and
+// every literal the same value of its kind, so renaming, rewording and
+// reformatting leave the tokens unchanged.
+func Tokens(src []byte) ([]string, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+
+	var tokens []string
+	var buf bytes.Buffer
+	for _, decl := range file.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.IMPORT {
+			continue
+		}
+		ast.Inspect(decl, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.Ident:
+				n.Name = "x"
+			case *ast.BasicLit:
+				n.Value = canonicalLiteral(n.Kind)
+			}
+			return true
+		})
+
+		buf.Reset()
+		if err := printer.Fprint(&buf, fset, decl); err != nil {
+			return nil, err
+		}
+		buf.WriteByte('\n')
+
+		var s scanner.Scanner
+		printed := token.NewFileSet().AddFile("", -1, buf.Len())
+		s.Init(printed, buf.Bytes(), nil, 0)
+		for {
+			_, tok, lit := s.Scan()
+			if tok == token.EOF {
+				break
+			}
+			switch {
+			case tok == token.IDENT || tok.IsLiteral():
+				tokens = append(tokens, lit)
+			default:
+				// Semicolons inserted at line ends are kept: they end statements
+				tokens = append(tokens, tok.String())
+			}
+		}
+	}
+	return tokens, nil
+}
+
+// canonicalLiteral is the value every literal of a kind is replaced with
+func canonicalLiteral(kind token.Token) string {
+	switch kind {
+	case token.STRING:
+		return `""`
+	case token.CHAR:
+		return "'x'"
+	case token.FLOAT:
+		return "0.0"
+	case token.IMAG:
+		return "0i"
+	}
+	return "0"
+}
+
+// Fingerprint is the set of k-gram hashes winnowing picked from a source
+type Fingerprint map[uint64]struct{}
+
+// Fingerprints winnows the k-grams of tokens. In each window of Window
+// consecutive k-gram hashes the smallest is picked, the rightmost on ties,
+// and each position is recorded once.
+func Fingerprints(tokens []string) Fingerprint {
+	fp := make(Fingerprint)
+	if len(tokens) == 0 {
+		return fp
+	}
+	if len(tokens) < K {
+		fp[hashGram(tokens)] = struct{}{}
+		return fp
+	}
+
+	hashes := make([]uint64, len(tokens)-K+1)
+	for i := range hashes {
+		hashes[i] = hashGram(tokens[i : i+K])
+	}
+	window := min(Window, len(hashes))
+	picked := -1
+	for start := 0; start+window <= len(hashes); start++ {
+		smallest := start + window - 1
+		for i := smallest - 1; i >= start; i-- {
+			if hashes[i] < hashes[smallest] {
+				smallest = i
+			}
+		}
+		if smallest != picked {
+			fp[hashes[smallest]] = struct{}{}
+			picked = smallest
+		}
+	}
+	return fp
+}
+
+func hashGram(gram []string) uint64 {
+	h := fnv.New64a()
+	for _, tok := range gram {
+		h.Write([]byte(tok))
+		h.Write([]byte{0})
+	}
+	return h.Sum64()
+}
+
+// Without returns the fingerprints of f that are not in base, such as those
+// of the template every solution starts from
+func (f Fingerprint) Without(base Fingerprint) Fingerprint {
+	rest := make(Fingerprint, len(f))
+	for h := range f {
+		if _, ok := base[h]; !ok {
+			rest[h] = struct{}{}
+		}
+	}
+	return rest
+}
+
+// Compare returns the Jaccard similarity of two fingerprints, from 0 for
+// nothing in common to 1 for the same set, and how many they share
+func Compare(a, b Fingerprint) (score float64, shared int) {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	for h := range a {
+		if _, ok := b[h]; ok {
+			shared++
+		}
+	}
+	union := len(a) + len(b) - shared
+	if union == 0 {
+		return 0, 0
+	}
+	return float64(shared) / float64(union), shared
+}
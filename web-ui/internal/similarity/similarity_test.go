@@ -0,0 +1,136 @@
+package similarity
+
+import (
+	"reflect"
+	"testing"
+)
+
+const original = `package main
+
+import "fmt"
+
+// Sum adds up the numbers
+func Sum(numbers []int) int {
+	total := 0
+	for _, n := range numbers {
+		total += n
+	}
+	return total
+}
+
+func main() {
+	fmt.Println(Sum([]int{1, 2, 3}), "done")
+}
+`
+
+// disguised is original renamed, recommented, reformatted and with other
+// literal values
+const disguised = `package solution
+
+import (
+	"fmt"
+)
+
+func Add(xs []int) int { acc := 10
+	for _, v := range xs {
+		acc += v // accumulate
+	}
+	return acc
+}
+
+/* entry point */
+func main() {
+	fmt.Println(Add([]int{4, 5, 6}), "finished")
+}
+`
+
+const different = `package main
+
+import "sort"
+
+func Median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	if len(sorted)%2 == 1 {
+		return sorted[len(sorted)/2]
+	}
+	return (sorted[len(sorted)/2-1] + sorted[len(sorted)/2]) / 2
+}
+`
+
+func mustFingerprint(t *testing.T, src string) Fingerprint {
+	t.Helper()
+	tokens, err := Tokens([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Fingerprints(tokens)
+}
+
+func TestTokens(t *testing.T) {
+	got, err := Tokens([]byte("package p\n\nimport \"os\"\n\n// c\nvar name = \"v\" + 'c' + 1.5\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"var", "x", "=", `""`, "+", "'x'", "+", "0.0", ";"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Tokens = %q, want %q", got, want)
+	}
+
+	a, _ := Tokens([]byte(original))
+	b, _ := Tokens([]byte(disguised))
+	if !reflect.DeepEqual(a, b) {
+		t.Errorf("disguised tokens differ:\n%q\n%q", a, b)
+	}
+	if _, err := Tokens([]byte("package p\nfunc {")); err == nil {
+		t.Error("Tokens of invalid source succeeded")
+	}
+}
+
+func TestCompare(t *testing.T) {
+	a := mustFingerprint(t, original)
+	if score, shared := Compare(a, mustFingerprint(t, disguised)); score != 1 || shared != len(a) {
+		t.Errorf("Compare with a disguised copy = %v, %d, want 1, %d", score, shared, len(a))
+	}
+	if score, _ := Compare(a, mustFingerprint(t, different)); score > 0.2 {
+		t.Errorf("Compare with a different solution = %v, want at most 0.2", score)
+	}
+	if score, shared := Compare(Fingerprint{}, Fingerprint{}); score != 0 || shared != 0 {
+		t.Errorf("Compare of empty fingerprints = %v, %d", score, shared)
+	}
+
+	// Copying a solution into a longer one still shares its fingerprints
+	extended := mustFingerprint(t, original+"\n"+different[len("package main\n\nimport \"sort\"\n"):])
+	if _, shared := Compare(a, extended); shared < len(a)*3/4 {
+		t.Errorf("extended copy shares %d of %d fingerprints", shared, len(a))
+	}
+}
+
+func TestFingerprintsWinnowing(t *testing.T) {
+	tokens := []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k", "l"}
+	fp := Fingerprints(tokens)
+	// Every window of Window k-grams contributes one of its hashes
+	grams := len(tokens) - K + 1
+	if len(fp) < (grams+Window-1)/Window || len(fp) > grams {
+		t.Errorf("%d fingerprints for %d k-grams", len(fp), grams)
+	}
+	for start := 0; start+Window <= grams; start++ {
+		found := false
+		for i := start; i < start+Window; i++ {
+			if _, ok := fp[hashGram(tokens[i:i+K])]; ok {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("window at %d has no fingerprint", start)
+		}
+	}
+
+	if got := Fingerprints([]string{"a", "b"}); len(got) != 1 {
+		t.Errorf("Fingerprints of a short source = %v, want one hash", got)
+	}
+	base := Fingerprints(tokens[:8])
+	if rest := fp.Without(base); len(rest) >= len(fp) {
+		t.Errorf("Without removed nothing: %d of %d left", len(rest), len(fp))
+	}
+}
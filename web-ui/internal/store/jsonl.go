@@ -0,0 +1,94 @@
+package store
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// DefaultMaxLine is the longest line Scan reads unless told otherwise
+const DefaultMaxLine = 1024 * 1024
+
+// OpenLog opens (or creates) the JSON-lines file at path for appending,
+// creating its directory if needed
+func OpenLog(path string) (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	// Terminate a line torn by a crash so the next record starts on its own line
+	if info, err := file.Stat(); err == nil && info.Size() > 0 {
+		last := make([]byte, 1)
+		if _, err := file.ReadAt(last, info.Size()-1); err == nil && last[0] != '\n' {
+			if _, err := file.Write([]byte{'\n'}); err != nil {
+				file.Close()
+				return nil, err
+			}
+		}
+	}
+	return file, nil
+}
+
+// Append writes v to a file opened by OpenLog as a single line
+func Append(file *os.File, v any) error {
+	line, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	// A single write per record keeps lines whole under O_APPEND
+	_, err = file.Write(append(line, '\n'))
+	return err
+}
+
+// AppendFile appends v to the JSON-lines file at path, for logs written too
+// rarely to keep open
+func AppendFile(path string, v any) error {
+	file, err := OpenLog(path)
+	if err != nil {
+		return err
+	}
+	if err := Append(file, v); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Scan decodes every complete record from r in write order, calling fn for
+// each. Lines longer than maxLine, or DefaultMaxLine when it is zero, fail
+// with bufio.ErrTooLong.
+func Scan[T any](r io.Reader, maxLine int, fn func(T)) error {
+	if maxLine <= 0 {
+		maxLine = DefaultMaxLine
+	}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, min(64*1024, maxLine)), maxLine)
+	for scanner.Scan() {
+		var record T
+		// Skip a torn final line left by a crash mid-write
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			continue
+		}
+		fn(record)
+	}
+	return scanner.Err()
+}
+
+// ScanFile is Scan over the file at path. A missing file holds no records.
+func ScanFile[T any](path string, maxLine int, fn func(T)) error {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return Scan(file, maxLine, fn)
+}
@@ -0,0 +1,23 @@
+// Package store holds the file handling shared by everything that persists
+// to disk: whole files replaced atomically, and append-only logs of JSON
+// lines that survive a crash mid-write.
+package store
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// WriteFile replaces the file at path with content atomically, creating its
+// directory if needed. Readers see either the old file or the new one, never
+// a partial write.
+func WriteFile(path string, content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, content, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
@@ -0,0 +1,80 @@
+package store
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type record struct {
+	N int    `json:"n"`
+	S string `json:"s,omitempty"`
+}
+
+func TestLogSurvivesTornLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "records.jsonl")
+
+	// A missing file holds no records
+	var got []record
+	collect := func(r record) { got = append(got, r) }
+	if err := ScanFile(path, 0, collect); err != nil || got != nil {
+		t.Fatalf("ScanFile on missing file = %v, %v", got, err)
+	}
+
+	if err := AppendFile(path, record{N: 1}); err != nil {
+		t.Fatalf("AppendFile: %v", err)
+	}
+	// Simulate a crash mid-write, then append again
+	f, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+	f.WriteString(`{"n":`)
+	f.Close()
+	file, err := OpenLog(path)
+	if err != nil {
+		t.Fatalf("OpenLog: %v", err)
+	}
+	if err := Append(file, record{N: 2, S: "two"}); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	file.Close()
+
+	if err := ScanFile(path, 0, collect); err != nil {
+		t.Fatalf("ScanFile: %v", err)
+	}
+	if want := []record{{N: 1}, {N: 2, S: "two"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("records = %v, want %v", got, want)
+	}
+}
+
+func TestScanLineLimit(t *testing.T) {
+	input := `{"n":1}` + "\n" + `{"n":2,"s":"` + strings.Repeat("x", 100) + `"}` + "\n"
+	var got []record
+	err := Scan(strings.NewReader(input), 64, func(r record) { got = append(got, r) })
+	if !errors.Is(err, bufio.ErrTooLong) {
+		t.Errorf("err = %v, want bufio.ErrTooLong", err)
+	}
+	if len(got) != 1 || got[0].N != 1 {
+		t.Errorf("records before the long line = %v", got)
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "state.json")
+	for _, content := range []string{"first", "second"} {
+		if err := WriteFile(path, []byte(content)); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+		if got, _ := os.ReadFile(path); string(got) != content {
+			t.Errorf("file holds %q, want %q", got, content)
+		}
+	}
+	if _, err := os.Stat(path + ".tmp"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("temporary file left behind: %v", err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("file mode = %v, %v", info.Mode(), err)
+	}
+}
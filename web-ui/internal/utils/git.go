@@ -13,27 +13,27 @@ type GitUserInfo struct {
 	Source   string // "git-config", "remote-origin", "not-found"
 }
 
-// GetGitUsername attempts to extract the GitHub username from git configuration
-func GetGitUsername() *GitUserInfo {
+// GetGitUsername attempts to extract the GitHub username from the git configuration of repoDir
+func GetGitUsername(repoDir string) *GitUserInfo {
 	info := &GitUserInfo{
 		Source: "not-found",
 	}
 
 	// Try to get from git remote origin URL first (most reliable for GitHub username)
-	if username := getGitUsernameFromRemote(); username != "" {
+	if username := getGitUsernameFromRemote(repoDir); username != "" {
 		info.Username = username
 		info.Source = "remote-origin"
 		return info
 	}
 
 	// Fallback to git config user.name
-	if username := getGitConfigValue("user.name"); username != "" {
+	if username := getGitConfigValue(repoDir, "user.name"); username != "" {
 		info.Username = username
 		info.Source = "git-config"
 	}
 
 	// Also get email for reference
-	if email := getGitConfigValue("user.email"); email != "" {
+	if email := getGitConfigValue(repoDir, "user.email"); email != "" {
 		info.Email = email
 		// If we got username from config but not remote, try to extract from email
 		if info.Username == "" && strings.Contains(email, "@") {
@@ -47,8 +47,9 @@ func GetGitUsername() *GitUserInfo {
 }
 
 // getGitUsernameFromRemote extracts username from git remote origin URL
-func getGitUsernameFromRemote() string {
+func getGitUsernameFromRemote(repoDir string) string {
 	cmd := exec.Command("git", "remote", "get-url", "origin")
+	cmd.Dir = repoDir
 	output, err := cmd.Output()
 	if err != nil {
 		return ""
@@ -72,8 +73,9 @@ func getGitUsernameFromRemote() string {
 }
 
 // getGitConfigValue gets a value from git config
-func getGitConfigValue(key string) string {
+func getGitConfigValue(repoDir, key string) string {
 	cmd := exec.Command("git", "config", "--get", key)
+	cmd.Dir = repoDir
 	output, err := cmd.Output()
 	if err != nil {
 		return ""
@@ -81,9 +83,10 @@ func getGitConfigValue(key string) string {
 	return strings.TrimSpace(string(output))
 }
 
-// IsGitRepository checks if the current directory is a git repository
-func IsGitRepository() bool {
+// IsGitRepository checks if dir is inside a git repository
+func IsGitRepository(dir string) bool {
 	cmd := exec.Command("git", "status")
+	cmd.Dir = dir
 	err := cmd.Run()
 	return err == nil
 }
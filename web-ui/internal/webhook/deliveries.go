@@ -0,0 +1,161 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"web-ui/internal/store"
+)
+
+// Delivery outcomes
+const (
+	OutcomeDelivered = "delivered" // the receiver answered 2xx
+	OutcomeRetrying  = "retrying"  // the attempt failed and will be retried
+	OutcomeFailed    = "failed"    // the last attempt failed, or the failure was not worth retrying
+	OutcomeDropped   = "dropped"   // never sent: the subscription's queue was full or the dispatcher stopped
+)
+
+// Query limits
+const (
+	DefaultLimit = 100
+	MaxLimit     = 1000
+)
+
+// Delivery is one attempt to deliver an event to a subscription
+type Delivery struct {
+	Time         time.Time `json:"time"`
+	Subscription string    `json:"subscription"`
+	EventID      string    `json:"eventId"`
+	EventType    string    `json:"eventType"`
+	URL          string    `json:"url"`
+	Attempt      int       `json:"attempt,omitempty" doc:"Numbered from 1; 0 for events that were never sent"`
+	StatusCode   int       `json:"statusCode,omitempty" doc:"The receiver's response status, when it answered"`
+	Outcome      string    `json:"outcome" enum:"delivered,retrying,failed,dropped"`
+	Error        string    `json:"error,omitempty"`
+	DurationMs   int64     `json:"durationMs,omitempty"`
+}
+
+// DeliveryQuery filters deliveries; zero values match everything
+type DeliveryQuery struct {
+	Subscription string
+	EventType    string
+	EventID      string
+	Outcome      string
+	Limit        int
+}
+
+func (q DeliveryQuery) matches(d Delivery) bool {
+	switch {
+	case q.Subscription != "" && d.Subscription != q.Subscription,
+		q.EventType != "" && d.EventType != q.EventType,
+		q.EventID != "" && d.EventID != q.EventID,
+		q.Outcome != "" && d.Outcome != q.Outcome:
+		return false
+	}
+	return true
+}
+
+// DeliveryLog appends delivery attempts as JSON lines to a file, or keeps
+// them in memory when no path is given
+type DeliveryLog struct {
+	path       string
+	mu         sync.Mutex
+	file       *os.File
+	deliveries []Delivery
+}
+
+// OpenDeliveryLog opens (or creates) the delivery log at path for appending.
+// An empty path keeps deliveries in memory only.
+func OpenDeliveryLog(path string) (*DeliveryLog, error) {
+	l := &DeliveryLog{path: path}
+	if path == "" {
+		return l, nil
+	}
+	file, err := store.OpenLog(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open webhook log: %v", err)
+	}
+	l.file = file
+	return l, nil
+}
+
+// Record appends a delivery, stamping it with the current time if unset
+func (l *DeliveryLog) Record(d Delivery) error {
+	if d.Time.IsZero() {
+		d.Time = time.Now().UTC()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.path == "" {
+		l.deliveries = append(l.deliveries, d)
+		return nil
+	}
+	if l.file == nil {
+		return errors.New("webhook log is closed")
+	}
+	if err := store.Append(l.file, d); err != nil {
+		return fmt.Errorf("failed to write webhook delivery: %v", err)
+	}
+	return nil
+}
+
+// Query returns matching deliveries, newest first
+func (l *DeliveryLog) Query(q DeliveryQuery) ([]Delivery, error) {
+	if q.Limit <= 0 {
+		q.Limit = DefaultLimit
+	}
+	if q.Limit > MaxLimit {
+		q.Limit = MaxLimit
+	}
+
+	var matched []Delivery
+	collect := func(d Delivery) {
+		if q.matches(d) {
+			matched = append(matched, d)
+		}
+	}
+
+	if l.path == "" {
+		l.mu.Lock()
+		for _, d := range l.deliveries {
+			collect(d)
+		}
+		l.mu.Unlock()
+	} else if err := l.scanFile(collect); err != nil {
+		return nil, err
+	}
+
+	if len(matched) > q.Limit {
+		matched = matched[len(matched)-q.Limit:]
+	}
+	for i, j := 0, len(matched)-1; i < j; i, j = i+1, j-1 {
+		matched[i], matched[j] = matched[j], matched[i]
+	}
+	return matched, nil
+}
+
+// scanFile reads every complete delivery in the log file in write order. A
+// line longer than store.DefaultMaxLine is an error, not the end of the log.
+func (l *DeliveryLog) scanFile(fn func(Delivery)) error {
+	if err := store.ScanFile(l.path, 0, fn); err != nil {
+		return fmt.Errorf("failed to read webhook log: %w", err)
+	}
+	return nil
+}
+
+// Close closes the underlying file
+func (l *DeliveryLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
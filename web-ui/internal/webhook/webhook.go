@@ -0,0 +1,366 @@
+// Package webhook posts server events as signed JSON to the URLs of
+// configured subscriptions, retrying failed deliveries with exponential
+// backoff and logging every attempt.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"web-ui/internal/events"
+)
+
+// Headers of every delivery
+const (
+	SignatureHeader = "X-Webhook-Signature" // "sha256=" and the hex HMAC of "<timestamp>.<body>"
+	TimestampHeader = "X-Webhook-Timestamp" // Unix seconds when the attempt was sent
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery" // the event ID, the same on every attempt
+)
+
+// Defaults for Options left zero
+const (
+	DefaultMaxAttempts = 5
+	DefaultTimeout     = 10 * time.Second
+	DefaultBackoff     = time.Second
+	DefaultQueueSize   = 256
+	maxBackoff         = 10 * time.Minute
+)
+
+var (
+	// ErrUnknownSubscription is returned by Ping for a name no subscription has
+	ErrUnknownSubscription = errors.New("unknown webhook subscription")
+	// ErrClosed is returned by Ping once the dispatcher is closing
+	ErrClosed = errors.New("webhook dispatcher is closed")
+)
+
+// Subscription sends the events of the listed types to a URL. No events
+// means every type.
+type Subscription struct {
+	Name   string   `json:"name"`
+	URL    string   `json:"url"`
+	Secret string   `json:"secret"`
+	Events []string `json:"events"`
+}
+
+// Wants reports whether the subscription receives events of eventType
+func (s Subscription) Wants(eventType string) bool {
+	if len(s.Events) == 0 {
+		return true
+	}
+	for _, t := range s.Events {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Info describes a subscription without its secret
+type Info struct {
+	Name   string   `json:"name"`
+	URL    string   `json:"url"`
+	Events []string `json:"events" doc:"Subscribed event types; empty for all"`
+	Queued int      `json:"queued" doc:"Events waiting to be delivered"`
+}
+
+// Options tune deliveries
+type Options struct {
+	MaxAttempts int           // attempts per event, including the first
+	Timeout     time.Duration // per attempt
+	Backoff     time.Duration // wait before the first retry; doubles on each one
+	QueueSize   int           // events buffered per subscription before new ones are dropped
+	Client      *http.Client
+}
+
+// Dispatcher delivers events to subscriptions. Each subscription has its own
+// queue and worker, so a slow receiver delays only its own events, which
+// arrive in the order they were published.
+type Dispatcher struct {
+	opts    Options
+	log     *DeliveryLog
+	workers []*worker
+
+	mu      sync.RWMutex
+	closing bool
+	// stopped is cancelled when Close gives up waiting: attempts in flight
+	// are cut short, retries abandoned and queued events dropped
+	stopped context.Context
+	stop    context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+type worker struct {
+	sub   Subscription
+	queue chan events.Event
+}
+
+// New validates the subscriptions and starts a worker for each. Attempts are
+// recorded in log.
+func New(subs []Subscription, log *DeliveryLog, opts Options) (*Dispatcher, error) {
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = DefaultMaxAttempts
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.Backoff <= 0 {
+		opts.Backoff = DefaultBackoff
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = DefaultQueueSize
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{}
+	}
+
+	d := &Dispatcher{opts: opts, log: log}
+	d.stopped, d.stop = context.WithCancel(context.Background())
+	names := make(map[string]bool)
+	for _, sub := range subs {
+		if err := validate(sub); err != nil {
+			return nil, err
+		}
+		if names[sub.Name] {
+			return nil, fmt.Errorf("webhook %q is configured twice", sub.Name)
+		}
+		names[sub.Name] = true
+		d.workers = append(d.workers, &worker{sub: sub, queue: make(chan events.Event, opts.QueueSize)})
+	}
+	for _, w := range d.workers {
+		d.wg.Add(1)
+		go d.run(w)
+	}
+	return d, nil
+}
+
+func validate(sub Subscription) error {
+	if sub.Name == "" {
+		return errors.New("webhooks need a name")
+	}
+	u, err := url.Parse(sub.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("webhook %q: URL must be an absolute http or https URL", sub.Name)
+	}
+	if sub.Secret == "" {
+		return fmt.Errorf("webhook %q: a secret is required to sign payloads", sub.Name)
+	}
+	for _, t := range sub.Events {
+		if !events.Known(t) {
+			return fmt.Errorf("webhook %q: unknown event type %q (known: %s)", sub.Name, t, strings.Join(events.Types, ", "))
+		}
+	}
+	return nil
+}
+
+// Handle queues an event for every subscription that wants it. It never
+// blocks: when a queue is full the event is dropped for that subscription
+// and the drop is logged.
+func (d *Dispatcher) Handle(e events.Event) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.closing {
+		return
+	}
+	for _, w := range d.workers {
+		if w.sub.Wants(e.Type) {
+			d.enqueue(w, e)
+		}
+	}
+}
+
+// Ping queues a ping event for the named subscription and returns it
+func (d *Dispatcher) Ping(name string) (events.Event, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.closing {
+		return events.Event{}, ErrClosed
+	}
+	for _, w := range d.workers {
+		if w.sub.Name == name {
+			e := events.New(events.Ping, map[string]string{"subscription": name})
+			d.enqueue(w, e)
+			return e, nil
+		}
+	}
+	return events.Event{}, ErrUnknownSubscription
+}
+
+// enqueue adds e to w's queue. Callers must hold d.mu for reading.
+func (d *Dispatcher) enqueue(w *worker, e events.Event) {
+	select {
+	case w.queue <- e:
+	default:
+		slog.Warn("webhook queue full, dropping event", "webhook", w.sub.Name, "event", e.Type, "id", e.ID)
+		d.record(Delivery{Subscription: w.sub.Name, EventID: e.ID, EventType: e.Type, URL: w.sub.URL, Outcome: OutcomeDropped, Error: "queue full"})
+	}
+}
+
+// Subscriptions describes the configured subscriptions in configuration order
+func (d *Dispatcher) Subscriptions() []Info {
+	infos := make([]Info, 0, len(d.workers))
+	for _, w := range d.workers {
+		eventTypes := append([]string{}, w.sub.Events...)
+		infos = append(infos, Info{Name: w.sub.Name, URL: w.sub.URL, Events: eventTypes, Queued: len(w.queue)})
+	}
+	return infos
+}
+
+// Close stops accepting events and waits for the queued ones to be
+// delivered. When ctx ends first, attempts in flight are cancelled, pending
+// retries abandoned and the events still queued logged as dropped.
+func (d *Dispatcher) Close(ctx context.Context) error {
+	d.mu.Lock()
+	if d.closing {
+		d.mu.Unlock()
+		return nil
+	}
+	d.closing = true
+	for _, w := range d.workers {
+		close(w.queue)
+	}
+	d.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		d.stop()
+		return nil
+	case <-ctx.Done():
+		d.stop()
+		<-done
+		return ctx.Err()
+	}
+}
+
+func (d *Dispatcher) run(w *worker) {
+	defer d.wg.Done()
+	for e := range w.queue {
+		if d.stopped.Err() != nil {
+			d.record(Delivery{Subscription: w.sub.Name, EventID: e.ID, EventType: e.Type, URL: w.sub.URL, Outcome: OutcomeDropped, Error: "dispatcher stopped"})
+			continue
+		}
+		d.deliver(w.sub, e)
+	}
+}
+
+// deliver posts e until the receiver accepts it, the attempts run out or the
+// dispatcher is stopped
+func (d *Dispatcher) deliver(sub Subscription, e events.Event) {
+	body, err := json.Marshal(e)
+	if err != nil {
+		d.record(Delivery{Subscription: sub.Name, EventID: e.ID, EventType: e.Type, URL: sub.URL, Outcome: OutcomeFailed, Error: err.Error()})
+		return
+	}
+
+	backoff := d.opts.Backoff
+	for attempt := 1; ; attempt++ {
+		if d.stopped.Err() != nil {
+			return
+		}
+		delivery := d.attempt(sub, e, body)
+		delivery.Attempt = attempt
+		retry := delivery.Outcome == OutcomeRetrying && attempt < d.opts.MaxAttempts
+		if delivery.Outcome == OutcomeRetrying && !retry {
+			delivery.Outcome = OutcomeFailed
+		}
+		d.record(delivery)
+		if !retry {
+			return
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-d.stopped.Done():
+			return
+		}
+		backoff = min(2*backoff, maxBackoff)
+	}
+}
+
+// attempt posts body once. Network errors, timeouts, 408, 429 and 5xx
+// responses are worth retrying; other non-2xx responses are not.
+func (d *Dispatcher) attempt(sub Subscription, e events.Event, body []byte) Delivery {
+	delivery := Delivery{Subscription: sub.Name, EventID: e.ID, EventType: e.Type, URL: sub.URL}
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(d.stopped, d.opts.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		delivery.Outcome, delivery.Error = OutcomeFailed, err.Error()
+		return delivery
+	}
+	timestamp := start.Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "go-interview-practice-webhooks")
+	req.Header.Set(EventHeader, e.Type)
+	req.Header.Set(DeliveryHeader, e.ID)
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, Sign(sub.Secret, timestamp, body))
+
+	resp, err := d.opts.Client.Do(req)
+	delivery.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		delivery.Outcome, delivery.Error = OutcomeRetrying, err.Error()
+		return delivery
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+
+	delivery.StatusCode = resp.StatusCode
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		delivery.Outcome = OutcomeDelivered
+	case resp.StatusCode == http.StatusRequestTimeout, resp.StatusCode == http.StatusTooManyRequests, resp.StatusCode >= 500:
+		delivery.Outcome, delivery.Error = OutcomeRetrying, resp.Status
+	default:
+		delivery.Outcome, delivery.Error = OutcomeFailed, resp.Status
+	}
+	return delivery
+}
+
+// record logs a delivery attempt; a log that cannot be written only warns,
+// since the attempt itself has happened
+func (d *Dispatcher) record(delivery Delivery) {
+	if err := d.log.Record(delivery); err != nil {
+		slog.Error("failed to record webhook delivery", "webhook", delivery.Subscription, "err", err)
+	}
+}
+
+// Sign returns the signature header value for a body sent at timestamp
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte{'.'})
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a delivery's signature in constant time. Receivers should
+// also reject timestamps too far from their own clock to stop replays.
+func Verify(secret, signature, timestamp string, body []byte) bool {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(Sign(secret, ts, body)))
+}
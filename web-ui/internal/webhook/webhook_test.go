@@ -0,0 +1,189 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"web-ui/internal/events"
+)
+
+// receiver is a local webhook endpoint that answers with the queued statuses,
+// then 200, and keeps every request it accepted
+type receiver struct {
+	*httptest.Server
+	mu       sync.Mutex
+	statuses []int
+	received []events.Event
+}
+
+func newReceiver(t *testing.T, secret string, statuses ...int) *receiver {
+	rc := &receiver{statuses: statuses}
+	rc.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !Verify(secret, r.Header.Get(SignatureHeader), r.Header.Get(TimestampHeader), body) {
+			t.Errorf("delivery with a bad signature %q", r.Header.Get(SignatureHeader))
+		}
+		var e events.Event
+		if err := json.Unmarshal(body, &e); err != nil || e.Type != r.Header.Get(EventHeader) || e.ID != r.Header.Get(DeliveryHeader) {
+			t.Errorf("delivery %s does not match its headers: %v", body, err)
+		}
+
+		rc.mu.Lock()
+		status := http.StatusOK
+		if len(rc.statuses) > 0 {
+			status, rc.statuses = rc.statuses[0], rc.statuses[1:]
+		}
+		if status == http.StatusOK {
+			rc.received = append(rc.received, e)
+		}
+		rc.mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(rc.Close)
+	return rc
+}
+
+func TestDispatcher(t *testing.T) {
+	log, err := OpenDeliveryLog(filepath.Join(t.TempDir(), "webhooks.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer log.Close()
+
+	flaky := newReceiver(t, "s3cret", http.StatusBadGateway, http.StatusTooManyRequests)
+	rejecting := newReceiver(t, "other", http.StatusBadRequest)
+	d, err := New([]Subscription{
+		{Name: "chat", URL: flaky.URL, Secret: "s3cret", Events: []string{events.SubmissionPassed, events.RankChanged}},
+		{Name: "audit", URL: rejecting.URL, Secret: "other"},
+	}, log, Options{Backoff: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bus := events.NewBus()
+	bus.Subscribe(d.Handle)
+	passed := bus.Publish(events.SubmissionPassed, events.Submission{Username: "alice", ChallengeID: 1})
+	bus.Publish(events.SubmissionFailed, events.Submission{Username: "bob", ChallengeID: 1})
+	if err := d.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// The flaky receiver gets the passed submission on the third attempt;
+	// the first event the other receiver rejects is lost, since 400 is not
+	// retried
+	if len(flaky.received) != 1 || flaky.received[0].ID != passed.ID {
+		t.Errorf("chat received %+v, want only the passed submission", flaky.received)
+	}
+	chat, err := log.Query(DeliveryQuery{Subscription: "chat"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chat) != 3 || chat[0].Outcome != OutcomeDelivered || chat[0].Attempt != 3 || chat[2].StatusCode != http.StatusBadGateway || chat[2].Outcome != OutcomeRetrying {
+		t.Errorf("chat deliveries = %+v, want two retries then a delivery", chat)
+	}
+	failed, err := log.Query(DeliveryQuery{Subscription: "audit", Outcome: OutcomeFailed})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(failed) != 1 || failed[0].EventType != events.SubmissionPassed || failed[0].Attempt != 1 {
+		t.Errorf("audit failures = %+v, want one unretried 400", failed)
+	}
+
+	if _, err := d.Ping("chat"); err != ErrClosed {
+		t.Errorf("Ping after Close = %v, want ErrClosed", err)
+	}
+}
+
+func TestDispatcherGivesUp(t *testing.T) {
+	log, _ := OpenDeliveryLog("")
+	down := newReceiver(t, "s", http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+	d, err := New([]Subscription{{Name: "down", URL: down.URL, Secret: "s"}}, log, Options{MaxAttempts: 2, Backoff: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ping, err := d.Ping("down")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.Ping("nope"); err != ErrUnknownSubscription {
+		t.Errorf("Ping of an unknown subscription = %v", err)
+	}
+	d.Close(context.Background())
+
+	deliveries, _ := log.Query(DeliveryQuery{EventID: ping.ID})
+	if len(deliveries) != 2 || deliveries[0].Outcome != OutcomeFailed || deliveries[1].Outcome != OutcomeRetrying {
+		t.Errorf("deliveries = %+v, want a retry then a failure", deliveries)
+	}
+}
+
+func TestCloseGivesUp(t *testing.T) {
+	log, _ := OpenDeliveryLog("")
+	release := make(chan struct{})
+	hung := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer hung.Close()
+	defer close(release)
+
+	d, err := New([]Subscription{{Name: "hung", URL: hung.URL, Secret: "s"}}, log, Options{Timeout: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		d.Handle(events.New(events.Ping, nil))
+	}
+
+	// Shutdown must not wait out an hour-long attempt for each queued event
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := d.Close(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Close = %v, want DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Close took %v", elapsed)
+	}
+
+	// The attempt in flight is cut short and the rest are dropped unsent
+	deliveries, _ := log.Query(DeliveryQuery{})
+	dropped, _ := log.Query(DeliveryQuery{Outcome: OutcomeDropped})
+	if len(deliveries) != 5 || len(dropped) != 4 {
+		t.Errorf("deliveries = %+v, want one cancelled attempt and 4 dropped", deliveries)
+	}
+}
+
+func TestNewValidatesSubscriptions(t *testing.T) {
+	log, _ := OpenDeliveryLog("")
+	for name, subs := range map[string][]Subscription{
+		"no name":        {{URL: "http://example.com", Secret: "s"}},
+		"relative URL":   {{Name: "a", URL: "/hook", Secret: "s"}},
+		"no secret":      {{Name: "a", URL: "http://example.com"}},
+		"unknown event":  {{Name: "a", URL: "http://example.com", Secret: "s", Events: []string{"submission.deleted"}}},
+		"duplicate name": {{Name: "a", URL: "http://example.com", Secret: "s"}, {Name: "a", URL: "https://example.org", Secret: "s"}},
+	} {
+		if _, err := New(subs, log, Options{}); err == nil {
+			t.Errorf("%s: New succeeded", name)
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"id":"1"}`)
+	signature := Sign("secret", 1700000000, body)
+	if !Verify("secret", signature, "1700000000", body) {
+		t.Error("Verify rejected a valid signature")
+	}
+	if Verify("secret", signature, "1700000001", body) || Verify("wrong", signature, "1700000000", body) || Verify("secret", signature, "1700000000", []byte(`{"id":"2"}`)) {
+		t.Error("Verify accepted a signature for a different timestamp, secret or body")
+	}
+}
@@ -1,50 +1,338 @@
 package main
 
 import (
+	"context"
 	"embed"
+	"errors"
+	"flag"
 	"fmt"
-	"log"
-	"net/http"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"web-ui/internal/audit"
+	"web-ui/internal/auth"
+	"web-ui/internal/collab"
+	"web-ui/internal/config"
+	"web-ui/internal/events"
+	"web-ui/internal/logging"
+	"web-ui/internal/models"
+	"web-ui/internal/paths"
 	"web-ui/internal/server"
 	"web-ui/internal/services"
+	"web-ui/internal/webhook"
 )
 
 //go:embed templates static
 var content embed.FS
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "similarity" {
+		os.Exit(runSimilarity(os.Args[2:], os.Stdout, os.Stderr, os.Getenv))
+	}
+
+	// Load configuration from flags, environment and optional config file
+	cfg, err := config.Load(os.Args[1:], os.Getenv)
+	if errors.Is(err, flag.ErrHelp) {
+		fmt.Fprintf(os.Stderr, "Usage of %s:\n%s\nCommands:\n  similarity\treport similar solutions (see %[1]s similarity -h)\n", os.Args[0], config.Usage())
+		return
+	}
+	if err != nil {
+		fatal("failed to load configuration", err)
+	}
+
+	// Route all logging, including the standard log package, through slog
+	logger, err := logging.New(os.Stderr, cfg.Logging.Format, cfg.Logging.Level)
+	if err != nil {
+		fatal("failed to configure logging", err)
+	}
+	slog.SetDefault(logger)
+
+	// Resolve the repository root that all challenge paths live under
+	if cfg.RepoRoot == "" {
+		cfg.RepoRoot, err = paths.DetectRoot()
+		if err != nil {
+			fatal("failed to locate repository root (set -root or WEBUI_REPO_ROOT)", err)
+		}
+	}
+	resolver, err := paths.NewResolver(cfg.RepoRoot)
+	if err != nil {
+		fatal("failed to locate repository root", err)
+	}
+	slog.Info("using repository root", "path", resolver.Root())
+
 	// Initialize services
-	challengeService := services.NewChallengeService()
-	scoreboardService := services.NewScoreboardService()
-	userService := services.NewUserService()
-	executionService := services.NewExecutionService()
+	challengeService := services.NewChallengeService(resolver)
+	scoreboardService := services.NewScoreboardService(resolver)
+	historyService := services.NewHistoryService(resolver)
+	scoreboardService.UseHistory(historyService)
+	userService := services.NewUserService(resolver)
+	executionService := services.NewExecutionService(resolver, services.ExecutionLimits{
+		Timeout:        time.Duration(cfg.Execution.Timeout),
+		MaxConcurrent:  cfg.Execution.MaxConcurrent,
+		MaxOutputBytes: cfg.Execution.MaxOutputBytes,
+	})
 
 	// Load data
-	log.Println("Loading challenges...")
+	slog.Info("loading challenges")
 	if err := challengeService.LoadChallenges(); err != nil {
-		log.Fatalf("Failed to load challenges: %v", err)
+		fatal("failed to load challenges", err)
+	}
+
+	// Solve dates and streaks come from git history; without it they fall
+	// back to submission file times
+	slog.Info("loading submission history")
+	if err := historyService.Load(context.Background()); err != nil {
+		slog.Warn("submission history unavailable", "err", err)
 	}
 
-	log.Println("Loading scoreboards...")
+	slog.Info("loading scoreboards")
 	if err := scoreboardService.LoadScoreboards(challengeService.GetChallenges()); err != nil {
-		log.Fatalf("Failed to load scoreboards: %v", err)
+		fatal("failed to load scoreboards", err)
+	}
+
+	scorer, err := services.NewScorer(cfg.Scoring.Model, services.ScoringRules{
+		DifficultyPoints: cfg.Scoring.DifficultyPoints,
+		PartialCredit:    cfg.Scoring.PartialCredit,
+		FirstSolverBonus: cfg.Scoring.FirstSolverBonus,
+		HalfLife:         time.Duration(cfg.Scoring.HalfLife),
+	})
+	if err != nil {
+		fatal("invalid scoring configuration", err)
+	}
+	var tiers []services.Tier
+	for _, tier := range cfg.Scoring.Tiers {
+		tiers = append(tiers, services.Tier{Name: tier.Name, MinSolved: tier.MinSolved})
+	}
+	leaderboardService := services.NewLeaderboardService(challengeService, scoreboardService, scorer, tiers)
+
+	// Teams come from the repository's teams.json and the admin API's store
+	teamService, err := services.NewTeamService(resolver, cfg.DataPath("teams.json"))
+	if err != nil {
+		fatal("failed to load teams", err)
+	}
+
+	// Contests are managed through the admin API; their attempts are appended
+	// to a log next to the audit log
+	contestService, err := services.NewContestService(challengeService, cfg.DataPath("contests.json"), cfg.DataPath("contest-attempts.jsonl"))
+	if err != nil {
+		fatal("failed to load contests", err)
+	}
+	defer contestService.Close()
+
+	// Mock interviews and their recordings are kept one file per interview
+	interviewService, err := services.NewInterviewService(challengeService, cfg.DataPath("interviews"))
+	if err != nil {
+		fatal("failed to load interviews", err)
+	}
+
+	// Pair-programming rooms live in memory only
+	pairHub := collab.NewHub(collab.DefaultRoomTTL)
+
+	// Draft versions are appended to one log per user and challenge
+	draftService := services.NewDraftService(challengeService, cfg.DataPath("drafts"))
+
+	// Benchmark results of gallery solutions are kept between restarts
+	solutionService, err := services.NewSolutionService(resolver, scoreboardService, executionService, cfg.DataPath("benchmarks.json"))
+	if err != nil {
+		fatal("failed to load benchmark results", err)
+	}
+
+	// Reviews of saved solutions and their notifications
+	reviewService, err := services.NewReviewService(draftService, teamService, cfg.DataPath("reviews"))
+	if err != nil {
+		fatal("failed to load reviews", err)
+	}
+
+	// Copied solutions are flagged by the similarity of their fingerprints
+	similarityService := services.NewSimilarityService(resolver, challengeService, cfg.Similarity.Threshold)
+
+	// Initialize authentication
+	sessions, err := auth.NewSessionManager([]byte(cfg.Auth.SessionSecret), cfg.Auth.SecureCookies || cfg.TLSEnabled())
+	if err != nil {
+		fatal("failed to initialize sessions", err)
+	}
+	if cfg.Auth.SessionSecret == "" {
+		slog.Warn("no session secret configured; sessions will not survive a restart")
+	}
+
+	authProviders, err := loadAuthProviders(cfg, resolver)
+	if err != nil {
+		fatal("failed to initialize auth providers", err)
+	}
+
+	// Open the append-only audit log
+	auditLog, err := audit.Open(cfg.DataPath("audit.jsonl"))
+	if err != nil {
+		fatal("failed to open audit log", err)
+	}
+	defer auditLog.Close()
+
+	// Events are published on a bus that feeds the outgoing webhooks, whose
+	// delivery attempts are logged next to the audit log
+	deliveries, err := webhook.OpenDeliveryLog(cfg.DataPath("webhook-deliveries.jsonl"))
+	if err != nil {
+		fatal("failed to open webhook delivery log", err)
+	}
+	defer deliveries.Close()
+	webhooks, err := newWebhooks(cfg, deliveries)
+	if err != nil {
+		fatal("invalid webhook configuration", err)
+	}
+	defer closeWebhooks(webhooks)
+	bus := events.NewBus()
+	bus.Subscribe(webhooks.Handle)
+
+	// Challenges added to the repository since the last run are announced
+	added, err := services.NewChallenges(challengeService.GetChallenges(), cfg.DataPath("known-challenges.json"))
+	if err != nil {
+		slog.Warn("could not check for new challenges", "err", err)
+	}
+	for _, challenge := range added {
+		bus.Publish(events.ChallengeAdded, challenge)
 	}
 
 	// Initialize server
 	srv := server.NewServer(
 		content,
+		cfg,
+		resolver,
 		challengeService,
 		scoreboardService,
+		historyService,
+		leaderboardService,
+		teamService,
+		contestService,
+		interviewService,
+		pairHub,
+		draftService,
+		solutionService,
+		reviewService,
+		similarityService,
 		userService,
 		executionService,
+		sessions,
+		authProviders,
+		auditLog,
+		bus,
+		webhooks,
+		deliveries,
 	)
 
-	// Setup routes
-	mux := srv.SetupRoutes()
+	// Start server and shut down gracefully on SIGINT/SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Standings change as scoreboards are updated, so rank changes are found
+	// by watching the leaderboard rather than on submission
+	if len(cfg.Webhooks.Subscriptions) > 0 {
+		go leaderboardService.WatchRanks(ctx, time.Duration(cfg.Webhooks.RankInterval), func(change events.RankChange) {
+			bus.Publish(events.RankChanged, change)
+		})
+	}
+
+	// Results held back by a contest's freeze are revealed when it ends
+	go contestService.WatchReleases(ctx, time.Minute, func(submission models.Submission) {
+		challenge, ok := challengeService.GetChallenge(submission.ChallengeID)
+		if !ok {
+			return
+		}
+		if submission.Passed {
+			scoreboardService.AddSubmission(submission)
+		}
+		bus.Publish(services.SubmissionEvent(submission, challenge))
+	})
+
+	if err := srv.Run(ctx); err != nil {
+		slog.Error("server error", "err", err)
+		closeWebhooks(webhooks)
+		deliveries.Close()
+		auditLog.Close()
+		contestService.Close()
+		os.Exit(1)
+	}
+}
+
+// fatal logs err and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, "err", err)
+	os.Exit(1)
+}
+
+// newWebhooks starts delivering to the configured webhook subscriptions
+func newWebhooks(cfg *config.Config, deliveries *webhook.DeliveryLog) (*webhook.Dispatcher, error) {
+	var subs []webhook.Subscription
+	for _, hook := range cfg.Webhooks.Subscriptions {
+		subs = append(subs, webhook.Subscription{Name: hook.Name, URL: hook.URL, Secret: hook.Secret, Events: hook.Events})
+	}
+	webhooks, err := webhook.New(subs, deliveries, webhook.Options{
+		MaxAttempts: cfg.Webhooks.MaxAttempts,
+		Timeout:     time.Duration(cfg.Webhooks.Timeout),
+		Backoff:     time.Duration(cfg.Webhooks.Backoff),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(subs) > 0 {
+		slog.Info("webhooks enabled", "subscriptions", len(subs))
+	}
+	return webhooks, nil
+}
+
+// closeWebhooks gives queued webhook deliveries a few seconds to finish
+func closeWebhooks(webhooks *webhook.Dispatcher) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := webhooks.Close(ctx); err != nil {
+		slog.Warn("abandoned pending webhook deliveries", "err", err)
+	}
+}
+
+// loadAuthProviders configures the local password provider and, when an
+// issuer is configured, a generic OIDC provider
+func loadAuthProviders(cfg *config.Config, resolver *paths.Resolver) (*auth.Registry, error) {
+	registry := auth.NewRegistry()
+
+	var local *auth.LocalProvider
+	if cfg.Features.PasswordAuth {
+		var err error
+		local, err = auth.NewLocalProvider(cfg.DataPath("accounts.json"))
+		if err != nil {
+			return nil, err
+		}
+		// A password account must not take over a contributor's existing
+		// submissions; contributors sign in through OIDC instead
+		local.Reserve(resolver.HasSubmissions)
+		registry.Register(local)
+	}
+
+	if oidcCfg := cfg.Auth.OIDC; oidcCfg.Issuer != "" {
+		// OIDC users and local accounts never share a name, and with it a
+		// submission directory
+		var usernameTaken func(string) bool
+		if local != nil {
+			usernameTaken = local.Exists
+		}
+		oidc, err := auth.NewOIDCProvider(context.Background(), auth.OIDCConfig{
+			Name:          oidcCfg.Name,
+			IssuerURL:     oidcCfg.Issuer,
+			ClientID:      oidcCfg.ClientID,
+			ClientSecret:  oidcCfg.ClientSecret,
+			RedirectURL:   oidcCfg.RedirectURL,
+			LinksPath:     cfg.DataPath("oidc-links.json"),
+			UsernameTaken: usernameTaken,
+		}, nil)
+		if err != nil {
+			return nil, err
+		}
+		if local != nil {
+			local.Reserve(oidc.Linked)
+		}
+		registry.Register(oidc)
+		slog.Info("OIDC sign-in enabled", "issuer", oidcCfg.Issuer)
+	}
 
-	// Start server
-	port := 8080
-	log.Printf("Server starting on http://localhost:%d", port)
-	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", port), mux))
+	return registry, nil
 }
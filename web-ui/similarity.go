@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+
+	"web-ui/internal/config"
+	"web-ui/internal/logging"
+	"web-ui/internal/paths"
+	"web-ui/internal/services"
+)
+
+// Exit codes of the similarity subcommand, so CI can fail on flagged pairs
+const (
+	exitClean   = 0
+	exitFlagged = 1
+	exitError   = 2
+)
+
+// runSimilarity implements "web-ui similarity [flags] [challenge-id...]",
+// reporting how similar the solutions to each challenge are. The repository
+// root and threshold default to the server's configuration.
+func runSimilarity(args []string, stdout, stderr io.Writer, getenv func(string) string) int {
+	cfg, err := config.Load(nil, getenv)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitError
+	}
+
+	fs := flag.NewFlagSet("web-ui similarity", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: web-ui similarity [flags] [challenge-id...]\n\n"+
+			"Scores every pair of solutions to the given challenges, or to all of them,\n"+
+			"and exits with status 1 when a pair is flagged.\n\n")
+		fs.PrintDefaults()
+	}
+	root := fs.String("root", cfg.RepoRoot, "repository root containing challenge-N directories")
+	threshold := fs.Float64("threshold", cfg.Similarity.Threshold, "similarity score from 0 to 1 at which pairs are flagged")
+	all := fs.Bool("all", false, "list every pair, not only flagged ones")
+	asJSON := fs.Bool("json", false, "print the reports as JSON")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitClean
+		}
+		return exitError
+	}
+	if *threshold < 0 || *threshold > 1 {
+		fmt.Fprintln(stderr, "threshold must be between 0 and 1")
+		return exitError
+	}
+	var ids []int
+	for _, arg := range fs.Args() {
+		id, err := strconv.Atoi(strings.TrimPrefix(arg, "challenge-"))
+		if err != nil || id < 1 {
+			fmt.Fprintf(stderr, "invalid challenge ID %q\n", arg)
+			return exitError
+		}
+		ids = append(ids, id)
+	}
+
+	// Only problems loading challenges are worth printing alongside the report
+	logger, err := logging.New(stderr, cfg.Logging.Format, "error")
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitError
+	}
+	slog.SetDefault(logger)
+
+	if *root == "" {
+		if *root, err = paths.DetectRoot(); err != nil {
+			fmt.Fprintf(stderr, "failed to locate repository root (set -root or WEBUI_REPO_ROOT): %v\n", err)
+			return exitError
+		}
+	}
+	resolver, err := paths.NewResolver(*root)
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to locate repository root: %v\n", err)
+		return exitError
+	}
+	challengeService := services.NewChallengeService(resolver)
+	if err := challengeService.LoadChallenges(); err != nil {
+		fmt.Fprintf(stderr, "failed to load challenges: %v\n", err)
+		return exitError
+	}
+	if len(ids) == 0 {
+		for id := range challengeService.GetChallenges() {
+			ids = append(ids, id)
+		}
+		sort.Ints(ids)
+	}
+
+	similarity := services.NewSimilarityService(resolver, challengeService, *threshold)
+	reports := []services.SimilarityReport{}
+	flagged := 0
+	for _, id := range ids {
+		report, err := similarity.Report(id, *threshold)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitError
+		}
+		flagged += report.Flagged
+		if !*all {
+			report.Pairs = report.FlaggedPairs()
+		}
+		reports = append(reports, report)
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(reports); err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitError
+		}
+	} else {
+		printSimilarity(stdout, reports)
+	}
+
+	if flagged > 0 {
+		return exitFlagged
+	}
+	return exitClean
+}
+
+// printSimilarity writes the reports of challenges with at least two
+// solutions as text
+func printSimilarity(w io.Writer, reports []services.SimilarityReport) {
+	for _, report := range reports {
+		if report.Solutions < 2 && len(report.Unparsed) == 0 {
+			continue
+		}
+		pairs := report.Solutions * (report.Solutions - 1) / 2
+		fmt.Fprintf(w, "challenge-%d: %d solutions, %d pairs, %d flagged at %.2f\n",
+			report.ChallengeID, report.Solutions, pairs, report.Flagged, report.Threshold)
+		for _, pair := range report.Pairs {
+			mark := " "
+			if pair.Flagged {
+				mark = "!"
+			}
+			fmt.Fprintf(w, "  %s %.3f  %s  %s  (%d shared)\n", mark, pair.Score, pair.Left, pair.Right, pair.Shared)
+		}
+		if len(report.Unparsed) > 0 {
+			fmt.Fprintf(w, "  not valid Go: %s\n", strings.Join(report.Unparsed, ", "))
+		}
+	}
+}
@@ -1,5 +1,62 @@
 // Common JavaScript utilities for Go Interview Practice web UI
 
+// Read a cookie value by name
+function getCookie(name) {
+    const prefix = name + '=';
+    for (const part of document.cookie.split(';')) {
+        const cookie = part.trim();
+        if (cookie.startsWith(prefix)) {
+            return decodeURIComponent(cookie.substring(prefix.length));
+        }
+    }
+    return '';
+}
+
+// Attach the CSRF token to every same-origin state-changing request
+(function() {
+    const originalFetch = window.fetch.bind(window);
+    const safeMethods = ['GET', 'HEAD', 'OPTIONS', 'TRACE'];
+
+    window.fetch = function(input, init = {}) {
+        const method = (init.method || (input instanceof Request ? input.method : 'GET')).toUpperCase();
+        const url = new URL(input instanceof Request ? input.url : input, window.location.href);
+
+        if (!safeMethods.includes(method) && url.origin === window.location.origin) {
+            const headers = new Headers(init.headers || (input instanceof Request ? input.headers : undefined));
+            headers.set('X-CSRF-Token', getCookie('csrf_token'));
+            init = Object.assign({}, init, { headers: headers, credentials: 'same-origin' });
+        }
+        return originalFetch(input, init);
+    };
+})();
+
+// Call a /api/v1 endpoint and return the decoded JSON body. A plain object
+// body is sent as JSON. Error envelopes ({error: {code, message, details}})
+// are thrown as Errors carrying the server's code, message and status.
+async function apiFetch(url, options = {}) {
+    const init = Object.assign({}, options);
+    init.headers = new Headers(options.headers || {});
+    init.headers.set('Accept', 'application/json');
+    if (init.body !== undefined && typeof init.body !== 'string') {
+        init.body = JSON.stringify(init.body);
+    }
+    if (init.body !== undefined) {
+        init.headers.set('Content-Type', 'application/json');
+    }
+
+    const response = await fetch(url, init);
+    const data = response.status === 204 ? null : await response.json().catch(() => null);
+    if (!response.ok) {
+        const apiError = data && data.error ? data.error : {};
+        const error = new Error(apiError.message || `Request failed with status ${response.status}`);
+        error.code = apiError.code || 'http_' + response.status;
+        error.details = apiError.details;
+        error.status = response.status;
+        throw error;
+    }
+    return data;
+}
+
 // Helper for formatting timestamps
 function formatDate(dateString) {
     const date = new Date(dateString);
@@ -462,14 +519,15 @@ function createEditor(elementId, code, isReadOnly = false) {
     return editor;
 }
 
-// Save editor content in localStorage to prevent loss on page refresh
-function saveEditorContent(key, content) {
-    localStorage.setItem(`editor_${key}`, content);
-}
-
-// Load editor content from localStorage if available
-function loadEditorContent(key) {
-    return localStorage.getItem(`editor_${key}`);
+// Save code as a server-side draft version of a challenge. Autosaves a few
+// minutes apart are folded into one version; keepalive lets the request
+// finish while the page unloads.
+function saveDraft(challengeId, code, options = {}) {
+    return apiFetch(`/api/v1/drafts/${challengeId}`, {
+        method: 'PUT',
+        keepalive: !!options.keepalive,
+        body: { code: code, label: options.label || undefined, autosave: !!options.autosave }
+    });
 }
 
 // Format the test output for display
@@ -553,21 +611,6 @@ document.addEventListener('DOMContentLoaded', function() {
             });
         }
     });
-    
-    // Handle username persistence
-    const usernameInput = document.getElementById('username');
-    if (usernameInput) {
-        // Load saved username
-        const savedUsername = localStorage.getItem('githubUsername');
-        if (savedUsername) {
-            usernameInput.value = savedUsername;
-        }
-        
-        // Save username when changed
-        usernameInput.addEventListener('change', function() {
-            localStorage.setItem('githubUsername', this.value);
-        });
-    }
 });
 
 // Initialize learning materials with highlighting
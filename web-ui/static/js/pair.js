@@ -0,0 +1,535 @@
+// Pair programming: keeps an Ace editor in sync with a room served at
+// /ws/pair/{room}.
+//
+// Edits travel as text operations, arrays in which a positive number retains
+// that many characters, a negative number deletes and a string inserts, with
+// lengths in UTF-16 code units like JavaScript strings. The server orders
+// every operation and transforms late ones; this client keeps at most one
+// operation in flight, buffers edits made meanwhile, and transforms incoming
+// operations against both so every participant ends up with the same text.
+
+const PairOT = (function() {
+    const isRetain = c => typeof c === 'number' && c > 0;
+    const isDelete = c => typeof c === 'number' && c < 0;
+    const isInsert = c => typeof c === 'string';
+
+    // Builder that merges adjacent components and keeps inserts before deletes
+    function builder() {
+        const ops = [];
+        return {
+            ops: ops,
+            retain(n) {
+                if (n <= 0) return this;
+                if (isRetain(ops[ops.length - 1])) ops[ops.length - 1] += n;
+                else ops.push(n);
+                return this;
+            },
+            insert(s) {
+                if (!s) return this;
+                const last = ops.length - 1;
+                if (isInsert(ops[last])) {
+                    ops[last] += s;
+                } else if (isDelete(ops[last])) {
+                    if (isInsert(ops[last - 1])) ops[last - 1] += s;
+                    else ops.splice(last, 0, s);
+                } else {
+                    ops.push(s);
+                }
+                return this;
+            },
+            delete(n) {
+                if (n <= 0) return this;
+                if (isDelete(ops[ops.length - 1])) ops[ops.length - 1] -= n;
+                else ops.push(-n);
+                return this;
+            }
+        };
+    }
+
+    function baseLength(op) {
+        return op.reduce((n, c) => n + (isInsert(c) ? 0 : Math.abs(c)), 0);
+    }
+
+    function apply(text, op) {
+        let result = '';
+        let pos = 0;
+        op.forEach(c => {
+            if (isRetain(c)) {
+                result += text.slice(pos, pos + c);
+                pos += c;
+            } else if (isInsert(c)) {
+                result += c;
+            } else {
+                pos -= c;
+            }
+        });
+        return result;
+    }
+
+    // Returns [a', b'] so that a then b' equals b then a'; a's inserts go
+    // first, matching the server, which always passes the client's operation
+    // as a
+    function transform(a, b) {
+        const aPrime = builder();
+        const bPrime = builder();
+        let i = 0, j = 0;
+        let ca = a[i++], cb = b[j++];
+        while (ca !== undefined || cb !== undefined) {
+            if (isInsert(ca)) {
+                aPrime.insert(ca);
+                bPrime.retain(ca.length);
+                ca = a[i++];
+                continue;
+            }
+            if (isInsert(cb)) {
+                aPrime.retain(cb.length);
+                bPrime.insert(cb);
+                cb = b[j++];
+                continue;
+            }
+            if (ca === undefined || cb === undefined) {
+                throw new Error('operations are for different documents');
+            }
+            const n = Math.min(Math.abs(ca), Math.abs(cb));
+            if (isRetain(ca) && isRetain(cb)) {
+                aPrime.retain(n);
+                bPrime.retain(n);
+            } else if (isDelete(ca) && isRetain(cb)) {
+                aPrime.delete(n);
+            } else if (isRetain(ca) && isDelete(cb)) {
+                bPrime.delete(n);
+            }
+            ca = shrink(ca, n) || a[i++];
+            cb = shrink(cb, n) || b[j++];
+        }
+        return [aPrime.ops, bPrime.ops];
+    }
+
+    // Returns one operation with the effect of a followed by b
+    function compose(a, b) {
+        const result = builder();
+        let i = 0, j = 0;
+        let ca = a[i++], cb = b[j++];
+        while (ca !== undefined || cb !== undefined) {
+            if (isDelete(ca)) {
+                result.delete(-ca);
+                ca = a[i++];
+                continue;
+            }
+            if (isInsert(cb)) {
+                result.insert(cb);
+                cb = b[j++];
+                continue;
+            }
+            if (ca === undefined || cb === undefined) {
+                throw new Error('operations do not follow each other');
+            }
+            const n = Math.min(isInsert(ca) ? ca.length : ca, Math.abs(cb));
+            if (isInsert(ca)) {
+                // An insert that b deletes again cancels out
+                if (isRetain(cb)) result.insert(ca.slice(0, n));
+                ca = ca.length > n ? ca.slice(n) : a[i++];
+            } else {
+                if (isRetain(cb)) result.retain(n);
+                else result.delete(n);
+                ca = shrink(ca, n) || a[i++];
+            }
+            cb = shrink(cb, n) || b[j++];
+        }
+        return result.ops;
+    }
+
+    // Consumes n units of a retain or delete, returning what is left or 0
+    function shrink(c, n) {
+        if (isRetain(c)) return c - n;
+        return c + n;
+    }
+
+    // Moves a position so it points at the same place after the operation
+    function transformIndex(index, op) {
+        let pos = 0, moved = index;
+        for (const c of op) {
+            if (pos > index) break;
+            if (isRetain(c)) {
+                pos += c;
+            } else if (isInsert(c)) {
+                moved += c.length;
+            } else {
+                moved -= Math.min(-c, index - pos);
+                pos -= c;
+            }
+        }
+        return moved;
+    }
+
+    return { builder, baseLength, apply, transform, compose, transformIndex, isRetain, isInsert };
+})();
+
+// Joins a pair room and syncs editor with it. Callbacks:
+//   onStatus(text, kind)      connection state for display
+//   onParticipants(list, me)  who is connected, with client IDs
+//   onRun(run)                a run result shared by another participant
+//   onClosed(message)         the room is gone and the session has stopped
+// Returns an object whose shareRun(result) relays a local run to the room.
+function startPairSession(options) {
+    const editor = options.editor;
+    const session = editor.session;
+    const doc = session.getDocument();
+    const Range = ace.require('ace/range').Range;
+    const onStatus = options.onStatus || function() {};
+    const onParticipants = options.onParticipants || function() {};
+    const onRun = options.onRun || function() {};
+    const onClosed = options.onClosed || function() {};
+
+    // Offsets must count a line break as one character on every platform
+    session.setNewLineMode('unix');
+
+    let socket = null;
+    let token = null;
+    let clientId = null;
+    let revision = 0;
+    let seq = 0;
+    let synced = false;
+    let stopped = false;
+    let retries = 0;
+    let applyingRemote = false;
+    let docLength = session.getValue().length;
+
+    // The operation awaiting the server's acknowledgement and the local edits
+    // made since, composed into one
+    let outstanding = null;
+    let outstandingSeq = 0;
+    let buffer = null;
+
+    const cursors = {};   // other participants' cursors by client ID
+    const markers = {};   // Ace marker IDs by client ID
+    let participants = [];
+    let cursorTimer = null;
+
+    function send(message) {
+        if (socket && socket.readyState === WebSocket.OPEN && synced) {
+            socket.send(JSON.stringify(message));
+        }
+    }
+
+    function sendOutstanding() {
+        send({ type: 'op', revision: revision, seq: outstandingSeq, op: outstanding });
+    }
+
+    // Local edits
+
+    function localOperation(op) {
+        if (outstanding === null) {
+            outstanding = op;
+            outstandingSeq = ++seq;
+            sendOutstanding();
+        } else if (buffer === null) {
+            buffer = op;
+        } else {
+            buffer = PairOT.compose(buffer, op);
+        }
+        Object.keys(cursors).forEach(id => {
+            cursors[id] = moveCursor(cursors[id], op);
+        });
+        renderCursors();
+    }
+
+    function deltaToOperation(delta) {
+        const start = doc.positionToIndex(delta.start);
+        const text = delta.lines.join('\n');
+        const before = delta.action === 'insert' ? docLength - text.length : docLength + text.length;
+        const op = PairOT.builder().retain(start);
+        if (delta.action === 'insert') {
+            op.insert(text).retain(before - start);
+        } else {
+            op.delete(text.length).retain(before - start - text.length);
+        }
+        docLength = delta.action === 'insert' ? before + text.length : before - text.length;
+        return op.ops;
+    }
+
+    session.on('change', function(delta) {
+        if (applyingRemote) {
+            return;
+        }
+        localOperation(deltaToOperation(delta));
+        scheduleCursor();
+    });
+
+    // Remote edits
+
+    function applyToEditor(op) {
+        applyingRemote = true;
+        try {
+            let index = 0;
+            op.forEach(c => {
+                if (PairOT.isRetain(c)) {
+                    index += c;
+                } else if (PairOT.isInsert(c)) {
+                    doc.insert(doc.indexToPosition(index, 0), c);
+                    index += c.length;
+                } else {
+                    const from = doc.indexToPosition(index, 0);
+                    const to = doc.indexToPosition(index - c, 0);
+                    doc.remove(new Range(from.row, from.column, to.row, to.column));
+                }
+            });
+        } finally {
+            applyingRemote = false;
+        }
+        docLength = session.getValue().length;
+    }
+
+    function serverOperation(change) {
+        revision++;
+        if (change.clientId === clientId && outstanding !== null && change.seq === outstandingSeq) {
+            // Our own operation coming back is the acknowledgement
+            outstanding = null;
+            if (buffer !== null) {
+                outstanding = buffer;
+                outstandingSeq = ++seq;
+                buffer = null;
+                sendOutstanding();
+            } else {
+                scheduleCursor();
+            }
+            return;
+        }
+        let op = change.op;
+        if (outstanding !== null) {
+            [outstanding, op] = PairOT.transform(outstanding, op);
+        }
+        if (buffer !== null) {
+            [buffer, op] = PairOT.transform(buffer, op);
+        }
+        applyToEditor(op);
+        Object.keys(cursors).forEach(id => {
+            cursors[id] = moveCursor(cursors[id], op);
+        });
+        renderCursors();
+    }
+
+    function reset(text) {
+        outstanding = null;
+        buffer = null;
+        applyingRemote = true;
+        try {
+            const position = editor.getCursorPosition();
+            session.setValue(text);
+            editor.moveCursorToPosition(position);
+            editor.clearSelection();
+        } finally {
+            applyingRemote = false;
+        }
+        docLength = text.length;
+    }
+
+    // Cursors
+
+    function moveCursor(cursor, op) {
+        return {
+            position: PairOT.transformIndex(cursor.position, op),
+            anchor: PairOT.transformIndex(cursor.anchor, op),
+            name: cursor.name
+        };
+    }
+
+    // Cursors are only sent while no edit is in flight, so they refer to a
+    // document the server knows
+    function scheduleCursor() {
+        clearTimeout(cursorTimer);
+        cursorTimer = setTimeout(function() {
+            if (outstanding !== null) {
+                return;
+            }
+            const selection = editor.getSelection();
+            send({
+                type: 'cursor',
+                revision: revision,
+                cursor: {
+                    position: doc.positionToIndex(selection.getCursor()),
+                    anchor: doc.positionToIndex(selection.getSelectionAnchor())
+                }
+            });
+        }, 100);
+    }
+
+    editor.getSelection().on('changeCursor', scheduleCursor);
+    editor.getSelection().on('changeSelection', scheduleCursor);
+
+    // A cursor from the server is at its current revision; local edits the
+    // server has not seen yet move it further
+    function remoteCursor(id, name, cursor) {
+        let local = { position: cursor.position, anchor: cursor.anchor, name: name };
+        if (outstanding !== null) local = moveCursor(local, outstanding);
+        if (buffer !== null) local = moveCursor(local, buffer);
+        cursors[id] = local;
+        renderCursors();
+    }
+
+    function colorIndex(id) {
+        const index = participants.findIndex(p => p.clientId === id);
+        return (index < 0 ? 0 : index) % 6;
+    }
+
+    function renderCursors() {
+        Object.keys(markers).forEach(id => {
+            markers[id].forEach(marker => session.removeMarker(marker));
+            delete markers[id];
+        });
+        Object.keys(cursors).forEach(id => {
+            const cursor = cursors[id];
+            const color = colorIndex(id);
+            const position = doc.indexToPosition(cursor.position, 0);
+            const anchor = doc.indexToPosition(cursor.anchor, 0);
+            markers[id] = [
+                session.addMarker(new Range(position.row, position.column, position.row, position.column + 1),
+                    `pair-caret pair-color-${color}`, 'text', true)
+            ];
+            if (cursor.position !== cursor.anchor) {
+                const range = cursor.position < cursor.anchor
+                    ? new Range(position.row, position.column, anchor.row, anchor.column)
+                    : new Range(anchor.row, anchor.column, position.row, position.column);
+                markers[id].push(session.addMarker(range, `pair-selection pair-color-${color}`, 'text', false));
+            }
+        });
+    }
+
+    function setParticipants(list) {
+        participants = list || [];
+        Object.keys(cursors).forEach(id => {
+            if (!participants.some(p => p.clientId === id)) delete cursors[id];
+        });
+        participants.forEach(p => {
+            if (p.clientId !== clientId && p.cursor && !cursors[p.clientId]) {
+                remoteCursor(p.clientId, p.name, p.cursor);
+            }
+        });
+        renderCursors();
+        onParticipants(participants.map(p => Object.assign({}, p, { color: colorIndex(p.clientId) })), clientId);
+    }
+
+    // Connection
+
+    function welcome(message) {
+        clientId = message.clientId;
+        token = message.token;
+        if (message.document !== undefined) {
+            if (outstanding !== null || buffer !== null) {
+                onStatus('Reconnected; edits made while offline could not be merged', 'warning');
+            }
+            reset(message.document);
+            revision = message.revision;
+            seq = message.seq || 0;
+        } else {
+            (message.ops || []).forEach(serverOperation);
+        }
+        synced = true;
+        editor.setReadOnly(false);
+        // Edits the server has not applied yet are sent again
+        if (outstanding !== null) {
+            sendOutstanding();
+        }
+        setParticipants(message.participants);
+        if (message.lastRun) {
+            onRun(message.lastRun);
+        }
+        scheduleCursor();
+    }
+
+    function handle(message) {
+        switch (message.type) {
+        case 'welcome':
+            welcome(message);
+            onStatus('Connected', 'success');
+            break;
+        case 'op':
+            serverOperation(message);
+            break;
+        case 'cursor':
+            remoteCursor(message.clientId, message.name, message.cursor);
+            break;
+        case 'presence':
+            setParticipants(message.participants);
+            break;
+        case 'run':
+            onRun(message.run);
+            break;
+        case 'error':
+            // Start over from the server's copy rather than repeat the problem
+            token = null;
+            onStatus('Out of sync: ' + message.message, 'warning');
+            break;
+        }
+    }
+
+    function connect() {
+        const protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:';
+        const params = new URLSearchParams();
+        if (token) {
+            params.set('token', token);
+            params.set('revision', revision);
+        }
+        socket = new WebSocket(`${protocol}//${window.location.host}/ws/pair/${encodeURIComponent(options.roomId)}?${params}`);
+        let opened = false;
+
+        socket.onopen = function() {
+            opened = true;
+            retries = 0;
+        };
+        socket.onmessage = function(event) {
+            try {
+                handle(JSON.parse(event.data));
+            } catch (error) {
+                console.error('pair session error:', error);
+                token = null;
+                socket.close();
+            }
+        };
+        socket.onclose = function() {
+            synced = false;
+            if (stopped) {
+                return;
+            }
+            if (!opened) {
+                // The upgrade was refused; find out whether the room is gone
+                apiFetch(`/api/v1/pair-rooms/${encodeURIComponent(options.roomId)}`)
+                    .then(() => reconnect())
+                    .catch(error => {
+                        if (error.status === 404) {
+                            stopped = true;
+                            onClosed('This pair room has expired.');
+                        } else {
+                            reconnect();
+                        }
+                    });
+                return;
+            }
+            reconnect();
+        };
+    }
+
+    function reconnect() {
+        const delay = Math.min(10000, 500 * Math.pow(2, retries++));
+        onStatus(`Disconnected, reconnecting in ${Math.round(delay / 1000)}s…`, 'warning');
+        setTimeout(connect, delay);
+    }
+
+    editor.setReadOnly(true);
+    onStatus('Connecting…', 'info');
+    connect();
+
+    return {
+        shareRun(result) {
+            send({
+                type: 'run',
+                run: {
+                    passed: result.passed,
+                    timedOut: result.timedOut,
+                    output: result.output,
+                    executionMs: result.executionMs
+                }
+            });
+        }
+    };
+}